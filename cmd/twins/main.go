@@ -11,56 +11,100 @@ import (
 	"log/slog"
 	"net/url"
 	"os"
+	"time"
 
 	chclient "github.com/absmach/callhome/pkg/client"
 	"github.com/absmach/supermq"
+	cassandraclient "github.com/absmach/supermq-contrib/pkg/clients/cassandra"
+	influxdbclient "github.com/absmach/supermq-contrib/pkg/clients/influxdb"
 	mongoclient "github.com/absmach/supermq-contrib/pkg/clients/mongo"
 	redisclient "github.com/absmach/supermq-contrib/pkg/clients/redis"
+	cassandrareader "github.com/absmach/supermq-contrib/readers/cassandra"
+	influxdbreader "github.com/absmach/supermq-contrib/readers/influxdb"
+	mongodbreader "github.com/absmach/supermq-contrib/readers/mongodb"
 	"github.com/absmach/supermq-contrib/twins"
 	"github.com/absmach/supermq-contrib/twins/api"
+	grpcapi "github.com/absmach/supermq-contrib/twins/api/grpc"
+	grpcTwinsV1 "github.com/absmach/supermq-contrib/twins/api/grpc/v1"
 	twapi "github.com/absmach/supermq-contrib/twins/api/http"
 	"github.com/absmach/supermq-contrib/twins/events"
 	twmongodb "github.com/absmach/supermq-contrib/twins/mongodb"
+	twpostgres "github.com/absmach/supermq-contrib/twins/postgres"
 	"github.com/absmach/supermq-contrib/twins/tracing"
 	smqlog "github.com/absmach/supermq/logger"
 	"github.com/absmach/supermq/pkg/authn"
 	authsvcAuthn "github.com/absmach/supermq/pkg/authn/authsvc"
+	"github.com/absmach/supermq/pkg/authz"
+	authsvcAuthz "github.com/absmach/supermq/pkg/authz/authsvc"
+	domainsAuthz "github.com/absmach/supermq/pkg/domains/grpcclient"
 	"github.com/absmach/supermq/pkg/grpcclient"
 	jaegerclient "github.com/absmach/supermq/pkg/jaeger"
 	"github.com/absmach/supermq/pkg/messaging"
 	"github.com/absmach/supermq/pkg/messaging/brokers"
 	brokerstracing "github.com/absmach/supermq/pkg/messaging/brokers/tracing"
+	"github.com/absmach/supermq/pkg/policies"
+	"github.com/absmach/supermq/pkg/policies/spicedb"
+	pgclient "github.com/absmach/supermq/pkg/postgres"
 	"github.com/absmach/supermq/pkg/prometheus"
 	"github.com/absmach/supermq/pkg/server"
+	grpcserver "github.com/absmach/supermq/pkg/server/grpc"
 	httpserver "github.com/absmach/supermq/pkg/server/http"
 	"github.com/absmach/supermq/pkg/uuid"
+	"github.com/absmach/supermq/readers"
+	"github.com/authzed/authzed-go/v1"
+	"github.com/authzed/grpcutil"
 	"github.com/caarlos0/env/v10"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
 	"github.com/go-redis/redis/v8"
-	"go.mongodb.org/mongo-driver/mongo"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 const (
-	svcName        = "twins"
-	envPrefixDB    = "SMQ_TWINS_DB_"
-	envPrefixHTTP  = "SMQ_TWINS_HTTP_"
-	envPrefixAuth  = "SMQ_AUTH_GRPC_"
-	defSvcHTTPPort = "9018"
+	svcName          = "twins"
+	envPrefixDB      = "SMQ_TWINS_DB_"
+	envPrefixHTTP    = "SMQ_TWINS_HTTP_"
+	envPrefixGRPC    = "SMQ_TWINS_GRPC_"
+	envPrefixAuth    = "SMQ_AUTH_GRPC_"
+	envPrefixDomains = "SMQ_DOMAINS_GRPC_"
+	envPrefixReplay  = "SMQ_TWINS_REPLAY_DB_"
+	defSvcHTTPPort   = "9018"
+	defSvcGRPCPort   = "7018"
+	defDB            = "twins"
+	dbTypeMongo      = "mongodb"
+	dbTypePostgres   = "postgres"
+	dbTypeInflux     = "influxdb"
+	dbTypeCassandra  = "cassandra"
 )
 
 type config struct {
-	LogLevel        string  `env:"SMQ_TWINS_LOG_LEVEL"          envDefault:"info"`
-	StandaloneID    string  `env:"SMQ_TWINS_STANDALONE_ID"      envDefault:""`
-	StandaloneToken string  `env:"SMQ_TWINS_STANDALONE_TOKEN"   envDefault:""`
-	ChannelID       string  `env:"SMQ_TWINS_CHANNEL_ID"         envDefault:""`
-	BrokerURL       string  `env:"SMQ_MESSAGE_BROKER_URL"       envDefault:"nats://localhost:4222"`
-	JaegerURL       url.URL `env:"SMQ_JAEGER_URL"               envDefault:"http://jaeger:14268/api/traces"`
-	SendTelemetry   bool    `env:"SMQ_SEND_TELEMETRY"           envDefault:"true"`
-	InstanceID      string  `env:"SMQ_TWINS_INSTANCE_ID"        envDefault:""`
-	ESURL           string  `env:"SMQ_ES_URL"                   envDefault:"nats://localhost:4222"`
-	CacheURL        string  `env:"SMQ_TWINS_CACHE_URL"          envDefault:"redis://localhost:6379/0"`
-	TraceRatio      float64 `env:"SMQ_JAEGER_TRACE_RATIO"       envDefault:"1.0"`
+	LogLevel          string        `env:"SMQ_TWINS_LOG_LEVEL"          envDefault:"info"`
+	StandaloneID      string        `env:"SMQ_TWINS_STANDALONE_ID"      envDefault:""`
+	StandaloneToken   string        `env:"SMQ_TWINS_STANDALONE_TOKEN"   envDefault:""`
+	ChannelID         string        `env:"SMQ_TWINS_CHANNEL_ID"         envDefault:""`
+	BrokerURL         string        `env:"SMQ_MESSAGE_BROKER_URL"       envDefault:"nats://localhost:4222"`
+	JaegerURL         url.URL       `env:"SMQ_JAEGER_URL"               envDefault:"http://jaeger:14268/api/traces"`
+	SendTelemetry     bool          `env:"SMQ_SEND_TELEMETRY"           envDefault:"true"`
+	InstanceID        string        `env:"SMQ_TWINS_INSTANCE_ID"        envDefault:""`
+	ESURL             string        `env:"SMQ_ES_URL"                   envDefault:"nats://localhost:4222"`
+	CacheURL          string        `env:"SMQ_TWINS_CACHE_URL"          envDefault:"redis://localhost:6379/0"`
+	DBType            string        `env:"SMQ_TWINS_DB_TYPE"            envDefault:"mongodb"`
+	TraceRatio        float64       `env:"SMQ_JAEGER_TRACE_RATIO"       envDefault:"1.0"`
+	IngestWorkers     int           `env:"SMQ_TWINS_INGEST_WORKERS"     envDefault:"8"`
+	IngestQueueSize   int           `env:"SMQ_TWINS_INGEST_QUEUE_SIZE"  envDefault:"1024"`
+	IngestBatchSize   int           `env:"SMQ_TWINS_INGEST_BATCH_SIZE"  envDefault:"100"`
+	IngestViewTTL     time.Duration `env:"SMQ_TWINS_INGEST_VIEW_TTL"    envDefault:"1m"`
+	IngestDrainTime   time.Duration `env:"SMQ_TWINS_INGEST_DRAIN_TIME"  envDefault:"30s"`
+	RetentionInterval time.Duration `env:"SMQ_TWINS_RETENTION_INTERVAL" envDefault:"1h"`
+	LivenessInterval  time.Duration `env:"SMQ_TWINS_LIVENESS_INTERVAL"  envDefault:"30s"`
+	ReplayDBType      string        `env:"SMQ_TWINS_REPLAY_DB_TYPE"     envDefault:""`
+	WSAllowedOrigins  []string      `env:"SMQ_TWINS_WS_ALLOWED_ORIGINS" envDefault:"" envSeparator:","`
+	SpicedbHost       string        `env:"SMQ_SPICEDB_HOST"             envDefault:"localhost"`
+	SpicedbPort       string        `env:"SMQ_SPICEDB_PORT"             envDefault:"50051"`
+	SpicedbSecret     string        `env:"SMQ_SPICEDB_PRE_SHARED_KEY"   envDefault:"12345678"`
 }
 
 func main() {
@@ -95,20 +139,20 @@ func main() {
 		return
 	}
 
-	cacheClient, err := redisclient.Connect(cfg.CacheURL)
-	if err != nil {
-		logger.Error(err.Error())
+	grpcServerConfig := server.Config{Port: defSvcGRPCPort}
+	if err := env.ParseWithOptions(&grpcServerConfig, env.Options{Prefix: envPrefixGRPC}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load %s gRPC server configuration : %s", svcName, err))
 		exitCode = 1
 		return
 	}
-	defer cacheClient.Close()
 
-	db, err := mongoclient.Setup(envPrefixDB)
+	cacheClient, err := redisclient.Connect(cfg.CacheURL)
 	if err != nil {
-		logger.Error(fmt.Sprintf("failed to setup postgres database : %s", err))
+		logger.Error(err.Error())
 		exitCode = 1
 		return
 	}
+	defer cacheClient.Close()
 
 	tp, err := jaegerclient.NewProvider(ctx, svcName, cfg.JaegerURL, cfg.InstanceID, cfg.TraceRatio)
 	if err != nil {
@@ -123,6 +167,91 @@ func main() {
 	}()
 	tracer := tp.Tracer(svcName)
 
+	var (
+		twinRepo     twins.TwinRepository
+		stateRepo    twins.StateRepository
+		templateRepo twins.TemplateRepository
+		relationRepo twins.RelationRepository
+	)
+	switch cfg.DBType {
+	case dbTypeMongo:
+		db, err := mongoclient.Setup(envPrefixDB)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to setup mongodb database : %s", err))
+			exitCode = 1
+			return
+		}
+		twinRepo = twmongodb.NewTwinRepository(db)
+		stateRepo = twmongodb.NewStateRepository(db)
+		templateRepo = twmongodb.NewTemplateRepository(db)
+		relationRepo = twmongodb.NewRelationRepository(db)
+	case dbTypePostgres:
+		dbConfig := pgclient.Config{Name: defDB}
+		if err := env.ParseWithOptions(&dbConfig, env.Options{Prefix: envPrefixDB}); err != nil {
+			logger.Error(fmt.Sprintf("failed to load %s database configuration : %s", svcName, err))
+			exitCode = 1
+			return
+		}
+		db, err := pgclient.Setup(dbConfig, *twpostgres.Migration())
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to setup postgres database : %s", err))
+			exitCode = 1
+			return
+		}
+		defer db.Close()
+		database := pgclient.NewDatabase(db, dbConfig, tracer)
+		twinRepo = twpostgres.NewTwinRepository(database)
+		stateRepo = twpostgres.NewStateRepository(database)
+		templateRepo = twpostgres.NewTemplateRepository(database)
+		relationRepo = twpostgres.NewRelationRepository(database)
+	default:
+		logger.Error(fmt.Sprintf("unsupported database type %q, expected %q or %q", cfg.DBType, dbTypeMongo, dbTypePostgres))
+		exitCode = 1
+		return
+	}
+
+	var msgRepo readers.MessageRepository
+	switch cfg.ReplayDBType {
+	case "":
+	case dbTypeMongo:
+		db, err := mongoclient.Setup(envPrefixReplay)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to setup replay mongodb database : %s", err))
+			exitCode = 1
+			return
+		}
+		msgRepo = mongodbreader.New(db)
+	case dbTypeCassandra:
+		csdSession, err := cassandraclient.Setup(envPrefixReplay)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to setup replay cassandra database : %s", err))
+			exitCode = 1
+			return
+		}
+		defer csdSession.Close()
+		msgRepo = cassandrareader.New(csdSession)
+	case dbTypeInflux:
+		influxDBConfig := influxdbclient.Config{}
+		if err := env.ParseWithOptions(&influxDBConfig, env.Options{Prefix: envPrefixReplay}); err != nil {
+			logger.Error(fmt.Sprintf("failed to load replay InfluxDB client configuration : %s", err))
+			exitCode = 1
+			return
+		}
+		influxDBConfig.DBUrl = fmt.Sprintf("%s://%s:%s", influxDBConfig.Protocol, influxDBConfig.Host, influxDBConfig.Port)
+		client, err := influxdbclient.Connect(ctx, influxDBConfig)
+		if err != nil {
+			logger.Error(fmt.Sprintf("failed to connect to replay InfluxDB : %s", err))
+			exitCode = 1
+			return
+		}
+		defer client.Close()
+		msgRepo = influxdbreader.New(client, influxdbreader.RepoConfig{Bucket: influxDBConfig.Bucket, Org: influxDBConfig.Org})
+	default:
+		logger.Error(fmt.Sprintf("unsupported replay database type %q, expected %q, %q or %q", cfg.ReplayDBType, dbTypeMongo, dbTypeCassandra, dbTypeInflux))
+		exitCode = 1
+		return
+	}
+
 	grpcCfg := grpcclient.Config{}
 	if err := env.ParseWithOptions(&grpcCfg, env.Options{Prefix: envPrefixAuth}); err != nil {
 		logger.Error(fmt.Sprintf("failed to load auth gRPC client configuration : %s", err))
@@ -138,6 +267,37 @@ func main() {
 	defer authnClient.Close()
 	logger.Info("AuthN  successfully connected to auth gRPC server " + authnClient.Secure())
 
+	domsGrpcCfg := grpcclient.Config{}
+	if err := env.ParseWithOptions(&domsGrpcCfg, env.Options{Prefix: envPrefixDomains}); err != nil {
+		logger.Error(fmt.Sprintf("failed to load domains gRPC client configuration : %s", err))
+		exitCode = 1
+		return
+	}
+	domAuthz, _, domainsHandler, err := domainsAuthz.NewAuthorization(ctx, domsGrpcCfg)
+	if err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	defer domainsHandler.Close()
+
+	authz, authzClient, err := authsvcAuthz.NewAuthorization(ctx, grpcCfg, domAuthz)
+	if err != nil {
+		logger.Error(err.Error())
+		exitCode = 1
+		return
+	}
+	defer authzClient.Close()
+	logger.Info("AuthZ  successfully connected to auth gRPC server " + authzClient.Secure())
+
+	policySvc, err := newPolicyService(cfg, logger)
+	if err != nil {
+		logger.Error(fmt.Sprintf("failed to connect to SpiceDB: %s", err))
+		exitCode = 1
+		return
+	}
+	logger.Info("Policy service successfully connected to SpiceDB gRPC server")
+
 	pubSub, err := brokers.NewPubSub(ctx, cfg.BrokerURL, logger)
 	if err != nil {
 		logger.Error(fmt.Sprintf("failed to connect to message broker: %s", err))
@@ -147,45 +307,82 @@ func main() {
 	defer pubSub.Close()
 	pubSub = brokerstracing.NewPubSub(httpServerConfig, tracer, pubSub)
 
-	svc, err := newService(ctx, svcName, pubSub, cfg, authn, tracer, db, cacheClient, logger)
+	svc, err := newService(ctx, svcName, pubSub, cfg, authn, authz, policySvc, tracer, twinRepo, stateRepo, templateRepo, relationRepo, msgRepo, cacheClient, logger)
 	if err != nil {
 		logger.Error(fmt.Sprintf("failed to create %s service: %s", svcName, err))
 		exitCode = 1
 		return
 	}
 
-	hs := httpserver.NewServer(ctx, cancel, svcName, httpServerConfig, twapi.MakeHandler(svc, logger, cfg.InstanceID), logger)
+	hs := httpserver.NewServer(ctx, cancel, svcName, httpServerConfig, twapi.MakeHandler(svc, logger, cfg.InstanceID, cfg.WSAllowedOrigins), logger)
+
+	registerTwinsServer := func(srv *grpc.Server) {
+		grpcTwinsV1.RegisterTwinsServiceServer(srv, grpcapi.NewServer(svc))
+	}
+	gs := grpcserver.NewServer(ctx, cancel, svcName, grpcServerConfig, registerTwinsServer, logger)
 
 	if cfg.SendTelemetry {
 		chc := chclient.New(svcName, supermq.Version, logger, cancel)
 		go chc.CallHome(ctx)
 	}
 
+	if cfg.RetentionInterval > 0 {
+		go applyRetention(ctx, cfg.RetentionInterval, svc)
+	}
+
+	if cfg.LivenessInterval > 0 {
+		go checkLiveness(ctx, cfg.LivenessInterval, svc)
+	}
+
 	g.Go(func() error {
 		return hs.Start()
 	})
 
 	g.Go(func() error {
-		return server.StopSignalHandler(ctx, cancel, logger, svcName, hs)
+		return gs.Start()
+	})
+
+	g.Go(func() error {
+		return server.StopSignalHandler(ctx, cancel, logger, svcName, hs, gs)
 	})
 
 	if err := g.Wait(); err != nil {
 		logger.Error(fmt.Sprintf("Twins service terminated: %s", err))
 	}
+
+	// Queued messages are acknowledged, so their states are saved before
+	// the repositories are closed.
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), cfg.IngestDrainTime)
+	defer drainCancel()
+	if err := svc.DrainStates(drainCtx); err != nil {
+		logger.Error(fmt.Sprintf("Failed to drain twin states: %s", err))
+	}
 }
 
-func newService(ctx context.Context, id string, ps messaging.PubSub, cfg config, authn authn.Authentication, tracer trace.Tracer, db *mongo.Database, cacheclient *redis.Client, logger *slog.Logger) (twins.Service, error) {
-	twinRepo := twmongodb.NewTwinRepository(db)
+func newService(ctx context.Context, id string, ps messaging.PubSub, cfg config, authn authn.Authentication, authz authz.Authorization, policySvc policies.Service, tracer trace.Tracer, twinRepo twins.TwinRepository, stateRepo twins.StateRepository, templateRepo twins.TemplateRepository, relationRepo twins.RelationRepository, msgRepo readers.MessageRepository, cacheclient *redis.Client, logger *slog.Logger) (twins.Service, error) {
 	twinRepo = tracing.TwinRepositoryMiddleware(tracer, twinRepo)
-
-	stateRepo := twmongodb.NewStateRepository(db)
 	stateRepo = tracing.StateRepositoryMiddleware(tracer, stateRepo)
+	templateRepo = tracing.TemplateRepositoryMiddleware(tracer, templateRepo)
+	relationRepo = tracing.RelationRepositoryMiddleware(tracer, relationRepo)
 
 	idProvider := uuid.New()
 	twinCache := events.NewTwinCache(cacheclient)
 	twinCache = tracing.TwinCacheMiddleware(tracer, twinCache)
 
-	svc := twins.New(ps, authn, twinRepo, twinCache, stateRepo, idProvider, cfg.ChannelID, logger)
+	failed := kitprometheus.NewCounterFrom(stdprometheus.CounterOpts{
+		Namespace: svcName,
+		Subsystem: "ingest",
+		Name:      "failed_messages",
+		Help:      "Number of received messages whose states are not saved.",
+	}, []string{})
+	ingest := twins.IngestConfig{
+		Workers:   cfg.IngestWorkers,
+		QueueSize: cfg.IngestQueueSize,
+		BatchSize: cfg.IngestBatchSize,
+		ViewTTL:   cfg.IngestViewTTL,
+		Failed:    failed,
+	}
+	svc := twins.New(ps, authn, authz, policySvc, twinRepo, twinCache, stateRepo, templateRepo, relationRepo, msgRepo, idProvider, cfg.ChannelID, ingest, logger)
 
 	var err error
 	svc, err = events.NewEventStoreMiddleware(ctx, svc, cfg.ESURL)
@@ -209,6 +406,19 @@ func newService(ctx context.Context, id string, ps messaging.PubSub, cfg config,
 	return svc, nil
 }
 
+func newPolicyService(cfg config, logger *slog.Logger) (policies.Service, error) {
+	client, err := authzed.NewClientWithExperimentalAPIs(
+		fmt.Sprintf("%s:%s", cfg.SpicedbHost, cfg.SpicedbPort),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpcutil.WithInsecureBearerToken(cfg.SpicedbSecret),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return spicedb.NewPolicyService(client, logger), nil
+}
+
 func handle(ctx context.Context, logger *slog.Logger, chanID string, svc twins.Service) handlerFunc {
 	return func(msg *messaging.Message) error {
 		if msg.GetChannel() == chanID {
@@ -224,6 +434,39 @@ func handle(ctx context.Context, logger *slog.Logger, chanID string, svc twins.S
 	}
 }
 
+// applyRetention periodically removes and compacts the twin states until the
+// context is done. Failures are logged by the logging middleware.
+func applyRetention(ctx context.Context, interval time.Duration, svc twins.Service) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = svc.ApplyRetention(ctx)
+		}
+	}
+}
+
+// checkLiveness periodically marks the twin attributes which were not
+// received in time as stale until the context is done. Failures are logged
+// by the logging middleware.
+func checkLiveness(ctx context.Context, interval time.Duration, svc twins.Service) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = svc.CheckLiveness(ctx)
+		}
+	}
+}
+
 type handlerFunc func(msg *messaging.Message) error
 
 func (h handlerFunc) Handle(msg *messaging.Message) error {
@@ -156,6 +156,12 @@ func EncodeError(_ context.Context, err error, w http.ResponseWriter) {
 		err = unwrap(err)
 		w.WriteHeader(http.StatusBadRequest)
 
+	// Conflicts are checked first, since failed writes wrap them.
+	case errors.Contains(err, errors.ErrStatusAlreadyAssigned),
+		errors.Contains(err, svcerr.ErrConflict):
+		err = unwrap(err)
+		w.WriteHeader(http.StatusConflict)
+
 	case errors.Contains(err, svcerr.ErrCreateEntity),
 		errors.Contains(err, svcerr.ErrUpdateEntity),
 		errors.Contains(err, svcerr.ErrRemoveEntity),
@@ -167,11 +173,6 @@ func EncodeError(_ context.Context, err error, w http.ResponseWriter) {
 		err = unwrap(err)
 		w.WriteHeader(http.StatusNotFound)
 
-	case errors.Contains(err, errors.ErrStatusAlreadyAssigned),
-		errors.Contains(err, svcerr.ErrConflict):
-		err = unwrap(err)
-		w.WriteHeader(http.StatusConflict)
-
 	case errors.Contains(err, apiutil.ErrUnsupportedContentType):
 		err = unwrap(err)
 		w.WriteHeader(http.StatusUnsupportedMediaType)
@@ -6,6 +6,7 @@ package api_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"testing"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/absmach/supermq-contrib/pkg/testsutil"
 	apiutil "github.com/absmach/supermq/api/http/util"
 	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
 	svcerr "github.com/absmach/supermq/pkg/errors/service"
 	"github.com/stretchr/testify/assert"
 )
@@ -336,3 +338,16 @@ func TestEncodeError(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeWrappedConflict(t *testing.T) {
+	errs := []error{
+		errors.Wrap(svcerr.ErrCreateEntity, repoerr.ErrConflict),
+		errors.Wrap(svcerr.ErrUpdateEntity, repoerr.ErrConflict),
+	}
+
+	for _, err := range errs {
+		responseWriter := newResponseWriter()
+		api.EncodeError(context.Background(), err, responseWriter)
+		assert.Equal(t, http.StatusConflict, responseWriter.StatusCode(), fmt.Sprintf("%s: expected status code %d got %d", err, http.StatusConflict, responseWriter.StatusCode()))
+	}
+}
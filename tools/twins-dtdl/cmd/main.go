@@ -0,0 +1,49 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+// Package main contains entry point for twins DTDL import tool.
+package main
+
+import (
+	"log"
+
+	dtdl "github.com/absmach/supermq-contrib/tools/twins-dtdl"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	dconf := dtdl.Config{}
+
+	rootCmd := &cobra.Command{
+		Use:   "twins-dtdl",
+		Short: "twins-dtdl is twins DTDL import tool for SupeMQ",
+		Long: `Tool for importing the interfaces of DTDL models as SupeMQ twins, one twin per interface,
+reporting the DTDL constructs which cannot be converted.`,
+	}
+
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import DTDL model",
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := dtdl.Import(cmd.Context(), dconf); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	importCmd.Flags().StringVarP(&dconf.Channel, "channel", "c", "", "channel the attributes of the twins are bound to")
+	importCmd.Flags().StringVarP(&dconf.Interface, "interface", "i", "", "identifier of the single interface to import")
+	importCmd.Flags().BoolVarP(&dconf.DryRun, "dry-run", "n", false, "convert the model without importing the twins")
+
+	// Root Flags
+	rootCmd.PersistentFlags().StringVarP(&dconf.Host, "host", "", "http://localhost:9018", "address of the twins service")
+	rootCmd.PersistentFlags().StringVarP(&dconf.Token, "token", "t", "", "user access token")
+	rootCmd.PersistentFlags().StringVarP(&dconf.Domain, "domain", "d", "", "domain of the twins")
+	rootCmd.PersistentFlags().StringVarP(&dconf.File, "file", "f", "", "file of the DTDL model, defaults to standard input")
+	rootCmd.PersistentFlags().BoolVarP(&dconf.Insecure, "insecure", "k", false, "skip TLS certificate verification")
+
+	rootCmd.AddCommand(importCmd)
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,6 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+// Package dtdl is a simple utility to import the interfaces of DTDL models
+// as twins, reporting the DTDL constructs which cannot be converted.
+package dtdl
@@ -0,0 +1,135 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package dtdl
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const jsonLDContentType = "application/ld+json"
+
+var errMissingChannel = errors.New("channel of the twin attributes is required")
+
+// Config - twins DTDL import configuration.
+type Config struct {
+	Host      string
+	Token     string
+	Domain    string
+	File      string
+	Channel   string
+	Interface string
+	DryRun    bool
+	Insecure  bool
+}
+
+type issue struct {
+	Interface string `json:"interface"`
+	Element   string `json:"element"`
+	Reason    string `json:"reason"`
+}
+
+type result struct {
+	DryRun bool `json:"dry_run"`
+	Twins  []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"twins"`
+	Issues []issue `json:"issues"`
+}
+
+// Import - function that imports the DTDL model from the file, or from the
+// standard input if the file is not set, and prints the imported twins
+// followed by the skipped DTDL constructs.
+func Import(ctx context.Context, conf Config) error {
+	if conf.Channel == "" {
+		return errMissingChannel
+	}
+
+	in := os.Stdin
+	if conf.File != "" && conf.File != "-" {
+		f, err := os.Open(conf.File)
+		if err != nil {
+			return fmt.Errorf("unable to open model file: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	query := url.Values{}
+	query.Set("channel", conf.Channel)
+	query.Set("dry_run", strconv.FormatBool(conf.DryRun))
+	if conf.Interface != "" {
+		query.Set("interface", conf.Interface)
+	}
+	u := fmt.Sprintf("%s/%s/twins/import/dtdl?%s", strings.TrimSuffix(conf.Host, "/"), conf.Domain, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, in)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", jsonLDContentType)
+	res, err := do(conf, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var r result
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return fmt.Errorf("unable to read import result: %w", err)
+	}
+	report(os.Stdout, r)
+
+	return nil
+}
+
+// report prints the twins, whose IDs are empty on a dry run, and the issues
+// one per line.
+func report(w io.Writer, r result) {
+	for _, tw := range r.Twins {
+		if r.DryRun {
+			fmt.Fprintf(w, "twin %q (dry run)\n", tw.Name)
+			continue
+		}
+		fmt.Fprintf(w, "twin %q %s\n", tw.Name, tw.ID)
+	}
+	for _, is := range r.Issues {
+		element := is.Interface
+		if is.Element != "" {
+			element += " " + is.Element
+		}
+		fmt.Fprintf(w, "skipped %s: %s\n", element, is.Reason)
+	}
+}
+
+func do(conf Config, req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+conf.Token)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: conf.Insecure},
+		},
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to reach twins service: %w", err)
+	}
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("twins service responded with status %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return res, nil
+}
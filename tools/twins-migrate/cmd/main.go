@@ -0,0 +1,60 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+// Package main contains entry point for twins migration tool.
+package main
+
+import (
+	"log"
+
+	migrate "github.com/absmach/supermq-contrib/tools/twins-migrate"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	mconf := migrate.Config{}
+
+	rootCmd := &cobra.Command{
+		Use:   "twins-migrate",
+		Short: "twins-migrate is twins migration tool for SupeMQ",
+		Long: `Tool for exporting twins of the owner, with their definitions history and metadata,
+and importing them into the same or another SupeMQ instance.`,
+	}
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export twins of the owner",
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := migrate.Export(cmd.Context(), mconf); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	exportCmd.Flags().StringVarP(&mconf.Owner, "owner", "o", "", "owner of the exported twins, defaults to the token user")
+
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import exported twins",
+		Run: func(cmd *cobra.Command, _ []string) {
+			if err := migrate.Import(cmd.Context(), mconf); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	importCmd.Flags().BoolVarP(&mconf.RemapIDs, "remap", "r", false, "assign new IDs to the imported twins")
+	importCmd.Flags().BoolVarP(&mconf.DryRun, "dry-run", "n", false, "validate the twins without importing them")
+
+	// Root Flags
+	rootCmd.PersistentFlags().StringVarP(&mconf.Host, "host", "", "http://localhost:9018", "address of the twins service")
+	rootCmd.PersistentFlags().StringVarP(&mconf.Token, "token", "t", "", "user access token")
+	rootCmd.PersistentFlags().StringVarP(&mconf.Domain, "domain", "d", "", "domain of the twins")
+	rootCmd.PersistentFlags().StringVarP(&mconf.File, "file", "f", "", "file of the exported twins, defaults to standard input or output")
+	rootCmd.PersistentFlags().StringVarP(&mconf.Format, "format", "", "", "format of the exported twins, json or ndjson, defaults to the file extension")
+	rootCmd.PersistentFlags().BoolVarP(&mconf.Insecure, "insecure", "k", false, "skip TLS certificate verification")
+
+	rootCmd.AddCommand(exportCmd, importCmd)
+
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatal(err)
+	}
+}
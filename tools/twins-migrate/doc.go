@@ -0,0 +1,7 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+// Package migrate is a simple utility to export the twins of the owner,
+// with their definitions history and metadata, and to import them into
+// the same or another SuperMQ instance.
+package migrate
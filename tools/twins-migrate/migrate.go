@@ -0,0 +1,148 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package migrate
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Formats of the exported twins.
+const (
+	JSONFormat   = "json"
+	NDJSONFormat = "ndjson"
+)
+
+const (
+	jsonContentType   = "application/json"
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// Config - twins migration configuration.
+type Config struct {
+	Host     string
+	Token    string
+	Domain   string
+	Owner    string
+	File     string
+	Format   string
+	RemapIDs bool
+	DryRun   bool
+	Insecure bool
+}
+
+// Export - function that exports the twins of the owner to the file, or to
+// the standard output if the file is not set.
+func Export(ctx context.Context, conf Config) error {
+	query := url.Values{}
+	query.Set("format", format(conf))
+	if conf.Owner != "" {
+		query.Set("owner", conf.Owner)
+	}
+	u := fmt.Sprintf("%s/%s/twins/export?%s", strings.TrimSuffix(conf.Host, "/"), conf.Domain, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	res, err := do(conf, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	out := os.Stdout
+	if conf.File != "" && conf.File != "-" {
+		f, err := os.Create(conf.File)
+		if err != nil {
+			return fmt.Errorf("unable to create export file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if _, err := io.Copy(out, res.Body); err != nil {
+		return fmt.Errorf("unable to write exported twins: %w", err)
+	}
+
+	return nil
+}
+
+// Import - function that imports the twins from the file, or from the
+// standard input if the file is not set, and prints the imported IDs.
+func Import(ctx context.Context, conf Config) error {
+	in := os.Stdin
+	if conf.File != "" && conf.File != "-" {
+		f, err := os.Open(conf.File)
+		if err != nil {
+			return fmt.Errorf("unable to open import file: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	query := url.Values{}
+	query.Set("remap", strconv.FormatBool(conf.RemapIDs))
+	query.Set("dry_run", strconv.FormatBool(conf.DryRun))
+	u := fmt.Sprintf("%s/%s/twins/import?%s", strings.TrimSuffix(conf.Host, "/"), conf.Domain, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, in)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", jsonContentType)
+	if format(conf) == NDJSONFormat {
+		req.Header.Set("Content-Type", ndjsonContentType)
+	}
+	res, err := do(conf, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if _, err := io.Copy(os.Stdout, res.Body); err != nil {
+		return fmt.Errorf("unable to read import result: %w", err)
+	}
+
+	return nil
+}
+
+// format returns the configured format, falling back to the format matching
+// the file extension.
+func format(conf Config) string {
+	if conf.Format != "" {
+		return conf.Format
+	}
+	if strings.HasSuffix(conf.File, "."+NDJSONFormat) {
+		return NDJSONFormat
+	}
+	return JSONFormat
+}
+
+func do(conf Config, req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+conf.Token)
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: conf.Insecure},
+		},
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to reach twins service: %w", err)
+	}
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("twins service responded with status %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return res, nil
+}
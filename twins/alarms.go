@@ -0,0 +1,373 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/absmach/supermq/pkg/errors"
+)
+
+// Conditions of the alarm rules.
+const (
+	// GreaterCondition raises the alarm while the value is greater than the
+	// threshold.
+	GreaterCondition = "gt"
+
+	// LessCondition raises the alarm while the value is less than the
+	// threshold.
+	LessCondition = "lt"
+
+	// RangeCondition raises the alarm while the value is out of the range
+	// bounded by the minimum and the maximum.
+	RangeCondition = "range"
+
+	// RateCondition raises the alarm while the absolute change of the value
+	// per second is greater than the threshold.
+	RateCondition = "rate"
+
+	// StuckCondition raises the alarm when the value has not changed by more
+	// than the hysteresis for the duration.
+	StuckCondition = "stuck"
+)
+
+// Severities of the alarms.
+const (
+	InfoSeverity     = "info"
+	WarningSeverity  = "warning"
+	CriticalSeverity = "critical"
+)
+
+// Statuses of the alarms.
+const (
+	RaisedStatus  = "raised"
+	ClearedStatus = "cleared"
+)
+
+var errInvalidAlarm = errors.New("invalid attribute alarm")
+
+// AlarmRule specifies the condition the numeric attribute values are
+// checked against when the states are saved. The hysteresis is the margin
+// the value has to return by past the threshold for the raised alarm to be
+// cleared, so that values oscillating around the threshold do not raise
+// the alarm repeatedly. The duration of the stuck condition is expressed in
+// nanoseconds, like the definition delta.
+type AlarmRule struct {
+	Name       string   `json:"name"`
+	Condition  string   `json:"condition"`
+	Severity   string   `json:"severity"`
+	Threshold  float64  `json:"threshold,omitempty"`
+	Min        *float64 `json:"min,omitempty"`
+	Max        *float64 `json:"max,omitempty"`
+	Duration   int64    `json:"duration,omitempty"`
+	Hysteresis float64  `json:"hysteresis,omitempty"`
+}
+
+// Alarm is the occurrence of the alarm rule of the twin attribute. The alarm
+// is active while its status is raised.
+type Alarm struct {
+	ID        string     `json:"id"`
+	TwinID    string     `json:"twin_id"`
+	Attribute string     `json:"attribute"`
+	Rule      string     `json:"rule"`
+	Condition string     `json:"condition"`
+	Severity  string     `json:"severity"`
+	Status    string     `json:"status"`
+	Value     float64    `json:"value"`
+	Raised    time.Time  `json:"raised"`
+	Cleared   *time.Time `json:"cleared,omitempty"`
+}
+
+// AlarmFilter narrows down the subset of alarms retrieved from the
+// repository. Zero values of the fields are ignored.
+type AlarmFilter struct {
+	Attribute string
+	Severity  string
+	Status    string
+}
+
+// AlarmsPage contains page related metadata as well as a list of alarms
+// that belong to this page.
+type AlarmsPage struct {
+	PageMetadata
+	Alarms []Alarm
+}
+
+// validateAlarms verifies the alarm rules of the attribute.
+func validateAlarms(attr Attribute) error {
+	if len(attr.Alarms) > 0 && attr.Type != "" && attr.Type != NumberType {
+		return errors.Wrap(errInvalidAlarm, fmt.Errorf("alarms of attribute %s require number type", attr.Name))
+	}
+
+	names := make(map[string]bool, len(attr.Alarms))
+	for _, rule := range attr.Alarms {
+		if rule.Name == "" || names[rule.Name] {
+			return errors.Wrap(errInvalidAlarm, fmt.Errorf("alarm names of attribute %s are missing or not unique", attr.Name))
+		}
+		names[rule.Name] = true
+
+		switch rule.Severity {
+		case InfoSeverity, WarningSeverity, CriticalSeverity:
+		default:
+			return errors.Wrap(errInvalidAlarm, fmt.Errorf("unknown severity %q of alarm %s", rule.Severity, rule.Name))
+		}
+		if rule.Hysteresis < 0 {
+			return errors.Wrap(errInvalidAlarm, fmt.Errorf("negative hysteresis of alarm %s", rule.Name))
+		}
+
+		switch rule.Condition {
+		case GreaterCondition, LessCondition:
+		case RangeCondition:
+			if rule.Min == nil && rule.Max == nil {
+				return errors.Wrap(errInvalidAlarm, fmt.Errorf("range of alarm %s has no bounds", rule.Name))
+			}
+			if rule.Min != nil && rule.Max != nil && *rule.Max-*rule.Min < 2*rule.Hysteresis {
+				return errors.Wrap(errInvalidAlarm, fmt.Errorf("range of alarm %s is narrower than the hysteresis", rule.Name))
+			}
+		case RateCondition:
+			if rule.Threshold <= 0 {
+				return errors.Wrap(errInvalidAlarm, fmt.Errorf("rate threshold of alarm %s is not positive", rule.Name))
+			}
+		case StuckCondition:
+			if rule.Duration <= 0 {
+				return errors.Wrap(errInvalidAlarm, fmt.Errorf("duration of alarm %s is not positive", rule.Name))
+			}
+		default:
+			return errors.Wrap(errInvalidAlarm, fmt.Errorf("unknown condition %q of alarm %s", rule.Condition, rule.Name))
+		}
+	}
+
+	return nil
+}
+
+// sample is the attribute value received at the given time. Samples keyed
+// by the attribute name hold the last value, used for the change rate,
+// while samples keyed by the alarm hold the value the stuck value is
+// compared to.
+type sample struct {
+	value float64
+	at    time.Time
+}
+
+// alarmState holds the active alarms of the twin, keyed by the attribute
+// and the rule name, and the samples the alarm rules are evaluated with.
+type alarmState struct {
+	active  map[string]Alarm
+	samples map[string]sample
+}
+
+func alarmKey(attribute, rule string) string {
+	return attribute + "/" + rule
+}
+
+// hasAlarms reports whether any definition of the twin has alarm rules.
+func hasAlarms(tw Twin) bool {
+	for _, def := range tw.Definitions {
+		for _, attr := range def.Attributes {
+			if len(attr.Alarms) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// loadAlarms retrieves the active alarms of the twin and initializes the
+// samples from the last state. Twins which never had alarm rules are
+// skipped. Otherwise, the alarms raised by the rules removed from the
+// latest definition are retrieved as well, so that they can be cleared.
+func (ts *twinservice) loadAlarms(ctx context.Context, tw Twin, last State) (alarmState, error) {
+	as := alarmState{
+		active:  make(map[string]Alarm),
+		samples: make(map[string]sample),
+	}
+	if !hasAlarms(tw) {
+		return as, nil
+	}
+
+	alarms, err := ts.states.RetrieveActiveAlarms(ctx, tw.ID)
+	if err != nil {
+		return alarmState{}, err
+	}
+	for _, a := range alarms {
+		as.active[alarmKey(a.Attribute, a.Rule)] = a
+	}
+	for _, attr := range tw.Definitions[len(tw.Definitions)-1].Attributes {
+		val, ok := toNumber(last.Payload[attr.Name])
+		if !ok {
+			continue
+		}
+		as.samples[attr.Name] = sample{value: val, at: last.Created}
+		for _, rule := range attr.Alarms {
+			if rule.Condition == StuckCondition {
+				as.samples[alarmKey(attr.Name, rule.Name)] = sample{value: val, at: last.Created}
+			}
+		}
+	}
+
+	return as, nil
+}
+
+// copy returns the alarm state which does not share the maps with the
+// original, so that the cached view is not changed in place.
+func (as alarmState) copy() alarmState {
+	cp := alarmState{
+		active:  make(map[string]Alarm, len(as.active)),
+		samples: make(map[string]sample, len(as.samples)),
+	}
+	for k, v := range as.active {
+		cp.active[k] = v
+	}
+	for k, v := range as.samples {
+		cp.samples[k] = v
+	}
+
+	return cp
+}
+
+// evaluateAlarms checks the received value of the changed attribute, and
+// the values of the computed attributes which changed as well, against their
+// alarm rules, and returns the alarms raised or cleared by them. The received
+// value is checked whether or not it is kept in the state.
+func (ts *twinservice) evaluateAlarms(as alarmState, twinID string, def Definition, st State, changed string, received interface{}, at time.Time) []Alarm {
+	var alarms []Alarm
+	for _, attr := range def.Attributes {
+		if len(attr.Alarms) == 0 {
+			continue
+		}
+		value := st.Payload[attr.Name]
+		if attr.Name == changed {
+			value = received
+		}
+		val, ok := toNumber(value)
+		if !ok {
+			continue
+		}
+		prev, sampled := as.samples[attr.Name]
+		if attr.Name != changed && (attr.Expression == "" || (sampled && prev.value == val)) {
+			continue
+		}
+		as.samples[attr.Name] = sample{value: val, at: at}
+
+		for _, rule := range attr.Alarms {
+			key := alarmKey(attr.Name, rule.Name)
+			raise, cleared, ok := checkRule(as, key, rule, val, at, prev, sampled)
+			if !ok {
+				continue
+			}
+			active, raised := as.active[key]
+			switch {
+			case !raised && raise:
+				id, err := ts.idProvider.ID()
+				if err != nil {
+					ts.logger.Warn(fmt.Sprintf("Failed to raise alarm %s of twin %s: %s", key, twinID, err))
+					continue
+				}
+				a := Alarm{
+					ID:        id,
+					TwinID:    twinID,
+					Attribute: attr.Name,
+					Rule:      rule.Name,
+					Condition: rule.Condition,
+					Severity:  rule.Severity,
+					Status:    RaisedStatus,
+					Value:     val,
+					Raised:    at,
+				}
+				as.active[key] = a
+				alarms = append(alarms, a)
+			case raised && cleared:
+				active.Status = ClearedStatus
+				active.Cleared = &at
+				delete(as.active, key)
+				alarms = append(alarms, active)
+			}
+		}
+	}
+
+	return alarms
+}
+
+// clearStaleAlarms clears the active alarms whose rules were removed from
+// the definition.
+func clearStaleAlarms(as alarmState, def Definition, at time.Time) []Alarm {
+	rules := make(map[string]bool)
+	for _, attr := range def.Attributes {
+		for _, rule := range attr.Alarms {
+			rules[alarmKey(attr.Name, rule.Name)] = true
+		}
+	}
+
+	var alarms []Alarm
+	for key, a := range as.active {
+		if rules[key] {
+			continue
+		}
+		a.Status = ClearedStatus
+		a.Cleared = &at
+		delete(as.active, key)
+		alarms = append(alarms, a)
+	}
+
+	return alarms
+}
+
+// checkRule reports whether the value raises or clears the alarm of the
+// rule. The last return value is false if the rule cannot be evaluated yet,
+// i.e. the change rate and the stuck value require the previous sample.
+func checkRule(as alarmState, key string, rule AlarmRule, val float64, at time.Time, prev sample, sampled bool) (raise, cleared, ok bool) {
+	h := rule.Hysteresis
+	switch rule.Condition {
+	case GreaterCondition:
+		return val > rule.Threshold, val <= rule.Threshold-h, true
+	case LessCondition:
+		return val < rule.Threshold, val >= rule.Threshold+h, true
+	case RangeCondition:
+		raise = (rule.Min != nil && val < *rule.Min) || (rule.Max != nil && val > *rule.Max)
+		cleared = (rule.Min == nil || val >= *rule.Min+h) && (rule.Max == nil || val <= *rule.Max-h)
+		return raise, cleared, true
+	case RateCondition:
+		elapsed := at.Sub(prev.at).Seconds()
+		if !sampled || elapsed <= 0 {
+			return false, false, false
+		}
+		rate := math.Abs(val-prev.value) / elapsed
+		return rate > rule.Threshold, rate <= rule.Threshold-h, true
+	case StuckCondition:
+		ref, ok := as.samples[key]
+		if !ok || math.Abs(val-ref.value) > h {
+			as.samples[key] = sample{value: val, at: at}
+			return false, ok, ok
+		}
+		return at.Sub(ref.at) >= time.Duration(rule.Duration), false, true
+	}
+
+	return false, false, false
+}
+
+// saveAlarms persists the raised and cleared alarms and publishes them to
+// the notification channel.
+func (ts *twinservice) saveAlarms(ctx context.Context, twinID string, alarms []Alarm) error {
+	for _, a := range alarms {
+		var b []byte
+		err := ts.states.SaveAlarm(ctx, a)
+		if err == nil {
+			b, err = json.Marshal(a)
+		}
+		op := crudOp["alarmRaised"]
+		if a.Status == ClearedStatus {
+			op = crudOp["alarmCleared"]
+		}
+		ts.publish(ctx, &twinID, &err, op, crudOp["alarmFail"], &b)
+		if err != nil {
+			return fmt.Errorf("save alarm %s of twin %s failed: %s", a.ID, twinID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,453 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package grpc
+
+import (
+	"context"
+
+	"github.com/absmach/supermq-contrib/twins"
+	apiutil "github.com/absmach/supermq/api/http/util"
+	"github.com/absmach/supermq/pkg/errors"
+	"github.com/go-kit/kit/endpoint"
+)
+
+func addTwinEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(addTwinReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		twin := twins.Twin{
+			Name:     req.name,
+			Template: req.template,
+			Bindings: req.bindings,
+			Metadata: req.metadata,
+		}
+		saved, err := svc.AddTwin(ctx, req.token, req.domainID, twin, req.definition)
+		if err != nil {
+			return nil, err
+		}
+
+		return twinRes{twin: saved}, nil
+	}
+}
+
+func updateTwinEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(updateTwinReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		twin := twins.Twin{
+			ID:       req.id,
+			Name:     req.name,
+			Metadata: req.metadata,
+		}
+		if err := svc.UpdateTwin(ctx, req.token, req.domainID, twin, req.definition, req.revision); err != nil {
+			return nil, err
+		}
+
+		return emptyRes{}, nil
+	}
+}
+
+func viewTwinEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(entityReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		twin, err := svc.ViewTwin(ctx, req.token, req.domainID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return twinRes{twin: twin}, nil
+	}
+}
+
+func removeTwinEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(removeTwinReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		if err := svc.RemoveTwin(ctx, req.token, req.domainID, req.id, req.cascade, req.revision); err != nil {
+			return nil, err
+		}
+
+		return emptyRes{}, nil
+	}
+}
+
+func listTwinsEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listTwinsReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		page, err := svc.ListTwins(ctx, req.token, req.domainID, req.offset, req.limit, req.name, req.parentID, req.metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		return twinsPageRes{page: page}, nil
+	}
+}
+
+func attachChildEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(childReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		if err := svc.AttachChild(ctx, req.token, req.domainID, req.parentID, req.childID); err != nil {
+			return nil, err
+		}
+
+		return emptyRes{}, nil
+	}
+}
+
+func detachChildEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(childReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		if err := svc.DetachChild(ctx, req.token, req.domainID, req.parentID, req.childID); err != nil {
+			return nil, err
+		}
+
+		return emptyRes{}, nil
+	}
+}
+
+func viewSubtreeEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(entityReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		node, err := svc.ViewSubtree(ctx, req.token, req.domainID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return subtreeRes{root: node}, nil
+	}
+}
+
+func viewCompositeStateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(entityReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		cs, err := svc.ViewCompositeState(ctx, req.token, req.domainID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return compositeStateRes{state: cs}, nil
+	}
+}
+
+func shareTwinEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(shareTwinReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		if req.role == "" {
+			return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrMissingRelation)
+		}
+
+		if err := svc.ShareTwin(ctx, req.token, req.domainID, req.id, req.role, req.userIDs); err != nil {
+			return nil, err
+		}
+
+		return emptyRes{}, nil
+	}
+}
+
+func unshareTwinEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(shareTwinReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		if err := svc.UnshareTwin(ctx, req.token, req.domainID, req.id, req.userIDs); err != nil {
+			return nil, err
+		}
+
+		return emptyRes{}, nil
+	}
+}
+
+func listStatesEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listStatesReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		page, err := svc.ListStates(ctx, req.token, req.domainID, req.offset, req.limit, req.twinID, req.filter)
+		if err != nil {
+			return nil, err
+		}
+
+		return statesPageRes{page: page}, nil
+	}
+}
+
+func listAlarmsEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listAlarmsReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		page, err := svc.ListAlarms(ctx, req.token, req.domainID, req.offset, req.limit, req.twinID, req.filter)
+		if err != nil {
+			return nil, err
+		}
+
+		return alarmsPageRes{page: page}, nil
+	}
+}
+
+func stateAtEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(stateAtReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		st, def, err := svc.StateAt(ctx, req.token, req.domainID, req.twinID, req.at)
+		if err != nil {
+			return nil, err
+		}
+
+		return stateAtRes{state: st, definition: def}, nil
+	}
+}
+
+func updateDesiredStateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(desiredStateReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		delta, err := svc.UpdateDesiredState(ctx, req.token, req.domainID, req.twinID, req.payload)
+		if err != nil {
+			return nil, err
+		}
+
+		return deltaRes{delta: delta}, nil
+	}
+}
+
+func viewDesiredStateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(entityReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		ds, err := svc.ViewDesiredState(ctx, req.token, req.domainID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return desiredStateRes{state: ds}, nil
+	}
+}
+
+func viewDeltaEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(entityReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		delta, err := svc.ViewDelta(ctx, req.token, req.domainID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return deltaRes{delta: delta}, nil
+	}
+}
+
+// watchStatesEndpoint subscribes to the twin changes. The subscription
+// lasts until the context is canceled.
+func watchStatesEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(watchStatesReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		events, err := svc.StreamStates(ctx, req.token, req.domainID, req.twinIDs, req.attributes)
+		if err != nil {
+			return nil, err
+		}
+
+		return watchStatesRes{events: events}, nil
+	}
+}
+
+func addTemplateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(addTemplateReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		tmpl := twins.Template{
+			Name:       req.name,
+			Definition: req.definition,
+			Metadata:   req.metadata,
+		}
+		saved, err := svc.AddTemplate(ctx, req.token, req.domainID, tmpl)
+		if err != nil {
+			return nil, err
+		}
+
+		return templateRes{template: saved}, nil
+	}
+}
+
+func updateTemplateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(updateTemplateReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		tmpl := twins.Template{
+			ID:         req.id,
+			Name:       req.name,
+			Definition: req.definition,
+			Metadata:   req.metadata,
+		}
+		if err := svc.UpdateTemplate(ctx, req.token, req.domainID, tmpl); err != nil {
+			return nil, err
+		}
+
+		return emptyRes{}, nil
+	}
+}
+
+func viewTemplateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(entityReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		tmpl, err := svc.ViewTemplate(ctx, req.token, req.domainID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return templateRes{template: tmpl}, nil
+	}
+}
+
+func listTemplatesEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listTemplatesReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		page, err := svc.ListTemplates(ctx, req.token, req.domainID, req.offset, req.limit, req.name)
+		if err != nil {
+			return nil, err
+		}
+
+		return templatesPageRes{page: page}, nil
+	}
+}
+
+func removeTemplateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(entityReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		if err := svc.RemoveTemplate(ctx, req.token, req.domainID, req.id); err != nil {
+			return nil, err
+		}
+
+		return emptyRes{}, nil
+	}
+}
+
+func rolloutTemplateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(rolloutTemplateReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		ro, err := svc.RolloutTemplate(ctx, req.token, req.domainID, req.id, req.bindings)
+		if err != nil {
+			return nil, err
+		}
+
+		return rolloutRes{rollout: ro}, nil
+	}
+}
+
+func exportTwinsEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(exportTwinsReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		tws, err := svc.ExportTwins(ctx, req.token, req.domainID, req.ownerID)
+		if err != nil {
+			return nil, err
+		}
+
+		return exportRes{twins: tws}, nil
+	}
+}
+
+func importTwinsEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(importTwinsReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		opts := twins.ImportOptions{
+			RemapIDs: req.remap,
+			DryRun:   req.dryRun,
+		}
+		imp, err := svc.ImportTwins(ctx, req.token, req.domainID, req.twins, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return importRes{imp: imp}, nil
+	}
+}
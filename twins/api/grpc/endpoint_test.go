@@ -0,0 +1,506 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package grpc_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/absmach/supermq-contrib/pkg/testsutil"
+	"github.com/absmach/supermq-contrib/twins"
+	grpcapi "github.com/absmach/supermq-contrib/twins/api/grpc"
+	grpcTwinsV1 "github.com/absmach/supermq-contrib/twins/api/grpc/v1"
+	"github.com/absmach/supermq-contrib/twins/mocks"
+	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	svcerr "github.com/absmach/supermq/pkg/errors/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	token    = "token"
+	domainID = "b6a8a5fd-9ab7-41f2-8e5f-b2f2aa6cabe6"
+)
+
+var validID = testsutil.GenerateUUID(&testing.T{})
+
+func startGRPCServer(t *testing.T, svc twins.Service) grpcTwinsV1.TwinsServiceClient {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("failed to obtain port: %s", err)
+	}
+	server := grpc.NewServer()
+	grpcTwinsV1.RegisterTwinsServiceServer(server, grpcapi.NewServer(svc))
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			panic(fmt.Sprintf("failed to serve: %s", err))
+		}
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return grpcTwinsV1.NewTwinsServiceClient(conn)
+}
+
+func withToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func TestAddTwin(t *testing.T) {
+	svc := new(mocks.Service)
+	client := startGRPCServer(t, svc)
+
+	created := time.Now().UTC().Round(time.Millisecond)
+	def := twins.Definition{
+		ID:      0,
+		Created: created,
+		Attributes: []twins.Attribute{
+			{Name: "temperature", Channel: "chanID", Subtopic: "temperature", PersistState: true},
+		},
+	}
+	saved := twins.Twin{
+		ID:          validID,
+		Owner:       "user",
+		Domain:      domainID,
+		Name:        "twin",
+		Created:     created,
+		Updated:     created,
+		Definitions: []twins.Definition{def},
+		Metadata:    twins.Metadata{"location": "lab"},
+	}
+
+	cases := []struct {
+		desc   string
+		token  string
+		req    *grpcTwinsV1.AddTwinReq
+		svcErr error
+		code   codes.Code
+	}{
+		{
+			desc:  "add twin",
+			token: token,
+			req: &grpcTwinsV1.AddTwinReq{
+				DomainId: domainID,
+				Name:     "twin",
+				Definition: &grpcTwinsV1.Definition{
+					Attributes: []*grpcTwinsV1.Attribute{
+						{Name: "temperature", Channel: "chanID", Subtopic: "temperature", PersistState: true},
+					},
+				},
+			},
+			code: codes.OK,
+		},
+		{
+			desc:  "add twin without token",
+			token: "",
+			req:   &grpcTwinsV1.AddTwinReq{DomainId: domainID, Name: "twin"},
+			code:  codes.Unauthenticated,
+		},
+		{
+			desc:  "add twin without domain",
+			token: token,
+			req:   &grpcTwinsV1.AddTwinReq{Name: "twin"},
+			code:  codes.InvalidArgument,
+		},
+		{
+			desc:  "add twin with long name",
+			token: token,
+			req:   &grpcTwinsV1.AddTwinReq{DomainId: domainID, Name: strings.Repeat("m", 1025)},
+			code:  codes.InvalidArgument,
+		},
+		{
+			desc:   "add twin with unauthorized token",
+			token:  token,
+			req:    &grpcTwinsV1.AddTwinReq{DomainId: domainID, Name: "twin"},
+			svcErr: svcerr.ErrAuthorization,
+			code:   codes.PermissionDenied,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svcCall := svc.On("AddTwin", mock.Anything, tc.token, domainID, mock.Anything, mock.Anything).Return(saved, tc.svcErr)
+			res, err := client.AddTwin(withToken(context.Background(), tc.token), tc.req)
+			assert.Equal(t, tc.code, status.Code(err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.code, status.Code(err)))
+			if tc.code == codes.OK {
+				assert.Equal(t, validID, res.GetTwin().GetId())
+				assert.Equal(t, "lab", res.GetTwin().GetMetadata().AsMap()["location"])
+				assert.Equal(t, created, res.GetTwin().GetCreated().AsTime())
+				assert.Len(t, res.GetTwin().GetDefinitions(), 1)
+				ok := svcCall.Parent.AssertCalled(t, "AddTwin", mock.Anything, tc.token, domainID, mock.MatchedBy(func(tw twins.Twin) bool {
+					return tw.Name == "twin"
+				}), mock.MatchedBy(func(d twins.Definition) bool {
+					return len(d.Attributes) == 1 && d.Attributes[0].PersistState
+				}))
+				assert.True(t, ok, fmt.Sprintf("%s: AddTwin was not called with the decoded twin", tc.desc))
+			}
+			svcCall.Unset()
+		})
+	}
+}
+
+func TestUpdateTwin(t *testing.T) {
+	svc := new(mocks.Service)
+	client := startGRPCServer(t, svc)
+
+	revision := int32(3)
+
+	cases := []struct {
+		desc     string
+		req      *grpcTwinsV1.UpdateTwinReq
+		revision int
+		svcErr   error
+		code     codes.Code
+	}{
+		{
+			desc:     "update twin with any revision",
+			req:      &grpcTwinsV1.UpdateTwinReq{DomainId: domainID, Id: validID, Name: "twin"},
+			revision: twins.AnyRevision,
+			code:     codes.OK,
+		},
+		{
+			desc:     "update twin with revision",
+			req:      &grpcTwinsV1.UpdateTwinReq{DomainId: domainID, Id: validID, Name: "twin", Revision: &revision},
+			revision: int(revision),
+			code:     codes.OK,
+		},
+		{
+			desc:     "update twin with stale revision",
+			req:      &grpcTwinsV1.UpdateTwinReq{DomainId: domainID, Id: validID, Name: "twin", Revision: &revision},
+			revision: int(revision),
+			svcErr:   errors.Wrap(svcerr.ErrConflict, twins.ErrRevisionConflict),
+			code:     codes.Aborted,
+		},
+		{
+			desc:     "update twin modified concurrently",
+			req:      &grpcTwinsV1.UpdateTwinReq{DomainId: domainID, Id: validID, Name: "twin", Revision: &revision},
+			revision: int(revision),
+			svcErr:   errors.Wrap(svcerr.ErrUpdateEntity, repoerr.ErrConflict),
+			code:     codes.Aborted,
+		},
+		{
+			desc:     "update non-existing twin",
+			req:      &grpcTwinsV1.UpdateTwinReq{DomainId: domainID, Id: validID, Name: "twin"},
+			revision: twins.AnyRevision,
+			svcErr:   svcerr.ErrNotFound,
+			code:     codes.NotFound,
+		},
+		{
+			desc: "update twin without ID",
+			req:  &grpcTwinsV1.UpdateTwinReq{DomainId: domainID, Name: "twin"},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svcCall := svc.On("UpdateTwin", mock.Anything, token, domainID, mock.Anything, mock.Anything, tc.revision).Return(tc.svcErr)
+			_, err := client.UpdateTwin(withToken(context.Background(), token), tc.req)
+			assert.Equal(t, tc.code, status.Code(err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.code, status.Code(err)))
+			svcCall.Unset()
+		})
+	}
+}
+
+func TestListStates(t *testing.T) {
+	svc := new(mocks.Service)
+	client := startGRPCServer(t, svc)
+
+	created := time.Now().UTC().Round(time.Millisecond)
+	// States hold the pointers to the SenML record values.
+	pressure, mode, running := 1.2, "auto", true
+	page := twins.StatesPage{
+		PageMetadata: twins.PageMetadata{Total: 1, Limit: 10},
+		States: []twins.State{
+			{
+				TwinID:  validID,
+				ID:      1,
+				Created: created,
+				Payload: map[string]interface{}{"temperature": 21.5, "pressure": &pressure, "mode": &mode, "running": &running},
+				Updated: map[string]time.Time{"temperature": created},
+			},
+		},
+	}
+
+	cases := []struct {
+		desc   string
+		req    *grpcTwinsV1.ListStatesReq
+		limit  uint64
+		filter twins.StateFilter
+		code   codes.Code
+	}{
+		{
+			desc:   "list states with default limit and direction",
+			req:    &grpcTwinsV1.ListStatesReq{DomainId: domainID, TwinId: validID},
+			limit:  10,
+			filter: twins.StateFilter{Dir: "asc"},
+			code:   codes.OK,
+		},
+		{
+			desc:   "list states with attribute in descending direction",
+			req:    &grpcTwinsV1.ListStatesReq{DomainId: domainID, TwinId: validID, Limit: 5, Attribute: "temperature", Dir: "desc"},
+			limit:  5,
+			filter: twins.StateFilter{Attribute: "temperature", Dir: "desc"},
+			code:   codes.OK,
+		},
+		{
+			desc: "list states with invalid direction",
+			req:  &grpcTwinsV1.ListStatesReq{DomainId: domainID, TwinId: validID, Dir: "up"},
+			code: codes.InvalidArgument,
+		},
+		{
+			desc: "list states with limit out of range",
+			req:  &grpcTwinsV1.ListStatesReq{DomainId: domainID, TwinId: validID, Limit: 101},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svcCall := svc.On("ListStates", mock.Anything, token, domainID, uint64(0), tc.limit, validID, tc.filter).Return(page, nil)
+			res, err := client.ListStates(withToken(context.Background(), token), tc.req)
+			assert.Equal(t, tc.code, status.Code(err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.code, status.Code(err)))
+			if tc.code == codes.OK {
+				assert.Equal(t, uint64(1), res.GetTotal())
+				assert.Len(t, res.GetStates(), 1)
+				payload := res.GetStates()[0].GetPayload().AsMap()
+				assert.Equal(t, 21.5, payload["temperature"])
+				assert.Equal(t, pressure, payload["pressure"])
+				assert.Equal(t, mode, payload["mode"])
+				assert.Equal(t, running, payload["running"])
+				assert.Equal(t, created, res.GetStates()[0].GetUpdated()["temperature"].AsTime())
+			}
+			svcCall.Unset()
+		})
+	}
+}
+
+func TestWatchStates(t *testing.T) {
+	svc := new(mocks.Service)
+	client := startGRPCServer(t, svc)
+
+	created := time.Now().UTC().Round(time.Millisecond)
+	temperature := 21.5
+	state := twins.State{
+		TwinID:  validID,
+		ID:      1,
+		Created: created,
+		Payload: map[string]interface{}{"temperature": &temperature},
+	}
+
+	t.Run("watch states", func(t *testing.T) {
+		events := make(chan twins.StreamEvent, 1)
+		events <- twins.StreamEvent{Type: twins.StateCreated, TwinID: validID, State: &state}
+		close(events)
+		svcCall := svc.On("StreamStates", mock.Anything, token, domainID, []string{validID}, []string(nil)).Return((<-chan twins.StreamEvent)(events), nil)
+		defer svcCall.Unset()
+
+		stream, err := client.WatchStates(withToken(context.Background(), token), &grpcTwinsV1.WatchStatesReq{DomainId: domainID, TwinIds: []string{validID}})
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		ev, err := stream.Recv()
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		assert.Equal(t, twins.StateCreated, ev.GetType())
+		assert.Equal(t, validID, ev.GetState().GetTwinId())
+		assert.Equal(t, temperature, ev.GetState().GetPayload().AsMap()["temperature"])
+		assert.Nil(t, ev.GetDefinition())
+
+		_, err = stream.Recv()
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("watch states without twins", func(t *testing.T) {
+		stream, err := client.WatchStates(withToken(context.Background(), token), &grpcTwinsV1.WatchStatesReq{DomainId: domainID})
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		_, err = stream.Recv()
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("watch states with unauthorized token", func(t *testing.T) {
+		svcCall := svc.On("StreamStates", mock.Anything, token, domainID, []string{validID}, []string(nil)).Return(nil, svcerr.ErrAuthorization)
+		defer svcCall.Unset()
+
+		stream, err := client.WatchStates(withToken(context.Background(), token), &grpcTwinsV1.WatchStatesReq{DomainId: domainID, TwinIds: []string{validID}})
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		_, err = stream.Recv()
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
+
+func TestExportTwins(t *testing.T) {
+	svc := new(mocks.Service)
+	client := startGRPCServer(t, svc)
+
+	exported := []twins.Twin{
+		{
+			ID:       validID,
+			Owner:    validID,
+			Domain:   domainID,
+			Name:     "twin",
+			Bindings: map[string]string{"site": "factory"},
+			Definitions: []twins.Definition{
+				{Attributes: []twins.Attribute{{Name: "temperature", Channel: validID}}},
+			},
+		},
+	}
+
+	cases := []struct {
+		desc    string
+		req     *grpcTwinsV1.ExportTwinsReq
+		token   string
+		ownerID string
+		svcRes  []twins.Twin
+		svcErr  error
+		code    codes.Code
+	}{
+		{
+			desc:   "export twins of the user",
+			req:    &grpcTwinsV1.ExportTwinsReq{DomainId: domainID},
+			token:  token,
+			svcRes: exported,
+			code:   codes.OK,
+		},
+		{
+			desc:    "export twins of the owner",
+			req:     &grpcTwinsV1.ExportTwinsReq{DomainId: domainID, OwnerId: validID},
+			token:   token,
+			ownerID: validID,
+			svcRes:  exported,
+			code:    codes.OK,
+		},
+		{
+			desc:    "export twins of the owner without permission",
+			req:     &grpcTwinsV1.ExportTwinsReq{DomainId: domainID, OwnerId: validID},
+			token:   token,
+			ownerID: validID,
+			svcErr:  svcerr.ErrAuthorization,
+			code:    codes.PermissionDenied,
+		},
+		{
+			desc: "export twins without token",
+			req:  &grpcTwinsV1.ExportTwinsReq{DomainId: domainID},
+			code: codes.Unauthenticated,
+		},
+		{
+			desc:  "export twins without domain",
+			req:   &grpcTwinsV1.ExportTwinsReq{},
+			token: token,
+			code:  codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			svcCall := svc.On("ExportTwins", mock.Anything, tc.token, domainID, tc.ownerID).Return(tc.svcRes, tc.svcErr)
+			res, err := client.ExportTwins(withToken(context.Background(), tc.token), tc.req)
+			assert.Equal(t, tc.code, status.Code(err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.code, status.Code(err)))
+			if tc.code == codes.OK {
+				assert.Len(t, res.GetTwins(), len(tc.svcRes))
+				assert.Equal(t, validID, res.GetTwins()[0].GetId())
+				assert.Equal(t, "factory", res.GetTwins()[0].GetBindings()["site"])
+			}
+			svcCall.Unset()
+		})
+	}
+}
+
+func TestImportTwins(t *testing.T) {
+	svc := new(mocks.Service)
+	client := startGRPCServer(t, svc)
+
+	parentID := testsutil.GenerateUUID(t)
+	imported := []*grpcTwinsV1.Twin{
+		{
+			Id:       validID,
+			ParentId: parentID,
+			Name:     "twin",
+			Definitions: []*grpcTwinsV1.Definition{
+				{Attributes: []*grpcTwinsV1.Attribute{{Name: "temperature", Channel: validID}}},
+			},
+		},
+	}
+
+	cases := []struct {
+		desc   string
+		req    *grpcTwinsV1.ImportTwinsReq
+		opts   twins.ImportOptions
+		svcRes twins.Import
+		svcErr error
+		code   codes.Code
+	}{
+		{
+			desc:   "import twins",
+			req:    &grpcTwinsV1.ImportTwinsReq{DomainId: domainID, Twins: imported},
+			svcRes: twins.Import{IDs: map[string]string{validID: validID}},
+			code:   codes.OK,
+		},
+		{
+			desc:   "import twins with remapped identifiers in dry run",
+			req:    &grpcTwinsV1.ImportTwinsReq{DomainId: domainID, Twins: imported, RemapIds: true, DryRun: true},
+			opts:   twins.ImportOptions{RemapIDs: true, DryRun: true},
+			svcRes: twins.Import{DryRun: true, IDs: map[string]string{validID: parentID}},
+			code:   codes.OK,
+		},
+		{
+			desc:   "import twins with unknown parent",
+			req:    &grpcTwinsV1.ImportTwinsReq{DomainId: domainID, Twins: imported},
+			svcErr: svcerr.ErrMalformedEntity,
+			code:   codes.InvalidArgument,
+		},
+		{
+			desc: "import empty list of twins",
+			req:  &grpcTwinsV1.ImportTwinsReq{DomainId: domainID},
+			code: codes.InvalidArgument,
+		},
+		{
+			desc: "import twins with too long name",
+			req: &grpcTwinsV1.ImportTwinsReq{
+				DomainId: domainID,
+				Twins:    []*grpcTwinsV1.Twin{{Name: strings.Repeat("a", 1025)}},
+			},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var tws []twins.Twin
+			svcCall := svc.On("ImportTwins", mock.Anything, token, domainID, mock.Anything, tc.opts).Return(tc.svcRes, tc.svcErr).Run(func(args mock.Arguments) {
+				tws = args.Get(3).([]twins.Twin)
+			})
+			res, err := client.ImportTwins(withToken(context.Background(), token), tc.req)
+			assert.Equal(t, tc.code, status.Code(err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.code, status.Code(err)))
+			if tc.code == codes.OK {
+				assert.Len(t, tws, 1)
+				assert.Equal(t, validID, tws[0].ID)
+				assert.Equal(t, parentID, tws[0].Parent)
+				assert.Equal(t, "temperature", tws[0].Definitions[0].Attributes[0].Name)
+				assert.Equal(t, tc.svcRes.DryRun, res.GetDryRun())
+				assert.Equal(t, tc.svcRes.IDs, res.GetIds())
+			}
+			svcCall.Unset()
+		})
+	}
+}
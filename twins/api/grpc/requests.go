@@ -0,0 +1,518 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package grpc
+
+import (
+	"time"
+
+	"github.com/absmach/supermq-contrib/pkg/api"
+	"github.com/absmach/supermq-contrib/twins"
+	apiutil "github.com/absmach/supermq/api/http/util"
+)
+
+const (
+	maxNameSize  = 1024
+	maxLimitSize = 100
+)
+
+type addTwinReq struct {
+	token      string
+	domainID   string
+	name       string
+	definition twins.Definition
+	template   string
+	bindings   map[string]string
+	metadata   twins.Metadata
+}
+
+func (req addTwinReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if len(req.name) > maxNameSize {
+		return apiutil.ErrNameSize
+	}
+
+	return nil
+}
+
+type updateTwinReq struct {
+	token      string
+	domainID   string
+	id         string
+	revision   int
+	name       string
+	definition twins.Definition
+	metadata   twins.Metadata
+}
+
+func (req updateTwinReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if len(req.name) > maxNameSize {
+		return apiutil.ErrNameSize
+	}
+
+	return nil
+}
+
+// entityReq identifies the twin or the template of the domain.
+type entityReq struct {
+	token    string
+	domainID string
+	id       string
+}
+
+func (req entityReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	return nil
+}
+
+type removeTwinReq struct {
+	token    string
+	domainID string
+	id       string
+	cascade  bool
+	revision int
+}
+
+func (req removeTwinReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	return nil
+}
+
+type listTwinsReq struct {
+	token    string
+	domainID string
+	offset   uint64
+	limit    uint64
+	name     string
+	parentID string
+	metadata twins.Metadata
+}
+
+func (req listTwinsReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.limit < 1 || req.limit > maxLimitSize {
+		return apiutil.ErrLimitSize
+	}
+
+	if len(req.name) > maxNameSize {
+		return apiutil.ErrNameSize
+	}
+
+	return nil
+}
+
+type childReq struct {
+	token    string
+	domainID string
+	parentID string
+	childID  string
+}
+
+func (req childReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.parentID == "" || req.childID == "" {
+		return apiutil.ErrMissingID
+	}
+
+	return nil
+}
+
+type shareTwinReq struct {
+	token    string
+	domainID string
+	id       string
+	role     string
+	userIDs  []string
+}
+
+func (req shareTwinReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if len(req.userIDs) == 0 {
+		return apiutil.ErrEmptyList
+	}
+
+	return nil
+}
+
+type listStatesReq struct {
+	token    string
+	domainID string
+	twinID   string
+	offset   uint64
+	limit    uint64
+	filter   twins.StateFilter
+}
+
+func (req listStatesReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.twinID == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if req.limit == 0 || req.limit > maxLimitSize {
+		return apiutil.ErrLimitSize
+	}
+
+	if !req.filter.To.IsZero() && req.filter.From.After(req.filter.To) {
+		return apiutil.ErrInvalidQueryParams
+	}
+
+	if req.filter.Dir != api.AscDir && req.filter.Dir != api.DescDir {
+		return apiutil.ErrInvalidDirection
+	}
+
+	return nil
+}
+
+type listAlarmsReq struct {
+	token    string
+	domainID string
+	twinID   string
+	offset   uint64
+	limit    uint64
+	filter   twins.AlarmFilter
+}
+
+func (req listAlarmsReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.twinID == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if req.limit == 0 || req.limit > maxLimitSize {
+		return apiutil.ErrLimitSize
+	}
+
+	switch req.filter.Severity {
+	case "", twins.InfoSeverity, twins.WarningSeverity, twins.CriticalSeverity:
+	default:
+		return apiutil.ErrInvalidQueryParams
+	}
+
+	switch req.filter.Status {
+	case "", twins.RaisedStatus, twins.ClearedStatus:
+	default:
+		return apiutil.ErrInvalidQueryParams
+	}
+
+	return nil
+}
+
+type stateAtReq struct {
+	token    string
+	domainID string
+	twinID   string
+	at       time.Time
+}
+
+func (req stateAtReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.twinID == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if req.at.IsZero() {
+		return apiutil.ErrInvalidQueryParams
+	}
+
+	return nil
+}
+
+type desiredStateReq struct {
+	token    string
+	domainID string
+	twinID   string
+	payload  map[string]interface{}
+}
+
+func (req desiredStateReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.twinID == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if len(req.payload) == 0 {
+		return apiutil.ErrEmptyList
+	}
+
+	return nil
+}
+
+type watchStatesReq struct {
+	token      string
+	domainID   string
+	twinIDs    []string
+	attributes []string
+}
+
+func (req watchStatesReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if len(req.twinIDs) == 0 {
+		return apiutil.ErrMissingID
+	}
+
+	if len(req.twinIDs) > maxLimitSize {
+		return apiutil.ErrLimitSize
+	}
+
+	for _, id := range req.twinIDs {
+		if id == "" {
+			return apiutil.ErrMissingID
+		}
+	}
+
+	return nil
+}
+
+type addTemplateReq struct {
+	token      string
+	domainID   string
+	name       string
+	definition twins.Definition
+	metadata   twins.Metadata
+}
+
+func (req addTemplateReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if len(req.name) > maxNameSize {
+		return apiutil.ErrNameSize
+	}
+
+	return nil
+}
+
+type updateTemplateReq struct {
+	token      string
+	domainID   string
+	id         string
+	name       string
+	definition twins.Definition
+	metadata   twins.Metadata
+}
+
+func (req updateTemplateReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if len(req.name) > maxNameSize {
+		return apiutil.ErrNameSize
+	}
+
+	return nil
+}
+
+type listTemplatesReq struct {
+	token    string
+	domainID string
+	offset   uint64
+	limit    uint64
+	name     string
+}
+
+func (req listTemplatesReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.limit < 1 || req.limit > maxLimitSize {
+		return apiutil.ErrLimitSize
+	}
+
+	if len(req.name) > maxNameSize {
+		return apiutil.ErrNameSize
+	}
+
+	return nil
+}
+
+type rolloutTemplateReq struct {
+	token    string
+	domainID string
+	id       string
+	bindings map[string]string
+}
+
+func (req rolloutTemplateReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	return nil
+}
+
+type exportTwinsReq struct {
+	token    string
+	domainID string
+	ownerID  string
+}
+
+func (req exportTwinsReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	return nil
+}
+
+type importTwinsReq struct {
+	token    string
+	domainID string
+	twins    []twins.Twin
+	remap    bool
+	dryRun   bool
+}
+
+func (req importTwinsReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if len(req.twins) == 0 {
+		return apiutil.ErrEmptyList
+	}
+
+	for _, tw := range req.twins {
+		if len(tw.Name) > maxNameSize {
+			return apiutil.ErrNameSize
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package grpc
+
+import "github.com/absmach/supermq-contrib/twins"
+
+type emptyRes struct{}
+
+type twinRes struct {
+	twin twins.Twin
+}
+
+type twinsPageRes struct {
+	page twins.Page
+}
+
+type subtreeRes struct {
+	root twins.TwinNode
+}
+
+type compositeStateRes struct {
+	state twins.CompositeState
+}
+
+type statesPageRes struct {
+	page twins.StatesPage
+}
+
+type alarmsPageRes struct {
+	page twins.AlarmsPage
+}
+
+type stateAtRes struct {
+	state      twins.State
+	definition twins.Definition
+}
+
+type desiredStateRes struct {
+	state twins.DesiredState
+}
+
+type deltaRes struct {
+	delta twins.Delta
+}
+
+type watchStatesRes struct {
+	events <-chan twins.StreamEvent
+}
+
+type templateRes struct {
+	template twins.Template
+}
+
+type templatesPageRes struct {
+	page twins.TemplatesPage
+}
+
+type rolloutRes struct {
+	rollout twins.Rollout
+}
+
+type exportRes struct {
+	twins []twins.Twin
+}
+
+type importRes struct {
+	imp twins.Import
+}
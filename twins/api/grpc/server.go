@@ -0,0 +1,1281 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/absmach/supermq-contrib/pkg/api"
+	"github.com/absmach/supermq-contrib/twins"
+	grpcTwinsV1 "github.com/absmach/supermq-contrib/twins/api/grpc/v1"
+	apiutil "github.com/absmach/supermq/api/http/util"
+	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	svcerr "github.com/absmach/supermq/pkg/errors/service"
+	"github.com/go-kit/kit/endpoint"
+	kitgrpc "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	authzKey = "authorization"
+	defLimit = 10
+)
+
+var _ grpcTwinsV1.TwinsServiceServer = (*grpcServer)(nil)
+
+type grpcServer struct {
+	grpcTwinsV1.UnimplementedTwinsServiceServer
+	addTwin            kitgrpc.Handler
+	updateTwin         kitgrpc.Handler
+	viewTwin           kitgrpc.Handler
+	removeTwin         kitgrpc.Handler
+	listTwins          kitgrpc.Handler
+	attachChild        kitgrpc.Handler
+	detachChild        kitgrpc.Handler
+	viewSubtree        kitgrpc.Handler
+	viewCompositeState kitgrpc.Handler
+	shareTwin          kitgrpc.Handler
+	unshareTwin        kitgrpc.Handler
+	listStates         kitgrpc.Handler
+	listAlarms         kitgrpc.Handler
+	stateAt            kitgrpc.Handler
+	updateDesiredState kitgrpc.Handler
+	viewDesiredState   kitgrpc.Handler
+	viewDelta          kitgrpc.Handler
+	addTemplate        kitgrpc.Handler
+	updateTemplate     kitgrpc.Handler
+	viewTemplate       kitgrpc.Handler
+	listTemplates      kitgrpc.Handler
+	removeTemplate     kitgrpc.Handler
+	rolloutTemplate    kitgrpc.Handler
+	exportTwins        kitgrpc.Handler
+	importTwins        kitgrpc.Handler
+	// Go kit handlers do not support streaming, so the state watch calls
+	// the endpoint directly.
+	watchStates endpoint.Endpoint
+}
+
+// NewServer returns new TwinsServiceServer instance.
+func NewServer(svc twins.Service) grpcTwinsV1.TwinsServiceServer {
+	return &grpcServer{
+		addTwin: kitgrpc.NewServer(
+			addTwinEndpoint(svc),
+			decodeAddTwinRequest,
+			encodeTwinResponse,
+		),
+		updateTwin: kitgrpc.NewServer(
+			updateTwinEndpoint(svc),
+			decodeUpdateTwinRequest,
+			encodeUpdateTwinResponse,
+		),
+		viewTwin: kitgrpc.NewServer(
+			viewTwinEndpoint(svc),
+			decodeTwinRequest,
+			encodeTwinResponse,
+		),
+		removeTwin: kitgrpc.NewServer(
+			removeTwinEndpoint(svc),
+			decodeRemoveTwinRequest,
+			encodeRemoveTwinResponse,
+		),
+		listTwins: kitgrpc.NewServer(
+			listTwinsEndpoint(svc),
+			decodeListTwinsRequest,
+			encodeListTwinsResponse,
+		),
+		attachChild: kitgrpc.NewServer(
+			attachChildEndpoint(svc),
+			decodeChildRequest,
+			encodeChildResponse,
+		),
+		detachChild: kitgrpc.NewServer(
+			detachChildEndpoint(svc),
+			decodeChildRequest,
+			encodeChildResponse,
+		),
+		viewSubtree: kitgrpc.NewServer(
+			viewSubtreeEndpoint(svc),
+			decodeTwinRequest,
+			encodeSubtreeResponse,
+		),
+		viewCompositeState: kitgrpc.NewServer(
+			viewCompositeStateEndpoint(svc),
+			decodeTwinRequest,
+			encodeCompositeStateResponse,
+		),
+		shareTwin: kitgrpc.NewServer(
+			shareTwinEndpoint(svc),
+			decodeShareTwinRequest,
+			encodeShareTwinResponse,
+		),
+		unshareTwin: kitgrpc.NewServer(
+			unshareTwinEndpoint(svc),
+			decodeShareTwinRequest,
+			encodeShareTwinResponse,
+		),
+		listStates: kitgrpc.NewServer(
+			listStatesEndpoint(svc),
+			decodeListStatesRequest,
+			encodeListStatesResponse,
+		),
+		listAlarms: kitgrpc.NewServer(
+			listAlarmsEndpoint(svc),
+			decodeListAlarmsRequest,
+			encodeListAlarmsResponse,
+		),
+		stateAt: kitgrpc.NewServer(
+			stateAtEndpoint(svc),
+			decodeStateAtRequest,
+			encodeStateAtResponse,
+		),
+		updateDesiredState: kitgrpc.NewServer(
+			updateDesiredStateEndpoint(svc),
+			decodeDesiredStateRequest,
+			encodeDeltaResponse,
+		),
+		viewDesiredState: kitgrpc.NewServer(
+			viewDesiredStateEndpoint(svc),
+			decodeTwinRequest,
+			encodeDesiredStateResponse,
+		),
+		viewDelta: kitgrpc.NewServer(
+			viewDeltaEndpoint(svc),
+			decodeTwinRequest,
+			encodeDeltaResponse,
+		),
+		addTemplate: kitgrpc.NewServer(
+			addTemplateEndpoint(svc),
+			decodeAddTemplateRequest,
+			encodeTemplateResponse,
+		),
+		updateTemplate: kitgrpc.NewServer(
+			updateTemplateEndpoint(svc),
+			decodeUpdateTemplateRequest,
+			encodeUpdateTemplateResponse,
+		),
+		viewTemplate: kitgrpc.NewServer(
+			viewTemplateEndpoint(svc),
+			decodeTemplateRequest,
+			encodeTemplateResponse,
+		),
+		listTemplates: kitgrpc.NewServer(
+			listTemplatesEndpoint(svc),
+			decodeListTemplatesRequest,
+			encodeListTemplatesResponse,
+		),
+		removeTemplate: kitgrpc.NewServer(
+			removeTemplateEndpoint(svc),
+			decodeTemplateRequest,
+			encodeRemoveTemplateResponse,
+		),
+		rolloutTemplate: kitgrpc.NewServer(
+			rolloutTemplateEndpoint(svc),
+			decodeRolloutTemplateRequest,
+			encodeRolloutTemplateResponse,
+		),
+		exportTwins: kitgrpc.NewServer(
+			exportTwinsEndpoint(svc),
+			decodeExportTwinsRequest,
+			encodeExportTwinsResponse,
+		),
+		importTwins: kitgrpc.NewServer(
+			importTwinsEndpoint(svc),
+			decodeImportTwinsRequest,
+			encodeImportTwinsResponse,
+		),
+		watchStates: watchStatesEndpoint(svc),
+	}
+}
+
+func (s *grpcServer) AddTwin(ctx context.Context, req *grpcTwinsV1.AddTwinReq) (*grpcTwinsV1.TwinRes, error) {
+	_, res, err := s.addTwin.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.TwinRes), nil
+}
+
+func (s *grpcServer) UpdateTwin(ctx context.Context, req *grpcTwinsV1.UpdateTwinReq) (*grpcTwinsV1.UpdateTwinRes, error) {
+	_, res, err := s.updateTwin.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.UpdateTwinRes), nil
+}
+
+func (s *grpcServer) ViewTwin(ctx context.Context, req *grpcTwinsV1.TwinReq) (*grpcTwinsV1.TwinRes, error) {
+	_, res, err := s.viewTwin.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.TwinRes), nil
+}
+
+func (s *grpcServer) RemoveTwin(ctx context.Context, req *grpcTwinsV1.RemoveTwinReq) (*grpcTwinsV1.RemoveTwinRes, error) {
+	_, res, err := s.removeTwin.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.RemoveTwinRes), nil
+}
+
+func (s *grpcServer) ListTwins(ctx context.Context, req *grpcTwinsV1.ListTwinsReq) (*grpcTwinsV1.ListTwinsRes, error) {
+	_, res, err := s.listTwins.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.ListTwinsRes), nil
+}
+
+func (s *grpcServer) AttachChild(ctx context.Context, req *grpcTwinsV1.ChildReq) (*grpcTwinsV1.ChildRes, error) {
+	_, res, err := s.attachChild.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.ChildRes), nil
+}
+
+func (s *grpcServer) DetachChild(ctx context.Context, req *grpcTwinsV1.ChildReq) (*grpcTwinsV1.ChildRes, error) {
+	_, res, err := s.detachChild.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.ChildRes), nil
+}
+
+func (s *grpcServer) ViewSubtree(ctx context.Context, req *grpcTwinsV1.TwinReq) (*grpcTwinsV1.SubtreeRes, error) {
+	_, res, err := s.viewSubtree.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.SubtreeRes), nil
+}
+
+func (s *grpcServer) ViewCompositeState(ctx context.Context, req *grpcTwinsV1.TwinReq) (*grpcTwinsV1.CompositeStateRes, error) {
+	_, res, err := s.viewCompositeState.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.CompositeStateRes), nil
+}
+
+func (s *grpcServer) ShareTwin(ctx context.Context, req *grpcTwinsV1.ShareTwinReq) (*grpcTwinsV1.ShareTwinRes, error) {
+	_, res, err := s.shareTwin.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.ShareTwinRes), nil
+}
+
+func (s *grpcServer) UnshareTwin(ctx context.Context, req *grpcTwinsV1.ShareTwinReq) (*grpcTwinsV1.ShareTwinRes, error) {
+	_, res, err := s.unshareTwin.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.ShareTwinRes), nil
+}
+
+func (s *grpcServer) ListStates(ctx context.Context, req *grpcTwinsV1.ListStatesReq) (*grpcTwinsV1.ListStatesRes, error) {
+	_, res, err := s.listStates.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.ListStatesRes), nil
+}
+
+func (s *grpcServer) ListAlarms(ctx context.Context, req *grpcTwinsV1.ListAlarmsReq) (*grpcTwinsV1.ListAlarmsRes, error) {
+	_, res, err := s.listAlarms.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.ListAlarmsRes), nil
+}
+
+func (s *grpcServer) StateAt(ctx context.Context, req *grpcTwinsV1.StateAtReq) (*grpcTwinsV1.StateAtRes, error) {
+	_, res, err := s.stateAt.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.StateAtRes), nil
+}
+
+func (s *grpcServer) UpdateDesiredState(ctx context.Context, req *grpcTwinsV1.DesiredStateReq) (*grpcTwinsV1.DeltaRes, error) {
+	_, res, err := s.updateDesiredState.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.DeltaRes), nil
+}
+
+func (s *grpcServer) ViewDesiredState(ctx context.Context, req *grpcTwinsV1.TwinReq) (*grpcTwinsV1.DesiredStateRes, error) {
+	_, res, err := s.viewDesiredState.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.DesiredStateRes), nil
+}
+
+func (s *grpcServer) ViewDelta(ctx context.Context, req *grpcTwinsV1.TwinReq) (*grpcTwinsV1.DeltaRes, error) {
+	_, res, err := s.viewDelta.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.DeltaRes), nil
+}
+
+func (s *grpcServer) WatchStates(req *grpcTwinsV1.WatchStatesReq, stream grpcTwinsV1.TwinsService_WatchStatesServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	res, err := s.watchStates(ctx, decodeWatchStatesRequest(ctx, req))
+	if err != nil {
+		return encodeError(err)
+	}
+
+	for ev := range res.(watchStatesRes).events {
+		msg, err := toStreamEvent(ev)
+		if err != nil {
+			return encodeError(err)
+		}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *grpcServer) AddTemplate(ctx context.Context, req *grpcTwinsV1.AddTemplateReq) (*grpcTwinsV1.TemplateRes, error) {
+	_, res, err := s.addTemplate.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.TemplateRes), nil
+}
+
+func (s *grpcServer) UpdateTemplate(ctx context.Context, req *grpcTwinsV1.UpdateTemplateReq) (*grpcTwinsV1.UpdateTemplateRes, error) {
+	_, res, err := s.updateTemplate.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.UpdateTemplateRes), nil
+}
+
+func (s *grpcServer) ViewTemplate(ctx context.Context, req *grpcTwinsV1.TemplateReq) (*grpcTwinsV1.TemplateRes, error) {
+	_, res, err := s.viewTemplate.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.TemplateRes), nil
+}
+
+func (s *grpcServer) ListTemplates(ctx context.Context, req *grpcTwinsV1.ListTemplatesReq) (*grpcTwinsV1.ListTemplatesRes, error) {
+	_, res, err := s.listTemplates.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.ListTemplatesRes), nil
+}
+
+func (s *grpcServer) RemoveTemplate(ctx context.Context, req *grpcTwinsV1.TemplateReq) (*grpcTwinsV1.RemoveTemplateRes, error) {
+	_, res, err := s.removeTemplate.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.RemoveTemplateRes), nil
+}
+
+func (s *grpcServer) RolloutTemplate(ctx context.Context, req *grpcTwinsV1.RolloutTemplateReq) (*grpcTwinsV1.RolloutTemplateRes, error) {
+	_, res, err := s.rolloutTemplate.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.RolloutTemplateRes), nil
+}
+
+func (s *grpcServer) ExportTwins(ctx context.Context, req *grpcTwinsV1.ExportTwinsReq) (*grpcTwinsV1.ExportTwinsRes, error) {
+	_, res, err := s.exportTwins.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.ExportTwinsRes), nil
+}
+
+func (s *grpcServer) ImportTwins(ctx context.Context, req *grpcTwinsV1.ImportTwinsReq) (*grpcTwinsV1.ImportTwinsRes, error) {
+	_, res, err := s.importTwins.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, encodeError(err)
+	}
+	return res.(*grpcTwinsV1.ImportTwinsRes), nil
+}
+
+func decodeAddTwinRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.AddTwinReq)
+	return addTwinReq{
+		token:      extractToken(ctx),
+		domainID:   req.GetDomainId(),
+		name:       req.GetName(),
+		definition: toDefinition(req.GetDefinition()),
+		template:   req.GetTemplateId(),
+		bindings:   req.GetBindings(),
+		metadata:   toMetadata(req.GetMetadata()),
+	}, nil
+}
+
+func decodeUpdateTwinRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.UpdateTwinReq)
+	revision := twins.AnyRevision
+	if req.Revision != nil {
+		revision = int(req.GetRevision())
+	}
+
+	return updateTwinReq{
+		token:      extractToken(ctx),
+		domainID:   req.GetDomainId(),
+		id:         req.GetId(),
+		revision:   revision,
+		name:       req.GetName(),
+		definition: toDefinition(req.GetDefinition()),
+		metadata:   toMetadata(req.GetMetadata()),
+	}, nil
+}
+
+func decodeTwinRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.TwinReq)
+	return entityReq{
+		token:    extractToken(ctx),
+		domainID: req.GetDomainId(),
+		id:       req.GetId(),
+	}, nil
+}
+
+func decodeRemoveTwinRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.RemoveTwinReq)
+	revision := twins.AnyRevision
+	if req.Revision != nil {
+		revision = int(req.GetRevision())
+	}
+
+	return removeTwinReq{
+		token:    extractToken(ctx),
+		domainID: req.GetDomainId(),
+		id:       req.GetId(),
+		cascade:  req.GetCascade(),
+		revision: revision,
+	}, nil
+}
+
+func decodeListTwinsRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.ListTwinsReq)
+	return listTwinsReq{
+		token:    extractToken(ctx),
+		domainID: req.GetDomainId(),
+		offset:   req.GetOffset(),
+		limit:    limit(req.GetLimit()),
+		name:     req.GetName(),
+		parentID: req.GetParentId(),
+		metadata: toMetadata(req.GetMetadata()),
+	}, nil
+}
+
+func decodeChildRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.ChildReq)
+	return childReq{
+		token:    extractToken(ctx),
+		domainID: req.GetDomainId(),
+		parentID: req.GetParentId(),
+		childID:  req.GetChildId(),
+	}, nil
+}
+
+func decodeShareTwinRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.ShareTwinReq)
+	return shareTwinReq{
+		token:    extractToken(ctx),
+		domainID: req.GetDomainId(),
+		id:       req.GetId(),
+		role:     req.GetRole(),
+		userIDs:  req.GetUserIds(),
+	}, nil
+}
+
+func decodeListStatesRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.ListStatesReq)
+	filter := twins.StateFilter{
+		From:      toTime(req.GetFrom()),
+		To:        toTime(req.GetTo()),
+		Attribute: req.GetAttribute(),
+		Dir:       req.GetDir(),
+	}
+	if filter.Dir == "" {
+		filter.Dir = api.AscDir
+	}
+	if req.Definition != nil {
+		def := int(req.GetDefinition())
+		filter.Definition = &def
+	}
+
+	return listStatesReq{
+		token:    extractToken(ctx),
+		domainID: req.GetDomainId(),
+		twinID:   req.GetTwinId(),
+		offset:   req.GetOffset(),
+		limit:    limit(req.GetLimit()),
+		filter:   filter,
+	}, nil
+}
+
+func decodeListAlarmsRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.ListAlarmsReq)
+	return listAlarmsReq{
+		token:    extractToken(ctx),
+		domainID: req.GetDomainId(),
+		twinID:   req.GetTwinId(),
+		offset:   req.GetOffset(),
+		limit:    limit(req.GetLimit()),
+		filter: twins.AlarmFilter{
+			Attribute: req.GetAttribute(),
+			Severity:  req.GetSeverity(),
+			Status:    req.GetStatus(),
+		},
+	}, nil
+}
+
+func decodeStateAtRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.StateAtReq)
+	return stateAtReq{
+		token:    extractToken(ctx),
+		domainID: req.GetDomainId(),
+		twinID:   req.GetTwinId(),
+		at:       toTime(req.GetTime()),
+	}, nil
+}
+
+func decodeDesiredStateRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.DesiredStateReq)
+	return desiredStateReq{
+		token:    extractToken(ctx),
+		domainID: req.GetDomainId(),
+		twinID:   req.GetTwinId(),
+		payload:  toMetadata(req.GetPayload()),
+	}, nil
+}
+
+func decodeWatchStatesRequest(ctx context.Context, req *grpcTwinsV1.WatchStatesReq) watchStatesReq {
+	return watchStatesReq{
+		token:      extractToken(ctx),
+		domainID:   req.GetDomainId(),
+		twinIDs:    req.GetTwinIds(),
+		attributes: req.GetAttributes(),
+	}
+}
+
+func decodeAddTemplateRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.AddTemplateReq)
+	return addTemplateReq{
+		token:      extractToken(ctx),
+		domainID:   req.GetDomainId(),
+		name:       req.GetName(),
+		definition: toDefinition(req.GetDefinition()),
+		metadata:   toMetadata(req.GetMetadata()),
+	}, nil
+}
+
+func decodeUpdateTemplateRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.UpdateTemplateReq)
+	return updateTemplateReq{
+		token:      extractToken(ctx),
+		domainID:   req.GetDomainId(),
+		id:         req.GetId(),
+		name:       req.GetName(),
+		definition: toDefinition(req.GetDefinition()),
+		metadata:   toMetadata(req.GetMetadata()),
+	}, nil
+}
+
+func decodeTemplateRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.TemplateReq)
+	return entityReq{
+		token:    extractToken(ctx),
+		domainID: req.GetDomainId(),
+		id:       req.GetId(),
+	}, nil
+}
+
+func decodeListTemplatesRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.ListTemplatesReq)
+	return listTemplatesReq{
+		token:    extractToken(ctx),
+		domainID: req.GetDomainId(),
+		offset:   req.GetOffset(),
+		limit:    limit(req.GetLimit()),
+		name:     req.GetName(),
+	}, nil
+}
+
+func decodeRolloutTemplateRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.RolloutTemplateReq)
+	return rolloutTemplateReq{
+		token:    extractToken(ctx),
+		domainID: req.GetDomainId(),
+		id:       req.GetId(),
+		bindings: req.GetBindings(),
+	}, nil
+}
+
+func decodeExportTwinsRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.ExportTwinsReq)
+	return exportTwinsReq{
+		token:    extractToken(ctx),
+		domainID: req.GetDomainId(),
+		ownerID:  req.GetOwnerId(),
+	}, nil
+}
+
+func decodeImportTwinsRequest(ctx context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*grpcTwinsV1.ImportTwinsReq)
+	tws := make([]twins.Twin, 0, len(req.GetTwins()))
+	for _, tw := range req.GetTwins() {
+		tws = append(tws, fromTwin(tw))
+	}
+	return importTwinsReq{
+		token:    extractToken(ctx),
+		domainID: req.GetDomainId(),
+		twins:    tws,
+		remap:    req.GetRemapIds(),
+		dryRun:   req.GetDryRun(),
+	}, nil
+}
+
+func encodeTwinResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(twinRes)
+	twin, err := toTwin(res.twin)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpcTwinsV1.TwinRes{Twin: twin}, nil
+}
+
+func encodeUpdateTwinResponse(_ context.Context, _ interface{}) (interface{}, error) {
+	return &grpcTwinsV1.UpdateTwinRes{}, nil
+}
+
+func encodeRemoveTwinResponse(_ context.Context, _ interface{}) (interface{}, error) {
+	return &grpcTwinsV1.RemoveTwinRes{}, nil
+}
+
+func encodeListTwinsResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(twinsPageRes)
+	tws := []*grpcTwinsV1.Twin{}
+	for _, tw := range res.page.Twins {
+		twin, err := toTwin(tw)
+		if err != nil {
+			return nil, err
+		}
+		tws = append(tws, twin)
+	}
+
+	return &grpcTwinsV1.ListTwinsRes{
+		Total:  res.page.Total,
+		Offset: res.page.Offset,
+		Limit:  res.page.Limit,
+		Twins:  tws,
+	}, nil
+}
+
+func encodeChildResponse(_ context.Context, _ interface{}) (interface{}, error) {
+	return &grpcTwinsV1.ChildRes{}, nil
+}
+
+func encodeSubtreeResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(subtreeRes)
+	root, err := toTwinNode(res.root)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpcTwinsV1.SubtreeRes{Root: root}, nil
+}
+
+func encodeCompositeStateResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(compositeStateRes)
+	cs, err := toCompositeState(res.state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpcTwinsV1.CompositeStateRes{State: cs}, nil
+}
+
+func encodeShareTwinResponse(_ context.Context, _ interface{}) (interface{}, error) {
+	return &grpcTwinsV1.ShareTwinRes{}, nil
+}
+
+func encodeListStatesResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(statesPageRes)
+	states := []*grpcTwinsV1.State{}
+	for _, st := range res.page.States {
+		state, err := toState(st)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+
+	return &grpcTwinsV1.ListStatesRes{
+		Total:  res.page.Total,
+		Offset: res.page.Offset,
+		Limit:  res.page.Limit,
+		States: states,
+	}, nil
+}
+
+func encodeListAlarmsResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(alarmsPageRes)
+	alarms := []*grpcTwinsV1.Alarm{}
+	for _, a := range res.page.Alarms {
+		alarm := &grpcTwinsV1.Alarm{
+			Id:        a.ID,
+			TwinId:    a.TwinID,
+			Attribute: a.Attribute,
+			Rule:      a.Rule,
+			Condition: a.Condition,
+			Severity:  a.Severity,
+			Status:    a.Status,
+			Value:     a.Value,
+			Raised:    toTimestamp(a.Raised),
+		}
+		if a.Cleared != nil {
+			alarm.Cleared = toTimestamp(*a.Cleared)
+		}
+		alarms = append(alarms, alarm)
+	}
+
+	return &grpcTwinsV1.ListAlarmsRes{
+		Total:  res.page.Total,
+		Offset: res.page.Offset,
+		Limit:  res.page.Limit,
+		Alarms: alarms,
+	}, nil
+}
+
+func encodeStateAtResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(stateAtRes)
+	state, err := toState(res.state)
+	if err != nil {
+		return nil, err
+	}
+	def, err := fromDefinition(res.definition)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpcTwinsV1.StateAtRes{State: state, Definition: def}, nil
+}
+
+func encodeDesiredStateResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(desiredStateRes)
+	payload, err := toStruct(res.state.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpcTwinsV1.DesiredStateRes{
+		DesiredState: &grpcTwinsV1.DesiredState{
+			TwinId:  res.state.TwinID,
+			Updated: toTimestamp(res.state.Updated),
+			Payload: payload,
+		},
+	}, nil
+}
+
+func encodeDeltaResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(deltaRes)
+	delta, err := toStruct(res.delta)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpcTwinsV1.DeltaRes{Delta: delta}, nil
+}
+
+func encodeTemplateResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(templateRes)
+	tmpl, err := toTemplate(res.template)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpcTwinsV1.TemplateRes{Template: tmpl}, nil
+}
+
+func encodeUpdateTemplateResponse(_ context.Context, _ interface{}) (interface{}, error) {
+	return &grpcTwinsV1.UpdateTemplateRes{}, nil
+}
+
+func encodeListTemplatesResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(templatesPageRes)
+	tmpls := []*grpcTwinsV1.Template{}
+	for _, t := range res.page.Templates {
+		tmpl, err := toTemplate(t)
+		if err != nil {
+			return nil, err
+		}
+		tmpls = append(tmpls, tmpl)
+	}
+
+	return &grpcTwinsV1.ListTemplatesRes{
+		Total:     res.page.Total,
+		Offset:    res.page.Offset,
+		Limit:     res.page.Limit,
+		Templates: tmpls,
+	}, nil
+}
+
+func encodeRemoveTemplateResponse(_ context.Context, _ interface{}) (interface{}, error) {
+	return &grpcTwinsV1.RemoveTemplateRes{}, nil
+}
+
+func encodeRolloutTemplateResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(rolloutRes)
+	return &grpcTwinsV1.RolloutTemplateRes{
+		TemplateId: res.rollout.Template,
+		Revision:   int32(res.rollout.Revision),
+		Updated:    res.rollout.Updated,
+		Skipped:    res.rollout.Skipped,
+	}, nil
+}
+
+func encodeExportTwinsResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(exportRes)
+	tws := []*grpcTwinsV1.Twin{}
+	for _, tw := range res.twins {
+		twin, err := toTwin(tw)
+		if err != nil {
+			return nil, err
+		}
+		tws = append(tws, twin)
+	}
+
+	return &grpcTwinsV1.ExportTwinsRes{Twins: tws}, nil
+}
+
+func encodeImportTwinsResponse(_ context.Context, grpcRes interface{}) (interface{}, error) {
+	res := grpcRes.(importRes)
+	return &grpcTwinsV1.ImportTwinsRes{
+		DryRun: res.imp.DryRun,
+		Ids:    res.imp.IDs,
+	}, nil
+}
+
+// extractToken returns the bearer token of the authorization metadata.
+func extractToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(authzKey)
+	if len(values) == 0 || !strings.HasPrefix(values[0], apiutil.BearerPrefix) {
+		return ""
+	}
+
+	return strings.TrimPrefix(values[0], apiutil.BearerPrefix)
+}
+
+func limit(l uint64) uint64 {
+	if l == 0 {
+		return defLimit
+	}
+	return l
+}
+
+func encodeError(err error) error {
+	switch {
+	case errors.Contains(err, nil):
+		return nil
+	case errors.Contains(err, svcerr.ErrAuthentication),
+		errors.Contains(err, apiutil.ErrBearerToken):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Contains(err, apiutil.ErrValidation),
+		errors.Contains(err, svcerr.ErrMalformedEntity),
+		errors.Contains(err, errors.ErrMalformedEntity),
+		errors.Contains(err, svcerr.ErrInvalidRole):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Contains(err, svcerr.ErrAuthorization),
+		errors.Contains(err, svcerr.ErrDomainAuthorization):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Contains(err, svcerr.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	// Twins are updated only if their revision did not change meanwhile.
+	case errors.Contains(err, twins.ErrRevisionConflict),
+		errors.Contains(err, svcerr.ErrUpdateEntity) && errors.Contains(err, repoerr.ErrConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Contains(err, svcerr.ErrConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func toTwin(tw twins.Twin) (*grpcTwinsV1.Twin, error) {
+	defs := []*grpcTwinsV1.Definition{}
+	for _, d := range tw.Definitions {
+		def, err := fromDefinition(d)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+	md, err := toStruct(tw.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpcTwinsV1.Twin{
+		Id:               tw.ID,
+		Owner:            tw.Owner,
+		DomainId:         tw.Domain,
+		ParentId:         tw.Parent,
+		TemplateId:       tw.Template,
+		TemplateRevision: int32(tw.TemplateRevision),
+		Bindings:         tw.Bindings,
+		Name:             tw.Name,
+		Revision:         int32(tw.Revision),
+		Created:          toTimestamp(tw.Created),
+		Updated:          toTimestamp(tw.Updated),
+		Definitions:      defs,
+		Metadata:         md,
+		Stale:            tw.Stale,
+	}, nil
+}
+
+// fromTwin converts the exported twin back to the twin. The ownership and
+// the sharing of the twin are assigned on import.
+func fromTwin(tw *grpcTwinsV1.Twin) twins.Twin {
+	defs := []twins.Definition{}
+	for _, d := range tw.GetDefinitions() {
+		defs = append(defs, toDefinition(d))
+	}
+
+	return twins.Twin{
+		ID:               tw.GetId(),
+		Parent:           tw.GetParentId(),
+		Template:         tw.GetTemplateId(),
+		TemplateRevision: int(tw.GetTemplateRevision()),
+		Bindings:         tw.GetBindings(),
+		Name:             tw.GetName(),
+		Created:          toTime(tw.GetCreated()),
+		Updated:          toTime(tw.GetUpdated()),
+		Definitions:      defs,
+		Metadata:         toMetadata(tw.GetMetadata()),
+	}
+}
+
+func toTwinNode(node twins.TwinNode) (*grpcTwinsV1.TwinNode, error) {
+	twin, err := toTwin(node.Twin)
+	if err != nil {
+		return nil, err
+	}
+	res := &grpcTwinsV1.TwinNode{Twin: twin}
+	for _, ch := range node.Children {
+		child, err := toTwinNode(ch)
+		if err != nil {
+			return nil, err
+		}
+		res.Children = append(res.Children, child)
+	}
+
+	return res, nil
+}
+
+func toState(st twins.State) (*grpcTwinsV1.State, error) {
+	payload, err := toStruct(st.Payload)
+	if err != nil {
+		return nil, err
+	}
+	updated := make(map[string]*timestamppb.Timestamp, len(st.Updated))
+	for name, t := range st.Updated {
+		updated[name] = toTimestamp(t)
+	}
+
+	return &grpcTwinsV1.State{
+		TwinId:     st.TwinID,
+		Id:         st.ID,
+		Definition: int32(st.Definition),
+		Created:    toTimestamp(st.Created),
+		Payload:    payload,
+		Updated:    updated,
+		Stale:      st.Stale,
+	}, nil
+}
+
+func toCompositeState(cs twins.CompositeState) (*grpcTwinsV1.CompositeState, error) {
+	res := &grpcTwinsV1.CompositeState{
+		TwinId:  cs.TwinID,
+		Name:    cs.Name,
+		Updated: toTimestamp(cs.Updated),
+	}
+	// Twins which have not reported any state yet are left without one.
+	if cs.State.Payload != nil {
+		state, err := toState(cs.State)
+		if err != nil {
+			return nil, err
+		}
+		res.State = state
+	}
+	for _, ch := range cs.Children {
+		child, err := toCompositeState(ch)
+		if err != nil {
+			return nil, err
+		}
+		res.Children = append(res.Children, child)
+	}
+
+	return res, nil
+}
+
+func toTemplate(tmpl twins.Template) (*grpcTwinsV1.Template, error) {
+	def, err := fromDefinition(tmpl.Definition)
+	if err != nil {
+		return nil, err
+	}
+	md, err := toStruct(tmpl.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpcTwinsV1.Template{
+		Id:         tmpl.ID,
+		Owner:      tmpl.Owner,
+		DomainId:   tmpl.Domain,
+		Name:       tmpl.Name,
+		Revision:   int32(tmpl.Revision),
+		Created:    toTimestamp(tmpl.Created),
+		Updated:    toTimestamp(tmpl.Updated),
+		Definition: def,
+		Parameters: tmpl.Parameters(),
+		Metadata:   md,
+	}, nil
+}
+
+func toStreamEvent(ev twins.StreamEvent) (*grpcTwinsV1.StreamEvent, error) {
+	res := &grpcTwinsV1.StreamEvent{
+		Type:   ev.Type,
+		TwinId: ev.TwinID,
+	}
+	if ev.State != nil {
+		state, err := toState(*ev.State)
+		if err != nil {
+			return nil, err
+		}
+		res.State = state
+	}
+	if ev.Definition != nil {
+		def, err := fromDefinition(*ev.Definition)
+		if err != nil {
+			return nil, err
+		}
+		res.Definition = def
+	}
+
+	return res, nil
+}
+
+func toDefinition(def *grpcTwinsV1.Definition) twins.Definition {
+	res := twins.Definition{
+		ID:      int(def.GetId()),
+		Created: toTime(def.GetCreated()),
+		Delta:   def.GetDelta(),
+	}
+	if r := def.GetRetention(); r != nil {
+		res.Retention = &twins.Retention{
+			MaxAge:     r.GetMaxAge(),
+			CompactAge: r.GetCompactAge(),
+			Bucket:     r.GetBucket(),
+		}
+	}
+	for _, attr := range def.GetAttributes() {
+		a := twins.Attribute{
+			Name:           attr.GetName(),
+			Channel:        attr.GetChannel(),
+			Subtopic:       attr.GetSubtopic(),
+			Path:           attr.GetPath(),
+			TimePath:       attr.GetTimePath(),
+			Expression:     attr.GetExpression(),
+			Type:           attr.GetType(),
+			Unit:           attr.GetUnit(),
+			Min:            attr.Min,
+			Max:            attr.Max,
+			UpdateInterval: attr.GetUpdateInterval(),
+			PersistState:   attr.GetPersistState(),
+		}
+		if p := attr.GetPersistence(); p != nil {
+			a.Persistence = &twins.Persistence{
+				Policy:   p.GetPolicy(),
+				Delta:    p.GetDelta(),
+				Deadband: p.GetDeadband(),
+				Percent:  p.GetPercent(),
+			}
+		}
+		for _, v := range attr.GetEnum() {
+			a.Enum = append(a.Enum, v.AsInterface())
+		}
+		for _, rule := range attr.GetAlarms() {
+			a.Alarms = append(a.Alarms, twins.AlarmRule{
+				Name:       rule.GetName(),
+				Condition:  rule.GetCondition(),
+				Severity:   rule.GetSeverity(),
+				Threshold:  rule.GetThreshold(),
+				Min:        rule.Min,
+				Max:        rule.Max,
+				Duration:   rule.GetDuration(),
+				Hysteresis: rule.GetHysteresis(),
+			})
+		}
+		res.Attributes = append(res.Attributes, a)
+	}
+
+	return res
+}
+
+func fromDefinition(def twins.Definition) (*grpcTwinsV1.Definition, error) {
+	res := &grpcTwinsV1.Definition{
+		Id:      int32(def.ID),
+		Created: toTimestamp(def.Created),
+		Delta:   def.Delta,
+	}
+	if def.Retention != nil {
+		res.Retention = &grpcTwinsV1.Retention{
+			MaxAge:     def.Retention.MaxAge,
+			CompactAge: def.Retention.CompactAge,
+			Bucket:     def.Retention.Bucket,
+		}
+	}
+	for _, attr := range def.Attributes {
+		a := &grpcTwinsV1.Attribute{
+			Name:           attr.Name,
+			Channel:        attr.Channel,
+			Subtopic:       attr.Subtopic,
+			Path:           attr.Path,
+			TimePath:       attr.TimePath,
+			Expression:     attr.Expression,
+			Type:           attr.Type,
+			Unit:           attr.Unit,
+			Min:            attr.Min,
+			Max:            attr.Max,
+			UpdateInterval: attr.UpdateInterval,
+			PersistState:   attr.PersistState,
+		}
+		if p := attr.Persistence; p != nil {
+			a.Persistence = &grpcTwinsV1.Persistence{
+				Policy:   p.Policy,
+				Delta:    p.Delta,
+				Deadband: p.Deadband,
+				Percent:  p.Percent,
+			}
+		}
+		for _, v := range attr.Enum {
+			val, err := structpb.NewValue(v)
+			if err != nil {
+				return nil, err
+			}
+			a.Enum = append(a.Enum, val)
+		}
+		for _, rule := range attr.Alarms {
+			a.Alarms = append(a.Alarms, &grpcTwinsV1.AlarmRule{
+				Name:       rule.Name,
+				Condition:  rule.Condition,
+				Severity:   rule.Severity,
+				Threshold:  rule.Threshold,
+				Min:        rule.Min,
+				Max:        rule.Max,
+				Duration:   rule.Duration,
+				Hysteresis: rule.Hysteresis,
+			})
+		}
+		res.Attributes = append(res.Attributes, a)
+	}
+
+	return res, nil
+}
+
+// toMetadata converts the struct to the map, leaving the missing struct
+// unset.
+func toMetadata(s *structpb.Struct) map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
+
+// toStruct converts the map to the struct. The state payloads hold the
+// pointers to the SenML record values, which are dereferenced since the
+// struct does not accept them.
+func toStruct(m map[string]interface{}) (*structpb.Struct, error) {
+	if m == nil {
+		return nil, nil
+	}
+	fields := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		fields[k] = toValue(v)
+	}
+	return structpb.NewStruct(fields)
+}
+
+func toValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case *float64:
+		if v != nil {
+			return *v
+		}
+		return nil
+	case *string:
+		if v != nil {
+			return *v
+		}
+		return nil
+	case *bool:
+		if v != nil {
+			return *v
+		}
+		return nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = toValue(item)
+		}
+		return m
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = toValue(item)
+		}
+		return items
+	}
+	return val
+}
+
+// toTime converts the timestamp to time, leaving the missing timestamp as
+// zero time.
+func toTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
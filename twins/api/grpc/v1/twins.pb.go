@@ -0,0 +1,4091 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v6.30.2
+// source: twins/api/grpc/v1/twins.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AlarmRule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Condition     string                 `protobuf:"bytes,2,opt,name=condition,proto3" json:"condition,omitempty"`
+	Severity      string                 `protobuf:"bytes,3,opt,name=severity,proto3" json:"severity,omitempty"`
+	Threshold     float64                `protobuf:"fixed64,4,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	Min           *float64               `protobuf:"fixed64,5,opt,name=min,proto3,oneof" json:"min,omitempty"`
+	Max           *float64               `protobuf:"fixed64,6,opt,name=max,proto3,oneof" json:"max,omitempty"`
+	Duration      int64                  `protobuf:"varint,7,opt,name=duration,proto3" json:"duration,omitempty"`
+	Hysteresis    float64                `protobuf:"fixed64,8,opt,name=hysteresis,proto3" json:"hysteresis,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AlarmRule) Reset() {
+	*x = AlarmRule{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AlarmRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AlarmRule) ProtoMessage() {}
+
+func (x *AlarmRule) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AlarmRule.ProtoReflect.Descriptor instead.
+func (*AlarmRule) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AlarmRule) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AlarmRule) GetCondition() string {
+	if x != nil {
+		return x.Condition
+	}
+	return ""
+}
+
+func (x *AlarmRule) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *AlarmRule) GetThreshold() float64 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *AlarmRule) GetMin() float64 {
+	if x != nil && x.Min != nil {
+		return *x.Min
+	}
+	return 0
+}
+
+func (x *AlarmRule) GetMax() float64 {
+	if x != nil && x.Max != nil {
+		return *x.Max
+	}
+	return 0
+}
+
+func (x *AlarmRule) GetDuration() int64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *AlarmRule) GetHysteresis() float64 {
+	if x != nil {
+		return x.Hysteresis
+	}
+	return 0
+}
+
+type Attribute struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Name           string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Channel        string                 `protobuf:"bytes,2,opt,name=channel,proto3" json:"channel,omitempty"`
+	Subtopic       string                 `protobuf:"bytes,3,opt,name=subtopic,proto3" json:"subtopic,omitempty"`
+	Path           string                 `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+	TimePath       string                 `protobuf:"bytes,5,opt,name=time_path,json=timePath,proto3" json:"time_path,omitempty"`
+	Expression     string                 `protobuf:"bytes,6,opt,name=expression,proto3" json:"expression,omitempty"`
+	Type           string                 `protobuf:"bytes,7,opt,name=type,proto3" json:"type,omitempty"`
+	Unit           string                 `protobuf:"bytes,8,opt,name=unit,proto3" json:"unit,omitempty"`
+	Min            *float64               `protobuf:"fixed64,9,opt,name=min,proto3,oneof" json:"min,omitempty"`
+	Max            *float64               `protobuf:"fixed64,10,opt,name=max,proto3,oneof" json:"max,omitempty"`
+	Enum           []*structpb.Value      `protobuf:"bytes,11,rep,name=enum,proto3" json:"enum,omitempty"`
+	Alarms         []*AlarmRule           `protobuf:"bytes,12,rep,name=alarms,proto3" json:"alarms,omitempty"`
+	UpdateInterval int64                  `protobuf:"varint,13,opt,name=update_interval,json=updateInterval,proto3" json:"update_interval,omitempty"`
+	PersistState   bool                   `protobuf:"varint,14,opt,name=persist_state,json=persistState,proto3" json:"persist_state,omitempty"`
+	Persistence    *Persistence           `protobuf:"bytes,15,opt,name=persistence,proto3" json:"persistence,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Attribute) Reset() {
+	*x = Attribute{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Attribute) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attribute) ProtoMessage() {}
+
+func (x *Attribute) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attribute.ProtoReflect.Descriptor instead.
+func (*Attribute) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Attribute) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Attribute) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *Attribute) GetSubtopic() string {
+	if x != nil {
+		return x.Subtopic
+	}
+	return ""
+}
+
+func (x *Attribute) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Attribute) GetTimePath() string {
+	if x != nil {
+		return x.TimePath
+	}
+	return ""
+}
+
+func (x *Attribute) GetExpression() string {
+	if x != nil {
+		return x.Expression
+	}
+	return ""
+}
+
+func (x *Attribute) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Attribute) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *Attribute) GetMin() float64 {
+	if x != nil && x.Min != nil {
+		return *x.Min
+	}
+	return 0
+}
+
+func (x *Attribute) GetMax() float64 {
+	if x != nil && x.Max != nil {
+		return *x.Max
+	}
+	return 0
+}
+
+func (x *Attribute) GetEnum() []*structpb.Value {
+	if x != nil {
+		return x.Enum
+	}
+	return nil
+}
+
+func (x *Attribute) GetAlarms() []*AlarmRule {
+	if x != nil {
+		return x.Alarms
+	}
+	return nil
+}
+
+func (x *Attribute) GetUpdateInterval() int64 {
+	if x != nil {
+		return x.UpdateInterval
+	}
+	return 0
+}
+
+func (x *Attribute) GetPersistState() bool {
+	if x != nil {
+		return x.PersistState
+	}
+	return false
+}
+
+func (x *Attribute) GetPersistence() *Persistence {
+	if x != nil {
+		return x.Persistence
+	}
+	return nil
+}
+
+type Persistence struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Policy        string                 `protobuf:"bytes,1,opt,name=policy,proto3" json:"policy,omitempty"`
+	Delta         int64                  `protobuf:"varint,2,opt,name=delta,proto3" json:"delta,omitempty"`
+	Deadband      float64                `protobuf:"fixed64,3,opt,name=deadband,proto3" json:"deadband,omitempty"`
+	Percent       bool                   `protobuf:"varint,4,opt,name=percent,proto3" json:"percent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Persistence) Reset() {
+	*x = Persistence{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Persistence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Persistence) ProtoMessage() {}
+
+func (x *Persistence) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Persistence.ProtoReflect.Descriptor instead.
+func (*Persistence) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Persistence) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+func (x *Persistence) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *Persistence) GetDeadband() float64 {
+	if x != nil {
+		return x.Deadband
+	}
+	return 0
+}
+
+func (x *Persistence) GetPercent() bool {
+	if x != nil {
+		return x.Percent
+	}
+	return false
+}
+
+type Retention struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MaxAge        int64                  `protobuf:"varint,1,opt,name=max_age,json=maxAge,proto3" json:"max_age,omitempty"`
+	CompactAge    int64                  `protobuf:"varint,2,opt,name=compact_age,json=compactAge,proto3" json:"compact_age,omitempty"`
+	Bucket        string                 `protobuf:"bytes,3,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Retention) Reset() {
+	*x = Retention{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Retention) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Retention) ProtoMessage() {}
+
+func (x *Retention) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Retention.ProtoReflect.Descriptor instead.
+func (*Retention) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Retention) GetMaxAge() int64 {
+	if x != nil {
+		return x.MaxAge
+	}
+	return 0
+}
+
+func (x *Retention) GetCompactAge() int64 {
+	if x != nil {
+		return x.CompactAge
+	}
+	return 0
+}
+
+func (x *Retention) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+type Definition struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Created       *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created,proto3" json:"created,omitempty"`
+	Attributes    []*Attribute           `protobuf:"bytes,3,rep,name=attributes,proto3" json:"attributes,omitempty"`
+	Delta         int64                  `protobuf:"varint,4,opt,name=delta,proto3" json:"delta,omitempty"`
+	Retention     *Retention             `protobuf:"bytes,5,opt,name=retention,proto3" json:"retention,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Definition) Reset() {
+	*x = Definition{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Definition) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Definition) ProtoMessage() {}
+
+func (x *Definition) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Definition.ProtoReflect.Descriptor instead.
+func (*Definition) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Definition) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Definition) GetCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Created
+	}
+	return nil
+}
+
+func (x *Definition) GetAttributes() []*Attribute {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+func (x *Definition) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *Definition) GetRetention() *Retention {
+	if x != nil {
+		return x.Retention
+	}
+	return nil
+}
+
+type Twin struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Owner            string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	DomainId         string                 `protobuf:"bytes,3,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	ParentId         string                 `protobuf:"bytes,4,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	TemplateId       string                 `protobuf:"bytes,5,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	TemplateRevision int32                  `protobuf:"varint,6,opt,name=template_revision,json=templateRevision,proto3" json:"template_revision,omitempty"`
+	Bindings         map[string]string      `protobuf:"bytes,7,rep,name=bindings,proto3" json:"bindings,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Name             string                 `protobuf:"bytes,8,opt,name=name,proto3" json:"name,omitempty"`
+	Revision         int32                  `protobuf:"varint,9,opt,name=revision,proto3" json:"revision,omitempty"`
+	Created          *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=created,proto3" json:"created,omitempty"`
+	Updated          *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=updated,proto3" json:"updated,omitempty"`
+	Definitions      []*Definition          `protobuf:"bytes,12,rep,name=definitions,proto3" json:"definitions,omitempty"`
+	Metadata         *structpb.Struct       `protobuf:"bytes,13,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Stale            []string               `protobuf:"bytes,15,rep,name=stale,proto3" json:"stale,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Twin) Reset() {
+	*x = Twin{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Twin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Twin) ProtoMessage() {}
+
+func (x *Twin) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Twin.ProtoReflect.Descriptor instead.
+func (*Twin) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Twin) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Twin) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Twin) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *Twin) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+func (x *Twin) GetTemplateId() string {
+	if x != nil {
+		return x.TemplateId
+	}
+	return ""
+}
+
+func (x *Twin) GetTemplateRevision() int32 {
+	if x != nil {
+		return x.TemplateRevision
+	}
+	return 0
+}
+
+func (x *Twin) GetBindings() map[string]string {
+	if x != nil {
+		return x.Bindings
+	}
+	return nil
+}
+
+func (x *Twin) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Twin) GetRevision() int32 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *Twin) GetCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Created
+	}
+	return nil
+}
+
+func (x *Twin) GetUpdated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+func (x *Twin) GetDefinitions() []*Definition {
+	if x != nil {
+		return x.Definitions
+	}
+	return nil
+}
+
+func (x *Twin) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Twin) GetStale() []string {
+	if x != nil {
+		return x.Stale
+	}
+	return nil
+}
+
+type TwinNode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Twin          *Twin                  `protobuf:"bytes,1,opt,name=twin,proto3" json:"twin,omitempty"`
+	Children      []*TwinNode            `protobuf:"bytes,2,rep,name=children,proto3" json:"children,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TwinNode) Reset() {
+	*x = TwinNode{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TwinNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TwinNode) ProtoMessage() {}
+
+func (x *TwinNode) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TwinNode.ProtoReflect.Descriptor instead.
+func (*TwinNode) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TwinNode) GetTwin() *Twin {
+	if x != nil {
+		return x.Twin
+	}
+	return nil
+}
+
+func (x *TwinNode) GetChildren() []*TwinNode {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+type State struct {
+	state         protoimpl.MessageState            `protogen:"open.v1"`
+	TwinId        string                            `protobuf:"bytes,1,opt,name=twin_id,json=twinId,proto3" json:"twin_id,omitempty"`
+	Id            int64                             `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	Definition    int32                             `protobuf:"varint,3,opt,name=definition,proto3" json:"definition,omitempty"`
+	Created       *timestamppb.Timestamp            `protobuf:"bytes,4,opt,name=created,proto3" json:"created,omitempty"`
+	Payload       *structpb.Struct                  `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`
+	Updated       map[string]*timestamppb.Timestamp `protobuf:"bytes,6,rep,name=updated,proto3" json:"updated,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Stale         []string                          `protobuf:"bytes,7,rep,name=stale,proto3" json:"stale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *State) Reset() {
+	*x = State{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *State) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*State) ProtoMessage() {}
+
+func (x *State) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use State.ProtoReflect.Descriptor instead.
+func (*State) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *State) GetTwinId() string {
+	if x != nil {
+		return x.TwinId
+	}
+	return ""
+}
+
+func (x *State) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *State) GetDefinition() int32 {
+	if x != nil {
+		return x.Definition
+	}
+	return 0
+}
+
+func (x *State) GetCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Created
+	}
+	return nil
+}
+
+func (x *State) GetPayload() *structpb.Struct {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *State) GetUpdated() map[string]*timestamppb.Timestamp {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+func (x *State) GetStale() []string {
+	if x != nil {
+		return x.Stale
+	}
+	return nil
+}
+
+type CompositeState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TwinId        string                 `protobuf:"bytes,1,opt,name=twin_id,json=twinId,proto3" json:"twin_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	State         *State                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	Updated       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=updated,proto3" json:"updated,omitempty"`
+	Children      []*CompositeState      `protobuf:"bytes,5,rep,name=children,proto3" json:"children,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompositeState) Reset() {
+	*x = CompositeState{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompositeState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompositeState) ProtoMessage() {}
+
+func (x *CompositeState) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompositeState.ProtoReflect.Descriptor instead.
+func (*CompositeState) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CompositeState) GetTwinId() string {
+	if x != nil {
+		return x.TwinId
+	}
+	return ""
+}
+
+func (x *CompositeState) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CompositeState) GetState() *State {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *CompositeState) GetUpdated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+func (x *CompositeState) GetChildren() []*CompositeState {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+type DesiredState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TwinId        string                 `protobuf:"bytes,1,opt,name=twin_id,json=twinId,proto3" json:"twin_id,omitempty"`
+	Updated       *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=updated,proto3" json:"updated,omitempty"`
+	Payload       *structpb.Struct       `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DesiredState) Reset() {
+	*x = DesiredState{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DesiredState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DesiredState) ProtoMessage() {}
+
+func (x *DesiredState) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DesiredState.ProtoReflect.Descriptor instead.
+func (*DesiredState) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DesiredState) GetTwinId() string {
+	if x != nil {
+		return x.TwinId
+	}
+	return ""
+}
+
+func (x *DesiredState) GetUpdated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+func (x *DesiredState) GetPayload() *structpb.Struct {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+type Alarm struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	TwinId        string                 `protobuf:"bytes,2,opt,name=twin_id,json=twinId,proto3" json:"twin_id,omitempty"`
+	Attribute     string                 `protobuf:"bytes,3,opt,name=attribute,proto3" json:"attribute,omitempty"`
+	Rule          string                 `protobuf:"bytes,4,opt,name=rule,proto3" json:"rule,omitempty"`
+	Condition     string                 `protobuf:"bytes,5,opt,name=condition,proto3" json:"condition,omitempty"`
+	Severity      string                 `protobuf:"bytes,6,opt,name=severity,proto3" json:"severity,omitempty"`
+	Status        string                 `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	Value         float64                `protobuf:"fixed64,8,opt,name=value,proto3" json:"value,omitempty"`
+	Raised        *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=raised,proto3" json:"raised,omitempty"`
+	Cleared       *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=cleared,proto3" json:"cleared,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Alarm) Reset() {
+	*x = Alarm{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Alarm) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Alarm) ProtoMessage() {}
+
+func (x *Alarm) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Alarm.ProtoReflect.Descriptor instead.
+func (*Alarm) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Alarm) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Alarm) GetTwinId() string {
+	if x != nil {
+		return x.TwinId
+	}
+	return ""
+}
+
+func (x *Alarm) GetAttribute() string {
+	if x != nil {
+		return x.Attribute
+	}
+	return ""
+}
+
+func (x *Alarm) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *Alarm) GetCondition() string {
+	if x != nil {
+		return x.Condition
+	}
+	return ""
+}
+
+func (x *Alarm) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *Alarm) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Alarm) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *Alarm) GetRaised() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Raised
+	}
+	return nil
+}
+
+func (x *Alarm) GetCleared() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Cleared
+	}
+	return nil
+}
+
+type Template struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Owner         string                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	DomainId      string                 `protobuf:"bytes,3,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Revision      int32                  `protobuf:"varint,5,opt,name=revision,proto3" json:"revision,omitempty"`
+	Created       *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created,proto3" json:"created,omitempty"`
+	Updated       *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated,proto3" json:"updated,omitempty"`
+	Definition    *Definition            `protobuf:"bytes,8,opt,name=definition,proto3" json:"definition,omitempty"`
+	Parameters    []string               `protobuf:"bytes,9,rep,name=parameters,proto3" json:"parameters,omitempty"`
+	Metadata      *structpb.Struct       `protobuf:"bytes,10,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Template) Reset() {
+	*x = Template{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Template) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Template) ProtoMessage() {}
+
+func (x *Template) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Template.ProtoReflect.Descriptor instead.
+func (*Template) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Template) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Template) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *Template) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *Template) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Template) GetRevision() int32 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *Template) GetCreated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Created
+	}
+	return nil
+}
+
+func (x *Template) GetUpdated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+func (x *Template) GetDefinition() *Definition {
+	if x != nil {
+		return x.Definition
+	}
+	return nil
+}
+
+func (x *Template) GetParameters() []string {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *Template) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+// StreamEvent is the change of the twin state or definition. Depending on
+// the type, either the state or the definition is set.
+type StreamEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	TwinId        string                 `protobuf:"bytes,2,opt,name=twin_id,json=twinId,proto3" json:"twin_id,omitempty"`
+	State         *State                 `protobuf:"bytes,3,opt,name=state,proto3" json:"state,omitempty"`
+	Definition    *Definition            `protobuf:"bytes,4,opt,name=definition,proto3" json:"definition,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamEvent) Reset() {
+	*x = StreamEvent{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEvent) ProtoMessage() {}
+
+func (x *StreamEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEvent.ProtoReflect.Descriptor instead.
+func (*StreamEvent) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StreamEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *StreamEvent) GetTwinId() string {
+	if x != nil {
+		return x.TwinId
+	}
+	return ""
+}
+
+func (x *StreamEvent) GetState() *State {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *StreamEvent) GetDefinition() *Definition {
+	if x != nil {
+		return x.Definition
+	}
+	return nil
+}
+
+type AddTwinReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Definition    *Definition            `protobuf:"bytes,3,opt,name=definition,proto3" json:"definition,omitempty"`
+	TemplateId    string                 `protobuf:"bytes,4,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	Bindings      map[string]string      `protobuf:"bytes,5,rep,name=bindings,proto3" json:"bindings,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Metadata      *structpb.Struct       `protobuf:"bytes,6,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddTwinReq) Reset() {
+	*x = AddTwinReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddTwinReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddTwinReq) ProtoMessage() {}
+
+func (x *AddTwinReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddTwinReq.ProtoReflect.Descriptor instead.
+func (*AddTwinReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *AddTwinReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *AddTwinReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AddTwinReq) GetDefinition() *Definition {
+	if x != nil {
+		return x.Definition
+	}
+	return nil
+}
+
+func (x *AddTwinReq) GetTemplateId() string {
+	if x != nil {
+		return x.TemplateId
+	}
+	return ""
+}
+
+func (x *AddTwinReq) GetBindings() map[string]string {
+	if x != nil {
+		return x.Bindings
+	}
+	return nil
+}
+
+func (x *AddTwinReq) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type TwinReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TwinReq) Reset() {
+	*x = TwinReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TwinReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TwinReq) ProtoMessage() {}
+
+func (x *TwinReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TwinReq.ProtoReflect.Descriptor instead.
+func (*TwinReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TwinReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *TwinReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type TwinRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Twin          *Twin                  `protobuf:"bytes,1,opt,name=twin,proto3" json:"twin,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TwinRes) Reset() {
+	*x = TwinRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TwinRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TwinRes) ProtoMessage() {}
+
+func (x *TwinRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TwinRes.ProtoReflect.Descriptor instead.
+func (*TwinRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *TwinRes) GetTwin() *Twin {
+	if x != nil {
+		return x.Twin
+	}
+	return nil
+}
+
+// UpdateTwinReq updates the twin only if its current revision matches the
+// provided one. Without the revision, any revision matches.
+type UpdateTwinReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Definition    *Definition            `protobuf:"bytes,4,opt,name=definition,proto3" json:"definition,omitempty"`
+	Metadata      *structpb.Struct       `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	Revision      *int32                 `protobuf:"varint,6,opt,name=revision,proto3,oneof" json:"revision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateTwinReq) Reset() {
+	*x = UpdateTwinReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTwinReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTwinReq) ProtoMessage() {}
+
+func (x *UpdateTwinReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTwinReq.ProtoReflect.Descriptor instead.
+func (*UpdateTwinReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *UpdateTwinReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *UpdateTwinReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateTwinReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateTwinReq) GetDefinition() *Definition {
+	if x != nil {
+		return x.Definition
+	}
+	return nil
+}
+
+func (x *UpdateTwinReq) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *UpdateTwinReq) GetRevision() int32 {
+	if x != nil && x.Revision != nil {
+		return *x.Revision
+	}
+	return 0
+}
+
+type UpdateTwinRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateTwinRes) Reset() {
+	*x = UpdateTwinRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTwinRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTwinRes) ProtoMessage() {}
+
+func (x *UpdateTwinRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTwinRes.ProtoReflect.Descriptor instead.
+func (*UpdateTwinRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{17}
+}
+
+// RemoveTwinReq removes the twin only if its current revision matches the
+// provided one. Without the revision, any revision matches.
+type RemoveTwinReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Cascade       bool                   `protobuf:"varint,3,opt,name=cascade,proto3" json:"cascade,omitempty"`
+	Revision      *int32                 `protobuf:"varint,4,opt,name=revision,proto3,oneof" json:"revision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveTwinReq) Reset() {
+	*x = RemoveTwinReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveTwinReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveTwinReq) ProtoMessage() {}
+
+func (x *RemoveTwinReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveTwinReq.ProtoReflect.Descriptor instead.
+func (*RemoveTwinReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RemoveTwinReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *RemoveTwinReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RemoveTwinReq) GetCascade() bool {
+	if x != nil {
+		return x.Cascade
+	}
+	return false
+}
+
+func (x *RemoveTwinReq) GetRevision() int32 {
+	if x != nil && x.Revision != nil {
+		return *x.Revision
+	}
+	return 0
+}
+
+type RemoveTwinRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveTwinRes) Reset() {
+	*x = RemoveTwinRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveTwinRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveTwinRes) ProtoMessage() {}
+
+func (x *RemoveTwinRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveTwinRes.ProtoReflect.Descriptor instead.
+func (*RemoveTwinRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{19}
+}
+
+type ListTwinsReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit         uint64                 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	ParentId      string                 `protobuf:"bytes,5,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	Metadata      *structpb.Struct       `protobuf:"bytes,6,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTwinsReq) Reset() {
+	*x = ListTwinsReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTwinsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTwinsReq) ProtoMessage() {}
+
+func (x *ListTwinsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTwinsReq.ProtoReflect.Descriptor instead.
+func (*ListTwinsReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListTwinsReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *ListTwinsReq) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListTwinsReq) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListTwinsReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ListTwinsReq) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+func (x *ListTwinsReq) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type ListTwinsRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         uint64                 `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit         uint64                 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Twins         []*Twin                `protobuf:"bytes,4,rep,name=twins,proto3" json:"twins,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTwinsRes) Reset() {
+	*x = ListTwinsRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTwinsRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTwinsRes) ProtoMessage() {}
+
+func (x *ListTwinsRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTwinsRes.ProtoReflect.Descriptor instead.
+func (*ListTwinsRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListTwinsRes) GetTotal() uint64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListTwinsRes) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListTwinsRes) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListTwinsRes) GetTwins() []*Twin {
+	if x != nil {
+		return x.Twins
+	}
+	return nil
+}
+
+type ChildReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	ParentId      string                 `protobuf:"bytes,2,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	ChildId       string                 `protobuf:"bytes,3,opt,name=child_id,json=childId,proto3" json:"child_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChildReq) Reset() {
+	*x = ChildReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChildReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChildReq) ProtoMessage() {}
+
+func (x *ChildReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChildReq.ProtoReflect.Descriptor instead.
+func (*ChildReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ChildReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *ChildReq) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+func (x *ChildReq) GetChildId() string {
+	if x != nil {
+		return x.ChildId
+	}
+	return ""
+}
+
+type ChildRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChildRes) Reset() {
+	*x = ChildRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChildRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChildRes) ProtoMessage() {}
+
+func (x *ChildRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChildRes.ProtoReflect.Descriptor instead.
+func (*ChildRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{23}
+}
+
+type SubtreeRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Root          *TwinNode              `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubtreeRes) Reset() {
+	*x = SubtreeRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubtreeRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubtreeRes) ProtoMessage() {}
+
+func (x *SubtreeRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubtreeRes.ProtoReflect.Descriptor instead.
+func (*SubtreeRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SubtreeRes) GetRoot() *TwinNode {
+	if x != nil {
+		return x.Root
+	}
+	return nil
+}
+
+type CompositeStateRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         *CompositeState        `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompositeStateRes) Reset() {
+	*x = CompositeStateRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompositeStateRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompositeStateRes) ProtoMessage() {}
+
+func (x *CompositeStateRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompositeStateRes.ProtoReflect.Descriptor instead.
+func (*CompositeStateRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *CompositeStateRes) GetState() *CompositeState {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+type ShareTwinReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Role          string                 `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"`
+	UserIds       []string               `protobuf:"bytes,4,rep,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShareTwinReq) Reset() {
+	*x = ShareTwinReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShareTwinReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShareTwinReq) ProtoMessage() {}
+
+func (x *ShareTwinReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShareTwinReq.ProtoReflect.Descriptor instead.
+func (*ShareTwinReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ShareTwinReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *ShareTwinReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ShareTwinReq) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *ShareTwinReq) GetUserIds() []string {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+type ShareTwinRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShareTwinRes) Reset() {
+	*x = ShareTwinRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShareTwinRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShareTwinRes) ProtoMessage() {}
+
+func (x *ShareTwinRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShareTwinRes.ProtoReflect.Descriptor instead.
+func (*ShareTwinRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{27}
+}
+
+type ListStatesReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	TwinId        string                 `protobuf:"bytes,2,opt,name=twin_id,json=twinId,proto3" json:"twin_id,omitempty"`
+	Offset        uint64                 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit         uint64                 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=to,proto3" json:"to,omitempty"`
+	Definition    *int32                 `protobuf:"varint,7,opt,name=definition,proto3,oneof" json:"definition,omitempty"`
+	Attribute     string                 `protobuf:"bytes,8,opt,name=attribute,proto3" json:"attribute,omitempty"`
+	Dir           string                 `protobuf:"bytes,9,opt,name=dir,proto3" json:"dir,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStatesReq) Reset() {
+	*x = ListStatesReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStatesReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStatesReq) ProtoMessage() {}
+
+func (x *ListStatesReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStatesReq.ProtoReflect.Descriptor instead.
+func (*ListStatesReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ListStatesReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *ListStatesReq) GetTwinId() string {
+	if x != nil {
+		return x.TwinId
+	}
+	return ""
+}
+
+func (x *ListStatesReq) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListStatesReq) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListStatesReq) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *ListStatesReq) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *ListStatesReq) GetDefinition() int32 {
+	if x != nil && x.Definition != nil {
+		return *x.Definition
+	}
+	return 0
+}
+
+func (x *ListStatesReq) GetAttribute() string {
+	if x != nil {
+		return x.Attribute
+	}
+	return ""
+}
+
+func (x *ListStatesReq) GetDir() string {
+	if x != nil {
+		return x.Dir
+	}
+	return ""
+}
+
+type ListStatesRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         uint64                 `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit         uint64                 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	States        []*State               `protobuf:"bytes,4,rep,name=states,proto3" json:"states,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStatesRes) Reset() {
+	*x = ListStatesRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStatesRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStatesRes) ProtoMessage() {}
+
+func (x *ListStatesRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStatesRes.ProtoReflect.Descriptor instead.
+func (*ListStatesRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ListStatesRes) GetTotal() uint64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListStatesRes) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListStatesRes) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListStatesRes) GetStates() []*State {
+	if x != nil {
+		return x.States
+	}
+	return nil
+}
+
+type ListAlarmsReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	TwinId        string                 `protobuf:"bytes,2,opt,name=twin_id,json=twinId,proto3" json:"twin_id,omitempty"`
+	Offset        uint64                 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit         uint64                 `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	Attribute     string                 `protobuf:"bytes,5,opt,name=attribute,proto3" json:"attribute,omitempty"`
+	Severity      string                 `protobuf:"bytes,6,opt,name=severity,proto3" json:"severity,omitempty"`
+	Status        string                 `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAlarmsReq) Reset() {
+	*x = ListAlarmsReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAlarmsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAlarmsReq) ProtoMessage() {}
+
+func (x *ListAlarmsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAlarmsReq.ProtoReflect.Descriptor instead.
+func (*ListAlarmsReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ListAlarmsReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *ListAlarmsReq) GetTwinId() string {
+	if x != nil {
+		return x.TwinId
+	}
+	return ""
+}
+
+func (x *ListAlarmsReq) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListAlarmsReq) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListAlarmsReq) GetAttribute() string {
+	if x != nil {
+		return x.Attribute
+	}
+	return ""
+}
+
+func (x *ListAlarmsReq) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *ListAlarmsReq) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type ListAlarmsRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         uint64                 `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit         uint64                 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Alarms        []*Alarm               `protobuf:"bytes,4,rep,name=alarms,proto3" json:"alarms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAlarmsRes) Reset() {
+	*x = ListAlarmsRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAlarmsRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAlarmsRes) ProtoMessage() {}
+
+func (x *ListAlarmsRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAlarmsRes.ProtoReflect.Descriptor instead.
+func (*ListAlarmsRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ListAlarmsRes) GetTotal() uint64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListAlarmsRes) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListAlarmsRes) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListAlarmsRes) GetAlarms() []*Alarm {
+	if x != nil {
+		return x.Alarms
+	}
+	return nil
+}
+
+type StateAtReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	TwinId        string                 `protobuf:"bytes,2,opt,name=twin_id,json=twinId,proto3" json:"twin_id,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StateAtReq) Reset() {
+	*x = StateAtReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StateAtReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateAtReq) ProtoMessage() {}
+
+func (x *StateAtReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateAtReq.ProtoReflect.Descriptor instead.
+func (*StateAtReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *StateAtReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *StateAtReq) GetTwinId() string {
+	if x != nil {
+		return x.TwinId
+	}
+	return ""
+}
+
+func (x *StateAtReq) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+type StateAtRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         *State                 `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	Definition    *Definition            `protobuf:"bytes,2,opt,name=definition,proto3" json:"definition,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StateAtRes) Reset() {
+	*x = StateAtRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StateAtRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateAtRes) ProtoMessage() {}
+
+func (x *StateAtRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateAtRes.ProtoReflect.Descriptor instead.
+func (*StateAtRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *StateAtRes) GetState() *State {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *StateAtRes) GetDefinition() *Definition {
+	if x != nil {
+		return x.Definition
+	}
+	return nil
+}
+
+type DesiredStateReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	TwinId        string                 `protobuf:"bytes,2,opt,name=twin_id,json=twinId,proto3" json:"twin_id,omitempty"`
+	Payload       *structpb.Struct       `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DesiredStateReq) Reset() {
+	*x = DesiredStateReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DesiredStateReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DesiredStateReq) ProtoMessage() {}
+
+func (x *DesiredStateReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DesiredStateReq.ProtoReflect.Descriptor instead.
+func (*DesiredStateReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *DesiredStateReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *DesiredStateReq) GetTwinId() string {
+	if x != nil {
+		return x.TwinId
+	}
+	return ""
+}
+
+func (x *DesiredStateReq) GetPayload() *structpb.Struct {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+type DesiredStateRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DesiredState  *DesiredState          `protobuf:"bytes,1,opt,name=desired_state,json=desiredState,proto3" json:"desired_state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DesiredStateRes) Reset() {
+	*x = DesiredStateRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DesiredStateRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DesiredStateRes) ProtoMessage() {}
+
+func (x *DesiredStateRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DesiredStateRes.ProtoReflect.Descriptor instead.
+func (*DesiredStateRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *DesiredStateRes) GetDesiredState() *DesiredState {
+	if x != nil {
+		return x.DesiredState
+	}
+	return nil
+}
+
+type DeltaRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Delta         *structpb.Struct       `protobuf:"bytes,1,opt,name=delta,proto3" json:"delta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeltaRes) Reset() {
+	*x = DeltaRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeltaRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeltaRes) ProtoMessage() {}
+
+func (x *DeltaRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeltaRes.ProtoReflect.Descriptor instead.
+func (*DeltaRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *DeltaRes) GetDelta() *structpb.Struct {
+	if x != nil {
+		return x.Delta
+	}
+	return nil
+}
+
+type WatchStatesReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	TwinIds       []string               `protobuf:"bytes,2,rep,name=twin_ids,json=twinIds,proto3" json:"twin_ids,omitempty"`
+	Attributes    []string               `protobuf:"bytes,3,rep,name=attributes,proto3" json:"attributes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchStatesReq) Reset() {
+	*x = WatchStatesReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchStatesReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchStatesReq) ProtoMessage() {}
+
+func (x *WatchStatesReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchStatesReq.ProtoReflect.Descriptor instead.
+func (*WatchStatesReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *WatchStatesReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *WatchStatesReq) GetTwinIds() []string {
+	if x != nil {
+		return x.TwinIds
+	}
+	return nil
+}
+
+func (x *WatchStatesReq) GetAttributes() []string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+type AddTemplateReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Definition    *Definition            `protobuf:"bytes,3,opt,name=definition,proto3" json:"definition,omitempty"`
+	Metadata      *structpb.Struct       `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddTemplateReq) Reset() {
+	*x = AddTemplateReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddTemplateReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddTemplateReq) ProtoMessage() {}
+
+func (x *AddTemplateReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddTemplateReq.ProtoReflect.Descriptor instead.
+func (*AddTemplateReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *AddTemplateReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *AddTemplateReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AddTemplateReq) GetDefinition() *Definition {
+	if x != nil {
+		return x.Definition
+	}
+	return nil
+}
+
+func (x *AddTemplateReq) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type TemplateReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TemplateReq) Reset() {
+	*x = TemplateReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TemplateReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TemplateReq) ProtoMessage() {}
+
+func (x *TemplateReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TemplateReq.ProtoReflect.Descriptor instead.
+func (*TemplateReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *TemplateReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *TemplateReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type TemplateRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Template      *Template              `protobuf:"bytes,1,opt,name=template,proto3" json:"template,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TemplateRes) Reset() {
+	*x = TemplateRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TemplateRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TemplateRes) ProtoMessage() {}
+
+func (x *TemplateRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TemplateRes.ProtoReflect.Descriptor instead.
+func (*TemplateRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *TemplateRes) GetTemplate() *Template {
+	if x != nil {
+		return x.Template
+	}
+	return nil
+}
+
+type UpdateTemplateReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Definition    *Definition            `protobuf:"bytes,4,opt,name=definition,proto3" json:"definition,omitempty"`
+	Metadata      *structpb.Struct       `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateTemplateReq) Reset() {
+	*x = UpdateTemplateReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTemplateReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTemplateReq) ProtoMessage() {}
+
+func (x *UpdateTemplateReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTemplateReq.ProtoReflect.Descriptor instead.
+func (*UpdateTemplateReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *UpdateTemplateReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *UpdateTemplateReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateTemplateReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateTemplateReq) GetDefinition() *Definition {
+	if x != nil {
+		return x.Definition
+	}
+	return nil
+}
+
+func (x *UpdateTemplateReq) GetMetadata() *structpb.Struct {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type UpdateTemplateRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateTemplateRes) Reset() {
+	*x = UpdateTemplateRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTemplateRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTemplateRes) ProtoMessage() {}
+
+func (x *UpdateTemplateRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTemplateRes.ProtoReflect.Descriptor instead.
+func (*UpdateTemplateRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{42}
+}
+
+type ListTemplatesReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit         uint64                 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTemplatesReq) Reset() {
+	*x = ListTemplatesReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTemplatesReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTemplatesReq) ProtoMessage() {}
+
+func (x *ListTemplatesReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTemplatesReq.ProtoReflect.Descriptor instead.
+func (*ListTemplatesReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *ListTemplatesReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *ListTemplatesReq) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListTemplatesReq) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListTemplatesReq) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ListTemplatesRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         uint64                 `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Limit         uint64                 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Templates     []*Template            `protobuf:"bytes,4,rep,name=templates,proto3" json:"templates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTemplatesRes) Reset() {
+	*x = ListTemplatesRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTemplatesRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTemplatesRes) ProtoMessage() {}
+
+func (x *ListTemplatesRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTemplatesRes.ProtoReflect.Descriptor instead.
+func (*ListTemplatesRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *ListTemplatesRes) GetTotal() uint64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListTemplatesRes) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListTemplatesRes) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListTemplatesRes) GetTemplates() []*Template {
+	if x != nil {
+		return x.Templates
+	}
+	return nil
+}
+
+type RemoveTemplateRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveTemplateRes) Reset() {
+	*x = RemoveTemplateRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveTemplateRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveTemplateRes) ProtoMessage() {}
+
+func (x *RemoveTemplateRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveTemplateRes.ProtoReflect.Descriptor instead.
+func (*RemoveTemplateRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{45}
+}
+
+type RolloutTemplateReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Bindings      map[string]string      `protobuf:"bytes,3,rep,name=bindings,proto3" json:"bindings,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RolloutTemplateReq) Reset() {
+	*x = RolloutTemplateReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RolloutTemplateReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RolloutTemplateReq) ProtoMessage() {}
+
+func (x *RolloutTemplateReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RolloutTemplateReq.ProtoReflect.Descriptor instead.
+func (*RolloutTemplateReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *RolloutTemplateReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *RolloutTemplateReq) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RolloutTemplateReq) GetBindings() map[string]string {
+	if x != nil {
+		return x.Bindings
+	}
+	return nil
+}
+
+type RolloutTemplateRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TemplateId    string                 `protobuf:"bytes,1,opt,name=template_id,json=templateId,proto3" json:"template_id,omitempty"`
+	Revision      int32                  `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	Updated       []string               `protobuf:"bytes,3,rep,name=updated,proto3" json:"updated,omitempty"`
+	Skipped       []string               `protobuf:"bytes,4,rep,name=skipped,proto3" json:"skipped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RolloutTemplateRes) Reset() {
+	*x = RolloutTemplateRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RolloutTemplateRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RolloutTemplateRes) ProtoMessage() {}
+
+func (x *RolloutTemplateRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RolloutTemplateRes.ProtoReflect.Descriptor instead.
+func (*RolloutTemplateRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *RolloutTemplateRes) GetTemplateId() string {
+	if x != nil {
+		return x.TemplateId
+	}
+	return ""
+}
+
+func (x *RolloutTemplateRes) GetRevision() int32 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *RolloutTemplateRes) GetUpdated() []string {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+func (x *RolloutTemplateRes) GetSkipped() []string {
+	if x != nil {
+		return x.Skipped
+	}
+	return nil
+}
+
+type ExportTwinsReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	OwnerId       string                 `protobuf:"bytes,2,opt,name=owner_id,json=ownerId,proto3" json:"owner_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportTwinsReq) Reset() {
+	*x = ExportTwinsReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportTwinsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportTwinsReq) ProtoMessage() {}
+
+func (x *ExportTwinsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportTwinsReq.ProtoReflect.Descriptor instead.
+func (*ExportTwinsReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *ExportTwinsReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *ExportTwinsReq) GetOwnerId() string {
+	if x != nil {
+		return x.OwnerId
+	}
+	return ""
+}
+
+type ExportTwinsRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Twins         []*Twin                `protobuf:"bytes,1,rep,name=twins,proto3" json:"twins,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportTwinsRes) Reset() {
+	*x = ExportTwinsRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportTwinsRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportTwinsRes) ProtoMessage() {}
+
+func (x *ExportTwinsRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportTwinsRes.ProtoReflect.Descriptor instead.
+func (*ExportTwinsRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *ExportTwinsRes) GetTwins() []*Twin {
+	if x != nil {
+		return x.Twins
+	}
+	return nil
+}
+
+type ImportTwinsReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DomainId      string                 `protobuf:"bytes,1,opt,name=domain_id,json=domainId,proto3" json:"domain_id,omitempty"`
+	Twins         []*Twin                `protobuf:"bytes,2,rep,name=twins,proto3" json:"twins,omitempty"`
+	RemapIds      bool                   `protobuf:"varint,3,opt,name=remap_ids,json=remapIds,proto3" json:"remap_ids,omitempty"`
+	DryRun        bool                   `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportTwinsReq) Reset() {
+	*x = ImportTwinsReq{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportTwinsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportTwinsReq) ProtoMessage() {}
+
+func (x *ImportTwinsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportTwinsReq.ProtoReflect.Descriptor instead.
+func (*ImportTwinsReq) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *ImportTwinsReq) GetDomainId() string {
+	if x != nil {
+		return x.DomainId
+	}
+	return ""
+}
+
+func (x *ImportTwinsReq) GetTwins() []*Twin {
+	if x != nil {
+		return x.Twins
+	}
+	return nil
+}
+
+func (x *ImportTwinsReq) GetRemapIds() bool {
+	if x != nil {
+		return x.RemapIds
+	}
+	return false
+}
+
+func (x *ImportTwinsReq) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type ImportTwinsRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DryRun        bool                   `protobuf:"varint,1,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	Ids           map[string]string      `protobuf:"bytes,2,rep,name=ids,proto3" json:"ids,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportTwinsRes) Reset() {
+	*x = ImportTwinsRes{}
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportTwinsRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportTwinsRes) ProtoMessage() {}
+
+func (x *ImportTwinsRes) ProtoReflect() protoreflect.Message {
+	mi := &file_twins_api_grpc_v1_twins_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportTwinsRes.ProtoReflect.Descriptor instead.
+func (*ImportTwinsRes) Descriptor() ([]byte, []int) {
+	return file_twins_api_grpc_v1_twins_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *ImportTwinsRes) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *ImportTwinsRes) GetIds() map[string]string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+var File_twins_api_grpc_v1_twins_proto protoreflect.FileDescriptor
+
+const file_twins_api_grpc_v1_twins_proto_rawDesc = "" +
+	"\n" +
+	"\x1dtwins/api/grpc/v1/twins.proto\x12\btwins.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xf1\x01\n" +
+	"\tAlarmRule\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1c\n" +
+	"\tcondition\x18\x02 \x01(\tR\tcondition\x12\x1a\n" +
+	"\bseverity\x18\x03 \x01(\tR\bseverity\x12\x1c\n" +
+	"\tthreshold\x18\x04 \x01(\x01R\tthreshold\x12\x15\n" +
+	"\x03min\x18\x05 \x01(\x01H\x00R\x03min\x88\x01\x01\x12\x15\n" +
+	"\x03max\x18\x06 \x01(\x01H\x01R\x03max\x88\x01\x01\x12\x1a\n" +
+	"\bduration\x18\a \x01(\x03R\bduration\x12\x1e\n" +
+	"\n" +
+	"hysteresis\x18\b \x01(\x01R\n" +
+	"hysteresisB\x06\n" +
+	"\x04_minB\x06\n" +
+	"\x04_max\"\xec\x03\n" +
+	"\tAttribute\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\achannel\x18\x02 \x01(\tR\achannel\x12\x1a\n" +
+	"\bsubtopic\x18\x03 \x01(\tR\bsubtopic\x12\x12\n" +
+	"\x04path\x18\x04 \x01(\tR\x04path\x12\x1b\n" +
+	"\ttime_path\x18\x05 \x01(\tR\btimePath\x12\x1e\n" +
+	"\n" +
+	"expression\x18\x06 \x01(\tR\n" +
+	"expression\x12\x12\n" +
+	"\x04type\x18\a \x01(\tR\x04type\x12\x12\n" +
+	"\x04unit\x18\b \x01(\tR\x04unit\x12\x15\n" +
+	"\x03min\x18\t \x01(\x01H\x00R\x03min\x88\x01\x01\x12\x15\n" +
+	"\x03max\x18\n" +
+	" \x01(\x01H\x01R\x03max\x88\x01\x01\x12*\n" +
+	"\x04enum\x18\v \x03(\v2\x16.google.protobuf.ValueR\x04enum\x12+\n" +
+	"\x06alarms\x18\f \x03(\v2\x13.twins.v1.AlarmRuleR\x06alarms\x12'\n" +
+	"\x0fupdate_interval\x18\r \x01(\x03R\x0eupdateInterval\x12#\n" +
+	"\rpersist_state\x18\x0e \x01(\bR\fpersistState\x127\n" +
+	"\vpersistence\x18\x0f \x01(\v2\x15.twins.v1.PersistenceR\vpersistenceB\x06\n" +
+	"\x04_minB\x06\n" +
+	"\x04_max\"q\n" +
+	"\vPersistence\x12\x16\n" +
+	"\x06policy\x18\x01 \x01(\tR\x06policy\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x03R\x05delta\x12\x1a\n" +
+	"\bdeadband\x18\x03 \x01(\x01R\bdeadband\x12\x18\n" +
+	"\apercent\x18\x04 \x01(\bR\apercent\"]\n" +
+	"\tRetention\x12\x17\n" +
+	"\amax_age\x18\x01 \x01(\x03R\x06maxAge\x12\x1f\n" +
+	"\vcompact_age\x18\x02 \x01(\x03R\n" +
+	"compactAge\x12\x16\n" +
+	"\x06bucket\x18\x03 \x01(\tR\x06bucket\"\xd0\x01\n" +
+	"\n" +
+	"Definition\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x124\n" +
+	"\acreated\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\acreated\x123\n" +
+	"\n" +
+	"attributes\x18\x03 \x03(\v2\x13.twins.v1.AttributeR\n" +
+	"attributes\x12\x14\n" +
+	"\x05delta\x18\x04 \x01(\x03R\x05delta\x121\n" +
+	"\tretention\x18\x05 \x01(\v2\x13.twins.v1.RetentionR\tretention\"\xd0\x04\n" +
+	"\x04Twin\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x1b\n" +
+	"\tdomain_id\x18\x03 \x01(\tR\bdomainId\x12\x1b\n" +
+	"\tparent_id\x18\x04 \x01(\tR\bparentId\x12\x1f\n" +
+	"\vtemplate_id\x18\x05 \x01(\tR\n" +
+	"templateId\x12+\n" +
+	"\x11template_revision\x18\x06 \x01(\x05R\x10templateRevision\x128\n" +
+	"\bbindings\x18\a \x03(\v2\x1c.twins.v1.Twin.BindingsEntryR\bbindings\x12\x12\n" +
+	"\x04name\x18\b \x01(\tR\x04name\x12\x1a\n" +
+	"\brevision\x18\t \x01(\x05R\brevision\x124\n" +
+	"\acreated\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\acreated\x124\n" +
+	"\aupdated\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\aupdated\x126\n" +
+	"\vdefinitions\x18\f \x03(\v2\x14.twins.v1.DefinitionR\vdefinitions\x123\n" +
+	"\bmetadata\x18\r \x01(\v2\x17.google.protobuf.StructR\bmetadata\x12\x14\n" +
+	"\x05stale\x18\x0f \x03(\tR\x05stale\x1a;\n" +
+	"\rBindingsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01J\x04\b\x0e\x10\x0f\"^\n" +
+	"\bTwinNode\x12\"\n" +
+	"\x04twin\x18\x01 \x01(\v2\x0e.twins.v1.TwinR\x04twin\x12.\n" +
+	"\bchildren\x18\x02 \x03(\v2\x12.twins.v1.TwinNodeR\bchildren\"\xdf\x02\n" +
+	"\x05State\x12\x17\n" +
+	"\atwin_id\x18\x01 \x01(\tR\x06twinId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\x03R\x02id\x12\x1e\n" +
+	"\n" +
+	"definition\x18\x03 \x01(\x05R\n" +
+	"definition\x124\n" +
+	"\acreated\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\acreated\x121\n" +
+	"\apayload\x18\x05 \x01(\v2\x17.google.protobuf.StructR\apayload\x126\n" +
+	"\aupdated\x18\x06 \x03(\v2\x1c.twins.v1.State.UpdatedEntryR\aupdated\x12\x14\n" +
+	"\x05stale\x18\a \x03(\tR\x05stale\x1aV\n" +
+	"\fUpdatedEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x120\n" +
+	"\x05value\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x05value:\x028\x01\"\xd0\x01\n" +
+	"\x0eCompositeState\x12\x17\n" +
+	"\atwin_id\x18\x01 \x01(\tR\x06twinId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12%\n" +
+	"\x05state\x18\x03 \x01(\v2\x0f.twins.v1.StateR\x05state\x124\n" +
+	"\aupdated\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\aupdated\x124\n" +
+	"\bchildren\x18\x05 \x03(\v2\x18.twins.v1.CompositeStateR\bchildren\"\x90\x01\n" +
+	"\fDesiredState\x12\x17\n" +
+	"\atwin_id\x18\x01 \x01(\tR\x06twinId\x124\n" +
+	"\aupdated\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\aupdated\x121\n" +
+	"\apayload\x18\x03 \x01(\v2\x17.google.protobuf.StructR\apayload\"\xb4\x02\n" +
+	"\x05Alarm\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\atwin_id\x18\x02 \x01(\tR\x06twinId\x12\x1c\n" +
+	"\tattribute\x18\x03 \x01(\tR\tattribute\x12\x12\n" +
+	"\x04rule\x18\x04 \x01(\tR\x04rule\x12\x1c\n" +
+	"\tcondition\x18\x05 \x01(\tR\tcondition\x12\x1a\n" +
+	"\bseverity\x18\x06 \x01(\tR\bseverity\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\x12\x14\n" +
+	"\x05value\x18\b \x01(\x01R\x05value\x122\n" +
+	"\x06raised\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x06raised\x124\n" +
+	"\acleared\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\acleared\"\xf4\x02\n" +
+	"\bTemplate\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\tR\x05owner\x12\x1b\n" +
+	"\tdomain_id\x18\x03 \x01(\tR\bdomainId\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x1a\n" +
+	"\brevision\x18\x05 \x01(\x05R\brevision\x124\n" +
+	"\acreated\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\acreated\x124\n" +
+	"\aupdated\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\aupdated\x124\n" +
+	"\n" +
+	"definition\x18\b \x01(\v2\x14.twins.v1.DefinitionR\n" +
+	"definition\x12\x1e\n" +
+	"\n" +
+	"parameters\x18\t \x03(\tR\n" +
+	"parameters\x123\n" +
+	"\bmetadata\x18\n" +
+	" \x01(\v2\x17.google.protobuf.StructR\bmetadata\"\x97\x01\n" +
+	"\vStreamEvent\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x17\n" +
+	"\atwin_id\x18\x02 \x01(\tR\x06twinId\x12%\n" +
+	"\x05state\x18\x03 \x01(\v2\x0f.twins.v1.StateR\x05state\x124\n" +
+	"\n" +
+	"definition\x18\x04 \x01(\v2\x14.twins.v1.DefinitionR\n" +
+	"definition\"\xc6\x02\n" +
+	"\n" +
+	"AddTwinReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x124\n" +
+	"\n" +
+	"definition\x18\x03 \x01(\v2\x14.twins.v1.DefinitionR\n" +
+	"definition\x12\x1f\n" +
+	"\vtemplate_id\x18\x04 \x01(\tR\n" +
+	"templateId\x12>\n" +
+	"\bbindings\x18\x05 \x03(\v2\".twins.v1.AddTwinReq.BindingsEntryR\bbindings\x123\n" +
+	"\bmetadata\x18\x06 \x01(\v2\x17.google.protobuf.StructR\bmetadata\x1a;\n" +
+	"\rBindingsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"6\n" +
+	"\aTwinReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\"-\n" +
+	"\aTwinRes\x12\"\n" +
+	"\x04twin\x18\x01 \x01(\v2\x0e.twins.v1.TwinR\x04twin\"\xe9\x01\n" +
+	"\rUpdateTwinReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x124\n" +
+	"\n" +
+	"definition\x18\x04 \x01(\v2\x14.twins.v1.DefinitionR\n" +
+	"definition\x123\n" +
+	"\bmetadata\x18\x05 \x01(\v2\x17.google.protobuf.StructR\bmetadata\x12\x1f\n" +
+	"\brevision\x18\x06 \x01(\x05H\x00R\brevision\x88\x01\x01B\v\n" +
+	"\t_revision\"\x0f\n" +
+	"\rUpdateTwinRes\"\x84\x01\n" +
+	"\rRemoveTwinReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\x18\n" +
+	"\acascade\x18\x03 \x01(\bR\acascade\x12\x1f\n" +
+	"\brevision\x18\x04 \x01(\x05H\x00R\brevision\x88\x01\x01B\v\n" +
+	"\t_revision\"\x0f\n" +
+	"\rRemoveTwinRes\"\xbf\x01\n" +
+	"\fListTwinsReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x04R\x05limit\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x1b\n" +
+	"\tparent_id\x18\x05 \x01(\tR\bparentId\x123\n" +
+	"\bmetadata\x18\x06 \x01(\v2\x17.google.protobuf.StructR\bmetadata\"x\n" +
+	"\fListTwinsRes\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x04R\x05total\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x04R\x05limit\x12$\n" +
+	"\x05twins\x18\x04 \x03(\v2\x0e.twins.v1.TwinR\x05twins\"_\n" +
+	"\bChildReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x1b\n" +
+	"\tparent_id\x18\x02 \x01(\tR\bparentId\x12\x19\n" +
+	"\bchild_id\x18\x03 \x01(\tR\achildId\"\n" +
+	"\n" +
+	"\bChildRes\"4\n" +
+	"\n" +
+	"SubtreeRes\x12&\n" +
+	"\x04root\x18\x01 \x01(\v2\x12.twins.v1.TwinNodeR\x04root\"C\n" +
+	"\x11CompositeStateRes\x12.\n" +
+	"\x05state\x18\x01 \x01(\v2\x18.twins.v1.CompositeStateR\x05state\"j\n" +
+	"\fShareTwinReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\x12\n" +
+	"\x04role\x18\x03 \x01(\tR\x04role\x12\x19\n" +
+	"\buser_ids\x18\x04 \x03(\tR\auserIds\"\x0e\n" +
+	"\fShareTwinRes\"\xb3\x02\n" +
+	"\rListStatesReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x17\n" +
+	"\atwin_id\x18\x02 \x01(\tR\x06twinId\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x04R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x04R\x05limit\x12.\n" +
+	"\x04from\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12#\n" +
+	"\n" +
+	"definition\x18\a \x01(\x05H\x00R\n" +
+	"definition\x88\x01\x01\x12\x1c\n" +
+	"\tattribute\x18\b \x01(\tR\tattribute\x12\x10\n" +
+	"\x03dir\x18\t \x01(\tR\x03dirB\r\n" +
+	"\v_definition\"|\n" +
+	"\rListStatesRes\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x04R\x05total\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x04R\x05limit\x12'\n" +
+	"\x06states\x18\x04 \x03(\v2\x0f.twins.v1.StateR\x06states\"\xc5\x01\n" +
+	"\rListAlarmsReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x17\n" +
+	"\atwin_id\x18\x02 \x01(\tR\x06twinId\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x04R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x04R\x05limit\x12\x1c\n" +
+	"\tattribute\x18\x05 \x01(\tR\tattribute\x12\x1a\n" +
+	"\bseverity\x18\x06 \x01(\tR\bseverity\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\"|\n" +
+	"\rListAlarmsRes\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x04R\x05total\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x04R\x05limit\x12'\n" +
+	"\x06alarms\x18\x04 \x03(\v2\x0f.twins.v1.AlarmR\x06alarms\"r\n" +
+	"\n" +
+	"StateAtReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x17\n" +
+	"\atwin_id\x18\x02 \x01(\tR\x06twinId\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\"i\n" +
+	"\n" +
+	"StateAtRes\x12%\n" +
+	"\x05state\x18\x01 \x01(\v2\x0f.twins.v1.StateR\x05state\x124\n" +
+	"\n" +
+	"definition\x18\x02 \x01(\v2\x14.twins.v1.DefinitionR\n" +
+	"definition\"z\n" +
+	"\x0fDesiredStateReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x17\n" +
+	"\atwin_id\x18\x02 \x01(\tR\x06twinId\x121\n" +
+	"\apayload\x18\x03 \x01(\v2\x17.google.protobuf.StructR\apayload\"N\n" +
+	"\x0fDesiredStateRes\x12;\n" +
+	"\rdesired_state\x18\x01 \x01(\v2\x16.twins.v1.DesiredStateR\fdesiredState\"9\n" +
+	"\bDeltaRes\x12-\n" +
+	"\x05delta\x18\x01 \x01(\v2\x17.google.protobuf.StructR\x05delta\"h\n" +
+	"\x0eWatchStatesReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x19\n" +
+	"\btwin_ids\x18\x02 \x03(\tR\atwinIds\x12\x1e\n" +
+	"\n" +
+	"attributes\x18\x03 \x03(\tR\n" +
+	"attributes\"\xac\x01\n" +
+	"\x0eAddTemplateReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x124\n" +
+	"\n" +
+	"definition\x18\x03 \x01(\v2\x14.twins.v1.DefinitionR\n" +
+	"definition\x123\n" +
+	"\bmetadata\x18\x04 \x01(\v2\x17.google.protobuf.StructR\bmetadata\":\n" +
+	"\vTemplateReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\"=\n" +
+	"\vTemplateRes\x12.\n" +
+	"\btemplate\x18\x01 \x01(\v2\x12.twins.v1.TemplateR\btemplate\"\xbf\x01\n" +
+	"\x11UpdateTemplateReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x124\n" +
+	"\n" +
+	"definition\x18\x04 \x01(\v2\x14.twins.v1.DefinitionR\n" +
+	"definition\x123\n" +
+	"\bmetadata\x18\x05 \x01(\v2\x17.google.protobuf.StructR\bmetadata\"\x13\n" +
+	"\x11UpdateTemplateRes\"q\n" +
+	"\x10ListTemplatesReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x04R\x05limit\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\"\x88\x01\n" +
+	"\x10ListTemplatesRes\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x04R\x05total\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x04R\x05limit\x120\n" +
+	"\ttemplates\x18\x04 \x03(\v2\x12.twins.v1.TemplateR\ttemplates\"\x13\n" +
+	"\x11RemoveTemplateRes\"\xc6\x01\n" +
+	"\x12RolloutTemplateReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12F\n" +
+	"\bbindings\x18\x03 \x03(\v2*.twins.v1.RolloutTemplateReq.BindingsEntryR\bbindings\x1a;\n" +
+	"\rBindingsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x85\x01\n" +
+	"\x12RolloutTemplateRes\x12\x1f\n" +
+	"\vtemplate_id\x18\x01 \x01(\tR\n" +
+	"templateId\x12\x1a\n" +
+	"\brevision\x18\x02 \x01(\x05R\brevision\x12\x18\n" +
+	"\aupdated\x18\x03 \x03(\tR\aupdated\x12\x18\n" +
+	"\askipped\x18\x04 \x03(\tR\askipped\"H\n" +
+	"\x0eExportTwinsReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12\x19\n" +
+	"\bowner_id\x18\x02 \x01(\tR\aownerId\"6\n" +
+	"\x0eExportTwinsRes\x12$\n" +
+	"\x05twins\x18\x01 \x03(\v2\x0e.twins.v1.TwinR\x05twins\"\x89\x01\n" +
+	"\x0eImportTwinsReq\x12\x1b\n" +
+	"\tdomain_id\x18\x01 \x01(\tR\bdomainId\x12$\n" +
+	"\x05twins\x18\x02 \x03(\v2\x0e.twins.v1.TwinR\x05twins\x12\x1b\n" +
+	"\tremap_ids\x18\x03 \x01(\bR\bremapIds\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\"\x96\x01\n" +
+	"\x0eImportTwinsRes\x12\x17\n" +
+	"\adry_run\x18\x01 \x01(\bR\x06dryRun\x123\n" +
+	"\x03ids\x18\x02 \x03(\v2!.twins.v1.ImportTwinsRes.IdsEntryR\x03ids\x1a6\n" +
+	"\bIdsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x012\xfb\f\n" +
+	"\fTwinsService\x122\n" +
+	"\aAddTwin\x12\x14.twins.v1.AddTwinReq\x1a\x11.twins.v1.TwinRes\x12>\n" +
+	"\n" +
+	"UpdateTwin\x12\x17.twins.v1.UpdateTwinReq\x1a\x17.twins.v1.UpdateTwinRes\x120\n" +
+	"\bViewTwin\x12\x11.twins.v1.TwinReq\x1a\x11.twins.v1.TwinRes\x12>\n" +
+	"\n" +
+	"RemoveTwin\x12\x17.twins.v1.RemoveTwinReq\x1a\x17.twins.v1.RemoveTwinRes\x12;\n" +
+	"\tListTwins\x12\x16.twins.v1.ListTwinsReq\x1a\x16.twins.v1.ListTwinsRes\x125\n" +
+	"\vAttachChild\x12\x12.twins.v1.ChildReq\x1a\x12.twins.v1.ChildRes\x125\n" +
+	"\vDetachChild\x12\x12.twins.v1.ChildReq\x1a\x12.twins.v1.ChildRes\x126\n" +
+	"\vViewSubtree\x12\x11.twins.v1.TwinReq\x1a\x14.twins.v1.SubtreeRes\x12D\n" +
+	"\x12ViewCompositeState\x12\x11.twins.v1.TwinReq\x1a\x1b.twins.v1.CompositeStateRes\x12;\n" +
+	"\tShareTwin\x12\x16.twins.v1.ShareTwinReq\x1a\x16.twins.v1.ShareTwinRes\x12=\n" +
+	"\vUnshareTwin\x12\x16.twins.v1.ShareTwinReq\x1a\x16.twins.v1.ShareTwinRes\x12>\n" +
+	"\n" +
+	"ListStates\x12\x17.twins.v1.ListStatesReq\x1a\x17.twins.v1.ListStatesRes\x12>\n" +
+	"\n" +
+	"ListAlarms\x12\x17.twins.v1.ListAlarmsReq\x1a\x17.twins.v1.ListAlarmsRes\x125\n" +
+	"\aStateAt\x12\x14.twins.v1.StateAtReq\x1a\x14.twins.v1.StateAtRes\x12C\n" +
+	"\x12UpdateDesiredState\x12\x19.twins.v1.DesiredStateReq\x1a\x12.twins.v1.DeltaRes\x12@\n" +
+	"\x10ViewDesiredState\x12\x11.twins.v1.TwinReq\x1a\x19.twins.v1.DesiredStateRes\x122\n" +
+	"\tViewDelta\x12\x11.twins.v1.TwinReq\x1a\x12.twins.v1.DeltaRes\x12@\n" +
+	"\vWatchStates\x12\x18.twins.v1.WatchStatesReq\x1a\x15.twins.v1.StreamEvent0\x01\x12>\n" +
+	"\vAddTemplate\x12\x18.twins.v1.AddTemplateReq\x1a\x15.twins.v1.TemplateRes\x12J\n" +
+	"\x0eUpdateTemplate\x12\x1b.twins.v1.UpdateTemplateReq\x1a\x1b.twins.v1.UpdateTemplateRes\x12<\n" +
+	"\fViewTemplate\x12\x15.twins.v1.TemplateReq\x1a\x15.twins.v1.TemplateRes\x12G\n" +
+	"\rListTemplates\x12\x1a.twins.v1.ListTemplatesReq\x1a\x1a.twins.v1.ListTemplatesRes\x12D\n" +
+	"\x0eRemoveTemplate\x12\x15.twins.v1.TemplateReq\x1a\x1b.twins.v1.RemoveTemplateRes\x12M\n" +
+	"\x0fRolloutTemplate\x12\x1c.twins.v1.RolloutTemplateReq\x1a\x1c.twins.v1.RolloutTemplateRes\x12A\n" +
+	"\vExportTwins\x12\x18.twins.v1.ExportTwinsReq\x1a\x18.twins.v1.ExportTwinsRes\x12A\n" +
+	"\vImportTwins\x12\x18.twins.v1.ImportTwinsReq\x1a\x18.twins.v1.ImportTwinsResB6Z4github.com/absmach/supermq-contrib/twins/api/grpc/v1b\x06proto3"
+
+var (
+	file_twins_api_grpc_v1_twins_proto_rawDescOnce sync.Once
+	file_twins_api_grpc_v1_twins_proto_rawDescData []byte
+)
+
+func file_twins_api_grpc_v1_twins_proto_rawDescGZIP() []byte {
+	file_twins_api_grpc_v1_twins_proto_rawDescOnce.Do(func() {
+		file_twins_api_grpc_v1_twins_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_twins_api_grpc_v1_twins_proto_rawDesc), len(file_twins_api_grpc_v1_twins_proto_rawDesc)))
+	})
+	return file_twins_api_grpc_v1_twins_proto_rawDescData
+}
+
+var file_twins_api_grpc_v1_twins_proto_msgTypes = make([]protoimpl.MessageInfo, 57)
+var file_twins_api_grpc_v1_twins_proto_goTypes = []any{
+	(*AlarmRule)(nil),             // 0: twins.v1.AlarmRule
+	(*Attribute)(nil),             // 1: twins.v1.Attribute
+	(*Persistence)(nil),           // 2: twins.v1.Persistence
+	(*Retention)(nil),             // 3: twins.v1.Retention
+	(*Definition)(nil),            // 4: twins.v1.Definition
+	(*Twin)(nil),                  // 5: twins.v1.Twin
+	(*TwinNode)(nil),              // 6: twins.v1.TwinNode
+	(*State)(nil),                 // 7: twins.v1.State
+	(*CompositeState)(nil),        // 8: twins.v1.CompositeState
+	(*DesiredState)(nil),          // 9: twins.v1.DesiredState
+	(*Alarm)(nil),                 // 10: twins.v1.Alarm
+	(*Template)(nil),              // 11: twins.v1.Template
+	(*StreamEvent)(nil),           // 12: twins.v1.StreamEvent
+	(*AddTwinReq)(nil),            // 13: twins.v1.AddTwinReq
+	(*TwinReq)(nil),               // 14: twins.v1.TwinReq
+	(*TwinRes)(nil),               // 15: twins.v1.TwinRes
+	(*UpdateTwinReq)(nil),         // 16: twins.v1.UpdateTwinReq
+	(*UpdateTwinRes)(nil),         // 17: twins.v1.UpdateTwinRes
+	(*RemoveTwinReq)(nil),         // 18: twins.v1.RemoveTwinReq
+	(*RemoveTwinRes)(nil),         // 19: twins.v1.RemoveTwinRes
+	(*ListTwinsReq)(nil),          // 20: twins.v1.ListTwinsReq
+	(*ListTwinsRes)(nil),          // 21: twins.v1.ListTwinsRes
+	(*ChildReq)(nil),              // 22: twins.v1.ChildReq
+	(*ChildRes)(nil),              // 23: twins.v1.ChildRes
+	(*SubtreeRes)(nil),            // 24: twins.v1.SubtreeRes
+	(*CompositeStateRes)(nil),     // 25: twins.v1.CompositeStateRes
+	(*ShareTwinReq)(nil),          // 26: twins.v1.ShareTwinReq
+	(*ShareTwinRes)(nil),          // 27: twins.v1.ShareTwinRes
+	(*ListStatesReq)(nil),         // 28: twins.v1.ListStatesReq
+	(*ListStatesRes)(nil),         // 29: twins.v1.ListStatesRes
+	(*ListAlarmsReq)(nil),         // 30: twins.v1.ListAlarmsReq
+	(*ListAlarmsRes)(nil),         // 31: twins.v1.ListAlarmsRes
+	(*StateAtReq)(nil),            // 32: twins.v1.StateAtReq
+	(*StateAtRes)(nil),            // 33: twins.v1.StateAtRes
+	(*DesiredStateReq)(nil),       // 34: twins.v1.DesiredStateReq
+	(*DesiredStateRes)(nil),       // 35: twins.v1.DesiredStateRes
+	(*DeltaRes)(nil),              // 36: twins.v1.DeltaRes
+	(*WatchStatesReq)(nil),        // 37: twins.v1.WatchStatesReq
+	(*AddTemplateReq)(nil),        // 38: twins.v1.AddTemplateReq
+	(*TemplateReq)(nil),           // 39: twins.v1.TemplateReq
+	(*TemplateRes)(nil),           // 40: twins.v1.TemplateRes
+	(*UpdateTemplateReq)(nil),     // 41: twins.v1.UpdateTemplateReq
+	(*UpdateTemplateRes)(nil),     // 42: twins.v1.UpdateTemplateRes
+	(*ListTemplatesReq)(nil),      // 43: twins.v1.ListTemplatesReq
+	(*ListTemplatesRes)(nil),      // 44: twins.v1.ListTemplatesRes
+	(*RemoveTemplateRes)(nil),     // 45: twins.v1.RemoveTemplateRes
+	(*RolloutTemplateReq)(nil),    // 46: twins.v1.RolloutTemplateReq
+	(*RolloutTemplateRes)(nil),    // 47: twins.v1.RolloutTemplateRes
+	(*ExportTwinsReq)(nil),        // 48: twins.v1.ExportTwinsReq
+	(*ExportTwinsRes)(nil),        // 49: twins.v1.ExportTwinsRes
+	(*ImportTwinsReq)(nil),        // 50: twins.v1.ImportTwinsReq
+	(*ImportTwinsRes)(nil),        // 51: twins.v1.ImportTwinsRes
+	nil,                           // 52: twins.v1.Twin.BindingsEntry
+	nil,                           // 53: twins.v1.State.UpdatedEntry
+	nil,                           // 54: twins.v1.AddTwinReq.BindingsEntry
+	nil,                           // 55: twins.v1.RolloutTemplateReq.BindingsEntry
+	nil,                           // 56: twins.v1.ImportTwinsRes.IdsEntry
+	(*structpb.Value)(nil),        // 57: google.protobuf.Value
+	(*timestamppb.Timestamp)(nil), // 58: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),       // 59: google.protobuf.Struct
+}
+var file_twins_api_grpc_v1_twins_proto_depIdxs = []int32{
+	57, // 0: twins.v1.Attribute.enum:type_name -> google.protobuf.Value
+	0,  // 1: twins.v1.Attribute.alarms:type_name -> twins.v1.AlarmRule
+	2,  // 2: twins.v1.Attribute.persistence:type_name -> twins.v1.Persistence
+	58, // 3: twins.v1.Definition.created:type_name -> google.protobuf.Timestamp
+	1,  // 4: twins.v1.Definition.attributes:type_name -> twins.v1.Attribute
+	3,  // 5: twins.v1.Definition.retention:type_name -> twins.v1.Retention
+	52, // 6: twins.v1.Twin.bindings:type_name -> twins.v1.Twin.BindingsEntry
+	58, // 7: twins.v1.Twin.created:type_name -> google.protobuf.Timestamp
+	58, // 8: twins.v1.Twin.updated:type_name -> google.protobuf.Timestamp
+	4,  // 9: twins.v1.Twin.definitions:type_name -> twins.v1.Definition
+	59, // 10: twins.v1.Twin.metadata:type_name -> google.protobuf.Struct
+	5,  // 11: twins.v1.TwinNode.twin:type_name -> twins.v1.Twin
+	6,  // 12: twins.v1.TwinNode.children:type_name -> twins.v1.TwinNode
+	58, // 13: twins.v1.State.created:type_name -> google.protobuf.Timestamp
+	59, // 14: twins.v1.State.payload:type_name -> google.protobuf.Struct
+	53, // 15: twins.v1.State.updated:type_name -> twins.v1.State.UpdatedEntry
+	7,  // 16: twins.v1.CompositeState.state:type_name -> twins.v1.State
+	58, // 17: twins.v1.CompositeState.updated:type_name -> google.protobuf.Timestamp
+	8,  // 18: twins.v1.CompositeState.children:type_name -> twins.v1.CompositeState
+	58, // 19: twins.v1.DesiredState.updated:type_name -> google.protobuf.Timestamp
+	59, // 20: twins.v1.DesiredState.payload:type_name -> google.protobuf.Struct
+	58, // 21: twins.v1.Alarm.raised:type_name -> google.protobuf.Timestamp
+	58, // 22: twins.v1.Alarm.cleared:type_name -> google.protobuf.Timestamp
+	58, // 23: twins.v1.Template.created:type_name -> google.protobuf.Timestamp
+	58, // 24: twins.v1.Template.updated:type_name -> google.protobuf.Timestamp
+	4,  // 25: twins.v1.Template.definition:type_name -> twins.v1.Definition
+	59, // 26: twins.v1.Template.metadata:type_name -> google.protobuf.Struct
+	7,  // 27: twins.v1.StreamEvent.state:type_name -> twins.v1.State
+	4,  // 28: twins.v1.StreamEvent.definition:type_name -> twins.v1.Definition
+	4,  // 29: twins.v1.AddTwinReq.definition:type_name -> twins.v1.Definition
+	54, // 30: twins.v1.AddTwinReq.bindings:type_name -> twins.v1.AddTwinReq.BindingsEntry
+	59, // 31: twins.v1.AddTwinReq.metadata:type_name -> google.protobuf.Struct
+	5,  // 32: twins.v1.TwinRes.twin:type_name -> twins.v1.Twin
+	4,  // 33: twins.v1.UpdateTwinReq.definition:type_name -> twins.v1.Definition
+	59, // 34: twins.v1.UpdateTwinReq.metadata:type_name -> google.protobuf.Struct
+	59, // 35: twins.v1.ListTwinsReq.metadata:type_name -> google.protobuf.Struct
+	5,  // 36: twins.v1.ListTwinsRes.twins:type_name -> twins.v1.Twin
+	6,  // 37: twins.v1.SubtreeRes.root:type_name -> twins.v1.TwinNode
+	8,  // 38: twins.v1.CompositeStateRes.state:type_name -> twins.v1.CompositeState
+	58, // 39: twins.v1.ListStatesReq.from:type_name -> google.protobuf.Timestamp
+	58, // 40: twins.v1.ListStatesReq.to:type_name -> google.protobuf.Timestamp
+	7,  // 41: twins.v1.ListStatesRes.states:type_name -> twins.v1.State
+	10, // 42: twins.v1.ListAlarmsRes.alarms:type_name -> twins.v1.Alarm
+	58, // 43: twins.v1.StateAtReq.time:type_name -> google.protobuf.Timestamp
+	7,  // 44: twins.v1.StateAtRes.state:type_name -> twins.v1.State
+	4,  // 45: twins.v1.StateAtRes.definition:type_name -> twins.v1.Definition
+	59, // 46: twins.v1.DesiredStateReq.payload:type_name -> google.protobuf.Struct
+	9,  // 47: twins.v1.DesiredStateRes.desired_state:type_name -> twins.v1.DesiredState
+	59, // 48: twins.v1.DeltaRes.delta:type_name -> google.protobuf.Struct
+	4,  // 49: twins.v1.AddTemplateReq.definition:type_name -> twins.v1.Definition
+	59, // 50: twins.v1.AddTemplateReq.metadata:type_name -> google.protobuf.Struct
+	11, // 51: twins.v1.TemplateRes.template:type_name -> twins.v1.Template
+	4,  // 52: twins.v1.UpdateTemplateReq.definition:type_name -> twins.v1.Definition
+	59, // 53: twins.v1.UpdateTemplateReq.metadata:type_name -> google.protobuf.Struct
+	11, // 54: twins.v1.ListTemplatesRes.templates:type_name -> twins.v1.Template
+	55, // 55: twins.v1.RolloutTemplateReq.bindings:type_name -> twins.v1.RolloutTemplateReq.BindingsEntry
+	5,  // 56: twins.v1.ExportTwinsRes.twins:type_name -> twins.v1.Twin
+	5,  // 57: twins.v1.ImportTwinsReq.twins:type_name -> twins.v1.Twin
+	56, // 58: twins.v1.ImportTwinsRes.ids:type_name -> twins.v1.ImportTwinsRes.IdsEntry
+	58, // 59: twins.v1.State.UpdatedEntry.value:type_name -> google.protobuf.Timestamp
+	13, // 60: twins.v1.TwinsService.AddTwin:input_type -> twins.v1.AddTwinReq
+	16, // 61: twins.v1.TwinsService.UpdateTwin:input_type -> twins.v1.UpdateTwinReq
+	14, // 62: twins.v1.TwinsService.ViewTwin:input_type -> twins.v1.TwinReq
+	18, // 63: twins.v1.TwinsService.RemoveTwin:input_type -> twins.v1.RemoveTwinReq
+	20, // 64: twins.v1.TwinsService.ListTwins:input_type -> twins.v1.ListTwinsReq
+	22, // 65: twins.v1.TwinsService.AttachChild:input_type -> twins.v1.ChildReq
+	22, // 66: twins.v1.TwinsService.DetachChild:input_type -> twins.v1.ChildReq
+	14, // 67: twins.v1.TwinsService.ViewSubtree:input_type -> twins.v1.TwinReq
+	14, // 68: twins.v1.TwinsService.ViewCompositeState:input_type -> twins.v1.TwinReq
+	26, // 69: twins.v1.TwinsService.ShareTwin:input_type -> twins.v1.ShareTwinReq
+	26, // 70: twins.v1.TwinsService.UnshareTwin:input_type -> twins.v1.ShareTwinReq
+	28, // 71: twins.v1.TwinsService.ListStates:input_type -> twins.v1.ListStatesReq
+	30, // 72: twins.v1.TwinsService.ListAlarms:input_type -> twins.v1.ListAlarmsReq
+	32, // 73: twins.v1.TwinsService.StateAt:input_type -> twins.v1.StateAtReq
+	34, // 74: twins.v1.TwinsService.UpdateDesiredState:input_type -> twins.v1.DesiredStateReq
+	14, // 75: twins.v1.TwinsService.ViewDesiredState:input_type -> twins.v1.TwinReq
+	14, // 76: twins.v1.TwinsService.ViewDelta:input_type -> twins.v1.TwinReq
+	37, // 77: twins.v1.TwinsService.WatchStates:input_type -> twins.v1.WatchStatesReq
+	38, // 78: twins.v1.TwinsService.AddTemplate:input_type -> twins.v1.AddTemplateReq
+	41, // 79: twins.v1.TwinsService.UpdateTemplate:input_type -> twins.v1.UpdateTemplateReq
+	39, // 80: twins.v1.TwinsService.ViewTemplate:input_type -> twins.v1.TemplateReq
+	43, // 81: twins.v1.TwinsService.ListTemplates:input_type -> twins.v1.ListTemplatesReq
+	39, // 82: twins.v1.TwinsService.RemoveTemplate:input_type -> twins.v1.TemplateReq
+	46, // 83: twins.v1.TwinsService.RolloutTemplate:input_type -> twins.v1.RolloutTemplateReq
+	48, // 84: twins.v1.TwinsService.ExportTwins:input_type -> twins.v1.ExportTwinsReq
+	50, // 85: twins.v1.TwinsService.ImportTwins:input_type -> twins.v1.ImportTwinsReq
+	15, // 86: twins.v1.TwinsService.AddTwin:output_type -> twins.v1.TwinRes
+	17, // 87: twins.v1.TwinsService.UpdateTwin:output_type -> twins.v1.UpdateTwinRes
+	15, // 88: twins.v1.TwinsService.ViewTwin:output_type -> twins.v1.TwinRes
+	19, // 89: twins.v1.TwinsService.RemoveTwin:output_type -> twins.v1.RemoveTwinRes
+	21, // 90: twins.v1.TwinsService.ListTwins:output_type -> twins.v1.ListTwinsRes
+	23, // 91: twins.v1.TwinsService.AttachChild:output_type -> twins.v1.ChildRes
+	23, // 92: twins.v1.TwinsService.DetachChild:output_type -> twins.v1.ChildRes
+	24, // 93: twins.v1.TwinsService.ViewSubtree:output_type -> twins.v1.SubtreeRes
+	25, // 94: twins.v1.TwinsService.ViewCompositeState:output_type -> twins.v1.CompositeStateRes
+	27, // 95: twins.v1.TwinsService.ShareTwin:output_type -> twins.v1.ShareTwinRes
+	27, // 96: twins.v1.TwinsService.UnshareTwin:output_type -> twins.v1.ShareTwinRes
+	29, // 97: twins.v1.TwinsService.ListStates:output_type -> twins.v1.ListStatesRes
+	31, // 98: twins.v1.TwinsService.ListAlarms:output_type -> twins.v1.ListAlarmsRes
+	33, // 99: twins.v1.TwinsService.StateAt:output_type -> twins.v1.StateAtRes
+	36, // 100: twins.v1.TwinsService.UpdateDesiredState:output_type -> twins.v1.DeltaRes
+	35, // 101: twins.v1.TwinsService.ViewDesiredState:output_type -> twins.v1.DesiredStateRes
+	36, // 102: twins.v1.TwinsService.ViewDelta:output_type -> twins.v1.DeltaRes
+	12, // 103: twins.v1.TwinsService.WatchStates:output_type -> twins.v1.StreamEvent
+	40, // 104: twins.v1.TwinsService.AddTemplate:output_type -> twins.v1.TemplateRes
+	42, // 105: twins.v1.TwinsService.UpdateTemplate:output_type -> twins.v1.UpdateTemplateRes
+	40, // 106: twins.v1.TwinsService.ViewTemplate:output_type -> twins.v1.TemplateRes
+	44, // 107: twins.v1.TwinsService.ListTemplates:output_type -> twins.v1.ListTemplatesRes
+	45, // 108: twins.v1.TwinsService.RemoveTemplate:output_type -> twins.v1.RemoveTemplateRes
+	47, // 109: twins.v1.TwinsService.RolloutTemplate:output_type -> twins.v1.RolloutTemplateRes
+	49, // 110: twins.v1.TwinsService.ExportTwins:output_type -> twins.v1.ExportTwinsRes
+	51, // 111: twins.v1.TwinsService.ImportTwins:output_type -> twins.v1.ImportTwinsRes
+	86, // [86:112] is the sub-list for method output_type
+	60, // [60:86] is the sub-list for method input_type
+	60, // [60:60] is the sub-list for extension type_name
+	60, // [60:60] is the sub-list for extension extendee
+	0,  // [0:60] is the sub-list for field type_name
+}
+
+func init() { file_twins_api_grpc_v1_twins_proto_init() }
+func file_twins_api_grpc_v1_twins_proto_init() {
+	if File_twins_api_grpc_v1_twins_proto != nil {
+		return
+	}
+	file_twins_api_grpc_v1_twins_proto_msgTypes[0].OneofWrappers = []any{}
+	file_twins_api_grpc_v1_twins_proto_msgTypes[1].OneofWrappers = []any{}
+	file_twins_api_grpc_v1_twins_proto_msgTypes[16].OneofWrappers = []any{}
+	file_twins_api_grpc_v1_twins_proto_msgTypes[18].OneofWrappers = []any{}
+	file_twins_api_grpc_v1_twins_proto_msgTypes[28].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_twins_api_grpc_v1_twins_proto_rawDesc), len(file_twins_api_grpc_v1_twins_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   57,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_twins_api_grpc_v1_twins_proto_goTypes,
+		DependencyIndexes: file_twins_api_grpc_v1_twins_proto_depIdxs,
+		MessageInfos:      file_twins_api_grpc_v1_twins_proto_msgTypes,
+	}.Build()
+	File_twins_api_grpc_v1_twins_proto = out.File
+	file_twins_api_grpc_v1_twins_proto_goTypes = nil
+	file_twins_api_grpc_v1_twins_proto_depIdxs = nil
+}
@@ -5,6 +5,8 @@ package http
 
 import (
 	"context"
+	"math"
+	"time"
 
 	"github.com/absmach/supermq-contrib/twins"
 	apiutil "github.com/absmach/supermq/api/http/util"
@@ -21,16 +23,19 @@ func addTwinEndpoint(svc twins.Service) endpoint.Endpoint {
 
 		twin := twins.Twin{
 			Name:     req.Name,
+			Template: req.Template,
+			Bindings: req.Bindings,
 			Metadata: req.Metadata,
 		}
-		saved, err := svc.AddTwin(ctx, req.token, twin, req.Definition)
+		saved, err := svc.AddTwin(ctx, req.token, req.domainID, twin, req.Definition)
 		if err != nil {
 			return nil, err
 		}
 
 		res := twinRes{
-			id:      saved.ID,
-			created: true,
+			domainID: req.domainID,
+			id:       saved.ID,
+			created:  true,
 		}
 		return res, nil
 	}
@@ -50,11 +55,11 @@ func updateTwinEndpoint(svc twins.Service) endpoint.Endpoint {
 			Metadata: req.Metadata,
 		}
 
-		if err := svc.UpdateTwin(ctx, req.token, twin, req.Definition); err != nil {
+		if err := svc.UpdateTwin(ctx, req.token, req.domainID, twin, req.Definition, req.revision); err != nil {
 			return nil, err
 		}
 
-		res := twinRes{id: req.id, created: false}
+		res := twinRes{domainID: req.domainID, id: req.id, created: false}
 		return res, nil
 	}
 }
@@ -67,22 +72,12 @@ func viewTwinEndpoint(svc twins.Service) endpoint.Endpoint {
 			return nil, errors.Wrap(apiutil.ErrValidation, err)
 		}
 
-		twin, err := svc.ViewTwin(ctx, req.token, req.id)
+		twin, err := svc.ViewTwin(ctx, req.token, req.domainID, req.id)
 		if err != nil {
 			return nil, err
 		}
 
-		res := viewTwinRes{
-			Owner:       twin.Owner,
-			ID:          twin.ID,
-			Name:        twin.Name,
-			Created:     twin.Created,
-			Updated:     twin.Updated,
-			Revision:    twin.Revision,
-			Definitions: twin.Definitions,
-			Metadata:    twin.Metadata,
-		}
-		return res, nil
+		return toViewTwinRes(twin), nil
 	}
 }
 
@@ -94,7 +89,7 @@ func listTwinsEndpoint(svc twins.Service) endpoint.Endpoint {
 			return nil, errors.Wrap(apiutil.ErrValidation, err)
 		}
 
-		page, err := svc.ListTwins(ctx, req.token, req.offset, req.limit, req.name, req.metadata)
+		page, err := svc.ListTwins(ctx, req.token, req.domainID, req.offset, req.limit, req.name, req.parentID, req.metadata)
 		if err != nil {
 			return nil, err
 		}
@@ -108,17 +103,95 @@ func listTwinsEndpoint(svc twins.Service) endpoint.Endpoint {
 			Twins: []viewTwinRes{},
 		}
 		for _, twin := range page.Twins {
-			view := viewTwinRes{
-				Owner:       twin.Owner,
-				ID:          twin.ID,
-				Name:        twin.Name,
-				Created:     twin.Created,
-				Updated:     twin.Updated,
-				Revision:    twin.Revision,
-				Definitions: twin.Definitions,
-				Metadata:    twin.Metadata,
-			}
-			res.Twins = append(res.Twins, view)
+			res.Twins = append(res.Twins, toViewTwinRes(twin))
+		}
+
+		return res, nil
+	}
+}
+
+func exportTwinsEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(exportTwinsReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		tws, err := svc.ExportTwins(ctx, req.token, req.domainID, req.ownerID)
+		if err != nil {
+			return nil, err
+		}
+
+		res := exportRes{
+			format: req.format,
+			twins:  []viewTwinRes{},
+		}
+		for _, twin := range tws {
+			res.twins = append(res.twins, toViewTwinRes(twin))
+		}
+
+		return res, nil
+	}
+}
+
+func importTwinsEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(importTwinsReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		tws := make([]twins.Twin, 0, len(req.twins))
+		for _, tw := range req.twins {
+			tws = append(tws, toTwin(tw))
+		}
+		opts := twins.ImportOptions{
+			RemapIDs: req.remap,
+			DryRun:   req.dryRun,
+		}
+		imp, err := svc.ImportTwins(ctx, req.token, req.domainID, tws, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return importRes{
+			DryRun: imp.DryRun,
+			IDs:    imp.IDs,
+		}, nil
+	}
+}
+
+func importDTDLEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(importDTDLReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		opts := twins.DTDLImportOptions{
+			Channel:   req.channel,
+			Interface: req.iface,
+			DryRun:    req.dryRun,
+		}
+		imp, err := svc.ImportDTDL(ctx, req.token, req.domainID, req.model, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		res := importDTDLRes{
+			DryRun: imp.DryRun,
+			Twins:  []viewTwinRes{},
+			Issues: []dtdlIssueRes{},
+		}
+		for _, tw := range imp.Twins {
+			res.Twins = append(res.Twins, toViewTwinRes(tw))
+		}
+		for _, is := range imp.Issues {
+			res.Issues = append(res.Issues, dtdlIssueRes{
+				Interface: is.Interface,
+				Element:   is.Element,
+				Reason:    is.Reason,
+			})
 		}
 
 		return res, nil
@@ -127,14 +200,126 @@ func listTwinsEndpoint(svc twins.Service) endpoint.Endpoint {
 
 func removeTwinEndpoint(svc twins.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		req := request.(viewTwinReq)
+		req := request.(removeTwinReq)
 
 		err := req.validate()
 		if err != nil {
 			return nil, errors.Wrap(apiutil.ErrValidation, err)
 		}
 
-		if err := svc.RemoveTwin(ctx, req.token, req.id); err != nil {
+		if err := svc.RemoveTwin(ctx, req.token, req.domainID, req.id, req.cascade, req.revision); err != nil {
+			return nil, err
+		}
+
+		return removeRes{}, nil
+	}
+}
+
+func shareTwinEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(shareTwinReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		if req.Relation == "" {
+			return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrMissingRelation)
+		}
+
+		if err := svc.ShareTwin(ctx, req.token, req.domainID, req.id, req.Relation, req.UserIDs); err != nil {
+			return nil, err
+		}
+
+		return shareRes{}, nil
+	}
+}
+
+func unshareTwinEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(shareTwinReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		if err := svc.UnshareTwin(ctx, req.token, req.domainID, req.id, req.UserIDs); err != nil {
+			return nil, err
+		}
+
+		return shareRes{}, nil
+	}
+}
+
+func attachChildEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(childReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		if err := svc.AttachChild(ctx, req.token, req.domainID, req.id, req.childID); err != nil {
+			return nil, err
+		}
+
+		return childRes{}, nil
+	}
+}
+
+func detachChildEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(childReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		if err := svc.DetachChild(ctx, req.token, req.domainID, req.id, req.childID); err != nil {
+			return nil, err
+		}
+
+		return childRes{}, nil
+	}
+}
+
+func addRelationEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(addRelationReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		rel := twins.Relation{
+			Source:   req.id,
+			Target:   req.Target,
+			Type:     req.Type,
+			Metadata: req.Metadata,
+		}
+		saved, err := svc.AddRelation(ctx, req.token, req.domainID, rel)
+		if err != nil {
+			return nil, err
+		}
+
+		return relationRes{toViewRelationRes(saved)}, nil
+	}
+}
+
+func removeRelationEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(relationReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		rel := twins.Relation{
+			Source: req.id,
+			Target: req.targetID,
+			Type:   req.relType,
+		}
+		if err := svc.RemoveRelation(ctx, req.token, req.domainID, rel); err != nil {
 			return nil, err
 		}
 
@@ -142,6 +327,107 @@ func removeTwinEndpoint(svc twins.Service) endpoint.Endpoint {
 	}
 }
 
+func listRelationsEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRelationsReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		filter := twins.RelationFilter{
+			Types:     req.types,
+			Direction: req.direction,
+		}
+		page, err := svc.ListRelations(ctx, req.token, req.domainID, req.id, req.offset, req.limit, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		res := relationsPageRes{
+			pageRes: pageRes{
+				Total:  page.Total,
+				Offset: page.Offset,
+				Limit:  page.Limit,
+			},
+			Relations: []viewRelationRes{},
+		}
+		for _, rel := range page.Relations {
+			res.Relations = append(res.Relations, toViewRelationRes(rel))
+		}
+
+		return res, nil
+	}
+}
+
+func traverseRelationsEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(traverseRelationsReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		query := twins.TraversalQuery{
+			Types:     req.types,
+			Direction: req.direction,
+			Depth:     int(req.depth),
+		}
+		trav, err := svc.TraverseRelations(ctx, req.token, req.domainID, req.id, query)
+		if err != nil {
+			return nil, err
+		}
+
+		res := traversalRes{
+			Origin: trav.Origin,
+			Hops:   []hopRes{},
+		}
+		for _, hop := range trav.Hops {
+			res.Hops = append(res.Hops, hopRes{
+				Depth: hop.Depth,
+				Twin:  toViewTwinRes(hop.Twin),
+				Via:   toViewRelationRes(hop.Via),
+			})
+		}
+
+		return res, nil
+	}
+}
+
+func viewSubtreeEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewTwinReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		node, err := svc.ViewSubtree(ctx, req.token, req.domainID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return toSubtreeRes(node), nil
+	}
+}
+
+func viewCompositeStateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewTwinReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		cs, err := svc.ViewCompositeState(ctx, req.token, req.domainID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return toCompositeStateRes(cs), nil
+	}
+}
+
 func listStatesEndpoint(svc twins.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(listStatesReq)
@@ -150,7 +436,18 @@ func listStatesEndpoint(svc twins.Service) endpoint.Endpoint {
 			return nil, errors.Wrap(apiutil.ErrValidation, err)
 		}
 
-		page, err := svc.ListStates(ctx, req.token, req.offset, req.limit, req.id)
+		filter := twins.StateFilter{
+			From:      toTime(req.from),
+			To:        toTime(req.to),
+			Attribute: req.attribute,
+			Dir:       req.dir,
+			Simulated: req.simulated,
+		}
+		if req.definition >= 0 {
+			filter.Definition = &req.definition
+		}
+
+		page, err := svc.ListStates(ctx, req.token, req.domainID, req.offset, req.limit, req.id, filter)
 		if err != nil {
 			return nil, err
 		}
@@ -170,6 +467,8 @@ func listStatesEndpoint(svc twins.Service) endpoint.Endpoint {
 				Definition: state.Definition,
 				Created:    state.Created,
 				Payload:    state.Payload,
+				Updated:    state.Updated,
+				Stale:      state.Stale,
 			}
 			res.States = append(res.States, view)
 		}
@@ -177,3 +476,573 @@ func listStatesEndpoint(svc twins.Service) endpoint.Endpoint {
 		return res, nil
 	}
 }
+
+func listAlarmsEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listAlarmsReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		filter := twins.AlarmFilter{
+			Attribute: req.attribute,
+			Severity:  req.severity,
+			Status:    req.status,
+		}
+		page, err := svc.ListAlarms(ctx, req.token, req.domainID, req.offset, req.limit, req.id, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		res := alarmsPageRes{
+			pageRes: pageRes{
+				Total:  page.Total,
+				Offset: page.Offset,
+				Limit:  page.Limit,
+			},
+			Alarms: []viewAlarmRes{},
+		}
+		for _, a := range page.Alarms {
+			res.Alarms = append(res.Alarms, viewAlarmRes{
+				ID:        a.ID,
+				TwinID:    a.TwinID,
+				Attribute: a.Attribute,
+				Rule:      a.Rule,
+				Condition: a.Condition,
+				Severity:  a.Severity,
+				Status:    a.Status,
+				Value:     a.Value,
+				Raised:    a.Raised,
+				Cleared:   a.Cleared,
+			})
+		}
+
+		return res, nil
+	}
+}
+
+func stateAtEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(stateAtReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		st, def, err := svc.StateAt(ctx, req.token, req.domainID, req.id, toTime(req.at))
+		if err != nil {
+			return nil, err
+		}
+
+		res := stateAtRes{
+			State: viewStateRes{
+				TwinID:     st.TwinID,
+				ID:         st.ID,
+				Definition: st.Definition,
+				Created:    st.Created,
+				Payload:    st.Payload,
+				Updated:    st.Updated,
+				Stale:      st.Stale,
+			},
+			Definition: def,
+		}
+		return res, nil
+	}
+}
+
+func listDefinitionsEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewTwinReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		defs, err := svc.ListDefinitions(ctx, req.token, req.domainID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		res := definitionsRes{
+			TwinID:      req.id,
+			Definitions: []definitionSummaryRes{},
+		}
+		for _, def := range defs {
+			sum := definitionSummaryRes{
+				Definition: def.Definition,
+				States:     def.States,
+			}
+			if def.States > 0 {
+				sum.First = &def.First
+				sum.Last = &def.Last
+			}
+			res.Definitions = append(res.Definitions, sum)
+		}
+
+		return res, nil
+	}
+}
+
+func diffDefinitionsEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(diffDefinitionsReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		diff, err := svc.DiffDefinitions(ctx, req.token, req.domainID, req.id, req.from, req.to)
+		if err != nil {
+			return nil, err
+		}
+
+		return toDefinitionDiffRes(req.id, diff), nil
+	}
+}
+
+func diffStatesEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(diffStatesReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		from := toStateSelector(req.fromID, req.fromTime)
+		to := toStateSelector(req.toID, req.toTime)
+		diff, err := svc.DiffStates(ctx, req.token, req.domainID, req.id, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		res := stateDiffRes{
+			TwinID:  req.id,
+			From:    stateRefRes{ID: diff.From.ID, Definition: diff.From.Definition, Created: diff.From.Created},
+			To:      stateRefRes{ID: diff.To.ID, Definition: diff.To.Definition, Created: diff.To.Created},
+			Added:   []attributeValueRes{},
+			Removed: []attributeValueRes{},
+			Changed: []valueChangeRes{},
+		}
+		for _, v := range diff.Added {
+			res.Added = append(res.Added, attributeValueRes{Name: v.Name, Value: v.Value})
+		}
+		for _, v := range diff.Removed {
+			res.Removed = append(res.Removed, attributeValueRes{Name: v.Name, Value: v.Value})
+		}
+		for _, c := range diff.Changed {
+			res.Changed = append(res.Changed, valueChangeRes{
+				Name:  c.Name,
+				From:  c.From,
+				To:    c.To,
+				Delta: c.Delta,
+			})
+		}
+		if diff.Definition != nil {
+			defDiff := toDefinitionDiffRes(req.id, *diff.Definition)
+			res.Definition = &defDiff
+		}
+
+		return res, nil
+	}
+}
+
+func replayStatesEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(replayStatesReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		opts := twins.ReplayOptions{
+			From:     toTime(req.From),
+			To:       toTime(req.To),
+			Simulate: req.Simulate,
+		}
+		rp, err := svc.ReplayStates(ctx, req.token, req.domainID, req.id, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		res := replayStatesRes{
+			TwinID:   req.id,
+			Simulate: rp.Simulate,
+			Messages: rp.Messages,
+			Skipped:  rp.Skipped,
+			Removed:  rp.Removed,
+			Created:  rp.Created,
+		}
+		if rp.Simulate {
+			res.States = []viewStateRes{}
+		}
+		for _, st := range rp.States {
+			res.States = append(res.States, viewStateRes{
+				TwinID:     st.TwinID,
+				ID:         st.ID,
+				Definition: st.Definition,
+				Created:    st.Created,
+				Payload:    st.Payload,
+				Updated:    st.Updated,
+				Stale:      st.Stale,
+			})
+		}
+
+		return res, nil
+	}
+}
+
+func toDefinitionDiffRes(twinID string, diff twins.DefinitionDiff) definitionDiffRes {
+	res := definitionDiffRes{
+		TwinID:  twinID,
+		From:    diff.From,
+		To:      diff.To,
+		Added:   diff.Added,
+		Removed: diff.Removed,
+		Changed: []attributeChangeRes{},
+		Delta:   diff.Delta,
+	}
+	for _, c := range diff.Changed {
+		res.Changed = append(res.Changed, attributeChangeRes{
+			Name: c.Name,
+			From: c.From,
+			To:   c.To,
+		})
+	}
+	if diff.Retention != nil {
+		res.Retention = &retentionChangeRes{
+			From: diff.Retention.From,
+			To:   diff.Retention.To,
+		}
+	}
+
+	return res
+}
+
+// toStateSelector selects the state by the ID unless it is unset, and by
+// the time in seconds otherwise.
+func toStateSelector(id int64, at float64) twins.StateSelector {
+	if id == defState {
+		return twins.StateSelector{At: toTime(at)}
+	}
+
+	return twins.StateSelector{ID: &id}
+}
+
+func rollbackDefinitionEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(rollbackDefinitionReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		def, err := svc.RollbackDefinition(ctx, req.token, req.domainID, req.id, req.definitionID, req.revision)
+		if err != nil {
+			return nil, err
+		}
+
+		res := rollbackDefinitionRes{
+			TwinID:       req.id,
+			DefinitionID: req.definitionID,
+			Definition:   def,
+		}
+		return res, nil
+	}
+}
+
+func updateDesiredStateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(desiredStateReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		delta, err := svc.UpdateDesiredState(ctx, req.token, req.domainID, req.id, req.Payload)
+		if err != nil {
+			return nil, err
+		}
+
+		res := deltaRes{
+			TwinID: req.id,
+			Delta:  delta,
+		}
+		return res, nil
+	}
+}
+
+func viewDesiredStateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewTwinReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		ds, err := svc.ViewDesiredState(ctx, req.token, req.domainID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		res := desiredStateRes{
+			TwinID:  ds.TwinID,
+			Updated: ds.Updated,
+			Payload: ds.Payload,
+		}
+		return res, nil
+	}
+}
+
+func viewDeltaEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewTwinReq)
+
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		delta, err := svc.ViewDelta(ctx, req.token, req.domainID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		res := deltaRes{
+			TwinID: req.id,
+			Delta:  delta,
+		}
+		return res, nil
+	}
+}
+
+func toViewTwinRes(twin twins.Twin) viewTwinRes {
+	res := viewTwinRes{
+		Owner:       twin.Owner,
+		Domain:      twin.Domain,
+		Parent:      twin.Parent,
+		Template:    twin.Template,
+		Bindings:    twin.Bindings,
+		ID:          twin.ID,
+		Name:        twin.Name,
+		Created:     twin.Created,
+		Updated:     twin.Updated,
+		Revision:    twin.Revision,
+		Definitions: twin.Definitions,
+		Metadata:    twin.Metadata,
+		Stale:       twin.Stale,
+	}
+	if twin.Template != "" {
+		res.TemplateRev = &twin.TemplateRevision
+	}
+
+	return res
+}
+
+func toViewRelationRes(rel twins.Relation) viewRelationRes {
+	return viewRelationRes{
+		Source:   rel.Source,
+		Type:     rel.Type,
+		Target:   rel.Target,
+		Created:  rel.Created,
+		Metadata: rel.Metadata,
+	}
+}
+
+// toTwin converts the exported twin back to the twin. The ownership and the
+// sharing of the twin are assigned on import.
+func toTwin(res viewTwinRes) twins.Twin {
+	twin := twins.Twin{
+		Parent:      res.Parent,
+		Template:    res.Template,
+		Bindings:    res.Bindings,
+		ID:          res.ID,
+		Name:        res.Name,
+		Created:     res.Created,
+		Updated:     res.Updated,
+		Definitions: res.Definitions,
+		Metadata:    res.Metadata,
+	}
+	if res.TemplateRev != nil {
+		twin.TemplateRevision = *res.TemplateRev
+	}
+
+	return twin
+}
+
+func toSubtreeRes(node twins.TwinNode) subtreeRes {
+	res := subtreeRes{viewTwinRes: toViewTwinRes(node.Twin)}
+	for _, ch := range node.Children {
+		res.Children = append(res.Children, toSubtreeRes(ch))
+	}
+	return res
+}
+
+func toCompositeStateRes(cs twins.CompositeState) compositeStateRes {
+	res := compositeStateRes{
+		TwinID:  cs.TwinID,
+		Name:    cs.Name,
+		Updated: cs.Updated,
+	}
+	// Twins which have not reported any state yet are left without one.
+	if cs.State.Payload != nil {
+		res.State = &viewStateRes{
+			TwinID:     cs.State.TwinID,
+			ID:         cs.State.ID,
+			Definition: cs.State.Definition,
+			Created:    cs.State.Created,
+			Payload:    cs.State.Payload,
+			Updated:    cs.State.Updated,
+			Stale:      cs.State.Stale,
+		}
+	}
+	for _, ch := range cs.Children {
+		res.Children = append(res.Children, toCompositeStateRes(ch))
+	}
+	return res
+}
+
+// toTime converts Unix time in seconds with fractional part to time.
+func toTime(sec float64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	whole, frac := math.Modf(sec)
+	return time.Unix(int64(whole), int64(frac*1e9))
+}
+
+func addTemplateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(addTemplateReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		tmpl := twins.Template{
+			Name:       req.Name,
+			Definition: req.Definition,
+			Metadata:   req.Metadata,
+		}
+		saved, err := svc.AddTemplate(ctx, req.token, req.domainID, tmpl)
+		if err != nil {
+			return nil, err
+		}
+
+		return templateRes{domainID: req.domainID, id: saved.ID, created: true}, nil
+	}
+}
+
+func updateTemplateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(updateTemplateReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		tmpl := twins.Template{
+			ID:         req.id,
+			Name:       req.Name,
+			Definition: req.Definition,
+			Metadata:   req.Metadata,
+		}
+		if err := svc.UpdateTemplate(ctx, req.token, req.domainID, tmpl); err != nil {
+			return nil, err
+		}
+
+		return templateRes{domainID: req.domainID, id: req.id, created: false}, nil
+	}
+}
+
+func viewTemplateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewTwinReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		tmpl, err := svc.ViewTemplate(ctx, req.token, req.domainID, req.id)
+		if err != nil {
+			return nil, err
+		}
+
+		return toViewTemplateRes(tmpl), nil
+	}
+}
+
+func listTemplatesEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listTemplatesReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		page, err := svc.ListTemplates(ctx, req.token, req.domainID, req.offset, req.limit, req.name)
+		if err != nil {
+			return nil, err
+		}
+
+		res := templatesPageRes{
+			pageRes: pageRes{
+				Total:  page.Total,
+				Offset: page.Offset,
+				Limit:  page.Limit,
+			},
+			Templates: []viewTemplateRes{},
+		}
+		for _, tmpl := range page.Templates {
+			res.Templates = append(res.Templates, toViewTemplateRes(tmpl))
+		}
+
+		return res, nil
+	}
+}
+
+func removeTemplateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(viewTwinReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		if err := svc.RemoveTemplate(ctx, req.token, req.domainID, req.id); err != nil {
+			return nil, err
+		}
+
+		return removeRes{}, nil
+	}
+}
+
+func rolloutTemplateEndpoint(svc twins.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(rolloutTemplateReq)
+		if err := req.validate(); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, err)
+		}
+
+		ro, err := svc.RolloutTemplate(ctx, req.token, req.domainID, req.id, req.Bindings)
+		if err != nil {
+			return nil, err
+		}
+
+		return rolloutRes{
+			Template: ro.Template,
+			Revision: ro.Revision,
+			Updated:  ro.Updated,
+			Skipped:  ro.Skipped,
+		}, nil
+	}
+}
+
+func toViewTemplateRes(tmpl twins.Template) viewTemplateRes {
+	return viewTemplateRes{
+		Owner:      tmpl.Owner,
+		Domain:     tmpl.Domain,
+		ID:         tmpl.ID,
+		Name:       tmpl.Name,
+		Revision:   tmpl.Revision,
+		Created:    tmpl.Created,
+		Updated:    tmpl.Updated,
+		Definition: tmpl.Definition,
+		Parameters: tmpl.Parameters(),
+		Metadata:   tmpl.Metadata,
+	}
+}
@@ -4,21 +4,35 @@
 package http_test
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/absmach/senml"
 	"github.com/absmach/supermq-contrib/pkg/testsutil"
 	"github.com/absmach/supermq-contrib/twins"
+	httpapi "github.com/absmach/supermq-contrib/twins/api/http"
 	"github.com/absmach/supermq-contrib/twins/mocks"
+	apiutil "github.com/absmach/supermq/api/http/util"
 	smqlog "github.com/absmach/supermq/logger"
 	smqauthn "github.com/absmach/supermq/pkg/authn"
 	authnmocks "github.com/absmach/supermq/pkg/authn/mocks"
+	authzmocks "github.com/absmach/supermq/pkg/authz/mocks"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
 	svcerr "github.com/absmach/supermq/pkg/errors/service"
+	policymocks "github.com/absmach/supermq/pkg/policies/mocks"
+	smqsenml "github.com/absmach/supermq/pkg/transformers/senml"
 	"github.com/absmach/supermq/pkg/uuid"
+	"github.com/absmach/supermq/readers"
+	readersmocks "github.com/absmach/supermq/readers/mocks"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -47,26 +61,31 @@ type statesPageRes struct {
 	States []stateRes `json:"states"`
 }
 
-func NewService() (twins.Service, *authnmocks.Authentication, *mocks.TwinRepository, *mocks.TwinCache, *mocks.StateRepository) {
+func NewService() (twins.Service, *authnmocks.Authentication, *authzmocks.Authorization, *policymocks.Service, *mocks.TwinRepository, *mocks.TwinCache, *mocks.StateRepository, *mocks.TemplateRepository, *mocks.RelationRepository) {
 	auth := new(authnmocks.Authentication)
+	authz := new(authzmocks.Authorization)
+	policySvc := new(policymocks.Service)
 	twinsRepo := new(mocks.TwinRepository)
 	twinCache := new(mocks.TwinCache)
 	statesRepo := new(mocks.StateRepository)
+	templatesRepo := new(mocks.TemplateRepository)
+	relationsRepo := new(mocks.RelationRepository)
 	idProvider := uuid.NewMock()
 	subs := map[string]string{"chanID": "chanID"}
 	broker := mocks.NewBroker(subs)
 
-	return twins.New(broker, auth, twinsRepo, twinCache, statesRepo, idProvider, "chanID", smqlog.NewMock()), auth, twinsRepo, twinCache, statesRepo
+	return twins.New(broker, auth, authz, policySvc, twinsRepo, twinCache, statesRepo, templatesRepo, relationsRepo, nil, idProvider, "chanID", twins.IngestConfig{}, smqlog.NewMock()), auth, authz, policySvc, twinsRepo, twinCache, statesRepo, templatesRepo, relationsRepo
 }
 
 func TestListStates(t *testing.T) {
-	svc, auth, _, _, stateRepo := NewService()
+	svc, auth, authz, _, twinRepo, _, stateRepo, _, _ := NewService()
 	ts := newServer(svc)
 	defer ts.Close()
 
 	def := mocks.CreateDefinition(channels[0:2], subtopics[0:2])
 	twin := twins.Twin{
 		Owner:       email,
+		Domain:      domainID,
 		Definitions: []twins.Definition{def},
 		ID:          testsutil.GenerateUUID(t),
 		Created:     time.Now(),
@@ -79,7 +98,7 @@ func TestListStates(t *testing.T) {
 		data = append(data, res)
 	}
 
-	baseURL := fmt.Sprintf("%s/states/%s", ts.URL, twin.ID)
+	baseURL := fmt.Sprintf("%s/%s/states/%s", ts.URL, domainID, twin.ID)
 	queryFmt := "%s?offset=%d&limit=%d"
 	cases := []struct {
 		desc            string
@@ -245,6 +264,82 @@ func TestListStates(t *testing.T) {
 			authenticateErr: nil,
 			userID:          validID,
 		},
+		{
+			desc:   "get a list of states filtered by period, definition and attribute",
+			token:  validToken,
+			status: http.StatusOK,
+			url:    fmt.Sprintf("%s?from=%d&to=%d&definition=0&attribute=temperature&dir=desc", baseURL, 1600000000, 1700000000),
+			res:    data[0:5],
+			page: twins.StatesPage{
+				States: convState(data[0:5]),
+			},
+			err:             nil,
+			authenticateErr: nil,
+			userID:          validID,
+		},
+		{
+			desc:   "get a list of simulated states",
+			token:  validToken,
+			status: http.StatusOK,
+			url:    fmt.Sprintf("%s?simulated=true&limit=%d", baseURL, 5),
+			res:    data[0:5],
+			page: twins.StatesPage{
+				States: convState(data[0:5]),
+			},
+			err:             nil,
+			authenticateErr: nil,
+			userID:          validID,
+		},
+		{
+			desc:            "get a list of states with invalid simulated",
+			token:           validToken,
+			status:          http.StatusBadRequest,
+			url:             fmt.Sprintf("%s?simulated=invalid", baseURL),
+			res:             nil,
+			err:             svcerr.ErrMalformedEntity,
+			authenticateErr: nil,
+			userID:          validID,
+		},
+		{
+			desc:            "get a list of states with from after to",
+			token:           validToken,
+			status:          http.StatusBadRequest,
+			url:             fmt.Sprintf("%s?from=%d&to=%d", baseURL, 1700000000, 1600000000),
+			res:             nil,
+			err:             svcerr.ErrMalformedEntity,
+			authenticateErr: nil,
+			userID:          validID,
+		},
+		{
+			desc:            "get a list of states with invalid from",
+			token:           validToken,
+			status:          http.StatusBadRequest,
+			url:             fmt.Sprintf("%s?from=invalid", baseURL),
+			res:             nil,
+			err:             svcerr.ErrMalformedEntity,
+			authenticateErr: nil,
+			userID:          validID,
+		},
+		{
+			desc:            "get a list of states with invalid definition",
+			token:           validToken,
+			status:          http.StatusBadRequest,
+			url:             fmt.Sprintf("%s?definition=invalid", baseURL),
+			res:             nil,
+			err:             svcerr.ErrMalformedEntity,
+			authenticateErr: nil,
+			userID:          validID,
+		},
+		{
+			desc:            "get a list of states with invalid direction",
+			token:           validToken,
+			status:          http.StatusBadRequest,
+			url:             fmt.Sprintf("%s?dir=invalid", baseURL),
+			res:             nil,
+			err:             svcerr.ErrMalformedEntity,
+			authenticateErr: nil,
+			userID:          validID,
+		},
 		{
 			desc:   "get a list of states with redundant query parameters",
 			token:  validToken,
@@ -262,7 +357,9 @@ func TestListStates(t *testing.T) {
 
 	for _, tc := range cases {
 		authCall := auth.On("Authenticate", mock.Anything, tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.authenticateErr)
-		repoCall := stateRepo.On("RetrieveAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tc.page, tc.err)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		repoCall := stateRepo.On("RetrieveAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tc.page, tc.err)
+		repoCall1 := twinRepo.On("RetrieveByID", mock.Anything, twin.ID).Return(twin, nil)
 		req := testRequest{
 			client: ts.Client(),
 			method: http.MethodGet,
@@ -281,8 +378,635 @@ func TestListStates(t *testing.T) {
 		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
 		assert.ElementsMatch(t, tc.res, resData.States, fmt.Sprintf("%s: got incorrect body from response", tc.desc))
 		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+type alarmRes struct {
+	ID       string `json:"id"`
+	TwinID   string `json:"twin_id"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Status   string `json:"status"`
+}
+
+type alarmsPageRes struct {
+	pageRes
+	Alarms []alarmRes `json:"alarms"`
+}
+
+func TestListAlarms(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, stateRepo, _, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	twin := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		Definitions: []twins.Definition{mocks.CreateDefinition(channels[0:1], subtopics[0:1])},
+		ID:          testsutil.GenerateUUID(t),
+		Created:     time.Now(),
+	}
+	cleared := time.Now()
+	alarms := []twins.Alarm{
+		{ID: testsutil.GenerateUUID(t), TwinID: twin.ID, Rule: "overheat", Severity: twins.CriticalSeverity, Status: twins.RaisedStatus, Raised: cleared},
+		{ID: testsutil.GenerateUUID(t), TwinID: twin.ID, Rule: "overheat", Severity: twins.CriticalSeverity, Status: twins.ClearedStatus, Raised: cleared.Add(-time.Hour), Cleared: &cleared},
+	}
+	var data []alarmRes
+	for _, a := range alarms {
+		data = append(data, alarmRes{ID: a.ID, TwinID: a.TwinID, Rule: a.Rule, Severity: a.Severity, Status: a.Status})
+	}
+
+	baseURL := fmt.Sprintf("%s/%s/states/%s/alarms", ts.URL, domainID, twin.ID)
+	cases := []struct {
+		desc            string
+		token           string
+		status          int
+		url             string
+		filter          twins.AlarmFilter
+		res             []alarmRes
+		page            twins.AlarmsPage
+		authenticateErr error
+		userID          string
+	}{
+		{
+			desc:   "get a list of alarms",
+			token:  validToken,
+			status: http.StatusOK,
+			url:    baseURL,
+			res:    data,
+			page:   twins.AlarmsPage{Alarms: alarms},
+			userID: validID,
+		},
+		{
+			desc:   "get a list of alarms filtered by attribute, severity and status",
+			token:  validToken,
+			status: http.StatusOK,
+			url:    fmt.Sprintf("%s?attribute=temperature&severity=%s&status=%s", baseURL, twins.CriticalSeverity, twins.RaisedStatus),
+			filter: twins.AlarmFilter{Attribute: "temperature", Severity: twins.CriticalSeverity, Status: twins.RaisedStatus},
+			res:    data[0:1],
+			page:   twins.AlarmsPage{Alarms: alarms[0:1]},
+			userID: validID,
+		},
+		{
+			desc:            "get a list of alarms with invalid token",
+			token:           invalidToken,
+			status:          http.StatusUnauthorized,
+			url:             baseURL,
+			authenticateErr: svcerr.ErrAuthentication,
+		},
+		{
+			desc:   "get a list of alarms with invalid limit",
+			token:  validToken,
+			status: http.StatusBadRequest,
+			url:    fmt.Sprintf("%s?limit=invalid", baseURL),
+			userID: validID,
+		},
+		{
+			desc:   "get a list of alarms with unknown severity",
+			token:  validToken,
+			status: http.StatusBadRequest,
+			url:    fmt.Sprintf("%s?severity=fatal", baseURL),
+			userID: validID,
+		},
+		{
+			desc:   "get a list of alarms with unknown status",
+			token:  validToken,
+			status: http.StatusBadRequest,
+			url:    fmt.Sprintf("%s?status=acknowledged", baseURL),
+			userID: validID,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.authenticateErr)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		repoCall := stateRepo.On("RetrieveAlarms", mock.Anything, uint64(0), uint64(10), twin.ID, tc.filter).Return(tc.page, nil)
+		repoCall1 := twinRepo.On("RetrieveByID", mock.Anything, twin.ID).Return(twin, nil)
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  tc.token,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+
+		var resData alarmsPageRes
+		if tc.res != nil {
+			err = json.NewDecoder(res.Body).Decode(&resData)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		}
+
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		assert.Equal(t, tc.res, resData.Alarms, fmt.Sprintf("%s: got incorrect body from response", tc.desc))
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+func TestDiffStates(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, stateRepo, _, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	twin := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		Definitions: []twins.Definition{mocks.CreateDefinition(channels[0:1], subtopics[0:1])},
+		ID:          testsutil.GenerateUUID(t),
+		Created:     time.Now(),
+	}
+	from := twins.State{TwinID: twin.ID, ID: 0, Payload: map[string]interface{}{"temperature": 20.0, "status": "ok"}}
+	to := twins.State{TwinID: twin.ID, ID: 1, Payload: map[string]interface{}{"temperature": 23.5, "pressure": 1.2}}
+
+	type diffRes struct {
+		Added []struct {
+			Name string `json:"name"`
+		} `json:"added"`
+		Removed []struct {
+			Name string `json:"name"`
+		} `json:"removed"`
+		Changed []struct {
+			Name  string  `json:"name"`
+			Delta float64 `json:"delta"`
+		} `json:"changed"`
+	}
+
+	baseURL := fmt.Sprintf("%s/%s/states/%s/diff", ts.URL, domainID, twin.ID)
+	cases := []struct {
+		desc            string
+		token           string
+		status          int
+		url             string
+		authenticateErr error
+		userID          string
+	}{
+		{
+			desc:   "diff states by ID",
+			token:  validToken,
+			status: http.StatusOK,
+			url:    fmt.Sprintf("%s?from_id=0&to_id=1", baseURL),
+			userID: validID,
+		},
+		{
+			desc:   "diff states by ID and time",
+			token:  validToken,
+			status: http.StatusOK,
+			url:    fmt.Sprintf("%s?from_id=0&to_time=%d", baseURL, time.Now().Unix()),
+			userID: validID,
+		},
+		{
+			desc:            "diff states with invalid token",
+			token:           invalidToken,
+			status:          http.StatusUnauthorized,
+			url:             fmt.Sprintf("%s?from_id=0&to_id=1", baseURL),
+			authenticateErr: svcerr.ErrAuthentication,
+		},
+		{
+			desc:   "diff states without source state",
+			token:  validToken,
+			status: http.StatusBadRequest,
+			url:    fmt.Sprintf("%s?to_id=1", baseURL),
+			userID: validID,
+		},
+		{
+			desc:   "diff states with source state selected both by ID and time",
+			token:  validToken,
+			status: http.StatusBadRequest,
+			url:    fmt.Sprintf("%s?from_id=0&from_time=%d&to_id=1", baseURL, time.Now().Unix()),
+			userID: validID,
+		},
+		{
+			desc:   "diff states with invalid state ID",
+			token:  validToken,
+			status: http.StatusBadRequest,
+			url:    fmt.Sprintf("%s?from_id=invalid&to_id=1", baseURL),
+			userID: validID,
+		},
+		{
+			desc:   "diff states with negative state ID",
+			token:  validToken,
+			status: http.StatusBadRequest,
+			url:    fmt.Sprintf("%s?from_id=-5&to_id=1", baseURL),
+			userID: validID,
+		},
+		{
+			desc:   "diff non-existing state",
+			token:  validToken,
+			status: http.StatusNotFound,
+			url:    fmt.Sprintf("%s?from_id=0&to_id=9", baseURL),
+			userID: validID,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.authenticateErr)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		repoCall := twinRepo.On("RetrieveByID", mock.Anything, twin.ID).Return(twin, nil)
+		repoCall1 := stateRepo.On("RetrieveByID", mock.Anything, twin.ID, mock.Anything).Return(func(_ context.Context, _ string, id int64) (twins.State, error) {
+			switch id {
+			case from.ID:
+				return from, nil
+			case to.ID:
+				return to, nil
+			}
+			return twins.State{}, repoerr.ErrNotFound
+		})
+		repoCall2 := stateRepo.On("RetrieveAt", mock.Anything, twin.ID, mock.Anything).Return(to, nil)
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  tc.token,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		if res.StatusCode == http.StatusOK {
+			var diff diffRes
+			err = json.NewDecoder(res.Body).Decode(&diff)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Len(t, diff.Added, 1, fmt.Sprintf("%s: expected 1 added attribute got %d", tc.desc, len(diff.Added)))
+			assert.Len(t, diff.Removed, 1, fmt.Sprintf("%s: expected 1 removed attribute got %d", tc.desc, len(diff.Removed)))
+			if assert.Len(t, diff.Changed, 1, fmt.Sprintf("%s: expected 1 changed attribute got %d", tc.desc, len(diff.Changed))) {
+				assert.Equal(t, 3.5, diff.Changed[0].Delta, fmt.Sprintf("%s: expected delta 3.5 got %v", tc.desc, diff.Changed[0].Delta))
+			}
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		repoCall2.Unset()
+	}
+}
+
+func TestReplayStates(t *testing.T) {
+	auth := new(authnmocks.Authentication)
+	authz := new(authzmocks.Authorization)
+	twinRepo := new(mocks.TwinRepository)
+	stateRepo := new(mocks.StateRepository)
+	msgRepo := new(readersmocks.MessageRepository)
+	svc := twins.New(mocks.NewBroker(map[string]string{"chanID": "chanID"}), auth, authz, new(policymocks.Service), twinRepo, new(mocks.TwinCache), stateRepo, nil, nil, msgRepo, uuid.NewMock(), "chanID", twins.IngestConfig{}, smqlog.NewMock())
+	ts := newServer(svc)
+	defer ts.Close()
+
+	attr := twins.Attribute{Name: "temperature", Channel: channels[0], Subtopic: subtopics[0], PersistState: true}
+	twin := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		Definitions: []twins.Definition{{Attributes: []twins.Attribute{attr}}},
+		ID:          testsutil.GenerateUUID(t),
+		Created:     time.Now(),
+	}
+	from := time.Now().Add(-time.Hour).Unix()
+	value := 21.5
+	page := readers.MessagesPage{
+		Total: 1,
+		Messages: []readers.Message{
+			smqsenml.Message{Channel: attr.Channel, Subtopic: attr.Subtopic, Name: attr.Name, Time: float64(from + 10), Value: &value},
+		},
+	}
+
+	type replayRes struct {
+		Messages uint64     `json:"messages"`
+		Created  int        `json:"created"`
+		States   []stateRes `json:"states"`
+	}
+
+	url := fmt.Sprintf("%s/%s/states/%s/replay", ts.URL, domainID, twin.ID)
+	cases := []struct {
+		desc            string
+		token           string
+		contentType     string
+		req             string
+		status          int
+		created         int
+		states          int
+		authenticateErr error
+		userID          string
+	}{
+		{
+			desc:        "replay states",
+			token:       validToken,
+			contentType: contentType,
+			req:         fmt.Sprintf(`{"from":%d}`, from),
+			status:      http.StatusOK,
+			created:     1,
+			userID:      validID,
+		},
+		{
+			desc:        "simulate replay of states",
+			token:       validToken,
+			contentType: contentType,
+			req:         fmt.Sprintf(`{"from":%d,"to":%d,"simulate":true}`, from, from+60),
+			status:      http.StatusOK,
+			created:     1,
+			states:      1,
+			userID:      validID,
+		},
+		{
+			desc:        "replay states ending before the current time",
+			token:       validToken,
+			contentType: contentType,
+			req:         fmt.Sprintf(`{"from":%d,"to":%d}`, from, from+60),
+			status:      http.StatusBadRequest,
+			userID:      validID,
+		},
+		{
+			desc:        "simulate replay of whole history",
+			token:       validToken,
+			contentType: contentType,
+			req:         `{"simulate":true}`,
+			status:      http.StatusOK,
+			created:     1,
+			states:      1,
+			userID:      validID,
+		},
+		{
+			desc:        "replay states with negative start time",
+			token:       validToken,
+			contentType: contentType,
+			req:         `{"from":-1,"simulate":true}`,
+			status:      http.StatusBadRequest,
+			userID:      validID,
+		},
+		{
+			desc:        "replay states with malformed request",
+			token:       validToken,
+			contentType: contentType,
+			req:         "{",
+			status:      http.StatusBadRequest,
+			userID:      validID,
+		},
+		{
+			desc:        "replay states without content type",
+			token:       validToken,
+			contentType: "",
+			req:         fmt.Sprintf(`{"from":%d}`, from),
+			status:      http.StatusUnsupportedMediaType,
+			userID:      validID,
+		},
+		{
+			desc:            "replay states with invalid token",
+			token:           invalidToken,
+			contentType:     contentType,
+			req:             fmt.Sprintf(`{"from":%d}`, from),
+			status:          http.StatusUnauthorized,
+			authenticateErr: svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.authenticateErr)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		repoCall := twinRepo.On("RetrieveByID", mock.Anything, twin.ID).Return(twin, nil)
+		readCall := msgRepo.On("ReadAll", attr.Channel, mock.Anything).Return(func(_ string, pm readers.PageMetadata) (readers.MessagesPage, error) {
+			// No messages were received since the history was read.
+			if pm.From > float64(from+10) {
+				return readers.MessagesPage{}, nil
+			}
+			return page, nil
+		})
+		repoCall1 := stateRepo.On("RetrieveAt", mock.Anything, twin.ID, mock.Anything).Return(twins.State{}, repoerr.ErrNotFound)
+		repoCall2 := stateRepo.On("RemoveAfter", mock.Anything, twin.ID, int64(-1)).Return(int64(0), nil)
+		repoCall3 := stateRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+		repoCall4 := stateRepo.On("SaveSimulated", mock.Anything, twin.ID, mock.Anything).Return(nil)
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPost,
+			url:         url,
+			contentType: tc.contentType,
+			token:       tc.token,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		if res.StatusCode == http.StatusOK {
+			var rp replayRes
+			err = json.NewDecoder(res.Body).Decode(&rp)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Equal(t, uint64(1), rp.Messages, fmt.Sprintf("%s: expected 1 message got %d", tc.desc, rp.Messages))
+			assert.Equal(t, tc.created, rp.Created, fmt.Sprintf("%s: expected %d created states got %d", tc.desc, tc.created, rp.Created))
+			assert.Len(t, rp.States, tc.states, fmt.Sprintf("%s: expected %d states got %d", tc.desc, tc.states, len(rp.States)))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		readCall.Unset()
+		repoCall1.Unset()
+		repoCall2.Unset()
+		repoCall3.Unset()
+		repoCall4.Unset()
+	}
+}
+
+type streamEventRes struct {
+	Type   string   `json:"type"`
+	TwinID string   `json:"twin_id"`
+	State  stateRes `json:"state"`
+}
+
+func TestStreamStates(t *testing.T) {
+	svc, auth, authz, _, twinRepo, twinCache, stateRepo, _, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+	dashboard := "http://dashboard.example.com"
+	dts := httptest.NewServer(httpapi.MakeHandler(svc, smqlog.NewMock(), instanceID, []string{dashboard}))
+	defer dts.Close()
+
+	def := mocks.CreateDefinition(channels[0:2], subtopics[0:2])
+	def.Attributes[0].Name = "temperature"
+	def.Attributes[1].Name = "pressure"
+	twin := twins.Twin{
+		Owner:       validID,
+		Domain:      domainID,
+		Definitions: []twins.Definition{def},
+		ID:          testsutil.GenerateUUID(t),
+		Created:     time.Now(),
+	}
+	temp := 21.5
+
+	baseURL := fmt.Sprintf("%s/%s/states/stream", ts.URL, domainID)
+	dashURL := fmt.Sprintf("%s/%s/states/stream", dts.URL, domainID)
+	cases := []struct {
+		desc            string
+		token           string
+		url             string
+		websocket       bool
+		origin          string
+		status          int
+		payload         map[string]interface{}
+		authenticateErr error
+		userID          string
+	}{
+		{
+			desc:    "stream states as server-sent events",
+			token:   validToken,
+			url:     fmt.Sprintf("%s?twin_id=%s", baseURL, twin.ID),
+			status:  http.StatusOK,
+			payload: map[string]interface{}{"temperature": temp},
+			userID:  validID,
+		},
+		{
+			desc:    "stream states filtered by attribute",
+			token:   validToken,
+			url:     fmt.Sprintf("%s?twin_id=%s&attribute=temperature,pressure", baseURL, twin.ID),
+			status:  http.StatusOK,
+			payload: map[string]interface{}{"temperature": temp},
+			userID:  validID,
+		},
+		{
+			desc:      "stream states over websocket",
+			token:     validToken,
+			url:       fmt.Sprintf("%s?twin_id=%s", baseURL, twin.ID),
+			websocket: true,
+			status:    http.StatusSwitchingProtocols,
+			payload:   map[string]interface{}{"temperature": temp},
+			userID:    validID,
+		},
+		{
+			desc:      "stream states over websocket from same origin",
+			token:     validToken,
+			url:       fmt.Sprintf("%s?twin_id=%s", baseURL, twin.ID),
+			websocket: true,
+			origin:    ts.URL,
+			status:    http.StatusSwitchingProtocols,
+			payload:   map[string]interface{}{"temperature": temp},
+			userID:    validID,
+		},
+		{
+			desc:      "stream states over websocket from other origin",
+			token:     validToken,
+			url:       fmt.Sprintf("%s?twin_id=%s", baseURL, twin.ID),
+			websocket: true,
+			origin:    dashboard,
+			status:    http.StatusForbidden,
+			userID:    validID,
+		},
+		{
+			desc:      "stream states over websocket from allowed origin",
+			token:     validToken,
+			url:       fmt.Sprintf("%s?twin_id=%s", dashURL, twin.ID),
+			websocket: true,
+			origin:    dashboard,
+			status:    http.StatusSwitchingProtocols,
+			payload:   map[string]interface{}{"temperature": temp},
+			userID:    validID,
+		},
+		{
+			desc:    "stream states with token in query",
+			url:     fmt.Sprintf("%s?twin_id=%s&authorization=%s", baseURL, twin.ID, validToken),
+			status:  http.StatusOK,
+			payload: map[string]interface{}{"temperature": temp},
+			userID:  validID,
+		},
+		{
+			desc:   "stream states without twin ID",
+			token:  validToken,
+			url:    baseURL,
+			status: http.StatusBadRequest,
+			userID: validID,
+		},
+		{
+			desc:            "stream states with invalid token",
+			token:           invalidToken,
+			url:             fmt.Sprintf("%s?twin_id=%s", baseURL, twin.ID),
+			status:          http.StatusUnauthorized,
+			authenticateErr: svcerr.ErrAuthentication,
+		},
+		{
+			desc:   "stream states with empty token",
+			url:    fmt.Sprintf("%s?twin_id=%s", baseURL, twin.ID),
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, validToken).Return(smqauthn.Session{UserID: tc.userID}, nil)
+		authCall1 := auth.On("Authenticate", mock.Anything, invalidToken).Return(smqauthn.Session{}, tc.authenticateErr)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		repoCall := twinRepo.On("RetrieveByID", mock.Anything, twin.ID).Return(twin, nil)
+		cacheCall := twinCache.On("IDs", mock.Anything, mock.Anything, mock.Anything).Return([]string{twin.ID}, nil)
+		stateCall := stateRepo.On("RetrieveLast", mock.Anything, twin.ID).Return(twins.State{}, nil)
+		stateCall1 := stateRepo.On("Save", mock.Anything, mock.Anything).Return(nil)
+
+		msg, err := mocks.CreateMessage(domainID, def.Attributes[0], []senml.Record{{Name: "temperature", Value: &temp}})
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+
+		var ev streamEventRes
+		var status int
+		if tc.websocket {
+			header := http.Header{}
+			header.Set("Authorization", apiutil.BearerPrefix+tc.token)
+			if tc.origin != "" {
+				header.Set("Origin", tc.origin)
+			}
+			conn, res, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(tc.url, "http"), header)
+			status = res.StatusCode
+			if tc.status == http.StatusSwitchingProtocols {
+				assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			}
+			if err == nil {
+				err = svc.SaveStates(context.Background(), msg)
+				assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+				err = conn.ReadJSON(&ev)
+				assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+				conn.Close()
+			}
+		} else {
+			req := testRequest{
+				client: ts.Client(),
+				method: http.MethodGet,
+				url:    tc.url,
+				token:  tc.token,
+			}
+			res, err := req.make()
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			status = res.StatusCode
+			if status == http.StatusOK {
+				err = svc.SaveStates(context.Background(), msg)
+				assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+				ev = readEvent(t, res.Body)
+			}
+			res.Body.Close()
+		}
+
+		assert.Equal(t, tc.status, status, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, status))
+		if tc.payload != nil {
+			assert.Equal(t, twins.StateCreated, ev.Type, fmt.Sprintf("%s: expected event %s got %s", tc.desc, twins.StateCreated, ev.Type))
+			assert.Equal(t, twin.ID, ev.TwinID, fmt.Sprintf("%s: expected twin %s got %s", tc.desc, twin.ID, ev.TwinID))
+			assert.Equal(t, tc.payload, ev.State.Payload, fmt.Sprintf("%s: expected payload %v got %v", tc.desc, tc.payload, ev.State.Payload))
+		}
+		authCall.Unset()
+		authCall1.Unset()
+		authzCall.Unset()
 		repoCall.Unset()
+		cacheCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+	}
+}
+
+// readEvent reads the data of the first server-sent event from the stream.
+func readEvent(t *testing.T, body io.Reader) streamEventRes {
+	var ev streamEventRes
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		err := json.Unmarshal([]byte(data), &ev)
+		assert.Nil(t, err, fmt.Sprintf("unexpected error %s", err))
+		break
 	}
+	return ev
 }
 
 func createStateResponse(id int, tw twins.Twin, rec senml.Record) stateRes {
@@ -0,0 +1,401 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package http_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/absmach/supermq-contrib/pkg/testsutil"
+	"github.com/absmach/supermq-contrib/twins"
+	smqauthn "github.com/absmach/supermq/pkg/authn"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type templateRes struct {
+	Owner      string           `json:"owner"`
+	ID         string           `json:"id"`
+	Name       string           `json:"name,omitempty"`
+	Revision   int              `json:"revision"`
+	Definition twins.Definition `json:"definition"`
+	Parameters []string         `json:"parameters,omitempty"`
+}
+
+type templatesPageRes struct {
+	pageRes
+	Templates []templateRes `json:"templates"`
+}
+
+type rolloutRes struct {
+	Template string   `json:"template_id"`
+	Revision int      `json:"revision"`
+	Updated  []string `json:"updated"`
+	Skipped  []string `json:"skipped"`
+}
+
+func TestAddTemplate(t *testing.T) {
+	svc, auth, authz, _, _, _, _, templateRepo, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	valid := `{"name":"pump","definition":{"attributes":[{"name":"pressure","channel":"{{channel}}","subtopic":"pressure","persist_state":true}]}}`
+	invalidParam := `{"definition":{"attributes":[{"name":"pressure","channel":"{{channel id}}"}]}}`
+
+	cases := []struct {
+		desc        string
+		req         string
+		contentType string
+		auth        string
+		status      int
+		location    string
+	}{
+		{
+			desc:        "add valid template",
+			req:         valid,
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusCreated,
+			location:    fmt.Sprintf("/%s/templates/123e4567-e89b-12d3-a456-000000000001", domainID),
+		},
+		{
+			desc:        "add template with invalid parameter",
+			req:         invalidParam,
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "add template with invalid request format",
+			req:         "{",
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "add template without content type",
+			req:         valid,
+			contentType: "",
+			auth:        token,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			desc:        "add template with empty token",
+			req:         valid,
+			contentType: contentType,
+			auth:        "",
+			status:      http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, tc.auth).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		repoCall := templateRepo.On("Save", mock.Anything, mock.Anything).Return(retained, nil)
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s/%s/templates", ts.URL, domainID),
+			contentType: tc.contentType,
+			token:       tc.auth,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+
+		location := res.Header.Get("Location")
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		assert.Equal(t, tc.location, location, fmt.Sprintf("%s: expected location %s got %s", tc.desc, tc.location, location))
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+	}
+}
+
+func TestViewTemplate(t *testing.T) {
+	svc, auth, authz, _, _, _, _, templateRepo, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	tmpl := twins.Template{
+		Owner:    validID,
+		Domain:   domainID,
+		ID:       testsutil.GenerateUUID(t),
+		Name:     "pump",
+		Revision: 3,
+		Definition: twins.Definition{
+			Attributes: []twins.Attribute{{Name: "pressure", Channel: "{{channel}}", Subtopic: "{{line}}.pressure"}},
+		},
+	}
+
+	cases := []struct {
+		desc        string
+		id          string
+		status      int
+		res         templateRes
+		retrieveErr error
+	}{
+		{
+			desc:   "view existing template",
+			id:     tmpl.ID,
+			status: http.StatusOK,
+			res: templateRes{
+				Owner:      tmpl.Owner,
+				ID:         tmpl.ID,
+				Name:       tmpl.Name,
+				Revision:   tmpl.Revision,
+				Definition: tmpl.Definition,
+				Parameters: []string{"channel", "line"},
+			},
+		},
+		{
+			desc:        "view non-existent template",
+			id:          strconv.FormatUint(wrongID, 10),
+			status:      http.StatusNotFound,
+			retrieveErr: repoerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, token).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		repoCall := templateRepo.On("RetrieveByID", mock.Anything, tc.id).Return(tmpl, tc.retrieveErr)
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/%s/templates/%s", ts.URL, domainID, tc.id),
+			token:  token,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+
+		var resData templateRes
+		err = json.NewDecoder(res.Body).Decode(&resData)
+		assert.Nil(t, err, fmt.Sprintf("%s: got unexpected error while decoding response body: %s\n", tc.desc, err))
+		assert.Equal(t, tc.res, resData, fmt.Sprintf("%s: expected body %v got %v", tc.desc, tc.res, resData))
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+	}
+}
+
+func TestListTemplates(t *testing.T) {
+	svc, auth, authz, _, _, _, _, templateRepo, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	var tmpls []twins.Template
+	for i := 0; i < 5; i++ {
+		tmpls = append(tmpls, twins.Template{
+			Owner:  validID,
+			Domain: domainID,
+			ID:     testsutil.GenerateUUID(t),
+			Name:   fmt.Sprintf("template-%d", i),
+		})
+	}
+
+	cases := []struct {
+		desc   string
+		url    string
+		status int
+		size   int
+	}{
+		{
+			desc:   "list templates",
+			url:    fmt.Sprintf("%s/%s/templates?offset=0&limit=5", ts.URL, domainID),
+			status: http.StatusOK,
+			size:   len(tmpls),
+		},
+		{
+			desc:   "list templates with invalid limit",
+			url:    fmt.Sprintf("%s/%s/templates?limit=0", ts.URL, domainID),
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "list templates with invalid offset",
+			url:    fmt.Sprintf("%s/%s/templates?offset=invalid", ts.URL, domainID),
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, token).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		repoCall := templateRepo.On("RetrieveAll", mock.Anything, domainID, mock.Anything, mock.Anything, mock.Anything).Return(twins.TemplatesPage{
+			PageMetadata: twins.PageMetadata{Total: uint64(len(tmpls)), Limit: 5},
+			Templates:    tmpls,
+		}, nil)
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
+			token:  token,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+
+		var resData templatesPageRes
+		err = json.NewDecoder(res.Body).Decode(&resData)
+		assert.Nil(t, err, fmt.Sprintf("%s: got unexpected error while decoding response body: %s\n", tc.desc, err))
+		assert.Len(t, resData.Templates, tc.size, fmt.Sprintf("%s: expected %d templates got %d", tc.desc, tc.size, len(resData.Templates)))
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+	}
+}
+
+func TestRemoveTemplate(t *testing.T) {
+	svc, auth, authz, _, _, _, _, templateRepo, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	tmpl := twins.Template{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+
+	cases := []struct {
+		desc        string
+		id          string
+		auth        string
+		status      int
+		retrieveErr error
+	}{
+		{
+			desc:   "remove existing template",
+			id:     tmpl.ID,
+			auth:   token,
+			status: http.StatusNoContent,
+		},
+		{
+			desc:        "remove non-existent template",
+			id:          strconv.FormatUint(wrongID, 10),
+			auth:        token,
+			status:      http.StatusNotFound,
+			retrieveErr: repoerr.ErrNotFound,
+		},
+		{
+			desc:   "remove template with empty token",
+			id:     tmpl.ID,
+			auth:   "",
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, tc.auth).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		repoCall := templateRepo.On("RetrieveByID", mock.Anything, tc.id).Return(tmpl, tc.retrieveErr)
+		repoCall1 := templateRepo.On("Remove", mock.Anything, tc.id).Return(nil)
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodDelete,
+			url:    fmt.Sprintf("%s/%s/templates/%s", ts.URL, domainID, tc.id),
+			token:  tc.auth,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+func TestRolloutTemplate(t *testing.T) {
+	svc, auth, authz, _, twinRepo, twinCache, _, templateRepo, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	tmpl := twins.Template{
+		Owner:    validID,
+		Domain:   domainID,
+		ID:       testsutil.GenerateUUID(t),
+		Revision: 1,
+		Definition: twins.Definition{
+			Attributes: []twins.Attribute{{Name: "pressure", Channel: "{{channel}}", Subtopic: "{{line}}"}},
+		},
+	}
+	derived := twins.Twin{
+		Owner:       validID,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Template:    tmpl.ID,
+		Bindings:    map[string]string{"channel": testsutil.GenerateUUID(t)},
+		Definitions: []twins.Definition{{ID: 0}},
+	}
+
+	cases := []struct {
+		desc        string
+		req         string
+		contentType string
+		status      int
+		res         rolloutRes
+	}{
+		{
+			desc:        "rollout template with bindings",
+			req:         `{"bindings":{"line":"a"}}`,
+			contentType: contentType,
+			status:      http.StatusOK,
+			res: rolloutRes{
+				Template: tmpl.ID,
+				Revision: tmpl.Revision,
+				Updated:  []string{derived.ID},
+				Skipped:  []string{},
+			},
+		},
+		{
+			desc:   "rollout template with missing bindings",
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:        "rollout template with invalid request format",
+			req:         "{",
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, token).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		tmplCall := templateRepo.On("RetrieveByID", mock.Anything, tmpl.ID).Return(tmpl, nil)
+		repoCall := twinRepo.On("RetrieveByTemplate", mock.Anything, tmpl.ID).Return([]twins.Twin{derived}, nil)
+		repoCall1 := twinRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+		cacheCall := twinCache.On("Update", mock.Anything, mock.Anything).Return(nil)
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s/%s/templates/%s/rollout", ts.URL, domainID, tmpl.ID),
+			contentType: tc.contentType,
+			token:       token,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+
+		if tc.status == http.StatusOK {
+			var resData rolloutRes
+			err = json.NewDecoder(res.Body).Decode(&resData)
+			assert.Nil(t, err, fmt.Sprintf("%s: got unexpected error while decoding response body: %s\n", tc.desc, err))
+			assert.Equal(t, tc.res, resData, fmt.Sprintf("%s: expected body %v got %v", tc.desc, tc.res, resData))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		tmplCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		cacheCall.Unset()
+	}
+}
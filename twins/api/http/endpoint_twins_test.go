@@ -4,14 +4,17 @@
 package http_test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/absmach/supermq-contrib/pkg/testsutil"
 	"github.com/absmach/supermq-contrib/twins"
@@ -19,6 +22,7 @@ import (
 	apiutil "github.com/absmach/supermq/api/http/util"
 	smqlog "github.com/absmach/supermq/logger"
 	smqauthn "github.com/absmach/supermq/pkg/authn"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
 	svcerr "github.com/absmach/supermq/pkg/errors/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -35,8 +39,11 @@ const (
 	instanceID   = "5de9b29a-feb9-11ed-be56-0242ac120002"
 	retained     = "saved"
 	validID      = "123e4567-e89b-12d3-a456-426614174000"
+	domainID     = "b6a8a5fd-9ab7-41f2-8e5f-b2f2aa6cabe6"
 )
 
+const ndjsonContentType = "application/x-ndjson"
+
 var invalidName = strings.Repeat("m", maxNameSize+1)
 
 type twinReq struct {
@@ -52,6 +59,14 @@ type twinRes struct {
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// exportedTwin is the twin record of the twins export and import.
+type exportedTwin struct {
+	ID          string             `json:"id"`
+	Parent      string             `json:"parent_id,omitempty"`
+	Name        string             `json:"name,omitempty"`
+	Definitions []twins.Definition `json:"definitions,omitempty"`
+}
+
 type pageRes struct {
 	Total  uint64 `json:"total"`
 	Offset uint64 `json:"offset"`
@@ -69,6 +84,7 @@ type testRequest struct {
 	url         string
 	contentType string
 	token       string
+	ifMatch     string
 	body        io.Reader
 }
 
@@ -83,12 +99,15 @@ func (tr testRequest) make() (*http.Response, error) {
 	if tr.contentType != "" {
 		req.Header.Set("Content-Type", tr.contentType)
 	}
+	if tr.ifMatch != "" {
+		req.Header.Set("If-Match", tr.ifMatch)
+	}
 	return tr.client.Do(req)
 }
 
 func newServer(svc twins.Service) *httptest.Server {
 	logger := smqlog.NewMock()
-	mux := httpapi.MakeHandler(svc, logger, instanceID)
+	mux := httpapi.MakeHandler(svc, logger, instanceID, nil)
 	return httptest.NewServer(mux)
 }
 
@@ -101,7 +120,7 @@ func toJSON(data interface{}) (string, error) {
 }
 
 func TestAddTwin(t *testing.T) {
-	svc, auth, twinRepo, twinCache, _ := NewService()
+	svc, auth, authz, policySvc, twinRepo, twinCache, _, _, _ := NewService()
 	ts := newServer(svc)
 	defer ts.Close()
 
@@ -131,7 +150,7 @@ func TestAddTwin(t *testing.T) {
 			contentType:     contentType,
 			auth:            token,
 			status:          http.StatusCreated,
-			location:        "/twins/123e4567-e89b-12d3-a456-000000000001",
+			location:        fmt.Sprintf("/%s/twins/123e4567-e89b-12d3-a456-000000000001", domainID),
 			err:             nil,
 			saveErr:         nil,
 			authenticateErr: nil,
@@ -143,7 +162,7 @@ func TestAddTwin(t *testing.T) {
 			contentType:     contentType,
 			auth:            token,
 			status:          http.StatusCreated,
-			location:        "/twins/123e4567-e89b-12d3-a456-000000000002",
+			location:        fmt.Sprintf("/%s/twins/123e4567-e89b-12d3-a456-000000000002", domainID),
 			err:             nil,
 			saveErr:         nil,
 			authenticateErr: nil,
@@ -223,12 +242,14 @@ func TestAddTwin(t *testing.T) {
 
 	for _, tc := range cases {
 		authCall := auth.On("Authenticate", mock.Anything, tc.auth).Return(smqauthn.Session{UserID: tc.userID}, tc.authenticateErr)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		policyCall := policySvc.On("AddPolicies", mock.Anything, mock.Anything).Return(nil)
 		repoCall := twinRepo.On("Save", mock.Anything, mock.Anything).Return(retained, tc.saveErr)
 		cacheCall := twinCache.On("Save", mock.Anything, mock.Anything).Return(tc.err)
 		req := testRequest{
 			client:      ts.Client(),
 			method:      http.MethodPost,
-			url:         fmt.Sprintf("%s/twins", ts.URL),
+			url:         fmt.Sprintf("%s/%s/twins", ts.URL, domainID),
 			contentType: tc.contentType,
 			token:       tc.auth,
 			body:        strings.NewReader(tc.req),
@@ -240,19 +261,23 @@ func TestAddTwin(t *testing.T) {
 		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
 		assert.Equal(t, tc.location, location, fmt.Sprintf("%s: expected location %s got %s", tc.desc, tc.location, location))
 		authCall.Unset()
+		authzCall.Unset()
+		policyCall.Unset()
 		repoCall.Unset()
 		cacheCall.Unset()
 	}
 }
 
 func TestUpdateTwin(t *testing.T) {
-	svc, auth, twinRepo, twinCache, _ := NewService()
+	svc, auth, authz, _, twinRepo, twinCache, _, _, _ := NewService()
 	ts := newServer(svc)
 	defer ts.Close()
 
 	twin := twins.Twin{
-		Owner: email,
-		ID:    testsutil.GenerateUUID(t),
+		Owner:    email,
+		Domain:   domainID,
+		ID:       testsutil.GenerateUUID(t),
+		Revision: 2,
 	}
 	twin.Name = twinName
 	data, err := toJSON(twin)
@@ -269,6 +294,7 @@ func TestUpdateTwin(t *testing.T) {
 		id              string
 		contentType     string
 		auth            string
+		ifMatch         string
 		status          int
 		err             error
 		retrieveErr     error
@@ -388,25 +414,79 @@ func TestUpdateTwin(t *testing.T) {
 			authenticateErr: nil,
 			userID:          validID,
 		},
+		{
+			desc:        "update twin with matching revision",
+			req:         data,
+			id:          twin.ID,
+			contentType: contentType,
+			auth:        token,
+			ifMatch:     `"2"`,
+			status:      http.StatusOK,
+			userID:      validID,
+		},
+		{
+			desc:        "update twin with any revision",
+			req:         data,
+			id:          twin.ID,
+			contentType: contentType,
+			auth:        token,
+			ifMatch:     "*",
+			status:      http.StatusOK,
+			userID:      validID,
+		},
+		{
+			desc:        "update twin with stale revision",
+			req:         data,
+			id:          twin.ID,
+			contentType: contentType,
+			auth:        token,
+			ifMatch:     `"1"`,
+			status:      http.StatusConflict,
+			userID:      validID,
+		},
+		{
+			desc:        "update twin modified concurrently",
+			req:         data,
+			id:          twin.ID,
+			contentType: contentType,
+			auth:        token,
+			ifMatch:     `"2"`,
+			status:      http.StatusConflict,
+			updateErr:   repoerr.ErrConflict,
+			userID:      validID,
+		},
+		{
+			desc:        "update twin with invalid revision",
+			req:         data,
+			id:          twin.ID,
+			contentType: contentType,
+			auth:        token,
+			ifMatch:     "invalid",
+			status:      http.StatusBadRequest,
+			userID:      validID,
+		},
 	}
 
 	for _, tc := range cases {
 		authCall := auth.On("Authenticate", mock.Anything, tc.auth).Return(smqauthn.Session{UserID: tc.userID}, tc.authenticateErr)
-		repoCall := twinRepo.On("RetrieveByID", mock.Anything, tc.id).Return(twins.Twin{}, tc.retrieveErr)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		repoCall := twinRepo.On("RetrieveByID", mock.Anything, tc.id).Return(twin, tc.retrieveErr)
 		repoCall1 := twinRepo.On("Update", mock.Anything, mock.Anything).Return(tc.updateErr)
 		cacheCall := twinCache.On("Update", mock.Anything, mock.Anything).Return(tc.err)
 		req := testRequest{
 			client:      ts.Client(),
 			method:      http.MethodPut,
-			url:         fmt.Sprintf("%s/twins/%s", ts.URL, tc.id),
+			url:         fmt.Sprintf("%s/%s/twins/%s", ts.URL, domainID, tc.id),
 			contentType: tc.contentType,
 			token:       tc.auth,
+			ifMatch:     tc.ifMatch,
 			body:        strings.NewReader(tc.req),
 		}
 		res, err := req.make()
 		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
 		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
 		authCall.Unset()
+		authzCall.Unset()
 		repoCall.Unset()
 		repoCall1.Unset()
 		cacheCall.Unset()
@@ -414,12 +494,13 @@ func TestUpdateTwin(t *testing.T) {
 }
 
 func TestViewTwin(t *testing.T) {
-	svc, auth, twinRepo, _, _ := NewService()
+	svc, auth, authz, _, twinRepo, _, _, _, _ := NewService()
 	ts := newServer(svc)
 	defer ts.Close()
 
 	twin := twins.Twin{
 		Owner:    email,
+		Domain:   domainID,
 		ID:       testsutil.GenerateUUID(t),
 		Name:     twinName,
 		Revision: 50,
@@ -469,7 +550,7 @@ func TestViewTwin(t *testing.T) {
 			desc:            "view twin by passing invalid token",
 			id:              twin.ID,
 			auth:            invalidtoken,
-			status:          http.StatusForbidden,
+			status:          http.StatusUnauthorized,
 			res:             twinRes{},
 			err:             svcerr.ErrAuthentication,
 			authenticateErr: svcerr.ErrAuthentication,
@@ -487,28 +568,34 @@ func TestViewTwin(t *testing.T) {
 
 	for _, tc := range cases {
 		authCall := auth.On("Authenticate", mock.Anything, tc.auth).Return(smqauthn.Session{UserID: tc.userID}, tc.authenticateErr)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
 		repoCall := twinRepo.On("RetrieveByID", mock.Anything, tc.id).Return(tc.twin, tc.err)
 		req := testRequest{
 			client: ts.Client(),
 			method: http.MethodGet,
-			url:    fmt.Sprintf("%s/twins/%s", ts.URL, tc.id),
+			url:    fmt.Sprintf("%s/%s/twins/%s", ts.URL, domainID, tc.id),
 			token:  tc.auth,
 		}
 		res, err := req.make()
 		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
 		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		if tc.status == http.StatusOK {
+			etag := strconv.Quote(strconv.Itoa(tc.twin.Revision))
+			assert.Equal(t, etag, res.Header.Get("ETag"), fmt.Sprintf("%s: expected ETag %s got %s", tc.desc, etag, res.Header.Get("ETag")))
+		}
 
 		var resData twinRes
 		err = json.NewDecoder(res.Body).Decode(&resData)
 		assert.Nil(t, err, fmt.Sprintf("%s: got unexpected error while decoding response body: %s\n", tc.desc, err))
 		assert.Equal(t, tc.res, resData, fmt.Sprintf("%s: expected body %v got %v", tc.desc, tc.res, resData))
 		authCall.Unset()
+		authzCall.Unset()
 		repoCall.Unset()
 	}
 }
 
 func TestListTwins(t *testing.T) {
-	svc, auth, twinRepo, _, _ := NewService()
+	svc, auth, authz, _, twinRepo, _, _, _, _ := NewService()
 	ts := newServer(svc)
 	defer ts.Close()
 
@@ -532,7 +619,7 @@ func TestListTwins(t *testing.T) {
 		data = append(data, twres)
 	}
 
-	baseURL := fmt.Sprintf("%s/twins", ts.URL)
+	baseURL := fmt.Sprintf("%s/%s/twins", ts.URL, domainID)
 	queryFmt := "%s?offset=%d&limit=%d"
 	cases := []struct {
 		desc            string
@@ -738,7 +825,8 @@ func TestListTwins(t *testing.T) {
 
 	for _, tc := range cases {
 		authCall := auth.On("Authenticate", mock.Anything, tc.auth).Return(smqauthn.Session{UserID: tc.userID}, nil)
-		repoCall := twinRepo.On("RetrieveAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tc.page, tc.err)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		repoCall := twinRepo.On("RetrieveAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tc.page, tc.err)
 		req := testRequest{
 			client: ts.Client(),
 			method: http.MethodGet,
@@ -757,25 +845,31 @@ func TestListTwins(t *testing.T) {
 		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
 		assert.ElementsMatch(t, tc.res, resData.Twins, fmt.Sprintf("%s: got incorrect list of twins", tc.desc))
 		authCall.Unset()
+		authzCall.Unset()
 		repoCall.Unset()
 	}
 }
 
 func TestRemoveTwin(t *testing.T) {
-	svc, auth, twinRepo, twinCache, _ := NewService()
+	svc, auth, authz, policySvc, twinRepo, twinCache, _, _, relationRepo := NewService()
 	ts := newServer(svc)
 	defer ts.Close()
 
 	twin := twins.Twin{
-		ID: testsutil.GenerateUUID(t),
+		Domain:   domainID,
+		ID:       testsutil.GenerateUUID(t),
+		Revision: 2,
 	}
 
 	cases := []struct {
 		desc            string
 		id              string
+		query           string
 		auth            string
+		ifMatch         string
 		status          int
 		err             error
+		retrieveErr     error
 		removeErr       error
 		authenticateErr error
 		userID          string
@@ -790,12 +884,29 @@ func TestRemoveTwin(t *testing.T) {
 			authenticateErr: nil,
 			userID:          validID,
 		},
+		{
+			desc:   "delete existing twin with its descendants",
+			id:     twin.ID,
+			query:  "?cascade=true",
+			auth:   token,
+			status: http.StatusNoContent,
+			userID: validID,
+		},
+		{
+			desc:   "delete existing twin with invalid cascade",
+			id:     twin.ID,
+			query:  "?cascade=yes",
+			auth:   token,
+			status: http.StatusBadRequest,
+			userID: validID,
+		},
 		{
 			desc:            "delete non-existent twin",
 			id:              strconv.FormatUint(wrongID, 10),
 			auth:            token,
-			status:          http.StatusNoContent,
-			err:             nil,
+			status:          http.StatusNotFound,
+			err:             svcerr.ErrNotFound,
+			retrieveErr:     svcerr.ErrNotFound,
 			removeErr:       nil,
 			authenticateErr: nil,
 			userID:          validID,
@@ -828,24 +939,1149 @@ func TestRemoveTwin(t *testing.T) {
 			removeErr:       svcerr.ErrRemoveEntity,
 			authenticateErr: svcerr.ErrAuthentication,
 		},
+		{
+			desc:    "delete twin with matching revision",
+			id:      twin.ID,
+			auth:    token,
+			ifMatch: `"2"`,
+			status:  http.StatusNoContent,
+			userID:  validID,
+		},
+		{
+			desc:    "delete twin with stale revision",
+			id:      twin.ID,
+			auth:    token,
+			ifMatch: `"1"`,
+			status:  http.StatusConflict,
+			userID:  validID,
+		},
+		{
+			desc:    "delete twin with invalid revision",
+			id:      twin.ID,
+			auth:    token,
+			ifMatch: `"-1"`,
+			status:  http.StatusBadRequest,
+			userID:  validID,
+		},
 	}
 
 	for _, tc := range cases {
 		authCall := auth.On("Authenticate", mock.Anything, tc.auth).Return(smqauthn.Session{UserID: tc.userID}, tc.authenticateErr)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		policyCall := policySvc.On("DeletePolicyFilter", mock.Anything, mock.Anything).Return(nil)
 		repoCall := twinRepo.On("Remove", mock.Anything, tc.id).Return(tc.removeErr)
+		repoCall1 := twinRepo.On("RetrieveByID", mock.Anything, tc.id).Return(twin, tc.retrieveErr)
+		repoCall2 := twinRepo.On("RetrieveChildren", mock.Anything, mock.Anything).Return([]twins.Twin{}, nil)
+		relationCall := relationRepo.On("RemoveByTwin", mock.Anything, tc.id).Return(nil)
 		cacheCall2 := twinCache.On("Remove", mock.Anything, tc.id).Return(tc.err)
+		req := testRequest{
+			client:  ts.Client(),
+			method:  http.MethodDelete,
+			url:     fmt.Sprintf("%s/%s/twins/%s%s", ts.URL, domainID, tc.id, tc.query),
+			token:   tc.auth,
+			ifMatch: tc.ifMatch,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		authCall.Unset()
+		authzCall.Unset()
+		policyCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		repoCall2.Unset()
+		relationCall.Unset()
+		cacheCall2.Unset()
+	}
+}
+
+func TestShareTwin(t *testing.T) {
+	svc, auth, authz, policySvc, twinRepo, _, _, _, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	twin := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	userID := testsutil.GenerateUUID(t)
+
+	cases := []struct {
+		desc            string
+		id              string
+		action          string
+		req             string
+		contentType     string
+		auth            string
+		status          int
+		authenticateErr error
+		userID          string
+	}{
+		{
+			desc:        "share twin",
+			id:          twin.ID,
+			action:      "share",
+			req:         fmt.Sprintf(`{"relation":"viewer","user_ids":["%s"]}`, userID),
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusNoContent,
+			userID:      validID,
+		},
+		{
+			desc:        "share twin with invalid relation",
+			id:          twin.ID,
+			action:      "share",
+			req:         fmt.Sprintf(`{"relation":"owner","user_ids":["%s"]}`, userID),
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+			userID:      validID,
+		},
+		{
+			desc:        "share twin without relation",
+			id:          twin.ID,
+			action:      "share",
+			req:         fmt.Sprintf(`{"user_ids":["%s"]}`, userID),
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+			userID:      validID,
+		},
+		{
+			desc:        "share twin without users",
+			id:          twin.ID,
+			action:      "share",
+			req:         `{"relation":"viewer","user_ids":[]}`,
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+			userID:      validID,
+		},
+		{
+			desc:        "share twin without content type",
+			id:          twin.ID,
+			action:      "share",
+			req:         fmt.Sprintf(`{"relation":"viewer","user_ids":["%s"]}`, userID),
+			contentType: "",
+			auth:        token,
+			status:      http.StatusUnsupportedMediaType,
+			userID:      validID,
+		},
+		{
+			desc:            "share twin with invalid token",
+			id:              twin.ID,
+			action:          "share",
+			req:             fmt.Sprintf(`{"relation":"viewer","user_ids":["%s"]}`, userID),
+			contentType:     contentType,
+			auth:            invalidtoken,
+			status:          http.StatusUnauthorized,
+			authenticateErr: svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "unshare twin",
+			id:          twin.ID,
+			action:      "unshare",
+			req:         fmt.Sprintf(`{"user_ids":["%s"]}`, userID),
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusNoContent,
+			userID:      validID,
+		},
+		{
+			desc:        "unshare twin with invalid data format",
+			id:          twin.ID,
+			action:      "unshare",
+			req:         "{",
+			contentType: contentType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+			userID:      validID,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, tc.auth).Return(smqauthn.Session{UserID: tc.userID}, tc.authenticateErr)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		policyCall := policySvc.On("DeletePolicies", mock.Anything, mock.Anything).Return(nil)
+		policyCall1 := policySvc.On("AddPolicies", mock.Anything, mock.Anything).Return(nil)
+		repoCall := twinRepo.On("RetrieveByID", mock.Anything, tc.id).Return(twin, nil)
+		repoCall1 := twinRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPost,
+			url:         fmt.Sprintf("%s/%s/twins/%s/%s", ts.URL, domainID, tc.id, tc.action),
+			contentType: tc.contentType,
+			token:       tc.auth,
+			body:        strings.NewReader(tc.req),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		authCall.Unset()
+		authzCall.Unset()
+		policyCall.Unset()
+		policyCall1.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+func TestTwinChildren(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, _, _, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	parent := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	child := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	attached := child
+	attached.Parent = parent.ID
+
+	cases := []struct {
+		desc   string
+		method string
+		child  twins.Twin
+		auth   string
+		status int
+	}{
+		{
+			desc:   "attach child",
+			method: http.MethodPost,
+			child:  child,
+			auth:   token,
+			status: http.StatusNoContent,
+		},
+		{
+			desc:   "attach already attached child",
+			method: http.MethodPost,
+			child:  attached,
+			auth:   token,
+			status: http.StatusConflict,
+		},
+		{
+			desc:   "attach child with empty token",
+			method: http.MethodPost,
+			child:  child,
+			auth:   "",
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "detach child",
+			method: http.MethodDelete,
+			child:  attached,
+			auth:   token,
+			status: http.StatusNoContent,
+		},
+		{
+			desc:   "detach child which is not attached",
+			method: http.MethodDelete,
+			child:  child,
+			auth:   token,
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, token).Return(smqauthn.Session{UserID: validID}, nil)
+		authCall1 := auth.On("Authenticate", mock.Anything, "").Return(smqauthn.Session{}, svcerr.ErrAuthentication)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		repoCall := twinRepo.On("RetrieveByID", mock.Anything, parent.ID).Return(parent, nil)
+		repoCall1 := twinRepo.On("RetrieveByID", mock.Anything, tc.child.ID).Return(tc.child, nil)
+		repoCall2 := twinRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
 		req := testRequest{
 			client: ts.Client(),
+			method: tc.method,
+			url:    fmt.Sprintf("%s/%s/twins/%s/children/%s", ts.URL, domainID, parent.ID, tc.child.ID),
+			token:  tc.auth,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		authCall.Unset()
+		authCall1.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		repoCall2.Unset()
+	}
+}
+
+func TestTwinRelations(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, _, _, relationRepo := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	pump := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	tank := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	feeds := twins.Relation{Domain: domainID, Source: pump.ID, Type: "feeds", Target: tank.ID}
+	page := twins.RelationsPage{
+		PageMetadata: twins.PageMetadata{Total: 1, Offset: 0, Limit: 10},
+		Relations:    []twins.Relation{feeds},
+	}
+	twinsURL := fmt.Sprintf("%s/%s/twins/%s", ts.URL, domainID, pump.ID)
+	data, err := toJSON(map[string]string{"type": "feeds", "target_id": tank.ID})
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	noType, err := toJSON(map[string]string{"target_id": tank.ID})
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	invalidType, err := toJSON(map[string]string{"type": "Feeds!", "target_id": tank.ID})
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	cases := []struct {
+		desc        string
+		method      string
+		url         string
+		contentType string
+		body        string
+		auth        string
+		saveErr     error
+		removeErr   error
+		status      int
+		count       int
+	}{
+		{
+			desc:        "add relation",
+			method:      http.MethodPost,
+			url:         twinsURL + "/relations",
+			contentType: contentType,
+			body:        data,
+			auth:        token,
+			status:      http.StatusCreated,
+		},
+		{
+			desc:        "add existing relation",
+			method:      http.MethodPost,
+			url:         twinsURL + "/relations",
+			contentType: contentType,
+			body:        data,
+			auth:        token,
+			saveErr:     repoerr.ErrConflict,
+			status:      http.StatusConflict,
+		},
+		{
+			desc:        "add relation without type",
+			method:      http.MethodPost,
+			url:         twinsURL + "/relations",
+			contentType: contentType,
+			body:        noType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "add relation with invalid type",
+			method:      http.MethodPost,
+			url:         twinsURL + "/relations",
+			contentType: contentType,
+			body:        invalidType,
+			auth:        token,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "add relation with empty token",
+			method:      http.MethodPost,
+			url:         twinsURL + "/relations",
+			contentType: contentType,
+			body:        data,
+			auth:        "",
+			status:      http.StatusUnauthorized,
+		},
+		{
+			desc:   "list relations",
+			method: http.MethodGet,
+			url:    twinsURL + "/relations?type=feeds&direction=out",
+			auth:   token,
+			status: http.StatusOK,
+			count:  1,
+		},
+		{
+			desc:   "list relations with invalid direction",
+			method: http.MethodGet,
+			url:    twinsURL + "/relations?direction=sideways",
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "list relations with invalid limit",
+			method: http.MethodGet,
+			url:    twinsURL + "/relations?limit=0",
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "remove relation",
 			method: http.MethodDelete,
-			url:    fmt.Sprintf("%s/twins/%s", ts.URL, tc.id),
+			url:    fmt.Sprintf("%s/relations/feeds/%s", twinsURL, tank.ID),
+			auth:   token,
+			status: http.StatusNoContent,
+		},
+		{
+			desc:      "remove non-existing relation",
+			method:    http.MethodDelete,
+			url:       fmt.Sprintf("%s/relations/feeds/%s", twinsURL, tank.ID),
+			auth:      token,
+			removeErr: repoerr.ErrNotFound,
+			status:    http.StatusNotFound,
+		},
+		{
+			desc:   "traverse relations",
+			method: http.MethodGet,
+			url:    twinsURL + "/traverse?type=feeds&depth=3",
+			auth:   token,
+			status: http.StatusOK,
+			count:  1,
+		},
+		{
+			desc:   "traverse relations with invalid depth",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/traverse?depth=%d", twinsURL, twins.MaxTraversalDepth+1),
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "traverse relations with invalid direction",
+			method: http.MethodGet,
+			url:    twinsURL + "/traverse?direction=sideways",
+			auth:   token,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, token).Return(smqauthn.Session{UserID: validID}, nil)
+		authCall1 := auth.On("Authenticate", mock.Anything, "").Return(smqauthn.Session{}, svcerr.ErrAuthentication)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		repoCall := twinRepo.On("RetrieveByID", mock.Anything, pump.ID).Return(pump, nil)
+		repoCall1 := twinRepo.On("RetrieveByID", mock.Anything, tank.ID).Return(tank, nil)
+		repoCall2 := relationRepo.On("Save", mock.Anything, mock.Anything).Return(tc.saveErr)
+		repoCall3 := relationRepo.On("Remove", mock.Anything, pump.ID, "feeds", tank.ID).Return(tc.removeErr)
+		repoCall4 := relationRepo.On("RetrieveAll", mock.Anything, pump.ID, mock.Anything, mock.Anything, mock.Anything).Return(page, nil)
+		repoCall5 := relationRepo.On("RetrieveByTwins", mock.Anything, []string{pump.ID}, mock.Anything).Return([]twins.Relation{feeds}, nil)
+		repoCall6 := relationRepo.On("RetrieveByTwins", mock.Anything, []string{tank.ID}, mock.Anything).Return([]twins.Relation{}, nil)
+		req := testRequest{
+			client:      ts.Client(),
+			method:      tc.method,
+			url:         tc.url,
+			contentType: tc.contentType,
+			token:       tc.auth,
+			body:        strings.NewReader(tc.body),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		if tc.count > 0 {
+			var body struct {
+				Relations []json.RawMessage `json:"relations"`
+				Hops      []json.RawMessage `json:"hops"`
+			}
+			err := json.NewDecoder(res.Body).Decode(&body)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			count := len(body.Relations) + len(body.Hops)
+			assert.Equal(t, tc.count, count, fmt.Sprintf("%s: expected %d entries got %d", tc.desc, tc.count, count))
+		}
+		authCall.Unset()
+		authCall1.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		repoCall2.Unset()
+		repoCall3.Unset()
+		repoCall4.Unset()
+		repoCall5.Unset()
+		repoCall6.Unset()
+	}
+}
+
+func TestViewSubtree(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, stateRepo, _, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	parent := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+		Name:   "line",
+	}
+	child := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		Parent: parent.ID,
+		ID:     testsutil.GenerateUUID(t),
+		Name:   "machine",
+	}
+	state := twins.State{
+		TwinID:  child.ID,
+		Created: time.Now().UTC().Truncate(time.Second),
+		Payload: map[string]interface{}{"temperature": 42.0},
+	}
+
+	authCall := auth.On("Authenticate", mock.Anything, token).Return(smqauthn.Session{UserID: validID}, nil)
+	authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+	repoCall := twinRepo.On("RetrieveByID", mock.Anything, parent.ID).Return(parent, nil)
+	repoCall1 := twinRepo.On("RetrieveChildren", mock.Anything, []string{parent.ID}).Return([]twins.Twin{child}, nil)
+	repoCall2 := twinRepo.On("RetrieveChildren", mock.Anything, []string{child.ID}).Return([]twins.Twin{}, nil)
+	stateCall := stateRepo.On("RetrieveLast", mock.Anything, parent.ID).Return(twins.State{}, nil)
+	stateCall1 := stateRepo.On("RetrieveLast", mock.Anything, child.ID).Return(state, nil)
+	defer func() {
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		repoCall2.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+	}()
+
+	req := testRequest{
+		client: ts.Client(),
+		method: http.MethodGet,
+		url:    fmt.Sprintf("%s/%s/twins/%s/subtree", ts.URL, domainID, parent.ID),
+		token:  token,
+	}
+	res, err := req.make()
+	assert.Nil(t, err, fmt.Sprintf("view subtree: unexpected error %s", err))
+	assert.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("view subtree: expected status code %d got %d", http.StatusOK, res.StatusCode))
+
+	var tree struct {
+		ID       string `json:"id"`
+		Children []struct {
+			ID     string `json:"id"`
+			Parent string `json:"parent_id"`
+		} `json:"children"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&tree)
+	assert.Nil(t, err, fmt.Sprintf("view subtree: unexpected error %s", err))
+	assert.Equal(t, parent.ID, tree.ID, fmt.Sprintf("view subtree: expected root %s got %s", parent.ID, tree.ID))
+	assert.Len(t, tree.Children, 1, "view subtree: expected a single child")
+	assert.Equal(t, parent.ID, tree.Children[0].Parent, fmt.Sprintf("view subtree: expected parent %s got %s", parent.ID, tree.Children[0].Parent))
+
+	req.url = fmt.Sprintf("%s/%s/twins/%s/composite", ts.URL, domainID, parent.ID)
+	res, err = req.make()
+	assert.Nil(t, err, fmt.Sprintf("view composite state: unexpected error %s", err))
+	assert.Equal(t, http.StatusOK, res.StatusCode, fmt.Sprintf("view composite state: expected status code %d got %d", http.StatusOK, res.StatusCode))
+
+	var cs struct {
+		TwinID   string           `json:"twin_id"`
+		State    *json.RawMessage `json:"state"`
+		Updated  time.Time        `json:"updated"`
+		Children []struct {
+			TwinID string `json:"twin_id"`
+			State  struct {
+				Payload map[string]interface{} `json:"payload"`
+			} `json:"state"`
+		} `json:"children"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&cs)
+	assert.Nil(t, err, fmt.Sprintf("view composite state: unexpected error %s", err))
+	assert.Nil(t, cs.State, "view composite state: expected no state of the root twin")
+	assert.True(t, state.Created.Equal(cs.Updated), fmt.Sprintf("view composite state: expected updated %s got %s", state.Created, cs.Updated))
+	assert.Len(t, cs.Children, 1, "view composite state: expected a single child")
+	assert.Equal(t, state.Payload, cs.Children[0].State.Payload, fmt.Sprintf("view composite state: expected payload %v got %v", state.Payload, cs.Children[0].State.Payload))
+}
+
+func TestTwinDefinitions(t *testing.T) {
+	svc, auth, authz, _, twinRepo, twinCache, stateRepo, _, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	created := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	def0 := twins.Definition{
+		ID:         0,
+		Created:    created,
+		Attributes: []twins.Attribute{{Name: "temperature", Channel: validID, Subtopic: "temperature", PersistState: true}},
+	}
+	def1 := twins.Definition{
+		ID:         1,
+		Created:    created.Add(time.Minute),
+		Attributes: []twins.Attribute{{Name: "pressure", Channel: validID, Subtopic: "pressure", PersistState: true}},
+	}
+	twin := twins.Twin{
+		Owner:       validID,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Name:        twinName,
+		Revision:    2,
+		Definitions: []twins.Definition{def0, def1},
+	}
+	state := twins.State{TwinID: twin.ID, Definition: def0.ID, Created: created.Add(time.Second)}
+
+	authCall := auth.On("Authenticate", mock.Anything, token).Return(smqauthn.Session{UserID: validID}, nil)
+	authCall1 := auth.On("Authenticate", mock.Anything, invalidtoken).Return(smqauthn.Session{}, svcerr.ErrAuthentication)
+	authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+	repoCall := twinRepo.On("RetrieveByID", mock.Anything, twin.ID).Return(twin, nil)
+	repoCall1 := twinRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+	cacheCall := twinCache.On("Update", mock.Anything, mock.Anything).Return(nil)
+	stateCall := stateRepo.On("RetrieveAll", mock.Anything, uint64(0), uint64(1), twin.ID, mock.Anything).Return(func(_ context.Context, _, _ uint64, _ string, filter twins.StateFilter) (twins.StatesPage, error) {
+		if *filter.Definition != def0.ID {
+			return twins.StatesPage{States: []twins.State{}}, nil
+		}
+		return twins.StatesPage{PageMetadata: twins.PageMetadata{Total: 1}, States: []twins.State{state}}, nil
+	})
+	defer func() {
+		authCall.Unset()
+		authCall1.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		cacheCall.Unset()
+		stateCall.Unset()
+	}()
+
+	baseURL := fmt.Sprintf("%s/%s/twins/%s/definitions", ts.URL, domainID, twin.ID)
+	cases := []struct {
+		desc    string
+		method  string
+		url     string
+		token   string
+		ifMatch string
+		status  int
+	}{
+		{
+			desc:   "list definitions",
+			method: http.MethodGet,
+			url:    baseURL,
+			token:  token,
+			status: http.StatusOK,
+		},
+		{
+			desc:   "list definitions with invalid token",
+			method: http.MethodGet,
+			url:    baseURL,
+			token:  invalidtoken,
+			status: http.StatusUnauthorized,
+		},
+		{
+			desc:   "diff definitions",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/diff?from=0&to=1", baseURL),
+			token:  token,
+			status: http.StatusOK,
+		},
+		{
+			desc:   "diff definitions without target definition",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/diff?from=0", baseURL),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "diff definitions with invalid source definition",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/diff?from=first&to=1", baseURL),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "diff non-existing definitions",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("%s/diff?from=0&to=5", baseURL),
+			token:  token,
+			status: http.StatusNotFound,
+		},
+		{
+			desc:    "rollback definition",
+			method:  http.MethodPost,
+			url:     fmt.Sprintf("%s/0/rollback", baseURL),
+			token:   token,
+			ifMatch: `"2"`,
+			status:  http.StatusOK,
+		},
+		{
+			desc:    "rollback definition with stale revision",
+			method:  http.MethodPost,
+			url:     fmt.Sprintf("%s/0/rollback", baseURL),
+			token:   token,
+			ifMatch: `"1"`,
+			status:  http.StatusConflict,
+		},
+		{
+			desc:   "rollback to latest definition",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/1/rollback", baseURL),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "rollback to invalid definition",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/latest/rollback", baseURL),
+			token:  token,
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "rollback definition with invalid token",
+			method: http.MethodPost,
+			url:    fmt.Sprintf("%s/0/rollback", baseURL),
+			token:  invalidtoken,
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		req := testRequest{
+			client:  ts.Client(),
+			method:  tc.method,
+			url:     tc.url,
+			token:   tc.token,
+			ifMatch: tc.ifMatch,
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+	}
+
+	req := testRequest{
+		client: ts.Client(),
+		method: http.MethodGet,
+		url:    baseURL,
+		token:  token,
+	}
+	res, err := req.make()
+	assert.Nil(t, err, fmt.Sprintf("list definitions: unexpected error %s", err))
+	var defs struct {
+		Definitions []struct {
+			ID     int        `json:"id"`
+			States uint64     `json:"states"`
+			First  *time.Time `json:"first_state"`
+		} `json:"definitions"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&defs)
+	assert.Nil(t, err, fmt.Sprintf("list definitions: unexpected error %s", err))
+	assert.Len(t, defs.Definitions, 2, "list definitions: expected two definitions")
+	assert.Equal(t, uint64(1), defs.Definitions[0].States, fmt.Sprintf("list definitions: expected a single state got %d", defs.Definitions[0].States))
+	assert.True(t, state.Created.Equal(*defs.Definitions[0].First), fmt.Sprintf("list definitions: expected first state %s got %s", state.Created, defs.Definitions[0].First))
+	assert.Nil(t, defs.Definitions[1].First, "list definitions: expected no states of the latest definition")
+
+	req.url = fmt.Sprintf("%s/diff?from=0&to=1", baseURL)
+	res, err = req.make()
+	assert.Nil(t, err, fmt.Sprintf("diff definitions: unexpected error %s", err))
+	var diff struct {
+		Added   []twins.Attribute `json:"added"`
+		Removed []twins.Attribute `json:"removed"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&diff)
+	assert.Nil(t, err, fmt.Sprintf("diff definitions: unexpected error %s", err))
+	assert.Equal(t, def1.Attributes, diff.Added, fmt.Sprintf("diff definitions: expected added %v got %v", def1.Attributes, diff.Added))
+	assert.Equal(t, def0.Attributes, diff.Removed, fmt.Sprintf("diff definitions: expected removed %v got %v", def0.Attributes, diff.Removed))
+}
+
+func TestExportTwins(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, _, _, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	def := twins.Definition{
+		Attributes: []twins.Attribute{
+			{Name: "temperature", Channel: testsutil.GenerateUUID(t), Subtopic: "engine", PersistState: true},
+		},
+	}
+	var data []twins.Twin
+	for i := 0; i < 3; i++ {
+		data = append(data, twins.Twin{
+			Owner:       validID,
+			Domain:      domainID,
+			ID:          testsutil.GenerateUUID(t),
+			Name:        fmt.Sprintf("%s-%d", twinName, i),
+			Definitions: []twins.Definition{{ID: 0}, def},
+		})
+	}
+	page := twins.Page{PageMetadata: twins.PageMetadata{Total: uint64(len(data))}, Twins: data}
+
+	baseURL := fmt.Sprintf("%s/%s/twins/export", ts.URL, domainID)
+	cases := []struct {
+		desc        string
+		auth        string
+		url         string
+		status      int
+		contentType string
+		ndjson      bool
+		authnErr    error
+	}{
+		{
+			desc:        "export twins as JSON",
+			auth:        token,
+			url:         baseURL,
+			status:      http.StatusOK,
+			contentType: contentType,
+		},
+		{
+			desc:        "export twins as NDJSON",
+			auth:        token,
+			url:         baseURL + "?format=ndjson",
+			status:      http.StatusOK,
+			contentType: ndjsonContentType,
+			ndjson:      true,
+		},
+		{
+			desc:        "export twins with unknown format",
+			auth:        token,
+			url:         baseURL + "?format=xml",
+			status:      http.StatusBadRequest,
+			contentType: contentType,
+		},
+		{
+			desc:        "export twins with invalid token",
+			auth:        invalidtoken,
+			url:         baseURL,
+			status:      http.StatusUnauthorized,
+			contentType: contentType,
+			authnErr:    svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, tc.auth).Return(smqauthn.Session{UserID: validID}, tc.authnErr)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		repoCall := twinRepo.On("RetrieveAll", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(page, nil)
+		req := testRequest{
+			client: ts.Client(),
+			method: http.MethodGet,
+			url:    tc.url,
 			token:  tc.auth,
 		}
 		res, err := req.make()
 		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
 		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		assert.Equal(t, tc.contentType, res.Header.Get("Content-Type"), fmt.Sprintf("%s: expected content type %s got %s", tc.desc, tc.contentType, res.Header.Get("Content-Type")))
+		if tc.status == http.StatusOK {
+			var exported []exportedTwin
+			dec := json.NewDecoder(res.Body)
+			if tc.ndjson {
+				for dec.More() {
+					var tw exportedTwin
+					err = dec.Decode(&tw)
+					assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+					exported = append(exported, tw)
+				}
+			} else {
+				err = dec.Decode(&exported)
+				assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			}
+			assert.Len(t, exported, len(data), fmt.Sprintf("%s: expected %d twins got %d", tc.desc, len(data), len(exported)))
+			for i, tw := range exported {
+				assert.Equal(t, data[i].ID, tw.ID, fmt.Sprintf("%s: expected twin %s got %s", tc.desc, data[i].ID, tw.ID))
+				assert.Equal(t, data[i].Definitions, tw.Definitions, fmt.Sprintf("%s: expected definitions %v got %v", tc.desc, data[i].Definitions, tw.Definitions))
+			}
+		}
 		authCall.Unset()
+		authzCall.Unset()
 		repoCall.Unset()
-		cacheCall2.Unset()
+	}
+}
+
+func TestImportTwins(t *testing.T) {
+	svc, auth, authz, policySvc, twinRepo, twinCache, _, _, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	def := twins.Definition{
+		Attributes: []twins.Attribute{
+			{Name: "temperature", Channel: testsutil.GenerateUUID(t), Subtopic: "engine", PersistState: true},
+		},
+	}
+	parent := exportedTwin{ID: testsutil.GenerateUUID(t), Name: "plant", Definitions: []twins.Definition{def}}
+	child := exportedTwin{ID: testsutil.GenerateUUID(t), Parent: parent.ID, Name: "line", Definitions: []twins.Definition{{ID: 0}, def}}
+
+	array, err := toJSON([]exportedTwin{parent, child})
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	p, err := toJSON(parent)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	c, err := toJSON(child)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	ndjson := p + "\n" + c + "\n"
+
+	baseURL := fmt.Sprintf("%s/%s/twins/import", ts.URL, domainID)
+	cases := []struct {
+		desc        string
+		auth        string
+		url         string
+		contentType string
+		body        string
+		status      int
+		saved       int
+		authnErr    error
+	}{
+		{
+			desc:        "import twins from JSON",
+			auth:        token,
+			url:         baseURL,
+			contentType: contentType,
+			body:        array,
+			status:      http.StatusCreated,
+			saved:       2,
+		},
+		{
+			desc:        "import twins from NDJSON remapping IDs",
+			auth:        token,
+			url:         baseURL + "?remap=true",
+			contentType: ndjsonContentType,
+			body:        ndjson,
+			status:      http.StatusCreated,
+			saved:       2,
+		},
+		{
+			desc:        "dry run import of twins",
+			auth:        token,
+			url:         baseURL + "?dry_run=true",
+			contentType: contentType,
+			body:        array,
+			status:      http.StatusOK,
+		},
+		{
+			desc:        "import no twins",
+			auth:        token,
+			url:         baseURL,
+			contentType: contentType,
+			body:        "[]",
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "import twins with malformed body",
+			auth:        token,
+			url:         baseURL,
+			contentType: contentType,
+			body:        "{",
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "import twins with invalid dry run",
+			auth:        token,
+			url:         baseURL + "?dry_run=maybe",
+			contentType: contentType,
+			body:        array,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "import twins with unsupported content type",
+			auth:        token,
+			url:         baseURL,
+			contentType: "text/plain",
+			body:        array,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			desc:        "import twins with invalid token",
+			auth:        invalidtoken,
+			url:         baseURL,
+			contentType: contentType,
+			body:        array,
+			status:      http.StatusUnauthorized,
+			authnErr:    svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		saved := 0
+		authCall := auth.On("Authenticate", mock.Anything, tc.auth).Return(smqauthn.Session{UserID: validID}, tc.authnErr)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		policyCall := policySvc.On("AddPolicies", mock.Anything, mock.Anything).Return(nil)
+		repoCall := twinRepo.On("RetrieveByID", mock.Anything, mock.Anything).Return(twins.Twin{}, repoerr.ErrNotFound)
+		repoCall1 := twinRepo.On("Save", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+			saved++
+		}).Return("", nil)
+		cacheCall := twinCache.On("Save", mock.Anything, mock.Anything).Return(nil)
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPost,
+			url:         tc.url,
+			contentType: tc.contentType,
+			token:       tc.auth,
+			body:        strings.NewReader(tc.body),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		assert.Equal(t, tc.saved, saved, fmt.Sprintf("%s: expected %d saved twins got %d", tc.desc, tc.saved, saved))
+		if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusCreated {
+			var imp struct {
+				DryRun bool              `json:"dry_run"`
+				IDs    map[string]string `json:"ids"`
+			}
+			err = json.NewDecoder(res.Body).Decode(&imp)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Len(t, imp.IDs, 2, fmt.Sprintf("%s: expected 2 imported IDs got %d", tc.desc, len(imp.IDs)))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		policyCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		cacheCall.Unset()
+	}
+}
+
+func TestImportDTDL(t *testing.T) {
+	svc, auth, authz, policySvc, twinRepo, twinCache, _, _, _ := NewService()
+	ts := newServer(svc)
+	defer ts.Close()
+
+	channel := testsutil.GenerateUUID(t)
+	model := `{
+		"@context": "dtmi:dtdl:context;3",
+		"@id": "dtmi:com:example:Pump;1",
+		"@type": "Interface",
+		"displayName": "Pump",
+		"contents": [
+			{"@type": "Telemetry", "name": "temperature", "schema": "double", "unit": "degreeCelsius"},
+			{"@type": "Command", "name": "reboot"}
+		]
+	}`
+
+	baseURL := fmt.Sprintf("%s/%s/twins/import/dtdl", ts.URL, domainID)
+	cases := []struct {
+		desc        string
+		auth        string
+		url         string
+		contentType string
+		body        string
+		status      int
+		saved       int
+		authnErr    error
+	}{
+		{
+			desc:        "import DTDL model",
+			auth:        token,
+			url:         fmt.Sprintf("%s?channel=%s", baseURL, channel),
+			contentType: contentType,
+			body:        model,
+			status:      http.StatusCreated,
+			saved:       1,
+		},
+		{
+			desc:        "import DTDL model as JSON-LD",
+			auth:        token,
+			url:         fmt.Sprintf("%s?channel=%s", baseURL, channel),
+			contentType: "application/ld+json",
+			body:        model,
+			status:      http.StatusCreated,
+			saved:       1,
+		},
+		{
+			desc:        "dry run import of DTDL model",
+			auth:        token,
+			url:         fmt.Sprintf("%s?channel=%s&dry_run=true", baseURL, channel),
+			contentType: contentType,
+			body:        model,
+			status:      http.StatusOK,
+		},
+		{
+			desc:        "import DTDL model without channel",
+			auth:        token,
+			url:         baseURL,
+			contentType: contentType,
+			body:        model,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "import DTDL model with invalid dry run",
+			auth:        token,
+			url:         fmt.Sprintf("%s?channel=%s&dry_run=maybe", baseURL, channel),
+			contentType: contentType,
+			body:        model,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "import unknown DTDL interface",
+			auth:        token,
+			url:         fmt.Sprintf("%s?channel=%s&interface=%s", baseURL, channel, url.QueryEscape("dtmi:com:example:Valve;1")),
+			contentType: contentType,
+			body:        model,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "import malformed DTDL model",
+			auth:        token,
+			url:         fmt.Sprintf("%s?channel=%s", baseURL, channel),
+			contentType: contentType,
+			body:        `{"@context"`,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "import empty DTDL model",
+			auth:        token,
+			url:         fmt.Sprintf("%s?channel=%s", baseURL, channel),
+			contentType: contentType,
+			status:      http.StatusBadRequest,
+		},
+		{
+			desc:        "import DTDL model with unsupported content type",
+			auth:        token,
+			url:         fmt.Sprintf("%s?channel=%s", baseURL, channel),
+			contentType: "text/plain",
+			body:        model,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			desc:        "import DTDL model with empty token",
+			url:         fmt.Sprintf("%s?channel=%s", baseURL, channel),
+			contentType: contentType,
+			body:        model,
+			status:      http.StatusUnauthorized,
+		},
+		{
+			desc:        "import DTDL model with invalid token",
+			auth:        invalidtoken,
+			url:         fmt.Sprintf("%s?channel=%s", baseURL, channel),
+			contentType: contentType,
+			body:        model,
+			status:      http.StatusUnauthorized,
+			authnErr:    svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		saved := 0
+		authCall := auth.On("Authenticate", mock.Anything, tc.auth).Return(smqauthn.Session{UserID: validID}, tc.authnErr)
+		authzCall := authz.On("Authorize", mock.Anything, mock.Anything).Return(nil)
+		policyCall := policySvc.On("AddPolicies", mock.Anything, mock.Anything).Return(nil)
+		repoCall := twinRepo.On("Save", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+			saved++
+		}).Return("", nil)
+		cacheCall := twinCache.On("Save", mock.Anything, mock.Anything).Return(nil)
+		req := testRequest{
+			client:      ts.Client(),
+			method:      http.MethodPost,
+			url:         tc.url,
+			contentType: tc.contentType,
+			token:       tc.auth,
+			body:        strings.NewReader(tc.body),
+		}
+		res, err := req.make()
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+		assert.Equal(t, tc.status, res.StatusCode, fmt.Sprintf("%s: expected status code %d got %d", tc.desc, tc.status, res.StatusCode))
+		assert.Equal(t, tc.saved, saved, fmt.Sprintf("%s: expected %d saved twins got %d", tc.desc, tc.saved, saved))
+		if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusCreated {
+			var imp struct {
+				Twins  []twinRes `json:"twins"`
+				Issues []struct {
+					Element string `json:"element"`
+				} `json:"issues"`
+			}
+			err = json.NewDecoder(res.Body).Decode(&imp)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error %s", tc.desc, err))
+			assert.Len(t, imp.Twins, 1, fmt.Sprintf("%s: expected 1 imported twin got %d", tc.desc, len(imp.Twins)))
+			assert.Len(t, imp.Issues, 1, fmt.Sprintf("%s: expected 1 issue got %d", tc.desc, len(imp.Issues)))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		policyCall.Unset()
+		repoCall.Unset()
+		cacheCall.Unset()
 	}
 }
 
@@ -4,6 +4,7 @@
 package http
 
 import (
+	"github.com/absmach/supermq-contrib/pkg/api"
 	"github.com/absmach/supermq-contrib/twins"
 	apiutil "github.com/absmach/supermq/api/http/util"
 )
@@ -15,8 +16,11 @@ const (
 
 type addTwinReq struct {
 	token      string
+	domainID   string
 	Name       string                 `json:"name,omitempty"`
 	Definition twins.Definition       `json:"definition,omitempty"`
+	Template   string                 `json:"template_id,omitempty"`
+	Bindings   map[string]string      `json:"bindings,omitempty"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
 }
 
@@ -25,6 +29,10 @@ func (req addTwinReq) validate() error {
 		return apiutil.ErrBearerToken
 	}
 
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
 	if len(req.Name) > maxNameSize {
 		return apiutil.ErrNameSize
 	}
@@ -34,7 +42,9 @@ func (req addTwinReq) validate() error {
 
 type updateTwinReq struct {
 	token      string
+	domainID   string
 	id         string
+	revision   int
 	Name       string                 `json:"name,omitempty"`
 	Definition twins.Definition       `json:"definition,omitempty"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
@@ -45,6 +55,10 @@ func (req updateTwinReq) validate() error {
 		return apiutil.ErrBearerToken
 	}
 
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
 	if req.id == "" {
 		return apiutil.ErrMissingID
 	}
@@ -57,8 +71,9 @@ func (req updateTwinReq) validate() error {
 }
 
 type viewTwinReq struct {
-	token string
-	id    string
+	token    string
+	domainID string
+	id       string
 }
 
 func (req viewTwinReq) validate() error {
@@ -66,18 +81,252 @@ func (req viewTwinReq) validate() error {
 		return apiutil.ErrBearerToken
 	}
 
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	return nil
+}
+
+type removeTwinReq struct {
+	token    string
+	domainID string
+	id       string
+	cascade  bool
+	revision int
+}
+
+func (req removeTwinReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	return nil
+}
+
+type diffDefinitionsReq struct {
+	token    string
+	domainID string
+	id       string
+	from     int
+	to       int
+}
+
+func (req diffDefinitionsReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if req.from < 0 || req.to < 0 {
+		return apiutil.ErrInvalidQueryParams
+	}
+
+	return nil
+}
+
+type rollbackDefinitionReq struct {
+	token        string
+	domainID     string
+	id           string
+	definitionID int
+	revision     int
+}
+
+func (req rollbackDefinitionReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if req.definitionID < 0 {
+		return apiutil.ErrMissingID
+	}
+
+	return nil
+}
+
+type addRelationReq struct {
+	token    string
+	domainID string
+	id       string
+	Type     string                 `json:"type"`
+	Target   string                 `json:"target_id"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+func (req addRelationReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" || req.Target == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if req.Type == "" {
+		return apiutil.ErrMissingRelation
+	}
+
+	return nil
+}
+
+type relationReq struct {
+	token    string
+	domainID string
+	id       string
+	relType  string
+	targetID string
+}
+
+func (req relationReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" || req.targetID == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if req.relType == "" {
+		return apiutil.ErrMissingRelation
+	}
+
+	return nil
+}
+
+type listRelationsReq struct {
+	token     string
+	domainID  string
+	id        string
+	offset    uint64
+	limit     uint64
+	types     []string
+	direction string
+}
+
+func (req listRelationsReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
 	if req.id == "" {
 		return apiutil.ErrMissingID
 	}
 
+	if req.limit == 0 || req.limit > maxLimitSize {
+		return apiutil.ErrLimitSize
+	}
+
+	return validateDirection(req.direction)
+}
+
+type traverseRelationsReq struct {
+	token     string
+	domainID  string
+	id        string
+	types     []string
+	direction string
+	depth     uint64
+}
+
+func (req traverseRelationsReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if req.depth < 1 || req.depth > twins.MaxTraversalDepth {
+		return apiutil.ErrInvalidQueryParams
+	}
+
+	return validateDirection(req.direction)
+}
+
+func validateDirection(dir string) error {
+	switch dir {
+	case "", twins.OutgoingDir, twins.IncomingDir, twins.BothDirs:
+		return nil
+	default:
+		return apiutil.ErrInvalidQueryParams
+	}
+}
+
+type childReq struct {
+	token    string
+	domainID string
+	id       string
+	childID  string
+}
+
+func (req childReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" || req.childID == "" {
+		return apiutil.ErrMissingID
+	}
+
 	return nil
 }
 
 type listReq struct {
 	token    string
+	domainID string
 	offset   uint64
 	limit    uint64
 	name     string
+	parentID string
 	metadata map[string]interface{}
 }
 
@@ -86,6 +335,10 @@ func (req *listReq) validate() error {
 		return apiutil.ErrBearerToken
 	}
 
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
 	if req.limit < 1 || req.limit > maxLimitSize {
 		return apiutil.ErrLimitSize
 	}
@@ -98,10 +351,17 @@ func (req *listReq) validate() error {
 }
 
 type listStatesReq struct {
-	token  string
-	offset uint64
-	limit  uint64
-	id     string
+	token      string
+	domainID   string
+	offset     uint64
+	limit      uint64
+	id         string
+	from       float64
+	to         float64
+	definition int
+	attribute  string
+	dir        string
+	simulated  bool
 }
 
 func (req *listStatesReq) validate() error {
@@ -109,6 +369,10 @@ func (req *listStatesReq) validate() error {
 		return apiutil.ErrBearerToken
 	}
 
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
 	if req.id == "" {
 		return apiutil.ErrMissingID
 	}
@@ -117,5 +381,426 @@ func (req *listStatesReq) validate() error {
 		return apiutil.ErrLimitSize
 	}
 
+	if req.from < 0 || req.to < 0 || (req.to > 0 && req.from > req.to) {
+		return apiutil.ErrInvalidQueryParams
+	}
+
+	if req.dir != api.AscDir && req.dir != api.DescDir {
+		return apiutil.ErrInvalidDirection
+	}
+
+	return nil
+}
+
+type listAlarmsReq struct {
+	token     string
+	domainID  string
+	offset    uint64
+	limit     uint64
+	id        string
+	attribute string
+	severity  string
+	status    string
+}
+
+func (req *listAlarmsReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if req.limit == 0 || req.limit > maxLimitSize {
+		return apiutil.ErrLimitSize
+	}
+
+	switch req.severity {
+	case "", twins.InfoSeverity, twins.WarningSeverity, twins.CriticalSeverity:
+	default:
+		return apiutil.ErrInvalidQueryParams
+	}
+
+	switch req.status {
+	case "", twins.RaisedStatus, twins.ClearedStatus:
+	default:
+		return apiutil.ErrInvalidQueryParams
+	}
+
+	return nil
+}
+
+type desiredStateReq struct {
+	token    string
+	domainID string
+	id       string
+	Payload  map[string]interface{} `json:"payload"`
+}
+
+func (req desiredStateReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if len(req.Payload) == 0 {
+		return apiutil.ErrEmptyList
+	}
+
+	return nil
+}
+
+type stateAtReq struct {
+	token    string
+	domainID string
+	id       string
+	at       float64
+}
+
+func (req stateAtReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if req.at <= 0 {
+		return apiutil.ErrInvalidQueryParams
+	}
+
+	return nil
+}
+
+type diffStatesReq struct {
+	token    string
+	domainID string
+	id       string
+	fromID   int64
+	toID     int64
+	fromTime float64
+	toTime   float64
+}
+
+func (req diffStatesReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if err := validateStateSelector(req.fromID, req.fromTime); err != nil {
+		return err
+	}
+
+	return validateStateSelector(req.toID, req.toTime)
+}
+
+// validateStateSelector verifies that the state is selected either by the
+// ID or by the time, but not both.
+func validateStateSelector(id int64, at float64) error {
+	if id < defState || at < 0 || (id == defState) == (at == 0) {
+		return apiutil.ErrInvalidQueryParams
+	}
+
+	return nil
+}
+
+type replayStatesReq struct {
+	token    string
+	domainID string
+	id       string
+	From     float64 `json:"from"`
+	To       float64 `json:"to,omitempty"`
+	Simulate bool    `json:"simulate,omitempty"`
+}
+
+func (req replayStatesReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if req.From < 0 || req.To < 0 {
+		return apiutil.ErrInvalidTimeFormat
+	}
+
+	return nil
+}
+
+type shareTwinReq struct {
+	token    string
+	domainID string
+	id       string
+	Relation string   `json:"relation,omitempty"`
+	UserIDs  []string `json:"user_ids"`
+}
+
+func (req shareTwinReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if len(req.UserIDs) == 0 {
+		return apiutil.ErrEmptyList
+	}
+
+	return nil
+}
+
+type streamStatesReq struct {
+	token      string
+	domainID   string
+	twinIDs    []string
+	attributes []string
+}
+
+func (req streamStatesReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if len(req.twinIDs) == 0 {
+		return apiutil.ErrMissingID
+	}
+
+	if len(req.twinIDs) > maxLimitSize {
+		return apiutil.ErrLimitSize
+	}
+
+	for _, id := range req.twinIDs {
+		if id == "" {
+			return apiutil.ErrMissingID
+		}
+	}
+
+	return nil
+}
+
+type addTemplateReq struct {
+	token      string
+	domainID   string
+	Name       string                 `json:"name,omitempty"`
+	Definition twins.Definition       `json:"definition,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+}
+
+func (req addTemplateReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if len(req.Name) > maxNameSize {
+		return apiutil.ErrNameSize
+	}
+
+	return nil
+}
+
+type updateTemplateReq struct {
+	token      string
+	domainID   string
+	id         string
+	Name       string                 `json:"name,omitempty"`
+	Definition twins.Definition       `json:"definition,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+}
+
+func (req updateTemplateReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	if len(req.Name) > maxNameSize {
+		return apiutil.ErrNameSize
+	}
+
+	return nil
+}
+
+type listTemplatesReq struct {
+	token    string
+	domainID string
+	offset   uint64
+	limit    uint64
+	name     string
+}
+
+func (req listTemplatesReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.limit < 1 || req.limit > maxLimitSize {
+		return apiutil.ErrLimitSize
+	}
+
+	if len(req.name) > maxNameSize {
+		return apiutil.ErrNameSize
+	}
+
+	return nil
+}
+
+type rolloutTemplateReq struct {
+	token    string
+	domainID string
+	id       string
+	Bindings map[string]string `json:"bindings,omitempty"`
+}
+
+func (req rolloutTemplateReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.id == "" {
+		return apiutil.ErrMissingID
+	}
+
+	return nil
+}
+
+type exportTwinsReq struct {
+	token    string
+	domainID string
+	ownerID  string
+	format   string
+}
+
+func (req exportTwinsReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.format != jsonFormat && req.format != ndjsonFormat {
+		return apiutil.ErrInvalidQueryParams
+	}
+
+	return nil
+}
+
+type importTwinsReq struct {
+	token    string
+	domainID string
+	remap    bool
+	dryRun   bool
+	twins    []viewTwinRes
+}
+
+func (req importTwinsReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if len(req.twins) == 0 {
+		return apiutil.ErrEmptyList
+	}
+
+	for _, tw := range req.twins {
+		if len(tw.Name) > maxNameSize {
+			return apiutil.ErrNameSize
+		}
+	}
+
+	return nil
+}
+
+type importDTDLReq struct {
+	token    string
+	domainID string
+	channel  string
+	iface    string
+	dryRun   bool
+	model    []byte
+}
+
+func (req importDTDLReq) validate() error {
+	if req.token == "" {
+		return apiutil.ErrBearerToken
+	}
+
+	if req.domainID == "" {
+		return apiutil.ErrMissingDomainID
+	}
+
+	if req.channel == "" {
+		return apiutil.ErrInvalidQueryParams
+	}
+
+	if len(req.model) == 0 {
+		return apiutil.ErrEmptyList
+	}
+
 	return nil
 }
@@ -18,12 +18,36 @@ var (
 	_ supermq.Response = (*viewStateRes)(nil)
 	_ supermq.Response = (*twinsPageRes)(nil)
 	_ supermq.Response = (*statesPageRes)(nil)
+	_ supermq.Response = (*alarmsPageRes)(nil)
 	_ supermq.Response = (*removeRes)(nil)
+	_ supermq.Response = (*shareRes)(nil)
+	_ supermq.Response = (*desiredStateRes)(nil)
+	_ supermq.Response = (*deltaRes)(nil)
+	_ supermq.Response = (*stateAtRes)(nil)
+	_ supermq.Response = (*definitionsRes)(nil)
+	_ supermq.Response = (*definitionDiffRes)(nil)
+	_ supermq.Response = (*rollbackDefinitionRes)(nil)
+	_ supermq.Response = (*childRes)(nil)
+	_ supermq.Response = (*subtreeRes)(nil)
+	_ supermq.Response = (*relationRes)(nil)
+	_ supermq.Response = (*relationsPageRes)(nil)
+	_ supermq.Response = (*traversalRes)(nil)
+	_ supermq.Response = (*compositeStateRes)(nil)
+	_ supermq.Response = (*templateRes)(nil)
+	_ supermq.Response = (*viewTemplateRes)(nil)
+	_ supermq.Response = (*templatesPageRes)(nil)
+	_ supermq.Response = (*rolloutRes)(nil)
+	_ supermq.Response = (*exportRes)(nil)
+	_ supermq.Response = (*importRes)(nil)
+	_ supermq.Response = (*stateDiffRes)(nil)
+	_ supermq.Response = (*importDTDLRes)(nil)
+	_ supermq.Response = (*replayStatesRes)(nil)
 )
 
 type twinRes struct {
-	id      string
-	created bool
+	domainID string
+	id       string
+	created  bool
 }
 
 func (res twinRes) Code() int {
@@ -37,7 +61,7 @@ func (res twinRes) Code() int {
 func (res twinRes) Headers() map[string]string {
 	if res.created {
 		return map[string]string{
-			"Location": fmt.Sprintf("/twins/%s", res.id),
+			"Location": fmt.Sprintf("/%s/twins/%s", res.domainID, res.id),
 		}
 	}
 
@@ -50,6 +74,11 @@ func (res twinRes) Empty() bool {
 
 type viewTwinRes struct {
 	Owner       string                 `json:"owner,omitempty"`
+	Domain      string                 `json:"domain_id,omitempty"`
+	Parent      string                 `json:"parent_id,omitempty"`
+	Template    string                 `json:"template_id,omitempty"`
+	TemplateRev *int                   `json:"template_revision,omitempty"`
+	Bindings    map[string]string      `json:"bindings,omitempty"`
 	ID          string                 `json:"id"`
 	Name        string                 `json:"name,omitempty"`
 	Revision    int                    `json:"revision"`
@@ -57,6 +86,7 @@ type viewTwinRes struct {
 	Updated     time.Time              `json:"updated"`
 	Definitions []twins.Definition     `json:"definitions,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Stale       []string               `json:"stale,omitempty"`
 }
 
 func (res viewTwinRes) Code() int {
@@ -64,7 +94,9 @@ func (res viewTwinRes) Code() int {
 }
 
 func (res viewTwinRes) Headers() map[string]string {
-	return map[string]string{}
+	return map[string]string{
+		"ETag": etag(res.Revision),
+	}
 }
 
 func (res viewTwinRes) Empty() bool {
@@ -77,6 +109,8 @@ type viewStateRes struct {
 	Definition int                    `json:"definition"`
 	Created    time.Time              `json:"created"`
 	Payload    map[string]interface{} `json:"payload"`
+	Updated    map[string]time.Time   `json:"updated,omitempty"`
+	Stale      []string               `json:"stale,omitempty"`
 }
 
 func (res viewStateRes) Code() int {
@@ -131,6 +165,36 @@ func (res statesPageRes) Empty() bool {
 	return false
 }
 
+type viewAlarmRes struct {
+	ID        string     `json:"id"`
+	TwinID    string     `json:"twin_id"`
+	Attribute string     `json:"attribute"`
+	Rule      string     `json:"rule"`
+	Condition string     `json:"condition"`
+	Severity  string     `json:"severity"`
+	Status    string     `json:"status"`
+	Value     float64    `json:"value"`
+	Raised    time.Time  `json:"raised"`
+	Cleared   *time.Time `json:"cleared,omitempty"`
+}
+
+type alarmsPageRes struct {
+	pageRes
+	Alarms []viewAlarmRes `json:"alarms"`
+}
+
+func (res alarmsPageRes) Code() int {
+	return http.StatusOK
+}
+
+func (res alarmsPageRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res alarmsPageRes) Empty() bool {
+	return false
+}
+
 type removeRes struct{}
 
 func (res removeRes) Code() int {
@@ -144,3 +208,486 @@ func (res removeRes) Headers() map[string]string {
 func (res removeRes) Empty() bool {
 	return true
 }
+
+type shareRes struct{}
+
+func (res shareRes) Code() int {
+	return http.StatusNoContent
+}
+
+func (res shareRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res shareRes) Empty() bool {
+	return true
+}
+
+type desiredStateRes struct {
+	TwinID  string                 `json:"twin_id"`
+	Updated time.Time              `json:"updated"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+func (res desiredStateRes) Code() int {
+	return http.StatusOK
+}
+
+func (res desiredStateRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res desiredStateRes) Empty() bool {
+	return false
+}
+
+type deltaRes struct {
+	TwinID string                 `json:"twin_id"`
+	Delta  map[string]interface{} `json:"delta"`
+}
+
+func (res deltaRes) Code() int {
+	return http.StatusOK
+}
+
+func (res deltaRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res deltaRes) Empty() bool {
+	return false
+}
+
+type stateAtRes struct {
+	State      viewStateRes     `json:"state"`
+	Definition twins.Definition `json:"definition"`
+}
+
+func (res stateAtRes) Code() int {
+	return http.StatusOK
+}
+
+func (res stateAtRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res stateAtRes) Empty() bool {
+	return false
+}
+
+type stateRefRes struct {
+	ID         int64     `json:"id"`
+	Definition int       `json:"definition"`
+	Created    time.Time `json:"created"`
+}
+
+type attributeValueRes struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+type valueChangeRes struct {
+	Name  string      `json:"name"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+	Delta *float64    `json:"delta,omitempty"`
+}
+
+type stateDiffRes struct {
+	TwinID     string              `json:"twin_id"`
+	From       stateRefRes         `json:"from"`
+	To         stateRefRes         `json:"to"`
+	Added      []attributeValueRes `json:"added"`
+	Removed    []attributeValueRes `json:"removed"`
+	Changed    []valueChangeRes    `json:"changed"`
+	Definition *definitionDiffRes  `json:"definition,omitempty"`
+}
+
+func (res stateDiffRes) Code() int {
+	return http.StatusOK
+}
+
+func (res stateDiffRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res stateDiffRes) Empty() bool {
+	return false
+}
+
+type replayStatesRes struct {
+	TwinID   string         `json:"twin_id"`
+	Simulate bool           `json:"simulate"`
+	Messages uint64         `json:"messages"`
+	Skipped  uint64         `json:"skipped"`
+	Removed  int64          `json:"removed"`
+	Created  int            `json:"created"`
+	States   []viewStateRes `json:"states,omitempty"`
+}
+
+func (res replayStatesRes) Code() int {
+	return http.StatusOK
+}
+
+func (res replayStatesRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res replayStatesRes) Empty() bool {
+	return false
+}
+
+type definitionSummaryRes struct {
+	twins.Definition
+	States uint64     `json:"states"`
+	First  *time.Time `json:"first_state,omitempty"`
+	Last   *time.Time `json:"last_state,omitempty"`
+}
+
+type definitionsRes struct {
+	TwinID      string                 `json:"twin_id"`
+	Definitions []definitionSummaryRes `json:"definitions"`
+}
+
+func (res definitionsRes) Code() int {
+	return http.StatusOK
+}
+
+func (res definitionsRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res definitionsRes) Empty() bool {
+	return false
+}
+
+type attributeChangeRes struct {
+	Name string          `json:"name"`
+	From twins.Attribute `json:"from"`
+	To   twins.Attribute `json:"to"`
+}
+
+type retentionChangeRes struct {
+	From *twins.Retention `json:"from"`
+	To   *twins.Retention `json:"to"`
+}
+
+type definitionDiffRes struct {
+	TwinID    string               `json:"twin_id"`
+	From      int                  `json:"from"`
+	To        int                  `json:"to"`
+	Added     []twins.Attribute    `json:"added"`
+	Removed   []twins.Attribute    `json:"removed"`
+	Changed   []attributeChangeRes `json:"changed"`
+	Delta     *int64               `json:"delta,omitempty"`
+	Retention *retentionChangeRes  `json:"retention,omitempty"`
+}
+
+func (res definitionDiffRes) Code() int {
+	return http.StatusOK
+}
+
+func (res definitionDiffRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res definitionDiffRes) Empty() bool {
+	return false
+}
+
+type rollbackDefinitionRes struct {
+	TwinID       string           `json:"twin_id"`
+	DefinitionID int              `json:"rolled_back_from"`
+	Definition   twins.Definition `json:"definition"`
+}
+
+func (res rollbackDefinitionRes) Code() int {
+	return http.StatusOK
+}
+
+func (res rollbackDefinitionRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res rollbackDefinitionRes) Empty() bool {
+	return false
+}
+
+type childRes struct{}
+
+func (res childRes) Code() int {
+	return http.StatusNoContent
+}
+
+func (res childRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res childRes) Empty() bool {
+	return true
+}
+
+type viewRelationRes struct {
+	Source   string                 `json:"source_id"`
+	Type     string                 `json:"type"`
+	Target   string                 `json:"target_id"`
+	Created  time.Time              `json:"created"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type relationRes struct {
+	viewRelationRes
+}
+
+func (res relationRes) Code() int {
+	return http.StatusCreated
+}
+
+func (res relationRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res relationRes) Empty() bool {
+	return false
+}
+
+type relationsPageRes struct {
+	pageRes
+	Relations []viewRelationRes `json:"relations"`
+}
+
+func (res relationsPageRes) Code() int {
+	return http.StatusOK
+}
+
+func (res relationsPageRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res relationsPageRes) Empty() bool {
+	return false
+}
+
+type hopRes struct {
+	Depth int             `json:"depth"`
+	Twin  viewTwinRes     `json:"twin"`
+	Via   viewRelationRes `json:"via"`
+}
+
+type traversalRes struct {
+	Origin string   `json:"origin_id"`
+	Hops   []hopRes `json:"hops"`
+}
+
+func (res traversalRes) Code() int {
+	return http.StatusOK
+}
+
+func (res traversalRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res traversalRes) Empty() bool {
+	return false
+}
+
+type subtreeRes struct {
+	viewTwinRes
+	Children []subtreeRes `json:"children,omitempty"`
+}
+
+func (res subtreeRes) Code() int {
+	return http.StatusOK
+}
+
+func (res subtreeRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res subtreeRes) Empty() bool {
+	return false
+}
+
+type compositeStateRes struct {
+	TwinID   string              `json:"twin_id"`
+	Name     string              `json:"name,omitempty"`
+	State    *viewStateRes       `json:"state,omitempty"`
+	Updated  time.Time           `json:"updated"`
+	Children []compositeStateRes `json:"children,omitempty"`
+}
+
+func (res compositeStateRes) Code() int {
+	return http.StatusOK
+}
+
+func (res compositeStateRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res compositeStateRes) Empty() bool {
+	return false
+}
+
+type streamEventRes struct {
+	Type       string            `json:"type"`
+	TwinID     string            `json:"twin_id"`
+	State      *viewStateRes     `json:"state,omitempty"`
+	Definition *twins.Definition `json:"definition,omitempty"`
+}
+
+type templateRes struct {
+	domainID string
+	id       string
+	created  bool
+}
+
+func (res templateRes) Code() int {
+	if res.created {
+		return http.StatusCreated
+	}
+
+	return http.StatusOK
+}
+
+func (res templateRes) Headers() map[string]string {
+	if res.created {
+		return map[string]string{
+			"Location": fmt.Sprintf("/%s/templates/%s", res.domainID, res.id),
+		}
+	}
+
+	return map[string]string{}
+}
+
+func (res templateRes) Empty() bool {
+	return true
+}
+
+type viewTemplateRes struct {
+	Owner      string                 `json:"owner,omitempty"`
+	Domain     string                 `json:"domain_id,omitempty"`
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name,omitempty"`
+	Revision   int                    `json:"revision"`
+	Created    time.Time              `json:"created"`
+	Updated    time.Time              `json:"updated"`
+	Definition twins.Definition       `json:"definition"`
+	Parameters []string               `json:"parameters,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+}
+
+func (res viewTemplateRes) Code() int {
+	return http.StatusOK
+}
+
+func (res viewTemplateRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res viewTemplateRes) Empty() bool {
+	return false
+}
+
+type templatesPageRes struct {
+	pageRes
+	Templates []viewTemplateRes `json:"templates"`
+}
+
+func (res templatesPageRes) Code() int {
+	return http.StatusOK
+}
+
+func (res templatesPageRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res templatesPageRes) Empty() bool {
+	return false
+}
+
+type rolloutRes struct {
+	Template string   `json:"template_id"`
+	Revision int      `json:"revision"`
+	Updated  []string `json:"updated"`
+	Skipped  []string `json:"skipped"`
+}
+
+func (res rolloutRes) Code() int {
+	return http.StatusOK
+}
+
+func (res rolloutRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res rolloutRes) Empty() bool {
+	return false
+}
+
+// exportRes holds the exported twins, which are encoded either as the JSON
+// array or as the newline delimited JSON records.
+type exportRes struct {
+	format string
+	twins  []viewTwinRes
+}
+
+func (res exportRes) Code() int {
+	return http.StatusOK
+}
+
+func (res exportRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res exportRes) Empty() bool {
+	return false
+}
+
+type importRes struct {
+	DryRun bool              `json:"dry_run"`
+	IDs    map[string]string `json:"ids"`
+}
+
+func (res importRes) Code() int {
+	if res.DryRun {
+		return http.StatusOK
+	}
+
+	return http.StatusCreated
+}
+
+func (res importRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res importRes) Empty() bool {
+	return false
+}
+
+type dtdlIssueRes struct {
+	Interface string `json:"interface"`
+	Element   string `json:"element,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+type importDTDLRes struct {
+	DryRun bool           `json:"dry_run"`
+	Twins  []viewTwinRes  `json:"twins"`
+	Issues []dtdlIssueRes `json:"issues"`
+}
+
+func (res importDTDLRes) Code() int {
+	if res.DryRun {
+		return http.StatusOK
+	}
+
+	return http.StatusCreated
+}
+
+func (res importDTDLRes) Headers() map[string]string {
+	return map[string]string{}
+}
+
+func (res importDTDLRes) Empty() bool {
+	return false
+}
@@ -0,0 +1,212 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/absmach/supermq-contrib/twins"
+	apiutil "github.com/absmach/supermq/api/http/util"
+	"github.com/absmach/supermq/pkg/errors"
+	"github.com/go-chi/chi/v5"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	twinIDKey    = "twin_id"
+	authzKey     = "authorization"
+	anyOrigin    = "*"
+	keepAlive    = 30 * time.Second
+	writeTimeout = 10 * time.Second
+)
+
+var errStreamingUnsupported = errors.New("response writer does not support streaming")
+
+// newUpgrader returns the WebSocket upgrader accepting the connections from
+// the same origin and from the allowed origins, or from any origin if "*"
+// is allowed. As the token can be passed in the query, connections from
+// other origins could otherwise be opened on behalf of the user.
+func newUpgrader(allowedOrigins []string) websocket.Upgrader {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+			allowed[origin] = true
+		}
+	}
+	if len(allowed) == 0 {
+		// Same origin is checked by default.
+		return websocket.Upgrader{}
+	}
+
+	return websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" || allowed[anyOrigin] || allowed[origin] {
+				return true
+			}
+			u, err := url.Parse(origin)
+			return err == nil && strings.EqualFold(u.Host, r.Host)
+		},
+	}
+}
+
+// streamStatesHandler streams the twin changes as WebSocket messages if
+// the connection upgrade is requested, or as server-sent events otherwise.
+func streamStatesHandler(svc twins.Service, upgrader websocket.Upgrader, encodeError kithttp.ErrorEncoder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := decodeStreamStates(r)
+		if err := req.validate(); err != nil {
+			encodeError(r.Context(), errors.Wrap(apiutil.ErrValidation, err), w)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		events, err := svc.StreamStates(ctx, req.token, req.domainID, req.twinIDs, req.attributes)
+		if err != nil {
+			encodeError(r.Context(), err, w)
+			return
+		}
+
+		if websocket.IsWebSocketUpgrade(r) {
+			serveWebSocket(w, r, upgrader, cancel, events)
+			return
+		}
+
+		if err := serveEvents(w, events); err != nil {
+			encodeError(r.Context(), err, w)
+		}
+	}
+}
+
+func decodeStreamStates(r *http.Request) streamStatesReq {
+	token := apiutil.ExtractBearerToken(r)
+	if token == "" {
+		// Browser EventSource and WebSocket clients cannot set headers.
+		token = strings.TrimPrefix(r.URL.Query().Get(authzKey), apiutil.BearerPrefix)
+	}
+
+	return streamStatesReq{
+		token:      token,
+		domainID:   chi.URLParam(r, "domainID"),
+		twinIDs:    readListQuery(r, twinIDKey),
+		attributes: readListQuery(r, attrKey),
+	}
+}
+
+// readListQuery reads the values of the query parameter which is either
+// repeated or contains comma-separated values.
+func readListQuery(r *http.Request, key string) []string {
+	var vals []string
+	for _, v := range r.URL.Query()[key] {
+		for _, val := range strings.Split(v, ",") {
+			if val = strings.TrimSpace(val); val != "" {
+				vals = append(vals, val)
+			}
+		}
+	}
+	return vals
+}
+
+func serveEvents(w http.ResponseWriter, events <-chan twins.StreamEvent) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errStreamingUnsupported
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			data, err := json.Marshal(toStreamEventRes(ev))
+			if err != nil {
+				return nil
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data); err != nil {
+				return nil
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func serveWebSocket(w http.ResponseWriter, r *http.Request, upgrader websocket.Upgrader, cancel context.CancelFunc, events <-chan twins.StreamEvent) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// The stream is read-only, reading only detects the closed connection.
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := conn.WriteJSON(toStreamEventRes(ev)); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func toStreamEventRes(ev twins.StreamEvent) streamEventRes {
+	res := streamEventRes{
+		Type:       ev.Type,
+		TwinID:     ev.TwinID,
+		Definition: ev.Definition,
+	}
+	if ev.State != nil {
+		res.State = &viewStateRes{
+			TwinID:     ev.State.TwinID,
+			ID:         ev.State.ID,
+			Definition: ev.State.Definition,
+			Created:    ev.State.Created,
+			Payload:    ev.State.Payload,
+		}
+	}
+
+	return res
+}
@@ -6,8 +6,10 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/absmach/supermq"
@@ -26,20 +28,60 @@ const (
 	offsetKey   = "offset"
 	limitKey    = "limit"
 	nameKey     = "name"
+	parentKey   = "parent_id"
+	cascadeKey  = "cascade"
 	metadataKey = "metadata"
+	fromKey     = "from"
+	toKey       = "to"
+	defKey      = "definition"
+	attrKey     = "attribute"
+	timeKey     = "time"
+	fromIDKey   = "from_id"
+	toIDKey     = "to_id"
+	fromTimeKey = "from_time"
+	toTimeKey   = "to_time"
+	severityKey = "severity"
+	statusKey   = "status"
+	typeKey     = "type"
+	dirKey      = "direction"
+	depthKey    = "depth"
+	simKey      = "simulated"
 	defLimit    = 10
 	defOffset   = 0
+	defDef      = -1
+	defDepth    = 1
+	defState    = -1
+)
+
+// Formats and parameters of the twins export and import.
+const (
+	jsonFormat        = "json"
+	ndjsonFormat      = "ndjson"
+	ndjsonContentType = "application/x-ndjson"
+	ownerKey          = "owner"
+	formatKey         = "format"
+	remapKey          = "remap"
+	dryRunKey         = "dry_run"
+	channelKey        = "channel"
+	interfaceKey      = "interface"
+	ldJSONContentType = "application/ld+json"
+)
+
+var (
+	errInvalidETag       = errors.New("invalid entity tag")
+	errInvalidDefinition = errors.New("invalid definition identifier")
 )
 
 // MakeHandler returns a HTTP handler for API endpoints.
-func MakeHandler(svc twins.Service, logger *slog.Logger, instanceID string) http.Handler {
+func MakeHandler(svc twins.Service, logger *slog.Logger, instanceID string, allowedOrigins []string) http.Handler {
+	encodeError := apiutil.LoggingErrorEncoder(logger, api.EncodeError)
 	opts := []kithttp.ServerOption{
-		kithttp.ServerErrorEncoder(apiutil.LoggingErrorEncoder(logger, api.EncodeError)),
+		kithttp.ServerErrorEncoder(encodeError),
 	}
 
 	r := chi.NewRouter()
 
-	r.Route("/twins", func(r chi.Router) {
+	r.Route("/{domainID}/twins", func(r chi.Router) {
 		r.Post("/", otelhttp.NewHandler(kithttp.NewServer(
 			addTwinEndpoint(svc),
 			decodeTwinCreation,
@@ -52,6 +94,24 @@ func MakeHandler(svc twins.Service, logger *slog.Logger, instanceID string) http
 			api.EncodeResponse,
 			opts...,
 		), "list_twins").ServeHTTP)
+		r.Get("/export", otelhttp.NewHandler(kithttp.NewServer(
+			exportTwinsEndpoint(svc),
+			decodeExportTwins,
+			encodeExport,
+			opts...,
+		), "export_twins").ServeHTTP)
+		r.Post("/import", otelhttp.NewHandler(kithttp.NewServer(
+			importTwinsEndpoint(svc),
+			decodeImportTwins,
+			api.EncodeResponse,
+			opts...,
+		), "import_twins").ServeHTTP)
+		r.Post("/import/dtdl", otelhttp.NewHandler(kithttp.NewServer(
+			importDTDLEndpoint(svc),
+			decodeImportDTDL,
+			api.EncodeResponse,
+			opts...,
+		), "import_dtdl").ServeHTTP)
 		r.Put("/{twinID}", otelhttp.NewHandler(kithttp.NewServer(
 			updateTwinEndpoint(svc),
 			decodeTwinUpdate,
@@ -66,17 +126,178 @@ func MakeHandler(svc twins.Service, logger *slog.Logger, instanceID string) http
 		), "view_twin").ServeHTTP)
 		r.Delete("/{twinID}", otelhttp.NewHandler(kithttp.NewServer(
 			removeTwinEndpoint(svc),
-			decodeView,
+			decodeRemove,
 			api.EncodeResponse,
 			opts...,
 		), "remove_twin").ServeHTTP)
+		r.Post("/{twinID}/share", otelhttp.NewHandler(kithttp.NewServer(
+			shareTwinEndpoint(svc),
+			decodeShareTwin,
+			api.EncodeResponse,
+			opts...,
+		), "share_twin").ServeHTTP)
+		r.Post("/{twinID}/unshare", otelhttp.NewHandler(kithttp.NewServer(
+			unshareTwinEndpoint(svc),
+			decodeShareTwin,
+			api.EncodeResponse,
+			opts...,
+		), "unshare_twin").ServeHTTP)
+		r.Post("/{twinID}/children/{childID}", otelhttp.NewHandler(kithttp.NewServer(
+			attachChildEndpoint(svc),
+			decodeChild,
+			api.EncodeResponse,
+			opts...,
+		), "attach_child").ServeHTTP)
+		r.Delete("/{twinID}/children/{childID}", otelhttp.NewHandler(kithttp.NewServer(
+			detachChildEndpoint(svc),
+			decodeChild,
+			api.EncodeResponse,
+			opts...,
+		), "detach_child").ServeHTTP)
+		r.Post("/{twinID}/relations", otelhttp.NewHandler(kithttp.NewServer(
+			addRelationEndpoint(svc),
+			decodeAddRelation,
+			api.EncodeResponse,
+			opts...,
+		), "add_relation").ServeHTTP)
+		r.Get("/{twinID}/relations", otelhttp.NewHandler(kithttp.NewServer(
+			listRelationsEndpoint(svc),
+			decodeListRelations,
+			api.EncodeResponse,
+			opts...,
+		), "list_relations").ServeHTTP)
+		r.Delete("/{twinID}/relations/{type}/{targetID}", otelhttp.NewHandler(kithttp.NewServer(
+			removeRelationEndpoint(svc),
+			decodeRelation,
+			api.EncodeResponse,
+			opts...,
+		), "remove_relation").ServeHTTP)
+		r.Get("/{twinID}/traverse", otelhttp.NewHandler(kithttp.NewServer(
+			traverseRelationsEndpoint(svc),
+			decodeTraverseRelations,
+			api.EncodeResponse,
+			opts...,
+		), "traverse_relations").ServeHTTP)
+		r.Get("/{twinID}/subtree", otelhttp.NewHandler(kithttp.NewServer(
+			viewSubtreeEndpoint(svc),
+			decodeView,
+			api.EncodeResponse,
+			opts...,
+		), "view_subtree").ServeHTTP)
+		r.Get("/{twinID}/composite", otelhttp.NewHandler(kithttp.NewServer(
+			viewCompositeStateEndpoint(svc),
+			decodeView,
+			api.EncodeResponse,
+			opts...,
+		), "view_composite_state").ServeHTTP)
+		r.Get("/{twinID}/definitions", otelhttp.NewHandler(kithttp.NewServer(
+			listDefinitionsEndpoint(svc),
+			decodeView,
+			api.EncodeResponse,
+			opts...,
+		), "list_definitions").ServeHTTP)
+		r.Get("/{twinID}/definitions/diff", otelhttp.NewHandler(kithttp.NewServer(
+			diffDefinitionsEndpoint(svc),
+			decodeDiffDefinitions,
+			api.EncodeResponse,
+			opts...,
+		), "diff_definitions").ServeHTTP)
+		r.Post("/{twinID}/definitions/{definitionID}/rollback", otelhttp.NewHandler(kithttp.NewServer(
+			rollbackDefinitionEndpoint(svc),
+			decodeRollbackDefinition,
+			api.EncodeResponse,
+			opts...,
+		), "rollback_definition").ServeHTTP)
+	})
+	r.Route("/{domainID}/templates", func(r chi.Router) {
+		r.Post("/", otelhttp.NewHandler(kithttp.NewServer(
+			addTemplateEndpoint(svc),
+			decodeTemplateCreation,
+			api.EncodeResponse,
+			opts...,
+		), "add_template").ServeHTTP)
+		r.Get("/", otelhttp.NewHandler(kithttp.NewServer(
+			listTemplatesEndpoint(svc),
+			decodeListTemplates,
+			api.EncodeResponse,
+			opts...,
+		), "list_templates").ServeHTTP)
+		r.Put("/{templateID}", otelhttp.NewHandler(kithttp.NewServer(
+			updateTemplateEndpoint(svc),
+			decodeTemplateUpdate,
+			api.EncodeResponse,
+			opts...,
+		), "update_template").ServeHTTP)
+		r.Get("/{templateID}", otelhttp.NewHandler(kithttp.NewServer(
+			viewTemplateEndpoint(svc),
+			decodeViewTemplate,
+			api.EncodeResponse,
+			opts...,
+		), "view_template").ServeHTTP)
+		r.Delete("/{templateID}", otelhttp.NewHandler(kithttp.NewServer(
+			removeTemplateEndpoint(svc),
+			decodeViewTemplate,
+			api.EncodeResponse,
+			opts...,
+		), "remove_template").ServeHTTP)
+		r.Post("/{templateID}/rollout", otelhttp.NewHandler(kithttp.NewServer(
+			rolloutTemplateEndpoint(svc),
+			decodeRolloutTemplate,
+			api.EncodeResponse,
+			opts...,
+		), "rollout_template").ServeHTTP)
+	})
+	r.Get("/{domainID}/states/stream", otelhttp.NewHandler(streamStatesHandler(svc, newUpgrader(allowedOrigins), encodeError), "stream_states").ServeHTTP)
+	r.Route("/{domainID}/states/{twinID}", func(r chi.Router) {
+		r.Get("/", otelhttp.NewHandler(kithttp.NewServer(
+			listStatesEndpoint(svc),
+			decodeListStates,
+			api.EncodeResponse,
+			opts...,
+		), "list_states").ServeHTTP)
+		r.Get("/alarms", otelhttp.NewHandler(kithttp.NewServer(
+			listAlarmsEndpoint(svc),
+			decodeListAlarms,
+			api.EncodeResponse,
+			opts...,
+		), "list_alarms").ServeHTTP)
+		r.Get("/at", otelhttp.NewHandler(kithttp.NewServer(
+			stateAtEndpoint(svc),
+			decodeStateAt,
+			api.EncodeResponse,
+			opts...,
+		), "state_at").ServeHTTP)
+		r.Get("/diff", otelhttp.NewHandler(kithttp.NewServer(
+			diffStatesEndpoint(svc),
+			decodeDiffStates,
+			api.EncodeResponse,
+			opts...,
+		), "diff_states").ServeHTTP)
+		r.Post("/replay", otelhttp.NewHandler(kithttp.NewServer(
+			replayStatesEndpoint(svc),
+			decodeReplayStates,
+			api.EncodeResponse,
+			opts...,
+		), "replay_states").ServeHTTP)
+		r.Put("/desired", otelhttp.NewHandler(kithttp.NewServer(
+			updateDesiredStateEndpoint(svc),
+			decodeDesiredState,
+			api.EncodeResponse,
+			opts...,
+		), "update_desired_state").ServeHTTP)
+		r.Get("/desired", otelhttp.NewHandler(kithttp.NewServer(
+			viewDesiredStateEndpoint(svc),
+			decodeView,
+			api.EncodeResponse,
+			opts...,
+		), "view_desired_state").ServeHTTP)
+		r.Get("/delta", otelhttp.NewHandler(kithttp.NewServer(
+			viewDeltaEndpoint(svc),
+			decodeView,
+			api.EncodeResponse,
+			opts...,
+		), "view_delta").ServeHTTP)
 	})
-	r.Get("/states/{twinID}", otelhttp.NewHandler(kithttp.NewServer(
-		listStatesEndpoint(svc),
-		decodeListStates,
-		api.EncodeResponse,
-		opts...,
-	), "list_states").ServeHTTP)
 
 	r.Get("/health", supermq.Health("twins", instanceID))
 	r.Handle("/metrics", promhttp.Handler())
@@ -89,7 +310,10 @@ func decodeTwinCreation(_ context.Context, r *http.Request) (interface{}, error)
 		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
 	}
 
-	req := addTwinReq{token: apiutil.ExtractBearerToken(r)}
+	req := addTwinReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(errors.ErrMalformedEntity, err))
 	}
@@ -102,9 +326,16 @@ func decodeTwinUpdate(_ context.Context, r *http.Request) (interface{}, error) {
 		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
 	}
 
+	rev, err := readIfMatch(r)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
 	req := updateTwinReq{
-		token: apiutil.ExtractBearerToken(r),
-		id:    chi.URLParam(r, "twinID"),
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "twinID"),
+		revision: rev,
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(err, errors.ErrMalformedEntity))
@@ -115,13 +346,221 @@ func decodeTwinUpdate(_ context.Context, r *http.Request) (interface{}, error) {
 
 func decodeView(_ context.Context, r *http.Request) (interface{}, error) {
 	req := viewTwinReq{
-		token: apiutil.ExtractBearerToken(r),
-		id:    chi.URLParam(r, "twinID"),
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "twinID"),
+	}
+
+	return req, nil
+}
+
+func decodeRemove(_ context.Context, r *http.Request) (interface{}, error) {
+	c, err := apiutil.ReadBoolQuery(r, cascadeKey, false)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	rev, err := readIfMatch(r)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	req := removeTwinReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "twinID"),
+		cascade:  c,
+		revision: rev,
+	}
+
+	return req, nil
+}
+
+// readIfMatch returns the twin revision from the If-Match header. Without
+// the header, or with the "*" value, any revision matches.
+func readIfMatch(r *http.Request) (int, error) {
+	tag := strings.TrimSpace(r.Header.Get("If-Match"))
+	if tag == "" || tag == "*" {
+		return twins.AnyRevision, nil
+	}
+
+	rev, err := strconv.Atoi(strings.Trim(tag, `"`))
+	if err != nil || rev < 0 {
+		return 0, errors.Wrap(errors.ErrMalformedEntity, errInvalidETag)
+	}
+
+	return rev, nil
+}
+
+// etag returns the entity tag of the twin revision.
+func etag(revision int) string {
+	return strconv.Quote(strconv.Itoa(revision))
+}
+
+func decodeDiffDefinitions(_ context.Context, r *http.Request) (interface{}, error) {
+	from, err := apiutil.ReadNumQuery[int64](r, fromKey, defDef)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	to, err := apiutil.ReadNumQuery[int64](r, toKey, defDef)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	req := diffDefinitionsReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "twinID"),
+		from:     int(from),
+		to:       int(to),
+	}
+
+	return req, nil
+}
+
+func decodeRollbackDefinition(_ context.Context, r *http.Request) (interface{}, error) {
+	def, err := strconv.Atoi(chi.URLParam(r, "definitionID"))
+	if err != nil || def < 0 {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(errors.ErrMalformedEntity, errInvalidDefinition))
+	}
+
+	rev, err := readIfMatch(r)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	req := rollbackDefinitionReq{
+		token:        apiutil.ExtractBearerToken(r),
+		domainID:     chi.URLParam(r, "domainID"),
+		id:           chi.URLParam(r, "twinID"),
+		definitionID: def,
+		revision:     rev,
+	}
+
+	return req, nil
+}
+
+func decodeChild(_ context.Context, r *http.Request) (interface{}, error) {
+	req := childReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "twinID"),
+		childID:  chi.URLParam(r, "childID"),
+	}
+
+	return req, nil
+}
+
+func decodeAddRelation(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := addRelationReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "twinID"),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(errors.ErrMalformedEntity, err))
+	}
+
+	return req, nil
+}
+
+func decodeRelation(_ context.Context, r *http.Request) (interface{}, error) {
+	req := relationReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "twinID"),
+		relType:  chi.URLParam(r, "type"),
+		targetID: chi.URLParam(r, "targetID"),
+	}
+
+	return req, nil
+}
+
+func decodeListRelations(_ context.Context, r *http.Request) (interface{}, error) {
+	l, err := apiutil.ReadNumQuery[uint64](r, limitKey, defLimit)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	o, err := apiutil.ReadNumQuery[uint64](r, offsetKey, defOffset)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	types, err := readTypes(r)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	dir, err := apiutil.ReadStringQuery(r, dirKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	req := listRelationsReq{
+		token:     apiutil.ExtractBearerToken(r),
+		domainID:  chi.URLParam(r, "domainID"),
+		id:        chi.URLParam(r, "twinID"),
+		offset:    o,
+		limit:     l,
+		types:     types,
+		direction: dir,
 	}
 
 	return req, nil
 }
 
+func decodeTraverseRelations(_ context.Context, r *http.Request) (interface{}, error) {
+	depth, err := apiutil.ReadNumQuery[uint64](r, depthKey, defDepth)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	types, err := readTypes(r)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	dir, err := apiutil.ReadStringQuery(r, dirKey, twins.OutgoingDir)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	req := traverseRelationsReq{
+		token:     apiutil.ExtractBearerToken(r),
+		domainID:  chi.URLParam(r, "domainID"),
+		id:        chi.URLParam(r, "twinID"),
+		types:     types,
+		direction: dir,
+		depth:     depth,
+	}
+
+	return req, nil
+}
+
+// readTypes reads the comma-separated relation types, such as
+// "feeds,powers".
+func readTypes(r *http.Request) ([]string, error) {
+	t, err := apiutil.ReadStringQuery(r, typeKey, "")
+	if err != nil || t == "" {
+		return nil, err
+	}
+
+	var types []string
+	for _, typ := range strings.Split(t, ",") {
+		if typ = strings.TrimSpace(typ); typ != "" {
+			types = append(types, typ)
+		}
+	}
+
+	return types, nil
+}
+
 func decodeList(_ context.Context, r *http.Request) (interface{}, error) {
 	l, err := apiutil.ReadNumQuery[uint64](r, limitKey, defLimit)
 	if err != nil {
@@ -138,6 +577,11 @@ func decodeList(_ context.Context, r *http.Request) (interface{}, error) {
 		return nil, errors.Wrap(apiutil.ErrValidation, err)
 	}
 
+	p, err := apiutil.ReadStringQuery(r, parentKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
 	m, err := apiutil.ReadMetadataQuery(r, metadataKey, nil)
 	if err != nil {
 		return nil, errors.Wrap(apiutil.ErrValidation, err)
@@ -145,15 +589,144 @@ func decodeList(_ context.Context, r *http.Request) (interface{}, error) {
 
 	req := listReq{
 		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
 		limit:    l,
 		offset:   o,
 		name:     n,
+		parentID: p,
 		metadata: m,
 	}
 
 	return req, nil
 }
 
+func decodeExportTwins(_ context.Context, r *http.Request) (interface{}, error) {
+	o, err := apiutil.ReadStringQuery(r, ownerKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	f, err := apiutil.ReadStringQuery(r, formatKey, jsonFormat)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	req := exportTwinsReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		ownerID:  o,
+		format:   f,
+	}
+
+	return req, nil
+}
+
+// decodeImportTwins decodes the exported twins either from the JSON array or
+// from the newline delimited JSON records, depending on the content type.
+func decodeImportTwins(_ context.Context, r *http.Request) (interface{}, error) {
+	ct := r.Header.Get("Content-Type")
+	ndjson := strings.Contains(ct, ndjsonContentType)
+	if !ndjson && !strings.Contains(ct, contentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	remap, err := apiutil.ReadBoolQuery(r, remapKey, false)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	dryRun, err := apiutil.ReadBoolQuery(r, dryRunKey, false)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	req := importTwinsReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		remap:    remap,
+		dryRun:   dryRun,
+	}
+
+	dec := json.NewDecoder(r.Body)
+	if !ndjson {
+		if err := dec.Decode(&req.twins); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(errors.ErrMalformedEntity, err))
+		}
+		return req, nil
+	}
+	for dec.More() {
+		var tw viewTwinRes
+		if err := dec.Decode(&tw); err != nil {
+			return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(errors.ErrMalformedEntity, err))
+		}
+		req.twins = append(req.twins, tw)
+	}
+
+	return req, nil
+}
+
+// decodeImportDTDL reads the DTDL model, which is either a single interface
+// or an array of interfaces, as is. The model is parsed by the service.
+func decodeImportDTDL(_ context.Context, r *http.Request) (interface{}, error) {
+	ct := r.Header.Get("Content-Type")
+	if !strings.Contains(ct, contentType) && !strings.Contains(ct, ldJSONContentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	channel, err := apiutil.ReadStringQuery(r, channelKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	iface, err := apiutil.ReadStringQuery(r, interfaceKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	dryRun, err := apiutil.ReadBoolQuery(r, dryRunKey, false)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	model, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(errors.ErrMalformedEntity, err))
+	}
+
+	req := importDTDLReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		channel:  channel,
+		iface:    iface,
+		dryRun:   dryRun,
+		model:    model,
+	}
+
+	return req, nil
+}
+
+// encodeExport encodes the exported twins as the JSON array, or as the
+// newline delimited JSON records, one twin per line.
+func encodeExport(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(exportRes)
+	if res.format != ndjsonFormat {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(res.Code())
+		return json.NewEncoder(w).Encode(res.twins)
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(res.Code())
+	enc := json.NewEncoder(w)
+	for _, tw := range res.twins {
+		if err := enc.Encode(tw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func decodeListStates(_ context.Context, r *http.Request) (interface{}, error) {
 	l, err := apiutil.ReadNumQuery[uint64](r, limitKey, defLimit)
 	if err != nil {
@@ -165,11 +738,279 @@ func decodeListStates(_ context.Context, r *http.Request) (interface{}, error) {
 		return nil, errors.Wrap(apiutil.ErrValidation, err)
 	}
 
+	from, err := apiutil.ReadNumQuery[float64](r, fromKey, 0)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	to, err := apiutil.ReadNumQuery[float64](r, toKey, 0)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	def, err := apiutil.ReadNumQuery[int64](r, defKey, defDef)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	attr, err := apiutil.ReadStringQuery(r, attrKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	dir, err := apiutil.ReadStringQuery(r, api.DirKey, api.AscDir)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	sim, err := apiutil.ReadBoolQuery(r, simKey, false)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
 	req := listStatesReq{
-		token:  apiutil.ExtractBearerToken(r),
-		limit:  l,
-		offset: o,
-		id:     chi.URLParam(r, "twinID"),
+		token:      apiutil.ExtractBearerToken(r),
+		domainID:   chi.URLParam(r, "domainID"),
+		limit:      l,
+		offset:     o,
+		id:         chi.URLParam(r, "twinID"),
+		from:       from,
+		to:         to,
+		definition: int(def),
+		attribute:  attr,
+		dir:        dir,
+		simulated:  sim,
+	}
+
+	return req, nil
+}
+
+func decodeListAlarms(_ context.Context, r *http.Request) (interface{}, error) {
+	l, err := apiutil.ReadNumQuery[uint64](r, limitKey, defLimit)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	o, err := apiutil.ReadNumQuery[uint64](r, offsetKey, defOffset)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	attr, err := apiutil.ReadStringQuery(r, attrKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	severity, err := apiutil.ReadStringQuery(r, severityKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	status, err := apiutil.ReadStringQuery(r, statusKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	req := listAlarmsReq{
+		token:     apiutil.ExtractBearerToken(r),
+		domainID:  chi.URLParam(r, "domainID"),
+		limit:     l,
+		offset:    o,
+		id:        chi.URLParam(r, "twinID"),
+		attribute: attr,
+		severity:  severity,
+		status:    status,
+	}
+
+	return req, nil
+}
+
+func decodeStateAt(_ context.Context, r *http.Request) (interface{}, error) {
+	at, err := apiutil.ReadNumQuery[float64](r, timeKey, 0)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	req := stateAtReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "twinID"),
+		at:       at,
+	}
+
+	return req, nil
+}
+
+func decodeDiffStates(_ context.Context, r *http.Request) (interface{}, error) {
+	fromID, err := apiutil.ReadNumQuery[int64](r, fromIDKey, defState)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	toID, err := apiutil.ReadNumQuery[int64](r, toIDKey, defState)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	fromAt, err := apiutil.ReadNumQuery[float64](r, fromTimeKey, 0)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	toAt, err := apiutil.ReadNumQuery[float64](r, toTimeKey, 0)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	req := diffStatesReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "twinID"),
+		fromID:   fromID,
+		toID:     toID,
+		fromTime: fromAt,
+		toTime:   toAt,
+	}
+
+	return req, nil
+}
+
+func decodeReplayStates(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := replayStatesReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "twinID"),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(errors.ErrMalformedEntity, err))
+	}
+
+	return req, nil
+}
+
+func decodeDesiredState(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := desiredStateReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "twinID"),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(errors.ErrMalformedEntity, err))
+	}
+
+	return req, nil
+}
+
+func decodeShareTwin(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := shareTwinReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "twinID"),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(errors.ErrMalformedEntity, err))
+	}
+
+	return req, nil
+}
+
+func decodeTemplateCreation(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := addTemplateReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(errors.ErrMalformedEntity, err))
+	}
+
+	return req, nil
+}
+
+func decodeTemplateUpdate(_ context.Context, r *http.Request) (interface{}, error) {
+	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+
+	req := updateTemplateReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "templateID"),
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(errors.ErrMalformedEntity, err))
+	}
+
+	return req, nil
+}
+
+func decodeViewTemplate(_ context.Context, r *http.Request) (interface{}, error) {
+	req := viewTwinReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "templateID"),
+	}
+
+	return req, nil
+}
+
+func decodeListTemplates(_ context.Context, r *http.Request) (interface{}, error) {
+	l, err := apiutil.ReadNumQuery[uint64](r, limitKey, defLimit)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	o, err := apiutil.ReadNumQuery[uint64](r, offsetKey, defOffset)
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	n, err := apiutil.ReadStringQuery(r, nameKey, "")
+	if err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, err)
+	}
+
+	req := listTemplatesReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		limit:    l,
+		offset:   o,
+		name:     n,
+	}
+
+	return req, nil
+}
+
+func decodeRolloutTemplate(_ context.Context, r *http.Request) (interface{}, error) {
+	req := rolloutTemplateReq{
+		token:    apiutil.ExtractBearerToken(r),
+		domainID: chi.URLParam(r, "domainID"),
+		id:       chi.URLParam(r, "templateID"),
+	}
+	if r.ContentLength == 0 {
+		return req, nil
+	}
+
+	if !strings.Contains(r.Header.Get("Content-Type"), contentType) {
+		return nil, errors.Wrap(apiutil.ErrValidation, apiutil.ErrUnsupportedContentType)
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.Wrap(apiutil.ErrValidation, errors.Wrap(errors.ErrMalformedEntity, err))
 	}
 
 	return req, nil
@@ -26,7 +26,7 @@ func LoggingMiddleware(svc twins.Service, logger *slog.Logger) twins.Service {
 	return &loggingMiddleware{logger, svc}
 }
 
-func (lm *loggingMiddleware) AddTwin(ctx context.Context, token string, twin twins.Twin, def twins.Definition) (tw twins.Twin, err error) {
+func (lm *loggingMiddleware) AddTwin(ctx context.Context, token, domainID string, twin twins.Twin, def twins.Definition) (tw twins.Twin, err error) {
 	defer func(begin time.Time) {
 		args := []any{
 			slog.String("duration", time.Since(begin).String()),
@@ -44,10 +44,10 @@ func (lm *loggingMiddleware) AddTwin(ctx context.Context, token string, twin twi
 		lm.logger.Info("Add twin completed successfully", args...)
 	}(time.Now())
 
-	return lm.svc.AddTwin(ctx, token, twin, def)
+	return lm.svc.AddTwin(ctx, token, domainID, twin, def)
 }
 
-func (lm *loggingMiddleware) UpdateTwin(ctx context.Context, token string, twin twins.Twin, def twins.Definition) (err error) {
+func (lm *loggingMiddleware) UpdateTwin(ctx context.Context, token, domainID string, twin twins.Twin, def twins.Definition, revision int) (err error) {
 	defer func(begin time.Time) {
 		args := []any{
 			slog.String("duration", time.Since(begin).String()),
@@ -56,6 +56,7 @@ func (lm *loggingMiddleware) UpdateTwin(ctx context.Context, token string, twin
 				slog.String("name", twin.Name),
 				slog.Any("definitions", def),
 			),
+			slog.Int("revision", revision),
 		}
 		if err != nil {
 			args = append(args, slog.Any("error", err))
@@ -65,10 +66,10 @@ func (lm *loggingMiddleware) UpdateTwin(ctx context.Context, token string, twin
 		lm.logger.Info("Update twin completed successfully", args...)
 	}(time.Now())
 
-	return lm.svc.UpdateTwin(ctx, token, twin, def)
+	return lm.svc.UpdateTwin(ctx, token, domainID, twin, def, revision)
 }
 
-func (lm *loggingMiddleware) ViewTwin(ctx context.Context, token, twinID string) (tw twins.Twin, err error) {
+func (lm *loggingMiddleware) ViewTwin(ctx context.Context, token, domainID, twinID string) (tw twins.Twin, err error) {
 	defer func(begin time.Time) {
 		args := []any{
 			slog.String("duration", time.Since(begin).String()),
@@ -82,15 +83,16 @@ func (lm *loggingMiddleware) ViewTwin(ctx context.Context, token, twinID string)
 		lm.logger.Info("View twin completed successfully", args...)
 	}(time.Now())
 
-	return lm.svc.ViewTwin(ctx, token, twinID)
+	return lm.svc.ViewTwin(ctx, token, domainID, twinID)
 }
 
-func (lm *loggingMiddleware) ListTwins(ctx context.Context, token string, offset, limit uint64, name string, metadata twins.Metadata) (page twins.Page, err error) {
+func (lm *loggingMiddleware) ListTwins(ctx context.Context, token, domainID string, offset, limit uint64, name, parentID string, metadata twins.Metadata) (page twins.Page, err error) {
 	defer func(begin time.Time) {
 		args := []any{
 			slog.String("duration", time.Since(begin).String()),
 			slog.Group("page",
 				slog.String("name", name),
+				slog.String("parent_id", parentID),
 				slog.Uint64("offset", offset),
 				slog.Uint64("limit", limit),
 				slog.Uint64("total", page.Total),
@@ -104,7 +106,132 @@ func (lm *loggingMiddleware) ListTwins(ctx context.Context, token string, offset
 		lm.logger.Info("List twins completed successfully", args...)
 	}(time.Now())
 
-	return lm.svc.ListTwins(ctx, token, offset, limit, name, metadata)
+	return lm.svc.ListTwins(ctx, token, domainID, offset, limit, name, parentID, metadata)
+}
+
+func (lm *loggingMiddleware) ExportTwins(ctx context.Context, token, domainID, ownerID string) (tws []twins.Twin, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("export",
+				slog.String("owner_id", ownerID),
+				slog.Int("twins", len(tws)),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Export twins failed", args...)
+			return
+		}
+		lm.logger.Info("Export twins completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ExportTwins(ctx, token, domainID, ownerID)
+}
+
+func (lm *loggingMiddleware) ImportTwins(ctx context.Context, token, domainID string, tws []twins.Twin, opts twins.ImportOptions) (imp twins.Import, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("import",
+				slog.Int("twins", len(tws)),
+				slog.Bool("remap_ids", opts.RemapIDs),
+				slog.Bool("dry_run", opts.DryRun),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Import twins failed", args...)
+			return
+		}
+		lm.logger.Info("Import twins completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ImportTwins(ctx, token, domainID, tws, opts)
+}
+
+func (lm *loggingMiddleware) ImportDTDL(ctx context.Context, token, domainID string, model []byte, opts twins.DTDLImportOptions) (imp twins.DTDLImport, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("import",
+				slog.String("channel", opts.Channel),
+				slog.String("interface", opts.Interface),
+				slog.Bool("dry_run", opts.DryRun),
+				slog.Int("twins", len(imp.Twins)),
+				slog.Int("issues", len(imp.Issues)),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Import DTDL failed", args...)
+			return
+		}
+		lm.logger.Info("Import DTDL completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ImportDTDL(ctx, token, domainID, model, opts)
+}
+
+func (lm *loggingMiddleware) ListDefinitions(ctx context.Context, token, domainID, twinID string) (defs []twins.DefinitionSummary, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("twin_id", twinID),
+			slog.Int("definitions", len(defs)),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("List definitions failed", args...)
+			return
+		}
+		lm.logger.Info("List definitions completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ListDefinitions(ctx, token, domainID, twinID)
+}
+
+func (lm *loggingMiddleware) DiffDefinitions(ctx context.Context, token, domainID, twinID string, from, to int) (diff twins.DefinitionDiff, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("twin_id", twinID),
+			slog.Group("diff",
+				slog.Int("from", from),
+				slog.Int("to", to),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Diff definitions failed", args...)
+			return
+		}
+		lm.logger.Info("Diff definitions completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.DiffDefinitions(ctx, token, domainID, twinID, from, to)
+}
+
+func (lm *loggingMiddleware) RollbackDefinition(ctx context.Context, token, domainID, twinID string, definitionID, revision int) (def twins.Definition, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("twin_id", twinID),
+			slog.Group("rollback",
+				slog.Int("definition_id", definitionID),
+				slog.Int("revision", revision),
+				slog.Int("new_definition_id", def.ID),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Rollback definition failed", args...)
+			return
+		}
+		lm.logger.Info("Rollback definition completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.RollbackDefinition(ctx, token, domainID, twinID, definitionID, revision)
 }
 
 func (lm *loggingMiddleware) SaveStates(ctx context.Context, msg *messaging.Message) (err error) {
@@ -128,7 +255,49 @@ func (lm *loggingMiddleware) SaveStates(ctx context.Context, msg *messaging.Mess
 	return lm.svc.SaveStates(ctx, msg)
 }
 
-func (lm *loggingMiddleware) ListStates(ctx context.Context, token string, offset, limit uint64, twinID string) (page twins.StatesPage, err error) {
+func (lm *loggingMiddleware) DrainStates(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Drain states failed", args...)
+			return
+		}
+		lm.logger.Info("Drain states completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.DrainStates(ctx)
+}
+
+func (lm *loggingMiddleware) ReplayStates(ctx context.Context, token, domainID, twinID string, opts twins.ReplayOptions) (rp twins.Replay, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("twin_id", twinID),
+			slog.Group("replay",
+				slog.Time("from", opts.From),
+				slog.Time("to", opts.To),
+				slog.Bool("simulate", opts.Simulate),
+				slog.Uint64("messages", rp.Messages),
+				slog.Uint64("skipped", rp.Skipped),
+				slog.Int64("removed", rp.Removed),
+				slog.Int("created", rp.Created),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Replay states failed", args...)
+			return
+		}
+		lm.logger.Info("Replay states completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ReplayStates(ctx, token, domainID, twinID, opts)
+}
+
+func (lm *loggingMiddleware) ListStates(ctx context.Context, token, domainID string, offset, limit uint64, twinID string, filter twins.StateFilter) (page twins.StatesPage, err error) {
 	defer func(begin time.Time) {
 		args := []any{
 			slog.String("duration", time.Since(begin).String()),
@@ -139,6 +308,15 @@ func (lm *loggingMiddleware) ListStates(ctx context.Context, token string, offse
 				slog.Uint64("total", page.Total),
 			),
 		}
+		if !filter.From.IsZero() || !filter.To.IsZero() {
+			args = append(args, slog.Group("period",
+				slog.Time("from", filter.From),
+				slog.Time("to", filter.To),
+			))
+		}
+		if filter.Attribute != "" {
+			args = append(args, slog.String("attribute", filter.Attribute))
+		}
 		if err != nil {
 			args = append(args, slog.Any("error", err))
 			lm.logger.Warn("List states failed", args...)
@@ -147,14 +325,47 @@ func (lm *loggingMiddleware) ListStates(ctx context.Context, token string, offse
 		lm.logger.Info("List states completed successfully", args...)
 	}(time.Now())
 
-	return lm.svc.ListStates(ctx, token, offset, limit, twinID)
+	return lm.svc.ListStates(ctx, token, domainID, offset, limit, twinID, filter)
 }
 
-func (lm *loggingMiddleware) RemoveTwin(ctx context.Context, token, twinID string) (err error) {
+func (lm *loggingMiddleware) ListAlarms(ctx context.Context, token, domainID string, offset, limit uint64, twinID string, filter twins.AlarmFilter) (page twins.AlarmsPage, err error) {
 	defer func(begin time.Time) {
 		args := []any{
 			slog.String("duration", time.Since(begin).String()),
 			slog.String("twin_id", twinID),
+			slog.Group("page",
+				slog.Uint64("offset", offset),
+				slog.Uint64("limit", limit),
+				slog.Uint64("total", page.Total),
+			),
+		}
+		if filter.Attribute != "" {
+			args = append(args, slog.String("attribute", filter.Attribute))
+		}
+		if filter.Severity != "" {
+			args = append(args, slog.String("severity", filter.Severity))
+		}
+		if filter.Status != "" {
+			args = append(args, slog.String("status", filter.Status))
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("List alarms failed", args...)
+			return
+		}
+		lm.logger.Info("List alarms completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ListAlarms(ctx, token, domainID, offset, limit, twinID, filter)
+}
+
+func (lm *loggingMiddleware) RemoveTwin(ctx context.Context, token, domainID, twinID string, cascade bool, revision int) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("twin_id", twinID),
+			slog.Bool("cascade", cascade),
+			slog.Int("revision", revision),
 		}
 		if err != nil {
 			args = append(args, slog.Any("error", err))
@@ -164,5 +375,469 @@ func (lm *loggingMiddleware) RemoveTwin(ctx context.Context, token, twinID strin
 		lm.logger.Info("Remove twin completed successfully", args...)
 	}(time.Now())
 
-	return lm.svc.RemoveTwin(ctx, token, twinID)
+	return lm.svc.RemoveTwin(ctx, token, domainID, twinID, cascade, revision)
+}
+
+func (lm *loggingMiddleware) ShareTwin(ctx context.Context, token, domainID, twinID, role string, userIDs []string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("domain_id", domainID),
+			slog.String("twin_id", twinID),
+			slog.String("role", role),
+			slog.Any("user_ids", userIDs),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Share twin failed", args...)
+			return
+		}
+		lm.logger.Info("Share twin completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ShareTwin(ctx, token, domainID, twinID, role, userIDs)
+}
+
+func (lm *loggingMiddleware) UnshareTwin(ctx context.Context, token, domainID, twinID string, userIDs []string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("domain_id", domainID),
+			slog.String("twin_id", twinID),
+			slog.Any("user_ids", userIDs),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Unshare twin failed", args...)
+			return
+		}
+		lm.logger.Info("Unshare twin completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.UnshareTwin(ctx, token, domainID, twinID, userIDs)
+}
+
+func (lm *loggingMiddleware) AttachChild(ctx context.Context, token, domainID, parentID, childID string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("parent_id", parentID),
+			slog.String("child_id", childID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Attach child twin failed", args...)
+			return
+		}
+		lm.logger.Info("Attach child twin completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.AttachChild(ctx, token, domainID, parentID, childID)
+}
+
+func (lm *loggingMiddleware) DetachChild(ctx context.Context, token, domainID, parentID, childID string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("parent_id", parentID),
+			slog.String("child_id", childID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Detach child twin failed", args...)
+			return
+		}
+		lm.logger.Info("Detach child twin completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.DetachChild(ctx, token, domainID, parentID, childID)
+}
+
+func (lm *loggingMiddleware) ViewSubtree(ctx context.Context, token, domainID, twinID string) (node twins.TwinNode, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("twin_id", twinID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("View twin subtree failed", args...)
+			return
+		}
+		lm.logger.Info("View twin subtree completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ViewSubtree(ctx, token, domainID, twinID)
+}
+
+func (lm *loggingMiddleware) ViewCompositeState(ctx context.Context, token, domainID, twinID string) (cs twins.CompositeState, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("twin_id", twinID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("View composite state failed", args...)
+			return
+		}
+		lm.logger.Info("View composite state completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ViewCompositeState(ctx, token, domainID, twinID)
+}
+
+func (lm *loggingMiddleware) AddRelation(ctx context.Context, token, domainID string, rel twins.Relation) (r twins.Relation, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("relation",
+				slog.String("source_id", rel.Source),
+				slog.String("type", rel.Type),
+				slog.String("target_id", rel.Target),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Add relation failed", args...)
+			return
+		}
+		lm.logger.Info("Add relation completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.AddRelation(ctx, token, domainID, rel)
+}
+
+func (lm *loggingMiddleware) RemoveRelation(ctx context.Context, token, domainID string, rel twins.Relation) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("relation",
+				slog.String("source_id", rel.Source),
+				slog.String("type", rel.Type),
+				slog.String("target_id", rel.Target),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Remove relation failed", args...)
+			return
+		}
+		lm.logger.Info("Remove relation completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.RemoveRelation(ctx, token, domainID, rel)
+}
+
+func (lm *loggingMiddleware) ListRelations(ctx context.Context, token, domainID, twinID string, offset, limit uint64, filter twins.RelationFilter) (page twins.RelationsPage, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("twin_id", twinID),
+			slog.Group("page",
+				slog.Uint64("offset", offset),
+				slog.Uint64("limit", limit),
+				slog.Uint64("total", page.Total),
+				slog.Any("types", filter.Types),
+				slog.String("direction", filter.Direction),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("List relations failed", args...)
+			return
+		}
+		lm.logger.Info("List relations completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ListRelations(ctx, token, domainID, twinID, offset, limit, filter)
+}
+
+func (lm *loggingMiddleware) TraverseRelations(ctx context.Context, token, domainID, twinID string, query twins.TraversalQuery) (trav twins.Traversal, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("twin_id", twinID),
+			slog.Group("traversal",
+				slog.Any("types", query.Types),
+				slog.String("direction", query.Direction),
+				slog.Int("depth", query.Depth),
+				slog.Int("hops", len(trav.Hops)),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Traverse relations failed", args...)
+			return
+		}
+		lm.logger.Info("Traverse relations completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.TraverseRelations(ctx, token, domainID, twinID, query)
+}
+
+func (lm *loggingMiddleware) UpdateDesiredState(ctx context.Context, token, domainID, twinID string, payload map[string]interface{}) (delta twins.Delta, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("twin_id", twinID),
+			slog.Any("delta", delta),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Update desired state failed", args...)
+			return
+		}
+		lm.logger.Info("Update desired state completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.UpdateDesiredState(ctx, token, domainID, twinID, payload)
+}
+
+func (lm *loggingMiddleware) ViewDesiredState(ctx context.Context, token, domainID, twinID string) (ds twins.DesiredState, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("twin_id", twinID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("View desired state failed", args...)
+			return
+		}
+		lm.logger.Info("View desired state completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ViewDesiredState(ctx, token, domainID, twinID)
+}
+
+func (lm *loggingMiddleware) ViewDelta(ctx context.Context, token, domainID, twinID string) (delta twins.Delta, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("twin_id", twinID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("View delta failed", args...)
+			return
+		}
+		lm.logger.Info("View delta completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ViewDelta(ctx, token, domainID, twinID)
+}
+
+func (lm *loggingMiddleware) StateAt(ctx context.Context, token, domainID, twinID string, at time.Time) (st twins.State, def twins.Definition, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("twin_id", twinID),
+			slog.Time("at", at),
+			slog.Int64("state_id", st.ID),
+			slog.Int("definition_id", def.ID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("View state at time failed", args...)
+			return
+		}
+		lm.logger.Info("View state at time completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.StateAt(ctx, token, domainID, twinID, at)
+}
+
+func (lm *loggingMiddleware) DiffStates(ctx context.Context, token, domainID, twinID string, from, to twins.StateSelector) (diff twins.StateDiff, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("twin_id", twinID),
+			slog.Int64("from", diff.From.ID),
+			slog.Int64("to", diff.To.ID),
+			slog.Group("diff",
+				slog.Int("added", len(diff.Added)),
+				slog.Int("removed", len(diff.Removed)),
+				slog.Int("changed", len(diff.Changed)),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Diff states failed", args...)
+			return
+		}
+		lm.logger.Info("Diff states completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.DiffStates(ctx, token, domainID, twinID, from, to)
+}
+
+func (lm *loggingMiddleware) StreamStates(ctx context.Context, token, domainID string, twinIDs, attributes []string) (events <-chan twins.StreamEvent, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Any("twin_ids", twinIDs),
+			slog.Any("attributes", attributes),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Stream states failed", args...)
+			return
+		}
+		lm.logger.Info("Stream states started successfully", args...)
+	}(time.Now())
+
+	return lm.svc.StreamStates(ctx, token, domainID, twinIDs, attributes)
+}
+
+func (lm *loggingMiddleware) AddTemplate(ctx context.Context, token, domainID string, tmpl twins.Template) (t twins.Template, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("template",
+				slog.String("id", t.ID),
+				slog.String("name", t.Name),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Add template failed", args...)
+			return
+		}
+		lm.logger.Info("Add template completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.AddTemplate(ctx, token, domainID, tmpl)
+}
+
+func (lm *loggingMiddleware) UpdateTemplate(ctx context.Context, token, domainID string, tmpl twins.Template) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("template",
+				slog.String("id", tmpl.ID),
+				slog.String("name", tmpl.Name),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Update template failed", args...)
+			return
+		}
+		lm.logger.Info("Update template completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.UpdateTemplate(ctx, token, domainID, tmpl)
+}
+
+func (lm *loggingMiddleware) ViewTemplate(ctx context.Context, token, domainID, templateID string) (tmpl twins.Template, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("template_id", templateID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("View template failed", args...)
+			return
+		}
+		lm.logger.Info("View template completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ViewTemplate(ctx, token, domainID, templateID)
+}
+
+func (lm *loggingMiddleware) ListTemplates(ctx context.Context, token, domainID string, offset, limit uint64, name string) (page twins.TemplatesPage, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("page",
+				slog.String("name", name),
+				slog.Uint64("offset", offset),
+				slog.Uint64("limit", limit),
+				slog.Uint64("total", page.Total),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("List templates failed", args...)
+			return
+		}
+		lm.logger.Info("List templates completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ListTemplates(ctx, token, domainID, offset, limit, name)
+}
+
+func (lm *loggingMiddleware) RemoveTemplate(ctx context.Context, token, domainID, templateID string) (err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.String("template_id", templateID),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Remove template failed", args...)
+			return
+		}
+		lm.logger.Info("Remove template completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.RemoveTemplate(ctx, token, domainID, templateID)
+}
+
+func (lm *loggingMiddleware) RolloutTemplate(ctx context.Context, token, domainID, templateID string, bindings map[string]string) (ro twins.Rollout, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Group("rollout",
+				slog.String("template_id", templateID),
+				slog.Int("revision", ro.Revision),
+				slog.Int("updated", len(ro.Updated)),
+				slog.Int("skipped", len(ro.Skipped)),
+			),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Rollout template failed", args...)
+			return
+		}
+		lm.logger.Info("Rollout template completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.RolloutTemplate(ctx, token, domainID, templateID, bindings)
+}
+
+func (lm *loggingMiddleware) ApplyRetention(ctx context.Context) (comps []twins.Compaction, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Int("compacted_twins", len(comps)),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Apply retention failed", args...)
+			return
+		}
+		lm.logger.Info("Apply retention completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.ApplyRetention(ctx)
+}
+
+func (lm *loggingMiddleware) CheckLiveness(ctx context.Context) (trs []twins.Staleness, err error) {
+	defer func(begin time.Time) {
+		args := []any{
+			slog.String("duration", time.Since(begin).String()),
+			slog.Int("transitions", len(trs)),
+		}
+		if err != nil {
+			args = append(args, slog.Any("error", err))
+			lm.logger.Warn("Check liveness failed", args...)
+			return
+		}
+		lm.logger.Info("Check liveness completed successfully", args...)
+	}(time.Now())
+
+	return lm.svc.CheckLiveness(ctx)
 }
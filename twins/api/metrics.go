@@ -31,40 +31,130 @@ func MetricsMiddleware(svc twins.Service, counter metrics.Counter, latency metri
 	}
 }
 
-func (ms *metricsMiddleware) AddTwin(ctx context.Context, token string, twin twins.Twin, def twins.Definition) (saved twins.Twin, err error) {
+func (ms *metricsMiddleware) AddTwin(ctx context.Context, token, domainID string, twin twins.Twin, def twins.Definition) (saved twins.Twin, err error) {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "add_twin").Add(1)
 		ms.latency.With("method", "add_twin").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return ms.svc.AddTwin(ctx, token, twin, def)
+	return ms.svc.AddTwin(ctx, token, domainID, twin, def)
 }
 
-func (ms *metricsMiddleware) UpdateTwin(ctx context.Context, token string, twin twins.Twin, def twins.Definition) (err error) {
+func (ms *metricsMiddleware) UpdateTwin(ctx context.Context, token, domainID string, twin twins.Twin, def twins.Definition, revision int) (err error) {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "update_twin").Add(1)
 		ms.latency.With("method", "update_twin").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return ms.svc.UpdateTwin(ctx, token, twin, def)
+	return ms.svc.UpdateTwin(ctx, token, domainID, twin, def, revision)
 }
 
-func (ms *metricsMiddleware) ViewTwin(ctx context.Context, token, twinID string) (tw twins.Twin, err error) {
+func (ms *metricsMiddleware) ViewTwin(ctx context.Context, token, domainID, twinID string) (tw twins.Twin, err error) {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "view_twin").Add(1)
 		ms.latency.With("method", "view_twin").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return ms.svc.ViewTwin(ctx, token, twinID)
+	return ms.svc.ViewTwin(ctx, token, domainID, twinID)
 }
 
-func (ms *metricsMiddleware) ListTwins(ctx context.Context, token string, offset, limit uint64, name string, metadata twins.Metadata) (page twins.Page, err error) {
+func (ms *metricsMiddleware) ListTwins(ctx context.Context, token, domainID string, offset, limit uint64, name, parentID string, metadata twins.Metadata) (page twins.Page, err error) {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "list_twins").Add(1)
 		ms.latency.With("method", "list_twins").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return ms.svc.ListTwins(ctx, token, offset, limit, name, metadata)
+	return ms.svc.ListTwins(ctx, token, domainID, offset, limit, name, parentID, metadata)
+}
+
+func (ms *metricsMiddleware) ExportTwins(ctx context.Context, token, domainID, ownerID string) ([]twins.Twin, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "export_twins").Add(1)
+		ms.latency.With("method", "export_twins").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ExportTwins(ctx, token, domainID, ownerID)
+}
+
+func (ms *metricsMiddleware) ImportTwins(ctx context.Context, token, domainID string, tws []twins.Twin, opts twins.ImportOptions) (twins.Import, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "import_twins").Add(1)
+		ms.latency.With("method", "import_twins").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ImportTwins(ctx, token, domainID, tws, opts)
+}
+
+func (ms *metricsMiddleware) ImportDTDL(ctx context.Context, token, domainID string, model []byte, opts twins.DTDLImportOptions) (twins.DTDLImport, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "import_dtdl").Add(1)
+		ms.latency.With("method", "import_dtdl").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ImportDTDL(ctx, token, domainID, model, opts)
+}
+
+func (ms *metricsMiddleware) ListDefinitions(ctx context.Context, token, domainID, twinID string) ([]twins.DefinitionSummary, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "list_definitions").Add(1)
+		ms.latency.With("method", "list_definitions").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ListDefinitions(ctx, token, domainID, twinID)
+}
+
+func (ms *metricsMiddleware) DiffDefinitions(ctx context.Context, token, domainID, twinID string, from, to int) (twins.DefinitionDiff, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "diff_definitions").Add(1)
+		ms.latency.With("method", "diff_definitions").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.DiffDefinitions(ctx, token, domainID, twinID, from, to)
+}
+
+func (ms *metricsMiddleware) RollbackDefinition(ctx context.Context, token, domainID, twinID string, definitionID, revision int) (twins.Definition, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "rollback_definition").Add(1)
+		ms.latency.With("method", "rollback_definition").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.RollbackDefinition(ctx, token, domainID, twinID, definitionID, revision)
+}
+
+func (ms *metricsMiddleware) AddRelation(ctx context.Context, token, domainID string, rel twins.Relation) (twins.Relation, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "add_relation").Add(1)
+		ms.latency.With("method", "add_relation").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.AddRelation(ctx, token, domainID, rel)
+}
+
+func (ms *metricsMiddleware) RemoveRelation(ctx context.Context, token, domainID string, rel twins.Relation) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "remove_relation").Add(1)
+		ms.latency.With("method", "remove_relation").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.RemoveRelation(ctx, token, domainID, rel)
+}
+
+func (ms *metricsMiddleware) ListRelations(ctx context.Context, token, domainID, twinID string, offset, limit uint64, filter twins.RelationFilter) (twins.RelationsPage, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "list_relations").Add(1)
+		ms.latency.With("method", "list_relations").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ListRelations(ctx, token, domainID, twinID, offset, limit, filter)
+}
+
+func (ms *metricsMiddleware) TraverseRelations(ctx context.Context, token, domainID, twinID string, query twins.TraversalQuery) (twins.Traversal, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "traverse_relations").Add(1)
+		ms.latency.With("method", "traverse_relations").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.TraverseRelations(ctx, token, domainID, twinID, query)
 }
 
 func (ms *metricsMiddleware) SaveStates(ctx context.Context, msg *messaging.Message) error {
@@ -76,20 +166,227 @@ func (ms *metricsMiddleware) SaveStates(ctx context.Context, msg *messaging.Mess
 	return ms.svc.SaveStates(ctx, msg)
 }
 
-func (ms *metricsMiddleware) ListStates(ctx context.Context, token string, offset, limit uint64, twinID string) (st twins.StatesPage, err error) {
+func (ms *metricsMiddleware) DrainStates(ctx context.Context) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "drain_states").Add(1)
+		ms.latency.With("method", "drain_states").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.DrainStates(ctx)
+}
+
+func (ms *metricsMiddleware) ReplayStates(ctx context.Context, token, domainID, twinID string, opts twins.ReplayOptions) (twins.Replay, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "replay_states").Add(1)
+		ms.latency.With("method", "replay_states").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ReplayStates(ctx, token, domainID, twinID, opts)
+}
+
+func (ms *metricsMiddleware) ListStates(ctx context.Context, token, domainID string, offset, limit uint64, twinID string, filter twins.StateFilter) (st twins.StatesPage, err error) {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "list_states").Add(1)
 		ms.latency.With("method", "list_states").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return ms.svc.ListStates(ctx, token, offset, limit, twinID)
+	return ms.svc.ListStates(ctx, token, domainID, offset, limit, twinID, filter)
 }
 
-func (ms *metricsMiddleware) RemoveTwin(ctx context.Context, token, twinID string) (err error) {
+func (ms *metricsMiddleware) ListAlarms(ctx context.Context, token, domainID string, offset, limit uint64, twinID string, filter twins.AlarmFilter) (page twins.AlarmsPage, err error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "list_alarms").Add(1)
+		ms.latency.With("method", "list_alarms").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ListAlarms(ctx, token, domainID, offset, limit, twinID, filter)
+}
+
+func (ms *metricsMiddleware) RemoveTwin(ctx context.Context, token, domainID, twinID string, cascade bool, revision int) (err error) {
 	defer func(begin time.Time) {
 		ms.counter.With("method", "remove_twin").Add(1)
 		ms.latency.With("method", "remove_twin").Observe(time.Since(begin).Seconds())
 	}(time.Now())
 
-	return ms.svc.RemoveTwin(ctx, token, twinID)
+	return ms.svc.RemoveTwin(ctx, token, domainID, twinID, cascade, revision)
+}
+
+func (ms *metricsMiddleware) ShareTwin(ctx context.Context, token, domainID, twinID, role string, userIDs []string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "share_twin").Add(1)
+		ms.latency.With("method", "share_twin").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ShareTwin(ctx, token, domainID, twinID, role, userIDs)
+}
+
+func (ms *metricsMiddleware) UnshareTwin(ctx context.Context, token, domainID, twinID string, userIDs []string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "unshare_twin").Add(1)
+		ms.latency.With("method", "unshare_twin").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.UnshareTwin(ctx, token, domainID, twinID, userIDs)
+}
+
+func (ms *metricsMiddleware) AttachChild(ctx context.Context, token, domainID, parentID, childID string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "attach_child").Add(1)
+		ms.latency.With("method", "attach_child").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.AttachChild(ctx, token, domainID, parentID, childID)
+}
+
+func (ms *metricsMiddleware) DetachChild(ctx context.Context, token, domainID, parentID, childID string) error {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "detach_child").Add(1)
+		ms.latency.With("method", "detach_child").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.DetachChild(ctx, token, domainID, parentID, childID)
+}
+
+func (ms *metricsMiddleware) ViewSubtree(ctx context.Context, token, domainID, twinID string) (twins.TwinNode, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "view_subtree").Add(1)
+		ms.latency.With("method", "view_subtree").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ViewSubtree(ctx, token, domainID, twinID)
+}
+
+func (ms *metricsMiddleware) ViewCompositeState(ctx context.Context, token, domainID, twinID string) (twins.CompositeState, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "view_composite_state").Add(1)
+		ms.latency.With("method", "view_composite_state").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ViewCompositeState(ctx, token, domainID, twinID)
+}
+
+func (ms *metricsMiddleware) UpdateDesiredState(ctx context.Context, token, domainID, twinID string, payload map[string]interface{}) (delta twins.Delta, err error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "update_desired_state").Add(1)
+		ms.latency.With("method", "update_desired_state").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.UpdateDesiredState(ctx, token, domainID, twinID, payload)
+}
+
+func (ms *metricsMiddleware) ViewDesiredState(ctx context.Context, token, domainID, twinID string) (ds twins.DesiredState, err error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "view_desired_state").Add(1)
+		ms.latency.With("method", "view_desired_state").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ViewDesiredState(ctx, token, domainID, twinID)
+}
+
+func (ms *metricsMiddleware) ViewDelta(ctx context.Context, token, domainID, twinID string) (delta twins.Delta, err error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "view_delta").Add(1)
+		ms.latency.With("method", "view_delta").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ViewDelta(ctx, token, domainID, twinID)
+}
+
+func (ms *metricsMiddleware) StateAt(ctx context.Context, token, domainID, twinID string, at time.Time) (st twins.State, def twins.Definition, err error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "state_at").Add(1)
+		ms.latency.With("method", "state_at").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.StateAt(ctx, token, domainID, twinID, at)
+}
+
+func (ms *metricsMiddleware) DiffStates(ctx context.Context, token, domainID, twinID string, from, to twins.StateSelector) (twins.StateDiff, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "diff_states").Add(1)
+		ms.latency.With("method", "diff_states").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.DiffStates(ctx, token, domainID, twinID, from, to)
+}
+
+func (ms *metricsMiddleware) StreamStates(ctx context.Context, token, domainID string, twinIDs, attributes []string) (events <-chan twins.StreamEvent, err error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "stream_states").Add(1)
+		ms.latency.With("method", "stream_states").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.StreamStates(ctx, token, domainID, twinIDs, attributes)
+}
+
+func (ms *metricsMiddleware) AddTemplate(ctx context.Context, token, domainID string, tmpl twins.Template) (t twins.Template, err error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "add_template").Add(1)
+		ms.latency.With("method", "add_template").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.AddTemplate(ctx, token, domainID, tmpl)
+}
+
+func (ms *metricsMiddleware) UpdateTemplate(ctx context.Context, token, domainID string, tmpl twins.Template) (err error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "update_template").Add(1)
+		ms.latency.With("method", "update_template").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.UpdateTemplate(ctx, token, domainID, tmpl)
+}
+
+func (ms *metricsMiddleware) ViewTemplate(ctx context.Context, token, domainID, templateID string) (tmpl twins.Template, err error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "view_template").Add(1)
+		ms.latency.With("method", "view_template").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ViewTemplate(ctx, token, domainID, templateID)
+}
+
+func (ms *metricsMiddleware) ListTemplates(ctx context.Context, token, domainID string, offset, limit uint64, name string) (page twins.TemplatesPage, err error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "list_templates").Add(1)
+		ms.latency.With("method", "list_templates").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ListTemplates(ctx, token, domainID, offset, limit, name)
+}
+
+func (ms *metricsMiddleware) RemoveTemplate(ctx context.Context, token, domainID, templateID string) (err error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "remove_template").Add(1)
+		ms.latency.With("method", "remove_template").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.RemoveTemplate(ctx, token, domainID, templateID)
+}
+
+func (ms *metricsMiddleware) RolloutTemplate(ctx context.Context, token, domainID, templateID string, bindings map[string]string) (ro twins.Rollout, err error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "rollout_template").Add(1)
+		ms.latency.With("method", "rollout_template").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.RolloutTemplate(ctx, token, domainID, templateID, bindings)
+}
+
+func (ms *metricsMiddleware) ApplyRetention(ctx context.Context) ([]twins.Compaction, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "apply_retention").Add(1)
+		ms.latency.With("method", "apply_retention").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.ApplyRetention(ctx)
+}
+
+func (ms *metricsMiddleware) CheckLiveness(ctx context.Context) ([]twins.Staleness, error) {
+	defer func(begin time.Time) {
+		ms.counter.With("method", "check_liveness").Add(1)
+		ms.latency.With("method", "check_liveness").Observe(time.Since(begin).Seconds())
+	}(time.Now())
+
+	return ms.svc.CheckLiveness(ctx)
 }
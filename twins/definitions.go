@@ -0,0 +1,233 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/absmach/supermq/pkg/errors"
+	svcerr "github.com/absmach/supermq/pkg/errors/service"
+	"github.com/absmach/supermq/pkg/policies"
+)
+
+const descDir = "desc"
+
+var errLatestDefinition = errors.New("definition is already the latest one")
+
+// DefinitionSummary is the twin definition together with the summary of the
+// states recorded under it. First and Last are the creation times of the
+// oldest and the newest of these states, and are zero if there are none.
+type DefinitionSummary struct {
+	Definition
+	States uint64
+	First  time.Time
+	Last   time.Time
+}
+
+// AttributeChange holds the attribute of the source and the target
+// definition which share the name but differ otherwise.
+type AttributeChange struct {
+	Name string
+	From Attribute
+	To   Attribute
+}
+
+// RetentionChange holds the retention of the source and the target
+// definition. Nil retention means the states are kept forever.
+type RetentionChange struct {
+	From *Retention
+	To   *Retention
+}
+
+// DefinitionDiff describes the changes made by the target definition to the
+// source definition. Delta and Retention are set only if they changed, in
+// which case Delta holds the value of the target definition.
+type DefinitionDiff struct {
+	From      int
+	To        int
+	Added     []Attribute
+	Removed   []Attribute
+	Changed   []AttributeChange
+	Delta     *int64
+	Retention *RetentionChange
+}
+
+// DefinitionByID returns the twin definition with the given ID. The second
+// return value reports whether such definition exists.
+func (tw Twin) DefinitionByID(id int) (Definition, bool) {
+	for _, def := range tw.Definitions {
+		if def.ID == id {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+// diffDefinitions compares the attributes of the definitions by name,
+// keeping the order in which they are declared.
+func diffDefinitions(from, to Definition) DefinitionDiff {
+	diff := DefinitionDiff{
+		From:    from.ID,
+		To:      to.ID,
+		Added:   []Attribute{},
+		Removed: []Attribute{},
+		Changed: []AttributeChange{},
+	}
+
+	for _, attr := range to.Attributes {
+		idx := findAttribute(attr.Name, from.Attributes)
+		switch {
+		case idx < 0:
+			diff.Added = append(diff.Added, attr)
+		case !reflect.DeepEqual(from.Attributes[idx], attr):
+			diff.Changed = append(diff.Changed, AttributeChange{Name: attr.Name, From: from.Attributes[idx], To: attr})
+		}
+	}
+	for _, attr := range from.Attributes {
+		if findAttribute(attr.Name, to.Attributes) < 0 {
+			diff.Removed = append(diff.Removed, attr)
+		}
+	}
+
+	if from.Delta != to.Delta {
+		delta := to.Delta
+		diff.Delta = &delta
+	}
+	if !reflect.DeepEqual(from.Retention, to.Retention) {
+		diff.Retention = &RetentionChange{From: from.Retention, To: to.Retention}
+	}
+
+	return diff
+}
+
+func (ts *twinservice) ListDefinitions(ctx context.Context, token, domainID, twinID string) ([]DefinitionSummary, error) {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	tw, err := ts.authorize(ctx, session, domainID, twinID, policies.ViewPermission)
+	if err != nil {
+		return nil, err
+	}
+
+	defs := []DefinitionSummary{}
+	for _, def := range tw.Definitions {
+		sum, err := ts.summarizeDefinition(ctx, twinID, def)
+		if err != nil {
+			return nil, errors.Wrap(svcerr.ErrViewEntity, err)
+		}
+		defs = append(defs, sum)
+	}
+
+	return defs, nil
+}
+
+// summarizeDefinition counts the states recorded under the definition and
+// finds the oldest and the newest of them.
+func (ts *twinservice) summarizeDefinition(ctx context.Context, twinID string, def Definition) (DefinitionSummary, error) {
+	sum := DefinitionSummary{Definition: def}
+
+	filter := StateFilter{Definition: &def.ID}
+	page, err := ts.states.RetrieveAll(ctx, 0, 1, twinID, filter)
+	if err != nil {
+		return DefinitionSummary{}, err
+	}
+	if len(page.States) == 0 {
+		return sum, nil
+	}
+	sum.States = page.Total
+	sum.First = page.States[0].Created
+
+	filter.Dir = descDir
+	if page, err = ts.states.RetrieveAll(ctx, 0, 1, twinID, filter); err != nil {
+		return DefinitionSummary{}, err
+	}
+	if len(page.States) > 0 {
+		sum.Last = page.States[0].Created
+	}
+
+	return sum, nil
+}
+
+func (ts *twinservice) DiffDefinitions(ctx context.Context, token, domainID, twinID string, from, to int) (DefinitionDiff, error) {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return DefinitionDiff{}, err
+	}
+
+	tw, err := ts.authorize(ctx, session, domainID, twinID, policies.ViewPermission)
+	if err != nil {
+		return DefinitionDiff{}, err
+	}
+
+	src, ok := tw.DefinitionByID(from)
+	if !ok {
+		return DefinitionDiff{}, svcerr.ErrNotFound
+	}
+	dst, ok := tw.DefinitionByID(to)
+	if !ok {
+		return DefinitionDiff{}, svcerr.ErrNotFound
+	}
+
+	return diffDefinitions(src, dst), nil
+}
+
+func (ts *twinservice) RollbackDefinition(ctx context.Context, token, domainID, twinID string, definitionID, revision int) (def Definition, err error) {
+	var b []byte
+	defer ts.publish(ctx, &twinID, &err, crudOp["rollbackSucc"], crudOp["rollbackFail"], &b)
+
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return Definition{}, err
+	}
+
+	tw, err := ts.authorize(ctx, session, domainID, twinID, policies.EditPermission)
+	if err != nil {
+		return Definition{}, err
+	}
+
+	if err := checkRevision(tw, revision); err != nil {
+		return Definition{}, err
+	}
+
+	def, ok := tw.DefinitionByID(definitionID)
+	if !ok {
+		return Definition{}, svcerr.ErrNotFound
+	}
+	latest := tw.Definitions[len(tw.Definitions)-1]
+	if def.ID == latest.ID {
+		return Definition{}, errors.Wrap(svcerr.ErrMalformedEntity, errLatestDefinition)
+	}
+	// The old definition is checked like the new one, since the rules and
+	// the channel access may have changed since it was created.
+	if err := validateDefinition(def); err != nil {
+		return Definition{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+	if err := ts.checkChannels(ctx, session.UserID, domainID, subscribePermission, def); err != nil {
+		return Definition{}, err
+	}
+
+	// The copy is appended as the newest definition to keep history linear.
+	def.ID = latest.ID + 1
+	def.Created = time.Now()
+	tw.Definitions = append(tw.Definitions, def)
+	tw.Updated = def.Created
+	tw.Revision++
+
+	if err := ts.twins.Update(ctx, tw); err != nil {
+		return Definition{}, errors.Wrap(svcerr.ErrUpdateEntity, err)
+	}
+	ts.views.invalidate(tw.ID)
+	ts.broadcast(StreamEvent{Type: DefinitionUpdated, TwinID: tw.ID, Definition: &def})
+
+	if b, err = json.Marshal(tw); err != nil {
+		return Definition{}, err
+	}
+
+	return def, ts.twinCache.Update(ctx, tw)
+}
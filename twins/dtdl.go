@@ -0,0 +1,670 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/absmach/supermq/pkg/errors"
+	svcerr "github.com/absmach/supermq/pkg/errors/service"
+)
+
+// Contexts of the supported DTDL versions.
+const (
+	dtdlV2Context = "dtmi:dtdl:context;2"
+	dtdlV3Context = "dtmi:dtdl:context;3"
+)
+
+// Kinds of the DTDL elements.
+const (
+	dtdlInterface    = "Interface"
+	dtdlTelemetry    = "Telemetry"
+	dtdlProperty     = "Property"
+	dtdlCommand      = "Command"
+	dtdlComponent    = "Component"
+	dtdlRelationship = "Relationship"
+	dtdlEnum         = "Enum"
+)
+
+var (
+	errDTDLDocument  = errors.New("invalid DTDL document")
+	errDTDLVersion   = errors.New("unsupported DTDL version")
+	errDTDLInterface = errors.New("DTDL interface does not exist")
+)
+
+// dtdlPrimitives maps the DTDL primitive schemas to the attribute types.
+// Temporal schemas are encoded as strings.
+var dtdlPrimitives = map[string]string{
+	"boolean":         BoolType,
+	"byte":            NumberType,
+	"short":           NumberType,
+	"integer":         NumberType,
+	"long":            NumberType,
+	"unsignedByte":    NumberType,
+	"unsignedShort":   NumberType,
+	"unsignedInteger": NumberType,
+	"unsignedLong":    NumberType,
+	"float":           NumberType,
+	"double":          NumberType,
+	"decimal":         NumberType,
+	"string":          StringType,
+	"uuid":            StringType,
+	"date":            StringType,
+	"dateTime":        StringType,
+	"time":            StringType,
+	"duration":        StringType,
+	"bytes":           DataType,
+}
+
+// dtdlUnits maps the DTDL units to the SenML units the records are validated
+// against.
+var dtdlUnits = map[string]string{
+	"ampere":                "A",
+	"bar":                   "bar",
+	"candela":               "cd",
+	"coulomb":               "C",
+	"cubicMetre":            "m3",
+	"cubicMetrePerSecond":   "m3/s",
+	"decibel":               "dB",
+	"degreeCelsius":         "Cel",
+	"farad":                 "F",
+	"gram":                  "g",
+	"hertz":                 "Hz",
+	"joule":                 "J",
+	"kelvin":                "K",
+	"kilogram":              "kg",
+	"kilowatt":              "kW",
+	"kilowattHour":          "kWh",
+	"litre":                 "l",
+	"litrePerSecond":        "l/s",
+	"lux":                   "lx",
+	"metre":                 "m",
+	"metrePerSecond":        "m/s",
+	"metrePerSecondSquared": "m/s2",
+	"newton":                "N",
+	"ohm":                   "Ohm",
+	"pascal":                "Pa",
+	"percent":               "%",
+	"radian":                "rad",
+	"revolutionPerMinute":   "rpm",
+	"second":                "s",
+	"volt":                  "V",
+	"watt":                  "W",
+	"wattHour":              "Wh",
+}
+
+// DTDLImportOptions specifies how the DTDL interfaces are imported.
+type DTDLImportOptions struct {
+	// Channel is the channel the attributes of the imported twins are bound
+	// to.
+	Channel string
+
+	// Interface is the identifier of the single interface to import.
+	// Otherwise, every interface of the document which is neither a
+	// component nor extended by another interface is imported.
+	Interface string
+
+	// DryRun converts the interfaces without saving the twins.
+	DryRun bool
+}
+
+// DTDLIssue describes the DTDL construct which could not be converted and
+// was skipped. Element is the name of the interface content, and is empty
+// for the issues of the interface itself.
+type DTDLIssue struct {
+	Interface string
+	Element   string
+	Reason    string
+}
+
+// DTDLImport describes the outcome of importing the DTDL interfaces, one
+// twin per interface.
+type DTDLImport struct {
+	DryRun bool
+	Twins  []Twin
+	Issues []DTDLIssue
+}
+
+// dtdlStrings holds the value which is either a single string or an array of
+// strings, such as the element type.
+type dtdlStrings []string
+
+func (ds *dtdlStrings) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*ds = dtdlStrings{s}
+		return nil
+	}
+	var ss []string
+	if err := json.Unmarshal(data, &ss); err != nil {
+		return err
+	}
+	*ds = ss
+	return nil
+}
+
+func (ds dtdlStrings) has(s string) bool {
+	for _, v := range ds {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// dtdlText holds the value which is either a string or a map of strings
+// keyed by the language code, such as the display name.
+type dtdlText string
+
+func (dt *dtdlText) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*dt = dtdlText(s)
+		return nil
+	}
+	var langs map[string]string
+	if err := json.Unmarshal(data, &langs); err != nil {
+		return err
+	}
+	if en, ok := langs["en"]; ok {
+		*dt = dtdlText(en)
+		return nil
+	}
+	keys := make([]string, 0, len(langs))
+	for k := range langs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(keys) > 0 {
+		*dt = dtdlText(langs[keys[0]])
+	}
+	return nil
+}
+
+// dtdlElements holds the value which is either a single element or an array
+// of elements, such as the extended interfaces.
+type dtdlElements []json.RawMessage
+
+func (de *dtdlElements) UnmarshalJSON(data []byte) error {
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		var elems []json.RawMessage
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return err
+		}
+		*de = elems
+		return nil
+	}
+	*de = dtdlElements{json.RawMessage(data)}
+	return nil
+}
+
+type dtdlInterfaceDoc struct {
+	ID          string        `json:"@id"`
+	Type        dtdlStrings   `json:"@type"`
+	Context     dtdlStrings   `json:"@context"`
+	DisplayName dtdlText      `json:"displayName"`
+	Description dtdlText      `json:"description"`
+	Extends     dtdlElements  `json:"extends"`
+	Contents    []dtdlContent `json:"contents"`
+	Schemas     dtdlElements  `json:"schemas"`
+}
+
+type dtdlContent struct {
+	ID              string          `json:"@id"`
+	Type            dtdlStrings     `json:"@type"`
+	Name            string          `json:"name"`
+	DisplayName     dtdlText        `json:"displayName"`
+	Description     dtdlText        `json:"description"`
+	Schema          json.RawMessage `json:"schema"`
+	Unit            string          `json:"unit"`
+	Writable        bool            `json:"writable"`
+	Target          string          `json:"target"`
+	MaxMultiplicity *int            `json:"maxMultiplicity"`
+	Properties      []dtdlContent   `json:"properties"`
+}
+
+type dtdlSchema struct {
+	ID          string      `json:"@id"`
+	Type        dtdlStrings `json:"@type"`
+	ValueSchema string      `json:"valueSchema"`
+	EnumValues  []struct {
+		Name      string      `json:"name"`
+		EnumValue interface{} `json:"enumValue"`
+	} `json:"enumValues"`
+}
+
+// dtdlConverter converts the DTDL interfaces of a document into the twin
+// definitions and metadata.
+type dtdlConverter struct {
+	channel    string
+	interfaces map[string]dtdlInterfaceDoc
+	schemas    map[string]json.RawMessage
+	nested     map[string]bool
+	order      []string
+	issues     []DTDLIssue
+}
+
+func (ts *twinservice) ImportDTDL(ctx context.Context, token, domainID string, model []byte, opts DTDLImportOptions) (imp DTDLImport, err error) {
+	var b []byte
+	id := domainID
+	defer func() {
+		if opts.DryRun {
+			return
+		}
+		ts.publish(ctx, &id, &err, crudOp["importSucc"], crudOp["importFail"], &b)
+	}()
+
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return DTDLImport{}, err
+	}
+
+	tws, issues, err := convertDTDL(model, opts)
+	if err != nil {
+		return DTDLImport{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+	for _, tw := range tws {
+		if err := validateDefinition(tw.Definitions[0]); err != nil {
+			return DTDLImport{}, errors.Wrap(svcerr.ErrMalformedEntity, errors.Wrap(err, fmt.Errorf("interface %s", tw.Name)))
+		}
+		if err := ts.checkChannels(ctx, session.UserID, domainID, subscribePermission, tw.Definitions[0]); err != nil {
+			return DTDLImport{}, err
+		}
+	}
+
+	imp = DTDLImport{DryRun: opts.DryRun, Twins: tws, Issues: issues}
+	if opts.DryRun {
+		return imp, nil
+	}
+
+	now := time.Now()
+	saved := make([]Twin, 0, len(tws))
+	// Either all the twins are imported or none of them.
+	defer func() {
+		if err != nil {
+			ts.rollbackImport(ctx, saved)
+		}
+	}()
+	for _, tw := range tws {
+		if tw.ID, err = ts.idProvider.ID(); err != nil {
+			return DTDLImport{}, err
+		}
+		tw.Owner = session.UserID
+		tw.Domain = domainID
+		tw.Created = now
+		tw.Updated = now
+		tw.Definitions[0].Created = now
+
+		if _, err := ts.twins.Save(ctx, tw); err != nil {
+			return DTDLImport{}, errors.Wrap(svcerr.ErrCreateEntity, err)
+		}
+		saved = append(saved, tw)
+	}
+	if err := ts.addTwinPolicies(ctx, saved...); err != nil {
+		return DTDLImport{}, err
+	}
+	for _, tw := range saved {
+		if err := ts.twinCache.Save(ctx, tw); err != nil {
+			return DTDLImport{}, err
+		}
+	}
+	imp.Twins = saved
+
+	b, err = json.Marshal(imp)
+
+	return imp, err
+}
+
+// convertDTDL converts the interfaces of the DTDL document, which is either
+// a single interface or an array of interfaces, into the twins with a single
+// definition each. The constructs which cannot be converted are skipped and
+// reported as issues.
+func convertDTDL(model []byte, opts DTDLImportOptions) ([]Twin, []DTDLIssue, error) {
+	var docs dtdlElements
+	if err := json.Unmarshal(model, &docs); err != nil {
+		return nil, nil, errors.Wrap(errDTDLDocument, err)
+	}
+
+	dc := dtdlConverter{
+		channel:    opts.Channel,
+		interfaces: map[string]dtdlInterfaceDoc{},
+		schemas:    map[string]json.RawMessage{},
+		nested:     map[string]bool{},
+		issues:     []DTDLIssue{},
+	}
+	for _, raw := range docs {
+		var iface dtdlInterfaceDoc
+		if err := json.Unmarshal(raw, &iface); err != nil {
+			return nil, nil, errors.Wrap(errDTDLDocument, err)
+		}
+		if !iface.Context.has(dtdlV2Context) && !iface.Context.has(dtdlV3Context) {
+			return nil, nil, errors.Wrap(errDTDLVersion, fmt.Errorf("interface %s", iface.ID))
+		}
+		if err := dc.register(iface); err != nil {
+			return nil, nil, err
+		}
+		dc.order = append(dc.order, iface.ID)
+	}
+	if len(dc.order) == 0 {
+		return nil, nil, errors.Wrap(errDTDLDocument, errors.New("no interfaces"))
+	}
+
+	roots := []string{}
+	switch opts.Interface {
+	case "":
+		for _, id := range dc.order {
+			if !dc.nested[id] {
+				roots = append(roots, id)
+			}
+		}
+	default:
+		if _, ok := dc.interfaces[opts.Interface]; !ok {
+			return nil, nil, errors.Wrap(errDTDLInterface, errors.New(opts.Interface))
+		}
+		roots = append(roots, opts.Interface)
+	}
+
+	tws := []Twin{}
+	for _, id := range roots {
+		tws = append(tws, dc.twin(dc.interfaces[id]))
+	}
+
+	return tws, dc.issues, nil
+}
+
+// register indexes the interface together with the interfaces and the
+// schemas defined inline within it, and marks the components and the
+// extended interfaces as nested.
+func (dc *dtdlConverter) register(iface dtdlInterfaceDoc) error {
+	if iface.ID == "" || !iface.Type.has(dtdlInterface) {
+		return errors.Wrap(errDTDLDocument, fmt.Errorf("element %q is not an interface", iface.ID))
+	}
+	if _, ok := dc.interfaces[iface.ID]; ok {
+		return errors.Wrap(errDTDLDocument, fmt.Errorf("duplicate interface %s", iface.ID))
+	}
+	dc.interfaces[iface.ID] = iface
+
+	for _, raw := range iface.Schemas {
+		var schema dtdlSchema
+		if err := json.Unmarshal(raw, &schema); err != nil || schema.ID == "" {
+			return errors.Wrap(errDTDLDocument, fmt.Errorf("invalid schema of interface %s", iface.ID))
+		}
+		dc.schemas[schema.ID] = raw
+	}
+
+	nested := []json.RawMessage(iface.Extends)
+	for _, c := range iface.Contents {
+		if c.Type.has(dtdlComponent) {
+			nested = append(nested, c.Schema)
+		}
+	}
+	for _, raw := range nested {
+		var ref string
+		if err := json.Unmarshal(raw, &ref); err == nil {
+			dc.nested[ref] = true
+			continue
+		}
+		var inline dtdlInterfaceDoc
+		if err := json.Unmarshal(raw, &inline); err != nil {
+			return errors.Wrap(errDTDLDocument, err)
+		}
+		if err := dc.register(inline); err != nil {
+			return err
+		}
+		dc.nested[inline.ID] = true
+	}
+
+	return nil
+}
+
+// twin converts the interface into the twin. The interface details which
+// have no counterpart in the definition are kept in the dtdl metadata.
+func (dc *dtdlConverter) twin(iface dtdlInterfaceDoc) Twin {
+	meta := map[string]interface{}{
+		"id": iface.ID,
+	}
+	if iface.DisplayName != "" {
+		meta["display_name"] = string(iface.DisplayName)
+	}
+	if iface.Description != "" {
+		meta["description"] = string(iface.Description)
+	}
+
+	attrs := map[string]interface{}{}
+	rels := []interface{}{}
+	def := Definition{
+		Attributes: dc.attributes(iface, "", attrs, &rels, map[string]bool{}),
+		Delta:      millisec,
+	}
+	if len(attrs) > 0 {
+		meta["attributes"] = attrs
+	}
+	if len(rels) > 0 {
+		meta["relationships"] = rels
+	}
+
+	name := string(iface.DisplayName)
+	if name == "" {
+		name = dtmiName(iface.ID)
+	}
+
+	return Twin{
+		Name:        name,
+		Definitions: []Definition{def},
+		Metadata:    Metadata{"dtdl": meta},
+	}
+}
+
+// attributes converts the telemetry and the properties of the interface,
+// of the interfaces it extends and of its components into the attributes.
+// Attributes of the components are prefixed with the component name.
+func (dc *dtdlConverter) attributes(iface dtdlInterfaceDoc, prefix string, meta map[string]interface{}, rels *[]interface{}, visiting map[string]bool) []Attribute {
+	if visiting[iface.ID] {
+		dc.issue(iface.ID, "", "interface is extended or embedded cyclically")
+		return nil
+	}
+	visiting[iface.ID] = true
+	defer delete(visiting, iface.ID)
+
+	attrs := []Attribute{}
+	for _, raw := range iface.Extends {
+		base, ok := dc.resolve(raw)
+		if !ok {
+			dc.issue(iface.ID, "", fmt.Sprintf("extended interface %s does not exist", string(raw)))
+			continue
+		}
+		attrs = dc.merge(iface.ID, attrs, dc.attributes(base, prefix, meta, rels, visiting))
+	}
+
+	for _, c := range iface.Contents {
+		name := prefix + c.Name
+		switch {
+		case c.Type.has(dtdlTelemetry), c.Type.has(dtdlProperty):
+			attr, ok := dc.attribute(iface.ID, name, c)
+			if !ok {
+				continue
+			}
+			attrs = dc.merge(iface.ID, attrs, []Attribute{attr})
+			meta[name] = contentMetadata(c)
+		case c.Type.has(dtdlComponent):
+			comp, ok := dc.resolve(c.Schema)
+			if !ok {
+				dc.issue(iface.ID, name, fmt.Sprintf("component interface %s does not exist", string(c.Schema)))
+				continue
+			}
+			attrs = dc.merge(iface.ID, attrs, dc.attributes(comp, name+SubtopicSeparator, meta, rels, visiting))
+		case c.Type.has(dtdlRelationship):
+			rel := map[string]interface{}{"name": name}
+			if c.Target != "" {
+				rel["target"] = c.Target
+			}
+			if c.MaxMultiplicity != nil {
+				rel["max_multiplicity"] = *c.MaxMultiplicity
+			}
+			if c.DisplayName != "" {
+				rel["display_name"] = string(c.DisplayName)
+			}
+			*rels = append(*rels, rel)
+			if len(c.Properties) > 0 {
+				dc.issue(iface.ID, name, "relationship properties are not supported")
+			}
+		case c.Type.has(dtdlCommand):
+			dc.issue(iface.ID, name, "commands are not supported")
+		default:
+			dc.issue(iface.ID, name, fmt.Sprintf("content type %s is not supported", strings.Join(c.Type, ", ")))
+		}
+	}
+
+	return attrs
+}
+
+// attribute converts the telemetry or the property into the attribute
+// mirroring the values received on the subtopic of the same name.
+func (dc *dtdlConverter) attribute(ifaceID, name string, c dtdlContent) (Attribute, bool) {
+	attr := Attribute{
+		Name:         name,
+		Channel:      dc.channel,
+		Subtopic:     name,
+		PersistState: true,
+	}
+
+	typ, enum, reason := dc.schema(c.Schema, map[string]bool{})
+	if reason != "" {
+		dc.issue(ifaceID, name, reason)
+		return Attribute{}, false
+	}
+	attr.Type = typ
+	attr.Enum = enum
+
+	if c.Unit != "" {
+		unit, ok := dtdlUnits[c.Unit]
+		switch {
+		case !ok:
+			dc.issue(ifaceID, name, fmt.Sprintf("unit %s has no SenML counterpart", c.Unit))
+		case typ != NumberType:
+			dc.issue(ifaceID, name, fmt.Sprintf("unit %s of non-numeric schema", c.Unit))
+		default:
+			attr.Unit = unit
+		}
+	}
+
+	return attr, true
+}
+
+// schema returns the attribute type and the enum values of the schema, or
+// the reason the schema cannot be converted.
+func (dc *dtdlConverter) schema(raw json.RawMessage, visiting map[string]bool) (string, []interface{}, string) {
+	var ref string
+	if err := json.Unmarshal(raw, &ref); err == nil {
+		if typ, ok := dtdlPrimitives[ref]; ok {
+			return typ, nil, ""
+		}
+		def, ok := dc.schemas[ref]
+		if !ok || visiting[ref] {
+			return "", nil, fmt.Sprintf("schema %s is not supported", ref)
+		}
+		visiting[ref] = true
+		return dc.schema(def, visiting)
+	}
+
+	var schema dtdlSchema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return "", nil, "schema is invalid"
+	}
+	if !schema.Type.has(dtdlEnum) {
+		return "", nil, fmt.Sprintf("%s schemas are not supported", strings.Join(schema.Type, ", "))
+	}
+
+	typ := dtdlPrimitives[schema.ValueSchema]
+	if typ != NumberType && typ != StringType {
+		return "", nil, fmt.Sprintf("enum value schema %s is not supported", schema.ValueSchema)
+	}
+	enum := []interface{}{}
+	for _, v := range schema.EnumValues {
+		if valueType(v.EnumValue) != typ {
+			return "", nil, fmt.Sprintf("enum value %s is not a %s", v.Name, typ)
+		}
+		enum = append(enum, v.EnumValue)
+	}
+
+	return typ, enum, ""
+}
+
+// resolve returns the interface which is either referenced by its
+// identifier or defined inline.
+func (dc *dtdlConverter) resolve(raw json.RawMessage) (dtdlInterfaceDoc, bool) {
+	var ref string
+	if err := json.Unmarshal(raw, &ref); err != nil {
+		var inline dtdlInterfaceDoc
+		if err := json.Unmarshal(raw, &inline); err != nil {
+			return dtdlInterfaceDoc{}, false
+		}
+		ref = inline.ID
+	}
+	iface, ok := dc.interfaces[ref]
+
+	return iface, ok
+}
+
+// merge appends the attributes, skipping the ones whose names are taken.
+func (dc *dtdlConverter) merge(ifaceID string, attrs, more []Attribute) []Attribute {
+	for _, attr := range more {
+		if findAttribute(attr.Name, attrs) >= 0 {
+			dc.issue(ifaceID, attr.Name, "duplicate attribute name")
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+
+	return attrs
+}
+
+func (dc *dtdlConverter) issue(ifaceID, element, reason string) {
+	dc.issues = append(dc.issues, DTDLIssue{Interface: ifaceID, Element: element, Reason: reason})
+}
+
+// contentMetadata returns the details of the telemetry or the property which
+// are not kept in the attribute.
+func contentMetadata(c dtdlContent) map[string]interface{} {
+	kind := strings.ToLower(dtdlTelemetry)
+	if c.Type.has(dtdlProperty) {
+		kind = strings.ToLower(dtdlProperty)
+	}
+	meta := map[string]interface{}{"kind": kind}
+	if c.Writable {
+		meta["writable"] = true
+	}
+	if c.DisplayName != "" {
+		meta["display_name"] = string(c.DisplayName)
+	}
+	if c.Description != "" {
+		meta["description"] = string(c.Description)
+	}
+	for _, t := range c.Type {
+		if t != dtdlTelemetry && t != dtdlProperty {
+			meta["semantic_type"] = t
+		}
+	}
+	if c.Unit != "" {
+		meta["unit"] = c.Unit
+	}
+
+	return meta
+}
+
+// dtmiName returns the name segment of the digital twin model identifier,
+// e.g. Pump for dtmi:com:example:Pump;1.
+func dtmiName(id string) string {
+	id, _, _ = strings.Cut(id, ";")
+	if i := strings.LastIndex(id, ":"); i >= 0 {
+		return id[i+1:]
+	}
+
+	return id
+}
@@ -5,6 +5,7 @@ package events
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/absmach/supermq-contrib/twins"
@@ -13,14 +14,48 @@ import (
 )
 
 const (
-	twinPrefix     = "twins."
-	twinAdd        = twinPrefix + "add"
-	twinUpdate     = twinPrefix + "update"
-	twinRemove     = twinPrefix + "remove"
-	twinView       = twinPrefix + "view"
-	twinList       = twinPrefix + "list"
-	twinListStates = twinPrefix + "list_states"
-	twinSaveStates = twinPrefix + "save_states"
+	twinPrefix             = "twins."
+	twinAdd                = twinPrefix + "add"
+	twinUpdate             = twinPrefix + "update"
+	twinRemove             = twinPrefix + "remove"
+	twinView               = twinPrefix + "view"
+	twinList               = twinPrefix + "list"
+	twinExport             = twinPrefix + "export"
+	twinImport             = twinPrefix + "import"
+	twinImportDTDL         = twinPrefix + "import_dtdl"
+	twinListDefinitions    = twinPrefix + "list_definitions"
+	twinDiffDefinitions    = twinPrefix + "diff_definitions"
+	twinRollbackDefinition = twinPrefix + "rollback_definition"
+	twinShare              = twinPrefix + "share"
+	twinUnshare            = twinPrefix + "unshare"
+	twinAttachChild        = twinPrefix + "attach_child"
+	twinDetachChild        = twinPrefix + "detach_child"
+	twinViewSubtree        = twinPrefix + "view_subtree"
+	twinViewComposite      = twinPrefix + "view_composite_state"
+	twinAddRelation        = twinPrefix + "add_relation"
+	twinRemoveRelation     = twinPrefix + "remove_relation"
+	twinListRelations      = twinPrefix + "list_relations"
+	twinTraverseRelations  = twinPrefix + "traverse_relations"
+	twinListStates         = twinPrefix + "list_states"
+	twinListAlarms         = twinPrefix + "list_alarms"
+	twinSaveStates         = twinPrefix + "save_states"
+	twinReplayStates       = twinPrefix + "replay_states"
+	twinStateAt            = twinPrefix + "state_at"
+	twinDiffStates         = twinPrefix + "diff_states"
+	twinUpdateDesiredState = twinPrefix + "update_desired_state"
+	twinViewDesiredState   = twinPrefix + "view_desired_state"
+	twinViewDelta          = twinPrefix + "view_delta"
+	twinStreamStates       = twinPrefix + "stream_states"
+	twinApplyRetention     = twinPrefix + "apply_retention"
+	twinCheckLiveness      = twinPrefix + "check_liveness"
+
+	templatePrefix  = "twins.template."
+	templateAdd     = templatePrefix + "add"
+	templateUpdate  = templatePrefix + "update"
+	templateView    = templatePrefix + "view"
+	templateList    = templatePrefix + "list"
+	templateRemove  = templatePrefix + "remove"
+	templateRollout = templatePrefix + "rollout"
 )
 
 var (
@@ -29,8 +64,40 @@ var (
 	_ events.Event = (*removeTwinEvent)(nil)
 	_ events.Event = (*viewTwinEvent)(nil)
 	_ events.Event = (*listTwinsEvent)(nil)
+	_ events.Event = (*exportTwinsEvent)(nil)
+	_ events.Event = (*importTwinsEvent)(nil)
+	_ events.Event = (*importDTDLEvent)(nil)
+	_ events.Event = (*listDefinitionsEvent)(nil)
+	_ events.Event = (*diffDefinitionsEvent)(nil)
+	_ events.Event = (*rollbackDefinitionEvent)(nil)
+	_ events.Event = (*shareTwinEvent)(nil)
+	_ events.Event = (*unshareTwinEvent)(nil)
+	_ events.Event = (*attachChildEvent)(nil)
+	_ events.Event = (*detachChildEvent)(nil)
+	_ events.Event = (*viewSubtreeEvent)(nil)
+	_ events.Event = (*viewCompositeStateEvent)(nil)
+	_ events.Event = (*addRelationEvent)(nil)
+	_ events.Event = (*removeRelationEvent)(nil)
+	_ events.Event = (*listRelationsEvent)(nil)
+	_ events.Event = (*traverseRelationsEvent)(nil)
 	_ events.Event = (*listStatesEvent)(nil)
+	_ events.Event = (*listAlarmsEvent)(nil)
 	_ events.Event = (*saveStatesEvent)(nil)
+	_ events.Event = (*replayStatesEvent)(nil)
+	_ events.Event = (*stateAtEvent)(nil)
+	_ events.Event = (*diffStatesEvent)(nil)
+	_ events.Event = (*updateDesiredStateEvent)(nil)
+	_ events.Event = (*viewDesiredStateEvent)(nil)
+	_ events.Event = (*viewDeltaEvent)(nil)
+	_ events.Event = (*streamStatesEvent)(nil)
+	_ events.Event = (*addTemplateEvent)(nil)
+	_ events.Event = (*updateTemplateEvent)(nil)
+	_ events.Event = (*viewTemplateEvent)(nil)
+	_ events.Event = (*listTemplatesEvent)(nil)
+	_ events.Event = (*removeTemplateEvent)(nil)
+	_ events.Event = (*rolloutTemplateEvent)(nil)
+	_ events.Event = (*applyRetentionEvent)(nil)
+	_ events.Event = (*checkLivenessEvent)(nil)
 )
 
 type addTwinEvent struct {
@@ -48,6 +115,9 @@ func (ate addTwinEvent) Encode() (map[string]interface{}, error) {
 	if ate.Twin.Owner != "" {
 		val["owner"] = ate.Twin.Owner
 	}
+	if ate.Twin.Domain != "" {
+		val["domain"] = ate.Twin.Domain
+	}
 	if ate.Twin.Name != "" {
 		val["name"] = ate.Twin.Name
 	}
@@ -163,20 +233,278 @@ func (vte viewTwinEvent) Encode() (map[string]interface{}, error) {
 }
 
 type removeTwinEvent struct {
-	id string
+	id      string
+	cascade bool
 }
 
 func (rte removeTwinEvent) Encode() (map[string]interface{}, error) {
 	return map[string]interface{}{
 		"operation": twinRemove,
 		"id":        rte.id,
+		"cascade":   rte.cascade,
+	}, nil
+}
+
+type exportTwinsEvent struct {
+	ownerID string
+	twins   []twins.Twin
+}
+
+func (ete exportTwinsEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": twinExport,
+		"owner_id":  ete.ownerID,
+		"twins":     len(ete.twins),
+	}, nil
+}
+
+type importTwinsEvent struct {
+	imp twins.Import
+}
+
+func (ite importTwinsEvent) Encode() (map[string]interface{}, error) {
+	ids, err := json.Marshal(ite.imp.IDs)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+
+	return map[string]interface{}{
+		"operation": twinImport,
+		"dry_run":   ite.imp.DryRun,
+		"ids":       ids,
+	}, nil
+}
+
+type importDTDLEvent struct {
+	imp twins.DTDLImport
+}
+
+func (ide importDTDLEvent) Encode() (map[string]interface{}, error) {
+	ids := make([]string, 0, len(ide.imp.Twins))
+	for _, tw := range ide.imp.Twins {
+		ids = append(ids, tw.ID)
+	}
+
+	return map[string]interface{}{
+		"operation": twinImportDTDL,
+		"dry_run":   ide.imp.DryRun,
+		"ids":       strings.Join(ids, ","),
+		"issues":    len(ide.imp.Issues),
 	}, nil
 }
 
+type listDefinitionsEvent struct {
+	id          string
+	definitions int
+}
+
+func (lde listDefinitionsEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation":   twinListDefinitions,
+		"id":          lde.id,
+		"definitions": lde.definitions,
+	}, nil
+}
+
+type diffDefinitionsEvent struct {
+	id   string
+	from int
+	to   int
+}
+
+func (dde diffDefinitionsEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": twinDiffDefinitions,
+		"id":        dde.id,
+		"from":      dde.from,
+		"to":        dde.to,
+	}, nil
+}
+
+type rollbackDefinitionEvent struct {
+	id           string
+	definitionID int
+	definition   twins.Definition
+}
+
+func (rde rollbackDefinitionEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation":     twinRollbackDefinition,
+		"id":            rde.id,
+		"definition_id": rde.definitionID,
+		"definition":    rde.definition.ID,
+	}, nil
+}
+
+type shareTwinEvent struct {
+	id      string
+	domain  string
+	role    string
+	userIDs []string
+}
+
+func (ste shareTwinEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": twinShare,
+		"id":        ste.id,
+		"domain":    ste.domain,
+		"role":      ste.role,
+		"user_ids":  ste.userIDs,
+	}, nil
+}
+
+type unshareTwinEvent struct {
+	id      string
+	domain  string
+	userIDs []string
+}
+
+func (ute unshareTwinEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": twinUnshare,
+		"id":        ute.id,
+		"domain":    ute.domain,
+		"user_ids":  ute.userIDs,
+	}, nil
+}
+
+type attachChildEvent struct {
+	parentID string
+	childID  string
+}
+
+func (ace attachChildEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": twinAttachChild,
+		"parent_id": ace.parentID,
+		"child_id":  ace.childID,
+	}, nil
+}
+
+type detachChildEvent struct {
+	parentID string
+	childID  string
+}
+
+func (dce detachChildEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": twinDetachChild,
+		"parent_id": dce.parentID,
+		"child_id":  dce.childID,
+	}, nil
+}
+
+type viewSubtreeEvent struct {
+	id string
+}
+
+func (vse viewSubtreeEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": twinViewSubtree,
+		"id":        vse.id,
+	}, nil
+}
+
+type viewCompositeStateEvent struct {
+	id string
+}
+
+func (vcse viewCompositeStateEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": twinViewComposite,
+		"id":        vcse.id,
+	}, nil
+}
+
+type addRelationEvent struct {
+	twins.Relation
+}
+
+func (are addRelationEvent) Encode() (map[string]interface{}, error) {
+	val := map[string]interface{}{
+		"operation": twinAddRelation,
+		"source_id": are.Source,
+		"type":      are.Type,
+		"target_id": are.Target,
+		"created":   are.Created,
+	}
+
+	if len(are.Metadata) > 0 {
+		metadata, err := json.Marshal(are.Metadata)
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
+		val["metadata"] = string(metadata)
+	}
+
+	return val, nil
+}
+
+type removeRelationEvent struct {
+	twins.Relation
+}
+
+func (rre removeRelationEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": twinRemoveRelation,
+		"source_id": rre.Source,
+		"type":      rre.Type,
+		"target_id": rre.Target,
+	}, nil
+}
+
+type listRelationsEvent struct {
+	offset uint64
+	limit  uint64
+	id     string
+	filter twins.RelationFilter
+}
+
+func (lre listRelationsEvent) Encode() (map[string]interface{}, error) {
+	val := map[string]interface{}{
+		"operation": twinListRelations,
+		"id":        lre.id,
+		"offset":    lre.offset,
+		"limit":     lre.limit,
+	}
+
+	if len(lre.filter.Types) > 0 {
+		val["types"] = strings.Join(lre.filter.Types, ",")
+	}
+	if lre.filter.Direction != "" {
+		val["direction"] = lre.filter.Direction
+	}
+
+	return val, nil
+}
+
+type traverseRelationsEvent struct {
+	id    string
+	query twins.TraversalQuery
+	hops  int
+}
+
+func (tre traverseRelationsEvent) Encode() (map[string]interface{}, error) {
+	val := map[string]interface{}{
+		"operation": twinTraverseRelations,
+		"id":        tre.id,
+		"direction": tre.query.Direction,
+		"depth":     tre.query.Depth,
+		"hops":      tre.hops,
+	}
+
+	if len(tre.query.Types) > 0 {
+		val["types"] = strings.Join(tre.query.Types, ",")
+	}
+
+	return val, nil
+}
+
 type listTwinsEvent struct {
 	offset   uint64
 	limit    uint64
 	name     string
+	parentID string
 	metadata twins.Metadata
 }
 
@@ -188,6 +516,9 @@ func (lte listTwinsEvent) Encode() (map[string]interface{}, error) {
 	if lte.name != "" {
 		val["name"] = lte.name
 	}
+	if lte.parentID != "" {
+		val["parent_id"] = lte.parentID
+	}
 	if lte.metadata != nil {
 		metadata, err := json.Marshal(lte.metadata)
 		if err != nil {
@@ -210,6 +541,7 @@ type listStatesEvent struct {
 	offset uint64
 	limit  uint64
 	id     string
+	filter twins.StateFilter
 }
 
 func (lsge listStatesEvent) Encode() (map[string]interface{}, error) {
@@ -226,6 +558,55 @@ func (lsge listStatesEvent) Encode() (map[string]interface{}, error) {
 	if lsge.id != "" {
 		val["id"] = lsge.id
 	}
+	if !lsge.filter.From.IsZero() {
+		val["from"] = lsge.filter.From
+	}
+	if !lsge.filter.To.IsZero() {
+		val["to"] = lsge.filter.To
+	}
+	if lsge.filter.Definition != nil {
+		val["definition"] = *lsge.filter.Definition
+	}
+	if lsge.filter.Attribute != "" {
+		val["attribute"] = lsge.filter.Attribute
+	}
+	if lsge.filter.Dir != "" {
+		val["dir"] = lsge.filter.Dir
+	}
+
+	return val, nil
+}
+
+type listAlarmsEvent struct {
+	offset uint64
+	limit  uint64
+	id     string
+	filter twins.AlarmFilter
+}
+
+func (lae listAlarmsEvent) Encode() (map[string]interface{}, error) {
+	val := map[string]interface{}{
+		"operation": twinListAlarms,
+	}
+
+	if lae.offset != 0 {
+		val["offset"] = lae.offset
+	}
+	if lae.limit != 0 {
+		val["limit"] = lae.limit
+	}
+	if lae.id != "" {
+		val["id"] = lae.id
+	}
+	if lae.filter.Attribute != "" {
+		val["attribute"] = lae.filter.Attribute
+	}
+	if lae.filter.Severity != "" {
+		val["severity"] = lae.filter.Severity
+	}
+	if lae.filter.Status != "" {
+		val["status"] = lae.filter.Status
+	}
 
 	return val, nil
 }
@@ -250,3 +631,271 @@ func (ice saveStatesEvent) Encode() (map[string]interface{}, error) {
 
 	return val, nil
 }
+
+type stateAtEvent struct {
+	id string
+	at time.Time
+}
+
+func (sae stateAtEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": twinStateAt,
+		"id":        sae.id,
+		"at":        sae.at,
+	}, nil
+}
+
+type replayStatesEvent struct {
+	id string
+	rp twins.Replay
+}
+
+func (rse replayStatesEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": twinReplayStates,
+		"id":        rse.id,
+		"simulate":  rse.rp.Simulate,
+		"messages":  rse.rp.Messages,
+		"skipped":   rse.rp.Skipped,
+		"removed":   rse.rp.Removed,
+		"created":   rse.rp.Created,
+	}, nil
+}
+
+type diffStatesEvent struct {
+	id   string
+	from int64
+	to   int64
+}
+
+func (dse diffStatesEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": twinDiffStates,
+		"id":        dse.id,
+		"from":      dse.from,
+		"to":        dse.to,
+	}, nil
+}
+
+type updateDesiredStateEvent struct {
+	id      string
+	payload map[string]interface{}
+}
+
+func (udse updateDesiredStateEvent) Encode() (map[string]interface{}, error) {
+	val := map[string]interface{}{
+		"operation": twinUpdateDesiredState,
+		"id":        udse.id,
+	}
+
+	if udse.payload != nil {
+		payload, err := json.Marshal(udse.payload)
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
+
+		val["payload"] = payload
+	}
+
+	return val, nil
+}
+
+type viewDesiredStateEvent struct {
+	id string
+}
+
+func (vdse viewDesiredStateEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": twinViewDesiredState,
+		"id":        vdse.id,
+	}, nil
+}
+
+type viewDeltaEvent struct {
+	id string
+}
+
+func (vde viewDeltaEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": twinViewDelta,
+		"id":        vde.id,
+	}, nil
+}
+
+type streamStatesEvent struct {
+	twinIDs    []string
+	attributes []string
+}
+
+func (sse streamStatesEvent) Encode() (map[string]interface{}, error) {
+	val := map[string]interface{}{
+		"operation": twinStreamStates,
+		"twin_ids":  sse.twinIDs,
+	}
+
+	if len(sse.attributes) > 0 {
+		val["attributes"] = sse.attributes
+	}
+
+	return val, nil
+}
+
+type addTemplateEvent struct {
+	tmpl twins.Template
+}
+
+func (ate addTemplateEvent) Encode() (map[string]interface{}, error) {
+	return encodeTemplate(templateAdd, ate.tmpl)
+}
+
+type updateTemplateEvent struct {
+	tmpl twins.Template
+}
+
+func (ute updateTemplateEvent) Encode() (map[string]interface{}, error) {
+	return encodeTemplate(templateUpdate, ute.tmpl)
+}
+
+func encodeTemplate(operation string, tmpl twins.Template) (map[string]interface{}, error) {
+	val := map[string]interface{}{
+		"operation": operation,
+		"id":        tmpl.ID,
+	}
+
+	if tmpl.Owner != "" {
+		val["owner"] = tmpl.Owner
+	}
+	if tmpl.Domain != "" {
+		val["domain"] = tmpl.Domain
+	}
+	if tmpl.Name != "" {
+		val["name"] = tmpl.Name
+	}
+	if tmpl.Revision != 0 {
+		val["revision"] = tmpl.Revision
+	}
+	if tmpl.Created != (time.Time{}) {
+		val["created"] = tmpl.Created
+	}
+	if tmpl.Metadata != nil {
+		metadata, err := json.Marshal(tmpl.Metadata)
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
+
+		val["metadata"] = metadata
+	}
+	if len(tmpl.Definition.Attributes) > 0 {
+		attributes, err := json.Marshal(tmpl.Definition.Attributes)
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
+
+		val["definition_attributes"] = attributes
+	}
+	if tmpl.Definition.Delta != 0 {
+		val["definition_delta"] = tmpl.Definition.Delta
+	}
+
+	return val, nil
+}
+
+type viewTemplateEvent struct {
+	id string
+}
+
+func (vte viewTemplateEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": templateView,
+		"id":        vte.id,
+	}, nil
+}
+
+type listTemplatesEvent struct {
+	offset uint64
+	limit  uint64
+	name   string
+}
+
+func (lte listTemplatesEvent) Encode() (map[string]interface{}, error) {
+	val := map[string]interface{}{
+		"operation": templateList,
+	}
+
+	if lte.name != "" {
+		val["name"] = lte.name
+	}
+	if lte.offset != 0 {
+		val["offset"] = lte.offset
+	}
+	if lte.limit != 0 {
+		val["limit"] = lte.limit
+	}
+
+	return val, nil
+}
+
+type removeTemplateEvent struct {
+	id string
+}
+
+func (rte removeTemplateEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": templateRemove,
+		"id":        rte.id,
+	}, nil
+}
+
+type rolloutTemplateEvent struct {
+	rollout twins.Rollout
+}
+
+func (rte rolloutTemplateEvent) Encode() (map[string]interface{}, error) {
+	return map[string]interface{}{
+		"operation": templateRollout,
+		"id":        rte.rollout.Template,
+		"revision":  rte.rollout.Revision,
+		"updated":   rte.rollout.Updated,
+		"skipped":   rte.rollout.Skipped,
+	}, nil
+}
+
+type applyRetentionEvent struct {
+	compactions []twins.Compaction
+}
+
+func (are applyRetentionEvent) Encode() (map[string]interface{}, error) {
+	var removed, compacted int64
+	for _, comp := range are.compactions {
+		removed += comp.Removed
+		compacted += comp.Compacted
+	}
+
+	return map[string]interface{}{
+		"operation": twinApplyRetention,
+		"twins":     len(are.compactions),
+		"removed":   removed,
+		"compacted": compacted,
+	}, nil
+}
+
+type checkLivenessEvent struct {
+	transitions []twins.Staleness
+}
+
+func (cle checkLivenessEvent) Encode() (map[string]interface{}, error) {
+	var stale, fresh int
+	for _, tr := range cle.transitions {
+		if tr.Stale {
+			stale++
+			continue
+		}
+		fresh++
+	}
+
+	return map[string]interface{}{
+		"operation": twinCheckLiveness,
+		"stale":     stale,
+		"fresh":     fresh,
+	}, nil
+}
@@ -5,6 +5,7 @@ package events
 
 import (
 	"context"
+	"time"
 
 	"github.com/absmach/supermq-contrib/twins"
 	"github.com/absmach/supermq/pkg/events"
@@ -35,8 +36,8 @@ func NewEventStoreMiddleware(ctx context.Context, svc twins.Service, url string)
 	}, nil
 }
 
-func (es eventStore) AddTwin(ctx context.Context, token string, twin twins.Twin, def twins.Definition) (twins.Twin, error) {
-	twin, err := es.svc.AddTwin(ctx, token, twin, def)
+func (es eventStore) AddTwin(ctx context.Context, token, domainID string, twin twins.Twin, def twins.Definition) (twins.Twin, error) {
+	twin, err := es.svc.AddTwin(ctx, token, domainID, twin, def)
 	if err != nil {
 		return twin, err
 	}
@@ -52,8 +53,8 @@ func (es eventStore) AddTwin(ctx context.Context, token string, twin twins.Twin,
 	return twin, nil
 }
 
-func (es eventStore) UpdateTwin(ctx context.Context, token string, twin twins.Twin, def twins.Definition) error {
-	if err := es.svc.UpdateTwin(ctx, token, twin, def); err != nil {
+func (es eventStore) UpdateTwin(ctx context.Context, token, domainID string, twin twins.Twin, def twins.Definition, revision int) error {
+	if err := es.svc.UpdateTwin(ctx, token, domainID, twin, def, revision); err != nil {
 		return err
 	}
 
@@ -68,8 +69,8 @@ func (es eventStore) UpdateTwin(ctx context.Context, token string, twin twins.Tw
 	return nil
 }
 
-func (es eventStore) ViewTwin(ctx context.Context, token, id string) (twins.Twin, error) {
-	twin, err := es.svc.ViewTwin(ctx, token, id)
+func (es eventStore) ViewTwin(ctx context.Context, token, domainID, id string) (twins.Twin, error) {
+	twin, err := es.svc.ViewTwin(ctx, token, domainID, id)
 	if err != nil {
 		return twin, err
 	}
@@ -85,13 +86,14 @@ func (es eventStore) ViewTwin(ctx context.Context, token, id string) (twins.Twin
 	return twin, nil
 }
 
-func (es eventStore) RemoveTwin(ctx context.Context, token, id string) error {
-	if err := es.svc.RemoveTwin(ctx, token, id); err != nil {
+func (es eventStore) RemoveTwin(ctx context.Context, token, domainID, id string, cascade bool, revision int) error {
+	if err := es.svc.RemoveTwin(ctx, token, domainID, id, cascade, revision); err != nil {
 		return err
 	}
 
 	event := removeTwinEvent{
 		id,
+		cascade,
 	}
 
 	if err := es.Publish(ctx, streamID, event); err != nil {
@@ -101,8 +103,45 @@ func (es eventStore) RemoveTwin(ctx context.Context, token, id string) error {
 	return nil
 }
 
-func (es eventStore) ListTwins(ctx context.Context, token string, offset, limit uint64, name string, metadata twins.Metadata) (twins.Page, error) {
-	tp, err := es.svc.ListTwins(ctx, token, offset, limit, name, metadata)
+func (es eventStore) ShareTwin(ctx context.Context, token, domainID, id, role string, userIDs []string) error {
+	if err := es.svc.ShareTwin(ctx, token, domainID, id, role, userIDs); err != nil {
+		return err
+	}
+
+	event := shareTwinEvent{
+		id:      id,
+		domain:  domainID,
+		role:    role,
+		userIDs: userIDs,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (es eventStore) UnshareTwin(ctx context.Context, token, domainID, id string, userIDs []string) error {
+	if err := es.svc.UnshareTwin(ctx, token, domainID, id, userIDs); err != nil {
+		return err
+	}
+
+	event := unshareTwinEvent{
+		id:      id,
+		domain:  domainID,
+		userIDs: userIDs,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (es eventStore) ListTwins(ctx context.Context, token, domainID string, offset, limit uint64, name, parentID string, metadata twins.Metadata) (twins.Page, error) {
+	tp, err := es.svc.ListTwins(ctx, token, domainID, offset, limit, name, parentID, metadata)
 	if err != nil {
 		return tp, err
 	}
@@ -110,6 +149,7 @@ func (es eventStore) ListTwins(ctx context.Context, token string, offset, limit
 		offset,
 		limit,
 		name,
+		parentID,
 		metadata,
 	}
 
@@ -120,8 +160,256 @@ func (es eventStore) ListTwins(ctx context.Context, token string, offset, limit
 	return tp, nil
 }
 
-func (es eventStore) ListStates(ctx context.Context, token string, offset, limit uint64, id string) (twins.StatesPage, error) {
-	sp, err := es.svc.ListStates(ctx, token, offset, limit, id)
+func (es eventStore) ExportTwins(ctx context.Context, token, domainID, ownerID string) ([]twins.Twin, error) {
+	tws, err := es.svc.ExportTwins(ctx, token, domainID, ownerID)
+	if err != nil {
+		return tws, err
+	}
+
+	event := exportTwinsEvent{
+		ownerID,
+		tws,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return tws, err
+	}
+
+	return tws, nil
+}
+
+func (es eventStore) ImportTwins(ctx context.Context, token, domainID string, tws []twins.Twin, opts twins.ImportOptions) (twins.Import, error) {
+	imp, err := es.svc.ImportTwins(ctx, token, domainID, tws, opts)
+	if err != nil {
+		return imp, err
+	}
+
+	event := importTwinsEvent{
+		imp,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return imp, err
+	}
+
+	return imp, nil
+}
+
+func (es eventStore) ImportDTDL(ctx context.Context, token, domainID string, model []byte, opts twins.DTDLImportOptions) (twins.DTDLImport, error) {
+	imp, err := es.svc.ImportDTDL(ctx, token, domainID, model, opts)
+	if err != nil {
+		return imp, err
+	}
+
+	event := importDTDLEvent{
+		imp,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return imp, err
+	}
+
+	return imp, nil
+}
+
+func (es eventStore) ListDefinitions(ctx context.Context, token, domainID, id string) ([]twins.DefinitionSummary, error) {
+	defs, err := es.svc.ListDefinitions(ctx, token, domainID, id)
+	if err != nil {
+		return defs, err
+	}
+
+	event := listDefinitionsEvent{
+		id,
+		len(defs),
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return defs, err
+	}
+
+	return defs, nil
+}
+
+func (es eventStore) DiffDefinitions(ctx context.Context, token, domainID, id string, from, to int) (twins.DefinitionDiff, error) {
+	diff, err := es.svc.DiffDefinitions(ctx, token, domainID, id, from, to)
+	if err != nil {
+		return diff, err
+	}
+
+	event := diffDefinitionsEvent{
+		id,
+		from,
+		to,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return diff, err
+	}
+
+	return diff, nil
+}
+
+func (es eventStore) RollbackDefinition(ctx context.Context, token, domainID, id string, definitionID, revision int) (twins.Definition, error) {
+	def, err := es.svc.RollbackDefinition(ctx, token, domainID, id, definitionID, revision)
+	if err != nil {
+		return def, err
+	}
+
+	event := rollbackDefinitionEvent{
+		id,
+		definitionID,
+		def,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return def, err
+	}
+
+	return def, nil
+}
+
+func (es eventStore) AttachChild(ctx context.Context, token, domainID, parentID, childID string) error {
+	if err := es.svc.AttachChild(ctx, token, domainID, parentID, childID); err != nil {
+		return err
+	}
+
+	event := attachChildEvent{
+		parentID: parentID,
+		childID:  childID,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (es eventStore) DetachChild(ctx context.Context, token, domainID, parentID, childID string) error {
+	if err := es.svc.DetachChild(ctx, token, domainID, parentID, childID); err != nil {
+		return err
+	}
+
+	event := detachChildEvent{
+		parentID: parentID,
+		childID:  childID,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (es eventStore) ViewSubtree(ctx context.Context, token, domainID, id string) (twins.TwinNode, error) {
+	node, err := es.svc.ViewSubtree(ctx, token, domainID, id)
+	if err != nil {
+		return node, err
+	}
+
+	event := viewSubtreeEvent{
+		id,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return node, err
+	}
+
+	return node, nil
+}
+
+func (es eventStore) ViewCompositeState(ctx context.Context, token, domainID, id string) (twins.CompositeState, error) {
+	cs, err := es.svc.ViewCompositeState(ctx, token, domainID, id)
+	if err != nil {
+		return cs, err
+	}
+
+	event := viewCompositeStateEvent{
+		id,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return cs, err
+	}
+
+	return cs, nil
+}
+
+func (es eventStore) AddRelation(ctx context.Context, token, domainID string, rel twins.Relation) (twins.Relation, error) {
+	saved, err := es.svc.AddRelation(ctx, token, domainID, rel)
+	if err != nil {
+		return saved, err
+	}
+
+	event := addRelationEvent{
+		saved,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return saved, err
+	}
+
+	return saved, nil
+}
+
+func (es eventStore) RemoveRelation(ctx context.Context, token, domainID string, rel twins.Relation) error {
+	if err := es.svc.RemoveRelation(ctx, token, domainID, rel); err != nil {
+		return err
+	}
+
+	event := removeRelationEvent{
+		rel,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (es eventStore) ListRelations(ctx context.Context, token, domainID, id string, offset, limit uint64, filter twins.RelationFilter) (twins.RelationsPage, error) {
+	page, err := es.svc.ListRelations(ctx, token, domainID, id, offset, limit, filter)
+	if err != nil {
+		return page, err
+	}
+
+	event := listRelationsEvent{
+		offset,
+		limit,
+		id,
+		filter,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return page, err
+	}
+
+	return page, nil
+}
+
+func (es eventStore) TraverseRelations(ctx context.Context, token, domainID, id string, query twins.TraversalQuery) (twins.Traversal, error) {
+	trav, err := es.svc.TraverseRelations(ctx, token, domainID, id, query)
+	if err != nil {
+		return trav, err
+	}
+
+	event := traverseRelationsEvent{
+		id,
+		query,
+		len(trav.Hops),
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return trav, err
+	}
+
+	return trav, nil
+}
+
+func (es eventStore) ListStates(ctx context.Context, token, domainID string, offset, limit uint64, id string, filter twins.StateFilter) (twins.StatesPage, error) {
+	sp, err := es.svc.ListStates(ctx, token, domainID, offset, limit, id, filter)
 	if err != nil {
 		return sp, err
 	}
@@ -130,6 +418,7 @@ func (es eventStore) ListStates(ctx context.Context, token string, offset, limit
 		offset,
 		limit,
 		id,
+		filter,
 	}
 
 	if err := es.Publish(ctx, streamID, event); err != nil {
@@ -139,6 +428,26 @@ func (es eventStore) ListStates(ctx context.Context, token string, offset, limit
 	return sp, nil
 }
 
+func (es eventStore) ListAlarms(ctx context.Context, token, domainID string, offset, limit uint64, id string, filter twins.AlarmFilter) (twins.AlarmsPage, error) {
+	ap, err := es.svc.ListAlarms(ctx, token, domainID, offset, limit, id, filter)
+	if err != nil {
+		return ap, err
+	}
+
+	event := listAlarmsEvent{
+		offset,
+		limit,
+		id,
+		filter,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return ap, err
+	}
+
+	return ap, nil
+}
+
 func (es eventStore) SaveStates(ctx context.Context, msg *messaging.Message) error {
 	if err := es.svc.SaveStates(ctx, msg); err != nil {
 		return err
@@ -153,3 +462,265 @@ func (es eventStore) SaveStates(ctx context.Context, msg *messaging.Message) err
 
 	return nil
 }
+
+func (es eventStore) DrainStates(ctx context.Context) error {
+	return es.svc.DrainStates(ctx)
+}
+
+func (es eventStore) ReplayStates(ctx context.Context, token, domainID, id string, opts twins.ReplayOptions) (twins.Replay, error) {
+	rp, err := es.svc.ReplayStates(ctx, token, domainID, id, opts)
+	if err != nil {
+		return rp, err
+	}
+
+	event := replayStatesEvent{
+		id,
+		rp,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return rp, err
+	}
+
+	return rp, nil
+}
+
+func (es eventStore) UpdateDesiredState(ctx context.Context, token, domainID, id string, payload map[string]interface{}) (twins.Delta, error) {
+	delta, err := es.svc.UpdateDesiredState(ctx, token, domainID, id, payload)
+	if err != nil {
+		return delta, err
+	}
+
+	event := updateDesiredStateEvent{
+		id,
+		payload,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return delta, err
+	}
+
+	return delta, nil
+}
+
+func (es eventStore) ViewDesiredState(ctx context.Context, token, domainID, id string) (twins.DesiredState, error) {
+	ds, err := es.svc.ViewDesiredState(ctx, token, domainID, id)
+	if err != nil {
+		return ds, err
+	}
+
+	event := viewDesiredStateEvent{
+		id,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return ds, err
+	}
+
+	return ds, nil
+}
+
+func (es eventStore) ViewDelta(ctx context.Context, token, domainID, id string) (twins.Delta, error) {
+	delta, err := es.svc.ViewDelta(ctx, token, domainID, id)
+	if err != nil {
+		return delta, err
+	}
+
+	event := viewDeltaEvent{
+		id,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return delta, err
+	}
+
+	return delta, nil
+}
+
+func (es eventStore) StateAt(ctx context.Context, token, domainID, id string, at time.Time) (twins.State, twins.Definition, error) {
+	st, def, err := es.svc.StateAt(ctx, token, domainID, id, at)
+	if err != nil {
+		return st, def, err
+	}
+
+	event := stateAtEvent{
+		id,
+		at,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return st, def, err
+	}
+
+	return st, def, nil
+}
+
+func (es eventStore) DiffStates(ctx context.Context, token, domainID, id string, from, to twins.StateSelector) (twins.StateDiff, error) {
+	diff, err := es.svc.DiffStates(ctx, token, domainID, id, from, to)
+	if err != nil {
+		return diff, err
+	}
+
+	event := diffStatesEvent{
+		id,
+		diff.From.ID,
+		diff.To.ID,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return diff, err
+	}
+
+	return diff, nil
+}
+
+func (es eventStore) StreamStates(ctx context.Context, token, domainID string, twinIDs, attributes []string) (<-chan twins.StreamEvent, error) {
+	events, err := es.svc.StreamStates(ctx, token, domainID, twinIDs, attributes)
+	if err != nil {
+		return events, err
+	}
+
+	event := streamStatesEvent{
+		twinIDs, attributes,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return events, err
+	}
+
+	return events, nil
+}
+
+func (es eventStore) AddTemplate(ctx context.Context, token, domainID string, tmpl twins.Template) (twins.Template, error) {
+	tmpl, err := es.svc.AddTemplate(ctx, token, domainID, tmpl)
+	if err != nil {
+		return tmpl, err
+	}
+
+	event := addTemplateEvent{
+		tmpl,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return tmpl, err
+	}
+
+	return tmpl, nil
+}
+
+func (es eventStore) UpdateTemplate(ctx context.Context, token, domainID string, tmpl twins.Template) error {
+	if err := es.svc.UpdateTemplate(ctx, token, domainID, tmpl); err != nil {
+		return err
+	}
+
+	event := updateTemplateEvent{
+		tmpl,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (es eventStore) ViewTemplate(ctx context.Context, token, domainID, id string) (twins.Template, error) {
+	tmpl, err := es.svc.ViewTemplate(ctx, token, domainID, id)
+	if err != nil {
+		return tmpl, err
+	}
+
+	event := viewTemplateEvent{
+		id,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return tmpl, err
+	}
+
+	return tmpl, nil
+}
+
+func (es eventStore) ListTemplates(ctx context.Context, token, domainID string, offset, limit uint64, name string) (twins.TemplatesPage, error) {
+	page, err := es.svc.ListTemplates(ctx, token, domainID, offset, limit, name)
+	if err != nil {
+		return page, err
+	}
+
+	event := listTemplatesEvent{
+		offset, limit, name,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return page, err
+	}
+
+	return page, nil
+}
+
+func (es eventStore) RemoveTemplate(ctx context.Context, token, domainID, id string) error {
+	if err := es.svc.RemoveTemplate(ctx, token, domainID, id); err != nil {
+		return err
+	}
+
+	event := removeTemplateEvent{
+		id,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (es eventStore) RolloutTemplate(ctx context.Context, token, domainID, id string, bindings map[string]string) (twins.Rollout, error) {
+	ro, err := es.svc.RolloutTemplate(ctx, token, domainID, id, bindings)
+	if err != nil {
+		return ro, err
+	}
+
+	event := rolloutTemplateEvent{
+		ro,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return ro, err
+	}
+
+	return ro, nil
+}
+
+func (es eventStore) ApplyRetention(ctx context.Context) ([]twins.Compaction, error) {
+	comps, err := es.svc.ApplyRetention(ctx)
+	if err != nil {
+		return comps, err
+	}
+
+	event := applyRetentionEvent{
+		comps,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return comps, err
+	}
+
+	return comps, nil
+}
+
+func (es eventStore) CheckLiveness(ctx context.Context) ([]twins.Staleness, error) {
+	trs, err := es.svc.CheckLiveness(ctx)
+	if err != nil {
+		return trs, err
+	}
+
+	event := checkLivenessEvent{
+		trs,
+	}
+
+	if err := es.Publish(ctx, streamID, event); err != nil {
+		return trs, err
+	}
+
+	return trs, nil
+}
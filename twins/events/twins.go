@@ -69,16 +69,61 @@ func (tc *twinCache) SaveIDs(ctx context.Context, channel, subtopic string, ids
 	return nil
 }
 
+// IDs returns the IDs of the twins bound to the channel and the subtopic,
+// either exactly or by the subtopic pattern matching it. Patterns of the
+// channel are kept in a set, so the concrete subtopic is resolved without
+// enumerating the possible patterns.
 func (tc *twinCache) IDs(ctx context.Context, channel, subtopic string) ([]string, error) {
-	ids, err := tc.client.SMembers(ctx, attrKey(channel, subtopic)).Result()
-	if err != nil {
+	pipe := tc.client.Pipeline()
+	idsCmd := pipe.SMembers(ctx, attrKey(channel, subtopic))
+	wildcardCmd := pipe.SMembers(ctx, attrKey(channel, twins.SubtopicWildcard))
+	patternsCmd := pipe.SMembers(ctx, patternsKey(channel))
+	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, errors.Wrap(errRedisTwinIDs, err)
 	}
-	idsWildcard, err := tc.client.SMembers(ctx, attrKey(channel, twins.SubtopicWildcard)).Result()
-	if err != nil {
+	ids := append(idsCmd.Val(), wildcardCmd.Val()...)
+	patterns := patternsCmd.Val()
+
+	var matched []string
+	for _, pattern := range patterns {
+		if pattern != subtopic && twins.MatchSubtopic(pattern, subtopic) {
+			matched = append(matched, pattern)
+		}
+	}
+	if len(matched) == 0 {
+		return ids, nil
+	}
+
+	pipe = tc.client.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, len(matched))
+	for i, pattern := range matched {
+		cmds[i] = pipe.SMembers(ctx, attrKey(channel, pattern))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, errors.Wrap(errRedisTwinIDs, err)
 	}
-	ids = append(ids, idsWildcard...)
+
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	for i, cmd := range cmds {
+		patternIDs := cmd.Val()
+		if len(patternIDs) == 0 {
+			// Patterns of the removed twins are dropped lazily.
+			if err := tc.client.SRem(ctx, patternsKey(channel), matched[i]).Err(); err != nil {
+				return nil, errors.Wrap(errRedisTwinIDs, err)
+			}
+			continue
+		}
+		for _, id := range patternIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
 	return ids, nil
 }
 
@@ -98,6 +143,11 @@ func (tc *twinCache) save(ctx context.Context, twin twins.Twin) error {
 		if err := tc.client.SAdd(ctx, twinKey(twin.ID), attrKey(attr.Channel, attr.Subtopic)).Err(); err != nil {
 			return errors.Wrap(errRedisTwinSave, err)
 		}
+		if attr.Subtopic != twins.SubtopicWildcard && twins.IsSubtopicPattern(attr.Subtopic) {
+			if err := tc.client.SAdd(ctx, patternsKey(attr.Channel), attr.Subtopic).Err(); err != nil {
+				return errors.Wrap(errRedisTwinSave, err)
+			}
+		}
 	}
 	return nil
 }
@@ -126,3 +176,7 @@ func twinKey(twinID string) string {
 func attrKey(channel, subtopic string) string {
 	return fmt.Sprintf("%s:%s-%s", prefix, channel, subtopic)
 }
+
+func patternsKey(channel string) string {
+	return fmt.Sprintf("%s:patterns:%s", prefix, channel)
+}
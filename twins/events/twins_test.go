@@ -242,6 +242,58 @@ func TestTwinIDs(t *testing.T) {
 	}
 }
 
+func TestTwinIDsSubtopicPattern(t *testing.T) {
+	redisClient.FlushAll(context.Background())
+	twinCache := events.NewTwinCache(redisClient)
+	ctx := context.Background()
+
+	exact := mocks.CreateTwin(channels[0:1], []string{"sensors.room_1.temp"})
+	singleLevel := mocks.CreateTwin(channels[0:1], []string{"sensors.*.temp"})
+	plus := mocks.CreateTwin(channels[0:1], []string{"sensors.+.+"})
+	multiLevel := mocks.CreateTwin(channels[0:1], []string{"sensors.>"})
+	otherChannel := mocks.CreateTwin(channels[1:2], []string{"sensors.>"})
+	removed := mocks.CreateTwin(channels[0:1], []string{"sensors.#"})
+	for _, tw := range []twins.Twin{exact, singleLevel, plus, multiLevel, otherChannel, removed} {
+		err := twinCache.Save(ctx, tw)
+		assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	}
+	err := twinCache.Remove(ctx, removed.ID)
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc     string
+		subtopic string
+		ids      []string
+	}{
+		{
+			desc:     "Get twin IDs for subtopic matching all the patterns",
+			subtopic: "sensors.room_1.temp",
+			ids:      []string{exact.ID, singleLevel.ID, plus.ID, multiLevel.ID},
+		},
+		{
+			desc:     "Get twin IDs for subtopic matching single-level and multi-level wildcards",
+			subtopic: "sensors.room_2.temp",
+			ids:      []string{singleLevel.ID, plus.ID, multiLevel.ID},
+		},
+		{
+			desc:     "Get twin IDs for subtopic matching multi-level wildcard",
+			subtopic: "sensors.room_1.temp.max",
+			ids:      []string{multiLevel.ID},
+		},
+		{
+			desc:     "Get twin IDs for subtopic matching no pattern",
+			subtopic: "sensors",
+			ids:      []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		ids, err := twinCache.IDs(ctx, channels[0], tc.subtopic)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.ElementsMatch(t, ids, tc.ids, fmt.Sprintf("%s: got unexpected list of IDs", tc.desc))
+	}
+}
+
 func TestTwinRemove(t *testing.T) {
 	redisClient.FlushAll(context.Background())
 	twinCache := events.NewTwinCache(redisClient)
@@ -0,0 +1,331 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"strconv"
+
+	"github.com/absmach/supermq/pkg/errors"
+)
+
+var (
+	errInvalidExpression = errors.New("invalid computed attribute expression")
+	errUnknownInput      = errors.New("computed attribute refers to an unknown or non-persisted attribute")
+	errComputedBinding   = errors.New("computed attribute cannot be bound to a channel")
+	errComputedCycle     = errors.New("computed attributes refer to each other in a cycle")
+	errMissingInput      = errors.New("computed attribute input is missing or not numeric")
+	errDivisionByZero    = errors.New("division by zero")
+)
+
+// functions lists the functions which can be used in the expressions of the
+// computed attributes, along with the number of their arguments.
+var functions = map[string]int{
+	"abs":   1,
+	"sqrt":  1,
+	"round": 1,
+	"floor": 1,
+	"ceil":  1,
+	"min":   2,
+	"max":   2,
+	"pow":   2,
+	"ema":   2,
+}
+
+// expression is a parsed expression of a computed attribute. Expressions use
+// Go syntax limited to numeric literals, attribute names, arithmetic
+// operators and the functions listed above. The ema(x, alpha) function
+// returns the exponential moving average of x, using the previous value of
+// the computed attribute itself.
+type expression struct {
+	root   ast.Expr
+	inputs []string
+}
+
+func parseExpression(src string) (expression, error) {
+	root, err := parser.ParseExpr(src)
+	if err != nil {
+		return expression{}, errors.Wrap(errInvalidExpression, err)
+	}
+
+	expr := expression{root: root}
+	if err := expr.validate(root, map[string]bool{}); err != nil {
+		return expression{}, err
+	}
+
+	return expr, nil
+}
+
+// validate verifies that the node uses only the supported syntax and
+// collects the names of the attributes it refers to.
+func (e *expression) validate(n ast.Expr, seen map[string]bool) error {
+	switch n := n.(type) {
+	case *ast.ParenExpr:
+		return e.validate(n.X, seen)
+	case *ast.BasicLit:
+		if n.Kind != token.INT && n.Kind != token.FLOAT {
+			return errors.Wrap(errInvalidExpression, fmt.Errorf("unsupported literal %s", n.Value))
+		}
+		return nil
+	case *ast.Ident:
+		if !seen[n.Name] {
+			seen[n.Name] = true
+			e.inputs = append(e.inputs, n.Name)
+		}
+		return nil
+	case *ast.UnaryExpr:
+		if n.Op != token.ADD && n.Op != token.SUB {
+			return errors.Wrap(errInvalidExpression, fmt.Errorf("unsupported operator %s", n.Op))
+		}
+		return e.validate(n.X, seen)
+	case *ast.BinaryExpr:
+		switch n.Op {
+		case token.ADD, token.SUB, token.MUL, token.QUO, token.REM:
+		default:
+			return errors.Wrap(errInvalidExpression, fmt.Errorf("unsupported operator %s", n.Op))
+		}
+		if err := e.validate(n.X, seen); err != nil {
+			return err
+		}
+		return e.validate(n.Y, seen)
+	case *ast.CallExpr:
+		fn, ok := n.Fun.(*ast.Ident)
+		if !ok {
+			return errors.Wrap(errInvalidExpression, errors.New("unsupported function call"))
+		}
+		argc, ok := functions[fn.Name]
+		if !ok || argc != len(n.Args) || n.Ellipsis.IsValid() {
+			return errors.Wrap(errInvalidExpression, fmt.Errorf("unsupported function %s", fn.Name))
+		}
+		for _, arg := range n.Args {
+			if err := e.validate(arg, seen); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return errors.Wrap(errInvalidExpression, errors.New("unsupported expression"))
+	}
+}
+
+// eval evaluates the expression using the provided attribute values. The
+// previous value of the computed attribute is used by the ema function.
+func (e expression) eval(values map[string]float64, prev *float64) (float64, error) {
+	val, err := evalNode(e.root, values, prev)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(val) || math.IsInf(val, 0) {
+		return 0, errors.Wrap(errInvalidExpression, errors.New("result is not a finite number"))
+	}
+
+	return val, nil
+}
+
+func evalNode(n ast.Expr, values map[string]float64, prev *float64) (float64, error) {
+	switch n := n.(type) {
+	case *ast.ParenExpr:
+		return evalNode(n.X, values, prev)
+	case *ast.BasicLit:
+		return strconv.ParseFloat(n.Value, 64)
+	case *ast.Ident:
+		val, ok := values[n.Name]
+		if !ok {
+			return 0, errors.Wrap(errMissingInput, errors.New(n.Name))
+		}
+		return val, nil
+	case *ast.UnaryExpr:
+		x, err := evalNode(n.X, values, prev)
+		if err != nil {
+			return 0, err
+		}
+		if n.Op == token.SUB {
+			return -x, nil
+		}
+		return x, nil
+	case *ast.BinaryExpr:
+		x, err := evalNode(n.X, values, prev)
+		if err != nil {
+			return 0, err
+		}
+		y, err := evalNode(n.Y, values, prev)
+		if err != nil {
+			return 0, err
+		}
+		switch n.Op {
+		case token.ADD:
+			return x + y, nil
+		case token.SUB:
+			return x - y, nil
+		case token.MUL:
+			return x * y, nil
+		case token.QUO:
+			if y == 0 {
+				return 0, errDivisionByZero
+			}
+			return x / y, nil
+		case token.REM:
+			if y == 0 {
+				return 0, errDivisionByZero
+			}
+			return math.Mod(x, y), nil
+		}
+	case *ast.CallExpr:
+		args := make([]float64, len(n.Args))
+		for i, arg := range n.Args {
+			val, err := evalNode(arg, values, prev)
+			if err != nil {
+				return 0, err
+			}
+			args[i] = val
+		}
+		return call(n.Fun.(*ast.Ident).Name, args, prev)
+	}
+
+	return 0, errInvalidExpression
+}
+
+func call(name string, args []float64, prev *float64) (float64, error) {
+	switch name {
+	case "abs":
+		return math.Abs(args[0]), nil
+	case "sqrt":
+		return math.Sqrt(args[0]), nil
+	case "round":
+		return math.Round(args[0]), nil
+	case "floor":
+		return math.Floor(args[0]), nil
+	case "ceil":
+		return math.Ceil(args[0]), nil
+	case "min":
+		return math.Min(args[0], args[1]), nil
+	case "max":
+		return math.Max(args[0], args[1]), nil
+	case "pow":
+		return math.Pow(args[0], args[1]), nil
+	case "ema":
+		x, alpha := args[0], args[1]
+		if alpha <= 0 || alpha > 1 {
+			return 0, errors.Wrap(errInvalidExpression, errors.New("ema smoothing factor must be in (0, 1]"))
+		}
+		if prev == nil {
+			return x, nil
+		}
+		return alpha*x + (1-alpha)*(*prev), nil
+	}
+
+	return 0, errInvalidExpression
+}
+
+// computedAttribute is a computed attribute with its parsed expression.
+type computedAttribute struct {
+	name string
+	expr expression
+}
+
+// computedAttributes parses the expressions of the computed attributes of
+// the definition and returns the persisted ones ordered so that every
+// attribute comes after the computed attributes it refers to.
+func computedAttributes(def Definition) ([]computedAttribute, error) {
+	persisted := map[string]bool{}
+	computed := map[string]computedAttribute{}
+	var names []string
+	for _, attr := range def.Attributes {
+		if attr.PersistState {
+			persisted[attr.Name] = true
+		}
+		if attr.Expression == "" {
+			continue
+		}
+		if attr.Channel != "" || attr.Subtopic != "" {
+			return nil, errors.Wrap(errComputedBinding, errors.New(attr.Name))
+		}
+		expr, err := parseExpression(attr.Expression)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := computed[attr.Name]; ok || attr.Name == "" {
+			return nil, errors.Wrap(errInvalidExpression, errors.New("computed attribute name must be unique and non-empty"))
+		}
+		if attr.PersistState {
+			computed[attr.Name] = computedAttribute{name: attr.Name, expr: expr}
+			names = append(names, attr.Name)
+		}
+	}
+
+	for _, name := range names {
+		for _, in := range computed[name].expr.inputs {
+			if !persisted[in] {
+				return nil, errors.Wrap(errUnknownInput, errors.New(in))
+			}
+		}
+	}
+
+	// Order the computed attributes topologically by their inputs.
+	const (
+		visiting = iota + 1
+		visited
+	)
+	marks := map[string]int{}
+	var ordered []computedAttribute
+	var visit func(name string) error
+	visit = func(name string) error {
+		ca, ok := computed[name]
+		if !ok {
+			return nil
+		}
+		switch marks[name] {
+		case visiting:
+			return errors.Wrap(errComputedCycle, errors.New(name))
+		case visited:
+			return nil
+		}
+		marks[name] = visiting
+		for _, in := range ca.expr.inputs {
+			if in == name {
+				return errors.Wrap(errComputedCycle, errors.New(name))
+			}
+			if err := visit(in); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+		ordered = append(ordered, ca)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// toFloat converts the attribute value stored in the state payload to
+// a number, if possible.
+func toFloat(val interface{}) (float64, bool) {
+	switch v := deref(val).(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,293 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/absmach/supermq/pkg/errors"
+	"github.com/absmach/supermq/pkg/messaging"
+	"github.com/go-kit/kit/metrics"
+)
+
+var errIngestDrained = errors.New("state ingestion is drained")
+
+// IngestConfig configures the processing of the messages the twin states
+// are created from.
+type IngestConfig struct {
+	// Workers is the number of goroutines processing the messages. Messages
+	// of the same twin are always processed by the same worker, in order of
+	// their reception. If zero, messages are processed synchronously.
+	Workers int
+
+	// QueueSize is the number of messages queued for each worker. Receiving
+	// of the messages blocks while the queue is full.
+	QueueSize int
+
+	// BatchSize is the maximum number of queued messages the worker
+	// processes at once. States created from the messages of the same twin
+	// are saved together.
+	BatchSize int
+
+	// ViewTTL is the time the twin and its last state are kept in memory
+	// between the messages. Views are invalidated by the twin changes made
+	// by this service instance, while TTL bounds the staleness of the views
+	// of the twins changed by other instances. If zero, the twin and its
+	// last state are retrieved for every batch.
+	ViewTTL time.Duration
+
+	// Failed counts the messages whose states are not saved. Since the
+	// queued messages are acknowledged before they are processed, such
+	// failures are otherwise only logged and published to the notification
+	// channel. Optional.
+	Failed metrics.Counter
+}
+
+// job is the message received on the channel and subtopic the twin is
+// bound to.
+type job struct {
+	ctx    context.Context
+	msg    *messaging.Message
+	twinID string
+}
+
+// ingester distributes the jobs among the workers so that the jobs of the
+// same twin are processed in order.
+type ingester struct {
+	mu        sync.RWMutex
+	drained   bool
+	queues    []chan job
+	batchSize int
+	workers   sync.WaitGroup
+}
+
+func newIngester(cfg IngestConfig, process func([]job)) *ingester {
+	batchSize := cfg.BatchSize
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	in := &ingester{
+		queues:    make([]chan job, cfg.Workers),
+		batchSize: batchSize,
+	}
+	for i := range in.queues {
+		in.queues[i] = make(chan job, cfg.QueueSize)
+		in.workers.Add(1)
+		go in.run(in.queues[i], process)
+	}
+
+	return in
+}
+
+// enqueue queues the job to the worker of the twin, blocking while the
+// worker queue is full. Jobs are rejected once the ingester is drained.
+func (in *ingester) enqueue(ctx context.Context, j job) error {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	if in.drained {
+		return errIngestDrained
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(j.twinID))
+	queue := in.queues[h.Sum32()%uint32(len(in.queues))]
+
+	select {
+	case queue <- j:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drain stops accepting the jobs and waits until the queued ones are
+// processed or the context is done.
+func (in *ingester) drain(ctx context.Context) error {
+	in.mu.Lock()
+	if !in.drained {
+		in.drained = true
+		for _, queue := range in.queues {
+			close(queue)
+		}
+	}
+	in.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		in.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (in *ingester) run(queue chan job, process func([]job)) {
+	defer in.workers.Done()
+
+	batch := make([]job, 0, in.batchSize)
+	for j := range queue {
+		batch = append(batch[:0], j)
+	drain:
+		for len(batch) < in.batchSize {
+			select {
+			case j, ok := <-queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, j)
+			default:
+				break drain
+			}
+		}
+		process(batch)
+	}
+}
+
+// twinView is the twin together with its last state, the state of its
+// alarms and its parsed computed attributes, used to create the states
+// without retrieving them for every message.
+type twinView struct {
+	twin     Twin
+	last     State
+	alarms   alarmState
+	computed []computedAttribute
+	expires  time.Time
+}
+
+// views caches the twin views. Every invalidation increments the epoch, so
+// views loaded before the invalidation are not cached.
+type views struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	epoch     uint64
+	nextSweep time.Time
+	items     map[string]twinView
+}
+
+func newViews(ttl time.Duration) *views {
+	return &views{
+		ttl:   ttl,
+		items: make(map[string]twinView),
+	}
+}
+
+// get returns the cached view of the twin and the current epoch.
+func (v *views) get(twinID string) (twinView, uint64, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	view, ok := v.items[twinID]
+	if ok && time.Now().After(view.expires) {
+		delete(v.items, twinID)
+		ok = false
+	}
+
+	return view, v.epoch, ok
+}
+
+// set caches the view unless some view was invalidated since the epoch.
+func (v *views) set(epoch uint64, view twinView) {
+	if v.ttl <= 0 {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if epoch != v.epoch {
+		return
+	}
+	now := time.Now()
+	if now.After(v.nextSweep) {
+		for id, item := range v.items {
+			if now.After(item.expires) {
+				delete(v.items, id)
+			}
+		}
+		v.nextSweep = now.Add(v.ttl)
+	}
+	view.expires = now.Add(v.ttl)
+	v.items[view.twin.ID] = view
+}
+
+func (v *views) invalidate(twinID string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.epoch++
+	delete(v.items, twinID)
+}
+
+// twinLocks serializes the changes of the state history of the same twin,
+// so that the history is not rewritten while the states are created from
+// the received messages.
+type twinLocks struct {
+	mu    sync.Mutex
+	items map[string]*twinLock
+}
+
+type twinLock struct {
+	sync.Mutex
+	refs int
+}
+
+func newTwinLocks() *twinLocks {
+	return &twinLocks{
+		items: make(map[string]*twinLock),
+	}
+}
+
+// lock locks the state history of the twin and returns the function which
+// unlocks it.
+func (tl *twinLocks) lock(twinID string) func() {
+	tl.mu.Lock()
+	l, ok := tl.items[twinID]
+	if !ok {
+		l = &twinLock{}
+		tl.items[twinID] = l
+	}
+	l.refs++
+	tl.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		tl.mu.Lock()
+		defer tl.mu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(tl.items, twinID)
+		}
+	}
+}
+
+// copyState returns the copy of the state which does not share the payload
+// with the original.
+func copyState(st State) State {
+	if st.Payload == nil {
+		return st
+	}
+	payload := make(map[string]interface{}, len(st.Payload))
+	for k, v := range st.Payload {
+		payload[k] = v
+	}
+	st.Payload = payload
+
+	if st.Updated != nil {
+		updated := make(map[string]time.Time, len(st.Updated))
+		for k, v := range st.Updated {
+			updated[k] = v
+		}
+		st.Updated = updated
+	}
+
+	return st
+}
@@ -0,0 +1,198 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/absmach/supermq/pkg/errors"
+	svcerr "github.com/absmach/supermq/pkg/errors/service"
+)
+
+const livenessPageSize = 100
+
+// Staleness is the transition of the twin attribute between stale and fresh.
+// Updated is the time the attribute value was last received, if any.
+type Staleness struct {
+	TwinID    string     `json:"twin_id"`
+	Attribute string     `json:"attribute"`
+	Stale     bool       `json:"stale"`
+	Updated   *time.Time `json:"updated,omitempty"`
+}
+
+// monitored reports whether any attribute of the latest twin definition has
+// the expected update interval.
+func monitored(tw Twin) bool {
+	if len(tw.Definitions) == 0 {
+		return false
+	}
+	for _, attr := range tw.Definitions[len(tw.Definitions)-1].Attributes {
+		if attr.UpdateInterval > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// staleAttributes returns the names of the attributes of the definition
+// which were not received within their expected update intervals. Attributes
+// which were never received are measured from the definition creation.
+func staleAttributes(def Definition, st State, now time.Time) []string {
+	var stale []string
+	for _, attr := range def.Attributes {
+		if attr.UpdateInterval <= 0 {
+			continue
+		}
+		updated, ok := st.Updated[attr.Name]
+		if !ok {
+			updated = def.Created
+		}
+		if now.Sub(updated) > time.Duration(attr.UpdateInterval) {
+			stale = append(stale, attr.Name)
+		}
+	}
+	return stale
+}
+
+// refresh records the time the value of the attribute was received and
+// removes the attribute from the stale ones. The stale attributes are
+// replaced rather than changed in place, as they may be shared with the
+// cached state.
+func refresh(st *State, attr Attribute, at time.Time) {
+	if attr.UpdateInterval <= 0 {
+		return
+	}
+	if st.Updated == nil {
+		st.Updated = make(map[string]time.Time)
+	}
+	st.Updated[attr.Name] = at
+
+	var stale []string
+	for _, name := range st.Stale {
+		if name != attr.Name {
+			stale = append(stale, name)
+		}
+	}
+	st.Stale = stale
+}
+
+// transitions returns the attributes which became stale or fresh between
+// the previous and the current state.
+func transitions(prev, cur State) []Staleness {
+	var trs []Staleness
+	for _, name := range cur.Stale {
+		if !containsName(prev.Stale, name) {
+			trs = append(trs, staleness(cur, name, true))
+		}
+	}
+	for _, name := range prev.Stale {
+		if !containsName(cur.Stale, name) {
+			trs = append(trs, staleness(cur, name, false))
+		}
+	}
+	return trs
+}
+
+func staleness(st State, attribute string, stale bool) Staleness {
+	s := Staleness{
+		TwinID:    st.TwinID,
+		Attribute: attribute,
+		Stale:     stale,
+	}
+	if updated, ok := st.Updated[attribute]; ok {
+		s.Updated = &updated
+	}
+	return s
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (ts *twinservice) CheckLiveness(ctx context.Context) ([]Staleness, error) {
+	var trs []Staleness
+	for offset := uint64(0); ; offset += livenessPageSize {
+		page, err := ts.twins.RetrieveMonitored(ctx, offset, livenessPageSize)
+		if err != nil {
+			return trs, errors.Wrap(svcerr.ErrViewEntity, err)
+		}
+		for _, tw := range page.Twins {
+			s, err := ts.checkLiveness(ctx, tw, time.Now())
+			if err != nil {
+				ts.logger.Warn(fmt.Sprintf("Failed to check liveness of twin %s: %s", tw.ID, err))
+				continue
+			}
+			trs = append(trs, s...)
+		}
+		if len(page.Twins) == 0 || offset+uint64(len(page.Twins)) >= page.Total {
+			return trs, nil
+		}
+	}
+}
+
+// checkLiveness marks the attributes of the last twin state which were not
+// received in time as stale, and publishes the transitions to the
+// notification channel. Attributes received in time are marked fresh, in
+// case their values were received while the check was in progress. For
+// twins without states, the attributes are measured from the definition
+// creation, and the stale ones are kept in the twin liveness.
+func (ts *twinservice) checkLiveness(ctx context.Context, tw Twin, now time.Time) ([]Staleness, error) {
+	// States must not be saved by the ingestion in the meantime.
+	defer ts.locks.lock(tw.ID)()
+
+	last, err := ts.states.RetrieveLast(ctx, tw.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	def := tw.Definitions[len(tw.Definitions)-1]
+	initial := last.Payload == nil
+	if initial {
+		last = State{TwinID: tw.ID}
+		if tw.Liveness != nil {
+			last.Stale = tw.Liveness.Stale
+		}
+	}
+
+	cur := last
+	cur.Stale = staleAttributes(def, last, now)
+	trs := transitions(last, cur)
+	if len(trs) == 0 {
+		return nil, nil
+	}
+
+	if initial {
+		if err := ts.twins.UpdateLiveness(ctx, tw.ID, Liveness{Stale: cur.Stale, Checked: now}); err != nil {
+			return nil, errors.Wrap(svcerr.ErrUpdateEntity, err)
+		}
+	} else if err := ts.states.UpdateStale(ctx, cur); err != nil {
+		return nil, errors.Wrap(svcerr.ErrUpdateEntity, err)
+	}
+	// The cached last state is stale as well.
+	ts.views.invalidate(tw.ID)
+	ts.publishLiveness(ctx, trs)
+
+	return trs, nil
+}
+
+// publishLiveness publishes the stale and fresh transitions to the
+// notification channel.
+func (ts *twinservice) publishLiveness(ctx context.Context, trs []Staleness) {
+	for _, s := range trs {
+		b, err := json.Marshal(s)
+		op := crudOp["freshSucc"]
+		if s.Stale {
+			op = crudOp["staleSucc"]
+		}
+		ts.publish(ctx, &s.TwinID, &err, op, crudOp["livenessFail"], &b)
+	}
+}
@@ -17,13 +17,14 @@ var (
 	id        = 0
 )
 
-// CreateMessage creates SupeMQ message using SenML record array.
-func CreateMessage(attr twins.Attribute, recs []senml.Record) (*messaging.Message, error) {
+// CreateMessage creates SupeMQ message of the domain using SenML record array.
+func CreateMessage(domainID string, attr twins.Attribute, recs []senml.Record) (*messaging.Message, error) {
 	mRecs, err := json.Marshal(recs)
 	if err != nil {
 		return nil, err
 	}
 	return &messaging.Message{
+		Domain:    domainID,
 		Channel:   attr.Channel,
 		Subtopic:  attr.Subtopic,
 		Payload:   mRecs,
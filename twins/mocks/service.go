@@ -9,6 +9,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/absmach/supermq-contrib/twins"
 	"github.com/absmach/supermq/pkg/messaging"
@@ -42,47 +43,109 @@ func (_m *Service) EXPECT() *Service_Expecter {
 	return &Service_Expecter{mock: &_m.Mock}
 }
 
-// AddTwin provides a mock function for the type Service
-func (_mock *Service) AddTwin(ctx context.Context, token string, twin twins.Twin, def twins.Definition) (twins.Twin, error) {
-	ret := _mock.Called(ctx, token, twin, def)
+// ApplyRetention provides a mock function for the type Service
+func (_mock *Service) ApplyRetention(ctx context.Context) ([]twins.Compaction, error) {
+	ret := _mock.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for AddTwin")
+		panic("no return value specified for ApplyRetention")
 	}
 
-	var r0 twins.Twin
+	var r0 []twins.Compaction
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, twins.Twin, twins.Definition) (twins.Twin, error)); ok {
-		return returnFunc(ctx, token, twin, def)
+	if returnFunc, ok := ret.Get(0).(func(context.Context) ([]twins.Compaction, error)); ok {
+		return returnFunc(ctx)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, twins.Twin, twins.Definition) twins.Twin); ok {
-		r0 = returnFunc(ctx, token, twin, def)
+	if returnFunc, ok := ret.Get(0).(func(context.Context) []twins.Compaction); ok {
+		r0 = returnFunc(ctx)
 	} else {
-		r0 = ret.Get(0).(twins.Twin)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]twins.Compaction)
+		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, twins.Twin, twins.Definition) error); ok {
-		r1 = returnFunc(ctx, token, twin, def)
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// Service_AddTwin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddTwin'
-type Service_AddTwin_Call struct {
+// Service_ApplyRetention_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ApplyRetention'
+type Service_ApplyRetention_Call struct {
 	*mock.Call
 }
 
-// AddTwin is a helper method to define mock.On call
+// ApplyRetention is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Service_Expecter) ApplyRetention(ctx interface{}) *Service_ApplyRetention_Call {
+	return &Service_ApplyRetention_Call{Call: _e.mock.On("ApplyRetention", ctx)}
+}
+
+func (_c *Service_ApplyRetention_Call) Run(run func(ctx context.Context)) *Service_ApplyRetention_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ApplyRetention_Call) Return(compactions []twins.Compaction, err error) *Service_ApplyRetention_Call {
+	_c.Call.Return(compactions, err)
+	return _c
+}
+
+func (_c *Service_ApplyRetention_Call) RunAndReturn(run func(ctx context.Context) ([]twins.Compaction, error)) *Service_ApplyRetention_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// AddRelation provides a mock function for the type Service
+func (_mock *Service) AddRelation(ctx context.Context, token string, domainID string, rel twins.Relation) (twins.Relation, error) {
+	ret := _mock.Called(ctx, token, domainID, rel)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddRelation")
+	}
+
+	var r0 twins.Relation
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, twins.Relation) (twins.Relation, error)); ok {
+		return returnFunc(ctx, token, domainID, rel)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, twins.Relation) twins.Relation); ok {
+		r0 = returnFunc(ctx, token, domainID, rel)
+	} else {
+		r0 = ret.Get(0).(twins.Relation)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, twins.Relation) error); ok {
+		r1 = returnFunc(ctx, token, domainID, rel)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_AddRelation_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddRelation'
+type Service_AddRelation_Call struct {
+	*mock.Call
+}
+
+// AddRelation is a helper method to define mock.On call
 //   - ctx context.Context
 //   - token string
-//   - twin twins.Twin
-//   - def twins.Definition
-func (_e *Service_Expecter) AddTwin(ctx interface{}, token interface{}, twin interface{}, def interface{}) *Service_AddTwin_Call {
-	return &Service_AddTwin_Call{Call: _e.mock.On("AddTwin", ctx, token, twin, def)}
+//   - domainID string
+//   - rel twins.Relation
+func (_e *Service_Expecter) AddRelation(ctx interface{}, token interface{}, domainID interface{}, rel interface{}) *Service_AddRelation_Call {
+	return &Service_AddRelation_Call{Call: _e.mock.On("AddRelation", ctx, token, domainID, rel)}
 }
 
-func (_c *Service_AddTwin_Call) Run(run func(ctx context.Context, token string, twin twins.Twin, def twins.Definition)) *Service_AddTwin_Call {
+func (_c *Service_AddRelation_Call) Run(run func(ctx context.Context, token string, domainID string, rel twins.Relation)) *Service_AddRelation_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -92,13 +155,13 @@ func (_c *Service_AddTwin_Call) Run(run func(ctx context.Context, token string,
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 twins.Twin
+		var arg2 string
 		if args[2] != nil {
-			arg2 = args[2].(twins.Twin)
+			arg2 = args[2].(string)
 		}
-		var arg3 twins.Definition
+		var arg3 twins.Relation
 		if args[3] != nil {
-			arg3 = args[3].(twins.Definition)
+			arg3 = args[3].(twins.Relation)
 		}
 		run(
 			arg0,
@@ -110,58 +173,57 @@ func (_c *Service_AddTwin_Call) Run(run func(ctx context.Context, token string,
 	return _c
 }
 
-func (_c *Service_AddTwin_Call) Return(tw twins.Twin, err error) *Service_AddTwin_Call {
-	_c.Call.Return(tw, err)
+func (_c *Service_AddRelation_Call) Return(relation twins.Relation, err error) *Service_AddRelation_Call {
+	_c.Call.Return(relation, err)
 	return _c
 }
 
-func (_c *Service_AddTwin_Call) RunAndReturn(run func(ctx context.Context, token string, twin twins.Twin, def twins.Definition) (twins.Twin, error)) *Service_AddTwin_Call {
+func (_c *Service_AddRelation_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, rel twins.Relation) (twins.Relation, error)) *Service_AddRelation_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListStates provides a mock function for the type Service
-func (_mock *Service) ListStates(ctx context.Context, token string, offset uint64, limit uint64, twinID string) (twins.StatesPage, error) {
-	ret := _mock.Called(ctx, token, offset, limit, twinID)
+// AddTemplate provides a mock function for the type Service
+func (_mock *Service) AddTemplate(ctx context.Context, token string, domainID string, tmpl twins.Template) (twins.Template, error) {
+	ret := _mock.Called(ctx, token, domainID, tmpl)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListStates")
+		panic("no return value specified for AddTemplate")
 	}
 
-	var r0 twins.StatesPage
+	var r0 twins.Template
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, uint64, uint64, string) (twins.StatesPage, error)); ok {
-		return returnFunc(ctx, token, offset, limit, twinID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, twins.Template) (twins.Template, error)); ok {
+		return returnFunc(ctx, token, domainID, tmpl)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, uint64, uint64, string) twins.StatesPage); ok {
-		r0 = returnFunc(ctx, token, offset, limit, twinID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, twins.Template) twins.Template); ok {
+		r0 = returnFunc(ctx, token, domainID, tmpl)
 	} else {
-		r0 = ret.Get(0).(twins.StatesPage)
+		r0 = ret.Get(0).(twins.Template)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, uint64, uint64, string) error); ok {
-		r1 = returnFunc(ctx, token, offset, limit, twinID)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, twins.Template) error); ok {
+		r1 = returnFunc(ctx, token, domainID, tmpl)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// Service_ListStates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListStates'
-type Service_ListStates_Call struct {
+// Service_AddTemplate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddTemplate'
+type Service_AddTemplate_Call struct {
 	*mock.Call
 }
 
-// ListStates is a helper method to define mock.On call
+// AddTemplate is a helper method to define mock.On call
 //   - ctx context.Context
 //   - token string
-//   - offset uint64
-//   - limit uint64
-//   - twinID string
-func (_e *Service_Expecter) ListStates(ctx interface{}, token interface{}, offset interface{}, limit interface{}, twinID interface{}) *Service_ListStates_Call {
-	return &Service_ListStates_Call{Call: _e.mock.On("ListStates", ctx, token, offset, limit, twinID)}
+//   - domainID string
+//   - tmpl twins.Template
+func (_e *Service_Expecter) AddTemplate(ctx interface{}, token interface{}, domainID interface{}, tmpl interface{}) *Service_AddTemplate_Call {
+	return &Service_AddTemplate_Call{Call: _e.mock.On("AddTemplate", ctx, token, domainID, tmpl)}
 }
 
-func (_c *Service_ListStates_Call) Run(run func(ctx context.Context, token string, offset uint64, limit uint64, twinID string)) *Service_ListStates_Call {
+func (_c *Service_AddTemplate_Call) Run(run func(ctx context.Context, token string, domainID string, tmpl twins.Template)) *Service_AddTemplate_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -171,82 +233,76 @@ func (_c *Service_ListStates_Call) Run(run func(ctx context.Context, token strin
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 uint64
+		var arg2 string
 		if args[2] != nil {
-			arg2 = args[2].(uint64)
+			arg2 = args[2].(string)
 		}
-		var arg3 uint64
+		var arg3 twins.Template
 		if args[3] != nil {
-			arg3 = args[3].(uint64)
-		}
-		var arg4 string
-		if args[4] != nil {
-			arg4 = args[4].(string)
+			arg3 = args[3].(twins.Template)
 		}
 		run(
 			arg0,
 			arg1,
 			arg2,
 			arg3,
-			arg4,
 		)
 	})
 	return _c
 }
 
-func (_c *Service_ListStates_Call) Return(statesPage twins.StatesPage, err error) *Service_ListStates_Call {
-	_c.Call.Return(statesPage, err)
+func (_c *Service_AddTemplate_Call) Return(template twins.Template, err error) *Service_AddTemplate_Call {
+	_c.Call.Return(template, err)
 	return _c
 }
 
-func (_c *Service_ListStates_Call) RunAndReturn(run func(ctx context.Context, token string, offset uint64, limit uint64, twinID string) (twins.StatesPage, error)) *Service_ListStates_Call {
+func (_c *Service_AddTemplate_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, tmpl twins.Template) (twins.Template, error)) *Service_AddTemplate_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ListTwins provides a mock function for the type Service
-func (_mock *Service) ListTwins(ctx context.Context, token string, offset uint64, limit uint64, name string, metadata twins.Metadata) (twins.Page, error) {
-	ret := _mock.Called(ctx, token, offset, limit, name, metadata)
+// AddTwin provides a mock function for the type Service
+func (_mock *Service) AddTwin(ctx context.Context, token string, domainID string, twin twins.Twin, def twins.Definition) (twins.Twin, error) {
+	ret := _mock.Called(ctx, token, domainID, twin, def)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ListTwins")
+		panic("no return value specified for AddTwin")
 	}
 
-	var r0 twins.Page
+	var r0 twins.Twin
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, uint64, uint64, string, twins.Metadata) (twins.Page, error)); ok {
-		return returnFunc(ctx, token, offset, limit, name, metadata)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, twins.Twin, twins.Definition) (twins.Twin, error)); ok {
+		return returnFunc(ctx, token, domainID, twin, def)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, uint64, uint64, string, twins.Metadata) twins.Page); ok {
-		r0 = returnFunc(ctx, token, offset, limit, name, metadata)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, twins.Twin, twins.Definition) twins.Twin); ok {
+		r0 = returnFunc(ctx, token, domainID, twin, def)
 	} else {
-		r0 = ret.Get(0).(twins.Page)
+		r0 = ret.Get(0).(twins.Twin)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, uint64, uint64, string, twins.Metadata) error); ok {
-		r1 = returnFunc(ctx, token, offset, limit, name, metadata)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, twins.Twin, twins.Definition) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twin, def)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// Service_ListTwins_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListTwins'
-type Service_ListTwins_Call struct {
+// Service_AddTwin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddTwin'
+type Service_AddTwin_Call struct {
 	*mock.Call
 }
 
-// ListTwins is a helper method to define mock.On call
+// AddTwin is a helper method to define mock.On call
 //   - ctx context.Context
 //   - token string
-//   - offset uint64
-//   - limit uint64
-//   - name string
-//   - metadata twins.Metadata
-func (_e *Service_Expecter) ListTwins(ctx interface{}, token interface{}, offset interface{}, limit interface{}, name interface{}, metadata interface{}) *Service_ListTwins_Call {
-	return &Service_ListTwins_Call{Call: _e.mock.On("ListTwins", ctx, token, offset, limit, name, metadata)}
+//   - domainID string
+//   - twin twins.Twin
+//   - def twins.Definition
+func (_e *Service_Expecter) AddTwin(ctx interface{}, token interface{}, domainID interface{}, twin interface{}, def interface{}) *Service_AddTwin_Call {
+	return &Service_AddTwin_Call{Call: _e.mock.On("AddTwin", ctx, token, domainID, twin, def)}
 }
 
-func (_c *Service_ListTwins_Call) Run(run func(ctx context.Context, token string, offset uint64, limit uint64, name string, metadata twins.Metadata)) *Service_ListTwins_Call {
+func (_c *Service_AddTwin_Call) Run(run func(ctx context.Context, token string, domainID string, twin twins.Twin, def twins.Definition)) *Service_AddTwin_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -256,21 +312,17 @@ func (_c *Service_ListTwins_Call) Run(run func(ctx context.Context, token string
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 uint64
+		var arg2 string
 		if args[2] != nil {
-			arg2 = args[2].(uint64)
+			arg2 = args[2].(string)
 		}
-		var arg3 uint64
+		var arg3 twins.Twin
 		if args[3] != nil {
-			arg3 = args[3].(uint64)
+			arg3 = args[3].(twins.Twin)
 		}
-		var arg4 string
+		var arg4 twins.Definition
 		if args[4] != nil {
-			arg4 = args[4].(string)
-		}
-		var arg5 twins.Metadata
-		if args[5] != nil {
-			arg5 = args[5].(twins.Metadata)
+			arg4 = args[4].(twins.Definition)
 		}
 		run(
 			arg0,
@@ -278,53 +330,54 @@ func (_c *Service_ListTwins_Call) Run(run func(ctx context.Context, token string
 			arg2,
 			arg3,
 			arg4,
-			arg5,
 		)
 	})
 	return _c
 }
 
-func (_c *Service_ListTwins_Call) Return(page twins.Page, err error) *Service_ListTwins_Call {
-	_c.Call.Return(page, err)
+func (_c *Service_AddTwin_Call) Return(tw twins.Twin, err error) *Service_AddTwin_Call {
+	_c.Call.Return(tw, err)
 	return _c
 }
 
-func (_c *Service_ListTwins_Call) RunAndReturn(run func(ctx context.Context, token string, offset uint64, limit uint64, name string, metadata twins.Metadata) (twins.Page, error)) *Service_ListTwins_Call {
+func (_c *Service_AddTwin_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twin twins.Twin, def twins.Definition) (twins.Twin, error)) *Service_AddTwin_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RemoveTwin provides a mock function for the type Service
-func (_mock *Service) RemoveTwin(ctx context.Context, token string, twinID string) error {
-	ret := _mock.Called(ctx, token, twinID)
+// AttachChild provides a mock function for the type Service
+func (_mock *Service) AttachChild(ctx context.Context, token string, domainID string, parentID string, childID string) error {
+	ret := _mock.Called(ctx, token, domainID, parentID, childID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RemoveTwin")
+		panic("no return value specified for AttachChild")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
-		r0 = returnFunc(ctx, token, twinID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string) error); ok {
+		r0 = returnFunc(ctx, token, domainID, parentID, childID)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// Service_RemoveTwin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveTwin'
-type Service_RemoveTwin_Call struct {
+// Service_AttachChild_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AttachChild'
+type Service_AttachChild_Call struct {
 	*mock.Call
 }
 
-// RemoveTwin is a helper method to define mock.On call
+// AttachChild is a helper method to define mock.On call
 //   - ctx context.Context
 //   - token string
-//   - twinID string
-func (_e *Service_Expecter) RemoveTwin(ctx interface{}, token interface{}, twinID interface{}) *Service_RemoveTwin_Call {
-	return &Service_RemoveTwin_Call{Call: _e.mock.On("RemoveTwin", ctx, token, twinID)}
+//   - domainID string
+//   - parentID string
+//   - childID string
+func (_e *Service_Expecter) AttachChild(ctx interface{}, token interface{}, domainID interface{}, parentID interface{}, childID interface{}) *Service_AttachChild_Call {
+	return &Service_AttachChild_Call{Call: _e.mock.On("AttachChild", ctx, token, domainID, parentID, childID)}
 }
 
-func (_c *Service_RemoveTwin_Call) Run(run func(ctx context.Context, token string, twinID string)) *Service_RemoveTwin_Call {
+func (_c *Service_AttachChild_Call) Run(run func(ctx context.Context, token string, domainID string, parentID string, childID string)) *Service_AttachChild_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -338,114 +391,215 @@ func (_c *Service_RemoveTwin_Call) Run(run func(ctx context.Context, token strin
 		if args[2] != nil {
 			arg2 = args[2].(string)
 		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 string
+		if args[4] != nil {
+			arg4 = args[4].(string)
+		}
 		run(
 			arg0,
 			arg1,
 			arg2,
+			arg3,
+			arg4,
 		)
 	})
 	return _c
 }
 
-func (_c *Service_RemoveTwin_Call) Return(err error) *Service_RemoveTwin_Call {
+func (_c *Service_AttachChild_Call) Return(err error) *Service_AttachChild_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Service_RemoveTwin_Call) RunAndReturn(run func(ctx context.Context, token string, twinID string) error) *Service_RemoveTwin_Call {
+func (_c *Service_AttachChild_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, parentID string, childID string) error) *Service_AttachChild_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// SaveStates provides a mock function for the type Service
-func (_mock *Service) SaveStates(ctx context.Context, msg *messaging.Message) error {
-	ret := _mock.Called(ctx, msg)
+// CheckLiveness provides a mock function for the type Service
+func (_mock *Service) CheckLiveness(ctx context.Context) ([]twins.Staleness, error) {
+	ret := _mock.Called(ctx)
 
 	if len(ret) == 0 {
-		panic("no return value specified for SaveStates")
+		panic("no return value specified for CheckLiveness")
+	}
+
+	var r0 []twins.Staleness
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) ([]twins.Staleness, error)); ok {
+		return returnFunc(ctx)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context) []twins.Staleness); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]twins.Staleness)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = returnFunc(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_CheckLiveness_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CheckLiveness'
+type Service_CheckLiveness_Call struct {
+	*mock.Call
+}
+
+// CheckLiveness is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Service_Expecter) CheckLiveness(ctx interface{}) *Service_CheckLiveness_Call {
+	return &Service_CheckLiveness_Call{Call: _e.mock.On("CheckLiveness", ctx)}
+}
+
+func (_c *Service_CheckLiveness_Call) Run(run func(ctx context.Context)) *Service_CheckLiveness_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_CheckLiveness_Call) Return(stalenesss []twins.Staleness, err error) *Service_CheckLiveness_Call {
+	_c.Call.Return(stalenesss, err)
+	return _c
+}
+
+func (_c *Service_CheckLiveness_Call) RunAndReturn(run func(ctx context.Context) ([]twins.Staleness, error)) *Service_CheckLiveness_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DetachChild provides a mock function for the type Service
+func (_mock *Service) DetachChild(ctx context.Context, token string, domainID string, parentID string, childID string) error {
+	ret := _mock.Called(ctx, token, domainID, parentID, childID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DetachChild")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, *messaging.Message) error); ok {
-		r0 = returnFunc(ctx, msg)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string) error); ok {
+		r0 = returnFunc(ctx, token, domainID, parentID, childID)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// Service_SaveStates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveStates'
-type Service_SaveStates_Call struct {
+// Service_DetachChild_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DetachChild'
+type Service_DetachChild_Call struct {
 	*mock.Call
 }
 
-// SaveStates is a helper method to define mock.On call
+// DetachChild is a helper method to define mock.On call
 //   - ctx context.Context
-//   - msg *messaging.Message
-func (_e *Service_Expecter) SaveStates(ctx interface{}, msg interface{}) *Service_SaveStates_Call {
-	return &Service_SaveStates_Call{Call: _e.mock.On("SaveStates", ctx, msg)}
+//   - token string
+//   - domainID string
+//   - parentID string
+//   - childID string
+func (_e *Service_Expecter) DetachChild(ctx interface{}, token interface{}, domainID interface{}, parentID interface{}, childID interface{}) *Service_DetachChild_Call {
+	return &Service_DetachChild_Call{Call: _e.mock.On("DetachChild", ctx, token, domainID, parentID, childID)}
 }
 
-func (_c *Service_SaveStates_Call) Run(run func(ctx context.Context, msg *messaging.Message)) *Service_SaveStates_Call {
+func (_c *Service_DetachChild_Call) Run(run func(ctx context.Context, token string, domainID string, parentID string, childID string)) *Service_DetachChild_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 *messaging.Message
+		var arg1 string
 		if args[1] != nil {
-			arg1 = args[1].(*messaging.Message)
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 string
+		if args[4] != nil {
+			arg4 = args[4].(string)
 		}
 		run(
 			arg0,
 			arg1,
+			arg2,
+			arg3,
+			arg4,
 		)
 	})
 	return _c
 }
 
-func (_c *Service_SaveStates_Call) Return(err error) *Service_SaveStates_Call {
+func (_c *Service_DetachChild_Call) Return(err error) *Service_DetachChild_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *Service_SaveStates_Call) RunAndReturn(run func(ctx context.Context, msg *messaging.Message) error) *Service_SaveStates_Call {
+func (_c *Service_DetachChild_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, parentID string, childID string) error) *Service_DetachChild_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// UpdateTwin provides a mock function for the type Service
-func (_mock *Service) UpdateTwin(ctx context.Context, token string, twin twins.Twin, def twins.Definition) error {
-	ret := _mock.Called(ctx, token, twin, def)
+// DiffDefinitions provides a mock function for the type Service
+func (_mock *Service) DiffDefinitions(ctx context.Context, token string, domainID string, twinID string, from int, to int) (twins.DefinitionDiff, error) {
+	ret := _mock.Called(ctx, token, domainID, twinID, from, to)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateTwin")
+		panic("no return value specified for DiffDefinitions")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, twins.Twin, twins.Definition) error); ok {
-		r0 = returnFunc(ctx, token, twin, def)
+	var r0 twins.DefinitionDiff
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, int, int) (twins.DefinitionDiff, error)); ok {
+		return returnFunc(ctx, token, domainID, twinID, from, to)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, int, int) twins.DefinitionDiff); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID, from, to)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(twins.DefinitionDiff)
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, int, int) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twinID, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// Service_UpdateTwin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateTwin'
-type Service_UpdateTwin_Call struct {
+// Service_DiffDefinitions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DiffDefinitions'
+type Service_DiffDefinitions_Call struct {
 	*mock.Call
 }
 
-// UpdateTwin is a helper method to define mock.On call
+// DiffDefinitions is a helper method to define mock.On call
 //   - ctx context.Context
 //   - token string
-//   - twin twins.Twin
-//   - def twins.Definition
-func (_e *Service_Expecter) UpdateTwin(ctx interface{}, token interface{}, twin interface{}, def interface{}) *Service_UpdateTwin_Call {
-	return &Service_UpdateTwin_Call{Call: _e.mock.On("UpdateTwin", ctx, token, twin, def)}
+//   - domainID string
+//   - twinID string
+//   - from int
+//   - to int
+func (_e *Service_Expecter) DiffDefinitions(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}, from interface{}, to interface{}) *Service_DiffDefinitions_Call {
+	return &Service_DiffDefinitions_Call{Call: _e.mock.On("DiffDefinitions", ctx, token, domainID, twinID, from, to)}
 }
 
-func (_c *Service_UpdateTwin_Call) Run(run func(ctx context.Context, token string, twin twins.Twin, def twins.Definition)) *Service_UpdateTwin_Call {
+func (_c *Service_DiffDefinitions_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string, from int, to int)) *Service_DiffDefinitions_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -455,74 +609,87 @@ func (_c *Service_UpdateTwin_Call) Run(run func(ctx context.Context, token strin
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 twins.Twin
+		var arg2 string
 		if args[2] != nil {
-			arg2 = args[2].(twins.Twin)
+			arg2 = args[2].(string)
 		}
-		var arg3 twins.Definition
+		var arg3 string
 		if args[3] != nil {
-			arg3 = args[3].(twins.Definition)
+			arg3 = args[3].(string)
+		}
+		var arg4 int
+		if args[4] != nil {
+			arg4 = args[4].(int)
+		}
+		var arg5 int
+		if args[5] != nil {
+			arg5 = args[5].(int)
 		}
 		run(
 			arg0,
 			arg1,
 			arg2,
 			arg3,
+			arg4,
+			arg5,
 		)
 	})
 	return _c
 }
 
-func (_c *Service_UpdateTwin_Call) Return(err error) *Service_UpdateTwin_Call {
-	_c.Call.Return(err)
+func (_c *Service_DiffDefinitions_Call) Return(definitionDiff twins.DefinitionDiff, err error) *Service_DiffDefinitions_Call {
+	_c.Call.Return(definitionDiff, err)
 	return _c
 }
 
-func (_c *Service_UpdateTwin_Call) RunAndReturn(run func(ctx context.Context, token string, twin twins.Twin, def twins.Definition) error) *Service_UpdateTwin_Call {
+func (_c *Service_DiffDefinitions_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string, from int, to int) (twins.DefinitionDiff, error)) *Service_DiffDefinitions_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// ViewTwin provides a mock function for the type Service
-func (_mock *Service) ViewTwin(ctx context.Context, token string, twinID string) (twins.Twin, error) {
-	ret := _mock.Called(ctx, token, twinID)
+// DiffStates provides a mock function for the type Service
+func (_mock *Service) DiffStates(ctx context.Context, token string, domainID string, twinID string, from twins.StateSelector, to twins.StateSelector) (twins.StateDiff, error) {
+	ret := _mock.Called(ctx, token, domainID, twinID, from, to)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ViewTwin")
+		panic("no return value specified for DiffStates")
 	}
 
-	var r0 twins.Twin
+	var r0 twins.StateDiff
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) (twins.Twin, error)); ok {
-		return returnFunc(ctx, token, twinID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, twins.StateSelector, twins.StateSelector) (twins.StateDiff, error)); ok {
+		return returnFunc(ctx, token, domainID, twinID, from, to)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) twins.Twin); ok {
-		r0 = returnFunc(ctx, token, twinID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, twins.StateSelector, twins.StateSelector) twins.StateDiff); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID, from, to)
 	} else {
-		r0 = ret.Get(0).(twins.Twin)
+		r0 = ret.Get(0).(twins.StateDiff)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = returnFunc(ctx, token, twinID)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, twins.StateSelector, twins.StateSelector) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twinID, from, to)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// Service_ViewTwin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewTwin'
-type Service_ViewTwin_Call struct {
+// Service_DiffStates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DiffStates'
+type Service_DiffStates_Call struct {
 	*mock.Call
 }
 
-// ViewTwin is a helper method to define mock.On call
+// DiffStates is a helper method to define mock.On call
 //   - ctx context.Context
 //   - token string
+//   - domainID string
 //   - twinID string
-func (_e *Service_Expecter) ViewTwin(ctx interface{}, token interface{}, twinID interface{}) *Service_ViewTwin_Call {
-	return &Service_ViewTwin_Call{Call: _e.mock.On("ViewTwin", ctx, token, twinID)}
+//   - from twins.StateSelector
+//   - to twins.StateSelector
+func (_e *Service_Expecter) DiffStates(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}, from interface{}, to interface{}) *Service_DiffStates_Call {
+	return &Service_DiffStates_Call{Call: _e.mock.On("DiffStates", ctx, token, domainID, twinID, from, to)}
 }
 
-func (_c *Service_ViewTwin_Call) Run(run func(ctx context.Context, token string, twinID string)) *Service_ViewTwin_Call {
+func (_c *Service_DiffStates_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string, from twins.StateSelector, to twins.StateSelector)) *Service_DiffStates_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -536,10 +703,2540 @@ func (_c *Service_ViewTwin_Call) Run(run func(ctx context.Context, token string,
 		if args[2] != nil {
 			arg2 = args[2].(string)
 		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 twins.StateSelector
+		if args[4] != nil {
+			arg4 = args[4].(twins.StateSelector)
+		}
+		var arg5 twins.StateSelector
+		if args[5] != nil {
+			arg5 = args[5].(twins.StateSelector)
+		}
 		run(
 			arg0,
 			arg1,
 			arg2,
+			arg3,
+			arg4,
+			arg5,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_DiffStates_Call) Return(stateDiff twins.StateDiff, err error) *Service_DiffStates_Call {
+	_c.Call.Return(stateDiff, err)
+	return _c
+}
+
+func (_c *Service_DiffStates_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string, from twins.StateSelector, to twins.StateSelector) (twins.StateDiff, error)) *Service_DiffStates_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DrainStates provides a mock function for the type Service
+func (_mock *Service) DrainStates(ctx context.Context) error {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DrainStates")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Service_DrainStates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DrainStates'
+type Service_DrainStates_Call struct {
+	*mock.Call
+}
+
+// DrainStates is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Service_Expecter) DrainStates(ctx interface{}) *Service_DrainStates_Call {
+	return &Service_DrainStates_Call{Call: _e.mock.On("DrainStates", ctx)}
+}
+
+func (_c *Service_DrainStates_Call) Run(run func(ctx context.Context)) *Service_DrainStates_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_DrainStates_Call) Return(err error) *Service_DrainStates_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Service_DrainStates_Call) RunAndReturn(run func(ctx context.Context) error) *Service_DrainStates_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ExportTwins provides a mock function for the type Service
+func (_mock *Service) ExportTwins(ctx context.Context, token string, domainID string, ownerID string) ([]twins.Twin, error) {
+	ret := _mock.Called(ctx, token, domainID, ownerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportTwins")
+	}
+
+	var r0 []twins.Twin
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) ([]twins.Twin, error)); ok {
+		return returnFunc(ctx, token, domainID, ownerID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) []twins.Twin); ok {
+		r0 = returnFunc(ctx, token, domainID, ownerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]twins.Twin)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, token, domainID, ownerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ExportTwins_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExportTwins'
+type Service_ExportTwins_Call struct {
+	*mock.Call
+}
+
+// ExportTwins is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - ownerID string
+func (_e *Service_Expecter) ExportTwins(ctx interface{}, token interface{}, domainID interface{}, ownerID interface{}) *Service_ExportTwins_Call {
+	return &Service_ExportTwins_Call{Call: _e.mock.On("ExportTwins", ctx, token, domainID, ownerID)}
+}
+
+func (_c *Service_ExportTwins_Call) Run(run func(ctx context.Context, token string, domainID string, ownerID string)) *Service_ExportTwins_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ExportTwins_Call) Return(twins1 []twins.Twin, err error) *Service_ExportTwins_Call {
+	_c.Call.Return(twins1, err)
+	return _c
+}
+
+func (_c *Service_ExportTwins_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, ownerID string) ([]twins.Twin, error)) *Service_ExportTwins_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ImportDTDL provides a mock function for the type Service
+func (_mock *Service) ImportDTDL(ctx context.Context, token string, domainID string, model []byte, opts twins.DTDLImportOptions) (twins.DTDLImport, error) {
+	ret := _mock.Called(ctx, token, domainID, model, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportDTDL")
+	}
+
+	var r0 twins.DTDLImport
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []byte, twins.DTDLImportOptions) (twins.DTDLImport, error)); ok {
+		return returnFunc(ctx, token, domainID, model, opts)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []byte, twins.DTDLImportOptions) twins.DTDLImport); ok {
+		r0 = returnFunc(ctx, token, domainID, model, opts)
+	} else {
+		r0 = ret.Get(0).(twins.DTDLImport)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, []byte, twins.DTDLImportOptions) error); ok {
+		r1 = returnFunc(ctx, token, domainID, model, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ImportDTDL_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ImportDTDL'
+type Service_ImportDTDL_Call struct {
+	*mock.Call
+}
+
+// ImportDTDL is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - model []byte
+//   - opts twins.DTDLImportOptions
+func (_e *Service_Expecter) ImportDTDL(ctx interface{}, token interface{}, domainID interface{}, model interface{}, opts interface{}) *Service_ImportDTDL_Call {
+	return &Service_ImportDTDL_Call{Call: _e.mock.On("ImportDTDL", ctx, token, domainID, model, opts)}
+}
+
+func (_c *Service_ImportDTDL_Call) Run(run func(ctx context.Context, token string, domainID string, model []byte, opts twins.DTDLImportOptions)) *Service_ImportDTDL_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 []byte
+		if args[3] != nil {
+			arg3 = args[3].([]byte)
+		}
+		var arg4 twins.DTDLImportOptions
+		if args[4] != nil {
+			arg4 = args[4].(twins.DTDLImportOptions)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ImportDTDL_Call) Return(dTDLImport twins.DTDLImport, err error) *Service_ImportDTDL_Call {
+	_c.Call.Return(dTDLImport, err)
+	return _c
+}
+
+func (_c *Service_ImportDTDL_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, model []byte, opts twins.DTDLImportOptions) (twins.DTDLImport, error)) *Service_ImportDTDL_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ImportTwins provides a mock function for the type Service
+func (_mock *Service) ImportTwins(ctx context.Context, token string, domainID string, tws []twins.Twin, opts twins.ImportOptions) (twins.Import, error) {
+	ret := _mock.Called(ctx, token, domainID, tws, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportTwins")
+	}
+
+	var r0 twins.Import
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []twins.Twin, twins.ImportOptions) (twins.Import, error)); ok {
+		return returnFunc(ctx, token, domainID, tws, opts)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []twins.Twin, twins.ImportOptions) twins.Import); ok {
+		r0 = returnFunc(ctx, token, domainID, tws, opts)
+	} else {
+		r0 = ret.Get(0).(twins.Import)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, []twins.Twin, twins.ImportOptions) error); ok {
+		r1 = returnFunc(ctx, token, domainID, tws, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ImportTwins_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ImportTwins'
+type Service_ImportTwins_Call struct {
+	*mock.Call
+}
+
+// ImportTwins is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - tws []twins.Twin
+//   - opts twins.ImportOptions
+func (_e *Service_Expecter) ImportTwins(ctx interface{}, token interface{}, domainID interface{}, tws interface{}, opts interface{}) *Service_ImportTwins_Call {
+	return &Service_ImportTwins_Call{Call: _e.mock.On("ImportTwins", ctx, token, domainID, tws, opts)}
+}
+
+func (_c *Service_ImportTwins_Call) Run(run func(ctx context.Context, token string, domainID string, tws []twins.Twin, opts twins.ImportOptions)) *Service_ImportTwins_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 []twins.Twin
+		if args[3] != nil {
+			arg3 = args[3].([]twins.Twin)
+		}
+		var arg4 twins.ImportOptions
+		if args[4] != nil {
+			arg4 = args[4].(twins.ImportOptions)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ImportTwins_Call) Return(import1 twins.Import, err error) *Service_ImportTwins_Call {
+	_c.Call.Return(import1, err)
+	return _c
+}
+
+func (_c *Service_ImportTwins_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, tws []twins.Twin, opts twins.ImportOptions) (twins.Import, error)) *Service_ImportTwins_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListAlarms provides a mock function for the type Service
+func (_mock *Service) ListAlarms(ctx context.Context, token string, domainID string, offset uint64, limit uint64, twinID string, filter twins.AlarmFilter) (twins.AlarmsPage, error) {
+	ret := _mock.Called(ctx, token, domainID, offset, limit, twinID, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAlarms")
+	}
+
+	var r0 twins.AlarmsPage
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, uint64, uint64, string, twins.AlarmFilter) (twins.AlarmsPage, error)); ok {
+		return returnFunc(ctx, token, domainID, offset, limit, twinID, filter)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, uint64, uint64, string, twins.AlarmFilter) twins.AlarmsPage); ok {
+		r0 = returnFunc(ctx, token, domainID, offset, limit, twinID, filter)
+	} else {
+		r0 = ret.Get(0).(twins.AlarmsPage)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, uint64, uint64, string, twins.AlarmFilter) error); ok {
+		r1 = returnFunc(ctx, token, domainID, offset, limit, twinID, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ListAlarms_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListAlarms'
+type Service_ListAlarms_Call struct {
+	*mock.Call
+}
+
+// ListAlarms is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - offset uint64
+//   - limit uint64
+//   - twinID string
+//   - filter twins.AlarmFilter
+func (_e *Service_Expecter) ListAlarms(ctx interface{}, token interface{}, domainID interface{}, offset interface{}, limit interface{}, twinID interface{}, filter interface{}) *Service_ListAlarms_Call {
+	return &Service_ListAlarms_Call{Call: _e.mock.On("ListAlarms", ctx, token, domainID, offset, limit, twinID, filter)}
+}
+
+func (_c *Service_ListAlarms_Call) Run(run func(ctx context.Context, token string, domainID string, offset uint64, limit uint64, twinID string, filter twins.AlarmFilter)) *Service_ListAlarms_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 uint64
+		if args[3] != nil {
+			arg3 = args[3].(uint64)
+		}
+		var arg4 uint64
+		if args[4] != nil {
+			arg4 = args[4].(uint64)
+		}
+		var arg5 string
+		if args[5] != nil {
+			arg5 = args[5].(string)
+		}
+		var arg6 twins.AlarmFilter
+		if args[6] != nil {
+			arg6 = args[6].(twins.AlarmFilter)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+			arg5,
+			arg6,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ListAlarms_Call) Return(alarmsPage twins.AlarmsPage, err error) *Service_ListAlarms_Call {
+	_c.Call.Return(alarmsPage, err)
+	return _c
+}
+
+func (_c *Service_ListAlarms_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, offset uint64, limit uint64, twinID string, filter twins.AlarmFilter) (twins.AlarmsPage, error)) *Service_ListAlarms_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListDefinitions provides a mock function for the type Service
+func (_mock *Service) ListDefinitions(ctx context.Context, token string, domainID string, twinID string) ([]twins.DefinitionSummary, error) {
+	ret := _mock.Called(ctx, token, domainID, twinID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDefinitions")
+	}
+
+	var r0 []twins.DefinitionSummary
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) ([]twins.DefinitionSummary, error)); ok {
+		return returnFunc(ctx, token, domainID, twinID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) []twins.DefinitionSummary); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]twins.DefinitionSummary)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twinID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ListDefinitions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListDefinitions'
+type Service_ListDefinitions_Call struct {
+	*mock.Call
+}
+
+// ListDefinitions is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+func (_e *Service_Expecter) ListDefinitions(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}) *Service_ListDefinitions_Call {
+	return &Service_ListDefinitions_Call{Call: _e.mock.On("ListDefinitions", ctx, token, domainID, twinID)}
+}
+
+func (_c *Service_ListDefinitions_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string)) *Service_ListDefinitions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ListDefinitions_Call) Return(definitionSummarys []twins.DefinitionSummary, err error) *Service_ListDefinitions_Call {
+	_c.Call.Return(definitionSummarys, err)
+	return _c
+}
+
+func (_c *Service_ListDefinitions_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string) ([]twins.DefinitionSummary, error)) *Service_ListDefinitions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListRelations provides a mock function for the type Service
+func (_mock *Service) ListRelations(ctx context.Context, token string, domainID string, twinID string, offset uint64, limit uint64, filter twins.RelationFilter) (twins.RelationsPage, error) {
+	ret := _mock.Called(ctx, token, domainID, twinID, offset, limit, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListRelations")
+	}
+
+	var r0 twins.RelationsPage
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, uint64, uint64, twins.RelationFilter) (twins.RelationsPage, error)); ok {
+		return returnFunc(ctx, token, domainID, twinID, offset, limit, filter)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, uint64, uint64, twins.RelationFilter) twins.RelationsPage); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID, offset, limit, filter)
+	} else {
+		r0 = ret.Get(0).(twins.RelationsPage)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, uint64, uint64, twins.RelationFilter) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twinID, offset, limit, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ListRelations_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRelations'
+type Service_ListRelations_Call struct {
+	*mock.Call
+}
+
+// ListRelations is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+//   - offset uint64
+//   - limit uint64
+//   - filter twins.RelationFilter
+func (_e *Service_Expecter) ListRelations(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}, offset interface{}, limit interface{}, filter interface{}) *Service_ListRelations_Call {
+	return &Service_ListRelations_Call{Call: _e.mock.On("ListRelations", ctx, token, domainID, twinID, offset, limit, filter)}
+}
+
+func (_c *Service_ListRelations_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string, offset uint64, limit uint64, filter twins.RelationFilter)) *Service_ListRelations_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 uint64
+		if args[4] != nil {
+			arg4 = args[4].(uint64)
+		}
+		var arg5 uint64
+		if args[5] != nil {
+			arg5 = args[5].(uint64)
+		}
+		var arg6 twins.RelationFilter
+		if args[6] != nil {
+			arg6 = args[6].(twins.RelationFilter)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+			arg5,
+			arg6,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ListRelations_Call) Return(relationsPage twins.RelationsPage, err error) *Service_ListRelations_Call {
+	_c.Call.Return(relationsPage, err)
+	return _c
+}
+
+func (_c *Service_ListRelations_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string, offset uint64, limit uint64, filter twins.RelationFilter) (twins.RelationsPage, error)) *Service_ListRelations_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListStates provides a mock function for the type Service
+func (_mock *Service) ListStates(ctx context.Context, token string, domainID string, offset uint64, limit uint64, twinID string, filter twins.StateFilter) (twins.StatesPage, error) {
+	ret := _mock.Called(ctx, token, domainID, offset, limit, twinID, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListStates")
+	}
+
+	var r0 twins.StatesPage
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, uint64, uint64, string, twins.StateFilter) (twins.StatesPage, error)); ok {
+		return returnFunc(ctx, token, domainID, offset, limit, twinID, filter)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, uint64, uint64, string, twins.StateFilter) twins.StatesPage); ok {
+		r0 = returnFunc(ctx, token, domainID, offset, limit, twinID, filter)
+	} else {
+		r0 = ret.Get(0).(twins.StatesPage)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, uint64, uint64, string, twins.StateFilter) error); ok {
+		r1 = returnFunc(ctx, token, domainID, offset, limit, twinID, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ListStates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListStates'
+type Service_ListStates_Call struct {
+	*mock.Call
+}
+
+// ListStates is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - offset uint64
+//   - limit uint64
+//   - twinID string
+//   - filter twins.StateFilter
+func (_e *Service_Expecter) ListStates(ctx interface{}, token interface{}, domainID interface{}, offset interface{}, limit interface{}, twinID interface{}, filter interface{}) *Service_ListStates_Call {
+	return &Service_ListStates_Call{Call: _e.mock.On("ListStates", ctx, token, domainID, offset, limit, twinID, filter)}
+}
+
+func (_c *Service_ListStates_Call) Run(run func(ctx context.Context, token string, domainID string, offset uint64, limit uint64, twinID string, filter twins.StateFilter)) *Service_ListStates_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 uint64
+		if args[3] != nil {
+			arg3 = args[3].(uint64)
+		}
+		var arg4 uint64
+		if args[4] != nil {
+			arg4 = args[4].(uint64)
+		}
+		var arg5 string
+		if args[5] != nil {
+			arg5 = args[5].(string)
+		}
+		var arg6 twins.StateFilter
+		if args[6] != nil {
+			arg6 = args[6].(twins.StateFilter)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+			arg5,
+			arg6,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ListStates_Call) Return(statesPage twins.StatesPage, err error) *Service_ListStates_Call {
+	_c.Call.Return(statesPage, err)
+	return _c
+}
+
+func (_c *Service_ListStates_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, offset uint64, limit uint64, twinID string, filter twins.StateFilter) (twins.StatesPage, error)) *Service_ListStates_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListTemplates provides a mock function for the type Service
+func (_mock *Service) ListTemplates(ctx context.Context, token string, domainID string, offset uint64, limit uint64, name string) (twins.TemplatesPage, error) {
+	ret := _mock.Called(ctx, token, domainID, offset, limit, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTemplates")
+	}
+
+	var r0 twins.TemplatesPage
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, uint64, uint64, string) (twins.TemplatesPage, error)); ok {
+		return returnFunc(ctx, token, domainID, offset, limit, name)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, uint64, uint64, string) twins.TemplatesPage); ok {
+		r0 = returnFunc(ctx, token, domainID, offset, limit, name)
+	} else {
+		r0 = ret.Get(0).(twins.TemplatesPage)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, uint64, uint64, string) error); ok {
+		r1 = returnFunc(ctx, token, domainID, offset, limit, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ListTemplates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListTemplates'
+type Service_ListTemplates_Call struct {
+	*mock.Call
+}
+
+// ListTemplates is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - offset uint64
+//   - limit uint64
+//   - name string
+func (_e *Service_Expecter) ListTemplates(ctx interface{}, token interface{}, domainID interface{}, offset interface{}, limit interface{}, name interface{}) *Service_ListTemplates_Call {
+	return &Service_ListTemplates_Call{Call: _e.mock.On("ListTemplates", ctx, token, domainID, offset, limit, name)}
+}
+
+func (_c *Service_ListTemplates_Call) Run(run func(ctx context.Context, token string, domainID string, offset uint64, limit uint64, name string)) *Service_ListTemplates_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 uint64
+		if args[3] != nil {
+			arg3 = args[3].(uint64)
+		}
+		var arg4 uint64
+		if args[4] != nil {
+			arg4 = args[4].(uint64)
+		}
+		var arg5 string
+		if args[5] != nil {
+			arg5 = args[5].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+			arg5,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ListTemplates_Call) Return(templatesPage twins.TemplatesPage, err error) *Service_ListTemplates_Call {
+	_c.Call.Return(templatesPage, err)
+	return _c
+}
+
+func (_c *Service_ListTemplates_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, offset uint64, limit uint64, name string) (twins.TemplatesPage, error)) *Service_ListTemplates_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListTwins provides a mock function for the type Service
+func (_mock *Service) ListTwins(ctx context.Context, token string, domainID string, offset uint64, limit uint64, name string, parentID string, metadata twins.Metadata) (twins.Page, error) {
+	ret := _mock.Called(ctx, token, domainID, offset, limit, name, parentID, metadata)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTwins")
+	}
+
+	var r0 twins.Page
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, uint64, uint64, string, string, twins.Metadata) (twins.Page, error)); ok {
+		return returnFunc(ctx, token, domainID, offset, limit, name, parentID, metadata)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, uint64, uint64, string, string, twins.Metadata) twins.Page); ok {
+		r0 = returnFunc(ctx, token, domainID, offset, limit, name, parentID, metadata)
+	} else {
+		r0 = ret.Get(0).(twins.Page)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, uint64, uint64, string, string, twins.Metadata) error); ok {
+		r1 = returnFunc(ctx, token, domainID, offset, limit, name, parentID, metadata)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ListTwins_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListTwins'
+type Service_ListTwins_Call struct {
+	*mock.Call
+}
+
+// ListTwins is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - offset uint64
+//   - limit uint64
+//   - name string
+//   - parentID string
+//   - metadata twins.Metadata
+func (_e *Service_Expecter) ListTwins(ctx interface{}, token interface{}, domainID interface{}, offset interface{}, limit interface{}, name interface{}, parentID interface{}, metadata interface{}) *Service_ListTwins_Call {
+	return &Service_ListTwins_Call{Call: _e.mock.On("ListTwins", ctx, token, domainID, offset, limit, name, parentID, metadata)}
+}
+
+func (_c *Service_ListTwins_Call) Run(run func(ctx context.Context, token string, domainID string, offset uint64, limit uint64, name string, parentID string, metadata twins.Metadata)) *Service_ListTwins_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 uint64
+		if args[3] != nil {
+			arg3 = args[3].(uint64)
+		}
+		var arg4 uint64
+		if args[4] != nil {
+			arg4 = args[4].(uint64)
+		}
+		var arg5 string
+		if args[5] != nil {
+			arg5 = args[5].(string)
+		}
+		var arg6 string
+		if args[6] != nil {
+			arg6 = args[6].(string)
+		}
+		var arg7 twins.Metadata
+		if args[7] != nil {
+			arg7 = args[7].(twins.Metadata)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+			arg5,
+			arg6,
+			arg7,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ListTwins_Call) Return(page twins.Page, err error) *Service_ListTwins_Call {
+	_c.Call.Return(page, err)
+	return _c
+}
+
+func (_c *Service_ListTwins_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, offset uint64, limit uint64, name string, parentID string, metadata twins.Metadata) (twins.Page, error)) *Service_ListTwins_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveRelation provides a mock function for the type Service
+func (_mock *Service) RemoveRelation(ctx context.Context, token string, domainID string, rel twins.Relation) error {
+	ret := _mock.Called(ctx, token, domainID, rel)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveRelation")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, twins.Relation) error); ok {
+		r0 = returnFunc(ctx, token, domainID, rel)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Service_RemoveRelation_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveRelation'
+type Service_RemoveRelation_Call struct {
+	*mock.Call
+}
+
+// RemoveRelation is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - rel twins.Relation
+func (_e *Service_Expecter) RemoveRelation(ctx interface{}, token interface{}, domainID interface{}, rel interface{}) *Service_RemoveRelation_Call {
+	return &Service_RemoveRelation_Call{Call: _e.mock.On("RemoveRelation", ctx, token, domainID, rel)}
+}
+
+func (_c *Service_RemoveRelation_Call) Run(run func(ctx context.Context, token string, domainID string, rel twins.Relation)) *Service_RemoveRelation_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 twins.Relation
+		if args[3] != nil {
+			arg3 = args[3].(twins.Relation)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_RemoveRelation_Call) Return(err error) *Service_RemoveRelation_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Service_RemoveRelation_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, rel twins.Relation) error) *Service_RemoveRelation_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveTemplate provides a mock function for the type Service
+func (_mock *Service) RemoveTemplate(ctx context.Context, token string, domainID string, templateID string) error {
+	ret := _mock.Called(ctx, token, domainID, templateID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveTemplate")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = returnFunc(ctx, token, domainID, templateID)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Service_RemoveTemplate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveTemplate'
+type Service_RemoveTemplate_Call struct {
+	*mock.Call
+}
+
+// RemoveTemplate is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - templateID string
+func (_e *Service_Expecter) RemoveTemplate(ctx interface{}, token interface{}, domainID interface{}, templateID interface{}) *Service_RemoveTemplate_Call {
+	return &Service_RemoveTemplate_Call{Call: _e.mock.On("RemoveTemplate", ctx, token, domainID, templateID)}
+}
+
+func (_c *Service_RemoveTemplate_Call) Run(run func(ctx context.Context, token string, domainID string, templateID string)) *Service_RemoveTemplate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_RemoveTemplate_Call) Return(err error) *Service_RemoveTemplate_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Service_RemoveTemplate_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, templateID string) error) *Service_RemoveTemplate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveTwin provides a mock function for the type Service
+func (_mock *Service) RemoveTwin(ctx context.Context, token string, domainID string, twinID string, cascade bool, revision int) error {
+	ret := _mock.Called(ctx, token, domainID, twinID, cascade, revision)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveTwin")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, bool, int) error); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID, cascade, revision)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Service_RemoveTwin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveTwin'
+type Service_RemoveTwin_Call struct {
+	*mock.Call
+}
+
+// RemoveTwin is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+//   - cascade bool
+//   - revision int
+func (_e *Service_Expecter) RemoveTwin(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}, cascade interface{}, revision interface{}) *Service_RemoveTwin_Call {
+	return &Service_RemoveTwin_Call{Call: _e.mock.On("RemoveTwin", ctx, token, domainID, twinID, cascade, revision)}
+}
+
+func (_c *Service_RemoveTwin_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string, cascade bool, revision int)) *Service_RemoveTwin_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 bool
+		if args[4] != nil {
+			arg4 = args[4].(bool)
+		}
+		var arg5 int
+		if args[5] != nil {
+			arg5 = args[5].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+			arg5,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_RemoveTwin_Call) Return(err error) *Service_RemoveTwin_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Service_RemoveTwin_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string, cascade bool, revision int) error) *Service_RemoveTwin_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReplayStates provides a mock function for the type Service
+func (_mock *Service) ReplayStates(ctx context.Context, token string, domainID string, twinID string, opts twins.ReplayOptions) (twins.Replay, error) {
+	ret := _mock.Called(ctx, token, domainID, twinID, opts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplayStates")
+	}
+
+	var r0 twins.Replay
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, twins.ReplayOptions) (twins.Replay, error)); ok {
+		return returnFunc(ctx, token, domainID, twinID, opts)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, twins.ReplayOptions) twins.Replay); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID, opts)
+	} else {
+		r0 = ret.Get(0).(twins.Replay)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, twins.ReplayOptions) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twinID, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ReplayStates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReplayStates'
+type Service_ReplayStates_Call struct {
+	*mock.Call
+}
+
+// ReplayStates is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+//   - opts twins.ReplayOptions
+func (_e *Service_Expecter) ReplayStates(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}, opts interface{}) *Service_ReplayStates_Call {
+	return &Service_ReplayStates_Call{Call: _e.mock.On("ReplayStates", ctx, token, domainID, twinID, opts)}
+}
+
+func (_c *Service_ReplayStates_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string, opts twins.ReplayOptions)) *Service_ReplayStates_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 twins.ReplayOptions
+		if args[4] != nil {
+			arg4 = args[4].(twins.ReplayOptions)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ReplayStates_Call) Return(replay twins.Replay, err error) *Service_ReplayStates_Call {
+	_c.Call.Return(replay, err)
+	return _c
+}
+
+func (_c *Service_ReplayStates_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string, opts twins.ReplayOptions) (twins.Replay, error)) *Service_ReplayStates_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RollbackDefinition provides a mock function for the type Service
+func (_mock *Service) RollbackDefinition(ctx context.Context, token string, domainID string, twinID string, definitionID int, revision int) (twins.Definition, error) {
+	ret := _mock.Called(ctx, token, domainID, twinID, definitionID, revision)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RollbackDefinition")
+	}
+
+	var r0 twins.Definition
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, int, int) (twins.Definition, error)); ok {
+		return returnFunc(ctx, token, domainID, twinID, definitionID, revision)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, int, int) twins.Definition); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID, definitionID, revision)
+	} else {
+		r0 = ret.Get(0).(twins.Definition)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, int, int) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twinID, definitionID, revision)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_RollbackDefinition_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RollbackDefinition'
+type Service_RollbackDefinition_Call struct {
+	*mock.Call
+}
+
+// RollbackDefinition is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+//   - definitionID int
+//   - revision int
+func (_e *Service_Expecter) RollbackDefinition(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}, definitionID interface{}, revision interface{}) *Service_RollbackDefinition_Call {
+	return &Service_RollbackDefinition_Call{Call: _e.mock.On("RollbackDefinition", ctx, token, domainID, twinID, definitionID, revision)}
+}
+
+func (_c *Service_RollbackDefinition_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string, definitionID int, revision int)) *Service_RollbackDefinition_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 int
+		if args[4] != nil {
+			arg4 = args[4].(int)
+		}
+		var arg5 int
+		if args[5] != nil {
+			arg5 = args[5].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+			arg5,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_RollbackDefinition_Call) Return(definition twins.Definition, err error) *Service_RollbackDefinition_Call {
+	_c.Call.Return(definition, err)
+	return _c
+}
+
+func (_c *Service_RollbackDefinition_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string, definitionID int, revision int) (twins.Definition, error)) *Service_RollbackDefinition_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RolloutTemplate provides a mock function for the type Service
+func (_mock *Service) RolloutTemplate(ctx context.Context, token string, domainID string, templateID string, bindings map[string]string) (twins.Rollout, error) {
+	ret := _mock.Called(ctx, token, domainID, templateID, bindings)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RolloutTemplate")
+	}
+
+	var r0 twins.Rollout
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, map[string]string) (twins.Rollout, error)); ok {
+		return returnFunc(ctx, token, domainID, templateID, bindings)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, map[string]string) twins.Rollout); ok {
+		r0 = returnFunc(ctx, token, domainID, templateID, bindings)
+	} else {
+		r0 = ret.Get(0).(twins.Rollout)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, map[string]string) error); ok {
+		r1 = returnFunc(ctx, token, domainID, templateID, bindings)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_RolloutTemplate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RolloutTemplate'
+type Service_RolloutTemplate_Call struct {
+	*mock.Call
+}
+
+// RolloutTemplate is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - templateID string
+//   - bindings map[string]string
+func (_e *Service_Expecter) RolloutTemplate(ctx interface{}, token interface{}, domainID interface{}, templateID interface{}, bindings interface{}) *Service_RolloutTemplate_Call {
+	return &Service_RolloutTemplate_Call{Call: _e.mock.On("RolloutTemplate", ctx, token, domainID, templateID, bindings)}
+}
+
+func (_c *Service_RolloutTemplate_Call) Run(run func(ctx context.Context, token string, domainID string, templateID string, bindings map[string]string)) *Service_RolloutTemplate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 map[string]string
+		if args[4] != nil {
+			arg4 = args[4].(map[string]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_RolloutTemplate_Call) Return(rollout twins.Rollout, err error) *Service_RolloutTemplate_Call {
+	_c.Call.Return(rollout, err)
+	return _c
+}
+
+func (_c *Service_RolloutTemplate_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, templateID string, bindings map[string]string) (twins.Rollout, error)) *Service_RolloutTemplate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveStates provides a mock function for the type Service
+func (_mock *Service) SaveStates(ctx context.Context, msg *messaging.Message) error {
+	ret := _mock.Called(ctx, msg)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveStates")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, *messaging.Message) error); ok {
+		r0 = returnFunc(ctx, msg)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Service_SaveStates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveStates'
+type Service_SaveStates_Call struct {
+	*mock.Call
+}
+
+// SaveStates is a helper method to define mock.On call
+//   - ctx context.Context
+//   - msg *messaging.Message
+func (_e *Service_Expecter) SaveStates(ctx interface{}, msg interface{}) *Service_SaveStates_Call {
+	return &Service_SaveStates_Call{Call: _e.mock.On("SaveStates", ctx, msg)}
+}
+
+func (_c *Service_SaveStates_Call) Run(run func(ctx context.Context, msg *messaging.Message)) *Service_SaveStates_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 *messaging.Message
+		if args[1] != nil {
+			arg1 = args[1].(*messaging.Message)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_SaveStates_Call) Return(err error) *Service_SaveStates_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Service_SaveStates_Call) RunAndReturn(run func(ctx context.Context, msg *messaging.Message) error) *Service_SaveStates_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ShareTwin provides a mock function for the type Service
+func (_mock *Service) ShareTwin(ctx context.Context, token string, domainID string, twinID string, role string, userIDs []string) error {
+	ret := _mock.Called(ctx, token, domainID, twinID, role, userIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ShareTwin")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, string, []string) error); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID, role, userIDs)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Service_ShareTwin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ShareTwin'
+type Service_ShareTwin_Call struct {
+	*mock.Call
+}
+
+// ShareTwin is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+//   - role string
+//   - userIDs []string
+func (_e *Service_Expecter) ShareTwin(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}, role interface{}, userIDs interface{}) *Service_ShareTwin_Call {
+	return &Service_ShareTwin_Call{Call: _e.mock.On("ShareTwin", ctx, token, domainID, twinID, role, userIDs)}
+}
+
+func (_c *Service_ShareTwin_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string, role string, userIDs []string)) *Service_ShareTwin_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 string
+		if args[4] != nil {
+			arg4 = args[4].(string)
+		}
+		var arg5 []string
+		if args[5] != nil {
+			arg5 = args[5].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+			arg5,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ShareTwin_Call) Return(err error) *Service_ShareTwin_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Service_ShareTwin_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string, role string, userIDs []string) error) *Service_ShareTwin_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StateAt provides a mock function for the type Service
+func (_mock *Service) StateAt(ctx context.Context, token string, domainID string, twinID string, at time.Time) (twins.State, twins.Definition, error) {
+	ret := _mock.Called(ctx, token, domainID, twinID, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StateAt")
+	}
+
+	var r0 twins.State
+	var r1 twins.Definition
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, time.Time) (twins.State, twins.Definition, error)); ok {
+		return returnFunc(ctx, token, domainID, twinID, at)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, time.Time) twins.State); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID, at)
+	} else {
+		r0 = ret.Get(0).(twins.State)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, time.Time) twins.Definition); ok {
+		r1 = returnFunc(ctx, token, domainID, twinID, at)
+	} else {
+		r1 = ret.Get(1).(twins.Definition)
+	}
+	if returnFunc, ok := ret.Get(2).(func(context.Context, string, string, string, time.Time) error); ok {
+		r2 = returnFunc(ctx, token, domainID, twinID, at)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// Service_StateAt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StateAt'
+type Service_StateAt_Call struct {
+	*mock.Call
+}
+
+// StateAt is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+//   - at time.Time
+func (_e *Service_Expecter) StateAt(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}, at interface{}) *Service_StateAt_Call {
+	return &Service_StateAt_Call{Call: _e.mock.On("StateAt", ctx, token, domainID, twinID, at)}
+}
+
+func (_c *Service_StateAt_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string, at time.Time)) *Service_StateAt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 time.Time
+		if args[4] != nil {
+			arg4 = args[4].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_StateAt_Call) Return(state twins.State, definition twins.Definition, err error) *Service_StateAt_Call {
+	_c.Call.Return(state, definition, err)
+	return _c
+}
+
+func (_c *Service_StateAt_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string, at time.Time) (twins.State, twins.Definition, error)) *Service_StateAt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StreamStates provides a mock function for the type Service
+func (_mock *Service) StreamStates(ctx context.Context, token string, domainID string, twinIDs []string, attributes []string) (<-chan twins.StreamEvent, error) {
+	ret := _mock.Called(ctx, token, domainID, twinIDs, attributes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamStates")
+	}
+
+	var r0 <-chan twins.StreamEvent
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []string, []string) (<-chan twins.StreamEvent, error)); ok {
+		return returnFunc(ctx, token, domainID, twinIDs, attributes)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []string, []string) <-chan twins.StreamEvent); ok {
+		r0 = returnFunc(ctx, token, domainID, twinIDs, attributes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan twins.StreamEvent)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, []string, []string) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twinIDs, attributes)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_StreamStates_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamStates'
+type Service_StreamStates_Call struct {
+	*mock.Call
+}
+
+// StreamStates is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinIDs []string
+//   - attributes []string
+func (_e *Service_Expecter) StreamStates(ctx interface{}, token interface{}, domainID interface{}, twinIDs interface{}, attributes interface{}) *Service_StreamStates_Call {
+	return &Service_StreamStates_Call{Call: _e.mock.On("StreamStates", ctx, token, domainID, twinIDs, attributes)}
+}
+
+func (_c *Service_StreamStates_Call) Run(run func(ctx context.Context, token string, domainID string, twinIDs []string, attributes []string)) *Service_StreamStates_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 []string
+		if args[3] != nil {
+			arg3 = args[3].([]string)
+		}
+		var arg4 []string
+		if args[4] != nil {
+			arg4 = args[4].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_StreamStates_Call) Return(streamEventCh <-chan twins.StreamEvent, err error) *Service_StreamStates_Call {
+	_c.Call.Return(streamEventCh, err)
+	return _c
+}
+
+func (_c *Service_StreamStates_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinIDs []string, attributes []string) (<-chan twins.StreamEvent, error)) *Service_StreamStates_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TraverseRelations provides a mock function for the type Service
+func (_mock *Service) TraverseRelations(ctx context.Context, token string, domainID string, twinID string, query twins.TraversalQuery) (twins.Traversal, error) {
+	ret := _mock.Called(ctx, token, domainID, twinID, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TraverseRelations")
+	}
+
+	var r0 twins.Traversal
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, twins.TraversalQuery) (twins.Traversal, error)); ok {
+		return returnFunc(ctx, token, domainID, twinID, query)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, twins.TraversalQuery) twins.Traversal); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID, query)
+	} else {
+		r0 = ret.Get(0).(twins.Traversal)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, twins.TraversalQuery) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twinID, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_TraverseRelations_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TraverseRelations'
+type Service_TraverseRelations_Call struct {
+	*mock.Call
+}
+
+// TraverseRelations is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+//   - query twins.TraversalQuery
+func (_e *Service_Expecter) TraverseRelations(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}, query interface{}) *Service_TraverseRelations_Call {
+	return &Service_TraverseRelations_Call{Call: _e.mock.On("TraverseRelations", ctx, token, domainID, twinID, query)}
+}
+
+func (_c *Service_TraverseRelations_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string, query twins.TraversalQuery)) *Service_TraverseRelations_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 twins.TraversalQuery
+		if args[4] != nil {
+			arg4 = args[4].(twins.TraversalQuery)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_TraverseRelations_Call) Return(traversal twins.Traversal, err error) *Service_TraverseRelations_Call {
+	_c.Call.Return(traversal, err)
+	return _c
+}
+
+func (_c *Service_TraverseRelations_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string, query twins.TraversalQuery) (twins.Traversal, error)) *Service_TraverseRelations_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UnshareTwin provides a mock function for the type Service
+func (_mock *Service) UnshareTwin(ctx context.Context, token string, domainID string, twinID string, userIDs []string) error {
+	ret := _mock.Called(ctx, token, domainID, twinID, userIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnshareTwin")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, []string) error); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID, userIDs)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Service_UnshareTwin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UnshareTwin'
+type Service_UnshareTwin_Call struct {
+	*mock.Call
+}
+
+// UnshareTwin is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+//   - userIDs []string
+func (_e *Service_Expecter) UnshareTwin(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}, userIDs interface{}) *Service_UnshareTwin_Call {
+	return &Service_UnshareTwin_Call{Call: _e.mock.On("UnshareTwin", ctx, token, domainID, twinID, userIDs)}
+}
+
+func (_c *Service_UnshareTwin_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string, userIDs []string)) *Service_UnshareTwin_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 []string
+		if args[4] != nil {
+			arg4 = args[4].([]string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_UnshareTwin_Call) Return(err error) *Service_UnshareTwin_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Service_UnshareTwin_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string, userIDs []string) error) *Service_UnshareTwin_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateDesiredState provides a mock function for the type Service
+func (_mock *Service) UpdateDesiredState(ctx context.Context, token string, domainID string, twinID string, payload map[string]interface{}) (twins.Delta, error) {
+	ret := _mock.Called(ctx, token, domainID, twinID, payload)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateDesiredState")
+	}
+
+	var r0 twins.Delta
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, map[string]interface{}) (twins.Delta, error)); ok {
+		return returnFunc(ctx, token, domainID, twinID, payload)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, map[string]interface{}) twins.Delta); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(twins.Delta)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, map[string]interface{}) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twinID, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_UpdateDesiredState_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateDesiredState'
+type Service_UpdateDesiredState_Call struct {
+	*mock.Call
+}
+
+// UpdateDesiredState is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+//   - payload map[string]interface{}
+func (_e *Service_Expecter) UpdateDesiredState(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}, payload interface{}) *Service_UpdateDesiredState_Call {
+	return &Service_UpdateDesiredState_Call{Call: _e.mock.On("UpdateDesiredState", ctx, token, domainID, twinID, payload)}
+}
+
+func (_c *Service_UpdateDesiredState_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string, payload map[string]interface{})) *Service_UpdateDesiredState_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 map[string]interface{}
+		if args[4] != nil {
+			arg4 = args[4].(map[string]interface{})
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_UpdateDesiredState_Call) Return(delta twins.Delta, err error) *Service_UpdateDesiredState_Call {
+	_c.Call.Return(delta, err)
+	return _c
+}
+
+func (_c *Service_UpdateDesiredState_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string, payload map[string]interface{}) (twins.Delta, error)) *Service_UpdateDesiredState_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateTemplate provides a mock function for the type Service
+func (_mock *Service) UpdateTemplate(ctx context.Context, token string, domainID string, tmpl twins.Template) error {
+	ret := _mock.Called(ctx, token, domainID, tmpl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateTemplate")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, twins.Template) error); ok {
+		r0 = returnFunc(ctx, token, domainID, tmpl)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Service_UpdateTemplate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateTemplate'
+type Service_UpdateTemplate_Call struct {
+	*mock.Call
+}
+
+// UpdateTemplate is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - tmpl twins.Template
+func (_e *Service_Expecter) UpdateTemplate(ctx interface{}, token interface{}, domainID interface{}, tmpl interface{}) *Service_UpdateTemplate_Call {
+	return &Service_UpdateTemplate_Call{Call: _e.mock.On("UpdateTemplate", ctx, token, domainID, tmpl)}
+}
+
+func (_c *Service_UpdateTemplate_Call) Run(run func(ctx context.Context, token string, domainID string, tmpl twins.Template)) *Service_UpdateTemplate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 twins.Template
+		if args[3] != nil {
+			arg3 = args[3].(twins.Template)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_UpdateTemplate_Call) Return(err error) *Service_UpdateTemplate_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Service_UpdateTemplate_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, tmpl twins.Template) error) *Service_UpdateTemplate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateTwin provides a mock function for the type Service
+func (_mock *Service) UpdateTwin(ctx context.Context, token string, domainID string, twin twins.Twin, def twins.Definition, revision int) error {
+	ret := _mock.Called(ctx, token, domainID, twin, def, revision)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateTwin")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, twins.Twin, twins.Definition, int) error); ok {
+		r0 = returnFunc(ctx, token, domainID, twin, def, revision)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// Service_UpdateTwin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateTwin'
+type Service_UpdateTwin_Call struct {
+	*mock.Call
+}
+
+// UpdateTwin is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twin twins.Twin
+//   - def twins.Definition
+//   - revision int
+func (_e *Service_Expecter) UpdateTwin(ctx interface{}, token interface{}, domainID interface{}, twin interface{}, def interface{}, revision interface{}) *Service_UpdateTwin_Call {
+	return &Service_UpdateTwin_Call{Call: _e.mock.On("UpdateTwin", ctx, token, domainID, twin, def, revision)}
+}
+
+func (_c *Service_UpdateTwin_Call) Run(run func(ctx context.Context, token string, domainID string, twin twins.Twin, def twins.Definition, revision int)) *Service_UpdateTwin_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 twins.Twin
+		if args[3] != nil {
+			arg3 = args[3].(twins.Twin)
+		}
+		var arg4 twins.Definition
+		if args[4] != nil {
+			arg4 = args[4].(twins.Definition)
+		}
+		var arg5 int
+		if args[5] != nil {
+			arg5 = args[5].(int)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+			arg5,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_UpdateTwin_Call) Return(err error) *Service_UpdateTwin_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *Service_UpdateTwin_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twin twins.Twin, def twins.Definition, revision int) error) *Service_UpdateTwin_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ViewCompositeState provides a mock function for the type Service
+func (_mock *Service) ViewCompositeState(ctx context.Context, token string, domainID string, twinID string) (twins.CompositeState, error) {
+	ret := _mock.Called(ctx, token, domainID, twinID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ViewCompositeState")
+	}
+
+	var r0 twins.CompositeState
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (twins.CompositeState, error)); ok {
+		return returnFunc(ctx, token, domainID, twinID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) twins.CompositeState); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID)
+	} else {
+		r0 = ret.Get(0).(twins.CompositeState)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twinID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ViewCompositeState_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewCompositeState'
+type Service_ViewCompositeState_Call struct {
+	*mock.Call
+}
+
+// ViewCompositeState is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+func (_e *Service_Expecter) ViewCompositeState(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}) *Service_ViewCompositeState_Call {
+	return &Service_ViewCompositeState_Call{Call: _e.mock.On("ViewCompositeState", ctx, token, domainID, twinID)}
+}
+
+func (_c *Service_ViewCompositeState_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string)) *Service_ViewCompositeState_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ViewCompositeState_Call) Return(compositeState twins.CompositeState, err error) *Service_ViewCompositeState_Call {
+	_c.Call.Return(compositeState, err)
+	return _c
+}
+
+func (_c *Service_ViewCompositeState_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string) (twins.CompositeState, error)) *Service_ViewCompositeState_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ViewDelta provides a mock function for the type Service
+func (_mock *Service) ViewDelta(ctx context.Context, token string, domainID string, twinID string) (twins.Delta, error) {
+	ret := _mock.Called(ctx, token, domainID, twinID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ViewDelta")
+	}
+
+	var r0 twins.Delta
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (twins.Delta, error)); ok {
+		return returnFunc(ctx, token, domainID, twinID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) twins.Delta); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(twins.Delta)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twinID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ViewDelta_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewDelta'
+type Service_ViewDelta_Call struct {
+	*mock.Call
+}
+
+// ViewDelta is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+func (_e *Service_Expecter) ViewDelta(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}) *Service_ViewDelta_Call {
+	return &Service_ViewDelta_Call{Call: _e.mock.On("ViewDelta", ctx, token, domainID, twinID)}
+}
+
+func (_c *Service_ViewDelta_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string)) *Service_ViewDelta_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ViewDelta_Call) Return(delta twins.Delta, err error) *Service_ViewDelta_Call {
+	_c.Call.Return(delta, err)
+	return _c
+}
+
+func (_c *Service_ViewDelta_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string) (twins.Delta, error)) *Service_ViewDelta_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ViewDesiredState provides a mock function for the type Service
+func (_mock *Service) ViewDesiredState(ctx context.Context, token string, domainID string, twinID string) (twins.DesiredState, error) {
+	ret := _mock.Called(ctx, token, domainID, twinID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ViewDesiredState")
+	}
+
+	var r0 twins.DesiredState
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (twins.DesiredState, error)); ok {
+		return returnFunc(ctx, token, domainID, twinID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) twins.DesiredState); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID)
+	} else {
+		r0 = ret.Get(0).(twins.DesiredState)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twinID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ViewDesiredState_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewDesiredState'
+type Service_ViewDesiredState_Call struct {
+	*mock.Call
+}
+
+// ViewDesiredState is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+func (_e *Service_Expecter) ViewDesiredState(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}) *Service_ViewDesiredState_Call {
+	return &Service_ViewDesiredState_Call{Call: _e.mock.On("ViewDesiredState", ctx, token, domainID, twinID)}
+}
+
+func (_c *Service_ViewDesiredState_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string)) *Service_ViewDesiredState_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ViewDesiredState_Call) Return(desiredState twins.DesiredState, err error) *Service_ViewDesiredState_Call {
+	_c.Call.Return(desiredState, err)
+	return _c
+}
+
+func (_c *Service_ViewDesiredState_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string) (twins.DesiredState, error)) *Service_ViewDesiredState_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ViewSubtree provides a mock function for the type Service
+func (_mock *Service) ViewSubtree(ctx context.Context, token string, domainID string, twinID string) (twins.TwinNode, error) {
+	ret := _mock.Called(ctx, token, domainID, twinID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ViewSubtree")
+	}
+
+	var r0 twins.TwinNode
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (twins.TwinNode, error)); ok {
+		return returnFunc(ctx, token, domainID, twinID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) twins.TwinNode); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID)
+	} else {
+		r0 = ret.Get(0).(twins.TwinNode)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twinID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ViewSubtree_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewSubtree'
+type Service_ViewSubtree_Call struct {
+	*mock.Call
+}
+
+// ViewSubtree is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+func (_e *Service_Expecter) ViewSubtree(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}) *Service_ViewSubtree_Call {
+	return &Service_ViewSubtree_Call{Call: _e.mock.On("ViewSubtree", ctx, token, domainID, twinID)}
+}
+
+func (_c *Service_ViewSubtree_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string)) *Service_ViewSubtree_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ViewSubtree_Call) Return(twinNode twins.TwinNode, err error) *Service_ViewSubtree_Call {
+	_c.Call.Return(twinNode, err)
+	return _c
+}
+
+func (_c *Service_ViewSubtree_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string) (twins.TwinNode, error)) *Service_ViewSubtree_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ViewTemplate provides a mock function for the type Service
+func (_mock *Service) ViewTemplate(ctx context.Context, token string, domainID string, templateID string) (twins.Template, error) {
+	ret := _mock.Called(ctx, token, domainID, templateID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ViewTemplate")
+	}
+
+	var r0 twins.Template
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (twins.Template, error)); ok {
+		return returnFunc(ctx, token, domainID, templateID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) twins.Template); ok {
+		r0 = returnFunc(ctx, token, domainID, templateID)
+	} else {
+		r0 = ret.Get(0).(twins.Template)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, token, domainID, templateID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ViewTemplate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewTemplate'
+type Service_ViewTemplate_Call struct {
+	*mock.Call
+}
+
+// ViewTemplate is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - templateID string
+func (_e *Service_Expecter) ViewTemplate(ctx interface{}, token interface{}, domainID interface{}, templateID interface{}) *Service_ViewTemplate_Call {
+	return &Service_ViewTemplate_Call{Call: _e.mock.On("ViewTemplate", ctx, token, domainID, templateID)}
+}
+
+func (_c *Service_ViewTemplate_Call) Run(run func(ctx context.Context, token string, domainID string, templateID string)) *Service_ViewTemplate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *Service_ViewTemplate_Call) Return(template twins.Template, err error) *Service_ViewTemplate_Call {
+	_c.Call.Return(template, err)
+	return _c
+}
+
+func (_c *Service_ViewTemplate_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, templateID string) (twins.Template, error)) *Service_ViewTemplate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ViewTwin provides a mock function for the type Service
+func (_mock *Service) ViewTwin(ctx context.Context, token string, domainID string, twinID string) (twins.Twin, error) {
+	ret := _mock.Called(ctx, token, domainID, twinID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ViewTwin")
+	}
+
+	var r0 twins.Twin
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) (twins.Twin, error)); ok {
+		return returnFunc(ctx, token, domainID, twinID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string) twins.Twin); ok {
+		r0 = returnFunc(ctx, token, domainID, twinID)
+	} else {
+		r0 = ret.Get(0).(twins.Twin)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = returnFunc(ctx, token, domainID, twinID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// Service_ViewTwin_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ViewTwin'
+type Service_ViewTwin_Call struct {
+	*mock.Call
+}
+
+// ViewTwin is a helper method to define mock.On call
+//   - ctx context.Context
+//   - token string
+//   - domainID string
+//   - twinID string
+func (_e *Service_Expecter) ViewTwin(ctx interface{}, token interface{}, domainID interface{}, twinID interface{}) *Service_ViewTwin_Call {
+	return &Service_ViewTwin_Call{Call: _e.mock.On("ViewTwin", ctx, token, domainID, twinID)}
+}
+
+func (_c *Service_ViewTwin_Call) Run(run func(ctx context.Context, token string, domainID string, twinID string)) *Service_ViewTwin_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
 		)
 	})
 	return _c
@@ -550,7 +3247,7 @@ func (_c *Service_ViewTwin_Call) Return(tw twins.Twin, err error) *Service_ViewT
 	return _c
 }
 
-func (_c *Service_ViewTwin_Call) RunAndReturn(run func(ctx context.Context, token string, twinID string) (twins.Twin, error)) *Service_ViewTwin_Call {
+func (_c *Service_ViewTwin_Call) RunAndReturn(run func(ctx context.Context, token string, domainID string, twinID string) (twins.Twin, error)) *Service_ViewTwin_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -9,6 +9,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/absmach/supermq-contrib/twins"
 	mock "github.com/stretchr/testify/mock"
@@ -41,146 +42,736 @@ func (_m *StateRepository) EXPECT() *StateRepository_Expecter {
 	return &StateRepository_Expecter{mock: &_m.Mock}
 }
 
+// Compact provides a mock function for the type StateRepository
+func (_mock *StateRepository) Compact(ctx context.Context, twinID string, before time.Time, bucket time.Duration) (int64, error) {
+	ret := _mock.Called(ctx, twinID, before, bucket)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Compact")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Duration) (int64, error)); ok {
+		return returnFunc(ctx, twinID, before, bucket)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Duration) int64); ok {
+		r0 = returnFunc(ctx, twinID, before, bucket)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, time.Time, time.Duration) error); ok {
+		r1 = returnFunc(ctx, twinID, before, bucket)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// StateRepository_Compact_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Compact'
+type StateRepository_Compact_Call struct {
+	*mock.Call
+}
+
+// Compact is a helper method to define mock.On call
+//   - ctx context.Context
+//   - twinID string
+//   - before time.Time
+//   - bucket time.Duration
+func (_e *StateRepository_Expecter) Compact(ctx interface{}, twinID interface{}, before interface{}, bucket interface{}) *StateRepository_Compact_Call {
+	return &StateRepository_Compact_Call{Call: _e.mock.On("Compact", ctx, twinID, before, bucket)}
+}
+
+func (_c *StateRepository_Compact_Call) Run(run func(ctx context.Context, twinID string, before time.Time, bucket time.Duration)) *StateRepository_Compact_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 time.Time
+		if args[2] != nil {
+			arg2 = args[2].(time.Time)
+		}
+		var arg3 time.Duration
+		if args[3] != nil {
+			arg3 = args[3].(time.Duration)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+		)
+	})
+	return _c
+}
+
+func (_c *StateRepository_Compact_Call) Return(n int64, err error) *StateRepository_Compact_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *StateRepository_Compact_Call) RunAndReturn(run func(ctx context.Context, twinID string, before time.Time, bucket time.Duration) (int64, error)) *StateRepository_Compact_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Count provides a mock function for the type StateRepository
 func (_mock *StateRepository) Count(ctx context.Context, twin twins.Twin) (int64, error) {
 	ret := _mock.Called(ctx, twin)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Count")
+		panic("no return value specified for Count")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, twins.Twin) (int64, error)); ok {
+		return returnFunc(ctx, twin)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, twins.Twin) int64); ok {
+		r0 = returnFunc(ctx, twin)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, twins.Twin) error); ok {
+		r1 = returnFunc(ctx, twin)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// StateRepository_Count_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Count'
+type StateRepository_Count_Call struct {
+	*mock.Call
+}
+
+// Count is a helper method to define mock.On call
+//   - ctx context.Context
+//   - twin twins.Twin
+func (_e *StateRepository_Expecter) Count(ctx interface{}, twin interface{}) *StateRepository_Count_Call {
+	return &StateRepository_Count_Call{Call: _e.mock.On("Count", ctx, twin)}
+}
+
+func (_c *StateRepository_Count_Call) Run(run func(ctx context.Context, twin twins.Twin)) *StateRepository_Count_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 twins.Twin
+		if args[1] != nil {
+			arg1 = args[1].(twins.Twin)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *StateRepository_Count_Call) Return(n int64, err error) *StateRepository_Count_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *StateRepository_Count_Call) RunAndReturn(run func(ctx context.Context, twin twins.Twin) (int64, error)) *StateRepository_Count_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveAfter provides a mock function for the type StateRepository
+func (_mock *StateRepository) RemoveAfter(ctx context.Context, twinID string, id int64) (int64, error) {
+	ret := _mock.Called(ctx, twinID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveAfter")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int64) (int64, error)); ok {
+		return returnFunc(ctx, twinID, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int64) int64); ok {
+		r0 = returnFunc(ctx, twinID, id)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = returnFunc(ctx, twinID, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// StateRepository_RemoveAfter_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveAfter'
+type StateRepository_RemoveAfter_Call struct {
+	*mock.Call
+}
+
+// RemoveAfter is a helper method to define mock.On call
+//   - ctx context.Context
+//   - twinID string
+//   - id int64
+func (_e *StateRepository_Expecter) RemoveAfter(ctx interface{}, twinID interface{}, id interface{}) *StateRepository_RemoveAfter_Call {
+	return &StateRepository_RemoveAfter_Call{Call: _e.mock.On("RemoveAfter", ctx, twinID, id)}
+}
+
+func (_c *StateRepository_RemoveAfter_Call) Run(run func(ctx context.Context, twinID string, id int64)) *StateRepository_RemoveAfter_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 int64
+		if args[2] != nil {
+			arg2 = args[2].(int64)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *StateRepository_RemoveAfter_Call) Return(n int64, err error) *StateRepository_RemoveAfter_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *StateRepository_RemoveAfter_Call) RunAndReturn(run func(ctx context.Context, twinID string, id int64) (int64, error)) *StateRepository_RemoveAfter_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveBefore provides a mock function for the type StateRepository
+func (_mock *StateRepository) RemoveBefore(ctx context.Context, twinID string, before time.Time) (int64, error) {
+	ret := _mock.Called(ctx, twinID, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveBefore")
+	}
+
+	var r0 int64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time) (int64, error)); ok {
+		return returnFunc(ctx, twinID, before)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time) int64); ok {
+		r0 = returnFunc(ctx, twinID, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = returnFunc(ctx, twinID, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// StateRepository_RemoveBefore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveBefore'
+type StateRepository_RemoveBefore_Call struct {
+	*mock.Call
+}
+
+// RemoveBefore is a helper method to define mock.On call
+//   - ctx context.Context
+//   - twinID string
+//   - before time.Time
+func (_e *StateRepository_Expecter) RemoveBefore(ctx interface{}, twinID interface{}, before interface{}) *StateRepository_RemoveBefore_Call {
+	return &StateRepository_RemoveBefore_Call{Call: _e.mock.On("RemoveBefore", ctx, twinID, before)}
+}
+
+func (_c *StateRepository_RemoveBefore_Call) Run(run func(ctx context.Context, twinID string, before time.Time)) *StateRepository_RemoveBefore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 time.Time
+		if args[2] != nil {
+			arg2 = args[2].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *StateRepository_RemoveBefore_Call) Return(n int64, err error) *StateRepository_RemoveBefore_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *StateRepository_RemoveBefore_Call) RunAndReturn(run func(ctx context.Context, twinID string, before time.Time) (int64, error)) *StateRepository_RemoveBefore_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveActiveAlarms provides a mock function for the type StateRepository
+func (_mock *StateRepository) RetrieveActiveAlarms(ctx context.Context, twinID string) ([]twins.Alarm, error) {
+	ret := _mock.Called(ctx, twinID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveActiveAlarms")
+	}
+
+	var r0 []twins.Alarm
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]twins.Alarm, error)); ok {
+		return returnFunc(ctx, twinID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []twins.Alarm); ok {
+		r0 = returnFunc(ctx, twinID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]twins.Alarm)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, twinID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// StateRepository_RetrieveActiveAlarms_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveActiveAlarms'
+type StateRepository_RetrieveActiveAlarms_Call struct {
+	*mock.Call
+}
+
+// RetrieveActiveAlarms is a helper method to define mock.On call
+//   - ctx context.Context
+//   - twinID string
+func (_e *StateRepository_Expecter) RetrieveActiveAlarms(ctx interface{}, twinID interface{}) *StateRepository_RetrieveActiveAlarms_Call {
+	return &StateRepository_RetrieveActiveAlarms_Call{Call: _e.mock.On("RetrieveActiveAlarms", ctx, twinID)}
+}
+
+func (_c *StateRepository_RetrieveActiveAlarms_Call) Run(run func(ctx context.Context, twinID string)) *StateRepository_RetrieveActiveAlarms_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *StateRepository_RetrieveActiveAlarms_Call) Return(alarms []twins.Alarm, err error) *StateRepository_RetrieveActiveAlarms_Call {
+	_c.Call.Return(alarms, err)
+	return _c
+}
+
+func (_c *StateRepository_RetrieveActiveAlarms_Call) RunAndReturn(run func(ctx context.Context, twinID string) ([]twins.Alarm, error)) *StateRepository_RetrieveActiveAlarms_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveAlarms provides a mock function for the type StateRepository
+func (_mock *StateRepository) RetrieveAlarms(ctx context.Context, offset uint64, limit uint64, twinID string, filter twins.AlarmFilter) (twins.AlarmsPage, error) {
+	ret := _mock.Called(ctx, offset, limit, twinID, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveAlarms")
+	}
+
+	var r0 twins.AlarmsPage
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uint64, uint64, string, twins.AlarmFilter) (twins.AlarmsPage, error)); ok {
+		return returnFunc(ctx, offset, limit, twinID, filter)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uint64, uint64, string, twins.AlarmFilter) twins.AlarmsPage); ok {
+		r0 = returnFunc(ctx, offset, limit, twinID, filter)
+	} else {
+		r0 = ret.Get(0).(twins.AlarmsPage)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uint64, uint64, string, twins.AlarmFilter) error); ok {
+		r1 = returnFunc(ctx, offset, limit, twinID, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// StateRepository_RetrieveAlarms_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveAlarms'
+type StateRepository_RetrieveAlarms_Call struct {
+	*mock.Call
+}
+
+// RetrieveAlarms is a helper method to define mock.On call
+//   - ctx context.Context
+//   - offset uint64
+//   - limit uint64
+//   - twinID string
+//   - filter twins.AlarmFilter
+func (_e *StateRepository_Expecter) RetrieveAlarms(ctx interface{}, offset interface{}, limit interface{}, twinID interface{}, filter interface{}) *StateRepository_RetrieveAlarms_Call {
+	return &StateRepository_RetrieveAlarms_Call{Call: _e.mock.On("RetrieveAlarms", ctx, offset, limit, twinID, filter)}
+}
+
+func (_c *StateRepository_RetrieveAlarms_Call) Run(run func(ctx context.Context, offset uint64, limit uint64, twinID string, filter twins.AlarmFilter)) *StateRepository_RetrieveAlarms_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uint64
+		if args[1] != nil {
+			arg1 = args[1].(uint64)
+		}
+		var arg2 uint64
+		if args[2] != nil {
+			arg2 = args[2].(uint64)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 twins.AlarmFilter
+		if args[4] != nil {
+			arg4 = args[4].(twins.AlarmFilter)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *StateRepository_RetrieveAlarms_Call) Return(alarmsPage twins.AlarmsPage, err error) *StateRepository_RetrieveAlarms_Call {
+	_c.Call.Return(alarmsPage, err)
+	return _c
+}
+
+func (_c *StateRepository_RetrieveAlarms_Call) RunAndReturn(run func(ctx context.Context, offset uint64, limit uint64, twinID string, filter twins.AlarmFilter) (twins.AlarmsPage, error)) *StateRepository_RetrieveAlarms_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveAll provides a mock function for the type StateRepository
+func (_mock *StateRepository) RetrieveAll(ctx context.Context, offset uint64, limit uint64, twinID string, filter twins.StateFilter) (twins.StatesPage, error) {
+	ret := _mock.Called(ctx, offset, limit, twinID, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveAll")
+	}
+
+	var r0 twins.StatesPage
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uint64, uint64, string, twins.StateFilter) (twins.StatesPage, error)); ok {
+		return returnFunc(ctx, offset, limit, twinID, filter)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uint64, uint64, string, twins.StateFilter) twins.StatesPage); ok {
+		r0 = returnFunc(ctx, offset, limit, twinID, filter)
+	} else {
+		r0 = ret.Get(0).(twins.StatesPage)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uint64, uint64, string, twins.StateFilter) error); ok {
+		r1 = returnFunc(ctx, offset, limit, twinID, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// StateRepository_RetrieveAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveAll'
+type StateRepository_RetrieveAll_Call struct {
+	*mock.Call
+}
+
+// RetrieveAll is a helper method to define mock.On call
+//   - ctx context.Context
+//   - offset uint64
+//   - limit uint64
+//   - twinID string
+//   - filter twins.StateFilter
+func (_e *StateRepository_Expecter) RetrieveAll(ctx interface{}, offset interface{}, limit interface{}, twinID interface{}, filter interface{}) *StateRepository_RetrieveAll_Call {
+	return &StateRepository_RetrieveAll_Call{Call: _e.mock.On("RetrieveAll", ctx, offset, limit, twinID, filter)}
+}
+
+func (_c *StateRepository_RetrieveAll_Call) Run(run func(ctx context.Context, offset uint64, limit uint64, twinID string, filter twins.StateFilter)) *StateRepository_RetrieveAll_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uint64
+		if args[1] != nil {
+			arg1 = args[1].(uint64)
+		}
+		var arg2 uint64
+		if args[2] != nil {
+			arg2 = args[2].(uint64)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 twins.StateFilter
+		if args[4] != nil {
+			arg4 = args[4].(twins.StateFilter)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
+		)
+	})
+	return _c
+}
+
+func (_c *StateRepository_RetrieveAll_Call) Return(statesPage twins.StatesPage, err error) *StateRepository_RetrieveAll_Call {
+	_c.Call.Return(statesPage, err)
+	return _c
+}
+
+func (_c *StateRepository_RetrieveAll_Call) RunAndReturn(run func(ctx context.Context, offset uint64, limit uint64, twinID string, filter twins.StateFilter) (twins.StatesPage, error)) *StateRepository_RetrieveAll_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveAt provides a mock function for the type StateRepository
+func (_mock *StateRepository) RetrieveAt(ctx context.Context, twinID string, at time.Time) (twins.State, error) {
+	ret := _mock.Called(ctx, twinID, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveAt")
+	}
+
+	var r0 twins.State
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time) (twins.State, error)); ok {
+		return returnFunc(ctx, twinID, at)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time) twins.State); ok {
+		r0 = returnFunc(ctx, twinID, at)
+	} else {
+		r0 = ret.Get(0).(twins.State)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = returnFunc(ctx, twinID, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// StateRepository_RetrieveAt_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveAt'
+type StateRepository_RetrieveAt_Call struct {
+	*mock.Call
+}
+
+// RetrieveAt is a helper method to define mock.On call
+//   - ctx context.Context
+//   - twinID string
+//   - at time.Time
+func (_e *StateRepository_Expecter) RetrieveAt(ctx interface{}, twinID interface{}, at interface{}) *StateRepository_RetrieveAt_Call {
+	return &StateRepository_RetrieveAt_Call{Call: _e.mock.On("RetrieveAt", ctx, twinID, at)}
+}
+
+func (_c *StateRepository_RetrieveAt_Call) Run(run func(ctx context.Context, twinID string, at time.Time)) *StateRepository_RetrieveAt_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 time.Time
+		if args[2] != nil {
+			arg2 = args[2].(time.Time)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *StateRepository_RetrieveAt_Call) Return(state twins.State, err error) *StateRepository_RetrieveAt_Call {
+	_c.Call.Return(state, err)
+	return _c
+}
+
+func (_c *StateRepository_RetrieveAt_Call) RunAndReturn(run func(ctx context.Context, twinID string, at time.Time) (twins.State, error)) *StateRepository_RetrieveAt_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveByID provides a mock function for the type StateRepository
+func (_mock *StateRepository) RetrieveByID(ctx context.Context, twinID string, id int64) (twins.State, error) {
+	ret := _mock.Called(ctx, twinID, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveByID")
 	}
 
-	var r0 int64
+	var r0 twins.State
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, twins.Twin) (int64, error)); ok {
-		return returnFunc(ctx, twin)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int64) (twins.State, error)); ok {
+		return returnFunc(ctx, twinID, id)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, twins.Twin) int64); ok {
-		r0 = returnFunc(ctx, twin)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, int64) twins.State); ok {
+		r0 = returnFunc(ctx, twinID, id)
 	} else {
-		r0 = ret.Get(0).(int64)
+		r0 = ret.Get(0).(twins.State)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, twins.Twin) error); ok {
-		r1 = returnFunc(ctx, twin)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = returnFunc(ctx, twinID, id)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// StateRepository_Count_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Count'
-type StateRepository_Count_Call struct {
+// StateRepository_RetrieveByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveByID'
+type StateRepository_RetrieveByID_Call struct {
 	*mock.Call
 }
 
-// Count is a helper method to define mock.On call
+// RetrieveByID is a helper method to define mock.On call
 //   - ctx context.Context
-//   - twin twins.Twin
-func (_e *StateRepository_Expecter) Count(ctx interface{}, twin interface{}) *StateRepository_Count_Call {
-	return &StateRepository_Count_Call{Call: _e.mock.On("Count", ctx, twin)}
+//   - twinID string
+//   - id int64
+func (_e *StateRepository_Expecter) RetrieveByID(ctx interface{}, twinID interface{}, id interface{}) *StateRepository_RetrieveByID_Call {
+	return &StateRepository_RetrieveByID_Call{Call: _e.mock.On("RetrieveByID", ctx, twinID, id)}
 }
 
-func (_c *StateRepository_Count_Call) Run(run func(ctx context.Context, twin twins.Twin)) *StateRepository_Count_Call {
+func (_c *StateRepository_RetrieveByID_Call) Run(run func(ctx context.Context, twinID string, id int64)) *StateRepository_RetrieveByID_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 twins.Twin
+		var arg1 string
 		if args[1] != nil {
-			arg1 = args[1].(twins.Twin)
+			arg1 = args[1].(string)
+		}
+		var arg2 int64
+		if args[2] != nil {
+			arg2 = args[2].(int64)
 		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *StateRepository_Count_Call) Return(n int64, err error) *StateRepository_Count_Call {
-	_c.Call.Return(n, err)
+func (_c *StateRepository_RetrieveByID_Call) Return(state twins.State, err error) *StateRepository_RetrieveByID_Call {
+	_c.Call.Return(state, err)
 	return _c
 }
 
-func (_c *StateRepository_Count_Call) RunAndReturn(run func(ctx context.Context, twin twins.Twin) (int64, error)) *StateRepository_Count_Call {
+func (_c *StateRepository_RetrieveByID_Call) RunAndReturn(run func(ctx context.Context, twinID string, id int64) (twins.State, error)) *StateRepository_RetrieveByID_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// RetrieveAll provides a mock function for the type StateRepository
-func (_mock *StateRepository) RetrieveAll(ctx context.Context, offset uint64, limit uint64, twinID string) (twins.StatesPage, error) {
-	ret := _mock.Called(ctx, offset, limit, twinID)
+// RetrieveDesired provides a mock function for the type StateRepository
+func (_mock *StateRepository) RetrieveDesired(ctx context.Context, twinID string) (twins.DesiredState, error) {
+	ret := _mock.Called(ctx, twinID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for RetrieveAll")
+		panic("no return value specified for RetrieveDesired")
 	}
 
-	var r0 twins.StatesPage
+	var r0 twins.DesiredState
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, uint64, uint64, string) (twins.StatesPage, error)); ok {
-		return returnFunc(ctx, offset, limit, twinID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (twins.DesiredState, error)); ok {
+		return returnFunc(ctx, twinID)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, uint64, uint64, string) twins.StatesPage); ok {
-		r0 = returnFunc(ctx, offset, limit, twinID)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) twins.DesiredState); ok {
+		r0 = returnFunc(ctx, twinID)
 	} else {
-		r0 = ret.Get(0).(twins.StatesPage)
+		r0 = ret.Get(0).(twins.DesiredState)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, uint64, uint64, string) error); ok {
-		r1 = returnFunc(ctx, offset, limit, twinID)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, twinID)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// StateRepository_RetrieveAll_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveAll'
-type StateRepository_RetrieveAll_Call struct {
+// StateRepository_RetrieveDesired_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveDesired'
+type StateRepository_RetrieveDesired_Call struct {
 	*mock.Call
 }
 
-// RetrieveAll is a helper method to define mock.On call
+// RetrieveDesired is a helper method to define mock.On call
 //   - ctx context.Context
-//   - offset uint64
-//   - limit uint64
 //   - twinID string
-func (_e *StateRepository_Expecter) RetrieveAll(ctx interface{}, offset interface{}, limit interface{}, twinID interface{}) *StateRepository_RetrieveAll_Call {
-	return &StateRepository_RetrieveAll_Call{Call: _e.mock.On("RetrieveAll", ctx, offset, limit, twinID)}
+func (_e *StateRepository_Expecter) RetrieveDesired(ctx interface{}, twinID interface{}) *StateRepository_RetrieveDesired_Call {
+	return &StateRepository_RetrieveDesired_Call{Call: _e.mock.On("RetrieveDesired", ctx, twinID)}
 }
 
-func (_c *StateRepository_RetrieveAll_Call) Run(run func(ctx context.Context, offset uint64, limit uint64, twinID string)) *StateRepository_RetrieveAll_Call {
+func (_c *StateRepository_RetrieveDesired_Call) Run(run func(ctx context.Context, twinID string)) *StateRepository_RetrieveDesired_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 uint64
+		var arg1 string
 		if args[1] != nil {
-			arg1 = args[1].(uint64)
-		}
-		var arg2 uint64
-		if args[2] != nil {
-			arg2 = args[2].(uint64)
-		}
-		var arg3 string
-		if args[3] != nil {
-			arg3 = args[3].(string)
+			arg1 = args[1].(string)
 		}
 		run(
 			arg0,
 			arg1,
-			arg2,
-			arg3,
 		)
 	})
 	return _c
 }
 
-func (_c *StateRepository_RetrieveAll_Call) Return(statesPage twins.StatesPage, err error) *StateRepository_RetrieveAll_Call {
-	_c.Call.Return(statesPage, err)
+func (_c *StateRepository_RetrieveDesired_Call) Return(desiredState twins.DesiredState, err error) *StateRepository_RetrieveDesired_Call {
+	_c.Call.Return(desiredState, err)
 	return _c
 }
 
-func (_c *StateRepository_RetrieveAll_Call) RunAndReturn(run func(ctx context.Context, offset uint64, limit uint64, twinID string) (twins.StatesPage, error)) *StateRepository_RetrieveAll_Call {
+func (_c *StateRepository_RetrieveDesired_Call) RunAndReturn(run func(ctx context.Context, twinID string) (twins.DesiredState, error)) *StateRepository_RetrieveDesired_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -252,16 +843,22 @@ func (_c *StateRepository_RetrieveLast_Call) RunAndReturn(run func(ctx context.C
 }
 
 // Save provides a mock function for the type StateRepository
-func (_mock *StateRepository) Save(ctx context.Context, state twins.State) error {
-	ret := _mock.Called(ctx, state)
+func (_mock *StateRepository) Save(ctx context.Context, states ...twins.State) error {
+	var tmpRet mock.Arguments
+	if len(states) > 0 {
+		tmpRet = _mock.Called(ctx, states)
+	} else {
+		tmpRet = _mock.Called(ctx)
+	}
+	ret := tmpRet
 
 	if len(ret) == 0 {
 		panic("no return value specified for Save")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, twins.State) error); ok {
-		r0 = returnFunc(ctx, state)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, ...twins.State) error); ok {
+		r0 = returnFunc(ctx, states...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -275,20 +872,80 @@ type StateRepository_Save_Call struct {
 
 // Save is a helper method to define mock.On call
 //   - ctx context.Context
-//   - state twins.State
-func (_e *StateRepository_Expecter) Save(ctx interface{}, state interface{}) *StateRepository_Save_Call {
-	return &StateRepository_Save_Call{Call: _e.mock.On("Save", ctx, state)}
+//   - states ...twins.State
+func (_e *StateRepository_Expecter) Save(ctx interface{}, states ...interface{}) *StateRepository_Save_Call {
+	return &StateRepository_Save_Call{Call: _e.mock.On("Save",
+		append([]interface{}{ctx}, states...)...)}
 }
 
-func (_c *StateRepository_Save_Call) Run(run func(ctx context.Context, state twins.State)) *StateRepository_Save_Call {
+func (_c *StateRepository_Save_Call) Run(run func(ctx context.Context, states ...twins.State)) *StateRepository_Save_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
 			arg0 = args[0].(context.Context)
 		}
-		var arg1 twins.State
+		var arg1 []twins.State
+		var variadicArgs []twins.State
+		if len(args) > 1 {
+			variadicArgs = args[1].([]twins.State)
+		}
+		arg1 = variadicArgs
+		run(
+			arg0,
+			arg1...,
+		)
+	})
+	return _c
+}
+
+func (_c *StateRepository_Save_Call) Return(err error) *StateRepository_Save_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *StateRepository_Save_Call) RunAndReturn(run func(ctx context.Context, states ...twins.State) error) *StateRepository_Save_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveAlarm provides a mock function for the type StateRepository
+func (_mock *StateRepository) SaveAlarm(ctx context.Context, alarm twins.Alarm) error {
+	ret := _mock.Called(ctx, alarm)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveAlarm")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, twins.Alarm) error); ok {
+		r0 = returnFunc(ctx, alarm)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// StateRepository_SaveAlarm_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveAlarm'
+type StateRepository_SaveAlarm_Call struct {
+	*mock.Call
+}
+
+// SaveAlarm is a helper method to define mock.On call
+//   - ctx context.Context
+//   - alarm twins.Alarm
+func (_e *StateRepository_Expecter) SaveAlarm(ctx interface{}, alarm interface{}) *StateRepository_SaveAlarm_Call {
+	return &StateRepository_SaveAlarm_Call{Call: _e.mock.On("SaveAlarm", ctx, alarm)}
+}
+
+func (_c *StateRepository_SaveAlarm_Call) Run(run func(ctx context.Context, alarm twins.Alarm)) *StateRepository_SaveAlarm_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 twins.Alarm
 		if args[1] != nil {
-			arg1 = args[1].(twins.State)
+			arg1 = args[1].(twins.Alarm)
 		}
 		run(
 			arg0,
@@ -298,12 +955,141 @@ func (_c *StateRepository_Save_Call) Run(run func(ctx context.Context, state twi
 	return _c
 }
 
-func (_c *StateRepository_Save_Call) Return(err error) *StateRepository_Save_Call {
+func (_c *StateRepository_SaveAlarm_Call) Return(err error) *StateRepository_SaveAlarm_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *StateRepository_SaveAlarm_Call) RunAndReturn(run func(ctx context.Context, alarm twins.Alarm) error) *StateRepository_SaveAlarm_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveDesired provides a mock function for the type StateRepository
+func (_mock *StateRepository) SaveDesired(ctx context.Context, ds twins.DesiredState) error {
+	ret := _mock.Called(ctx, ds)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveDesired")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, twins.DesiredState) error); ok {
+		r0 = returnFunc(ctx, ds)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// StateRepository_SaveDesired_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveDesired'
+type StateRepository_SaveDesired_Call struct {
+	*mock.Call
+}
+
+// SaveDesired is a helper method to define mock.On call
+//   - ctx context.Context
+//   - ds twins.DesiredState
+func (_e *StateRepository_Expecter) SaveDesired(ctx interface{}, ds interface{}) *StateRepository_SaveDesired_Call {
+	return &StateRepository_SaveDesired_Call{Call: _e.mock.On("SaveDesired", ctx, ds)}
+}
+
+func (_c *StateRepository_SaveDesired_Call) Run(run func(ctx context.Context, ds twins.DesiredState)) *StateRepository_SaveDesired_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 twins.DesiredState
+		if args[1] != nil {
+			arg1 = args[1].(twins.DesiredState)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *StateRepository_SaveDesired_Call) Return(err error) *StateRepository_SaveDesired_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *StateRepository_SaveDesired_Call) RunAndReturn(run func(ctx context.Context, ds twins.DesiredState) error) *StateRepository_SaveDesired_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveSimulated provides a mock function for the type StateRepository
+func (_mock *StateRepository) SaveSimulated(ctx context.Context, twinID string, states ...twins.State) error {
+	var tmpRet mock.Arguments
+	if len(states) > 0 {
+		tmpRet = _mock.Called(ctx, twinID, states)
+	} else {
+		tmpRet = _mock.Called(ctx, twinID)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveSimulated")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, ...twins.State) error); ok {
+		r0 = returnFunc(ctx, twinID, states...)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// StateRepository_SaveSimulated_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveSimulated'
+type StateRepository_SaveSimulated_Call struct {
+	*mock.Call
+}
+
+// SaveSimulated is a helper method to define mock.On call
+//   - ctx context.Context
+//   - twinID string
+//   - states ...twins.State
+func (_e *StateRepository_Expecter) SaveSimulated(ctx interface{}, twinID interface{}, states ...interface{}) *StateRepository_SaveSimulated_Call {
+	return &StateRepository_SaveSimulated_Call{Call: _e.mock.On("SaveSimulated",
+		append([]interface{}{ctx, twinID}, states...)...)}
+}
+
+func (_c *StateRepository_SaveSimulated_Call) Run(run func(ctx context.Context, twinID string, states ...twins.State)) *StateRepository_SaveSimulated_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []twins.State
+		var variadicArgs []twins.State
+		if len(args) > 2 {
+			variadicArgs = args[2].([]twins.State)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *StateRepository_SaveSimulated_Call) Return(err error) *StateRepository_SaveSimulated_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *StateRepository_Save_Call) RunAndReturn(run func(ctx context.Context, state twins.State) error) *StateRepository_Save_Call {
+func (_c *StateRepository_SaveSimulated_Call) RunAndReturn(run func(ctx context.Context, twinID string, states ...twins.State) error) *StateRepository_SaveSimulated_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -364,3 +1150,60 @@ func (_c *StateRepository_Update_Call) RunAndReturn(run func(ctx context.Context
 	_c.Call.Return(run)
 	return _c
 }
+
+// UpdateStale provides a mock function for the type StateRepository
+func (_mock *StateRepository) UpdateStale(ctx context.Context, state twins.State) error {
+	ret := _mock.Called(ctx, state)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateStale")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, twins.State) error); ok {
+		r0 = returnFunc(ctx, state)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// StateRepository_UpdateStale_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateStale'
+type StateRepository_UpdateStale_Call struct {
+	*mock.Call
+}
+
+// UpdateStale is a helper method to define mock.On call
+//   - ctx context.Context
+//   - state twins.State
+func (_e *StateRepository_Expecter) UpdateStale(ctx interface{}, state interface{}) *StateRepository_UpdateStale_Call {
+	return &StateRepository_UpdateStale_Call{Call: _e.mock.On("UpdateStale", ctx, state)}
+}
+
+func (_c *StateRepository_UpdateStale_Call) Run(run func(ctx context.Context, state twins.State)) *StateRepository_UpdateStale_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 twins.State
+		if args[1] != nil {
+			arg1 = args[1].(twins.State)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *StateRepository_UpdateStale_Call) Return(err error) *StateRepository_UpdateStale_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *StateRepository_UpdateStale_Call) RunAndReturn(run func(ctx context.Context, state twins.State) error) *StateRepository_UpdateStale_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -99,8 +99,8 @@ func (_c *TwinRepository_Remove_Call) RunAndReturn(run func(ctx context.Context,
 }
 
 // RetrieveAll provides a mock function for the type TwinRepository
-func (_mock *TwinRepository) RetrieveAll(ctx context.Context, owner string, offset uint64, limit uint64, name string, metadata twins.Metadata) (twins.Page, error) {
-	ret := _mock.Called(ctx, owner, offset, limit, name, metadata)
+func (_mock *TwinRepository) RetrieveAll(ctx context.Context, domainID string, ids []string, offset uint64, limit uint64, name string, parentID string, metadata twins.Metadata) (twins.Page, error) {
+	ret := _mock.Called(ctx, domainID, ids, offset, limit, name, parentID, metadata)
 
 	if len(ret) == 0 {
 		panic("no return value specified for RetrieveAll")
@@ -108,16 +108,16 @@ func (_mock *TwinRepository) RetrieveAll(ctx context.Context, owner string, offs
 
 	var r0 twins.Page
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, uint64, uint64, string, twins.Metadata) (twins.Page, error)); ok {
-		return returnFunc(ctx, owner, offset, limit, name, metadata)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string, uint64, uint64, string, string, twins.Metadata) (twins.Page, error)); ok {
+		return returnFunc(ctx, domainID, ids, offset, limit, name, parentID, metadata)
 	}
-	if returnFunc, ok := ret.Get(0).(func(context.Context, string, uint64, uint64, string, twins.Metadata) twins.Page); ok {
-		r0 = returnFunc(ctx, owner, offset, limit, name, metadata)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []string, uint64, uint64, string, string, twins.Metadata) twins.Page); ok {
+		r0 = returnFunc(ctx, domainID, ids, offset, limit, name, parentID, metadata)
 	} else {
 		r0 = ret.Get(0).(twins.Page)
 	}
-	if returnFunc, ok := ret.Get(1).(func(context.Context, string, uint64, uint64, string, twins.Metadata) error); ok {
-		r1 = returnFunc(ctx, owner, offset, limit, name, metadata)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []string, uint64, uint64, string, string, twins.Metadata) error); ok {
+		r1 = returnFunc(ctx, domainID, ids, offset, limit, name, parentID, metadata)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -131,16 +131,18 @@ type TwinRepository_RetrieveAll_Call struct {
 
 // RetrieveAll is a helper method to define mock.On call
 //   - ctx context.Context
-//   - owner string
+//   - domainID string
+//   - ids []string
 //   - offset uint64
 //   - limit uint64
 //   - name string
+//   - parentID string
 //   - metadata twins.Metadata
-func (_e *TwinRepository_Expecter) RetrieveAll(ctx interface{}, owner interface{}, offset interface{}, limit interface{}, name interface{}, metadata interface{}) *TwinRepository_RetrieveAll_Call {
-	return &TwinRepository_RetrieveAll_Call{Call: _e.mock.On("RetrieveAll", ctx, owner, offset, limit, name, metadata)}
+func (_e *TwinRepository_Expecter) RetrieveAll(ctx interface{}, domainID interface{}, ids interface{}, offset interface{}, limit interface{}, name interface{}, parentID interface{}, metadata interface{}) *TwinRepository_RetrieveAll_Call {
+	return &TwinRepository_RetrieveAll_Call{Call: _e.mock.On("RetrieveAll", ctx, domainID, ids, offset, limit, name, parentID, metadata)}
 }
 
-func (_c *TwinRepository_RetrieveAll_Call) Run(run func(ctx context.Context, owner string, offset uint64, limit uint64, name string, metadata twins.Metadata)) *TwinRepository_RetrieveAll_Call {
+func (_c *TwinRepository_RetrieveAll_Call) Run(run func(ctx context.Context, domainID string, ids []string, offset uint64, limit uint64, name string, parentID string, metadata twins.Metadata)) *TwinRepository_RetrieveAll_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 context.Context
 		if args[0] != nil {
@@ -150,21 +152,29 @@ func (_c *TwinRepository_RetrieveAll_Call) Run(run func(ctx context.Context, own
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 uint64
+		var arg2 []string
 		if args[2] != nil {
-			arg2 = args[2].(uint64)
+			arg2 = args[2].([]string)
 		}
 		var arg3 uint64
 		if args[3] != nil {
 			arg3 = args[3].(uint64)
 		}
-		var arg4 string
+		var arg4 uint64
 		if args[4] != nil {
-			arg4 = args[4].(string)
+			arg4 = args[4].(uint64)
 		}
-		var arg5 twins.Metadata
+		var arg5 string
 		if args[5] != nil {
-			arg5 = args[5].(twins.Metadata)
+			arg5 = args[5].(string)
+		}
+		var arg6 string
+		if args[6] != nil {
+			arg6 = args[6].(string)
+		}
+		var arg7 twins.Metadata
+		if args[7] != nil {
+			arg7 = args[7].(twins.Metadata)
 		}
 		run(
 			arg0,
@@ -173,6 +183,8 @@ func (_c *TwinRepository_RetrieveAll_Call) Run(run func(ctx context.Context, own
 			arg3,
 			arg4,
 			arg5,
+			arg6,
+			arg7,
 		)
 	})
 	return _c
@@ -183,7 +195,7 @@ func (_c *TwinRepository_RetrieveAll_Call) Return(page twins.Page, err error) *T
 	return _c
 }
 
-func (_c *TwinRepository_RetrieveAll_Call) RunAndReturn(run func(ctx context.Context, owner string, offset uint64, limit uint64, name string, metadata twins.Metadata) (twins.Page, error)) *TwinRepository_RetrieveAll_Call {
+func (_c *TwinRepository_RetrieveAll_Call) RunAndReturn(run func(ctx context.Context, domainID string, ids []string, offset uint64, limit uint64, name string, parentID string, metadata twins.Metadata) (twins.Page, error)) *TwinRepository_RetrieveAll_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -328,6 +340,295 @@ func (_c *TwinRepository_RetrieveByID_Call) RunAndReturn(run func(ctx context.Co
 	return _c
 }
 
+// RetrieveByTemplate provides a mock function for the type TwinRepository
+func (_mock *TwinRepository) RetrieveByTemplate(ctx context.Context, templateID string) ([]twins.Twin, error) {
+	ret := _mock.Called(ctx, templateID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveByTemplate")
+	}
+
+	var r0 []twins.Twin
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) ([]twins.Twin, error)); ok {
+		return returnFunc(ctx, templateID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) []twins.Twin); ok {
+		r0 = returnFunc(ctx, templateID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]twins.Twin)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, templateID)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// TwinRepository_RetrieveByTemplate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveByTemplate'
+type TwinRepository_RetrieveByTemplate_Call struct {
+	*mock.Call
+}
+
+// RetrieveByTemplate is a helper method to define mock.On call
+//   - ctx context.Context
+//   - templateID string
+func (_e *TwinRepository_Expecter) RetrieveByTemplate(ctx interface{}, templateID interface{}) *TwinRepository_RetrieveByTemplate_Call {
+	return &TwinRepository_RetrieveByTemplate_Call{Call: _e.mock.On("RetrieveByTemplate", ctx, templateID)}
+}
+
+func (_c *TwinRepository_RetrieveByTemplate_Call) Run(run func(ctx context.Context, templateID string)) *TwinRepository_RetrieveByTemplate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *TwinRepository_RetrieveByTemplate_Call) Return(twins1 []twins.Twin, err error) *TwinRepository_RetrieveByTemplate_Call {
+	_c.Call.Return(twins1, err)
+	return _c
+}
+
+func (_c *TwinRepository_RetrieveByTemplate_Call) RunAndReturn(run func(ctx context.Context, templateID string) ([]twins.Twin, error)) *TwinRepository_RetrieveByTemplate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveChildren provides a mock function for the type TwinRepository
+func (_mock *TwinRepository) RetrieveChildren(ctx context.Context, parentIDs ...string) ([]twins.Twin, error) {
+	var tmpRet mock.Arguments
+	if len(parentIDs) > 0 {
+		tmpRet = _mock.Called(ctx, parentIDs)
+	} else {
+		tmpRet = _mock.Called(ctx)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveChildren")
+	}
+
+	var r0 []twins.Twin
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, ...string) ([]twins.Twin, error)); ok {
+		return returnFunc(ctx, parentIDs...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, ...string) []twins.Twin); ok {
+		r0 = returnFunc(ctx, parentIDs...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]twins.Twin)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, ...string) error); ok {
+		r1 = returnFunc(ctx, parentIDs...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// TwinRepository_RetrieveChildren_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveChildren'
+type TwinRepository_RetrieveChildren_Call struct {
+	*mock.Call
+}
+
+// RetrieveChildren is a helper method to define mock.On call
+//   - ctx context.Context
+//   - parentIDs ...string
+func (_e *TwinRepository_Expecter) RetrieveChildren(ctx interface{}, parentIDs ...interface{}) *TwinRepository_RetrieveChildren_Call {
+	return &TwinRepository_RetrieveChildren_Call{Call: _e.mock.On("RetrieveChildren",
+		append([]interface{}{ctx}, parentIDs...)...)}
+}
+
+func (_c *TwinRepository_RetrieveChildren_Call) Run(run func(ctx context.Context, parentIDs ...string)) *TwinRepository_RetrieveChildren_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 []string
+		var variadicArgs []string
+		if len(args) > 1 {
+			variadicArgs = args[1].([]string)
+		}
+		arg1 = variadicArgs
+		run(
+			arg0,
+			arg1...,
+		)
+	})
+	return _c
+}
+
+func (_c *TwinRepository_RetrieveChildren_Call) Return(twins1 []twins.Twin, err error) *TwinRepository_RetrieveChildren_Call {
+	_c.Call.Return(twins1, err)
+	return _c
+}
+
+func (_c *TwinRepository_RetrieveChildren_Call) RunAndReturn(run func(ctx context.Context, parentIDs ...string) ([]twins.Twin, error)) *TwinRepository_RetrieveChildren_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveMonitored provides a mock function for the type TwinRepository
+func (_mock *TwinRepository) RetrieveMonitored(ctx context.Context, offset uint64, limit uint64) (twins.Page, error) {
+	ret := _mock.Called(ctx, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveMonitored")
+	}
+
+	var r0 twins.Page
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uint64, uint64) (twins.Page, error)); ok {
+		return returnFunc(ctx, offset, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uint64, uint64) twins.Page); ok {
+		r0 = returnFunc(ctx, offset, limit)
+	} else {
+		r0 = ret.Get(0).(twins.Page)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uint64, uint64) error); ok {
+		r1 = returnFunc(ctx, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// TwinRepository_RetrieveMonitored_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveMonitored'
+type TwinRepository_RetrieveMonitored_Call struct {
+	*mock.Call
+}
+
+// RetrieveMonitored is a helper method to define mock.On call
+//   - ctx context.Context
+//   - offset uint64
+//   - limit uint64
+func (_e *TwinRepository_Expecter) RetrieveMonitored(ctx interface{}, offset interface{}, limit interface{}) *TwinRepository_RetrieveMonitored_Call {
+	return &TwinRepository_RetrieveMonitored_Call{Call: _e.mock.On("RetrieveMonitored", ctx, offset, limit)}
+}
+
+func (_c *TwinRepository_RetrieveMonitored_Call) Run(run func(ctx context.Context, offset uint64, limit uint64)) *TwinRepository_RetrieveMonitored_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uint64
+		if args[1] != nil {
+			arg1 = args[1].(uint64)
+		}
+		var arg2 uint64
+		if args[2] != nil {
+			arg2 = args[2].(uint64)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *TwinRepository_RetrieveMonitored_Call) Return(page twins.Page, err error) *TwinRepository_RetrieveMonitored_Call {
+	_c.Call.Return(page, err)
+	return _c
+}
+
+func (_c *TwinRepository_RetrieveMonitored_Call) RunAndReturn(run func(ctx context.Context, offset uint64, limit uint64) (twins.Page, error)) *TwinRepository_RetrieveMonitored_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RetrieveRetained provides a mock function for the type TwinRepository
+func (_mock *TwinRepository) RetrieveRetained(ctx context.Context, offset uint64, limit uint64) (twins.Page, error) {
+	ret := _mock.Called(ctx, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RetrieveRetained")
+	}
+
+	var r0 twins.Page
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uint64, uint64) (twins.Page, error)); ok {
+		return returnFunc(ctx, offset, limit)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, uint64, uint64) twins.Page); ok {
+		r0 = returnFunc(ctx, offset, limit)
+	} else {
+		r0 = ret.Get(0).(twins.Page)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, uint64, uint64) error); ok {
+		r1 = returnFunc(ctx, offset, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// TwinRepository_RetrieveRetained_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RetrieveRetained'
+type TwinRepository_RetrieveRetained_Call struct {
+	*mock.Call
+}
+
+// RetrieveRetained is a helper method to define mock.On call
+//   - ctx context.Context
+//   - offset uint64
+//   - limit uint64
+func (_e *TwinRepository_Expecter) RetrieveRetained(ctx interface{}, offset interface{}, limit interface{}) *TwinRepository_RetrieveRetained_Call {
+	return &TwinRepository_RetrieveRetained_Call{Call: _e.mock.On("RetrieveRetained", ctx, offset, limit)}
+}
+
+func (_c *TwinRepository_RetrieveRetained_Call) Run(run func(ctx context.Context, offset uint64, limit uint64)) *TwinRepository_RetrieveRetained_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 uint64
+		if args[1] != nil {
+			arg1 = args[1].(uint64)
+		}
+		var arg2 uint64
+		if args[2] != nil {
+			arg2 = args[2].(uint64)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *TwinRepository_RetrieveRetained_Call) Return(page twins.Page, err error) *TwinRepository_RetrieveRetained_Call {
+	_c.Call.Return(page, err)
+	return _c
+}
+
+func (_c *TwinRepository_RetrieveRetained_Call) RunAndReturn(run func(ctx context.Context, offset uint64, limit uint64) (twins.Page, error)) *TwinRepository_RetrieveRetained_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Save provides a mock function for the type TwinRepository
 func (_mock *TwinRepository) Save(ctx context.Context, twin twins.Twin) (string, error) {
 	ret := _mock.Called(ctx, twin)
@@ -450,3 +751,66 @@ func (_c *TwinRepository_Update_Call) RunAndReturn(run func(ctx context.Context,
 	_c.Call.Return(run)
 	return _c
 }
+
+// UpdateLiveness provides a mock function for the type TwinRepository
+func (_mock *TwinRepository) UpdateLiveness(ctx context.Context, twinID string, liveness twins.Liveness) error {
+	ret := _mock.Called(ctx, twinID, liveness)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateLiveness")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, twins.Liveness) error); ok {
+		r0 = returnFunc(ctx, twinID, liveness)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// TwinRepository_UpdateLiveness_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateLiveness'
+type TwinRepository_UpdateLiveness_Call struct {
+	*mock.Call
+}
+
+// UpdateLiveness is a helper method to define mock.On call
+//   - ctx context.Context
+//   - twinID string
+//   - liveness twins.Liveness
+func (_e *TwinRepository_Expecter) UpdateLiveness(ctx interface{}, twinID interface{}, liveness interface{}) *TwinRepository_UpdateLiveness_Call {
+	return &TwinRepository_UpdateLiveness_Call{Call: _e.mock.On("UpdateLiveness", ctx, twinID, liveness)}
+}
+
+func (_c *TwinRepository_UpdateLiveness_Call) Run(run func(ctx context.Context, twinID string, liveness twins.Liveness)) *TwinRepository_UpdateLiveness_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 twins.Liveness
+		if args[2] != nil {
+			arg2 = args[2].(twins.Liveness)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *TwinRepository_UpdateLiveness_Call) Return(err error) *TwinRepository_UpdateLiveness_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *TwinRepository_UpdateLiveness_Call) RunAndReturn(run func(ctx context.Context, twinID string, liveness twins.Liveness) error) *TwinRepository_UpdateLiveness_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -0,0 +1,166 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package mongodb
+
+import (
+	"context"
+
+	"github.com/absmach/supermq-contrib/twins"
+	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const relationsCollection string = "relations"
+
+var relationsSort = bson.D{{Key: "created", Value: 1}, {Key: "source", Value: 1}, {Key: "type", Value: 1}, {Key: "target", Value: 1}}
+
+type relationRepository struct {
+	db *mongo.Database
+}
+
+var _ twins.RelationRepository = (*relationRepository)(nil)
+
+// NewRelationRepository instantiates a MongoDB implementation of relation
+// repository.
+func NewRelationRepository(db *mongo.Database) twins.RelationRepository {
+	return &relationRepository{
+		db: db,
+	}
+}
+
+func (rr *relationRepository) Save(ctx context.Context, rel twins.Relation) error {
+	coll := rr.db.Collection(relationsCollection)
+
+	// The relation is inserted only if it does not exist yet.
+	filter := bson.M{"source": rel.Source, "type": rel.Type, "target": rel.Target}
+	update := bson.M{"$setOnInsert": rel}
+	res, err := coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return errors.Wrap(repoerr.ErrCreateEntity, err)
+	}
+
+	if res.UpsertedCount < 1 {
+		return repoerr.ErrConflict
+	}
+
+	return nil
+}
+
+func (rr *relationRepository) Remove(ctx context.Context, source, relType, target string) error {
+	coll := rr.db.Collection(relationsCollection)
+
+	filter := bson.M{"source": source, "type": relType, "target": target}
+	res, err := coll.DeleteOne(ctx, filter)
+	if err != nil {
+		return errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+
+	if res.DeletedCount < 1 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
+func (rr *relationRepository) RemoveByTwin(ctx context.Context, twinID string) error {
+	coll := rr.db.Collection(relationsCollection)
+
+	filter := bson.M{"$or": []bson.M{{"source": twinID}, {"target": twinID}}}
+	if _, err := coll.DeleteMany(ctx, filter); err != nil {
+		return errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+
+	return nil
+}
+
+func (rr *relationRepository) RetrieveAll(ctx context.Context, twinID string, offset, limit uint64, rf twins.RelationFilter) (twins.RelationsPage, error) {
+	coll := rr.db.Collection(relationsCollection)
+
+	findOptions := options.Find()
+	findOptions.SetSkip(int64(offset))
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSort(relationsSort)
+
+	filter := relationFilter([]string{twinID}, rf)
+	cur, err := coll.Find(ctx, filter, findOptions)
+	if err != nil {
+		return twins.RelationsPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	results, err := decodeRelations(ctx, cur)
+	if err != nil {
+		return twins.RelationsPage{}, err
+	}
+
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return twins.RelationsPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return twins.RelationsPage{
+		Relations: results,
+		PageMetadata: twins.PageMetadata{
+			Total:  uint64(total),
+			Offset: offset,
+			Limit:  limit,
+		},
+	}, nil
+}
+
+func (rr *relationRepository) RetrieveByTwins(ctx context.Context, twinIDs []string, rf twins.RelationFilter) ([]twins.Relation, error) {
+	if len(twinIDs) == 0 {
+		return []twins.Relation{}, nil
+	}
+
+	coll := rr.db.Collection(relationsCollection)
+
+	cur, err := coll.Find(ctx, relationFilter(twinIDs, rf), options.Find().SetSort(relationsSort))
+	if err != nil {
+		return []twins.Relation{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return decodeRelations(ctx, cur)
+}
+
+// relationFilter selects the relations of the twins in the direction
+// specified by the filter.
+func relationFilter(twinIDs []string, rf twins.RelationFilter) bson.M {
+	ids := bson.M{"$in": twinIDs}
+
+	var filter bson.M
+	switch rf.Direction {
+	case twins.OutgoingDir:
+		filter = bson.M{"source": ids}
+	case twins.IncomingDir:
+		filter = bson.M{"target": ids}
+	default:
+		filter = bson.M{"$or": []bson.M{{"source": ids}, {"target": ids}}}
+	}
+	if len(rf.Types) > 0 {
+		filter["type"] = bson.M{"$in": rf.Types}
+	}
+
+	return filter
+}
+
+func decodeRelations(ctx context.Context, cur *mongo.Cursor) ([]twins.Relation, error) {
+	defer cur.Close(ctx)
+
+	results := []twins.Relation{}
+	for cur.Next(ctx) {
+		var elem twins.Relation
+		if err := cur.Decode(&elem); err != nil {
+			return []twins.Relation{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		return []twins.Relation{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return results, nil
+}
@@ -5,16 +5,22 @@ package mongodb
 
 import (
 	"context"
+	"time"
 
 	"github.com/absmach/supermq-contrib/twins"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const (
-	statesCollection string = "states"
-	twinid           string = "twinid"
+	descDir                        = "desc"
+	statesCollection        string = "states"
+	simulatedCollection     string = "simulated_states"
+	desiredStatesCollection string = "desired_states"
+	alarmsCollection        string = "alarms"
+	twinid                  string = "twinid"
 )
 
 type stateRepository struct {
@@ -31,11 +37,19 @@ func NewStateRepository(db *mongo.Database) twins.StateRepository {
 	}
 }
 
-// SaveState persists the state.
-func (sr *stateRepository) Save(ctx context.Context, st twins.State) error {
+// Save persists the states.
+func (sr *stateRepository) Save(ctx context.Context, sts ...twins.State) error {
+	if len(sts) == 0 {
+		return nil
+	}
+
 	coll := sr.db.Collection(statesCollection)
 
-	if _, err := coll.InsertOne(ctx, st); err != nil {
+	docs := make([]interface{}, len(sts))
+	for i, st := range sts {
+		docs[i] = st
+	}
+	if _, err := coll.InsertMany(ctx, docs); err != nil {
 		return err
 	}
 
@@ -55,6 +69,19 @@ func (sr *stateRepository) Update(ctx context.Context, st twins.State) error {
 	return nil
 }
 
+// UpdateStale persists the stale attributes of the state.
+func (sr *stateRepository) UpdateStale(ctx context.Context, st twins.State) error {
+	coll := sr.db.Collection(statesCollection)
+
+	filter := bson.M{"id": st.ID, twinid: st.TwinID}
+	update := bson.M{"$set": bson.M{"stale": st.Stale}}
+	if _, err := coll.UpdateOne(ctx, filter, update); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // CountStates returns the number of states related to twin.
 func (sr *stateRepository) Count(ctx context.Context, tw twins.Twin) (int64, error) {
 	coll := sr.db.Collection(statesCollection)
@@ -69,14 +96,23 @@ func (sr *stateRepository) Count(ctx context.Context, tw twins.Twin) (int64, err
 }
 
 // RetrieveAll retrieves the subset of states related to twin specified by id.
-func (sr *stateRepository) RetrieveAll(ctx context.Context, offset, limit uint64, twinID string) (twins.StatesPage, error) {
+func (sr *stateRepository) RetrieveAll(ctx context.Context, offset, limit uint64, twinID string, sf twins.StateFilter) (twins.StatesPage, error) {
 	coll := sr.db.Collection(statesCollection)
+	if sf.Simulated {
+		coll = sr.db.Collection(simulatedCollection)
+	}
+
+	order := 1
+	if sf.Dir == descDir {
+		order = -1
+	}
 
 	findOptions := options.Find()
 	findOptions.SetSkip(int64(offset))
 	findOptions.SetLimit(int64(limit))
+	findOptions.SetSort(bson.D{{Key: "id", Value: order}})
 
-	filter := bson.M{twinid: twinID}
+	filter := stateFilter(twinID, sf)
 
 	cur, err := coll.Find(ctx, filter, findOptions)
 	if err != nil {
@@ -137,6 +173,264 @@ func (sr *stateRepository) RetrieveLast(ctx context.Context, twinID string) (twi
 	return results[0], nil
 }
 
+// RetrieveByID returns the state related to twin spec by id with the given
+// state id.
+func (sr *stateRepository) RetrieveByID(ctx context.Context, twinID string, id int64) (twins.State, error) {
+	coll := sr.db.Collection(statesCollection)
+
+	var st twins.State
+	if err := coll.FindOne(ctx, bson.M{twinid: twinID, "id": id}).Decode(&st); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return twins.State{}, repoerr.ErrNotFound
+		}
+		return twins.State{}, err
+	}
+
+	return st, nil
+}
+
+// RetrieveAt returns the last state related to twin spec by id created at or
+// before the given time.
+func (sr *stateRepository) RetrieveAt(ctx context.Context, twinID string, at time.Time) (twins.State, error) {
+	coll := sr.db.Collection(statesCollection)
+
+	filter := bson.M{twinid: twinID, "created": bson.M{"$lte": at}}
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "created", Value: -1}, {Key: "id", Value: -1}})
+
+	var st twins.State
+	if err := coll.FindOne(ctx, filter, findOptions).Decode(&st); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return twins.State{}, repoerr.ErrNotFound
+		}
+		return twins.State{}, err
+	}
+
+	return st, nil
+}
+
+// RemoveBefore removes the states of the twin created before the given time.
+func (sr *stateRepository) RemoveBefore(ctx context.Context, twinID string, before time.Time) (int64, error) {
+	coll := sr.db.Collection(statesCollection)
+
+	filter := bson.M{twinid: twinID, "created": bson.M{"$lt": before}}
+	res, err := coll.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.DeletedCount, nil
+}
+
+// RemoveAfter removes the states of the twin with the id greater than the
+// given one.
+func (sr *stateRepository) RemoveAfter(ctx context.Context, twinID string, id int64) (int64, error) {
+	coll := sr.db.Collection(statesCollection)
+
+	filter := bson.M{twinid: twinID, "id": bson.M{"$gt": id}}
+	res, err := coll.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.DeletedCount, nil
+}
+
+// SaveSimulated replaces the simulated states of the twin.
+func (sr *stateRepository) SaveSimulated(ctx context.Context, twinID string, sts ...twins.State) error {
+	coll := sr.db.Collection(simulatedCollection)
+
+	if _, err := coll.DeleteMany(ctx, bson.M{twinid: twinID}); err != nil {
+		return err
+	}
+	if len(sts) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(sts))
+	for i, st := range sts {
+		docs[i] = st
+	}
+	if _, err := coll.InsertMany(ctx, docs); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Compact removes the states of the twin created before the given time,
+// except the last state of each bucket.
+func (sr *stateRepository) Compact(ctx context.Context, twinID string, before time.Time, bucket time.Duration) (int64, error) {
+	coll := sr.db.Collection(statesCollection)
+
+	filter := bson.M{twinid: twinID, "created": bson.M{"$lt": before}}
+	created := bson.M{"$toLong": "$created"}
+	pipeline := []bson.M{
+		{"$match": filter},
+		{"$sort": bson.D{{Key: "created", Value: -1}, {Key: "id", Value: -1}}},
+		{"$group": bson.M{
+			"_id": bson.M{"$subtract": []interface{}{created, bson.M{"$mod": []interface{}{created, bucket.Milliseconds()}}}},
+			"id":  bson.M{"$first": "$id"},
+		}},
+	}
+	cur, err := coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+
+	var kept []int64
+	for cur.Next(ctx) {
+		var elem struct {
+			ID int64 `bson:"id"`
+		}
+		if err := cur.Decode(&elem); err != nil {
+			return 0, err
+		}
+		kept = append(kept, elem.ID)
+	}
+	if err := cur.Err(); err != nil {
+		return 0, err
+	}
+	if len(kept) == 0 {
+		return 0, nil
+	}
+
+	filter["id"] = bson.M{"$nin": kept}
+	res, err := coll.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.DeletedCount, nil
+}
+
+func stateFilter(twinID string, sf twins.StateFilter) bson.M {
+	filter := bson.M{twinid: twinID}
+
+	created := bson.M{}
+	if !sf.From.IsZero() {
+		created["$gte"] = sf.From
+	}
+	if !sf.To.IsZero() {
+		created["$lte"] = sf.To
+	}
+	if len(created) > 0 {
+		filter["created"] = created
+	}
+	if sf.Definition != nil {
+		filter["definition"] = *sf.Definition
+	}
+	if sf.Attribute != "" {
+		filter["payload."+sf.Attribute] = bson.M{"$exists": true}
+	}
+
+	return filter
+}
+
+// SaveDesired creates or replaces the desired state of the twin.
+func (sr *stateRepository) SaveDesired(ctx context.Context, ds twins.DesiredState) error {
+	coll := sr.db.Collection(desiredStatesCollection)
+
+	filter := bson.M{twinid: ds.TwinID}
+	if _, err := coll.ReplaceOne(ctx, filter, ds, options.Replace().SetUpsert(true)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RetrieveDesired returns the desired state of the twin specified by id.
+func (sr *stateRepository) RetrieveDesired(ctx context.Context, twinID string) (twins.DesiredState, error) {
+	coll := sr.db.Collection(desiredStatesCollection)
+
+	var ds twins.DesiredState
+	filter := bson.M{twinid: twinID}
+	if err := coll.FindOne(ctx, filter).Decode(&ds); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return twins.DesiredState{}, repoerr.ErrNotFound
+		}
+		return twins.DesiredState{}, err
+	}
+
+	return ds, nil
+}
+
+// SaveAlarm creates or replaces the alarm.
+func (sr *stateRepository) SaveAlarm(ctx context.Context, alarm twins.Alarm) error {
+	coll := sr.db.Collection(alarmsCollection)
+
+	filter := bson.M{"id": alarm.ID}
+	if _, err := coll.ReplaceOne(ctx, filter, alarm, options.Replace().SetUpsert(true)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RetrieveActiveAlarms returns the raised alarms of the twin specified by id.
+func (sr *stateRepository) RetrieveActiveAlarms(ctx context.Context, twinID string) ([]twins.Alarm, error) {
+	coll := sr.db.Collection(alarmsCollection)
+
+	filter := bson.M{twinid: twinID, "status": twins.RaisedStatus}
+	cur, err := coll.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []twins.Alarm
+	if err := cur.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// RetrieveAlarms retrieves the subset of alarms of the twin specified by id,
+// newest first.
+func (sr *stateRepository) RetrieveAlarms(ctx context.Context, offset, limit uint64, twinID string, af twins.AlarmFilter) (twins.AlarmsPage, error) {
+	coll := sr.db.Collection(alarmsCollection)
+
+	findOptions := options.Find()
+	findOptions.SetSkip(int64(offset))
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSort(bson.D{{Key: "raised", Value: -1}, {Key: "id", Value: 1}})
+
+	filter := bson.M{twinid: twinID}
+	if af.Attribute != "" {
+		filter["attribute"] = af.Attribute
+	}
+	if af.Severity != "" {
+		filter["severity"] = af.Severity
+	}
+	if af.Status != "" {
+		filter["status"] = af.Status
+	}
+
+	cur, err := coll.Find(ctx, filter, findOptions)
+	if err != nil {
+		return twins.AlarmsPage{}, err
+	}
+
+	results := []twins.Alarm{}
+	if err := cur.All(ctx, &results); err != nil {
+		return twins.AlarmsPage{}, err
+	}
+
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return twins.AlarmsPage{}, err
+	}
+
+	return twins.AlarmsPage{
+		Alarms: results,
+		PageMetadata: twins.PageMetadata{
+			Total:  uint64(total),
+			Offset: offset,
+			Limit:  limit,
+		},
+	}, nil
+}
+
 func decodeStates(ctx context.Context, cur *mongo.Cursor) ([]twins.State, error) {
 	defer cur.Close(ctx)
 
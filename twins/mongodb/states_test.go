@@ -11,6 +11,7 @@ import (
 
 	"github.com/absmach/supermq-contrib/twins"
 	"github.com/absmach/supermq-contrib/twins/mongodb"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
@@ -67,23 +68,33 @@ func TestStatesRetrieveAll(t *testing.T) {
 	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
 	n := uint64(10)
+	created := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
 	for i := uint64(0); i < n; i++ {
 		st := twins.State{
-			TwinID:  twid,
-			ID:      int64(i),
-			Created: time.Now(),
+			TwinID:     twid,
+			ID:         int64(i),
+			Definition: int(i % 2),
+			Created:    created.Add(time.Duration(i) * time.Minute),
+			Payload:    map[string]interface{}{},
+		}
+		if i%2 == 0 {
+			st.Payload["temperature"] = float64(i)
 		}
 
 		err = repo.Save(context.Background(), st)
 		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
 	}
 
+	defID := 1
+
 	cases := map[string]struct {
-		twid   string
-		limit  uint64
-		offset uint64
-		size   uint64
-		total  uint64
+		twid    string
+		limit   uint64
+		offset  uint64
+		filter  twins.StateFilter
+		size    uint64
+		total   uint64
+		firstID int64
 	}{
 		"retrieve all states with existing twin": {
 			twid:   twid,
@@ -106,14 +117,55 @@ func TestStatesRetrieveAll(t *testing.T) {
 			size:   0,
 			total:  0,
 		},
+		"retrieve states within period": {
+			twid:   twid,
+			offset: 0,
+			limit:  n,
+			filter: twins.StateFilter{
+				From: created.Add(2 * time.Minute),
+				To:   created.Add(5 * time.Minute),
+			},
+			size:    4,
+			total:   4,
+			firstID: 2,
+		},
+		"retrieve states by definition": {
+			twid:    twid,
+			offset:  0,
+			limit:   n,
+			filter:  twins.StateFilter{Definition: &defID},
+			size:    n / 2,
+			total:   n / 2,
+			firstID: 1,
+		},
+		"retrieve states by attribute": {
+			twid:   twid,
+			offset: 0,
+			limit:  n,
+			filter: twins.StateFilter{Attribute: "temperature"},
+			size:   n / 2,
+			total:  n / 2,
+		},
+		"retrieve states in descending order": {
+			twid:    twid,
+			offset:  0,
+			limit:   n / 2,
+			filter:  twins.StateFilter{Dir: "desc"},
+			size:    n / 2,
+			total:   n,
+			firstID: int64(n - 1),
+		},
 	}
 
 	for desc, tc := range cases {
-		page, err := repo.RetrieveAll(context.Background(), tc.offset, tc.limit, tc.twid)
+		page, err := repo.RetrieveAll(context.Background(), tc.offset, tc.limit, tc.twid, tc.filter)
 		size := uint64(len(page.States))
 		assert.Equal(t, tc.size, size, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.size, size))
 		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.total, page.Total))
 		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %d\n", desc, err))
+		if size > 0 {
+			assert.Equal(t, tc.firstID, page.States[0].ID, fmt.Sprintf("%s: expected first id %d got %d\n", desc, tc.firstID, page.States[0].ID))
+		}
 	}
 }
 
@@ -162,3 +214,354 @@ func TestStatesRetrieveLast(t *testing.T) {
 		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %d\n", desc, err))
 	}
 }
+
+func TestStatesRetrieveByID(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	repo := mongodb.NewStateRepository(db)
+
+	twid, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	n := int64(5)
+	for i := int64(0); i < n; i++ {
+		st := twins.State{
+			TwinID:     twid,
+			ID:         i,
+			Definition: int(i / 3),
+			Created:    time.Now(),
+			Payload:    map[string]interface{}{"temperature": float64(i)},
+		}
+
+		err = repo.Save(context.Background(), st)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	}
+
+	cases := map[string]struct {
+		twid string
+		id   int64
+		def  int
+		err  error
+	}{
+		"retrieve first state": {
+			twid: twid,
+			id:   0,
+		},
+		"retrieve state of later definition": {
+			twid: twid,
+			id:   4,
+			def:  1,
+		},
+		"retrieve non-existing state": {
+			twid: twid,
+			id:   n,
+			err:  repoerr.ErrNotFound,
+		},
+		"retrieve state with non-existing twin": {
+			twid: wrongValue,
+			id:   0,
+			err:  repoerr.ErrNotFound,
+		},
+	}
+
+	for desc, tc := range cases {
+		state, err := repo.RetrieveByID(context.Background(), tc.twid, tc.id)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, tc.id, state.ID, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.id, state.ID))
+			assert.Equal(t, tc.def, state.Definition, fmt.Sprintf("%s: expected definition %d got %d\n", desc, tc.def, state.Definition))
+			assert.Equal(t, float64(tc.id), state.Payload["temperature"], fmt.Sprintf("%s: expected temperature %d got %v\n", desc, tc.id, state.Payload["temperature"]))
+		}
+	}
+}
+
+func TestStatesRetrieveAt(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	_, err = db.Collection("states").DeleteMany(context.Background(), bson.D{})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	repo := mongodb.NewStateRepository(db)
+
+	twid, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	n := int64(10)
+	created := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	for i := int64(0); i < n; i++ {
+		st := twins.State{
+			TwinID:  twid,
+			ID:      i,
+			Created: created.Add(time.Duration(i) * time.Minute),
+		}
+
+		err = repo.Save(context.Background(), st)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	}
+
+	cases := map[string]struct {
+		twid string
+		at   time.Time
+		id   int64
+		err  error
+	}{
+		"retrieve state at exact creation time": {
+			twid: twid,
+			at:   created.Add(3 * time.Minute),
+			id:   3,
+		},
+		"retrieve state between two states": {
+			twid: twid,
+			at:   created.Add(5*time.Minute + 30*time.Second),
+			id:   5,
+		},
+		"retrieve state after the last state": {
+			twid: twid,
+			at:   time.Now(),
+			id:   n - 1,
+		},
+		"retrieve state before the first state": {
+			twid: twid,
+			at:   created.Add(-time.Minute),
+			err:  repoerr.ErrNotFound,
+		},
+		"retrieve state with non-existing twin": {
+			twid: wrongValue,
+			at:   time.Now(),
+			err:  repoerr.ErrNotFound,
+		},
+	}
+
+	for desc, tc := range cases {
+		state, err := repo.RetrieveAt(context.Background(), tc.twid, tc.at)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", desc, tc.err, err))
+		assert.Equal(t, tc.id, state.ID, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.id, state.ID))
+	}
+}
+
+func TestStatesRetention(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	repo := mongodb.NewStateRepository(db)
+
+	twid, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	// Three states are created every hour, twenty minutes apart.
+	n := int64(18)
+	created := time.Now().Add(-48 * time.Hour).Truncate(time.Hour)
+	for i := int64(0); i < n; i++ {
+		st := twins.State{
+			TwinID:  twid,
+			ID:      i,
+			Created: created.Add(time.Duration(i) * 20 * time.Minute),
+		}
+
+		err = repo.Save(context.Background(), st)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	}
+
+	compacted, err := repo.Compact(context.Background(), twid, created.Add(4*time.Hour), time.Hour)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	assert.Equal(t, int64(8), compacted, fmt.Sprintf("expected %d compacted states got %d\n", 8, compacted))
+
+	page, err := repo.RetrieveAll(context.Background(), 0, uint64(n), twid, twins.StateFilter{})
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	var ids []int64
+	for _, st := range page.States {
+		ids = append(ids, st.ID)
+	}
+	assert.Equal(t, []int64{2, 5, 8, 11, 12, 13, 14, 15, 16, 17}, ids, fmt.Sprintf("unexpected states after compaction %v\n", ids))
+
+	removed, err := repo.RemoveBefore(context.Background(), twid, created.Add(2*time.Hour))
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	assert.Equal(t, int64(2), removed, fmt.Sprintf("expected %d removed states got %d\n", 2, removed))
+
+	last, err := repo.RetrieveLast(context.Background(), twid)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	assert.Equal(t, n-1, last.ID, fmt.Sprintf("expected last state %d got %d\n", n-1, last.ID))
+
+	removed, err = repo.RemoveAfter(context.Background(), twid, 14)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	assert.Equal(t, int64(3), removed, fmt.Sprintf("expected %d removed states got %d\n", 3, removed))
+
+	last, err = repo.RetrieveLast(context.Background(), twid)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	assert.Equal(t, int64(14), last.ID, fmt.Sprintf("expected last state %d got %d\n", 14, last.ID))
+}
+
+func TestStatesSimulated(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	repo := mongodb.NewStateRepository(db)
+
+	twid, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	live := twins.State{TwinID: twid, ID: 0, Created: time.Now(), Payload: map[string]interface{}{"temperature": 20.0}}
+	err = repo.Save(context.Background(), live)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	var sim []twins.State
+	for i := 0; i < 3; i++ {
+		sim = append(sim, twins.State{TwinID: twid, ID: int64(i), Created: time.Now(), Payload: map[string]interface{}{"temperature": 21.0}})
+	}
+
+	cases := []struct {
+		desc   string
+		states []twins.State
+		size   uint64
+	}{
+		{
+			desc:   "save simulated states",
+			states: sim,
+			size:   3,
+		},
+		{
+			desc:   "replace simulated states",
+			states: sim[:1],
+			size:   1,
+		},
+		{
+			desc:   "clear simulated states",
+			states: nil,
+			size:   0,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.SaveSimulated(context.Background(), twid, tc.states...)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s\n", tc.desc, err))
+		page, err := repo.RetrieveAll(context.Background(), 0, 10, twid, twins.StateFilter{Simulated: true})
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s\n", tc.desc, err))
+		assert.Equal(t, tc.size, page.Total, fmt.Sprintf("%s: expected %d simulated states got %d\n", tc.desc, tc.size, page.Total))
+		page, err = repo.RetrieveAll(context.Background(), 0, 10, twid, twins.StateFilter{})
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s\n", tc.desc, err))
+		assert.Equal(t, uint64(1), page.Total, fmt.Sprintf("%s: expected live history to be intact got %d states\n", tc.desc, page.Total))
+	}
+}
+
+func TestStatesLiveness(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	repo := mongodb.NewStateRepository(db)
+
+	twid, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	updated := time.Now().Add(-time.Hour).Truncate(time.Millisecond).UTC()
+	st := twins.State{
+		TwinID:  twid,
+		ID:      0,
+		Created: time.Now(),
+		Payload: map[string]interface{}{"temperature": 21.5},
+		Updated: map[string]time.Time{"temperature": updated},
+	}
+	err = repo.Save(context.Background(), st)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	st.Stale = []string{"temperature"}
+	st.Payload = map[string]interface{}{"temperature": 22.5}
+	err = repo.UpdateStale(context.Background(), st)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	last, err := repo.RetrieveLast(context.Background(), twid)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	assert.Equal(t, []string{"temperature"}, last.Stale, fmt.Sprintf("expected stale attributes %v got %v\n", st.Stale, last.Stale))
+	assert.True(t, updated.Equal(last.Updated["temperature"]), fmt.Sprintf("expected update time %s got %s\n", updated, last.Updated["temperature"]))
+	assert.Equal(t, 21.5, last.Payload["temperature"], "expected payload not to be updated")
+}
+
+func TestStatesAlarms(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	repo := mongodb.NewStateRepository(db)
+
+	twid, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	raised := time.Now().Add(-time.Hour).Truncate(time.Second)
+	var alarms []twins.Alarm
+	for i, sev := range []string{twins.CriticalSeverity, twins.WarningSeverity, twins.CriticalSeverity} {
+		id, err := idProvider.ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		a := twins.Alarm{
+			ID:        id,
+			TwinID:    twid,
+			Attribute: "temperature",
+			Rule:      fmt.Sprintf("rule_%d", i),
+			Condition: twins.GreaterCondition,
+			Severity:  sev,
+			Status:    twins.RaisedStatus,
+			Value:     float64(80 + i),
+			Raised:    raised.Add(time.Duration(i) * time.Minute),
+		}
+		err = repo.SaveAlarm(context.Background(), a)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+		alarms = append(alarms, a)
+	}
+
+	cleared := raised.Add(time.Hour)
+	alarms[0].Status = twins.ClearedStatus
+	alarms[0].Cleared = &cleared
+	err = repo.SaveAlarm(context.Background(), alarms[0])
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	active, err := repo.RetrieveActiveAlarms(context.Background(), twid)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	var ids []string
+	for _, a := range active {
+		ids = append(ids, a.ID)
+	}
+	assert.ElementsMatch(t, []string{alarms[1].ID, alarms[2].ID}, ids, fmt.Sprintf("unexpected active alarms %v\n", ids))
+
+	cases := []struct {
+		desc   string
+		filter twins.AlarmFilter
+		ids    []string
+	}{
+		{
+			desc: "retrieve all alarms newest first",
+			ids:  []string{alarms[2].ID, alarms[1].ID, alarms[0].ID},
+		},
+		{
+			desc:   "retrieve cleared alarms",
+			filter: twins.AlarmFilter{Status: twins.ClearedStatus},
+			ids:    []string{alarms[0].ID},
+		},
+		{
+			desc:   "retrieve critical alarms",
+			filter: twins.AlarmFilter{Severity: twins.CriticalSeverity},
+			ids:    []string{alarms[2].ID, alarms[0].ID},
+		},
+		{
+			desc:   "retrieve alarms of unknown attribute",
+			filter: twins.AlarmFilter{Attribute: "pressure"},
+			ids:    nil,
+		},
+	}
+
+	for _, tc := range cases {
+		page, err := repo.RetrieveAlarms(context.Background(), 0, 10, twid, tc.filter)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s\n", tc.desc, err))
+		var ids []string
+		for _, a := range page.Alarms {
+			ids = append(ids, a.ID)
+			assert.Equal(t, a.Status == twins.ClearedStatus, a.Cleared != nil, fmt.Sprintf("%s: unexpected clearing time of %s alarm\n", tc.desc, a.Status))
+		}
+		assert.Equal(t, tc.ids, ids, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.ids, ids))
+		assert.Equal(t, uint64(len(tc.ids)), page.Total, fmt.Sprintf("%s: expected total %d got %d\n", tc.desc, len(tc.ids), page.Total))
+	}
+}
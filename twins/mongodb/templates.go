@@ -0,0 +1,138 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package mongodb
+
+import (
+	"context"
+
+	"github.com/absmach/supermq-contrib/twins"
+	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const templatesCollection string = "templates"
+
+type templateRepository struct {
+	db *mongo.Database
+}
+
+var _ twins.TemplateRepository = (*templateRepository)(nil)
+
+// NewTemplateRepository instantiates a MongoDB implementation of template
+// repository.
+func NewTemplateRepository(db *mongo.Database) twins.TemplateRepository {
+	return &templateRepository{
+		db: db,
+	}
+}
+
+func (tr *templateRepository) Save(ctx context.Context, tmpl twins.Template) (string, error) {
+	if len(tmpl.Name) > maxNameSize {
+		return "", errors.ErrMalformedEntity
+	}
+
+	coll := tr.db.Collection(templatesCollection)
+
+	if _, err := coll.InsertOne(ctx, tmpl); err != nil {
+		return "", errors.Wrap(repoerr.ErrCreateEntity, err)
+	}
+
+	return tmpl.ID, nil
+}
+
+func (tr *templateRepository) Update(ctx context.Context, tmpl twins.Template) error {
+	if len(tmpl.Name) > maxNameSize {
+		return errors.ErrMalformedEntity
+	}
+
+	coll := tr.db.Collection(templatesCollection)
+
+	filter := bson.M{"id": tmpl.ID}
+	update := bson.M{"$set": tmpl}
+	res, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if res.ModifiedCount < 1 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
+func (tr *templateRepository) RetrieveByID(ctx context.Context, id string) (twins.Template, error) {
+	coll := tr.db.Collection(templatesCollection)
+	var tmpl twins.Template
+
+	filter := bson.M{"id": id}
+	if err := coll.FindOne(ctx, filter).Decode(&tmpl); err != nil {
+		return tmpl, repoerr.ErrNotFound
+	}
+
+	return tmpl, nil
+}
+
+func (tr *templateRepository) RetrieveAll(ctx context.Context, domainID string, offset, limit uint64, name string) (twins.TemplatesPage, error) {
+	coll := tr.db.Collection(templatesCollection)
+
+	findOptions := options.Find()
+	findOptions.SetSkip(int64(offset))
+	findOptions.SetLimit(int64(limit))
+
+	filter := bson.M{"domain": domainID}
+	if name != "" {
+		filter["name"] = name
+	}
+	cur, err := coll.Find(ctx, filter, findOptions)
+	if err != nil {
+		return twins.TemplatesPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+	defer cur.Close(ctx)
+
+	results := []twins.Template{}
+	for cur.Next(ctx) {
+		var elem twins.Template
+		if err := cur.Decode(&elem); err != nil {
+			return twins.TemplatesPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		return twins.TemplatesPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return twins.TemplatesPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return twins.TemplatesPage{
+		Templates: results,
+		PageMetadata: twins.PageMetadata{
+			Total:  uint64(total),
+			Offset: offset,
+			Limit:  limit,
+		},
+	}, nil
+}
+
+func (tr *templateRepository) Remove(ctx context.Context, id string) error {
+	coll := tr.db.Collection(templatesCollection)
+
+	filter := bson.M{"id": id}
+	res, err := coll.DeleteOne(ctx, filter)
+	if err != nil {
+		return errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+
+	if res.DeletedCount < 1 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
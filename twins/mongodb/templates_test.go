@@ -0,0 +1,283 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package mongodb_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/absmach/supermq-contrib/twins"
+	"github.com/absmach/supermq-contrib/twins/mongodb"
+	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const templatesCollection = "templates"
+
+func TestTemplateSave(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	repo := mongodb.NewTemplateRepository(db)
+
+	id, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc string
+		tmpl twins.Template
+		err  error
+	}{
+		{
+			desc: "create new template",
+			tmpl: twins.Template{
+				Owner:  email,
+				Domain: domainID,
+				ID:     id,
+				Name:   validName,
+			},
+			err: nil,
+		},
+		{
+			desc: "create template with invalid name",
+			tmpl: twins.Template{
+				Owner: email,
+				ID:    id,
+				Name:  invalidName,
+			},
+			err: errors.ErrMalformedEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := repo.Save(context.Background(), tc.tmpl)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestTemplateUpdate(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	repo := mongodb.NewTemplateRepository(db)
+
+	id, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nonexistentID, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	tmpl := twins.Template{
+		Owner:    email,
+		Domain:   domainID,
+		ID:       id,
+		Name:     validName,
+		Revision: 1,
+	}
+	_, err = repo.Save(context.Background(), tmpl)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	tmpl.Revision = 2
+	tmpl.Definition = twins.Definition{
+		Attributes: []twins.Attribute{{Name: "pressure", Channel: "{{channel}}", Subtopic: subtopic}},
+	}
+
+	cases := []struct {
+		desc string
+		tmpl twins.Template
+		err  error
+	}{
+		{
+			desc: "update existing template",
+			tmpl: tmpl,
+			err:  nil,
+		},
+		{
+			desc: "update non-existing template",
+			tmpl: twins.Template{ID: nonexistentID},
+			err:  repoerr.ErrNotFound,
+		},
+		{
+			desc: "update template with invalid name",
+			tmpl: twins.Template{ID: id, Name: invalidName},
+			err:  errors.ErrMalformedEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Update(context.Background(), tc.tmpl)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+
+	saved, err := repo.RetrieveByID(context.Background(), id)
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	assert.Equal(t, tmpl.Revision, saved.Revision, fmt.Sprintf("expected revision %d got %d\n", tmpl.Revision, saved.Revision))
+	assert.Equal(t, tmpl.Definition.Attributes, saved.Definition.Attributes, fmt.Sprintf("expected attributes %v got %v\n", tmpl.Definition.Attributes, saved.Definition.Attributes))
+}
+
+func TestTemplateRetrieveByID(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	repo := mongodb.NewTemplateRepository(db)
+
+	id, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nonexistentID, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	_, err = repo.Save(context.Background(), twins.Template{Owner: email, Domain: domainID, ID: id})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	cases := []struct {
+		desc string
+		id   string
+		err  error
+	}{
+		{
+			desc: "retrieve an existing template",
+			id:   id,
+			err:  nil,
+		},
+		{
+			desc: "retrieve a non-existing template",
+			id:   nonexistentID,
+			err:  repoerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := repo.RetrieveByID(context.Background(), tc.id)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestTemplateRetrieveAll(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	_, err = db.Collection(templatesCollection).DeleteMany(context.Background(), bson.D{})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	repo := mongodb.NewTemplateRepository(db)
+
+	n := uint64(10)
+	for i := uint64(0); i < n; i++ {
+		id, err := idProvider.ID()
+		assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+		tmpl := twins.Template{
+			Owner:  email,
+			Domain: domainID,
+			ID:     id,
+		}
+		// Create first two templates with name.
+		if i < 2 {
+			tmpl.Name = validName
+		}
+		_, err = repo.Save(context.Background(), tmpl)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	}
+
+	foreignID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	_, err = repo.Save(context.Background(), twins.Template{Owner: email, Domain: otherDomainID, ID: foreignID})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	cases := []struct {
+		desc     string
+		domainID string
+		offset   uint64
+		limit    uint64
+		name     string
+		size     uint64
+		total    uint64
+	}{
+		{
+			desc:     "retrieve all templates of the domain",
+			domainID: domainID,
+			limit:    n,
+			size:     n,
+			total:    n,
+		},
+		{
+			desc:     "retrieve subset of templates",
+			domainID: domainID,
+			offset:   n / 2,
+			limit:    n,
+			size:     n / 2,
+			total:    n,
+		},
+		{
+			desc:     "retrieve templates with name",
+			domainID: domainID,
+			limit:    n,
+			name:     validName,
+			size:     2,
+			total:    2,
+		},
+		{
+			desc:     "retrieve templates of other domain",
+			domainID: otherDomainID,
+			limit:    n,
+			size:     1,
+			total:    1,
+		},
+	}
+
+	for _, tc := range cases {
+		page, err := repo.RetrieveAll(context.Background(), tc.domainID, tc.offset, tc.limit, tc.name)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s\n", tc.desc, err))
+		size := uint64(len(page.Templates))
+		assert.Equal(t, tc.size, size, fmt.Sprintf("%s: expected size %d got %d\n", tc.desc, tc.size, size))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected total %d got %d\n", tc.desc, tc.total, page.Total))
+	}
+}
+
+func TestTemplateRemove(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	repo := mongodb.NewTemplateRepository(db)
+
+	id, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	_, err = repo.Save(context.Background(), twins.Template{Owner: email, Domain: domainID, ID: id})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	cases := []struct {
+		desc string
+		id   string
+		err  error
+	}{
+		{
+			desc: "remove an existing template",
+			id:   id,
+			err:  nil,
+		},
+		{
+			desc: "remove a removed template",
+			id:   id,
+			err:  repoerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Remove(context.Background(), tc.id)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
@@ -17,6 +17,8 @@ import (
 const (
 	maxNameSize            = 1024
 	twinsCollection string = "twins"
+	// subtopicPattern selects the subtopics which may contain wildcards.
+	subtopicPattern = `[*+>#]`
 )
 
 type twinRepository struct {
@@ -53,15 +55,27 @@ func (tr *twinRepository) Update(ctx context.Context, tw twins.Twin) error {
 
 	coll := tr.db.Collection(twinsCollection)
 
-	filter := bson.M{"id": tw.ID}
+	// The liveness is changed by the liveness check only.
+	tw.Liveness = nil
+
+	// The twin is written only if nobody else updated it since it was
+	// retrieved, i.e. if the stored revision precedes the new one.
+	filter := bson.M{"id": tw.ID, "revision": tw.Revision - 1}
 	update := bson.M{"$set": tw}
 	res, err := coll.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
 
-	if res.ModifiedCount < 1 {
-		return repoerr.ErrNotFound
+	if res.MatchedCount < 1 {
+		n, err := coll.CountDocuments(ctx, bson.M{"id": tw.ID})
+		if err != nil {
+			return errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		if n < 1 {
+			return repoerr.ErrNotFound
+		}
+		return repoerr.ErrConflict
 	}
 
 	return nil
@@ -79,6 +93,9 @@ func (tr *twinRepository) RetrieveByID(ctx context.Context, twinID string) (twin
 	return tw, nil
 }
 
+// RetrieveByAttribute retrieves the twins bound to the channel and the
+// subtopic. The twins with the subtopic patterns of the channel are matched
+// against the subtopic after they are retrieved.
 func (tr *twinRepository) RetrieveByAttribute(ctx context.Context, channel, subtopic string) ([]string, error) {
 	coll := tr.db.Collection(twinsCollection)
 
@@ -94,16 +111,22 @@ func (tr *twinRepository) RetrieveByAttribute(ctx context.Context, channel, subt
 	}
 	match := bson.M{
 		"$match": bson.M{
-			"definition.channel": channel,
-			"$or": []interface{}{
-				bson.M{"definition.subtopic": subtopic},
-				bson.M{"definition.subtopic": twins.SubtopicWildcard},
+			"definition": bson.M{
+				"$elemMatch": bson.M{
+					"channel": channel,
+					"$or": []interface{}{
+						bson.M{"subtopic": subtopic},
+						bson.M{"subtopic": bson.M{"$regex": subtopicPattern}},
+					},
+				},
 			},
 		},
 	}
 	prj2 := bson.M{
 		"$project": bson.M{
-			"id": true,
+			"id":                  true,
+			"definition.channel":  true,
+			"definition.subtopic": true,
 		},
 	}
 
@@ -120,33 +143,43 @@ func (tr *twinRepository) RetrieveByAttribute(ctx context.Context, channel, subt
 	var ids []string
 	for cur.Next(ctx) {
 		var elem struct {
-			ID string `json:"id"`
+			ID         string            `json:"id"`
+			Definition []twins.Attribute `bson:"definition"`
 		}
 		err := cur.Decode(&elem)
 		if err != nil {
 			return ids, nil
 		}
-		ids = append(ids, elem.ID)
+		for _, attr := range elem.Definition {
+			if attr.Channel == channel && twins.MatchSubtopic(attr.Subtopic, subtopic) {
+				ids = append(ids, elem.ID)
+				break
+			}
+		}
 	}
 
 	return ids, nil
 }
 
-func (tr *twinRepository) RetrieveAll(ctx context.Context, owner string, offset, limit uint64, name string, metadata twins.Metadata) (twins.Page, error) {
+func (tr *twinRepository) RetrieveAll(ctx context.Context, domainID string, ids []string, offset, limit uint64, name, parentID string, metadata twins.Metadata) (twins.Page, error) {
 	coll := tr.db.Collection(twinsCollection)
 
 	findOptions := options.Find()
 	findOptions.SetSkip(int64(offset))
 	findOptions.SetLimit(int64(limit))
+	findOptions.SetSort(bson.D{{Key: "created", Value: 1}, {Key: "id", Value: 1}})
 
-	filter := bson.M{}
+	filter := bson.M{"domain": domainID}
 
-	if owner != "" {
-		filter["owner"] = owner
+	if ids != nil {
+		filter["id"] = bson.M{"$in": ids}
 	}
 	if name != "" {
 		filter["name"] = name
 	}
+	if parentID != "" {
+		filter["parent"] = parentID
+	}
 	if len(metadata) > 0 {
 		filter["metadata"] = metadata
 	}
@@ -175,6 +208,81 @@ func (tr *twinRepository) RetrieveAll(ctx context.Context, owner string, offset,
 	}, nil
 }
 
+func (tr *twinRepository) RetrieveChildren(ctx context.Context, parentIDs ...string) ([]twins.Twin, error) {
+	if len(parentIDs) == 0 {
+		return []twins.Twin{}, nil
+	}
+
+	coll := tr.db.Collection(twinsCollection)
+
+	filter := bson.M{"parent": bson.M{"$in": parentIDs}}
+	cur, err := coll.Find(ctx, filter)
+	if err != nil {
+		return []twins.Twin{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return decodeTwins(ctx, cur)
+}
+
+func (tr *twinRepository) RetrieveRetained(ctx context.Context, offset, limit uint64) (twins.Page, error) {
+	// Retention of the latest definition is null unless it is set.
+	filter := bson.M{
+		"$expr": bson.M{
+			"$gt": []interface{}{
+				bson.M{"$arrayElemAt": []interface{}{"$definitions.retention", -1}},
+				nil,
+			},
+		},
+	}
+
+	return tr.retrievePage(ctx, filter, offset, limit)
+}
+
+func (tr *twinRepository) RetrieveMonitored(ctx context.Context, offset, limit uint64) (twins.Page, error) {
+	// Update interval of the attributes is null unless it is set.
+	filter := bson.M{
+		"$expr": bson.M{
+			"$anyElementTrue": []interface{}{
+				bson.M{"$map": bson.M{
+					"input": bson.M{"$ifNull": []interface{}{bson.M{"$arrayElemAt": []interface{}{"$definitions.attributes", -1}}, []interface{}{}}},
+					"as":    "attr",
+					"in":    bson.M{"$gt": []interface{}{"$$attr.updateinterval", 0}},
+				}},
+			},
+		},
+	}
+
+	return tr.retrievePage(ctx, filter, offset, limit)
+}
+
+func (tr *twinRepository) RetrieveByTemplate(ctx context.Context, templateID string) ([]twins.Twin, error) {
+	coll := tr.db.Collection(twinsCollection)
+
+	filter := bson.M{"template": templateID}
+	cur, err := coll.Find(ctx, filter)
+	if err != nil {
+		return []twins.Twin{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return decodeTwins(ctx, cur)
+}
+
+func (tr *twinRepository) UpdateLiveness(ctx context.Context, twinID string, liveness twins.Liveness) error {
+	coll := tr.db.Collection(twinsCollection)
+
+	filter := bson.M{"id": twinID}
+	update := bson.M{"$set": bson.M{"liveness": liveness}}
+	res, err := coll.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.Wrap(repoerr.ErrUpdateEntity, err)
+	}
+	if res.MatchedCount < 1 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
 func (tr *twinRepository) Remove(ctx context.Context, twinID string) error {
 	coll := tr.db.Collection(twinsCollection)
 
@@ -208,3 +316,38 @@ func decodeTwins(ctx context.Context, cur *mongo.Cursor) ([]twins.Twin, error) {
 	}
 	return results, nil
 }
+
+// retrievePage retrieves the subset of twins matching the filter,
+// regardless of the domain.
+func (tr *twinRepository) retrievePage(ctx context.Context, filter bson.M, offset, limit uint64) (twins.Page, error) {
+	coll := tr.db.Collection(twinsCollection)
+
+	findOptions := options.Find()
+	findOptions.SetSkip(int64(offset))
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSort(bson.D{{Key: "id", Value: 1}})
+
+	cur, err := coll.Find(ctx, filter, findOptions)
+	if err != nil {
+		return twins.Page{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	results, err := decodeTwins(ctx, cur)
+	if err != nil {
+		return twins.Page{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	total, err := coll.CountDocuments(ctx, filter)
+	if err != nil {
+		return twins.Page{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return twins.Page{
+		Twins: results,
+		PageMetadata: twins.PageMetadata{
+			Total:  uint64(total),
+			Offset: offset,
+			Limit:  limit,
+		},
+	}, nil
+}
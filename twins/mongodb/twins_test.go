@@ -7,8 +7,10 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/absmach/supermq-contrib/twins"
 	"github.com/absmach/supermq-contrib/twins/mocks"
@@ -24,12 +26,14 @@ import (
 )
 
 const (
-	maxNameSize = 1024
-	testDB      = "test"
-	collection  = "twins"
-	email       = "mgx_twin@example.com"
-	validName   = "mgx_twin"
-	subtopic    = "engine"
+	maxNameSize   = 1024
+	testDB        = "test"
+	collection    = "twins"
+	email         = "mgx_twin@example.com"
+	validName     = "mgx_twin"
+	subtopic      = "engine"
+	domainID      = "b6a8a5fd-9ab7-41f2-8e5f-b2f2aa6cabe6"
+	otherDomainID = "2f5e3c1a-4d8b-4c7e-9a1f-6b3d2e8c9f0a"
 )
 
 var (
@@ -107,7 +111,9 @@ func TestTwinsUpdate(t *testing.T) {
 		testLog.Error(err.Error())
 	}
 
-	twin.Name = "new_name"
+	updated := twin
+	updated.Name = "new_name"
+	updated.Revision = twin.Revision + 1
 	cases := []struct {
 		desc string
 		twin twins.Twin
@@ -115,13 +121,19 @@ func TestTwinsUpdate(t *testing.T) {
 	}{
 		{
 			desc: "update existing twin",
-			twin: twin,
+			twin: updated,
 			err:  nil,
 		},
+		{
+			desc: "update existing twin with stale revision",
+			twin: updated,
+			err:  repoerr.ErrConflict,
+		},
 		{
 			desc: "update non-existing twin",
 			twin: twins.Twin{
-				ID: nonexistentTwinID,
+				ID:       nonexistentTwinID,
+				Revision: 1,
 			},
 			err: repoerr.ErrNotFound,
 		},
@@ -205,6 +217,12 @@ func TestTwinsRetrieveByAttribute(t *testing.T) {
 	nonEmpty := mocks.CreateTwin([]string{chID}, []string{subtopic})
 	_, err = repo.Save(context.Background(), nonEmpty)
 	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	singleLevel := mocks.CreateTwin([]string{chID}, []string{"sensors.*.temp"})
+	_, err = repo.Save(context.Background(), singleLevel)
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	multiLevel := mocks.CreateTwin([]string{chID}, []string{"sensors.>"})
+	_, err = repo.Save(context.Background(), multiLevel)
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
 	cases := []struct {
 		desc     string
@@ -226,6 +244,21 @@ func TestTwinsRetrieveByAttribute(t *testing.T) {
 			subtopic: subtopic,
 			ids:      []string{wildcard.ID, nonEmpty.ID},
 		},
+		{
+			desc:     "retrieve subtopic matching single-level and multi-level wildcards",
+			subtopic: "sensors.room_1.temp",
+			ids:      []string{wildcard.ID, singleLevel.ID, multiLevel.ID},
+		},
+		{
+			desc:     "retrieve subtopic matching multi-level wildcard",
+			subtopic: "sensors.room_1",
+			ids:      []string{wildcard.ID, multiLevel.ID},
+		},
+		{
+			desc:     "retrieve subtopic matching no wildcard",
+			subtopic: "sensors",
+			ids:      []string{wildcard.ID},
+		},
 	}
 
 	for _, tc := range cases {
@@ -236,7 +269,7 @@ func TestTwinsRetrieveByAttribute(t *testing.T) {
 }
 
 func TestTwinsRetrieveAll(t *testing.T) {
-	email := "twin-multi-retrieval@example.com"
+	userID := "8c5a9a5e-3c44-4a8f-9c1b-2f3f9e7d6a10"
 	name := "supermq"
 	metadata := twins.Metadata{
 		"type": "test",
@@ -254,15 +287,23 @@ func TestTwinsRetrieveAll(t *testing.T) {
 
 	twinRepo := mongodb.NewTwinRepository(db)
 
+	parentID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
 	n := uint64(10)
+	created := time.Now()
+	var ids []string
 	for i := uint64(0); i < n; i++ {
 		twid, err := idProvider.ID()
 		assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
 
 		tw := twins.Twin{
-			Owner:    email,
+			Owner:    userID,
+			Domain:   domainID,
 			ID:       twid,
 			Metadata: metadata,
+			// Twins are saved in the reverse order of their creation.
+			Created: created.Add(-time.Duration(i) * time.Minute),
 		}
 
 		// Create first two Twins with name.
@@ -272,10 +313,36 @@ func TestTwinsRetrieveAll(t *testing.T) {
 
 		_, err = twinRepo.Save(context.Background(), tw)
 		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+		ids = append(ids, twid)
+	}
+
+	// Create a child Twin of another user, and a Twin of the same owner
+	// in another domain.
+	childID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	child := twins.Twin{
+		Owner:  wrongValue,
+		Domain: domainID,
+		Parent: parentID,
+		ID:     childID,
+	}
+	_, err = twinRepo.Save(context.Background(), child)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	foreignID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	foreign := twins.Twin{
+		Owner:  userID,
+		Domain: otherDomainID,
+		ID:     foreignID,
 	}
+	_, err = twinRepo.Save(context.Background(), foreign)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
 
 	cases := map[string]struct {
-		owner    string
+		domain   string
+		ids      []string
+		parent   string
 		limit    uint64
 		offset   uint64
 		name     string
@@ -283,28 +350,94 @@ func TestTwinsRetrieveAll(t *testing.T) {
 		total    uint64
 		metadata twins.Metadata
 	}{
-		"retrieve all twins with existing owner": {
-			owner:  email,
+		"retrieve all twins with given IDs": {
+			domain: domainID,
+			ids:    append([]string{childID}, ids...),
 			offset: 0,
-			limit:  n,
-			size:   n,
-			total:  n,
+			limit:  2 * n,
+			size:   n + 1,
+			total:  n + 1,
 		},
-		"retrieve subset of twins with existing owner": {
-			owner:  email,
+		"retrieve subset of twins with given IDs": {
+			domain: domainID,
+			ids:    ids,
 			offset: 0,
 			limit:  n / 2,
 			size:   n / 2,
 			total:  n,
 		},
-		"retrieve twins with non-existing owner": {
-			owner:  wrongValue,
+		"retrieve twins with non-existing IDs": {
+			domain: domainID,
+			ids:    []string{"non-existing"},
+			offset: 0,
+			limit:  n,
+			size:   0,
+			total:  0,
+		},
+		"retrieve twins with no IDs": {
+			domain: domainID,
+			ids:    []string{},
+			offset: 0,
+			limit:  n,
+			size:   0,
+			total:  0,
+		},
+		"retrieve all domain twins": {
+			domain: domainID,
+			offset: 0,
+			limit:  2 * n,
+			size:   n + 1,
+			total:  n + 1,
+		},
+		"retrieve twins of another domain": {
+			domain: otherDomainID,
+			offset: 0,
+			limit:  n,
+			size:   1,
+			total:  1,
+		},
+		"retrieve twins of another domain with given IDs": {
+			domain: otherDomainID,
+			ids:    ids,
+			offset: 0,
+			limit:  n,
+			size:   0,
+			total:  0,
+		},
+		"retrieve twins with non-existing domain": {
+			domain: wrongValue,
+			offset: 0,
+			limit:  n,
+			size:   0,
+			total:  0,
+		},
+		"retrieve children of twin": {
+			domain: domainID,
+			parent: parentID,
+			offset: 0,
+			limit:  n,
+			size:   1,
+			total:  1,
+		},
+		"retrieve children of twin with given IDs": {
+			domain: domainID,
+			ids:    []string{childID},
+			parent: parentID,
+			offset: 0,
+			limit:  n,
+			size:   1,
+			total:  1,
+		},
+		"retrieve children of twin without children": {
+			domain: domainID,
+			parent: childID,
 			offset: 0,
 			limit:  n,
 			size:   0,
 			total:  0,
 		},
 		"retrieve twins with existing name": {
+			domain: domainID,
 			offset: 0,
 			limit:  1,
 			name:   name,
@@ -312,6 +445,7 @@ func TestTwinsRetrieveAll(t *testing.T) {
 			total:  2,
 		},
 		"retrieve twins with non-existing name": {
+			domain: domainID,
 			offset: 0,
 			limit:  n,
 			name:   "wrong",
@@ -319,6 +453,7 @@ func TestTwinsRetrieveAll(t *testing.T) {
 			total:  0,
 		},
 		"retrieve twins with metadata": {
+			domain:   domainID,
 			offset:   0,
 			limit:    n,
 			size:     n,
@@ -326,6 +461,7 @@ func TestTwinsRetrieveAll(t *testing.T) {
 			metadata: metadata,
 		},
 		"retrieve twins with wrong metadata": {
+			domain:   domainID,
 			offset:   0,
 			limit:    n,
 			size:     0,
@@ -335,12 +471,261 @@ func TestTwinsRetrieveAll(t *testing.T) {
 	}
 
 	for desc, tc := range cases {
-		page, err := twinRepo.RetrieveAll(context.Background(), tc.owner, tc.offset, tc.limit, tc.name, tc.metadata)
+		page, err := twinRepo.RetrieveAll(context.Background(), tc.domain, tc.ids, tc.offset, tc.limit, tc.name, tc.parent, tc.metadata)
 		size := uint64(len(page.Twins))
 		assert.Equal(t, tc.size, size, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.size, size))
 		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.total, page.Total))
 		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %d\n", desc, err))
+		sorted := sort.SliceIsSorted(page.Twins, func(i, j int) bool {
+			return page.Twins[i].Created.Before(page.Twins[j].Created)
+		})
+		assert.True(t, sorted, fmt.Sprintf("%s: expected twins ordered by creation time\n", desc))
+	}
+}
+
+func TestTwinsRetrieveRetained(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	repo := mongodb.NewTwinRepository(db)
+
+	chID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	retained := mocks.CreateTwin([]string{chID}, []string{subtopic})
+	retained.Definitions[0].Retention = &twins.Retention{MaxAge: int64(24 * time.Hour)}
+	notRetained := mocks.CreateTwin([]string{chID}, []string{subtopic})
+	for _, tw := range []*twins.Twin{&retained, &notRetained} {
+		tw.ID, err = idProvider.ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		_, err = repo.Save(context.Background(), *tw)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	}
+
+	page, err := repo.RetrieveRetained(context.Background(), 0, 100)
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	var ids []string
+	for _, tw := range page.Twins {
+		ids = append(ids, tw.ID)
+	}
+	assert.Contains(t, ids, retained.ID, fmt.Sprintf("retained twin %s not found in %v", retained.ID, ids))
+	assert.NotContains(t, ids, notRetained.ID, fmt.Sprintf("found twin %s without retention in %v", notRetained.ID, ids))
+}
+
+func TestTwinsRetrieveMonitored(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	repo := mongodb.NewTwinRepository(db)
+
+	chID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	monitored := mocks.CreateTwin([]string{chID}, []string{subtopic})
+	monitored.Definitions[0].Attributes[0].UpdateInterval = int64(time.Minute)
+	notMonitored := mocks.CreateTwin([]string{chID}, []string{subtopic})
+	for _, tw := range []*twins.Twin{&monitored, &notMonitored} {
+		tw.ID, err = idProvider.ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		_, err = repo.Save(context.Background(), *tw)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	}
+
+	page, err := repo.RetrieveMonitored(context.Background(), 0, 100)
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	var ids []string
+	for _, tw := range page.Twins {
+		ids = append(ids, tw.ID)
 	}
+	assert.Contains(t, ids, monitored.ID, fmt.Sprintf("monitored twin %s not found in %v", monitored.ID, ids))
+	assert.NotContains(t, ids, notMonitored.ID, fmt.Sprintf("found twin %s without update interval in %v", notMonitored.ID, ids))
+}
+
+func TestTwinsRetrieveChildren(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	_, err = db.Collection(collection).DeleteMany(context.Background(), bson.D{})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	twinRepo := mongodb.NewTwinRepository(db)
+
+	var ids []string
+	for i := 0; i < 4; i++ {
+		twid, err := idProvider.ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		ids = append(ids, twid)
+	}
+
+	// Create a chain of Twins where each Twin is the parent of the next one.
+	for i, id := range ids {
+		tw := twins.Twin{
+			Owner:  email,
+			Domain: domainID,
+			ID:     id,
+		}
+		if i > 0 {
+			tw.Parent = ids[i-1]
+		}
+		_, err := twinRepo.Save(context.Background(), tw)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	}
+
+	cases := []struct {
+		desc      string
+		parentIDs []string
+		children  []string
+	}{
+		{
+			desc:      "retrieve children of a twin",
+			parentIDs: []string{ids[0]},
+			children:  []string{ids[1]},
+		},
+		{
+			desc:      "retrieve children of multiple twins",
+			parentIDs: []string{ids[1], ids[2]},
+			children:  []string{ids[2], ids[3]},
+		},
+		{
+			desc:      "retrieve children of a twin without children",
+			parentIDs: []string{ids[3]},
+			children:  []string{},
+		},
+		{
+			desc:      "retrieve children without parents",
+			parentIDs: []string{},
+			children:  []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		children, err := twinRepo.RetrieveChildren(context.Background(), tc.parentIDs...)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s\n", tc.desc, err))
+		got := []string{}
+		for _, ch := range children {
+			got = append(got, ch.ID)
+		}
+		assert.ElementsMatch(t, tc.children, got, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.children, got))
+	}
+}
+
+func TestTwinsRetrieveByTemplate(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	twinRepo := mongodb.NewTwinRepository(db)
+
+	tmplID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	otherTmplID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	var derived []string
+	for _, id := range []string{tmplID, tmplID, otherTmplID, ""} {
+		twid, err := idProvider.ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		tw := twins.Twin{
+			Owner:            email,
+			Domain:           domainID,
+			ID:               twid,
+			Template:         id,
+			TemplateRevision: 1,
+			Bindings:         map[string]string{"channel": twid},
+		}
+		_, err = twinRepo.Save(context.Background(), tw)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+		if id == tmplID {
+			derived = append(derived, twid)
+		}
+	}
+
+	nonexistentTmplID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc     string
+		template string
+		ids      []string
+	}{
+		{
+			desc:     "retrieve twins derived from the template",
+			template: tmplID,
+			ids:      derived,
+		},
+		{
+			desc:     "retrieve twins derived from non-existing template",
+			template: nonexistentTmplID,
+			ids:      []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		tws, err := twinRepo.RetrieveByTemplate(context.Background(), tc.template)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s\n", tc.desc, err))
+		ids := []string{}
+		for _, tw := range tws {
+			ids = append(ids, tw.ID)
+			assert.Equal(t, tw.ID, tw.Bindings["channel"], fmt.Sprintf("%s: expected bindings to be retrieved\n", tc.desc))
+		}
+		assert.ElementsMatch(t, tc.ids, ids, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.ids, ids))
+	}
+}
+
+func TestTwinsUpdateLiveness(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(addr))
+	require.Nil(t, err, fmt.Sprintf("Creating new MongoDB client expected to succeed: %s.\n", err))
+
+	db := client.Database(testDB)
+	repo := mongodb.NewTwinRepository(db)
+
+	twid, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nonexistentTwinID, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	twin := twins.Twin{ID: twid, Revision: 1}
+	_, err = repo.Save(context.Background(), twin)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	liveness := twins.Liveness{Stale: []string{"temperature"}, Checked: time.Now()}
+	cases := []struct {
+		desc string
+		id   string
+		err  error
+	}{
+		{
+			desc: "update liveness of an existing twin",
+			id:   twid,
+			err:  nil,
+		},
+		{
+			desc: "update liveness of a non-existing twin",
+			id:   nonexistentTwinID,
+			err:  repoerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.UpdateLiveness(context.Background(), tc.id, liveness)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+
+	// The liveness is kept when the twin is updated.
+	twin.Revision++
+	twin.Name = "updated"
+	err = repo.Update(context.Background(), twin)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	tw, err := repo.RetrieveByID(context.Background(), twid)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	require.NotNil(t, tw.Liveness, "expected twin liveness")
+	assert.Equal(t, liveness.Stale, tw.Liveness.Stale, fmt.Sprintf("expected stale attributes %v got %v\n", liveness.Stale, tw.Liveness.Stale))
+	assert.Equal(t, twin.Revision, tw.Revision, fmt.Sprintf("expected revision %d got %d\n", twin.Revision, tw.Revision))
 }
 
 func TestTwinsRemove(t *testing.T) {
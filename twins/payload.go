@@ -0,0 +1,175 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/absmach/senml"
+	"github.com/absmach/supermq/pkg/errors"
+	"github.com/absmach/supermq/pkg/messaging"
+)
+
+var (
+	errInvalidPath = errors.New("invalid attribute payload path")
+	errPathMissing = errors.New("payload path not found")
+)
+
+// validatePaths verifies that the payload paths of the attribute are valid
+// JSON pointers.
+func validatePaths(attr Attribute) error {
+	if attr.Path == "" {
+		if attr.TimePath != "" {
+			return errors.Wrap(errInvalidPath, fmt.Errorf("time path of attribute %s requires the value path", attr.Name))
+		}
+		return nil
+	}
+	if attr.Expression != "" {
+		return errors.Wrap(errInvalidPath, fmt.Errorf("computed attribute %s cannot have a payload path", attr.Name))
+	}
+	for _, p := range []string{attr.Path, attr.TimePath} {
+		if p != "" && !strings.HasPrefix(p, "/") {
+			return errors.Wrap(errInvalidPath, fmt.Errorf("path %s of attribute %s is not a JSON pointer", p, attr.Name))
+		}
+	}
+
+	return nil
+}
+
+// boundRecord is the record decoded from the message for the attribute it
+// is bound to.
+type boundRecord struct {
+	attr Attribute
+	rec  senml.Record
+}
+
+// decodeRecords decodes the message payload into the records of the bound
+// attributes. SenML records are bound to the first attribute, unless the
+// attribute has the payload path. Such payloads are plain JSON documents,
+// which are converted to a record for each attribute with the payload path,
+// holding the value found at the path. Values of the attributes without the
+// time path share the time of the message. Attributes whose values are not
+// found are skipped, unless none of them is found.
+func decodeRecords(attrs []Attribute, msg *messaging.Message) ([]boundRecord, error) {
+	if len(attrs) == 0 {
+		return nil, nil
+	}
+	if attrs[0].Path == "" {
+		var recs []senml.Record
+		if err := json.Unmarshal(msg.GetPayload(), &recs); err != nil {
+			return nil, err
+		}
+		brs := make([]boundRecord, len(recs))
+		for i, rec := range recs {
+			brs[i] = boundRecord{attr: attrs[0], rec: rec}
+		}
+		return brs, nil
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(msg.GetPayload(), &doc); err != nil {
+		return nil, err
+	}
+
+	var (
+		brs   []boundRecord
+		first error
+	)
+	for _, attr := range attrs {
+		if attr.Path == "" {
+			continue
+		}
+		rec, err := pathRecord(attr, doc, msg.GetCreated())
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		brs = append(brs, boundRecord{attr: attr, rec: rec})
+	}
+	if len(brs) == 0 {
+		return nil, first
+	}
+
+	return brs, nil
+}
+
+// pathRecord creates the record of the attribute from the value found at
+// its payload path in the JSON decoded document of the message created at
+// the given time, in nanoseconds.
+func pathRecord(attr Attribute, doc interface{}, created int64) (senml.Record, error) {
+	val, ok := lookupPath(doc, attr.Path)
+	if !ok || val == nil {
+		return senml.Record{}, errors.Wrap(errPathMissing, errors.New(attr.Path))
+	}
+	rec := createRecord(attr.Name, val)
+
+	if attr.TimePath == "" {
+		rec.Time = float64(created) / nanosec
+		return rec, nil
+	}
+	t, ok := lookupPath(doc, attr.TimePath)
+	if !ok {
+		return senml.Record{}, errors.Wrap(errPathMissing, errors.New(attr.TimePath))
+	}
+	sec, err := toSeconds(t)
+	if err != nil {
+		return senml.Record{}, err
+	}
+	rec.Time = sec
+
+	return rec, nil
+}
+
+// lookupPath returns the value of the JSON decoded document referenced by
+// the JSON pointer as defined in RFC 6901.
+func lookupPath(doc interface{}, pointer string) (interface{}, bool) {
+	if pointer == "" {
+		return doc, true
+	}
+	cur := doc
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			next, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []interface{}:
+			idx, err := strconv.Atoi(token)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			cur = v[idx]
+		default:
+			return nil, false
+		}
+	}
+
+	return cur, true
+}
+
+// toSeconds converts the timestamp found in the JSON payload to the SenML
+// time, i.e. seconds since the Unix epoch. Timestamps are either numbers of
+// seconds or RFC 3339 formatted strings.
+func toSeconds(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return 0, err
+		}
+		return float64(t.UnixNano()) / nanosec, nil
+	}
+	return 0, fmt.Errorf("invalid timestamp %v", val)
+}
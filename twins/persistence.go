@@ -0,0 +1,163 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/absmach/senml"
+	"github.com/absmach/supermq/pkg/errors"
+)
+
+// Persistence policies deciding whether the received attribute value creates
+// a new state or is merged into the last one.
+const (
+	// DeltaPolicy creates a new state if the value is received later than
+	// the time delta after the last state was created, and updates the last
+	// state otherwise. It is the default policy.
+	DeltaPolicy = "delta"
+
+	// DeadbandPolicy creates a new state if the value differs from the last
+	// value by more than the deadband.
+	DeadbandPolicy = "deadband"
+
+	// ChangePolicy creates a new state only if the value changed.
+	ChangePolicy = "change"
+
+	// AlwaysPolicy creates a new state for every value.
+	AlwaysPolicy = "always"
+)
+
+var errInvalidPersistence = errors.New("invalid attribute persistence")
+
+// Persistence is the policy of creating states from the attribute values,
+// overriding the definition delta. Values which do not create a new state
+// under the deadband and the change policy are dropped, and only refresh
+// the liveness of the attribute and are checked against its alarm rules.
+type Persistence struct {
+	Policy string `json:"policy"`
+
+	// Delta is the time delta of the delta policy in nanoseconds. The delta
+	// of the definition is used if it is not set.
+	Delta int64 `json:"delta,omitempty"`
+
+	// Deadband is the deadband of the deadband policy, either absolute or,
+	// if Percent is set, relative to the last value.
+	Deadband float64 `json:"deadband,omitempty"`
+	Percent  bool    `json:"percent,omitempty"`
+}
+
+func validatePersistence(attr Attribute) error {
+	p := attr.Persistence
+	if p == nil {
+		return nil
+	}
+	if attr.Expression != "" {
+		return errors.Wrap(errInvalidPersistence, fmt.Errorf("computed attribute %s cannot have persistence policy", attr.Name))
+	}
+
+	switch p.Policy {
+	case DeltaPolicy:
+		if p.Delta < 0 {
+			return errors.Wrap(errInvalidPersistence, fmt.Errorf("negative delta of attribute %s", attr.Name))
+		}
+	case DeadbandPolicy:
+		if p.Deadband <= 0 {
+			return errors.Wrap(errInvalidPersistence, fmt.Errorf("deadband of attribute %s must be positive", attr.Name))
+		}
+		if attr.Type != "" && attr.Type != NumberType {
+			return errors.Wrap(errInvalidPersistence, fmt.Errorf("deadband of attribute %s requires number type", attr.Name))
+		}
+	case ChangePolicy, AlwaysPolicy:
+	default:
+		return errors.Wrap(errInvalidPersistence, fmt.Errorf("unknown policy %s of attribute %s", p.Policy, attr.Name))
+	}
+
+	if p.Delta != 0 && p.Policy != DeltaPolicy {
+		return errors.Wrap(errInvalidPersistence, fmt.Errorf("delta of attribute %s requires delta policy", attr.Name))
+	}
+	if (p.Deadband != 0 || p.Percent) && p.Policy != DeadbandPolicy {
+		return errors.Wrap(errInvalidPersistence, fmt.Errorf("deadband of attribute %s requires deadband policy", attr.Name))
+	}
+
+	return nil
+}
+
+// persistAction decides, by the persistence policy of the attribute the
+// value is received for, whether the value creates a new state, updates the
+// last state, only refreshes the liveness of the attribute, or is dropped.
+func persistAction(def Definition, attr Attribute, st State, rec senml.Record, val interface{}) int {
+	p := Persistence{Policy: DeltaPolicy}
+	if attr.Persistence != nil {
+		p = *attr.Persistence
+	}
+
+	last, ok := st.Payload[attr.Name]
+	switch p.Policy {
+	case AlwaysPolicy:
+		return save
+	case ChangePolicy:
+		if !ok || !sameValue(last, val) {
+			return save
+		}
+		return unchanged(attr)
+	case DeadbandPolicy:
+		if !ok || outsideDeadband(p, last, val) {
+			return save
+		}
+		return unchanged(attr)
+	default:
+		delta := def.Delta
+		if p.Delta > 0 {
+			delta = p.Delta
+		}
+		recNano := (rec.BaseTime + rec.Time) * nanosec
+		if recNano == 0 || math.Abs(float64(st.Created.UnixNano())-recNano) > float64(delta) {
+			return save
+		}
+		return update
+	}
+}
+
+// unchanged returns the action for the value which does not create a new
+// state. Such value refreshes the attribute liveness, if it is tracked.
+func unchanged(attr Attribute) int {
+	if attr.UpdateInterval > 0 {
+		return refreshed
+	}
+	return noop
+}
+
+// sameValue reports whether the values are equal, comparing numbers by
+// their value regardless of their type.
+func sameValue(a, b interface{}) bool {
+	x, okA := toNumber(a)
+	y, okB := toNumber(b)
+	if okA && okB {
+		return x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// outsideDeadband reports whether the value differs from the last value by
+// more than the deadband. Non-numeric values are outside the deadband if
+// they changed.
+func outsideDeadband(p Persistence, last, val interface{}) bool {
+	x, okLast := toNumber(last)
+	y, okVal := toNumber(val)
+	if !okLast || !okVal {
+		return !sameValue(last, val)
+	}
+
+	diff := math.Abs(y - x)
+	if !p.Percent {
+		return diff > p.Deadband
+	}
+	if x == 0 {
+		return diff > 0
+	}
+	return diff/math.Abs(x)*100 > p.Deadband
+}
@@ -0,0 +1,151 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package postgres
+
+import migrate "github.com/rubenv/sql-migrate"
+
+// Migration returns the migrations of the twins database.
+func Migration() *migrate.MemoryMigrationSource {
+	return &migrate.MemoryMigrationSource{
+		Migrations: []*migrate.Migration{
+			{
+				Id: "twins_1",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS twins (
+                        id                VARCHAR(36) PRIMARY KEY,
+                        owner             VARCHAR(254),
+                        domain_id         VARCHAR(36),
+                        parent_id         VARCHAR(36),
+                        template_id       VARCHAR(36),
+                        template_revision INTEGER NOT NULL DEFAULT 0,
+                        bindings          JSONB,
+                        name              VARCHAR(1024),
+                        created           TIMESTAMPTZ,
+                        updated           TIMESTAMPTZ,
+                        revision          INTEGER NOT NULL DEFAULT 0,
+                        definitions       JSONB NOT NULL DEFAULT '[]',
+                        metadata          JSONB
+                    )`,
+					`CREATE INDEX IF NOT EXISTS twins_domain_idx ON twins (domain_id)`,
+					`CREATE INDEX IF NOT EXISTS twins_parent_idx ON twins (parent_id)`,
+					`CREATE INDEX IF NOT EXISTS twins_template_idx ON twins (template_id)`,
+					// Supports the lookup of the twins by the channel and the
+					// subtopic of the attributes of their latest definition.
+					`CREATE INDEX IF NOT EXISTS twins_attributes_idx ON twins USING GIN ((definitions -> -1 -> 'attributes') jsonb_path_ops)`,
+					`CREATE TABLE IF NOT EXISTS states (
+                        twin_id     VARCHAR(36) NOT NULL,
+                        id          BIGINT NOT NULL,
+                        definition  INTEGER NOT NULL,
+                        created     TIMESTAMPTZ NOT NULL,
+                        payload     JSONB,
+                        PRIMARY KEY (twin_id, id)
+                    )`,
+					`CREATE INDEX IF NOT EXISTS states_created_idx ON states (twin_id, created)`,
+					`CREATE TABLE IF NOT EXISTS desired_states (
+                        twin_id     VARCHAR(36) PRIMARY KEY,
+                        updated     TIMESTAMPTZ,
+                        payload     JSONB
+                    )`,
+					`CREATE TABLE IF NOT EXISTS templates (
+                        id          VARCHAR(36) PRIMARY KEY,
+                        owner       VARCHAR(254),
+                        domain_id   VARCHAR(36),
+                        name        VARCHAR(1024),
+                        created     TIMESTAMPTZ,
+                        updated     TIMESTAMPTZ,
+                        revision    INTEGER NOT NULL DEFAULT 0,
+                        definition  JSONB,
+                        metadata    JSONB
+                    )`,
+					`CREATE INDEX IF NOT EXISTS templates_domain_idx ON templates (domain_id)`,
+				},
+				Down: []string{
+					"DROP TABLE IF EXISTS templates",
+					"DROP TABLE IF EXISTS desired_states",
+					"DROP TABLE IF EXISTS states",
+					"DROP TABLE IF EXISTS twins",
+				},
+			},
+			{
+				Id: "twins_2",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS alarms (
+                        id          VARCHAR(36) PRIMARY KEY,
+                        twin_id     VARCHAR(36) NOT NULL,
+                        attribute   VARCHAR(1024) NOT NULL,
+                        rule        VARCHAR(1024) NOT NULL,
+                        condition   VARCHAR(16) NOT NULL,
+                        severity    VARCHAR(16) NOT NULL,
+                        status      VARCHAR(16) NOT NULL,
+                        value       DOUBLE PRECISION,
+                        raised      TIMESTAMPTZ NOT NULL,
+                        cleared     TIMESTAMPTZ
+                    )`,
+					`CREATE INDEX IF NOT EXISTS alarms_twin_idx ON alarms (twin_id, raised)`,
+				},
+				Down: []string{
+					"DROP TABLE IF EXISTS alarms",
+				},
+			},
+			{
+				Id: "twins_3",
+				Up: []string{
+					`ALTER TABLE states ADD COLUMN IF NOT EXISTS updated JSONB`,
+					`ALTER TABLE states ADD COLUMN IF NOT EXISTS stale JSONB`,
+				},
+				Down: []string{
+					"ALTER TABLE states DROP COLUMN IF EXISTS stale",
+					"ALTER TABLE states DROP COLUMN IF EXISTS updated",
+				},
+			},
+			{
+				Id: "twins_4",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS relations (
+                        domain_id   VARCHAR(36) NOT NULL,
+                        source_id   VARCHAR(36) NOT NULL,
+                        target_id   VARCHAR(36) NOT NULL,
+                        type        VARCHAR(64) NOT NULL,
+                        created     TIMESTAMPTZ NOT NULL,
+                        metadata    JSONB,
+                        PRIMARY KEY (source_id, type, target_id)
+                    )`,
+					`CREATE INDEX IF NOT EXISTS relations_target_idx ON relations (target_id, type)`,
+				},
+				Down: []string{
+					"DROP TABLE IF EXISTS relations",
+				},
+			},
+			{
+				// Simulated replays are kept apart from the live history.
+				Id: "twins_5",
+				Up: []string{
+					`CREATE TABLE IF NOT EXISTS simulated_states (
+                        twin_id     VARCHAR(36) NOT NULL,
+                        id          BIGINT NOT NULL,
+                        definition  INTEGER NOT NULL,
+                        created     TIMESTAMPTZ NOT NULL,
+                        payload     JSONB,
+                        updated     JSONB,
+                        stale       JSONB,
+                        PRIMARY KEY (twin_id, id)
+                    )`,
+				},
+				Down: []string{
+					"DROP TABLE IF EXISTS simulated_states",
+				},
+			},
+			{
+				// Twins without states keep the outcome of their liveness check.
+				Id: "twins_6",
+				Up: []string{
+					`ALTER TABLE twins ADD COLUMN IF NOT EXISTS liveness JSONB`,
+				},
+				Down: []string{
+					"ALTER TABLE twins DROP COLUMN IF EXISTS liveness",
+				},
+			},
+		},
+	}
+}
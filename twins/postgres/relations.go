@@ -0,0 +1,196 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/absmach/supermq-contrib/twins"
+	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	"github.com/absmach/supermq/pkg/postgres"
+)
+
+const relationColumns = `domain_id, source_id, target_id, type, created, metadata`
+
+var _ twins.RelationRepository = (*relationRepository)(nil)
+
+type relationRepository struct {
+	db postgres.Database
+}
+
+// NewRelationRepository instantiates a PostgreSQL implementation of relation
+// repository.
+func NewRelationRepository(db postgres.Database) twins.RelationRepository {
+	return &relationRepository{
+		db: db,
+	}
+}
+
+func (rr *relationRepository) Save(ctx context.Context, rel twins.Relation) error {
+	dbrel, err := toDBRelation(rel)
+	if err != nil {
+		return errors.Wrap(repoerr.ErrCreateEntity, err)
+	}
+
+	q := fmt.Sprintf(`INSERT INTO relations (%s) VALUES (:domain_id, :source_id, :target_id, :type, :created, :metadata)`, relationColumns)
+	if _, err := rr.db.NamedExecContext(ctx, q, dbrel); err != nil {
+		return postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+func (rr *relationRepository) Remove(ctx context.Context, source, relType, target string) error {
+	q := `DELETE FROM relations WHERE source_id = $1 AND type = $2 AND target_id = $3`
+	res, err := rr.db.ExecContext(ctx, q, source, relType, target)
+	if err != nil {
+		return errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+	if cnt < 1 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
+func (rr *relationRepository) RemoveByTwin(ctx context.Context, twinID string) error {
+	if _, err := rr.db.ExecContext(ctx, `DELETE FROM relations WHERE source_id = $1 OR target_id = $1`, twinID); err != nil {
+		return errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+
+	return nil
+}
+
+func (rr *relationRepository) RetrieveAll(ctx context.Context, twinID string, offset, limit uint64, filter twins.RelationFilter) (twins.RelationsPage, error) {
+	where, params := relationFilter([]string{twinID}, filter)
+	params["offset"] = offset
+	params["limit"] = limit
+
+	q := fmt.Sprintf(`SELECT %s FROM relations %s ORDER BY created, source_id, type, target_id LIMIT :limit OFFSET :offset`, relationColumns, where)
+	results, err := rr.retrieve(ctx, q, params)
+	if err != nil {
+		return twins.RelationsPage{}, err
+	}
+
+	total, err := postgres.Total(ctx, rr.db, fmt.Sprintf(`SELECT COUNT(*) FROM relations %s`, where), params)
+	if err != nil {
+		return twins.RelationsPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return twins.RelationsPage{
+		Relations: results,
+		PageMetadata: twins.PageMetadata{
+			Total:  total,
+			Offset: offset,
+			Limit:  limit,
+		},
+	}, nil
+}
+
+func (rr *relationRepository) RetrieveByTwins(ctx context.Context, twinIDs []string, filter twins.RelationFilter) ([]twins.Relation, error) {
+	if len(twinIDs) == 0 {
+		return []twins.Relation{}, nil
+	}
+
+	where, params := relationFilter(twinIDs, filter)
+	q := fmt.Sprintf(`SELECT %s FROM relations %s ORDER BY created, source_id, type, target_id`, relationColumns, where)
+
+	return rr.retrieve(ctx, q, params)
+}
+
+func (rr *relationRepository) retrieve(ctx context.Context, query string, params map[string]interface{}) ([]twins.Relation, error) {
+	rows, err := rr.db.NamedQueryContext(ctx, query, params)
+	if err != nil {
+		return []twins.Relation{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	results := []twins.Relation{}
+	for rows.Next() {
+		var dbrel dbRelation
+		if err := rows.StructScan(&dbrel); err != nil {
+			return []twins.Relation{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		rel, err := toRelation(dbrel)
+		if err != nil {
+			return []twins.Relation{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		results = append(results, rel)
+	}
+
+	return results, nil
+}
+
+// relationFilter selects the relations of the twins in the direction
+// specified by the filter.
+func relationFilter(twinIDs []string, filter twins.RelationFilter) (string, map[string]interface{}) {
+	params := map[string]interface{}{
+		"twin_ids": twinIDs,
+	}
+
+	var conds []string
+	switch filter.Direction {
+	case twins.OutgoingDir:
+		conds = append(conds, "source_id = ANY(:twin_ids)")
+	case twins.IncomingDir:
+		conds = append(conds, "target_id = ANY(:twin_ids)")
+	default:
+		conds = append(conds, "(source_id = ANY(:twin_ids) OR target_id = ANY(:twin_ids))")
+	}
+	if len(filter.Types) > 0 {
+		params["types"] = filter.Types
+		conds = append(conds, "type = ANY(:types)")
+	}
+
+	return fmt.Sprintf("WHERE %s", strings.Join(conds, " AND ")), params
+}
+
+type dbRelation struct {
+	Domain   string    `db:"domain_id"`
+	Source   string    `db:"source_id"`
+	Target   string    `db:"target_id"`
+	Type     string    `db:"type"`
+	Created  time.Time `db:"created"`
+	Metadata []byte    `db:"metadata"`
+}
+
+func toDBRelation(rel twins.Relation) (dbRelation, error) {
+	metadata, err := toJSON(rel.Metadata)
+	if err != nil {
+		return dbRelation{}, err
+	}
+
+	return dbRelation{
+		Domain:   rel.Domain,
+		Source:   rel.Source,
+		Target:   rel.Target,
+		Type:     rel.Type,
+		Created:  rel.Created,
+		Metadata: metadata,
+	}, nil
+}
+
+func toRelation(dbrel dbRelation) (twins.Relation, error) {
+	rel := twins.Relation{
+		Domain:  dbrel.Domain,
+		Source:  dbrel.Source,
+		Target:  dbrel.Target,
+		Type:    dbrel.Type,
+		Created: dbrel.Created,
+	}
+	if err := fromJSON(dbrel.Metadata, &rel.Metadata); err != nil {
+		return twins.Relation{}, err
+	}
+
+	return rel, nil
+}
@@ -0,0 +1,207 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/absmach/supermq-contrib/twins"
+	"github.com/absmach/supermq-contrib/twins/postgres"
+	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelationSave(t *testing.T) {
+	repo := postgres.NewRelationRepository(database)
+
+	source, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	target, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	rel := twins.Relation{
+		Domain:   domainID,
+		Source:   source,
+		Target:   target,
+		Type:     "feeds",
+		Created:  time.Now(),
+		Metadata: twins.Metadata{"pipe": "p-1"},
+	}
+	reversed := rel
+	reversed.Source, reversed.Target = target, source
+
+	cases := []struct {
+		desc string
+		rel  twins.Relation
+		err  error
+	}{
+		{
+			desc: "create new relation",
+			rel:  rel,
+			err:  nil,
+		},
+		{
+			desc: "create existing relation",
+			rel:  rel,
+			err:  repoerr.ErrConflict,
+		},
+		{
+			desc: "create reversed relation",
+			rel:  reversed,
+			err:  nil,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Save(context.Background(), tc.rel)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestRelationRemove(t *testing.T) {
+	repo := postgres.NewRelationRepository(database)
+
+	source, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	target, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	rel := twins.Relation{Domain: domainID, Source: source, Target: target, Type: "feeds", Created: time.Now()}
+	err = repo.Save(context.Background(), rel)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	cases := []struct {
+		desc string
+		rel  twins.Relation
+		err  error
+	}{
+		{
+			desc: "remove existing relation",
+			rel:  rel,
+			err:  nil,
+		},
+		{
+			desc: "remove removed relation",
+			rel:  rel,
+			err:  repoerr.ErrNotFound,
+		},
+		{
+			desc: "remove relation of another type",
+			rel:  twins.Relation{Source: source, Target: target, Type: "powers"},
+			err:  repoerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Remove(context.Background(), tc.rel.Source, tc.rel.Type, tc.rel.Target)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestRelationRetrieve(t *testing.T) {
+	_, err := db.Exec("DELETE FROM relations")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	repo := postgres.NewRelationRepository(database)
+
+	ids := make([]string, 4)
+	for i := range ids {
+		id, err := idProvider.ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		ids[i] = id
+	}
+	pump, tank, boiler, meter := ids[0], ids[1], ids[2], ids[3]
+
+	// pump feeds tank, which feeds boiler, while meter measures tank.
+	rels := []twins.Relation{
+		{Domain: domainID, Source: pump, Target: tank, Type: "feeds"},
+		{Domain: domainID, Source: tank, Target: boiler, Type: "feeds"},
+		{Domain: domainID, Source: meter, Target: tank, Type: "measures"},
+	}
+	for i, rel := range rels {
+		rel.Created = time.Now().Add(time.Duration(i) * time.Second)
+		err := repo.Save(context.Background(), rel)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	}
+
+	cases := []struct {
+		desc   string
+		twinID string
+		offset uint64
+		limit  uint64
+		filter twins.RelationFilter
+		size   uint64
+		total  uint64
+	}{
+		{
+			desc:   "retrieve all relations of twin",
+			twinID: tank,
+			limit:  10,
+			size:   3,
+			total:  3,
+		},
+		{
+			desc:   "retrieve subset of relations of twin",
+			twinID: tank,
+			offset: 1,
+			limit:  1,
+			size:   1,
+			total:  3,
+		},
+		{
+			desc:   "retrieve outgoing relations of twin",
+			twinID: tank,
+			limit:  10,
+			filter: twins.RelationFilter{Direction: twins.OutgoingDir},
+			size:   1,
+			total:  1,
+		},
+		{
+			desc:   "retrieve incoming relations of twin by type",
+			twinID: tank,
+			limit:  10,
+			filter: twins.RelationFilter{Types: []string{"measures"}, Direction: twins.IncomingDir},
+			size:   1,
+			total:  1,
+		},
+		{
+			desc:   "retrieve relations of twin without relations",
+			twinID: wrongValue,
+			limit:  10,
+			size:   0,
+			total:  0,
+		},
+	}
+
+	for _, tc := range cases {
+		page, err := repo.RetrieveAll(context.Background(), tc.twinID, tc.offset, tc.limit, tc.filter)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s\n", tc.desc, err))
+		assert.Equal(t, tc.size, uint64(len(page.Relations)), fmt.Sprintf("%s: expected %d relations got %d\n", tc.desc, tc.size, len(page.Relations)))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected total %d got %d\n", tc.desc, tc.total, page.Total))
+	}
+
+	byTwins, err := repo.RetrieveByTwins(context.Background(), []string{pump, boiler}, twins.RelationFilter{Direction: twins.BothDirs})
+	assert.Nil(t, err, fmt.Sprintf("retrieve relations by twins: unexpected error: %s\n", err))
+	assert.Equal(t, rels[:2], stripCreated(byTwins), fmt.Sprintf("retrieve relations by twins: expected %v got %v\n", rels[:2], byTwins))
+
+	err = repo.RemoveByTwin(context.Background(), tank)
+	assert.Nil(t, err, fmt.Sprintf("remove relations of twin: unexpected error: %s\n", err))
+	page, err := repo.RetrieveAll(context.Background(), tank, 0, 10, twins.RelationFilter{})
+	assert.Nil(t, err, fmt.Sprintf("retrieve relations of removed twin: unexpected error: %s\n", err))
+	assert.Equal(t, uint64(0), page.Total, fmt.Sprintf("retrieve relations of removed twin: expected no relations got %d\n", page.Total))
+}
+
+// stripCreated clears the creation time of the retrieved relations, which
+// the database may store with a different precision.
+func stripCreated(rels []twins.Relation) []twins.Relation {
+	for i := range rels {
+		rels[i].Created = time.Time{}
+	}
+	return rels
+}
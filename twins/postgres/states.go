@@ -0,0 +1,562 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/absmach/supermq-contrib/twins"
+	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	"github.com/absmach/supermq/pkg/postgres"
+)
+
+const (
+	descDir      = "desc"
+	stateColumns = `twin_id, id, definition, created, payload, updated, stale`
+	alarmColumns = `id, twin_id, attribute, rule, condition, severity, status, value, raised, cleared`
+
+	// simulatedBatch is the number of the simulated states inserted at once.
+	simulatedBatch = 1000
+)
+
+var _ twins.StateRepository = (*stateRepository)(nil)
+
+type stateRepository struct {
+	db postgres.Database
+}
+
+// NewStateRepository instantiates a PostgreSQL implementation of state
+// repository.
+func NewStateRepository(db postgres.Database) twins.StateRepository {
+	return &stateRepository{
+		db: db,
+	}
+}
+
+// Save persists the states.
+func (sr *stateRepository) Save(ctx context.Context, sts ...twins.State) error {
+	if len(sts) == 0 {
+		return nil
+	}
+
+	dbsts := make([]dbState, len(sts))
+	for i, st := range sts {
+		dbst, err := toDBState(st)
+		if err != nil {
+			return errors.Wrap(repoerr.ErrCreateEntity, err)
+		}
+		dbsts[i] = dbst
+	}
+
+	q := `INSERT INTO states (twin_id, id, definition, created, payload, updated, stale) VALUES (:twin_id, :id, :definition, :created, :payload, :updated, :stale)`
+	if _, err := sr.db.NamedExecContext(ctx, q, dbsts); err != nil {
+		return postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+// Update persists the state.
+func (sr *stateRepository) Update(ctx context.Context, st twins.State) error {
+	dbst, err := toDBState(st)
+	if err != nil {
+		return errors.Wrap(repoerr.ErrUpdateEntity, err)
+	}
+
+	q := `UPDATE states SET definition = :definition, created = :created, payload = :payload, updated = :updated, stale = :stale WHERE twin_id = :twin_id AND id = :id`
+	if _, err := sr.db.NamedExecContext(ctx, q, dbst); err != nil {
+		return postgres.HandleError(repoerr.ErrUpdateEntity, err)
+	}
+
+	return nil
+}
+
+// UpdateStale persists the stale attributes of the state.
+func (sr *stateRepository) UpdateStale(ctx context.Context, st twins.State) error {
+	dbst, err := toDBState(st)
+	if err != nil {
+		return errors.Wrap(repoerr.ErrUpdateEntity, err)
+	}
+
+	q := `UPDATE states SET stale = :stale WHERE twin_id = :twin_id AND id = :id`
+	if _, err := sr.db.NamedExecContext(ctx, q, dbst); err != nil {
+		return postgres.HandleError(repoerr.ErrUpdateEntity, err)
+	}
+
+	return nil
+}
+
+// Count returns the number of states related to twin.
+func (sr *stateRepository) Count(ctx context.Context, tw twins.Twin) (int64, error) {
+	var total int64
+	if err := sr.db.QueryRowxContext(ctx, `SELECT COUNT(*) FROM states WHERE twin_id = $1`, tw.ID).Scan(&total); err != nil {
+		return 0, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return total, nil
+}
+
+// RetrieveAll retrieves the subset of states related to twin specified by id.
+func (sr *stateRepository) RetrieveAll(ctx context.Context, offset, limit uint64, twinID string, sf twins.StateFilter) (twins.StatesPage, error) {
+	order := "ASC"
+	if sf.Dir == descDir {
+		order = "DESC"
+	}
+
+	where, params := stateFilter(twinID, sf)
+	params["offset"] = offset
+	params["limit"] = limit
+
+	table := "states"
+	if sf.Simulated {
+		table = "simulated_states"
+	}
+
+	q := fmt.Sprintf(`SELECT %s FROM %s %s ORDER BY id %s LIMIT :limit OFFSET :offset`, stateColumns, table, where, order)
+	rows, err := sr.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return twins.StatesPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	results := []twins.State{}
+	for rows.Next() {
+		var dbst dbState
+		if err := rows.StructScan(&dbst); err != nil {
+			return twins.StatesPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		st, err := toState(dbst)
+		if err != nil {
+			return twins.StatesPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		results = append(results, st)
+	}
+
+	total, err := postgres.Total(ctx, sr.db, fmt.Sprintf(`SELECT COUNT(*) FROM %s %s`, table, where), params)
+	if err != nil {
+		return twins.StatesPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return twins.StatesPage{
+		States: results,
+		PageMetadata: twins.PageMetadata{
+			Total:  total,
+			Offset: offset,
+			Limit:  limit,
+		},
+	}, nil
+}
+
+// RetrieveLast returns the last state related to twin spec by id.
+func (sr *stateRepository) RetrieveLast(ctx context.Context, twinID string) (twins.State, error) {
+	q := fmt.Sprintf(`SELECT %s FROM states WHERE twin_id = $1 ORDER BY id DESC LIMIT 1`, stateColumns)
+
+	st, err := sr.retrieveOne(ctx, q, twinID)
+	if err == repoerr.ErrNotFound {
+		return twins.State{}, nil
+	}
+
+	return st, err
+}
+
+// RetrieveByID returns the state related to twin spec by id with the given
+// state id.
+func (sr *stateRepository) RetrieveByID(ctx context.Context, twinID string, id int64) (twins.State, error) {
+	q := fmt.Sprintf(`SELECT %s FROM states WHERE twin_id = $1 AND id = $2`, stateColumns)
+
+	return sr.retrieveOne(ctx, q, twinID, id)
+}
+
+// RetrieveAt returns the last state related to twin spec by id created at or
+// before the given time.
+func (sr *stateRepository) RetrieveAt(ctx context.Context, twinID string, at time.Time) (twins.State, error) {
+	q := fmt.Sprintf(`SELECT %s FROM states WHERE twin_id = $1 AND created <= $2 ORDER BY created DESC, id DESC LIMIT 1`, stateColumns)
+
+	return sr.retrieveOne(ctx, q, twinID, at)
+}
+
+// RemoveBefore removes the states of the twin created before the given time.
+func (sr *stateRepository) RemoveBefore(ctx context.Context, twinID string, before time.Time) (int64, error) {
+	res, err := sr.db.ExecContext(ctx, `DELETE FROM states WHERE twin_id = $1 AND created < $2`, twinID, before)
+	if err != nil {
+		return 0, errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+
+	return cnt, nil
+}
+
+// RemoveAfter removes the states of the twin with the id greater than the
+// given one.
+func (sr *stateRepository) RemoveAfter(ctx context.Context, twinID string, id int64) (int64, error) {
+	res, err := sr.db.ExecContext(ctx, `DELETE FROM states WHERE twin_id = $1 AND id > $2`, twinID, id)
+	if err != nil {
+		return 0, errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+
+	return cnt, nil
+}
+
+// SaveSimulated replaces the simulated states of the twin at once.
+func (sr *stateRepository) SaveSimulated(ctx context.Context, twinID string, sts ...twins.State) (err error) {
+	dbsts := make([]dbState, len(sts))
+	for i, st := range sts {
+		if dbsts[i], err = toDBState(st); err != nil {
+			return errors.Wrap(repoerr.ErrCreateEntity, err)
+		}
+	}
+
+	tx, err := sr.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(repoerr.ErrCreateEntity, err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				err = errors.Wrap(err, rbErr)
+			}
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM simulated_states WHERE twin_id = $1`, twinID); err != nil {
+		return errors.Wrap(repoerr.ErrCreateEntity, err)
+	}
+	// States are inserted in batches to stay within the bind parameters
+	// limit.
+	q := `INSERT INTO simulated_states (twin_id, id, definition, created, payload, updated, stale) VALUES (:twin_id, :id, :definition, :created, :payload, :updated, :stale)`
+	for len(dbsts) > 0 {
+		n := min(len(dbsts), simulatedBatch)
+		if _, err = tx.NamedExecContext(ctx, q, dbsts[:n]); err != nil {
+			return postgres.HandleError(repoerr.ErrCreateEntity, err)
+		}
+		dbsts = dbsts[n:]
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrap(repoerr.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+// Compact removes the states of the twin created before the given time,
+// except the last state of each bucket.
+func (sr *stateRepository) Compact(ctx context.Context, twinID string, before time.Time, bucket time.Duration) (int64, error) {
+	q := `DELETE FROM states WHERE twin_id = $1 AND created < $2 AND id NOT IN (
+		SELECT DISTINCT ON (floor(extract(epoch FROM created) / $3)) id FROM states
+		WHERE twin_id = $1 AND created < $2
+		ORDER BY floor(extract(epoch FROM created) / $3), created DESC, id DESC)`
+	res, err := sr.db.ExecContext(ctx, q, twinID, before, bucket.Seconds())
+	if err != nil {
+		return 0, errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+
+	return cnt, nil
+}
+
+// SaveDesired creates or replaces the desired state of the twin.
+func (sr *stateRepository) SaveDesired(ctx context.Context, ds twins.DesiredState) error {
+	payload, err := toJSON(ds.Payload)
+	if err != nil {
+		return errors.Wrap(repoerr.ErrCreateEntity, err)
+	}
+	dbds := dbDesiredState{
+		TwinID:  ds.TwinID,
+		Updated: ds.Updated,
+		Payload: payload,
+	}
+
+	q := `INSERT INTO desired_states (twin_id, updated, payload) VALUES (:twin_id, :updated, :payload)
+		ON CONFLICT (twin_id) DO UPDATE SET updated = EXCLUDED.updated, payload = EXCLUDED.payload`
+	if _, err := sr.db.NamedExecContext(ctx, q, dbds); err != nil {
+		return postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+// RetrieveDesired returns the desired state of the twin specified by id.
+func (sr *stateRepository) RetrieveDesired(ctx context.Context, twinID string) (twins.DesiredState, error) {
+	q := `SELECT twin_id, updated, payload FROM desired_states WHERE twin_id = $1`
+
+	var dbds dbDesiredState
+	if err := sr.db.QueryRowxContext(ctx, q, twinID).StructScan(&dbds); err != nil {
+		if err == sql.ErrNoRows {
+			return twins.DesiredState{}, repoerr.ErrNotFound
+		}
+		return twins.DesiredState{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	ds := twins.DesiredState{
+		TwinID:  dbds.TwinID,
+		Updated: dbds.Updated,
+	}
+	if err := fromJSON(dbds.Payload, &ds.Payload); err != nil {
+		return twins.DesiredState{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return ds, nil
+}
+
+// SaveAlarm creates or replaces the alarm.
+func (sr *stateRepository) SaveAlarm(ctx context.Context, alarm twins.Alarm) error {
+	q := fmt.Sprintf(`INSERT INTO alarms (%s) VALUES (:id, :twin_id, :attribute, :rule, :condition, :severity, :status, :value, :raised, :cleared)
+		ON CONFLICT (id) DO UPDATE SET status = EXCLUDED.status, cleared = EXCLUDED.cleared`, alarmColumns)
+	if _, err := sr.db.NamedExecContext(ctx, q, toDBAlarm(alarm)); err != nil {
+		return postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+
+	return nil
+}
+
+// RetrieveActiveAlarms returns the raised alarms of the twin specified by id.
+func (sr *stateRepository) RetrieveActiveAlarms(ctx context.Context, twinID string) ([]twins.Alarm, error) {
+	q := fmt.Sprintf(`SELECT %s FROM alarms WHERE twin_id = $1 AND status = $2`, alarmColumns)
+	rows, err := sr.db.QueryxContext(ctx, q, twinID, twins.RaisedStatus)
+	if err != nil {
+		return nil, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	var results []twins.Alarm
+	for rows.Next() {
+		var dba dbAlarm
+		if err := rows.StructScan(&dba); err != nil {
+			return nil, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		results = append(results, toAlarm(dba))
+	}
+
+	return results, nil
+}
+
+// RetrieveAlarms retrieves the subset of alarms of the twin specified by id,
+// newest first.
+func (sr *stateRepository) RetrieveAlarms(ctx context.Context, offset, limit uint64, twinID string, af twins.AlarmFilter) (twins.AlarmsPage, error) {
+	params := map[string]interface{}{
+		"twin_id": twinID,
+		"offset":  offset,
+		"limit":   limit,
+	}
+	conds := []string{"twin_id = :twin_id"}
+	if af.Attribute != "" {
+		params["attribute"] = af.Attribute
+		conds = append(conds, "attribute = :attribute")
+	}
+	if af.Severity != "" {
+		params["severity"] = af.Severity
+		conds = append(conds, "severity = :severity")
+	}
+	if af.Status != "" {
+		params["status"] = af.Status
+		conds = append(conds, "status = :status")
+	}
+	where := fmt.Sprintf("WHERE %s", strings.Join(conds, " AND "))
+
+	q := fmt.Sprintf(`SELECT %s FROM alarms %s ORDER BY raised DESC, id LIMIT :limit OFFSET :offset`, alarmColumns, where)
+	rows, err := sr.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return twins.AlarmsPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	results := []twins.Alarm{}
+	for rows.Next() {
+		var dba dbAlarm
+		if err := rows.StructScan(&dba); err != nil {
+			return twins.AlarmsPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		results = append(results, toAlarm(dba))
+	}
+
+	total, err := postgres.Total(ctx, sr.db, fmt.Sprintf(`SELECT COUNT(*) FROM alarms %s`, where), params)
+	if err != nil {
+		return twins.AlarmsPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return twins.AlarmsPage{
+		Alarms: results,
+		PageMetadata: twins.PageMetadata{
+			Total:  total,
+			Offset: offset,
+			Limit:  limit,
+		},
+	}, nil
+}
+
+func (sr *stateRepository) retrieveOne(ctx context.Context, query string, args ...interface{}) (twins.State, error) {
+	var dbst dbState
+	if err := sr.db.QueryRowxContext(ctx, query, args...).StructScan(&dbst); err != nil {
+		if err == sql.ErrNoRows {
+			return twins.State{}, repoerr.ErrNotFound
+		}
+		return twins.State{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	st, err := toState(dbst)
+	if err != nil {
+		return twins.State{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return st, nil
+}
+
+func stateFilter(twinID string, sf twins.StateFilter) (string, map[string]interface{}) {
+	params := map[string]interface{}{
+		"twin_id": twinID,
+	}
+	conds := []string{"twin_id = :twin_id"}
+
+	if !sf.From.IsZero() {
+		params["from"] = sf.From
+		conds = append(conds, "created >= :from")
+	}
+	if !sf.To.IsZero() {
+		params["to"] = sf.To
+		conds = append(conds, "created <= :to")
+	}
+	if sf.Definition != nil {
+		params["definition"] = *sf.Definition
+		conds = append(conds, "definition = :definition")
+	}
+	if sf.Attribute != "" {
+		params["attribute"] = sf.Attribute
+		conds = append(conds, "payload -> :attribute IS NOT NULL")
+	}
+
+	return fmt.Sprintf("WHERE %s", strings.Join(conds, " AND ")), params
+}
+
+type dbState struct {
+	TwinID     string    `db:"twin_id"`
+	ID         int64     `db:"id"`
+	Definition int       `db:"definition"`
+	Created    time.Time `db:"created"`
+	Payload    []byte    `db:"payload"`
+	Updated    []byte    `db:"updated"`
+	Stale      []byte    `db:"stale"`
+}
+
+type dbDesiredState struct {
+	TwinID  string    `db:"twin_id"`
+	Updated time.Time `db:"updated"`
+	Payload []byte    `db:"payload"`
+}
+
+type dbAlarm struct {
+	ID        string       `db:"id"`
+	TwinID    string       `db:"twin_id"`
+	Attribute string       `db:"attribute"`
+	Rule      string       `db:"rule"`
+	Condition string       `db:"condition"`
+	Severity  string       `db:"severity"`
+	Status    string       `db:"status"`
+	Value     float64      `db:"value"`
+	Raised    time.Time    `db:"raised"`
+	Cleared   sql.NullTime `db:"cleared"`
+}
+
+func toDBAlarm(a twins.Alarm) dbAlarm {
+	dba := dbAlarm{
+		ID:        a.ID,
+		TwinID:    a.TwinID,
+		Attribute: a.Attribute,
+		Rule:      a.Rule,
+		Condition: a.Condition,
+		Severity:  a.Severity,
+		Status:    a.Status,
+		Value:     a.Value,
+		Raised:    a.Raised,
+	}
+	if a.Cleared != nil {
+		dba.Cleared = sql.NullTime{Time: *a.Cleared, Valid: true}
+	}
+
+	return dba
+}
+
+func toAlarm(dba dbAlarm) twins.Alarm {
+	a := twins.Alarm{
+		ID:        dba.ID,
+		TwinID:    dba.TwinID,
+		Attribute: dba.Attribute,
+		Rule:      dba.Rule,
+		Condition: dba.Condition,
+		Severity:  dba.Severity,
+		Status:    dba.Status,
+		Value:     dba.Value,
+		Raised:    dba.Raised,
+	}
+	if dba.Cleared.Valid {
+		a.Cleared = &dba.Cleared.Time
+	}
+
+	return a
+}
+
+func toDBState(st twins.State) (dbState, error) {
+	payload, err := json.Marshal(st.Payload)
+	if err != nil {
+		return dbState{}, err
+	}
+	updated, err := toJSON(st.Updated)
+	if err != nil {
+		return dbState{}, err
+	}
+	var stale []byte
+	if len(st.Stale) > 0 {
+		if stale, err = json.Marshal(st.Stale); err != nil {
+			return dbState{}, err
+		}
+	}
+
+	return dbState{
+		TwinID:     st.TwinID,
+		ID:         st.ID,
+		Definition: st.Definition,
+		Created:    st.Created,
+		Payload:    payload,
+		Updated:    updated,
+		Stale:      stale,
+	}, nil
+}
+
+func toState(dbst dbState) (twins.State, error) {
+	st := twins.State{
+		TwinID:     dbst.TwinID,
+		ID:         dbst.ID,
+		Definition: dbst.Definition,
+		Created:    dbst.Created,
+	}
+	if err := fromJSON(dbst.Payload, &st.Payload); err != nil {
+		return twins.State{}, err
+	}
+	if err := fromJSON(dbst.Updated, &st.Updated); err != nil {
+		return twins.State{}, err
+	}
+	if err := fromJSON(dbst.Stale, &st.Stale); err != nil {
+		return twins.State{}, err
+	}
+
+	return st, nil
+}
@@ -0,0 +1,212 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/absmach/supermq-contrib/twins"
+	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	"github.com/absmach/supermq/pkg/postgres"
+)
+
+const templateColumns = `id, owner, domain_id, name, created, updated, revision, definition, metadata`
+
+var _ twins.TemplateRepository = (*templateRepository)(nil)
+
+type templateRepository struct {
+	db postgres.Database
+}
+
+// NewTemplateRepository instantiates a PostgreSQL implementation of template
+// repository.
+func NewTemplateRepository(db postgres.Database) twins.TemplateRepository {
+	return &templateRepository{
+		db: db,
+	}
+}
+
+func (tr *templateRepository) Save(ctx context.Context, tmpl twins.Template) (string, error) {
+	if len(tmpl.Name) > maxNameSize {
+		return "", errors.ErrMalformedEntity
+	}
+
+	dbtmpl, err := toDBTemplate(tmpl)
+	if err != nil {
+		return "", errors.Wrap(repoerr.ErrCreateEntity, err)
+	}
+
+	q := fmt.Sprintf(`INSERT INTO templates (%s) VALUES (:id, :owner, :domain_id, :name, :created, :updated, :revision, :definition, :metadata)`, templateColumns)
+	if _, err := tr.db.NamedExecContext(ctx, q, dbtmpl); err != nil {
+		return "", postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+
+	return tmpl.ID, nil
+}
+
+func (tr *templateRepository) Update(ctx context.Context, tmpl twins.Template) error {
+	if len(tmpl.Name) > maxNameSize {
+		return errors.ErrMalformedEntity
+	}
+
+	dbtmpl, err := toDBTemplate(tmpl)
+	if err != nil {
+		return errors.Wrap(repoerr.ErrUpdateEntity, err)
+	}
+
+	q := `UPDATE templates SET owner = :owner, domain_id = :domain_id, name = :name, created = :created, updated = :updated,
+		revision = :revision, definition = :definition, metadata = :metadata WHERE id = :id`
+	res, err := tr.db.NamedExecContext(ctx, q, dbtmpl)
+	if err != nil {
+		return postgres.HandleError(repoerr.ErrUpdateEntity, err)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(repoerr.ErrUpdateEntity, err)
+	}
+	if cnt < 1 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
+func (tr *templateRepository) RetrieveByID(ctx context.Context, id string) (twins.Template, error) {
+	q := fmt.Sprintf(`SELECT %s FROM templates WHERE id = $1`, templateColumns)
+
+	var dbtmpl dbTemplate
+	if err := tr.db.QueryRowxContext(ctx, q, id).StructScan(&dbtmpl); err != nil {
+		if err == sql.ErrNoRows {
+			return twins.Template{}, repoerr.ErrNotFound
+		}
+		return twins.Template{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return toTemplate(dbtmpl)
+}
+
+func (tr *templateRepository) RetrieveAll(ctx context.Context, domainID string, offset, limit uint64, name string) (twins.TemplatesPage, error) {
+	params := map[string]interface{}{
+		"domain_id": domainID,
+		"offset":    offset,
+		"limit":     limit,
+	}
+	where := "WHERE domain_id = :domain_id"
+	if name != "" {
+		params["name"] = name
+		where += " AND name = :name"
+	}
+
+	q := fmt.Sprintf(`SELECT %s FROM templates %s ORDER BY created, id LIMIT :limit OFFSET :offset`, templateColumns, where)
+	rows, err := tr.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return twins.TemplatesPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	results := []twins.Template{}
+	for rows.Next() {
+		var dbtmpl dbTemplate
+		if err := rows.StructScan(&dbtmpl); err != nil {
+			return twins.TemplatesPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		tmpl, err := toTemplate(dbtmpl)
+		if err != nil {
+			return twins.TemplatesPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		results = append(results, tmpl)
+	}
+
+	total, err := postgres.Total(ctx, tr.db, fmt.Sprintf(`SELECT COUNT(*) FROM templates %s`, where), params)
+	if err != nil {
+		return twins.TemplatesPage{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return twins.TemplatesPage{
+		Templates: results,
+		PageMetadata: twins.PageMetadata{
+			Total:  total,
+			Offset: offset,
+			Limit:  limit,
+		},
+	}, nil
+}
+
+func (tr *templateRepository) Remove(ctx context.Context, id string) error {
+	res, err := tr.db.ExecContext(ctx, `DELETE FROM templates WHERE id = $1`, id)
+	if err != nil {
+		return errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+	if cnt < 1 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
+type dbTemplate struct {
+	ID         string    `db:"id"`
+	Owner      string    `db:"owner"`
+	Domain     string    `db:"domain_id"`
+	Name       string    `db:"name"`
+	Created    time.Time `db:"created"`
+	Updated    time.Time `db:"updated"`
+	Revision   int       `db:"revision"`
+	Definition []byte    `db:"definition"`
+	Metadata   []byte    `db:"metadata"`
+}
+
+func toDBTemplate(tmpl twins.Template) (dbTemplate, error) {
+	def, err := json.Marshal(tmpl.Definition)
+	if err != nil {
+		return dbTemplate{}, err
+	}
+	metadata, err := toJSON(tmpl.Metadata)
+	if err != nil {
+		return dbTemplate{}, err
+	}
+
+	return dbTemplate{
+		ID:         tmpl.ID,
+		Owner:      tmpl.Owner,
+		Domain:     tmpl.Domain,
+		Name:       tmpl.Name,
+		Created:    tmpl.Created,
+		Updated:    tmpl.Updated,
+		Revision:   tmpl.Revision,
+		Definition: def,
+		Metadata:   metadata,
+	}, nil
+}
+
+func toTemplate(dbtmpl dbTemplate) (twins.Template, error) {
+	tmpl := twins.Template{
+		ID:       dbtmpl.ID,
+		Owner:    dbtmpl.Owner,
+		Domain:   dbtmpl.Domain,
+		Name:     dbtmpl.Name,
+		Created:  dbtmpl.Created,
+		Updated:  dbtmpl.Updated,
+		Revision: dbtmpl.Revision,
+	}
+	if err := fromJSON(dbtmpl.Definition, &tmpl.Definition); err != nil {
+		return twins.Template{}, err
+	}
+	if err := fromJSON(dbtmpl.Metadata, &tmpl.Metadata); err != nil {
+		return twins.Template{}, err
+	}
+
+	return tmpl, nil
+}
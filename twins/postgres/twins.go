@@ -0,0 +1,442 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/absmach/supermq-contrib/twins"
+	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	"github.com/absmach/supermq/pkg/postgres"
+)
+
+const maxNameSize = 1024
+
+const twinColumns = `id, owner, domain_id, parent_id, template_id, template_revision, bindings, name, created, updated, revision, definitions, metadata, liveness`
+
+var _ twins.TwinRepository = (*twinRepository)(nil)
+
+type twinRepository struct {
+	db postgres.Database
+}
+
+// NewTwinRepository instantiates a PostgreSQL implementation of twin
+// repository.
+func NewTwinRepository(db postgres.Database) twins.TwinRepository {
+	return &twinRepository{
+		db: db,
+	}
+}
+
+func (tr *twinRepository) Save(ctx context.Context, tw twins.Twin) (string, error) {
+	if len(tw.Name) > maxNameSize {
+		return "", errors.ErrMalformedEntity
+	}
+
+	dbtw, err := toDBTwin(tw)
+	if err != nil {
+		return "", errors.Wrap(repoerr.ErrCreateEntity, err)
+	}
+
+	q := fmt.Sprintf(`INSERT INTO twins (%s) VALUES (:id, :owner, :domain_id, :parent_id, :template_id, :template_revision, :bindings, :name, :created, :updated, :revision, :definitions, :metadata, :liveness)`, twinColumns)
+	if _, err := tr.db.NamedExecContext(ctx, q, dbtw); err != nil {
+		return "", postgres.HandleError(repoerr.ErrCreateEntity, err)
+	}
+
+	return tw.ID, nil
+}
+
+func (tr *twinRepository) Update(ctx context.Context, tw twins.Twin) error {
+	if len(tw.Name) > maxNameSize {
+		return errors.ErrMalformedEntity
+	}
+
+	dbtw, err := toDBTwin(tw)
+	if err != nil {
+		return errors.Wrap(repoerr.ErrUpdateEntity, err)
+	}
+
+	// The twin is written only if nobody else updated it since it was
+	// retrieved, i.e. if the stored revision precedes the new one. The
+	// liveness is changed by the liveness check only.
+	q := `UPDATE twins SET owner = :owner, domain_id = :domain_id, parent_id = :parent_id, template_id = :template_id,
+		template_revision = :template_revision, bindings = :bindings, name = :name, created = :created, updated = :updated,
+		revision = :revision, definitions = :definitions, metadata = :metadata
+		WHERE id = :id AND revision = :revision - 1`
+	res, err := tr.db.NamedExecContext(ctx, q, dbtw)
+	if err != nil {
+		return postgres.HandleError(repoerr.ErrUpdateEntity, err)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(repoerr.ErrUpdateEntity, err)
+	}
+	if cnt < 1 {
+		var exists bool
+		if err := tr.db.QueryRowxContext(ctx, `SELECT EXISTS (SELECT 1 FROM twins WHERE id = $1)`, tw.ID).Scan(&exists); err != nil {
+			return errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		if !exists {
+			return repoerr.ErrNotFound
+		}
+		return repoerr.ErrConflict
+	}
+
+	return nil
+}
+
+func (tr *twinRepository) RetrieveByID(ctx context.Context, twinID string) (twins.Twin, error) {
+	q := fmt.Sprintf(`SELECT %s FROM twins WHERE id = $1`, twinColumns)
+
+	var dbtw dbTwin
+	if err := tr.db.QueryRowxContext(ctx, q, twinID).StructScan(&dbtw); err != nil {
+		if err == sql.ErrNoRows {
+			return twins.Twin{}, repoerr.ErrNotFound
+		}
+		return twins.Twin{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return toTwin(dbtw)
+}
+
+// RetrieveByAttribute retrieves the twins bound to the channel and the
+// subtopic. The attributes of the channel are narrowed down by the
+// attributes index and their subtopics are matched afterwards, so that the
+// subtopic patterns are resolved as well.
+func (tr *twinRepository) RetrieveByAttribute(ctx context.Context, channel, subtopic string) ([]string, error) {
+	attr, err := json.Marshal([]map[string]string{{"channel": channel}})
+	if err != nil {
+		return []string{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	q := `SELECT t.id, a ->> 'subtopic' FROM twins t CROSS JOIN LATERAL jsonb_array_elements(t.definitions -> -1 -> 'attributes') a
+		WHERE (t.definitions -> -1 -> 'attributes') @> $1 AND a ->> 'channel' = $2`
+	rows, err := tr.db.QueryxContext(ctx, q, attr, channel)
+	if err != nil {
+		return []string{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	seen := map[string]bool{}
+	for rows.Next() {
+		var id, pattern string
+		if err := rows.Scan(&id, &pattern); err != nil {
+			return []string{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		if !seen[id] && twins.MatchSubtopic(pattern, subtopic) {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
+
+func (tr *twinRepository) RetrieveAll(ctx context.Context, domainID string, ids []string, offset, limit uint64, name, parentID string, metadata twins.Metadata) (twins.Page, error) {
+	params := map[string]interface{}{
+		"domain_id": domainID,
+		"offset":    offset,
+		"limit":     limit,
+	}
+	conds := []string{"domain_id = :domain_id"}
+
+	if ids != nil {
+		params["ids"] = ids
+		conds = append(conds, "id = ANY(:ids)")
+	}
+	if name != "" {
+		params["name"] = name
+		conds = append(conds, "name = :name")
+	}
+	if parentID != "" {
+		params["parent_id"] = parentID
+		conds = append(conds, "parent_id = :parent_id")
+	}
+	mq, mp, err := postgres.CreateMetadataQuery("", metadata)
+	if err != nil {
+		return twins.Page{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+	if mq != "" {
+		params["metadata"] = mp
+		conds = append(conds, mq)
+	}
+	where := fmt.Sprintf("WHERE %s", strings.Join(conds, " AND "))
+
+	q := fmt.Sprintf(`SELECT %s FROM twins %s ORDER BY created, id LIMIT :limit OFFSET :offset`, twinColumns, where)
+	rows, err := tr.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return twins.Page{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	results := []twins.Twin{}
+	for rows.Next() {
+		var dbtw dbTwin
+		if err := rows.StructScan(&dbtw); err != nil {
+			return twins.Page{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		tw, err := toTwin(dbtw)
+		if err != nil {
+			return twins.Page{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		results = append(results, tw)
+	}
+
+	total, err := postgres.Total(ctx, tr.db, fmt.Sprintf(`SELECT COUNT(*) FROM twins %s`, where), params)
+	if err != nil {
+		return twins.Page{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return twins.Page{
+		Twins: results,
+		PageMetadata: twins.PageMetadata{
+			Total:  total,
+			Offset: offset,
+			Limit:  limit,
+		},
+	}, nil
+}
+
+func (tr *twinRepository) RetrieveChildren(ctx context.Context, parentIDs ...string) ([]twins.Twin, error) {
+	if len(parentIDs) == 0 {
+		return []twins.Twin{}, nil
+	}
+
+	q := fmt.Sprintf(`SELECT %s FROM twins WHERE parent_id = ANY($1)`, twinColumns)
+
+	return tr.retrieve(ctx, q, parentIDs)
+}
+
+func (tr *twinRepository) RetrieveRetained(ctx context.Context, offset, limit uint64) (twins.Page, error) {
+	return tr.retrievePage(ctx, `WHERE definitions -> -1 -> 'retention' IS NOT NULL`, offset, limit)
+}
+
+func (tr *twinRepository) RetrieveMonitored(ctx context.Context, offset, limit uint64) (twins.Page, error) {
+	return tr.retrievePage(ctx, `WHERE jsonb_path_exists(definitions -> -1, '$.attributes[*] ? (@.update_interval > 0)')`, offset, limit)
+}
+
+func (tr *twinRepository) RetrieveByTemplate(ctx context.Context, templateID string) ([]twins.Twin, error) {
+	q := fmt.Sprintf(`SELECT %s FROM twins WHERE template_id = $1`, twinColumns)
+
+	return tr.retrieve(ctx, q, templateID)
+}
+
+func (tr *twinRepository) UpdateLiveness(ctx context.Context, twinID string, liveness twins.Liveness) error {
+	data, err := json.Marshal(liveness)
+	if err != nil {
+		return errors.Wrap(repoerr.ErrUpdateEntity, err)
+	}
+
+	res, err := tr.db.ExecContext(ctx, `UPDATE twins SET liveness = $1 WHERE id = $2`, data, twinID)
+	if err != nil {
+		return postgres.HandleError(repoerr.ErrUpdateEntity, err)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(repoerr.ErrUpdateEntity, err)
+	}
+	if cnt < 1 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
+func (tr *twinRepository) Remove(ctx context.Context, twinID string) error {
+	res, err := tr.db.ExecContext(ctx, `DELETE FROM twins WHERE id = $1`, twinID)
+	if err != nil {
+		return errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+
+	cnt, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(repoerr.ErrRemoveEntity, err)
+	}
+	if cnt < 1 {
+		return repoerr.ErrNotFound
+	}
+
+	return nil
+}
+
+func (tr *twinRepository) retrieve(ctx context.Context, query string, args ...interface{}) ([]twins.Twin, error) {
+	rows, err := tr.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return []twins.Twin{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	results := []twins.Twin{}
+	for rows.Next() {
+		var dbtw dbTwin
+		if err := rows.StructScan(&dbtw); err != nil {
+			return []twins.Twin{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		tw, err := toTwin(dbtw)
+		if err != nil {
+			return []twins.Twin{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		results = append(results, tw)
+	}
+
+	return results, nil
+}
+
+type dbTwin struct {
+	ID               string         `db:"id"`
+	Owner            string         `db:"owner"`
+	Domain           string         `db:"domain_id"`
+	Parent           sql.NullString `db:"parent_id"`
+	Template         sql.NullString `db:"template_id"`
+	TemplateRevision int            `db:"template_revision"`
+	Bindings         []byte         `db:"bindings"`
+	Name             string         `db:"name"`
+	Created          time.Time      `db:"created"`
+	Updated          time.Time      `db:"updated"`
+	Revision         int            `db:"revision"`
+	Definitions      []byte         `db:"definitions"`
+	Metadata         []byte         `db:"metadata"`
+	Liveness         []byte         `db:"liveness"`
+}
+
+func toDBTwin(tw twins.Twin) (dbTwin, error) {
+	defs := tw.Definitions
+	if defs == nil {
+		defs = []twins.Definition{}
+	}
+	definitions, err := json.Marshal(defs)
+	if err != nil {
+		return dbTwin{}, err
+	}
+	bindings, err := toJSON(tw.Bindings)
+	if err != nil {
+		return dbTwin{}, err
+	}
+	metadata, err := toJSON(tw.Metadata)
+	if err != nil {
+		return dbTwin{}, err
+	}
+	var liveness []byte
+	if tw.Liveness != nil {
+		if liveness, err = json.Marshal(tw.Liveness); err != nil {
+			return dbTwin{}, err
+		}
+	}
+
+	return dbTwin{
+		ID:               tw.ID,
+		Owner:            tw.Owner,
+		Domain:           tw.Domain,
+		Parent:           toNullString(tw.Parent),
+		Template:         toNullString(tw.Template),
+		TemplateRevision: tw.TemplateRevision,
+		Bindings:         bindings,
+		Name:             tw.Name,
+		Created:          tw.Created,
+		Updated:          tw.Updated,
+		Revision:         tw.Revision,
+		Definitions:      definitions,
+		Metadata:         metadata,
+		Liveness:         liveness,
+	}, nil
+}
+
+func toTwin(dbtw dbTwin) (twins.Twin, error) {
+	tw := twins.Twin{
+		ID:               dbtw.ID,
+		Owner:            dbtw.Owner,
+		Domain:           dbtw.Domain,
+		Parent:           dbtw.Parent.String,
+		Template:         dbtw.Template.String,
+		TemplateRevision: dbtw.TemplateRevision,
+		Name:             dbtw.Name,
+		Created:          dbtw.Created,
+		Updated:          dbtw.Updated,
+		Revision:         dbtw.Revision,
+	}
+	if err := fromJSON(dbtw.Definitions, &tw.Definitions); err != nil {
+		return twins.Twin{}, err
+	}
+	if err := fromJSON(dbtw.Bindings, &tw.Bindings); err != nil {
+		return twins.Twin{}, err
+	}
+	if err := fromJSON(dbtw.Metadata, &tw.Metadata); err != nil {
+		return twins.Twin{}, err
+	}
+	if err := fromJSON(dbtw.Liveness, &tw.Liveness); err != nil {
+		return twins.Twin{}, err
+	}
+
+	return tw, nil
+}
+
+// toJSON marshals the value, storing empty maps as NULL.
+func toJSON[M ~map[K]V, K comparable, V any](m M) ([]byte, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(m)
+}
+
+func fromJSON(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+func toNullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// retrievePage retrieves the subset of twins matching the where clause,
+// regardless of the domain.
+func (tr *twinRepository) retrievePage(ctx context.Context, where string, offset, limit uint64) (twins.Page, error) {
+	params := map[string]interface{}{
+		"offset": offset,
+		"limit":  limit,
+	}
+	q := fmt.Sprintf(`SELECT %s FROM twins %s ORDER BY id LIMIT :limit OFFSET :offset`, twinColumns, where)
+	rows, err := tr.db.NamedQueryContext(ctx, q, params)
+	if err != nil {
+		return twins.Page{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+	defer rows.Close()
+
+	results := []twins.Twin{}
+	for rows.Next() {
+		var dbtw dbTwin
+		if err := rows.StructScan(&dbtw); err != nil {
+			return twins.Page{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		tw, err := toTwin(dbtw)
+		if err != nil {
+			return twins.Page{}, errors.Wrap(repoerr.ErrViewEntity, err)
+		}
+		results = append(results, tw)
+	}
+
+	total, err := postgres.Total(ctx, tr.db, fmt.Sprintf(`SELECT COUNT(*) FROM twins %s`, where), params)
+	if err != nil {
+		return twins.Page{}, errors.Wrap(repoerr.ErrViewEntity, err)
+	}
+
+	return twins.Page{
+		Twins: results,
+		PageMetadata: twins.PageMetadata{
+			Total:  total,
+			Offset: offset,
+			Limit:  limit,
+		},
+	}, nil
+}
@@ -0,0 +1,621 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/absmach/supermq-contrib/twins"
+	"github.com/absmach/supermq-contrib/twins/mocks"
+	"github.com/absmach/supermq-contrib/twins/postgres"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	maxNameSize   = 1024
+	email         = "mgx_twin@example.com"
+	validName     = "mgx_twin"
+	subtopic      = "engine"
+	wrongValue    = "wrong-value"
+	domainID      = "b6a8a5fd-9ab7-41f2-8e5f-b2f2aa6cabe6"
+	otherDomainID = "2f5e3c1a-4d8b-4c7e-9a1f-6b3d2e8c9f0a"
+)
+
+var invalidName = strings.Repeat("m", maxNameSize+1)
+
+func TestTwinSave(t *testing.T) {
+	repo := postgres.NewTwinRepository(database)
+
+	twid, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nonexistentTwinID, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	twin := twins.Twin{
+		Owner: email,
+		ID:    twid,
+	}
+
+	cases := []struct {
+		desc string
+		twin twins.Twin
+		err  error
+	}{
+		{
+			desc: "create new twin",
+			twin: twin,
+			err:  nil,
+		},
+		{
+			desc: "create twin with invalid name",
+			twin: twins.Twin{
+				ID:    nonexistentTwinID,
+				Owner: email,
+				Name:  invalidName,
+			},
+			err: repoerr.ErrMalformedEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := repo.Save(context.Background(), tc.twin)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestTwinsUpdate(t *testing.T) {
+	repo := postgres.NewTwinRepository(database)
+
+	twid, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nonexistentTwinID, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	twin := twins.Twin{
+		ID:   twid,
+		Name: validName,
+	}
+
+	_, err = repo.Save(context.Background(), twin)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	updated := twin
+	updated.Name = "new_name"
+	updated.Revision = twin.Revision + 1
+	cases := []struct {
+		desc string
+		twin twins.Twin
+		err  error
+	}{
+		{
+			desc: "update existing twin",
+			twin: updated,
+			err:  nil,
+		},
+		{
+			desc: "update existing twin with stale revision",
+			twin: updated,
+			err:  repoerr.ErrConflict,
+		},
+		{
+			desc: "update non-existing twin",
+			twin: twins.Twin{
+				ID:       nonexistentTwinID,
+				Revision: 1,
+			},
+			err: repoerr.ErrNotFound,
+		},
+		{
+			desc: "update twin with invalid name",
+			twin: twins.Twin{
+				ID:    twid,
+				Owner: email,
+				Name:  invalidName,
+			},
+			err: repoerr.ErrMalformedEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Update(context.Background(), tc.twin)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
+
+func TestTwinsRetrieveByID(t *testing.T) {
+	repo := postgres.NewTwinRepository(database)
+
+	twid, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nonexistentTwinID, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	chID, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	twin := mocks.CreateTwin([]string{chID}, []string{subtopic})
+	twin.ID = twid
+	twin.Owner = email
+	twin.Domain = domainID
+	twin.Created = time.Now().UTC().Truncate(time.Millisecond)
+	twin.Updated = twin.Created
+	twin.Definitions[0].Created = twin.Created
+	twin.Metadata = twins.Metadata{"type": "test"}
+
+	_, err = repo.Save(context.Background(), twin)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	cases := []struct {
+		desc string
+		id   string
+		twin twins.Twin
+		err  error
+	}{
+		{
+			desc: "retrieve an existing twin",
+			id:   twin.ID,
+			twin: twin,
+			err:  nil,
+		},
+		{
+			desc: "retrieve a non-existing twin",
+			id:   nonexistentTwinID,
+			twin: twins.Twin{},
+			err:  repoerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		tw, err := repo.RetrieveByID(context.Background(), tc.id)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.twin.ID, tw.ID, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.twin.ID, tw.ID))
+		assert.Equal(t, tc.twin.Definitions, tw.Definitions, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.twin.Definitions, tw.Definitions))
+		assert.Equal(t, tc.twin.Metadata, tw.Metadata, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.twin.Metadata, tw.Metadata))
+	}
+}
+
+func TestTwinsRetrieveByAttribute(t *testing.T) {
+	repo := postgres.NewTwinRepository(database)
+
+	chID, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	empty := mocks.CreateTwin([]string{chID}, []string{""})
+	empty.ID, err = idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	_, err = repo.Save(context.Background(), empty)
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	wildcard := mocks.CreateTwin([]string{chID}, []string{twins.SubtopicWildcard})
+	wildcard.ID, err = idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	_, err = repo.Save(context.Background(), wildcard)
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nonEmpty := mocks.CreateTwin([]string{chID}, []string{subtopic})
+	nonEmpty.ID, err = idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	_, err = repo.Save(context.Background(), nonEmpty)
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	singleLevel := mocks.CreateTwin([]string{chID}, []string{"sensors.*.temp"})
+	singleLevel.ID, err = idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	_, err = repo.Save(context.Background(), singleLevel)
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	multiLevel := mocks.CreateTwin([]string{chID}, []string{"sensors.>"})
+	multiLevel.ID, err = idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	_, err = repo.Save(context.Background(), multiLevel)
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	cases := []struct {
+		desc     string
+		subtopic string
+		ids      []string
+	}{
+		{
+			desc:     "retrieve empty subtopic",
+			subtopic: "",
+			ids:      []string{wildcard.ID, empty.ID},
+		},
+		{
+			desc:     "retrieve wildcard subtopic",
+			subtopic: twins.SubtopicWildcard,
+			ids:      []string{wildcard.ID},
+		},
+		{
+			desc:     "retrieve non-empty subtopic",
+			subtopic: subtopic,
+			ids:      []string{wildcard.ID, nonEmpty.ID},
+		},
+		{
+			desc:     "retrieve subtopic matching single-level and multi-level wildcards",
+			subtopic: "sensors.room_1.temp",
+			ids:      []string{wildcard.ID, singleLevel.ID, multiLevel.ID},
+		},
+		{
+			desc:     "retrieve subtopic matching multi-level wildcard",
+			subtopic: "sensors.room_1",
+			ids:      []string{wildcard.ID, multiLevel.ID},
+		},
+		{
+			desc:     "retrieve subtopic matching no wildcard",
+			subtopic: "sensors",
+			ids:      []string{wildcard.ID},
+		},
+	}
+
+	for _, tc := range cases {
+		ids, err := repo.RetrieveByAttribute(context.Background(), chID, tc.subtopic)
+		assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		assert.ElementsMatch(t, ids, tc.ids, fmt.Sprintf("%s: expected ids %v do not match received ids %v", tc.desc, tc.ids, ids))
+	}
+}
+
+func TestTwinsRetrieveAll(t *testing.T) {
+	userID := "8c5a9a5e-3c44-4a8f-9c1b-2f3f9e7d6a10"
+	name := "supermq"
+	metadata := twins.Metadata{
+		"type": "test",
+	}
+	wrongMetadata := twins.Metadata{
+		"wrong": "wrong",
+	}
+
+	_, err := db.Exec("DELETE FROM twins")
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	twinRepo := postgres.NewTwinRepository(database)
+
+	parentID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	n := uint64(10)
+	var ids []string
+	for i := uint64(0); i < n; i++ {
+		twid, err := idProvider.ID()
+		assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+		tw := twins.Twin{
+			Owner:    userID,
+			Domain:   domainID,
+			ID:       twid,
+			Metadata: metadata,
+		}
+
+		// Create first two Twins with name.
+		if i < 2 {
+			tw.Name = name
+		}
+
+		_, err = twinRepo.Save(context.Background(), tw)
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+		ids = append(ids, twid)
+	}
+
+	// Create a child Twin of another user, and a Twin of the same owner
+	// in another domain.
+	childID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	child := twins.Twin{
+		Owner:  wrongValue,
+		Domain: domainID,
+		Parent: parentID,
+		ID:     childID,
+	}
+	_, err = twinRepo.Save(context.Background(), child)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	foreignID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	foreign := twins.Twin{
+		Owner:  userID,
+		Domain: otherDomainID,
+		ID:     foreignID,
+	}
+	_, err = twinRepo.Save(context.Background(), foreign)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	cases := map[string]struct {
+		domain   string
+		ids      []string
+		parent   string
+		limit    uint64
+		offset   uint64
+		name     string
+		size     uint64
+		total    uint64
+		metadata twins.Metadata
+	}{
+		"retrieve all twins with given IDs": {
+			domain: domainID,
+			ids:    append([]string{childID}, ids...),
+			offset: 0,
+			limit:  2 * n,
+			size:   n + 1,
+			total:  n + 1,
+		},
+		"retrieve subset of twins with given IDs": {
+			domain: domainID,
+			ids:    ids,
+			offset: 0,
+			limit:  n / 2,
+			size:   n / 2,
+			total:  n,
+		},
+		"retrieve twins with non-existing IDs": {
+			domain: domainID,
+			ids:    []string{"non-existing"},
+			offset: 0,
+			limit:  n,
+			size:   0,
+			total:  0,
+		},
+		"retrieve twins with no IDs": {
+			domain: domainID,
+			ids:    []string{},
+			offset: 0,
+			limit:  n,
+			size:   0,
+			total:  0,
+		},
+		"retrieve all domain twins": {
+			domain: domainID,
+			offset: 0,
+			limit:  2 * n,
+			size:   n + 1,
+			total:  n + 1,
+		},
+		"retrieve twins of another domain": {
+			domain: otherDomainID,
+			offset: 0,
+			limit:  n,
+			size:   1,
+			total:  1,
+		},
+		"retrieve twins of another domain with given IDs": {
+			domain: otherDomainID,
+			ids:    ids,
+			offset: 0,
+			limit:  n,
+			size:   0,
+			total:  0,
+		},
+		"retrieve children of twin": {
+			domain: domainID,
+			parent: parentID,
+			offset: 0,
+			limit:  n,
+			size:   1,
+			total:  1,
+		},
+		"retrieve twins with existing name": {
+			domain: domainID,
+			offset: 0,
+			limit:  1,
+			name:   name,
+			size:   1,
+			total:  2,
+		},
+		"retrieve twins with metadata": {
+			domain:   domainID,
+			offset:   0,
+			limit:    n,
+			size:     n,
+			total:    n,
+			metadata: metadata,
+		},
+		"retrieve twins with wrong metadata": {
+			domain:   domainID,
+			offset:   0,
+			limit:    n,
+			size:     0,
+			total:    0,
+			metadata: wrongMetadata,
+		},
+	}
+
+	for desc, tc := range cases {
+		page, err := twinRepo.RetrieveAll(context.Background(), tc.domain, tc.ids, tc.offset, tc.limit, tc.name, tc.parent, tc.metadata)
+		size := uint64(len(page.Twins))
+		assert.Equal(t, tc.size, size, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.size, size))
+		assert.Equal(t, tc.total, page.Total, fmt.Sprintf("%s: expected %d got %d\n", desc, tc.total, page.Total))
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %d\n", desc, err))
+	}
+}
+
+func TestTwinsRetrieveRetained(t *testing.T) {
+	repo := postgres.NewTwinRepository(database)
+
+	chID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	retained := mocks.CreateTwin([]string{chID}, []string{subtopic})
+	retained.Definitions[0].Retention = &twins.Retention{MaxAge: int64(24 * time.Hour)}
+	notRetained := mocks.CreateTwin([]string{chID}, []string{subtopic})
+	for _, tw := range []*twins.Twin{&retained, &notRetained} {
+		tw.ID, err = idProvider.ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		_, err = repo.Save(context.Background(), *tw)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	}
+
+	page, err := repo.RetrieveRetained(context.Background(), 0, 100)
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	var ids []string
+	for _, tw := range page.Twins {
+		ids = append(ids, tw.ID)
+	}
+	assert.Contains(t, ids, retained.ID, fmt.Sprintf("retained twin %s not found in %v", retained.ID, ids))
+	assert.NotContains(t, ids, notRetained.ID, fmt.Sprintf("found twin %s without retention in %v", notRetained.ID, ids))
+}
+
+func TestTwinsRetrieveMonitored(t *testing.T) {
+	repo := postgres.NewTwinRepository(database)
+
+	chID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	monitored := mocks.CreateTwin([]string{chID}, []string{subtopic})
+	monitored.Definitions[0].Attributes[0].UpdateInterval = int64(time.Minute)
+	notMonitored := mocks.CreateTwin([]string{chID}, []string{subtopic})
+	for _, tw := range []*twins.Twin{&monitored, &notMonitored} {
+		tw.ID, err = idProvider.ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		_, err = repo.Save(context.Background(), *tw)
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	}
+
+	page, err := repo.RetrieveMonitored(context.Background(), 0, 100)
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+	var ids []string
+	for _, tw := range page.Twins {
+		ids = append(ids, tw.ID)
+	}
+	assert.Contains(t, ids, monitored.ID, fmt.Sprintf("monitored twin %s not found in %v", monitored.ID, ids))
+	assert.NotContains(t, ids, notMonitored.ID, fmt.Sprintf("found twin %s without update interval in %v", notMonitored.ID, ids))
+}
+
+func TestTwinsRetrieveChildren(t *testing.T) {
+	repo := postgres.NewTwinRepository(database)
+
+	parentID, err := idProvider.ID()
+	require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		twid, err := idProvider.ID()
+		require.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+		_, err = repo.Save(context.Background(), twins.Twin{ID: twid, Parent: parentID})
+		require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+		ids = append(ids, twid)
+	}
+
+	cases := []struct {
+		desc    string
+		parents []string
+		ids     []string
+	}{
+		{
+			desc:    "retrieve children of parent twin",
+			parents: []string{parentID},
+			ids:     ids,
+		},
+		{
+			desc:    "retrieve children of twin without children",
+			parents: []string{ids[0]},
+			ids:     []string{},
+		},
+		{
+			desc:    "retrieve children without parents",
+			parents: []string{},
+			ids:     []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		children, err := repo.RetrieveChildren(context.Background(), tc.parents...)
+		assert.Nil(t, err, fmt.Sprintf("%s: got unexpected error: %s", tc.desc, err))
+		var got []string
+		for _, c := range children {
+			got = append(got, c.ID)
+		}
+		assert.ElementsMatch(t, tc.ids, got, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.ids, got))
+	}
+}
+
+func TestTwinsUpdateLiveness(t *testing.T) {
+	repo := postgres.NewTwinRepository(database)
+
+	twid, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nonexistentTwinID, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	twin := twins.Twin{ID: twid, Revision: 1}
+	_, err = repo.Save(context.Background(), twin)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	liveness := twins.Liveness{Stale: []string{"temperature"}, Checked: time.Now()}
+	cases := []struct {
+		desc string
+		id   string
+		err  error
+	}{
+		{
+			desc: "update liveness of an existing twin",
+			id:   twid,
+			err:  nil,
+		},
+		{
+			desc: "update liveness of a non-existing twin",
+			id:   nonexistentTwinID,
+			err:  repoerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.UpdateLiveness(context.Background(), tc.id, liveness)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+
+	// The liveness is kept when the twin is updated.
+	twin.Revision++
+	twin.Name = "updated"
+	err = repo.Update(context.Background(), twin)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	tw, err := repo.RetrieveByID(context.Background(), twid)
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+	require.NotNil(t, tw.Liveness, "expected twin liveness")
+	assert.Equal(t, liveness.Stale, tw.Liveness.Stale, fmt.Sprintf("expected stale attributes %v got %v\n", liveness.Stale, tw.Liveness.Stale))
+	assert.Equal(t, twin.Revision, tw.Revision, fmt.Sprintf("expected revision %d got %d\n", twin.Revision, tw.Revision))
+}
+
+func TestTwinsRemove(t *testing.T) {
+	repo := postgres.NewTwinRepository(database)
+
+	twid, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	nonexistentTwinID, err := idProvider.ID()
+	assert.Nil(t, err, fmt.Sprintf("got unexpected error: %s", err))
+
+	_, err = repo.Save(context.Background(), twins.Twin{ID: twid})
+	require.Nil(t, err, fmt.Sprintf("unexpected error: %s\n", err))
+
+	cases := []struct {
+		desc string
+		id   string
+		err  error
+	}{
+		{
+			desc: "remove an existing twin",
+			id:   twid,
+			err:  nil,
+		},
+		{
+			desc: "remove a non-existing twin",
+			id:   nonexistentTwinID,
+			err:  repoerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		err := repo.Remove(context.Background(), tc.id)
+		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+	}
+}
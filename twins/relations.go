@@ -0,0 +1,288 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"time"
+
+	smqauthn "github.com/absmach/supermq/pkg/authn"
+	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	svcerr "github.com/absmach/supermq/pkg/errors/service"
+	"github.com/absmach/supermq/pkg/policies"
+)
+
+// Directions of the relations relative to the twin they are retrieved for.
+const (
+	// OutgoingDir selects the relations whose source is the twin.
+	OutgoingDir = "out"
+
+	// IncomingDir selects the relations whose target is the twin.
+	IncomingDir = "in"
+
+	// BothDirs selects the relations regardless of their direction.
+	BothDirs = "both"
+)
+
+// MaxTraversalDepth is the maximal number of hops of the relations
+// traversal.
+const MaxTraversalDepth = 10
+
+var (
+	errRelationType = errors.New("invalid relation type")
+	errSelfRelation = errors.New("twin cannot relate to itself")
+	errDirection    = errors.New("invalid relation direction")
+	errDepth        = errors.New("invalid traversal depth")
+)
+
+// relationType matches the valid relation types, such as "feeds" or
+// "powered_by".
+var relationType = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// Relation is the typed, directed relationship between two twins of the
+// same domain, such as "pump feeds tank". A relation is identified by its
+// source, type and target.
+type Relation struct {
+	Domain   string
+	Source   string
+	Target   string
+	Type     string
+	Created  time.Time
+	Metadata Metadata
+}
+
+// RelationsPage contains page related metadata as well as a list of
+// relations that belong to this page.
+type RelationsPage struct {
+	PageMetadata
+	Relations []Relation
+}
+
+// RelationFilter narrows down the subset of relations retrieved from the
+// repository. Zero values of the fields are ignored.
+type RelationFilter struct {
+	// Types limits relations to the ones of the given types.
+	Types []string
+
+	// Direction limits relations to the outgoing or incoming ones. Both
+	// are retrieved by default.
+	Direction string
+}
+
+// TraversalQuery specifies which relations are followed by the traversal.
+type TraversalQuery struct {
+	// Types limits the traversal to the relations of the given types. All
+	// the relations are followed by default.
+	Types []string
+
+	// Direction is the direction relations are followed in. Outgoing
+	// relations lead downstream, and incoming relations lead upstream.
+	Direction string
+
+	// Depth is the maximal number of hops from the origin twin.
+	Depth int
+}
+
+// Hop is the twin reached by the traversal. Depth is the number of hops
+// from the origin twin, and Via is the relation the twin was reached by.
+type Hop struct {
+	Twin  Twin
+	Depth int
+	Via   Relation
+}
+
+// Traversal contains the twins reached from the origin twin, ordered by
+// their distance from it.
+type Traversal struct {
+	Origin string
+	Hops   []Hop
+}
+
+// RelationRepository specifies a relation persistence API.
+type RelationRepository interface {
+	// Save persists the relation. A conflict error is returned if the
+	// relation already exists.
+	Save(ctx context.Context, rel Relation) error
+
+	// Remove removes the relation identified by the source, type and
+	// target.
+	Remove(ctx context.Context, source, relType, target string) error
+
+	// RemoveByTwin removes all the relations the twin identified by the
+	// provided ID is either the source or the target of.
+	RemoveByTwin(ctx context.Context, twinID string) error
+
+	// RetrieveAll retrieves the subset of relations of the twin identified
+	// by the provided ID and matching the provided filter.
+	RetrieveAll(ctx context.Context, twinID string, offset, limit uint64, filter RelationFilter) (RelationsPage, error)
+
+	// RetrieveByTwins retrieves all the relations of the twins identified
+	// by the provided IDs and matching the provided filter.
+	RetrieveByTwins(ctx context.Context, twinIDs []string, filter RelationFilter) ([]Relation, error)
+}
+
+func validateDirection(dir string) error {
+	switch dir {
+	case "", OutgoingDir, IncomingDir, BothDirs:
+		return nil
+	default:
+		return errDirection
+	}
+}
+
+func (ts *twinservice) AddRelation(ctx context.Context, token, domainID string, rel Relation) (r Relation, err error) {
+	var b []byte
+	defer ts.publish(ctx, &rel.Source, &err, crudOp["relateSucc"], crudOp["relateFail"], &b)
+
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return Relation{}, err
+	}
+
+	if !relationType.MatchString(rel.Type) {
+		return Relation{}, errors.Wrap(svcerr.ErrMalformedEntity, errRelationType)
+	}
+	if rel.Source == rel.Target {
+		return Relation{}, errors.Wrap(svcerr.ErrMalformedEntity, errSelfRelation)
+	}
+
+	if _, err := ts.authorize(ctx, session, domainID, rel.Source, policies.EditPermission); err != nil {
+		return Relation{}, err
+	}
+	if _, err := ts.authorize(ctx, session, domainID, rel.Target, policies.ViewPermission); err != nil {
+		return Relation{}, err
+	}
+
+	rel.Domain = domainID
+	rel.Created = time.Now()
+	if err := ts.relations.Save(ctx, rel); err != nil {
+		return Relation{}, errors.Wrap(svcerr.ErrCreateEntity, err)
+	}
+
+	if b, err = json.Marshal(rel); err != nil {
+		return Relation{}, err
+	}
+
+	return rel, nil
+}
+
+func (ts *twinservice) RemoveRelation(ctx context.Context, token, domainID string, rel Relation) (err error) {
+	var b []byte
+	defer ts.publish(ctx, &rel.Source, &err, crudOp["unrelateSucc"], crudOp["unrelateFail"], &b)
+
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := ts.authorize(ctx, session, domainID, rel.Source, policies.EditPermission); err != nil {
+		return err
+	}
+
+	if err := ts.relations.Remove(ctx, rel.Source, rel.Type, rel.Target); err != nil {
+		if errors.Contains(err, repoerr.ErrNotFound) {
+			return errors.Wrap(svcerr.ErrNotFound, err)
+		}
+		return errors.Wrap(svcerr.ErrRemoveEntity, err)
+	}
+
+	b, err = json.Marshal(rel)
+
+	return err
+}
+
+func (ts *twinservice) ListRelations(ctx context.Context, token, domainID, twinID string, offset, limit uint64, filter RelationFilter) (RelationsPage, error) {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return RelationsPage{}, err
+	}
+
+	if err := validateDirection(filter.Direction); err != nil {
+		return RelationsPage{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+
+	if _, err := ts.authorize(ctx, session, domainID, twinID, policies.ViewPermission); err != nil {
+		return RelationsPage{}, err
+	}
+
+	page, err := ts.relations.RetrieveAll(ctx, twinID, offset, limit, filter)
+	if err != nil {
+		return RelationsPage{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	return page, nil
+}
+
+func (ts *twinservice) TraverseRelations(ctx context.Context, token, domainID, twinID string, query TraversalQuery) (Traversal, error) {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return Traversal{}, err
+	}
+
+	if err := validateDirection(query.Direction); err != nil {
+		return Traversal{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+	if query.Direction == "" {
+		query.Direction = OutgoingDir
+	}
+	if query.Depth < 1 || query.Depth > MaxTraversalDepth {
+		return Traversal{}, errors.Wrap(svcerr.ErrMalformedEntity, errDepth)
+	}
+
+	tw, err := ts.authorize(ctx, session, domainID, twinID, policies.ViewPermission)
+	if err != nil {
+		return Traversal{}, err
+	}
+
+	return ts.traverse(ctx, session, tw, query)
+}
+
+// traverse follows the relations breadth-first, level by level, so that
+// every twin is reached by the shortest path. Twins the user is not allowed
+// to view are neither returned nor traversed through.
+func (ts *twinservice) traverse(ctx context.Context, session smqauthn.Session, origin Twin, query TraversalQuery) (Traversal, error) {
+	admin := ts.checkDomain(ctx, session.UserID, origin.Domain, policies.AdminPermission) == nil
+	filter := RelationFilter{Types: query.Types, Direction: query.Direction}
+
+	trav := Traversal{Origin: origin.ID, Hops: []Hop{}}
+	visited := map[string]bool{origin.ID: true}
+	level := []string{origin.ID}
+	for depth := 1; depth <= query.Depth && len(level) > 0; depth++ {
+		rels, err := ts.relations.RetrieveByTwins(ctx, level, filter)
+		if err != nil {
+			return Traversal{}, errors.Wrap(svcerr.ErrViewEntity, err)
+		}
+		level = []string{}
+		for _, rel := range rels {
+			// Relations of the level twins lead to the twins at the other
+			// end, while the level twins themselves are already visited.
+			next := rel.Target
+			if query.Direction == IncomingDir || (query.Direction == BothDirs && !visited[rel.Source]) {
+				next = rel.Source
+			}
+			if visited[next] {
+				continue
+			}
+			visited[next] = true
+
+			tw, err := ts.twins.RetrieveByID(ctx, next)
+			if err != nil {
+				if errors.Contains(err, repoerr.ErrNotFound) {
+					continue
+				}
+				return Traversal{}, errors.Wrap(svcerr.ErrViewEntity, err)
+			}
+			if tw.Domain != origin.Domain || !(admin || ts.checkTwin(ctx, session.UserID, tw.Domain, tw.ID, policies.ViewPermission) == nil) {
+				continue
+			}
+			trav.Hops = append(trav.Hops, Hop{Twin: tw, Depth: depth, Via: rel})
+			level = append(level, tw.ID)
+		}
+	}
+
+	return trav, nil
+}
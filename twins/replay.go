@@ -0,0 +1,436 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/absmach/senml"
+	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	svcerr "github.com/absmach/supermq/pkg/errors/service"
+	"github.com/absmach/supermq/pkg/messaging"
+	"github.com/absmach/supermq/pkg/policies"
+	smqsenml "github.com/absmach/supermq/pkg/transformers/senml"
+	"github.com/absmach/supermq/readers"
+)
+
+const (
+	replayPageSize = 1000
+
+	// maxReplayMessages bounds the number of messages replayed at once, as
+	// the replayed states are kept in memory.
+	maxReplayMessages = 100000
+)
+
+var (
+	errReplayDisabled = errors.New("message reader is not configured")
+	errReplayRange    = errors.New("replay must start before it ends")
+	errReplayLive     = errors.New("replay into live history cannot end before the current time")
+	errReplayTooLarge = fmt.Errorf("replay exceeds %d messages", maxReplayMessages)
+	errReplayJSON     = errors.New("JSON messages are replayed only by simulated replays of the whole history")
+	errReplayFormat   = errors.New("last subtopic level of the JSON attribute must name the message format")
+
+	// formatPattern matches the JSON formats the message writers store the
+	// messages in, named by the last subtopic level.
+	formatPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+)
+
+// ReplayOptions specifies the historical messages replayed into the twin.
+type ReplayOptions struct {
+	// From is the time of the first replayed message. If zero, the whole
+	// history is replayed.
+	From time.Time
+
+	// To is the time before which the messages are replayed. If zero, the
+	// messages are replayed up to the current time. Replay into the live
+	// history always reaches the current time.
+	To time.Time
+
+	// Simulate replays the messages into the simulated state series of the
+	// twin, leaving the live history intact. The series is replaced by
+	// every simulated replay.
+	Simulate bool
+}
+
+// Replay describes the outcome of replaying the historical messages into the
+// twin. Skipped is the number of replayed messages which are not bound to
+// any attribute or violate its schema, while Removed is the number of live
+// states replaced by the replayed ones.
+type Replay struct {
+	Simulate bool
+	Messages uint64
+	Skipped  uint64
+	Removed  int64
+	Created  int
+
+	// States holds the states created or updated by the simulated replay,
+	// oldest first. They are saved as the simulated state series.
+	States []State
+}
+
+func (opts *ReplayOptions) validate(now time.Time) error {
+	if !opts.Simulate && !opts.To.IsZero() {
+		return errReplayLive
+	}
+	if opts.To.IsZero() {
+		opts.To = now
+	}
+	if !opts.From.Before(opts.To) {
+		return errReplayRange
+	}
+
+	return nil
+}
+
+func (ts *twinservice) ReplayStates(ctx context.Context, token, domainID, twinID string, opts ReplayOptions) (rp Replay, err error) {
+	var b []byte
+	id := twinID
+	defer func() {
+		if opts.Simulate {
+			return
+		}
+		ts.publish(ctx, &id, &err, crudOp["replaySucc"], crudOp["replayFail"], &b)
+	}()
+
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return Replay{}, err
+	}
+
+	if ts.messages == nil {
+		return Replay{}, errors.Wrap(svcerr.ErrMalformedEntity, errReplayDisabled)
+	}
+	// JSON messages have no time, so they cannot be read within a range.
+	whole := opts.Simulate && opts.From.IsZero() && opts.To.IsZero()
+	if err := opts.validate(time.Now()); err != nil {
+		return Replay{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+
+	perm := policies.EditPermission
+	if opts.Simulate {
+		perm = policies.ViewPermission
+	}
+	tw, err := ts.authorize(ctx, session, domainID, twinID, perm)
+	if err != nil {
+		return Replay{}, err
+	}
+
+	// Only the messages of the channels of the twin domain are replayed.
+	def := tw.Definitions[len(tw.Definitions)-1]
+	if err := ts.checkChannels(ctx, session.UserID, tw.Domain, subscribePermission, def); err != nil {
+		return Replay{}, err
+	}
+	qs, err := replayQueries(def, whole)
+	if err != nil {
+		return Replay{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+
+	msgs, err := ts.readMessages(qs, opts.From, opts.To)
+	if err != nil {
+		return Replay{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	if !opts.Simulate {
+		// Ingestion of the twin messages is paused only while the live
+		// history is rewritten, and the messages received since the history
+		// was read are replayed as well.
+		defer ts.locks.lock(twinID)()
+		defer ts.views.invalidate(twinID)
+
+		cur, err := ts.twins.RetrieveByID(ctx, twinID)
+		if err != nil {
+			return Replay{}, errors.Wrap(svcerr.ErrViewEntity, err)
+		}
+		if cur.Revision != tw.Revision {
+			return Replay{}, errors.Wrap(svcerr.ErrConflict, ErrRevisionConflict)
+		}
+
+		tail, err := ts.readMessages(qs, opts.To, time.Now())
+		if err != nil {
+			return Replay{}, errors.Wrap(svcerr.ErrViewEntity, err)
+		}
+		if len(msgs)+len(tail) > maxReplayMessages {
+			return Replay{}, errors.Wrap(svcerr.ErrViewEntity, errReplayTooLarge)
+		}
+		msgs = append(msgs, tail...)
+	}
+
+	// The replay continues from the last state created before it starts.
+	seed, err := ts.states.RetrieveAt(ctx, twinID, opts.From)
+	if err != nil && !errors.Contains(err, repoerr.ErrNotFound) {
+		return Replay{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+	if err != nil {
+		seed = State{ID: -1}
+	}
+
+	rp = Replay{Simulate: opts.Simulate, Messages: uint64(len(msgs))}
+	updated, created := ts.replay(&rp, tw, seed, msgs)
+	rp.Created = len(created)
+
+	if opts.Simulate {
+		rp.States = created
+		if updated != nil {
+			rp.States = append([]State{*updated}, created...)
+		}
+		if err := ts.states.SaveSimulated(ctx, twinID, rp.States...); err != nil {
+			return Replay{}, errors.Wrap(svcerr.ErrCreateEntity, err)
+		}
+		return rp, nil
+	}
+
+	if rp.Removed, err = ts.states.RemoveAfter(ctx, twinID, seed.ID); err != nil {
+		return Replay{}, errors.Wrap(svcerr.ErrRemoveEntity, err)
+	}
+	if updated != nil {
+		if err := ts.states.Update(ctx, *updated); err != nil {
+			return Replay{}, errors.Wrap(svcerr.ErrUpdateEntity, err)
+		}
+	}
+	for len(created) > 0 {
+		n := min(len(created), replayPageSize)
+		if err := ts.states.Save(ctx, created[:n]...); err != nil {
+			return Replay{}, errors.Wrap(svcerr.ErrCreateEntity, err)
+		}
+		created = created[n:]
+	}
+
+	b, err = json.Marshal(rp)
+
+	return rp, err
+}
+
+// replay applies the messages to the seed state the same way the received
+// messages are, and returns the seed state if it was updated and the created
+// states. Values are considered received at the time of their record, and
+// the alarms of the attributes are not evaluated.
+func (ts *twinservice) replay(rp *Replay, tw Twin, seed State, msgs []*messaging.Message) (*State, []State) {
+	def := tw.Definitions[len(tw.Definitions)-1]
+
+	var (
+		st       = copyState(seed)
+		computed = ts.computedAttributes(tw)
+		updated  *State
+		created  []State
+	)
+	for _, msg := range msgs {
+		brs, err := decodeRecords(boundAttributes(def, msg), msg)
+		if err != nil {
+			rp.Skipped++
+			continue
+		}
+
+		applied := false
+		for _, br := range brs {
+			if validateRecord(br.attr, br.rec) != nil {
+				continue
+			}
+			applied = true
+
+			received := recordTime(br.rec)
+			if received.IsZero() {
+				received = time.Unix(0, msg.GetCreated())
+			}
+			switch ts.prepareState(&st, &tw, computed, br.attr, br.rec, received) {
+			case update, refreshed:
+				s := copyState(st)
+				if n := len(created); n > 0 {
+					created[n-1] = s
+				} else {
+					updated = &s
+				}
+			case save:
+				created = append(created, copyState(st))
+			}
+		}
+		if !applied {
+			rp.Skipped++
+		}
+	}
+
+	return updated, created
+}
+
+// readMessages reads the messages of the queries received within the given
+// time range, oldest first. The messages of the JSON format are read
+// regardless of the range, since they have no time.
+func (ts *twinservice) readMessages(qs []replayQuery, from, to time.Time) ([]*messaging.Message, error) {
+	var msgs []*messaging.Message
+	for _, q := range qs {
+		var chMsgs []*messaging.Message
+		pm := q.pm
+		pm.Limit = replayPageSize
+		if pm.Format == "" {
+			if !from.IsZero() {
+				pm.From = float64(from.UnixNano()) / nanosec
+			}
+			pm.To = float64(to.UnixNano()) / nanosec
+		}
+		for {
+			page, err := ts.messages.ReadAll(q.channel, pm)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range page.Messages {
+				if msg, ok := replayedMessage(m); ok {
+					chMsgs = append(chMsgs, msg)
+				}
+			}
+			if len(msgs)+len(chMsgs) > maxReplayMessages {
+				return nil, errReplayTooLarge
+			}
+			pm.Offset += uint64(len(page.Messages))
+			if len(page.Messages) == 0 || pm.Offset >= page.Total {
+				break
+			}
+		}
+
+		// Readers return the newest messages first.
+		for i, j := 0, len(chMsgs)-1; i < j; i, j = i+1, j-1 {
+			chMsgs[i], chMsgs[j] = chMsgs[j], chMsgs[i]
+		}
+		msgs = append(msgs, chMsgs...)
+	}
+	sort.SliceStable(msgs, func(i, j int) bool {
+		return msgs[i].GetCreated() < msgs[j].GetCreated()
+	})
+
+	return msgs, nil
+}
+
+// replayQuery selects the replayed messages of the channel.
+type replayQuery struct {
+	channel string
+	pm      readers.PageMetadata
+}
+
+// replayQueries returns the queries of the messages the persisted attributes
+// of the definition are bound to. Messages are filtered by the subtopic,
+// unless some attribute of the channel is bound to the subtopic pattern or
+// to the empty subtopic, in which case all the messages of the channel are
+// read once. Messages of the attributes with the payload path are read in
+// the JSON format named by the last level of their subtopic, as they are
+// stored by the message writers, and only if the whole history is replayed.
+func replayQueries(def Definition, whole bool) ([]replayQuery, error) {
+	type source struct {
+		channel string
+		format  string
+	}
+	var (
+		sources   []source
+		subtopics = map[source][]string{}
+		all       = map[source]bool{}
+	)
+	for _, attr := range def.Attributes {
+		if attr.Channel == "" || !attr.PersistState {
+			continue
+		}
+		src := source{channel: attr.Channel}
+		if attr.Path != "" {
+			if !whole {
+				return nil, errReplayJSON
+			}
+			format, err := jsonFormat(attr)
+			if err != nil {
+				return nil, err
+			}
+			src.format = format
+		}
+		if _, ok := subtopics[src]; !ok {
+			sources = append(sources, src)
+			subtopics[src] = nil
+		}
+		if attr.Subtopic == "" || IsSubtopicPattern(attr.Subtopic) {
+			all[src] = true
+			continue
+		}
+		if !slices.Contains(subtopics[src], attr.Subtopic) {
+			subtopics[src] = append(subtopics[src], attr.Subtopic)
+		}
+	}
+
+	var qs []replayQuery
+	for _, src := range sources {
+		if all[src] {
+			qs = append(qs, replayQuery{channel: src.channel, pm: readers.PageMetadata{Format: src.format}})
+			continue
+		}
+		for _, subtopic := range subtopics[src] {
+			qs = append(qs, replayQuery{channel: src.channel, pm: readers.PageMetadata{Format: src.format, Subtopic: subtopic}})
+		}
+	}
+
+	return qs, nil
+}
+
+// jsonFormat returns the format of the JSON messages of the attribute with
+// the payload path, which is named by the last level of its subtopic.
+func jsonFormat(attr Attribute) (string, error) {
+	levels := strings.Split(attr.Subtopic, SubtopicSeparator)
+	format := levels[len(levels)-1]
+	if !formatPattern.MatchString(format) {
+		return "", errors.Wrap(errReplayFormat, fmt.Errorf("attribute %s", attr.Name))
+	}
+
+	return format, nil
+}
+
+// replayedMessage converts the message returned by the reader to the
+// received message. SenML messages are converted to the payload holding
+// their record, while JSON messages keep their payload.
+func replayedMessage(m readers.Message) (*messaging.Message, bool) {
+	switch m := m.(type) {
+	case smqsenml.Message:
+		rec := senml.Record{
+			Name:        m.Name,
+			Unit:        m.Unit,
+			Time:        m.Time,
+			UpdateTime:  m.UpdateTime,
+			Value:       m.Value,
+			StringValue: m.StringValue,
+			DataValue:   m.DataValue,
+			BoolValue:   m.BoolValue,
+			Sum:         m.Sum,
+		}
+		payload, err := json.Marshal([]senml.Record{rec})
+		if err != nil {
+			return nil, false
+		}
+		return &messaging.Message{
+			Channel:   m.Channel,
+			Subtopic:  m.Subtopic,
+			Publisher: m.Publisher,
+			Protocol:  m.Protocol,
+			Payload:   payload,
+			Created:   int64(m.Time * nanosec),
+		}, true
+	case map[string]interface{}:
+		payload, err := json.Marshal(m["payload"])
+		if err != nil {
+			return nil, false
+		}
+		msg := &messaging.Message{Payload: payload}
+		msg.Channel, _ = m["channel"].(string)
+		msg.Subtopic, _ = m["subtopic"].(string)
+		msg.Publisher, _ = m["publisher"].(string)
+		msg.Protocol, _ = m["protocol"].(string)
+		switch created := m["created"].(type) {
+		case int64:
+			msg.Created = created
+		case float64:
+			msg.Created = int64(created)
+		}
+		return msg, true
+	default:
+		return nil, false
+	}
+}
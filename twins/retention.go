@@ -0,0 +1,158 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/absmach/supermq/pkg/errors"
+	svcerr "github.com/absmach/supermq/pkg/errors/service"
+)
+
+// Buckets the compacted states are grouped into. Buckets are aligned to
+// UTC hours and days.
+const (
+	// HourBucket keeps the last state of every hour.
+	HourBucket = "hour"
+
+	// DayBucket keeps the last state of every day.
+	DayBucket = "day"
+)
+
+const retentionPageSize = 100
+
+var errInvalidRetention = errors.New("invalid state retention")
+
+// Retention specifies how long the twin states are kept. States older than
+// the compaction age are compacted into the buckets, i.e. only the last
+// state of each bucket is kept, while the states older than the maximum age
+// are removed. Ages are expressed in nanoseconds, like the definition delta,
+// and are ignored if zero. The last state of the twin is never removed.
+type Retention struct {
+	MaxAge     int64  `json:"max_age,omitempty"`
+	CompactAge int64  `json:"compact_age,omitempty"`
+	Bucket     string `json:"bucket,omitempty"`
+}
+
+// Compaction reports the states of the twin removed by the retention.
+type Compaction struct {
+	TwinID string `json:"twin_id"`
+
+	// Removed is the number of states older than the maximum age.
+	Removed int64 `json:"removed"`
+
+	// Compacted is the number of states replaced by the bucket snapshots.
+	Compacted int64 `json:"compacted"`
+}
+
+// validateRetention verifies that the ages are not negative and that the
+// bucket is set together with the compaction age.
+func validateRetention(ret *Retention) error {
+	if ret == nil {
+		return nil
+	}
+	if ret.MaxAge < 0 || ret.CompactAge < 0 {
+		return errors.Wrap(errInvalidRetention, errors.New("negative retention age"))
+	}
+	if ret.CompactAge > 0 && bucketDuration(ret.Bucket) == 0 {
+		return errors.Wrap(errInvalidRetention, fmt.Errorf("unknown compaction bucket %q", ret.Bucket))
+	}
+	if ret.CompactAge == 0 && ret.Bucket != "" {
+		return errors.Wrap(errInvalidRetention, errors.New("compaction bucket requires the compaction age"))
+	}
+	if ret.MaxAge > 0 && ret.CompactAge >= ret.MaxAge {
+		return errors.Wrap(errInvalidRetention, errors.New("compaction age is not less than the maximum age"))
+	}
+
+	return nil
+}
+
+func bucketDuration(bucket string) time.Duration {
+	switch bucket {
+	case HourBucket:
+		return time.Hour
+	case DayBucket:
+		return 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+func (ts *twinservice) ApplyRetention(ctx context.Context) ([]Compaction, error) {
+	var comps []Compaction
+	for offset := uint64(0); ; offset += retentionPageSize {
+		page, err := ts.twins.RetrieveRetained(ctx, offset, retentionPageSize)
+		if err != nil {
+			return comps, errors.Wrap(svcerr.ErrViewEntity, err)
+		}
+		for _, tw := range page.Twins {
+			comp, err := ts.applyRetention(ctx, tw, time.Now())
+			if err != nil {
+				ts.logger.Warn(fmt.Sprintf("Failed to apply retention to twin %s: %s", tw.ID, err))
+				continue
+			}
+			if comp.Removed > 0 || comp.Compacted > 0 {
+				comps = append(comps, comp)
+			}
+		}
+		if len(page.Twins) == 0 || offset+uint64(len(page.Twins)) >= page.Total {
+			return comps, nil
+		}
+	}
+}
+
+// applyRetention removes and compacts the states of the twin according to
+// the retention of its latest definition, and reports the outcome to the
+// notification channel.
+func (ts *twinservice) applyRetention(ctx context.Context, tw Twin, now time.Time) (comp Compaction, err error) {
+	var b []byte
+	twinID := tw.ID
+	comp.TwinID = tw.ID
+
+	ret := tw.Definitions[len(tw.Definitions)-1].Retention
+	if ret == nil {
+		return comp, nil
+	}
+
+	last, err := ts.states.RetrieveLast(ctx, tw.ID)
+	if err != nil || last.Payload == nil {
+		return comp, err
+	}
+
+	defer func() {
+		if err == nil && comp.Removed == 0 && comp.Compacted == 0 {
+			return
+		}
+		ts.publish(ctx, &twinID, &err, crudOp["retentionSucc"], crudOp["retentionFail"], &b)
+	}()
+
+	// States are numbered after the last one, so it is always kept.
+	if ret.MaxAge > 0 {
+		before := earliest(now.Add(-time.Duration(ret.MaxAge)), last.Created)
+		if comp.Removed, err = ts.states.RemoveBefore(ctx, tw.ID, before); err != nil {
+			return comp, errors.Wrap(svcerr.ErrRemoveEntity, err)
+		}
+	}
+	if ret.CompactAge > 0 {
+		bucket := bucketDuration(ret.Bucket)
+		before := earliest(now.Add(-time.Duration(ret.CompactAge)), last.Created).Truncate(bucket)
+		if comp.Compacted, err = ts.states.Compact(ctx, tw.ID, before, bucket); err != nil {
+			return comp, errors.Wrap(svcerr.ErrRemoveEntity, err)
+		}
+	}
+
+	b, err = json.Marshal(comp)
+
+	return comp, err
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,197 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"fmt"
+
+	"github.com/absmach/senml"
+	"github.com/absmach/supermq/pkg/errors"
+)
+
+// Types of the attribute values, corresponding to the SenML value fields.
+const (
+	// NumberType is used for SenML value and sum fields.
+	NumberType = "number"
+
+	// StringType is used for SenML string value field.
+	StringType = "string"
+
+	// BoolType is used for SenML boolean value field.
+	BoolType = "bool"
+
+	// DataType is used for SenML data value field.
+	DataType = "data"
+)
+
+var (
+	errInvalidSchema   = errors.New("invalid attribute schema")
+	errSchemaViolation = errors.New("value violates the attribute schema")
+)
+
+// validateDefinition verifies the schemas, the subtopics, the expressions,
+// the alarms and the persistence policies of the definition attributes, and
+// the state retention.
+func validateDefinition(def Definition) error {
+	if err := validateRetention(def.Retention); err != nil {
+		return err
+	}
+	for _, attr := range def.Attributes {
+		if err := validateSchema(attr); err != nil {
+			return err
+		}
+		if err := validatePaths(attr); err != nil {
+			return err
+		}
+		if err := validateSubtopic(attr); err != nil {
+			return err
+		}
+		if err := validateAlarms(attr); err != nil {
+			return err
+		}
+		if err := validatePersistence(attr); err != nil {
+			return err
+		}
+	}
+	_, err := computedAttributes(def)
+
+	return err
+}
+
+func validateSchema(attr Attribute) error {
+	switch attr.Type {
+	case "", NumberType, StringType, BoolType, DataType:
+	default:
+		return errors.Wrap(errInvalidSchema, fmt.Errorf("unknown type %s of attribute %s", attr.Type, attr.Name))
+	}
+
+	if (attr.Min != nil || attr.Max != nil) && attr.Type != NumberType {
+		return errors.Wrap(errInvalidSchema, fmt.Errorf("bounds of attribute %s require number type", attr.Name))
+	}
+	if attr.Min != nil && attr.Max != nil && *attr.Min > *attr.Max {
+		return errors.Wrap(errInvalidSchema, fmt.Errorf("minimum of attribute %s is greater than maximum", attr.Name))
+	}
+	if attr.Expression != "" && attr.Type != "" && attr.Type != NumberType {
+		return errors.Wrap(errInvalidSchema, fmt.Errorf("computed attribute %s must be a number", attr.Name))
+	}
+	if attr.UpdateInterval < 0 {
+		return errors.Wrap(errInvalidSchema, fmt.Errorf("negative update interval of attribute %s", attr.Name))
+	}
+	if attr.UpdateInterval > 0 && attr.Expression != "" {
+		return errors.Wrap(errInvalidSchema, fmt.Errorf("computed attribute %s cannot have update interval", attr.Name))
+	}
+
+	if len(attr.Enum) > 0 {
+		if attr.Type != NumberType && attr.Type != StringType {
+			return errors.Wrap(errInvalidSchema, fmt.Errorf("enum of attribute %s requires number or string type", attr.Name))
+		}
+		for _, v := range attr.Enum {
+			if valueType(v) != attr.Type {
+				return errors.Wrap(errInvalidSchema, fmt.Errorf("enum value %v of attribute %s is not a %s", v, attr.Name, attr.Type))
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateRecord verifies that the SenML record conforms to the schema of
+// the attribute it is bound to.
+func validateRecord(attr Attribute, rec senml.Record) error {
+	unit := rec.Unit
+	if unit == "" {
+		unit = rec.BaseUnit
+	}
+	if attr.Unit != "" && unit != "" && unit != attr.Unit {
+		return errors.Wrap(errSchemaViolation, fmt.Errorf("unit %s differs from %s", unit, attr.Unit))
+	}
+
+	typ, val := recordValue(rec)
+	return validateValue(attr, typ, val)
+}
+
+// validateValue verifies that the value of the given type conforms to the
+// schema of the attribute.
+func validateValue(attr Attribute, typ string, val interface{}) error {
+	if attr.Type != "" && typ != attr.Type {
+		return errors.Wrap(errSchemaViolation, fmt.Errorf("expected %s value, got %s", attr.Type, typeName(typ)))
+	}
+
+	if num, ok := val.(float64); ok && typ == NumberType {
+		if attr.Min != nil && num < *attr.Min {
+			return errors.Wrap(errSchemaViolation, fmt.Errorf("value %v is less than minimum %v", num, *attr.Min))
+		}
+		if attr.Max != nil && num > *attr.Max {
+			return errors.Wrap(errSchemaViolation, fmt.Errorf("value %v is greater than maximum %v", num, *attr.Max))
+		}
+	}
+
+	if len(attr.Enum) > 0 && !contains(attr.Enum, val) {
+		return errors.Wrap(errSchemaViolation, fmt.Errorf("value %v is not one of %v", val, attr.Enum))
+	}
+
+	return nil
+}
+
+// recordValue returns the type and the value of the SenML record, using the
+// same precedence of the value fields as the stored state payload.
+func recordValue(rec senml.Record) (string, interface{}) {
+	switch {
+	case rec.Value != nil:
+		return NumberType, *rec.Value
+	case rec.StringValue != nil:
+		return StringType, *rec.StringValue
+	case rec.DataValue != nil:
+		return DataType, *rec.DataValue
+	case rec.BoolValue != nil:
+		return BoolType, *rec.BoolValue
+	case rec.Sum != nil:
+		return NumberType, *rec.Sum
+	}
+	return "", nil
+}
+
+// valueType returns the attribute type of the JSON decoded value. Values
+// which are neither numbers, strings nor booleans are treated as data, the
+// same way they are published as desired values.
+func valueType(val interface{}) string {
+	switch val.(type) {
+	case float64, float32, int, int32, int64:
+		return NumberType
+	case string:
+		return StringType
+	case bool:
+		return BoolType
+	case nil:
+		return ""
+	}
+	return DataType
+}
+
+func typeName(typ string) string {
+	if typ == "" {
+		return "no"
+	}
+	return typ
+}
+
+func contains(enum []interface{}, val interface{}) bool {
+	num, isNum := toNumber(val)
+	for _, v := range enum {
+		if n, ok := toNumber(v); ok && isNum && n == num {
+			return true
+		}
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+func toNumber(val interface{}) (float64, bool) {
+	if _, ok := val.(bool); ok {
+		return 0, false
+	}
+	return toFloat(val)
+}
@@ -9,104 +9,381 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
+	"reflect"
 	"time"
 
 	"github.com/absmach/senml"
 	"github.com/absmach/supermq"
 	smqauthn "github.com/absmach/supermq/pkg/authn"
+	smqauthz "github.com/absmach/supermq/pkg/authz"
 	"github.com/absmach/supermq/pkg/errors"
 	svcerr "github.com/absmach/supermq/pkg/errors/service"
 	"github.com/absmach/supermq/pkg/messaging"
+	"github.com/absmach/supermq/pkg/policies"
+	"github.com/absmach/supermq/readers"
+	"github.com/go-kit/kit/metrics"
 )
 
-const publisher = "twins"
+const (
+	publisher = "twins"
+	// desiredPublisher identifies messages carrying desired attribute values
+	// so that they are not mistaken for reported ones.
+	desiredPublisher = "twins.desired"
+	// subscribePermission is the channel permission required to bind the
+	// twin attributes to the channel.
+	subscribePermission = "subscribe_permission"
+	// publishPermission is the channel permission required to publish the
+	// desired values of the twin attributes to the channel.
+	publishPermission = "publish_permission"
+)
+
+var (
+	errUnknownAttribute = errors.New("attribute is not part of the twin definition")
+	errAlreadyAttached  = errors.New("twin is already attached to a parent")
+	errCycle            = errors.New("twin cannot be attached to itself or its descendant")
+	errNotAttached      = errors.New("twin is not a child of the parent twin")
+)
+
+// ErrRevisionConflict indicates that the twin was changed since the revision
+// the change is based on.
+var ErrRevisionConflict = errors.New("twin revision does not match")
 
 // Service specifies an API that must be fullfiled by the domain service
 // implementation, and all of its decorators (e.g. logging & metrics).
 type Service interface {
-	// AddTwin adds new twin related to user identified by the provided key.
-	AddTwin(ctx context.Context, token string, twin Twin, def Definition) (tw Twin, err error)
+	// AddTwin adds new twin to the domain on behalf of the user identified by
+	// the provided key. If the twin refers to a template, its definition is
+	// instantiated from the template using the twin parameter bindings.
+	AddTwin(ctx context.Context, token, domainID string, twin Twin, def Definition) (tw Twin, err error)
 
 	// UpdateTwin updates twin identified by the provided Twin that
-	// belongs to the user identified by the provided key.
-	UpdateTwin(ctx context.Context, token string, twin Twin, def Definition) (err error)
+	// the user identified by the provided key is allowed to edit. The twin
+	// is updated only if its current revision matches the provided one,
+	// unless AnyRevision is provided.
+	UpdateTwin(ctx context.Context, token, domainID string, twin Twin, def Definition, revision int) (err error)
 
 	// ViewTwin retrieves data about twin with the provided
-	// ID belonging to the user identified by the provided key.
-	ViewTwin(ctx context.Context, token, twinID string) (tw Twin, err error)
+	// ID that the user identified by the provided key is allowed to view.
+	ViewTwin(ctx context.Context, token, domainID, twinID string) (tw Twin, err error)
 
 	// RemoveTwin removes the twin identified with the provided ID, that
-	// belongs to the user identified by the provided key.
-	RemoveTwin(ctx context.Context, token, twinID string) (err error)
-
-	// ListTwins retrieves data about subset of twins that belongs to the
-	// user identified by the provided key.
-	ListTwins(ctx context.Context, token string, offset uint64, limit uint64, name string, metadata Metadata) (Page, error)
+	// belongs to the user identified by the provided key. If cascade is set,
+	// all the twin descendants are removed as well. Otherwise, the children
+	// of the twin are detached and become root twins. The twin is removed
+	// only if its current revision matches the provided one, unless
+	// AnyRevision is provided.
+	RemoveTwin(ctx context.Context, token, domainID, twinID string, cascade bool, revision int) (err error)
+
+	// ListTwins retrieves data about subset of domain twins that are owned by
+	// or shared with the user identified by the provided key. Domain
+	// administrators retrieve all twins of the domain. If the parent ID is
+	// provided, only children of the parent twin are retrieved.
+	ListTwins(ctx context.Context, token, domainID string, offset uint64, limit uint64, name, parentID string, metadata Metadata) (Page, error)
+
+	// ExportTwins retrieves all the domain twins owned by the user identified
+	// by the owner ID, with their full definitions history and metadata. If
+	// the owner ID is empty, the twins of the user identified by the provided
+	// key are exported. Twins of other users are exported by domain
+	// administrators only.
+	ExportTwins(ctx context.Context, token, domainID, ownerID string) ([]Twin, error)
+
+	// ImportTwins saves the exported twins to the domain on behalf of the
+	// user identified by the provided key, either preserving or remapping
+	// their IDs. The twins are validated as a whole before any of them is
+	// saved, so a dry run saves none of them. Twins stay linked to the
+	// templates they were created from only if these exist in the domain.
+	ImportTwins(ctx context.Context, token, domainID string, tws []Twin, opts ImportOptions) (Import, error)
+
+	// ImportDTDL converts the interfaces of the DTDL model into twins, one
+	// per interface, and saves them to the domain on behalf of the user
+	// identified by the provided key. The DTDL constructs which cannot be
+	// converted are skipped and reported as issues.
+	ImportDTDL(ctx context.Context, token, domainID string, model []byte, opts DTDLImportOptions) (DTDLImport, error)
+
+	// ListDefinitions retrieves the definitions history of the twin
+	// identified by the provided ID, oldest first, summarizing the states
+	// recorded under each of the definitions.
+	ListDefinitions(ctx context.Context, token, domainID, twinID string) ([]DefinitionSummary, error)
+
+	// DiffDefinitions retrieves the changes made by the twin definition
+	// identified by the to ID to the definition identified by the from ID.
+	DiffDefinitions(ctx context.Context, token, domainID, twinID string, from, to int) (DefinitionDiff, error)
+
+	// RollbackDefinition appends the copy of the twin definition identified
+	// by the definition ID as the newest definition of the twin, and returns
+	// the copy. The twin is updated only if its current revision matches the
+	// provided one, unless AnyRevision is provided.
+	RollbackDefinition(ctx context.Context, token, domainID, twinID string, definitionID, revision int) (Definition, error)
+
+	// AttachChild makes the twin identified by the child ID a child of the
+	// twin identified by the parent ID. A twin can have a single parent.
+	AttachChild(ctx context.Context, token, domainID, parentID, childID string) error
+
+	// DetachChild detaches the twin identified by the child ID from the
+	// twin identified by the parent ID.
+	DetachChild(ctx context.Context, token, domainID, parentID, childID string) error
+
+	// ViewSubtree retrieves the twin identified by the provided ID together
+	// with all of its descendants the user is allowed to view.
+	ViewSubtree(ctx context.Context, token, domainID, twinID string) (TwinNode, error)
+
+	// ViewCompositeState retrieves the last states of the twin identified by
+	// the provided ID and all of its descendants the user is allowed to view.
+	ViewCompositeState(ctx context.Context, token, domainID, twinID string) (CompositeState, error)
+
+	// AddRelation adds the typed, directed relation between two twins of
+	// the domain. The user has to be allowed to edit the source twin and to
+	// view the target twin.
+	AddRelation(ctx context.Context, token, domainID string, rel Relation) (Relation, error)
+
+	// RemoveRelation removes the relation identified by its source, type
+	// and target. The user has to be allowed to edit the source twin.
+	RemoveRelation(ctx context.Context, token, domainID string, rel Relation) error
+
+	// ListRelations retrieves data about subset of relations of the twin
+	// identified by the provided ID and matching the provided filter.
+	ListRelations(ctx context.Context, token, domainID, twinID string, offset, limit uint64, filter RelationFilter) (RelationsPage, error)
+
+	// TraverseRelations retrieves the twins reachable from the twin
+	// identified by the provided ID by following the relations specified
+	// by the query, up to the query depth.
+	TraverseRelations(ctx context.Context, token, domainID, twinID string, query TraversalQuery) (Traversal, error)
+
+	// ShareTwin grants the role over the twin identified by the provided ID
+	// to the domain members identified by the user IDs.
+	ShareTwin(ctx context.Context, token, domainID, twinID, role string, userIDs []string) error
+
+	// UnshareTwin revokes the roles over the twin identified by the provided
+	// ID from the users identified by the user IDs.
+	UnshareTwin(ctx context.Context, token, domainID, twinID string, userIDs []string) error
 
 	// ListStates retrieves data about subset of states that belongs to the
-	// twin identified by the id.
-	ListStates(ctx context.Context, token string, offset uint64, limit uint64, twinID string) (StatesPage, error)
+	// twin identified by the id and matches the provided filter.
+	ListStates(ctx context.Context, token, domainID string, offset uint64, limit uint64, twinID string, filter StateFilter) (StatesPage, error)
+
+	// ListAlarms retrieves data about subset of the raised and cleared
+	// alarms of the twin identified by the id, matching the provided filter.
+	ListAlarms(ctx context.Context, token, domainID string, offset, limit uint64, twinID string, filter AlarmFilter) (AlarmsPage, error)
+
+	// StateAt retrieves the last state of the twin identified by the id
+	// created at or before the given time, together with the definition
+	// which was active at that time.
+	StateAt(ctx context.Context, token, domainID, twinID string, at time.Time) (State, Definition, error)
+
+	// DiffStates retrieves the changes of the attribute values between the
+	// two selected states of the twin identified by the provided ID. States
+	// created using different definitions are compared as well.
+	DiffStates(ctx context.Context, token, domainID, twinID string, from, to StateSelector) (StateDiff, error)
 
 	// SaveStates persists states into database
 	SaveStates(ctx context.Context, msg *messaging.Message) error
+
+	// DrainStates stops accepting the received messages and waits until the
+	// states of the queued ones are saved, or the context is done. Messages
+	// received afterwards are rejected, so that they are redelivered.
+	DrainStates(ctx context.Context) error
+
+	// ReplayStates replays the historical messages of the channels and
+	// subtopics the attributes of the twin identified by the provided ID are
+	// bound to, using the latest definition. The states created by the
+	// messages either replace the live states since the start of the replay,
+	// or replace the simulated state series of the twin.
+	ReplayStates(ctx context.Context, token, domainID, twinID string, opts ReplayOptions) (Replay, error)
+
+	// UpdateDesiredState replaces the desired state of the twin identified by
+	// the provided ID and publishes the delta against the last reported state
+	// to the channels of the twin attributes.
+	UpdateDesiredState(ctx context.Context, token, domainID, twinID string, payload map[string]interface{}) (Delta, error)
+
+	// ViewDesiredState retrieves the desired state of the twin identified by
+	// the provided ID.
+	ViewDesiredState(ctx context.Context, token, domainID, twinID string) (DesiredState, error)
+
+	// ViewDelta retrieves the difference between the desired state and the
+	// last reported state of the twin identified by the provided ID.
+	ViewDelta(ctx context.Context, token, domainID, twinID string) (Delta, error)
+
+	// StreamStates subscribes to the state and definition changes of the
+	// twins identified by the provided IDs. If attributes are provided, only
+	// states containing them are delivered, with the payload narrowed down
+	// to these attributes. Events are delivered until the context is done,
+	// when the returned channel is closed. Only the changes made by this
+	// service instance are delivered, as the events are not shared between
+	// the instances. Access to the twins is verified only when the stream is
+	// opened, so users keep receiving the events of twins they lost access
+	// to until they reopen the stream.
+	StreamStates(ctx context.Context, token, domainID string, twinIDs, attributes []string) (<-chan StreamEvent, error)
+
+	// AddTemplate adds new twin definition template to the domain on behalf
+	// of the user identified by the provided key.
+	AddTemplate(ctx context.Context, token, domainID string, tmpl Template) (Template, error)
+
+	// UpdateTemplate updates the template the user identified by the
+	// provided key is allowed to edit. The template revision is incremented
+	// when its definition changes.
+	UpdateTemplate(ctx context.Context, token, domainID string, tmpl Template) error
+
+	// ViewTemplate retrieves data about the template with the provided ID.
+	ViewTemplate(ctx context.Context, token, domainID, templateID string) (Template, error)
+
+	// ListTemplates retrieves data about subset of domain templates.
+	ListTemplates(ctx context.Context, token, domainID string, offset, limit uint64, name string) (TemplatesPage, error)
+
+	// RemoveTemplate removes the template identified with the provided ID.
+	// Twins derived from the template keep their definitions.
+	RemoveTemplate(ctx context.Context, token, domainID, templateID string) error
+
+	// RolloutTemplate adds the latest template revision as the new
+	// definition of the twins derived from the template. The provided
+	// bindings are used for the parameters the twins are missing bindings
+	// for.
+	RolloutTemplate(ctx context.Context, token, domainID, templateID string, bindings map[string]string) (Rollout, error)
+
+	// ApplyRetention removes and compacts the states of all the twins
+	// according to the retention of their latest definitions, and returns
+	// the compactions of the twins whose states were removed.
+	ApplyRetention(ctx context.Context) ([]Compaction, error)
+
+	// CheckLiveness marks the attributes of all the twins which were not
+	// received within their expected update intervals as stale, and returns
+	// the attributes which became stale or fresh since the last check.
+	CheckLiveness(ctx context.Context) ([]Staleness, error)
 }
 
 const (
 	noop = iota
 	update
 	save
+	refreshed
 	millisec         = 1e6
 	nanosec          = 1e9
 	SubtopicWildcard = ">"
 )
 
 var crudOp = map[string]string{
-	"createSucc": "create.success",
-	"createFail": "create.failure",
-	"updateSucc": "update.success",
-	"updateFail": "update.failure",
-	"getSucc":    "get.success",
-	"getFail":    "get.failure",
-	"removeSucc": "remove.success",
-	"removeFail": "remove.failure",
-	"stateSucc":  "save.success",
-	"stateFail":  "save.failure",
+	"createSucc":    "create.success",
+	"createFail":    "create.failure",
+	"updateSucc":    "update.success",
+	"updateFail":    "update.failure",
+	"getSucc":       "get.success",
+	"getFail":       "get.failure",
+	"removeSucc":    "remove.success",
+	"removeFail":    "remove.failure",
+	"stateSucc":     "save.success",
+	"stateFail":     "save.failure",
+	"desireSucc":    "desired.success",
+	"desireFail":    "desired.failure",
+	"shareSucc":     "share.success",
+	"shareFail":     "share.failure",
+	"unshareSucc":   "unshare.success",
+	"unshareFail":   "unshare.failure",
+	"attachSucc":    "attach.success",
+	"attachFail":    "attach.failure",
+	"detachSucc":    "detach.success",
+	"detachFail":    "detach.failure",
+	"relateSucc":    "relate.success",
+	"relateFail":    "relate.failure",
+	"unrelateSucc":  "unrelate.success",
+	"unrelateFail":  "unrelate.failure",
+	"rolloutSucc":   "rollout.success",
+	"rolloutFail":   "rollout.failure",
+	"importSucc":    "import.success",
+	"importFail":    "import.failure",
+	"rollbackSucc":  "rollback.success",
+	"rollbackFail":  "rollback.failure",
+	"retentionSucc": "retention.success",
+	"retentionFail": "retention.failure",
+	"replaySucc":    "replay.success",
+	"replayFail":    "replay.failure",
+	"alarmRaised":   "alarm.raised",
+	"alarmCleared":  "alarm.cleared",
+	"alarmFail":     "alarm.failure",
+	"staleSucc":     "attribute.stale",
+	"freshSucc":     "attribute.fresh",
+	"livenessFail":  "liveness.failure",
+	"invalidFail":   "validation.failure",
 }
 
 type twinservice struct {
 	publisher  messaging.Publisher
 	auth       smqauthn.Authentication
+	authz      smqauthz.Authorization
+	policies   policies.Service
 	twins      TwinRepository
+	templates  TemplateRepository
 	states     StateRepository
+	relations  RelationRepository
+	messages   readers.MessageRepository
 	idProvider supermq.IDProvider
 	channelID  string
 	twinCache  TwinCache
+	stream     *stream
+	views      *views
+	locks      *twinLocks
+	ingester   *ingester
+	failed     metrics.Counter
 	logger     *slog.Logger
 }
 
 var _ Service = (*twinservice)(nil)
 
 // New instantiates the twins service implementation.
-func New(publisher messaging.Publisher, auth smqauthn.Authentication, twins TwinRepository, tcache TwinCache, sr StateRepository, idp supermq.IDProvider, chann string, logger *slog.Logger) Service {
-	return &twinservice{
+func New(publisher messaging.Publisher, auth smqauthn.Authentication, authz smqauthz.Authorization, ps policies.Service, twins TwinRepository, tcache TwinCache, sr StateRepository, tr TemplateRepository, rr RelationRepository, mr readers.MessageRepository, idp supermq.IDProvider, chann string, ingest IngestConfig, logger *slog.Logger) Service {
+	ts := &twinservice{
 		publisher:  publisher,
 		auth:       auth,
+		authz:      authz,
+		policies:   ps,
 		twins:      twins,
+		templates:  tr,
 		twinCache:  tcache,
 		states:     sr,
+		relations:  rr,
+		messages:   mr,
 		idProvider: idp,
 		channelID:  chann,
+		stream:     newStream(),
+		views:      newViews(ingest.ViewTTL),
+		locks:      newTwinLocks(),
+		failed:     ingest.Failed,
 		logger:     logger,
 	}
+	if ingest.Workers > 0 {
+		ts.ingester = newIngester(ingest, ts.processBatch)
+	}
+
+	return ts
 }
 
-func (ts *twinservice) AddTwin(ctx context.Context, token string, twin Twin, def Definition) (tw Twin, err error) {
+func (ts *twinservice) AddTwin(ctx context.Context, token, domainID string, twin Twin, def Definition) (tw Twin, err error) {
 	var id string
 	var b []byte
 	defer ts.publish(ctx, &id, &err, crudOp["createSucc"], crudOp["createFail"], &b)
-	res, err := ts.auth.Authenticate(ctx, token)
+	session, err := ts.identify(ctx, token, domainID)
 	if err != nil {
-		return Twin{}, errors.Wrap(svcerr.ErrAuthentication, err)
+		return Twin{}, err
+	}
+
+	twin.TemplateRevision = 0
+	if twin.Template != "" {
+		if len(def.Attributes) > 0 {
+			return Twin{}, errors.Wrap(svcerr.ErrMalformedEntity, errTemplateAndDef)
+		}
+		tmpl, err := ts.authorizeTemplate(ctx, session, domainID, twin.Template, policies.ViewPermission)
+		if err != nil {
+			return Twin{}, err
+		}
+		if def, err = instantiate(tmpl, twin.Bindings); err != nil {
+			return Twin{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+		}
+		twin.TemplateRevision = tmpl.Revision
+	}
+
+	if err := validateDefinition(def); err != nil {
+		return Twin{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+	if err := ts.checkChannels(ctx, session.UserID, domainID, subscribePermission, def); err != nil {
+		return Twin{}, err
 	}
 
 	twin.ID, err = ts.idProvider.ID()
@@ -114,7 +391,8 @@ func (ts *twinservice) AddTwin(ctx context.Context, token string, twin Twin, def
 		return Twin{}, err
 	}
 
-	twin.Owner = res.UserID
+	twin.Owner = session.UserID
+	twin.Domain = domainID
 
 	t := time.Now()
 	twin.Created = t
@@ -135,6 +413,12 @@ func (ts *twinservice) AddTwin(ctx context.Context, token string, twin Twin, def
 	if _, err = ts.twins.Save(ctx, twin); err != nil {
 		return Twin{}, errors.Wrap(svcerr.ErrCreateEntity, err)
 	}
+	if err := ts.addTwinPolicies(ctx, twin); err != nil {
+		if errRollback := ts.twins.Remove(ctx, twin.ID); errRollback != nil {
+			err = errors.Wrap(err, errRollback)
+		}
+		return Twin{}, err
+	}
 
 	id = twin.ID
 	b, err = json.Marshal(twin)
@@ -142,41 +426,51 @@ func (ts *twinservice) AddTwin(ctx context.Context, token string, twin Twin, def
 	return twin, ts.twinCache.Save(ctx, twin)
 }
 
-func (ts *twinservice) UpdateTwin(ctx context.Context, token string, twin Twin, def Definition) (err error) {
+func (ts *twinservice) UpdateTwin(ctx context.Context, token, domainID string, twin Twin, def Definition, revision int) (err error) {
 	var b []byte
 	var id string
 	defer ts.publish(ctx, &id, &err, crudOp["updateSucc"], crudOp["updateFail"], &b)
 
-	_, err = ts.auth.Authenticate(ctx, token)
+	session, err := ts.identify(ctx, token, domainID)
 	if err != nil {
-		return errors.Wrap(svcerr.ErrAuthentication, err)
+		return err
 	}
 
-	tw, err := ts.twins.RetrieveByID(ctx, twin.ID)
+	tw, err := ts.authorize(ctx, session, domainID, twin.ID, policies.EditPermission)
 	if err != nil {
-		return errors.Wrap(svcerr.ErrNotFound, err)
+		return err
 	}
 
-	revision := false
+	if err := checkRevision(tw, revision); err != nil {
+		return err
+	}
+
+	changed := false
 
 	if twin.Name != "" {
-		revision = true
+		changed = true
 		tw.Name = twin.Name
 	}
 
 	if len(def.Attributes) > 0 {
-		revision = true
+		if err := validateDefinition(def); err != nil {
+			return errors.Wrap(svcerr.ErrMalformedEntity, err)
+		}
+		if err := ts.checkChannels(ctx, session.UserID, domainID, subscribePermission, def); err != nil {
+			return err
+		}
+		changed = true
 		def.Created = time.Now()
 		def.ID = tw.Definitions[len(tw.Definitions)-1].ID + 1
 		tw.Definitions = append(tw.Definitions, def)
 	}
 
 	if len(twin.Metadata) > 0 {
-		revision = true
+		changed = true
 		tw.Metadata = twin.Metadata
 	}
 
-	if !revision {
+	if !changed {
 		return errors.ErrMalformedEntity
 	}
 
@@ -186,6 +480,11 @@ func (ts *twinservice) UpdateTwin(ctx context.Context, token string, twin Twin,
 	if err := ts.twins.Update(ctx, tw); err != nil {
 		return errors.Wrap(svcerr.ErrUpdateEntity, err)
 	}
+	ts.views.invalidate(tw.ID)
+
+	if len(def.Attributes) > 0 {
+		ts.broadcast(StreamEvent{Type: DefinitionUpdated, TwinID: tw.ID, Definition: &def})
+	}
 
 	id = twin.ID
 	b, err = json.Marshal(tw)
@@ -193,18 +492,31 @@ func (ts *twinservice) UpdateTwin(ctx context.Context, token string, twin Twin,
 	return ts.twinCache.Update(ctx, twin)
 }
 
-func (ts *twinservice) ViewTwin(ctx context.Context, token, twinID string) (tw Twin, err error) {
+func (ts *twinservice) ViewTwin(ctx context.Context, token, domainID, twinID string) (tw Twin, err error) {
 	var b []byte
 	defer ts.publish(ctx, &twinID, &err, crudOp["getSucc"], crudOp["getFail"], &b)
 
-	_, err = ts.auth.Authenticate(ctx, token)
+	session, err := ts.identify(ctx, token, domainID)
 	if err != nil {
-		return Twin{}, errors.Wrap(svcerr.ErrAuthorization, err)
+		return Twin{}, err
 	}
 
-	twin, err := ts.twins.RetrieveByID(ctx, twinID)
+	twin, err := ts.authorize(ctx, session, domainID, twinID, policies.ViewPermission)
 	if err != nil {
-		return Twin{}, errors.Wrap(svcerr.ErrNotFound, err)
+		return Twin{}, err
+	}
+
+	if monitored(twin) {
+		last, err := ts.states.RetrieveLast(ctx, twinID)
+		if err != nil {
+			return Twin{}, errors.Wrap(svcerr.ErrViewEntity, err)
+		}
+		switch {
+		case last.Payload != nil:
+			twin.Stale = last.Stale
+		case twin.Liveness != nil:
+			twin.Stale = twin.Liveness.Stale
+		}
 	}
 
 	b, err = json.Marshal(twin)
@@ -212,210 +524,1445 @@ func (ts *twinservice) ViewTwin(ctx context.Context, token, twinID string) (tw T
 	return twin, nil
 }
 
-func (ts *twinservice) RemoveTwin(ctx context.Context, token, twinID string) (err error) {
+func (ts *twinservice) RemoveTwin(ctx context.Context, token, domainID, twinID string, cascade bool, revision int) (err error) {
 	var b []byte
 	defer ts.publish(ctx, &twinID, &err, crudOp["removeSucc"], crudOp["removeFail"], &b)
 
-	_, err = ts.auth.Authenticate(ctx, token)
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return err
+	}
+
+	tw, err := ts.authorize(ctx, session, domainID, twinID, policies.DeletePermission)
+	if err != nil {
+		return err
+	}
+
+	if err := checkRevision(tw, revision); err != nil {
+		return err
+	}
+
+	if cascade {
+		admin := ts.checkDomain(ctx, session.UserID, domainID, policies.AdminPermission) == nil
+		children, err := ts.descendants(ctx, tw, func(Twin) bool { return true })
+		if err != nil {
+			return err
+		}
+		// Verify all the permissions upfront so that the subtree is not
+		// removed partially. Descendants are removed bottom-up, so that an
+		// interrupted removal does not leave orphaned twins.
+		var ids []string
+		var collect func(node TwinNode) bool
+		collect = func(node TwinNode) bool {
+			for _, ch := range node.Children {
+				if !admin && ts.checkTwin(ctx, session.UserID, domainID, ch.Twin.ID, policies.DeletePermission) != nil {
+					return false
+				}
+				if !collect(ch) {
+					return false
+				}
+				ids = append(ids, ch.Twin.ID)
+			}
+			return true
+		}
+		if !collect(buildNode(tw, children)) {
+			return svcerr.ErrAuthorization
+		}
+		for _, id := range ids {
+			if err := ts.removeTwin(ctx, id); err != nil {
+				return err
+			}
+		}
+		return ts.removeTwin(ctx, twinID)
+	}
+
+	children, err := ts.twins.RetrieveChildren(ctx, twinID)
 	if err != nil {
-		return errors.Wrap(svcerr.ErrAuthentication, err)
+		return errors.Wrap(svcerr.ErrViewEntity, err)
 	}
+	for _, ch := range children {
+		ch.Parent = ""
+		ch.Updated = time.Now()
+		ch.Revision++
+		if err := ts.twins.Update(ctx, ch); err != nil {
+			return errors.Wrap(svcerr.ErrUpdateEntity, err)
+		}
+	}
+
+	return ts.removeTwin(ctx, twinID)
+}
 
+func (ts *twinservice) removeTwin(ctx context.Context, twinID string) error {
 	if err := ts.twins.Remove(ctx, twinID); err != nil {
 		return errors.Wrap(svcerr.ErrRemoveEntity, err)
 	}
+	if err := ts.policies.DeletePolicyFilter(ctx, policies.Policy{ObjectType: TwinType, Object: twinID}); err != nil {
+		return errors.Wrap(svcerr.ErrDeletePolicies, err)
+	}
+	if err := ts.relations.RemoveByTwin(ctx, twinID); err != nil {
+		return errors.Wrap(svcerr.ErrRemoveEntity, err)
+	}
+	ts.views.invalidate(twinID)
 
 	return ts.twinCache.Remove(ctx, twinID)
 }
 
-func (ts *twinservice) ListTwins(ctx context.Context, token string, offset, limit uint64, name string, metadata Metadata) (Page, error) {
-	res, err := ts.auth.Authenticate(ctx, token)
+func (ts *twinservice) ListTwins(ctx context.Context, token, domainID string, offset, limit uint64, name, parentID string, metadata Metadata) (Page, error) {
+	session, err := ts.identify(ctx, token, domainID)
 	if err != nil {
-		return Page{}, errors.Wrap(svcerr.ErrAuthentication, err)
+		return Page{}, err
+	}
+
+	// Domain administrators can see all the twins of the domain, while
+	// the other users only see the twins they are allowed to view.
+	var ids []string
+	if err := ts.checkDomain(ctx, session.UserID, domainID, policies.AdminPermission); err != nil {
+		pr := policies.Policy{
+			SubjectType: policies.UserType,
+			Subject:     policies.EncodeDomainUserID(domainID, session.UserID),
+			Permission:  policies.ViewPermission,
+			ObjectType:  TwinType,
+		}
+		page, err := ts.policies.ListAllObjects(ctx, pr)
+		if err != nil {
+			return Page{}, errors.Wrap(svcerr.ErrViewEntity, err)
+		}
+		ids = append([]string{}, page.Policies...)
 	}
 
-	return ts.twins.RetrieveAll(ctx, res.UserID, offset, limit, name, metadata)
+	return ts.twins.RetrieveAll(ctx, domainID, ids, offset, limit, name, parentID, metadata)
 }
 
-func (ts *twinservice) ListStates(ctx context.Context, token string, offset, limit uint64, twinID string) (StatesPage, error) {
-	_, err := ts.auth.Authenticate(ctx, token)
+func (ts *twinservice) AttachChild(ctx context.Context, token, domainID, parentID, childID string) (err error) {
+	var b []byte
+	defer ts.publish(ctx, &childID, &err, crudOp["attachSucc"], crudOp["attachFail"], &b)
+
+	session, err := ts.identify(ctx, token, domainID)
 	if err != nil {
-		return StatesPage{}, svcerr.ErrAuthentication
+		return err
 	}
 
-	return ts.states.RetrieveAll(ctx, offset, limit, twinID)
-}
-
-func (ts *twinservice) SaveStates(ctx context.Context, msg *messaging.Message) error {
-	var ids []string
+	if _, err := ts.authorize(ctx, session, domainID, parentID, policies.EditPermission); err != nil {
+		return err
+	}
 
-	channel, subtopic := msg.GetChannel(), msg.GetSubtopic()
-	ids, err := ts.twinCache.IDs(ctx, channel, subtopic)
+	child, err := ts.authorize(ctx, session, domainID, childID, policies.EditPermission)
 	if err != nil {
 		return err
 	}
-	if len(ids) < 1 {
-		ids, err = ts.twins.RetrieveByAttribute(ctx, channel, subtopic)
-		if err != nil {
-			return err
-		}
-		if len(ids) < 1 {
-			return nil
+
+	if child.Parent != "" {
+		return errors.Wrap(svcerr.ErrConflict, errAlreadyAttached)
+	}
+
+	// Walk up the ancestors of the parent to prevent cycles.
+	for id := parentID; id != ""; {
+		if id == childID {
+			return errors.Wrap(svcerr.ErrMalformedEntity, errCycle)
 		}
-		if err := ts.twinCache.SaveIDs(ctx, channel, subtopic, ids); err != nil {
-			return err
+		ancestor, err := ts.twins.RetrieveByID(ctx, id)
+		if err != nil {
+			return errors.Wrap(svcerr.ErrViewEntity, err)
 		}
+		id = ancestor.Parent
 	}
 
-	for _, id := range ids {
-		if err := ts.saveState(ctx, msg, id); err != nil {
-			return err
-		}
+	child.Parent = parentID
+	child.Updated = time.Now()
+	child.Revision++
+	if err := ts.twins.Update(ctx, child); err != nil {
+		return errors.Wrap(svcerr.ErrUpdateEntity, err)
 	}
 
-	return nil
+	b, err = json.Marshal(child)
+
+	return err
 }
 
-func (ts *twinservice) saveState(ctx context.Context, msg *messaging.Message, twinID string) error {
+func (ts *twinservice) DetachChild(ctx context.Context, token, domainID, parentID, childID string) (err error) {
 	var b []byte
-	var err error
+	defer ts.publish(ctx, &childID, &err, crudOp["detachSucc"], crudOp["detachFail"], &b)
+
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return err
+	}
 
-	defer ts.publish(ctx, &twinID, &err, crudOp["stateSucc"], crudOp["stateFail"], &b)
+	if _, err := ts.authorize(ctx, session, domainID, parentID, policies.EditPermission); err != nil {
+		return err
+	}
 
-	tw, err := ts.twins.RetrieveByID(ctx, twinID)
+	child, err := ts.authorize(ctx, session, domainID, childID, policies.EditPermission)
 	if err != nil {
-		return fmt.Errorf("retrieving twin for %s failed: %s", msg.GetPublisher(), err)
+		return err
+	}
+
+	if child.Parent != parentID {
+		return errors.Wrap(svcerr.ErrNotFound, errNotAttached)
 	}
 
-	var recs []senml.Record
-	if err := json.Unmarshal(msg.GetPayload(), &recs); err != nil {
-		return fmt.Errorf("unmarshal payload for %s failed: %s", msg.GetPublisher(), err)
+	child.Parent = ""
+	child.Updated = time.Now()
+	child.Revision++
+	if err := ts.twins.Update(ctx, child); err != nil {
+		return errors.Wrap(svcerr.ErrUpdateEntity, err)
 	}
 
-	st, err := ts.states.RetrieveLast(ctx, tw.ID)
+	b, err = json.Marshal(child)
+
+	return err
+}
+
+func (ts *twinservice) ViewSubtree(ctx context.Context, token, domainID, twinID string) (TwinNode, error) {
+	session, err := ts.identify(ctx, token, domainID)
 	if err != nil {
-		return fmt.Errorf("retrieve last state for %s failed: %s", msg.GetPublisher(), err)
+		return TwinNode{}, err
 	}
 
-	for _, rec := range recs {
-		action := ts.prepareState(&st, &tw, rec, msg)
-		switch action {
-		case noop:
-			return nil
-		case update:
-			if err := ts.states.Update(ctx, st); err != nil {
-				return fmt.Errorf("update state for %s failed: %s", msg.GetPublisher(), err)
-			}
-		case save:
-			if err := ts.states.Save(ctx, st); err != nil {
-				return fmt.Errorf("save state for %s failed: %s", msg.GetPublisher(), err)
-			}
-		}
+	tw, err := ts.authorize(ctx, session, domainID, twinID, policies.ViewPermission)
+	if err != nil {
+		return TwinNode{}, err
 	}
 
-	twinID = msg.GetPublisher()
-	b = msg.GetPayload()
+	return ts.subtree(ctx, session, tw)
+}
 
-	return nil
+func (ts *twinservice) ViewCompositeState(ctx context.Context, token, domainID, twinID string) (CompositeState, error) {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return CompositeState{}, err
+	}
+
+	tw, err := ts.authorize(ctx, session, domainID, twinID, policies.ViewPermission)
+	if err != nil {
+		return CompositeState{}, err
+	}
+
+	node, err := ts.subtree(ctx, session, tw)
+	if err != nil {
+		return CompositeState{}, err
+	}
+
+	return ts.compose(ctx, node)
 }
 
-func (ts *twinservice) prepareState(st *State, tw *Twin, rec senml.Record, msg *messaging.Message) int {
-	def := tw.Definitions[len(tw.Definitions)-1]
-	st.TwinID = tw.ID
-	st.Definition = def.ID
+// subtree builds the tree of the twin descendants visible to the user.
+// Descendants the user is not allowed to view are omitted together with
+// their own descendants.
+func (ts *twinservice) subtree(ctx context.Context, session smqauthn.Session, tw Twin) (TwinNode, error) {
+	admin := ts.checkDomain(ctx, session.UserID, tw.Domain, policies.AdminPermission) == nil
+	children, err := ts.descendants(ctx, tw, func(ch Twin) bool {
+		return admin || ts.checkTwin(ctx, session.UserID, tw.Domain, ch.ID, policies.ViewPermission) == nil
+	})
+	if err != nil {
+		return TwinNode{}, err
+	}
 
-	if st.Payload == nil {
-		st.Payload = make(map[string]interface{})
-		st.ID = -1 // state is incremented on save -> zero-based index
-	} else {
-		for k := range st.Payload {
-			idx := findAttribute(k, def.Attributes)
-			if idx < 0 || !def.Attributes[idx].PersistState {
-				delete(st.Payload, k)
+	return buildNode(tw, children), nil
+}
+
+// descendants retrieves the descendants of the twin level by level and
+// returns them grouped by the parent ID. Descendants rejected by the accept
+// function are skipped together with their own descendants.
+func (ts *twinservice) descendants(ctx context.Context, tw Twin, accept func(Twin) bool) (map[string][]Twin, error) {
+	children := map[string][]Twin{}
+	visited := map[string]bool{tw.ID: true}
+	level := []string{tw.ID}
+	for len(level) > 0 {
+		found, err := ts.twins.RetrieveChildren(ctx, level...)
+		if err != nil {
+			return nil, errors.Wrap(svcerr.ErrViewEntity, err)
+		}
+		level = []string{}
+		for _, ch := range found {
+			if visited[ch.ID] || !accept(ch) {
+				continue
 			}
+			visited[ch.ID] = true
+			children[ch.Parent] = append(children[ch.Parent], ch)
+			level = append(level, ch.ID)
 		}
 	}
 
-	recSec := rec.BaseTime + rec.Time
-	recNano := recSec * nanosec
-	sec, dec := math.Modf(recSec)
-	recTime := time.Unix(int64(sec), int64(dec*nanosec))
+	return children, nil
+}
 
-	action := noop
-	for _, attr := range def.Attributes {
-		if !attr.PersistState {
-			continue
+func buildNode(tw Twin, children map[string][]Twin) TwinNode {
+	node := TwinNode{Twin: tw}
+	for _, ch := range children[tw.ID] {
+		node.Children = append(node.Children, buildNode(ch, children))
+	}
+	return node
+}
+
+// compose assembles the composite state out of the last states of the twins
+// in the tree.
+func (ts *twinservice) compose(ctx context.Context, node TwinNode) (CompositeState, error) {
+	st, err := ts.states.RetrieveLast(ctx, node.Twin.ID)
+	if err != nil {
+		return CompositeState{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	cs := CompositeState{
+		TwinID:  node.Twin.ID,
+		Name:    node.Twin.Name,
+		State:   st,
+		Updated: st.Created,
+	}
+	for _, ch := range node.Children {
+		chs, err := ts.compose(ctx, ch)
+		if err != nil {
+			return CompositeState{}, err
 		}
-		if attr.Channel == msg.GetChannel() && (attr.Subtopic == SubtopicWildcard || attr.Subtopic == msg.GetSubtopic()) {
-			action = update
-			delta := math.Abs(float64(st.Created.UnixNano()) - recNano)
-			if recNano == 0 || delta > float64(def.Delta) {
-				action = save
-				st.ID++
-				st.Created = time.Now()
-				if recNano != 0 {
-					st.Created = recTime
-				}
-			}
-			val := findValue(rec)
-			st.Payload[attr.Name] = val
+		if chs.Updated.After(cs.Updated) {
+			cs.Updated = chs.Updated
+		}
+		cs.Children = append(cs.Children, chs)
+	}
 
-			break
+	return cs, nil
+}
+
+func (ts *twinservice) ShareTwin(ctx context.Context, token, domainID, twinID, role string, userIDs []string) (err error) {
+	var b []byte
+	defer ts.publish(ctx, &twinID, &err, crudOp["shareSucc"], crudOp["shareFail"], &b)
+
+	if role != ViewerRole && role != EditorRole {
+		return svcerr.ErrInvalidRole
+	}
+
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return err
+	}
+
+	tw, err := ts.authorize(ctx, session, domainID, twinID, policies.SharePermission)
+	if err != nil {
+		return err
+	}
+
+	var prs []policies.Policy
+	for _, userID := range userIDs {
+		// Twins can only be shared with members of their domain.
+		if err := ts.checkDomain(ctx, userID, domainID, policies.MembershipPermission); err != nil {
+			return err
 		}
+		prs = append(prs, sharePolicy(domainID, twinID, userID, role))
 	}
 
-	return action
+	// The role replaces the one the users were previously granted.
+	if err := ts.unshare(ctx, domainID, twinID, userIDs); err != nil {
+		return err
+	}
+	if err := ts.policies.AddPolicies(ctx, prs); err != nil {
+		return errors.Wrap(svcerr.ErrAddPolicies, err)
+	}
+
+	b, err = json.Marshal(tw)
+
+	return err
 }
 
-func findValue(rec senml.Record) interface{} {
-	if rec.Value != nil {
-		return rec.Value
+func (ts *twinservice) UnshareTwin(ctx context.Context, token, domainID, twinID string, userIDs []string) (err error) {
+	var b []byte
+	defer ts.publish(ctx, &twinID, &err, crudOp["unshareSucc"], crudOp["unshareFail"], &b)
+
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return err
 	}
-	if rec.StringValue != nil {
-		return rec.StringValue
+
+	tw, err := ts.authorize(ctx, session, domainID, twinID, policies.SharePermission)
+	if err != nil {
+		return err
 	}
-	if rec.DataValue != nil {
-		return rec.DataValue
+
+	if err := ts.unshare(ctx, domainID, twinID, userIDs); err != nil {
+		return err
 	}
-	if rec.BoolValue != nil {
-		return rec.BoolValue
+
+	b, err = json.Marshal(tw)
+
+	return err
+}
+
+// unshare revokes the roles the users were granted over the twin.
+func (ts *twinservice) unshare(ctx context.Context, domainID, twinID string, userIDs []string) error {
+	var prs []policies.Policy
+	for _, userID := range userIDs {
+		prs = append(prs, sharePolicy(domainID, twinID, userID, ViewerRole), sharePolicy(domainID, twinID, userID, EditorRole))
 	}
-	if rec.Sum != nil {
-		return rec.Sum
+	if len(prs) == 0 {
+		return nil
+	}
+	if err := ts.policies.DeletePolicies(ctx, prs); err != nil {
+		return errors.Wrap(svcerr.ErrDeletePolicies, err)
 	}
+
 	return nil
 }
 
-func findAttribute(name string, attrs []Attribute) (idx int) {
-	for idx, attr := range attrs {
-		if attr.Name == name {
-			return idx
-		}
+func sharePolicy(domainID, twinID, userID, role string) policies.Policy {
+	return policies.Policy{
+		Domain:      domainID,
+		SubjectType: policies.UserType,
+		Subject:     policies.EncodeDomainUserID(domainID, userID),
+		Relation:    role,
+		ObjectType:  TwinType,
+		Object:      twinID,
 	}
-	return -1
 }
 
-func (ts *twinservice) publish(ctx context.Context, twinID *string, err *error, succOp, failOp string, payload *[]byte) {
-	if ts.channelID == "" {
-		return
+func (ts *twinservice) ListStates(ctx context.Context, token, domainID string, offset, limit uint64, twinID string, filter StateFilter) (StatesPage, error) {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return StatesPage{}, err
 	}
 
-	op := succOp
-	if *err != nil {
-		op = failOp
-		esb := []byte((*err).Error())
-		payload = &esb
+	if _, err := ts.authorize(ctx, session, domainID, twinID, policies.ViewPermission); err != nil {
+		return StatesPage{}, err
 	}
 
-	pl := *payload
-	if pl == nil {
-		pl = []byte(fmt.Sprintf("{\"deleted\":\"%s\"}", *twinID))
+	return ts.states.RetrieveAll(ctx, offset, limit, twinID, filter)
+}
+
+func (ts *twinservice) ListAlarms(ctx context.Context, token, domainID string, offset, limit uint64, twinID string, filter AlarmFilter) (AlarmsPage, error) {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return AlarmsPage{}, err
+	}
+
+	if _, err := ts.authorize(ctx, session, domainID, twinID, policies.ViewPermission); err != nil {
+		return AlarmsPage{}, err
+	}
+
+	return ts.states.RetrieveAlarms(ctx, offset, limit, twinID, filter)
+}
+
+func (ts *twinservice) StateAt(ctx context.Context, token, domainID, twinID string, at time.Time) (State, Definition, error) {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return State{}, Definition{}, err
+	}
+
+	tw, err := ts.authorize(ctx, session, domainID, twinID, policies.ViewPermission)
+	if err != nil {
+		return State{}, Definition{}, err
+	}
+
+	def, ok := tw.DefinitionAt(at)
+	if !ok {
+		return State{}, Definition{}, svcerr.ErrNotFound
+	}
+
+	st, err := ts.states.RetrieveAt(ctx, twinID, at)
+	if err != nil {
+		return State{}, Definition{}, errors.Wrap(svcerr.ErrNotFound, err)
+	}
+
+	return st, def, nil
+}
+
+func (ts *twinservice) UpdateDesiredState(ctx context.Context, token, domainID, twinID string, payload map[string]interface{}) (delta Delta, err error) {
+	var b []byte
+	defer ts.publish(ctx, &twinID, &err, crudOp["desireSucc"], crudOp["desireFail"], &b)
+
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	tw, err := ts.authorize(ctx, session, domainID, twinID, policies.EditPermission)
+	if err != nil {
+		return nil, err
+	}
+
+	def := tw.Definitions[len(tw.Definitions)-1]
+	var desired Definition
+	for name, val := range payload {
+		idx := findAttribute(name, def.Attributes)
+		if idx < 0 {
+			return nil, errors.Wrap(svcerr.ErrMalformedEntity, errUnknownAttribute)
+		}
+		if err := validateValue(def.Attributes[idx], valueType(val), val); err != nil {
+			return nil, errors.Wrap(svcerr.ErrMalformedEntity, err)
+		}
+		desired.Attributes = append(desired.Attributes, def.Attributes[idx])
+	}
+	// The desired values are published to the channels of the attributes.
+	if err := ts.checkChannels(ctx, session.UserID, tw.Domain, publishPermission, desired); err != nil {
+		return nil, err
+	}
+
+	ds := DesiredState{
+		TwinID:  twinID,
+		Updated: time.Now(),
+		Payload: payload,
+	}
+	if err = ts.states.SaveDesired(ctx, ds); err != nil {
+		return nil, errors.Wrap(svcerr.ErrUpdateEntity, err)
+	}
+
+	st, err := ts.states.RetrieveLast(ctx, twinID)
+	if err != nil {
+		return nil, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	delta = computeDelta(ds, st)
+	ts.publishDelta(ctx, tw.Domain, def, delta)
+
+	b, err = json.Marshal(ds)
+
+	return delta, err
+}
+
+func (ts *twinservice) ViewDesiredState(ctx context.Context, token, domainID, twinID string) (DesiredState, error) {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return DesiredState{}, err
+	}
+
+	if _, err := ts.authorize(ctx, session, domainID, twinID, policies.ViewPermission); err != nil {
+		return DesiredState{}, err
+	}
+
+	ds, err := ts.states.RetrieveDesired(ctx, twinID)
+	if err != nil {
+		return DesiredState{}, errors.Wrap(svcerr.ErrNotFound, err)
+	}
+
+	return ds, nil
+}
+
+func (ts *twinservice) ViewDelta(ctx context.Context, token, domainID, twinID string) (Delta, error) {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := ts.authorize(ctx, session, domainID, twinID, policies.ViewPermission); err != nil {
+		return nil, err
+	}
+
+	ds, err := ts.states.RetrieveDesired(ctx, twinID)
+	if err != nil {
+		return nil, errors.Wrap(svcerr.ErrNotFound, err)
+	}
+
+	st, err := ts.states.RetrieveLast(ctx, twinID)
+	if err != nil {
+		return nil, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	return computeDelta(ds, st), nil
+}
+
+// identify authenticates the user and verifies that the user is a member of
+// the domain.
+func (ts *twinservice) identify(ctx context.Context, token, domainID string) (smqauthn.Session, error) {
+	session, err := ts.auth.Authenticate(ctx, token)
+	if err != nil {
+		return smqauthn.Session{}, errors.Wrap(svcerr.ErrAuthentication, err)
+	}
+
+	if err := ts.checkDomain(ctx, session.UserID, domainID, policies.MembershipPermission); err != nil {
+		return smqauthn.Session{}, err
+	}
+
+	return session, nil
+}
+
+// authorize retrieves the twin and verifies that the user holds the
+// permission over it. The twin owner and domain administrators hold all the
+// permissions, while the users the twin is shared with are limited by their
+// role.
+func (ts *twinservice) authorize(ctx context.Context, session smqauthn.Session, domainID, twinID, permission string) (Twin, error) {
+	tw, err := ts.twins.RetrieveByID(ctx, twinID)
+	if err != nil {
+		return Twin{}, errors.Wrap(svcerr.ErrNotFound, err)
+	}
+
+	// Twins of other domains are hidden rather than forbidden.
+	if tw.Domain != domainID {
+		return Twin{}, svcerr.ErrNotFound
+	}
+
+	if err := ts.checkTwin(ctx, session.UserID, domainID, twinID, permission); err != nil {
+		return Twin{}, err
+	}
+
+	return tw, nil
+}
+
+func (ts *twinservice) checkTwin(ctx context.Context, userID, domainID, twinID, permission string) error {
+	req := smqauthz.PolicyReq{
+		Domain:      domainID,
+		SubjectType: policies.UserType,
+		SubjectKind: policies.UsersKind,
+		Subject:     policies.EncodeDomainUserID(domainID, userID),
+		Permission:  permission,
+		ObjectType:  TwinType,
+		Object:      twinID,
+	}
+	if err := ts.authz.Authorize(ctx, req); err != nil {
+		return errors.Wrap(svcerr.ErrAuthorization, err)
+	}
+
+	return nil
+}
+
+// addTwinPolicies binds the twin to its domain and grants its owner the
+// administrator role over it.
+func (ts *twinservice) addTwinPolicies(ctx context.Context, tws ...Twin) error {
+	var prs []policies.Policy
+	for _, tw := range tws {
+		prs = append(prs,
+			policies.Policy{
+				Domain:      tw.Domain,
+				SubjectType: policies.DomainType,
+				Subject:     tw.Domain,
+				Relation:    policies.DomainRelation,
+				ObjectType:  TwinType,
+				Object:      tw.ID,
+			},
+			policies.Policy{
+				Domain:      tw.Domain,
+				SubjectType: policies.UserType,
+				Subject:     policies.EncodeDomainUserID(tw.Domain, tw.Owner),
+				Relation:    policies.AdministratorRelation,
+				ObjectType:  TwinType,
+				Object:      tw.ID,
+			})
+	}
+	if err := ts.policies.AddPolicies(ctx, prs); err != nil {
+		return errors.Wrap(svcerr.ErrAddPolicies, err)
+	}
+
+	return nil
+}
+
+func (ts *twinservice) checkDomain(ctx context.Context, userID, domainID, permission string) error {
+	req := smqauthz.PolicyReq{
+		Domain:      domainID,
+		SubjectType: policies.UserType,
+		SubjectKind: policies.UsersKind,
+		Subject:     policies.EncodeDomainUserID(domainID, userID),
+		Permission:  permission,
+		ObjectType:  policies.DomainType,
+		Object:      domainID,
+	}
+	if err := ts.authz.Authorize(ctx, req); err != nil {
+		return errors.Wrap(svcerr.ErrAuthorization, err)
+	}
+
+	return nil
+}
+
+// checkChannels verifies that the user holds the permission over the
+// channels the attributes of the definition are bound to.
+func (ts *twinservice) checkChannels(ctx context.Context, userID, domainID, permission string, def Definition) error {
+	checked := make(map[string]bool)
+	for _, attr := range def.Attributes {
+		if attr.Channel == "" || checked[attr.Channel] {
+			continue
+		}
+		checked[attr.Channel] = true
+		req := smqauthz.PolicyReq{
+			Domain:      domainID,
+			SubjectType: policies.UserType,
+			SubjectKind: policies.UsersKind,
+			Subject:     policies.EncodeDomainUserID(domainID, userID),
+			Permission:  permission,
+			ObjectType:  policies.ChannelType,
+			Object:      attr.Channel,
+		}
+		if err := ts.authz.Authorize(ctx, req); err != nil {
+			return errors.Wrap(svcerr.ErrAuthorization, err)
+		}
+	}
+
+	return nil
+}
+
+// checkRevision verifies that the twin has the expected revision.
+func checkRevision(tw Twin, revision int) error {
+	if revision != AnyRevision && tw.Revision != revision {
+		return errors.Wrap(svcerr.ErrConflict, ErrRevisionConflict)
+	}
+	return nil
+}
+
+func (ts *twinservice) AddTemplate(ctx context.Context, token, domainID string, tmpl Template) (Template, error) {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return Template{}, err
+	}
+
+	if err := validateTemplate(tmpl); err != nil {
+		return Template{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+
+	tmpl.ID, err = ts.idProvider.ID()
+	if err != nil {
+		return Template{}, err
+	}
+
+	tmpl.Owner = session.UserID
+	tmpl.Domain = domainID
+	tmpl.Revision = 0
+
+	t := time.Now()
+	tmpl.Created = t
+	tmpl.Updated = t
+
+	if tmpl.Definition.Attributes == nil {
+		tmpl.Definition.Attributes = []Attribute{}
+	}
+	if tmpl.Definition.Delta == 0 {
+		tmpl.Definition.Delta = millisec
+	}
+	tmpl.Definition.Created = t
+
+	if _, err := ts.templates.Save(ctx, tmpl); err != nil {
+		return Template{}, errors.Wrap(svcerr.ErrCreateEntity, err)
+	}
+
+	return tmpl, nil
+}
+
+func (ts *twinservice) UpdateTemplate(ctx context.Context, token, domainID string, tmpl Template) error {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return err
+	}
+
+	t, err := ts.authorizeTemplate(ctx, session, domainID, tmpl.ID, policies.EditPermission)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	if tmpl.Name != "" {
+		changed = true
+		t.Name = tmpl.Name
+	}
+
+	if len(tmpl.Metadata) > 0 {
+		changed = true
+		t.Metadata = tmpl.Metadata
+	}
+
+	if len(tmpl.Definition.Attributes) > 0 {
+		if err := validateTemplate(tmpl); err != nil {
+			return errors.Wrap(svcerr.ErrMalformedEntity, err)
+		}
+		changed = true
+		if tmpl.Definition.Delta == 0 {
+			tmpl.Definition.Delta = millisec
+		}
+		tmpl.Definition.Created = time.Now()
+		t.Definition = tmpl.Definition
+		t.Revision++
+	}
+
+	if !changed {
+		return errors.ErrMalformedEntity
+	}
+
+	t.Updated = time.Now()
+	if err := ts.templates.Update(ctx, t); err != nil {
+		return errors.Wrap(svcerr.ErrUpdateEntity, err)
+	}
+
+	return nil
+}
+
+func (ts *twinservice) ViewTemplate(ctx context.Context, token, domainID, templateID string) (Template, error) {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return Template{}, err
+	}
+
+	return ts.authorizeTemplate(ctx, session, domainID, templateID, policies.ViewPermission)
+}
+
+func (ts *twinservice) ListTemplates(ctx context.Context, token, domainID string, offset, limit uint64, name string) (TemplatesPage, error) {
+	if _, err := ts.identify(ctx, token, domainID); err != nil {
+		return TemplatesPage{}, err
+	}
+
+	page, err := ts.templates.RetrieveAll(ctx, domainID, offset, limit, name)
+	if err != nil {
+		return TemplatesPage{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	return page, nil
+}
+
+func (ts *twinservice) RemoveTemplate(ctx context.Context, token, domainID, templateID string) error {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := ts.authorizeTemplate(ctx, session, domainID, templateID, policies.DeletePermission); err != nil {
+		return err
+	}
+
+	if err := ts.templates.Remove(ctx, templateID); err != nil {
+		return errors.Wrap(svcerr.ErrRemoveEntity, err)
+	}
+
+	return nil
+}
+
+func (ts *twinservice) RolloutTemplate(ctx context.Context, token, domainID, templateID string, bindings map[string]string) (ro Rollout, err error) {
+	var b []byte
+	defer ts.publish(ctx, &templateID, &err, crudOp["rolloutSucc"], crudOp["rolloutFail"], &b)
+
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return Rollout{}, err
+	}
+
+	tmpl, err := ts.authorizeTemplate(ctx, session, domainID, templateID, policies.EditPermission)
+	if err != nil {
+		return Rollout{}, err
+	}
+
+	derived, err := ts.twins.RetrieveByTemplate(ctx, templateID)
+	if err != nil {
+		return Rollout{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	admin := ts.checkDomain(ctx, session.UserID, domainID, policies.AdminPermission) == nil
+	ro = Rollout{
+		Template: templateID,
+		Revision: tmpl.Revision,
+		Updated:  []string{},
+		Skipped:  []string{},
+	}
+	// Instantiate and validate all the definitions upfront so that the
+	// rollout is not interrupted by missing bindings or invalid definitions.
+	var pending []Twin
+	for _, tw := range derived {
+		if tw.Domain != domainID || tw.TemplateRevision >= tmpl.Revision {
+			continue
+		}
+		if !admin && ts.checkTwin(ctx, session.UserID, domainID, tw.ID, policies.EditPermission) != nil {
+			ro.Skipped = append(ro.Skipped, tw.ID)
+			continue
+		}
+
+		bound := map[string]string{}
+		for k, v := range bindings {
+			bound[k] = v
+		}
+		for k, v := range tw.Bindings {
+			bound[k] = v
+		}
+		def, err := instantiate(tmpl, bound)
+		if err == nil {
+			err = validateDefinition(def)
+		}
+		if err != nil {
+			return Rollout{}, errors.Wrap(svcerr.ErrMalformedEntity, errors.Wrap(err, fmt.Errorf("twin %s", tw.ID)))
+		}
+		if err := ts.checkChannels(ctx, session.UserID, domainID, subscribePermission, def); err != nil {
+			return Rollout{}, err
+		}
+
+		def.Created = time.Now()
+		def.ID = tw.Definitions[len(tw.Definitions)-1].ID + 1
+		tw.Definitions = append(tw.Definitions, def)
+		tw.Bindings = bound
+		tw.TemplateRevision = tmpl.Revision
+		tw.Updated = time.Now()
+		tw.Revision++
+		pending = append(pending, tw)
+	}
+
+	for _, tw := range pending {
+		if err := ts.twins.Update(ctx, tw); err != nil {
+			return ro, errors.Wrap(svcerr.ErrUpdateEntity, err)
+		}
+		ts.views.invalidate(tw.ID)
+		if err := ts.twinCache.Update(ctx, tw); err != nil {
+			return ro, err
+		}
+		def := tw.Definitions[len(tw.Definitions)-1]
+		ts.broadcast(StreamEvent{Type: DefinitionUpdated, TwinID: tw.ID, Definition: &def})
+		ro.Updated = append(ro.Updated, tw.ID)
+	}
+
+	b, err = json.Marshal(ro)
+
+	return ro, err
+}
+
+// authorizeTemplate retrieves the template and verifies that the user holds
+// the permission over it. Domain members can view all the domain templates,
+// while only the template owner and domain administrators can change them.
+func (ts *twinservice) authorizeTemplate(ctx context.Context, session smqauthn.Session, domainID, templateID, permission string) (Template, error) {
+	tmpl, err := ts.templates.RetrieveByID(ctx, templateID)
+	if err != nil {
+		return Template{}, errors.Wrap(svcerr.ErrNotFound, err)
+	}
+
+	if tmpl.Domain != domainID {
+		return Template{}, svcerr.ErrNotFound
+	}
+
+	if permission == policies.ViewPermission || tmpl.Owner == session.UserID {
+		return tmpl, nil
+	}
+
+	if err := ts.checkDomain(ctx, session.UserID, domainID, policies.AdminPermission); err != nil {
+		return Template{}, err
+	}
+
+	return tmpl, nil
+}
+
+func (ts *twinservice) StreamStates(ctx context.Context, token, domainID string, twinIDs, attributes []string) (<-chan StreamEvent, error) {
+	if len(twinIDs) == 0 {
+		return nil, svcerr.ErrMalformedEntity
+	}
+
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range twinIDs {
+		if _, err := ts.authorize(ctx, session, domainID, id, policies.ViewPermission); err != nil {
+			return nil, err
+		}
+	}
+
+	sub := ts.stream.subscribe(twinIDs, attributes)
+	go func() {
+		<-ctx.Done()
+		ts.stream.unsubscribe(sub)
+	}()
+
+	return sub.events, nil
+}
+
+// broadcast delivers the twin change to the stream subscribers.
+func (ts *twinservice) broadcast(ev StreamEvent) {
+	if dropped := ts.stream.broadcast(ev); dropped > 0 {
+		ts.logger.Warn(fmt.Sprintf("Dropped %s event of twin %s for %d slow stream subscribers", ev.Type, ev.TwinID, dropped))
+	}
+}
+
+func (ts *twinservice) SaveStates(ctx context.Context, msg *messaging.Message) error {
+	var ids []string
+
+	if msg.GetPublisher() == desiredPublisher {
+		return nil
+	}
+
+	channel, subtopic := msg.GetChannel(), msg.GetSubtopic()
+	ids, err := ts.twinCache.IDs(ctx, channel, subtopic)
+	if err != nil {
+		return err
+	}
+	if len(ids) < 1 {
+		ids, err = ts.twins.RetrieveByAttribute(ctx, channel, subtopic)
+		if err != nil {
+			return err
+		}
+		if len(ids) < 1 {
+			return nil
+		}
+		if err := ts.twinCache.SaveIDs(ctx, channel, subtopic, ids); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range ids {
+		j := job{ctx: ctx, msg: msg, twinID: id}
+		if ts.ingester != nil {
+			// The job outlives the reception of the message.
+			j.ctx = context.WithoutCancel(ctx)
+			if err := ts.ingester.enqueue(ctx, j); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := ts.saveStates(id, []job{j}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ts *twinservice) DrainStates(ctx context.Context) error {
+	if ts.ingester == nil {
+		return nil
+	}
+
+	return ts.ingester.drain(ctx)
+}
+
+// processBatch saves the states created from the batch of jobs, grouped by
+// the twin. Failures are only logged since there is no caller to return
+// them to.
+func (ts *twinservice) processBatch(batch []job) {
+	var order []string
+	jobs := make(map[string][]job)
+	for _, j := range batch {
+		if _, ok := jobs[j.twinID]; !ok {
+			order = append(order, j.twinID)
+		}
+		jobs[j.twinID] = append(jobs[j.twinID], j)
+	}
+
+	for _, id := range order {
+		if err := ts.saveStates(id, jobs[id]); err != nil {
+			ts.logger.Error(fmt.Sprintf("State save failed: %s", err))
+		}
+	}
+}
+
+// saveStates creates the states of the twin from the messages of the jobs,
+// in order, and persists them at once. Messages which cannot be decoded are
+// skipped and the first such error is returned.
+func (ts *twinservice) saveStates(twinID string, jobs []job) error {
+	ctx := jobs[0].ctx
+	defer ts.locks.lock(twinID)()
+
+	view, epoch, err := ts.view(ctx, twinID)
+	if err != nil {
+		ts.notifyStates(ctx, jobs, err)
+		return err
+	}
+	def := view.twin.Definitions[len(view.twin.Definitions)-1]
+
+	var (
+		st      = copyState(view.last)
+		as      = view.alarms.copy()
+		alarms  = clearStaleAlarms(as, def, time.Now())
+		updated *State
+		created []State
+		events  []StreamEvent
+		done    []job
+		failed  error
+	)
+	for _, j := range jobs {
+		// Twins only consume the messages of their own domain.
+		if j.msg.GetDomain() != view.twin.Domain {
+			continue
+		}
+		brs, err := decodeRecords(boundAttributes(def, j.msg), j.msg)
+		if err != nil {
+			err = fmt.Errorf("unmarshal payload for %s failed: %s", j.msg.GetPublisher(), err)
+			ts.notifyStates(ctx, []job{j}, err)
+			if failed == nil {
+				failed = err
+			}
+			continue
+		}
+
+		for _, br := range brs {
+			if !ts.validRecord(ctx, view.twin, br.attr, br.rec) {
+				continue
+			}
+			action := ts.prepareState(&st, &view.twin, view.computed, br.attr, br.rec, time.Now())
+			switch action {
+			case update, refreshed:
+				s := copyState(st)
+				// The state created by the preceding records is updated
+				// before it is saved.
+				if n := len(created); n > 0 {
+					created[n-1] = s
+				} else {
+					updated = &s
+				}
+				events = append(events, StreamEvent{Type: StateUpdated, TwinID: twinID, State: &s})
+			case save:
+				s := copyState(st)
+				created = append(created, s)
+				events = append(events, StreamEvent{Type: StateCreated, TwinID: twinID, State: &s})
+			}
+			// Alarms are evaluated on every received value, including the
+			// ones the persistence policy drops.
+			at := recordTime(br.rec)
+			if at.IsZero() {
+				at = time.Now()
+			}
+			alarms = append(alarms, ts.evaluateAlarms(as, twinID, def, st, br.attr.Name, findValue(br.rec), at)...)
+		}
+		done = append(done, j)
+	}
+
+	if updated != nil {
+		if err := ts.states.Update(ctx, *updated); err != nil {
+			ts.views.invalidate(twinID)
+			err = fmt.Errorf("update state of twin %s failed: %s", twinID, err)
+			ts.notifyStates(ctx, done, err)
+			return err
+		}
+	}
+	if len(created) > 0 {
+		if err := ts.states.Save(ctx, created...); err != nil {
+			ts.views.invalidate(twinID)
+			err = fmt.Errorf("save states of twin %s failed: %s", twinID, err)
+			ts.notifyStates(ctx, done, err)
+			return err
+		}
+	}
+
+	for _, ev := range events {
+		ts.broadcast(ev)
+	}
+	ts.notifyStates(ctx, done, nil)
+	ts.publishLiveness(ctx, transitions(view.last, st))
+
+	view.last = st
+	view.alarms = as
+	if err := ts.saveAlarms(ctx, twinID, alarms); err != nil {
+		// Active alarms are reloaded from the repository.
+		ts.views.invalidate(twinID)
+		return err
+	}
+	ts.views.set(epoch, view)
+
+	return failed
+}
+
+// view returns the twin together with its last state, either cached or
+// retrieved from the repositories.
+func (ts *twinservice) view(ctx context.Context, twinID string) (twinView, uint64, error) {
+	view, epoch, ok := ts.views.get(twinID)
+	if ok {
+		return view, epoch, nil
+	}
+
+	tw, err := ts.twins.RetrieveByID(ctx, twinID)
+	if err != nil {
+		return twinView{}, 0, fmt.Errorf("retrieving twin %s failed: %s", twinID, err)
+	}
+	st, err := ts.states.RetrieveLast(ctx, twinID)
+	if err != nil {
+		return twinView{}, 0, fmt.Errorf("retrieve last state of twin %s failed: %s", twinID, err)
+	}
+	// The first state takes over the stale attributes of the twin, so that
+	// they are marked fresh once received.
+	if st.Payload == nil && tw.Liveness != nil {
+		st.Stale = tw.Liveness.Stale
+	}
+	as, err := ts.loadAlarms(ctx, tw, st)
+	if err != nil {
+		return twinView{}, 0, fmt.Errorf("retrieve alarms of twin %s failed: %s", twinID, err)
+	}
+
+	return twinView{twin: tw, last: st, alarms: as, computed: ts.computedAttributes(tw)}, epoch, nil
+}
+
+// notifyStates publishes the outcome of saving the states created from the
+// messages of the jobs to the notification channel.
+func (ts *twinservice) notifyStates(ctx context.Context, jobs []job, err error) {
+	for _, j := range jobs {
+		if err != nil {
+			if ts.failed != nil {
+				ts.failed.Add(1)
+			}
+			ts.notify(ctx, crudOp["stateFail"], []byte(err.Error()))
+			continue
+		}
+		ts.notify(ctx, crudOp["stateSucc"], j.msg.GetPayload())
+	}
+}
+
+// prepareState merges the value of the attribute record received at the
+// given time into the state, and returns the action decided by the
+// persistence policy of the attribute.
+func (ts *twinservice) prepareState(st *State, tw *Twin, computed []computedAttribute, attr Attribute, rec senml.Record, received time.Time) int {
+	def := tw.Definitions[len(tw.Definitions)-1]
+	st.TwinID = tw.ID
+	st.Definition = def.ID
+
+	if st.Payload == nil {
+		st.Payload = make(map[string]interface{})
+		st.ID = -1 // state is incremented on save -> zero-based index
+	} else {
+		for k := range st.Payload {
+			idx := findAttribute(k, def.Attributes)
+			if idx < 0 || !def.Attributes[idx].PersistState {
+				delete(st.Payload, k)
+			}
+		}
+	}
+
+	val := findValue(rec)
+	action := persistAction(def, attr, *st, rec, val)
+	switch action {
+	case noop:
+		return noop
+	case refreshed:
+		refresh(st, attr, received)
+		return refreshed
+	case save:
+		st.ID++
+		st.Created = received
+		if at := recordTime(rec); !at.IsZero() {
+			st.Created = at
+		}
+	}
+	st.Payload[attr.Name] = val
+	refresh(st, attr, received)
+	ts.computeAttributes(st, tw.ID, computed, attr.Name)
+
+	return action
+}
+
+// recordTime returns the time of the record, or zero time if the record
+// has no time.
+func recordTime(rec senml.Record) time.Time {
+	recSec := rec.BaseTime + rec.Time
+	if recSec == 0 {
+		return time.Time{}
+	}
+	sec, dec := math.Modf(recSec)
+	return time.Unix(int64(sec), int64(dec*nanosec))
+}
+
+// boundAttributes returns the persisted attributes of the definition which
+// are bound to the channel and subtopic of the message.
+func boundAttributes(def Definition, msg *messaging.Message) []Attribute {
+	var attrs []Attribute
+	for _, attr := range def.Attributes {
+		if !attr.PersistState {
+			continue
+		}
+		if attr.Channel == msg.GetChannel() && MatchSubtopic(attr.Subtopic, msg.GetSubtopic()) {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
+
+// validRecord reports whether the record conforms to the schema of the
+// attribute it is bound to. Records violating the schema are reported on
+// the notification channel.
+func (ts *twinservice) validRecord(ctx context.Context, tw Twin, attr Attribute, rec senml.Record) bool {
+	err := validateRecord(attr, rec)
+	if err == nil {
+		return true
+	}
+
+	ts.logger.Warn(fmt.Sprintf("Discarded value of attribute %s of twin %s: %s", attr.Name, tw.ID, err))
+	b, merr := json.Marshal(map[string]interface{}{
+		"twin_id":   tw.ID,
+		"attribute": attr.Name,
+		"record":    rec,
+		"error":     err.Error(),
+	})
+	if merr != nil {
+		ts.logger.Warn(fmt.Sprintf("Failed to encode validation failure: %s", merr))
+		return false
+	}
+	ts.notify(ctx, crudOp["invalidFail"], b)
+
+	return false
+}
+
+// computedAttributes parses the computed attributes of the latest definition
+// of the twin, so that their expressions are not parsed for every message.
+func (ts *twinservice) computedAttributes(tw Twin) []computedAttribute {
+	computed, err := computedAttributes(tw.Definitions[len(tw.Definitions)-1])
+	if err != nil {
+		ts.logger.Warn(fmt.Sprintf("Failed to parse computed attributes of twin %s: %s", tw.ID, err))
+		return nil
+	}
+
+	return computed
+}
+
+// computeAttributes evaluates the computed attributes which depend, directly
+// or through other computed attributes, on the changed attribute. Computed
+// attributes whose inputs are missing or not numeric are left unchanged.
+func (ts *twinservice) computeAttributes(st *State, twinID string, computed []computedAttribute, changed string) {
+	if len(computed) == 0 {
+		return
+	}
+
+	values := make(map[string]float64)
+	for name, val := range st.Payload {
+		if f, ok := toFloat(val); ok {
+			values[name] = f
+		}
+	}
+
+	dirty := map[string]bool{changed: true}
+	for _, ca := range computed {
+		affected := false
+		for _, in := range ca.expr.inputs {
+			affected = affected || dirty[in]
+		}
+		if !affected {
+			continue
+		}
+
+		var prev *float64
+		if p, ok := values[ca.name]; ok {
+			prev = &p
+		}
+		val, err := ca.expr.eval(values, prev)
+		if err != nil {
+			ts.logger.Warn(fmt.Sprintf("Failed to compute attribute %s of twin %s: %s", ca.name, twinID, err))
+			continue
+		}
+		st.Payload[ca.name] = val
+		values[ca.name] = val
+		dirty[ca.name] = true
+	}
+}
+
+func findValue(rec senml.Record) interface{} {
+	if rec.Value != nil {
+		return rec.Value
+	}
+	if rec.StringValue != nil {
+		return rec.StringValue
+	}
+	if rec.DataValue != nil {
+		return rec.DataValue
+	}
+	if rec.BoolValue != nil {
+		return rec.BoolValue
+	}
+	if rec.Sum != nil {
+		return rec.Sum
+	}
+	return nil
+}
+
+// computeDelta returns desired values which are missing from or differ from
+// the payload of the reported state.
+func computeDelta(ds DesiredState, st State) Delta {
+	delta := Delta{}
+	for name, val := range ds.Payload {
+		reported, ok := st.Payload[name]
+		if !ok || !reflect.DeepEqual(deref(reported), val) {
+			delta[name] = val
+		}
+	}
+	return delta
+}
+
+// deref unwraps SenML value pointers stored by prepareState so they can be
+// compared to plain JSON decoded values.
+func deref(val interface{}) interface{} {
+	switch v := val.(type) {
+	case *float64:
+		return *v
+	case *string:
+		return *v
+	case *bool:
+		return *v
+	}
+	return val
+}
+
+type topic struct {
+	channel  string
+	subtopic string
+}
+
+// publishDelta publishes desired values as SenML records on the channel and
+// subtopic of each attribute. Attributes subscribed to the subtopic patterns
+// are skipped since there is no concrete subtopic to publish to.
+func (ts *twinservice) publishDelta(ctx context.Context, domainID string, def Definition, delta Delta) {
+	recs := make(map[topic][]senml.Record)
+	for _, attr := range def.Attributes {
+		val, ok := delta[attr.Name]
+		if !ok || IsSubtopicPattern(attr.Subtopic) {
+			continue
+		}
+		t := topic{channel: attr.Channel, subtopic: attr.Subtopic}
+		recs[t] = append(recs[t], createRecord(attr.Name, val))
+	}
+
+	for t, rs := range recs {
+		payload, err := json.Marshal(rs)
+		if err != nil {
+			ts.logger.Warn(fmt.Sprintf("Failed to encode desired state: %s", err))
+			continue
+		}
+		msg := messaging.Message{
+			Domain:    domainID,
+			Channel:   t.channel,
+			Subtopic:  t.subtopic,
+			Payload:   payload,
+			Publisher: desiredPublisher,
+			Created:   time.Now().UnixNano(),
+		}
+		if err := ts.publisher.Publish(ctx, msg.GetChannel(), &msg); err != nil {
+			ts.logger.Warn(fmt.Sprintf("Failed to publish desired state on Message Broker: %s", err))
+		}
+	}
+}
+
+func createRecord(name string, val interface{}) senml.Record {
+	rec := senml.Record{Name: name}
+	switch v := val.(type) {
+	case float64:
+		rec.Value = &v
+	case string:
+		rec.StringValue = &v
+	case bool:
+		rec.BoolValue = &v
+	default:
+		data, _ := json.Marshal(v)
+		s := string(data)
+		rec.DataValue = &s
+	}
+	return rec
+}
+
+func findAttribute(name string, attrs []Attribute) (idx int) {
+	for idx, attr := range attrs {
+		if attr.Name == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (ts *twinservice) publish(ctx context.Context, twinID *string, err *error, succOp, failOp string, payload *[]byte) {
+	if ts.channelID == "" {
+		return
+	}
+
+	op := succOp
+	if *err != nil {
+		op = failOp
+		esb := []byte((*err).Error())
+		payload = &esb
+	}
+
+	pl := *payload
+	if pl == nil {
+		pl = []byte(fmt.Sprintf("{\"deleted\":\"%s\"}", *twinID))
+	}
+
+	ts.notify(ctx, op, pl)
+}
+
+// notify publishes the payload of the operation to the notification channel.
+func (ts *twinservice) notify(ctx context.Context, op string, payload []byte) {
+	if ts.channelID == "" {
+		return
 	}
 
 	msg := messaging.Message{
 		Channel:   ts.channelID,
 		Subtopic:  op,
-		Payload:   pl,
+		Payload:   payload,
 		Publisher: publisher,
 		Created:   time.Now().UnixNano(),
 	}
@@ -6,7 +6,11 @@ package twins_test
 import (
 	"context"
 	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/absmach/senml"
 	"github.com/absmach/supermq-contrib/pkg/testsutil"
@@ -15,9 +19,19 @@ import (
 	smqlog "github.com/absmach/supermq/logger"
 	smqauthn "github.com/absmach/supermq/pkg/authn"
 	authnmocks "github.com/absmach/supermq/pkg/authn/mocks"
+	smqauthz "github.com/absmach/supermq/pkg/authz"
+	authzmocks "github.com/absmach/supermq/pkg/authz/mocks"
 	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
 	svcerr "github.com/absmach/supermq/pkg/errors/service"
+	"github.com/absmach/supermq/pkg/messaging"
+	"github.com/absmach/supermq/pkg/policies"
+	policymocks "github.com/absmach/supermq/pkg/policies/mocks"
+	smqsenml "github.com/absmach/supermq/pkg/transformers/senml"
 	"github.com/absmach/supermq/pkg/uuid"
+	"github.com/absmach/supermq/readers"
+	readersmocks "github.com/absmach/supermq/readers/mocks"
+	"github.com/go-kit/kit/metrics/generic"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -31,6 +45,7 @@ const (
 	numRecs      = 100
 	retained     = "saved"
 	validID      = "123e4567-e89b-12d3-a456-426614174000"
+	domainID     = "b6a8a5fd-9ab7-41f2-8e5f-b2f2aa6cabe6"
 )
 
 var (
@@ -38,30 +53,116 @@ var (
 	channels  = []string{"01ec3c3e-0e66-4e69-9751-a0545b44e08f", "48061e4f-7c23-4f5c-9012-0f9b7cd9d18d", "5b2180e4-e96b-4469-9dc1-b6745078d0b6"}
 )
 
-func NewService() (twins.Service, *authnmocks.Authentication, *mocks.TwinRepository, *mocks.TwinCache, *mocks.StateRepository) {
+func NewService() (twins.Service, *authnmocks.Authentication, *authzmocks.Authorization, *policymocks.Service, *mocks.TwinRepository, *mocks.TwinCache, *mocks.StateRepository, *mocks.TemplateRepository, *mocks.RelationRepository) {
 	auth := new(authnmocks.Authentication)
+	authz := new(authzmocks.Authorization)
+	policySvc := new(policymocks.Service)
 	twinsRepo := new(mocks.TwinRepository)
 	twinCache := new(mocks.TwinCache)
 	statesRepo := new(mocks.StateRepository)
+	templatesRepo := new(mocks.TemplateRepository)
+	relationsRepo := new(mocks.RelationRepository)
 	idProvider := uuid.NewMock()
 	subs := map[string]string{"chanID": "chanID"}
 	broker := mocks.NewBroker(subs)
 
-	return twins.New(broker, auth, twinsRepo, twinCache, statesRepo, idProvider, "chanID", smqlog.NewMock()), auth, twinsRepo, twinCache, statesRepo
+	return twins.New(broker, auth, authz, policySvc, twinsRepo, twinCache, statesRepo, templatesRepo, relationsRepo, nil, idProvider, "chanID", twins.IngestConfig{}, smqlog.NewMock()), auth, authz, policySvc, twinsRepo, twinCache, statesRepo, templatesRepo, relationsRepo
+}
+
+// authorizeCall mocks the domain membership and domain administrator checks.
+// Only the domain administrators hold the permissions over the twins.
+func authorizeCall(authz *authzmocks.Authorization, memberErr, adminErr error) *mock.Call {
+	return accessCall(authz, memberErr, adminErr, nil)
+}
+
+// accessCall mocks the domain membership and domain administrator checks,
+// and the twin permission checks. The domain administrators hold all the
+// permissions over the twins, while the other users hold the permissions of
+// the roles they were granted, keyed by the twin ID.
+func accessCall(authz *authzmocks.Authorization, memberErr, adminErr error, roles map[string]string) *mock.Call {
+	return channelAccessCall(authz, nil, memberErr, adminErr, roles)
+}
+
+// channelAccessCall mocks the access checks like accessCall, and the
+// channel permission checks failing with the channel error.
+func channelAccessCall(authz *authzmocks.Authorization, channelErr, memberErr, adminErr error, roles map[string]string) *mock.Call {
+	return authz.On("Authorize", mock.Anything, mock.Anything).Return(func(_ context.Context, pr smqauthz.PolicyReq) error {
+		switch {
+		case pr.ObjectType == policies.ChannelType:
+			return channelErr
+		case pr.ObjectType == twins.TwinType:
+			if roleAllows(roles[pr.Object], pr.Permission) {
+				return nil
+			}
+			return adminErr
+		case pr.Permission == policies.AdminPermission:
+			return adminErr
+		default:
+			return memberErr
+		}
+	})
+}
+
+// ownerRoles grants the test user the administrator relation over the twins
+// they own, as the service does when the twins are created.
+func ownerRoles(tws ...twins.Twin) map[string]string {
+	roles := make(map[string]string)
+	for _, tw := range tws {
+		if tw.Owner == validID {
+			roles[tw.ID] = policies.AdministratorRelation
+		}
+	}
+	return roles
+}
+
+func roleAllows(role, permission string) bool {
+	switch role {
+	case policies.AdministratorRelation:
+		return true
+	case twins.EditorRole:
+		return permission == policies.ViewPermission || permission == policies.EditPermission
+	case twins.ViewerRole:
+		return permission == policies.ViewPermission
+	default:
+		return false
+	}
+}
+
+// childrenCall mocks retrieval of twin children from the tree of twins keyed
+// by the parent ID.
+func childrenCall(twinRepo *mocks.TwinRepository, tree map[string][]twins.Twin) *mock.Call {
+	return twinRepo.On("RetrieveChildren", context.Background(), mock.Anything).Return(func(_ context.Context, parentIDs ...string) ([]twins.Twin, error) {
+		var children []twins.Twin
+		for _, id := range parentIDs {
+			children = append(children, tree[id]...)
+		}
+		return children, nil
+	})
 }
 
 func TestAddTwin(t *testing.T) {
-	svc, auth, twinRepo, twinCache, _ := NewService()
+	svc, auth, authz, policySvc, twinRepo, twinCache, _, _, _ := NewService()
 	twin := twins.Twin{}
-	def := twins.Definition{}
+	minVal, maxVal := 0.0, 100.0
+	computed := func(attrs ...twins.Attribute) twins.Definition {
+		raw := []twins.Attribute{
+			{Name: "voltage", Channel: channels[0], Subtopic: subtopics[0], PersistState: true},
+			{Name: "current", Channel: channels[0], Subtopic: subtopics[1], PersistState: true},
+		}
+		return twins.Definition{Attributes: append(raw, attrs...)}
+	}
 
 	cases := []struct {
 		desc        string
 		twin        twins.Twin
+		def         twins.Definition
 		token       string
 		err         error
 		saveErr     error
+		policyErr   error
 		identifyErr error
+		authzErr    error
+		channelErr  error
 		userID      string
 	}{
 		{
@@ -81,41 +182,361 @@ func TestAddTwin(t *testing.T) {
 			saveErr:     svcerr.ErrCreateEntity,
 			identifyErr: svcerr.ErrAuthentication,
 		},
+		{
+			desc:      "add twin with failed policy addition",
+			twin:      twin,
+			token:     token,
+			err:       svcerr.ErrAddPolicies,
+			policyErr: svcerr.ErrAuthorization,
+			userID:    validID,
+		},
+		{
+			desc:     "add twin without domain membership",
+			twin:     twin,
+			token:    token,
+			err:      svcerr.ErrAuthorization,
+			authzErr: svcerr.ErrAuthorization,
+			userID:   validID,
+		},
+		{
+			desc:       "add twin bound to channel without subscribe permission",
+			twin:       twin,
+			def:        computed(),
+			token:      token,
+			err:        svcerr.ErrAuthorization,
+			channelErr: svcerr.ErrAuthorization,
+			userID:     validID,
+		},
+		{
+			desc:   "add twin with computed attribute",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "power", Expression: "voltage * current", PersistState: true}),
+			token:  token,
+			err:    nil,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with invalid computed attribute expression",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "power", Expression: "voltage ** current", PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with computed attribute using unsupported function",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "power", Expression: "exp(voltage)", PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with computed attribute referring to unknown attribute",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "power", Expression: "voltage * resistance", PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with computed attribute bound to channel",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "power", Channel: channels[1], Expression: "voltage * current", PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with attribute schema",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "mode", Channel: channels[1], Type: twins.StringType, Enum: []interface{}{"eco", "comfort"}}),
+			token:  token,
+			err:    nil,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with unknown attribute type",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "mode", Channel: channels[1], Type: "integer"}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with bounds of string attribute",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "mode", Channel: channels[1], Type: twins.StringType, Max: &maxVal}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with minimum greater than maximum",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "level", Channel: channels[1], Type: twins.NumberType, Min: &maxVal, Max: &minVal}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with enum value of wrong type",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "mode", Channel: channels[1], Type: twins.StringType, Enum: []interface{}{"eco", 1.0}}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with payload path",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "level", Channel: channels[1], Path: "/data/level", TimePath: "/ts"}),
+			token:  token,
+			err:    nil,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with invalid payload path",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "level", Channel: channels[1], Path: "data.level"}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with time path without payload path",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "level", Channel: channels[1], TimePath: "/ts"}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with subtopic wildcards",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "temperature", Channel: channels[1], Subtopic: "sensors.*.temp.>", PersistState: true}),
+			token:  token,
+			err:    nil,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with multi-level wildcard before the last subtopic level",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "temperature", Channel: channels[1], Subtopic: "sensors.#.temp", PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with wildcard within subtopic level",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "temperature", Channel: channels[1], Subtopic: "sensors.room*.temp", PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with state retention",
+			twin:   twin,
+			def:    twins.Definition{Retention: &twins.Retention{MaxAge: int64(24 * time.Hour), CompactAge: int64(time.Hour), Bucket: twins.HourBucket}},
+			token:  token,
+			err:    nil,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with unknown state compaction bucket",
+			twin:   twin,
+			def:    twins.Definition{Retention: &twins.Retention{CompactAge: int64(time.Hour), Bucket: "week"}},
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with state compaction age exceeding maximum age",
+			twin:   twin,
+			def:    twins.Definition{Retention: &twins.Retention{MaxAge: int64(time.Hour), CompactAge: int64(24 * time.Hour), Bucket: twins.DayBucket}},
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc: "add twin with attribute alarms",
+			twin: twin,
+			def: computed(twins.Attribute{Name: "power", Expression: "voltage * current", Alarms: []twins.AlarmRule{
+				{Name: "overload", Condition: twins.GreaterCondition, Severity: twins.CriticalSeverity, Threshold: 2000, Hysteresis: 100},
+				{Name: "out_of_range", Condition: twins.RangeCondition, Severity: twins.WarningSeverity, Min: &minVal, Max: &maxVal, Hysteresis: 5},
+			}, PersistState: true}),
+			token:  token,
+			err:    nil,
+			userID: validID,
+		},
+		{
+			desc: "add twin with alarm of unknown condition",
+			twin: twin,
+			def: computed(twins.Attribute{Name: "power", Expression: "voltage * current", Alarms: []twins.AlarmRule{
+				{Name: "overload", Condition: "eq", Severity: twins.CriticalSeverity},
+			}, PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc: "add twin with alarms of duplicate names",
+			twin: twin,
+			def: computed(twins.Attribute{Name: "power", Expression: "voltage * current", Alarms: []twins.AlarmRule{
+				{Name: "overload", Condition: twins.GreaterCondition, Severity: twins.CriticalSeverity, Threshold: 2000},
+				{Name: "overload", Condition: twins.GreaterCondition, Severity: twins.WarningSeverity, Threshold: 1500},
+			}, PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc: "add twin with stuck alarm without duration",
+			twin: twin,
+			def: computed(twins.Attribute{Name: "power", Expression: "voltage * current", Alarms: []twins.AlarmRule{
+				{Name: "frozen", Condition: twins.StuckCondition, Severity: twins.InfoSeverity},
+			}, PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc: "add twin with alarm range narrower than hysteresis",
+			twin: twin,
+			def: computed(twins.Attribute{Name: "power", Expression: "voltage * current", Alarms: []twins.AlarmRule{
+				{Name: "out_of_range", Condition: twins.RangeCondition, Severity: twins.WarningSeverity, Min: &minVal, Max: &maxVal, Hysteresis: 60},
+			}, PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc: "add twin with alarms on string attribute",
+			twin: twin,
+			def: twins.Definition{Attributes: []twins.Attribute{{Name: "mode", Channel: channels[0], Subtopic: subtopics[0], Type: twins.StringType, Alarms: []twins.AlarmRule{
+				{Name: "overload", Condition: twins.GreaterCondition, Severity: twins.CriticalSeverity, Threshold: 2000},
+			}, PersistState: true}}},
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with attribute persistence policy",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "level", Channel: channels[1], Type: twins.NumberType, Persistence: &twins.Persistence{Policy: twins.DeadbandPolicy, Deadband: 5, Percent: true}, PersistState: true}),
+			token:  token,
+			err:    nil,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with unknown attribute persistence policy",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "level", Channel: channels[1], Persistence: &twins.Persistence{Policy: "sometimes"}, PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with deadband persistence policy without deadband",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "level", Channel: channels[1], Persistence: &twins.Persistence{Policy: twins.DeadbandPolicy}, PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with deadband persistence policy of string attribute",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "mode", Channel: channels[1], Type: twins.StringType, Persistence: &twins.Persistence{Policy: twins.DeadbandPolicy, Deadband: 1}, PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with deadband of change persistence policy",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "level", Channel: channels[1], Persistence: &twins.Persistence{Policy: twins.ChangePolicy, Deadband: 1}, PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "add twin with persistence policy of computed attribute",
+			twin:   twin,
+			def:    computed(twins.Attribute{Name: "power", Expression: "voltage * current", Persistence: &twins.Persistence{Policy: twins.AlwaysPolicy}, PersistState: true}),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc: "add twin with cyclic computed attributes",
+			twin: twin,
+			def: computed(
+				twins.Attribute{Name: "a", Expression: "b + voltage", PersistState: true},
+				twins.Attribute{Name: "b", Expression: "a * 2", PersistState: true},
+			),
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
 	}
 
 	for _, tc := range cases {
 		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := channelAccessCall(authz, tc.channelErr, tc.authzErr, nil, nil)
 		repoCall := twinRepo.On("Save", context.Background(), mock.Anything).Return(retained, tc.saveErr)
-		cacheCall := twinCache.On("Save", context.Background(), mock.Anything).Return(tc.err)
-		_, err := svc.AddTwin(context.Background(), tc.token, tc.twin, def)
+		repoCall1 := twinRepo.On("Remove", context.Background(), mock.Anything).Return(nil)
+		policyCall := policySvc.On("AddPolicies", context.Background(), mock.Anything).Return(tc.policyErr)
+		cacheCall := twinCache.On("Save", context.Background(), mock.Anything).Return(nil)
+		tw, err := svc.AddTwin(context.Background(), tc.token, domainID, tc.twin, tc.def)
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err == nil {
+			assert.Equal(t, domainID, tw.Domain, fmt.Sprintf("%s: expected domain %s got %s\n", tc.desc, domainID, tw.Domain))
+			assert.Equal(t, tc.userID, tw.Owner, fmt.Sprintf("%s: expected owner %s got %s\n", tc.desc, tc.userID, tw.Owner))
+		}
 		authCall.Unset()
+		authzCall.Unset()
 		repoCall.Unset()
+		repoCall1.Unset()
+		policyCall.Unset()
 		cacheCall.Unset()
 	}
 }
 
 func TestUpdateTwin(t *testing.T) {
-	svc, auth, twinRepo, twinCache, _ := NewService()
+	svc, auth, authz, _, twinRepo, twinCache, _, _, _ := NewService()
 
 	other := twins.Twin{}
-	def := twins.Definition{}
+	def := twins.Definition{Attributes: []twins.Attribute{{Name: "temperature", Channel: channels[0], Subtopic: subtopics[0], PersistState: true}}}
 	twin := twins.Twin{
-		Owner: email,
-		ID:    testsutil.GenerateUUID(t),
-		Name:  twinName,
+		Owner:  email,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+		Name:   twinName,
 	}
+	foreign := twin
+	foreign.Domain = testsutil.GenerateUUID(t)
+	revised := twin
+	revised.Revision = 3
+	defined := twin
+	defined.Definitions = []twins.Definition{{ID: 0}}
 
 	other.ID = wrongID
 
 	cases := []struct {
 		desc        string
 		twin        twins.Twin
+		def         twins.Definition
+		revision    int
 		token       string
 		err         error
 		retrieveErr error
 		updateErr   error
 		identifyErr error
+		adminErr    error
+		channelErr  error
+		role        string
 		userID      string
 	}{
 		{
@@ -145,16 +566,99 @@ func TestUpdateTwin(t *testing.T) {
 			identifyErr: nil,
 			userID:      validID,
 		},
+		{
+			desc:   "update twin from another domain",
+			twin:   foreign,
+			token:  token,
+			err:    svcerr.ErrNotFound,
+			userID: validID,
+		},
+		{
+			desc:     "update twin shared with user as editor",
+			twin:     twin,
+			token:    token,
+			err:      nil,
+			adminErr: svcerr.ErrAuthorization,
+			role:     twins.EditorRole,
+			userID:   validID,
+		},
+		{
+			desc:     "update twin shared with user as viewer",
+			twin:     twin,
+			token:    token,
+			err:      svcerr.ErrAuthorization,
+			adminErr: svcerr.ErrAuthorization,
+			role:     twins.ViewerRole,
+			userID:   validID,
+		},
+		{
+			desc:   "update twin definition",
+			twin:   defined,
+			def:    def,
+			token:  token,
+			err:    nil,
+			userID: validID,
+		},
+		{
+			desc:       "update twin definition bound to channel without subscribe permission",
+			twin:       defined,
+			def:        def,
+			token:      token,
+			err:        svcerr.ErrAuthorization,
+			channelErr: svcerr.ErrAuthorization,
+			userID:     validID,
+		},
+		{
+			desc:     "update twin with matching revision",
+			twin:     revised,
+			revision: revised.Revision,
+			token:    token,
+			err:      nil,
+			userID:   validID,
+		},
+		{
+			desc:     "update twin with any revision",
+			twin:     revised,
+			revision: twins.AnyRevision,
+			token:    token,
+			err:      nil,
+			userID:   validID,
+		},
+		{
+			desc:     "update twin with stale revision",
+			twin:     revised,
+			revision: revised.Revision - 1,
+			token:    token,
+			err:      svcerr.ErrConflict,
+			userID:   validID,
+		},
+		{
+			desc:      "update twin modified concurrently",
+			twin:      revised,
+			revision:  revised.Revision,
+			token:     token,
+			err:       svcerr.ErrConflict,
+			updateErr: repoerr.ErrConflict,
+			userID:    validID,
+		},
 	}
 
 	for _, tc := range cases {
 		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := channelAccessCall(authz, tc.channelErr, nil, tc.adminErr, map[string]string{tc.twin.ID: tc.role})
 		repoCall := twinRepo.On("RetrieveByID", context.Background(), tc.twin.ID).Return(tc.twin, tc.retrieveErr)
-		repoCall1 := twinRepo.On("Update", context.Background(), mock.Anything).Return(tc.updateErr)
+		var updated twins.Twin
+		repoCall1 := twinRepo.On("Update", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			updated = args.Get(1).(twins.Twin)
+		}).Return(tc.updateErr)
 		cacheCall := twinCache.On("Update", context.Background(), mock.Anything).Return(tc.err)
-		err := svc.UpdateTwin(context.Background(), tc.token, tc.twin, def)
+		err := svc.UpdateTwin(context.Background(), tc.token, domainID, tc.twin, tc.def, tc.revision)
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err == nil {
+			assert.Equal(t, tc.twin.Revision+1, updated.Revision, fmt.Sprintf("%s: expected revision %d got %d\n", tc.desc, tc.twin.Revision+1, updated.Revision))
+		}
 		authCall.Unset()
+		authzCall.Unset()
 		repoCall.Unset()
 		repoCall1.Unset()
 		cacheCall.Unset()
@@ -162,25 +666,48 @@ func TestUpdateTwin(t *testing.T) {
 }
 
 func TestViewTwin(t *testing.T) {
-	svc, auth, twinRepo, _, _ := NewService()
+	svc, auth, authz, _, twinRepo, _, stateRepo, _, _ := NewService()
 
 	twin := twins.Twin{
-		Owner: email,
-		ID:    testsutil.GenerateUUID(t),
-		Name:  twinName,
+		Owner:  email,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+		Name:   twinName,
 	}
+	owned := twin
+	owned.ID = testsutil.GenerateUUID(t)
+	owned.Owner = validID
+	shared := twin
+	shared.ID = testsutil.GenerateUUID(t)
+	roles := map[string]string{owned.ID: policies.AdministratorRelation, shared.ID: twins.ViewerRole}
+	foreign := twin
+	foreign.ID = testsutil.GenerateUUID(t)
+	foreign.Domain = testsutil.GenerateUUID(t)
+	monitored := twin
+	monitored.ID = testsutil.GenerateUUID(t)
+	monitored.Definitions = []twins.Definition{{Attributes: []twins.Attribute{{Name: "temperature", UpdateInterval: int64(time.Minute)}}}}
+	stateless := monitored
+	stateless.ID = testsutil.GenerateUUID(t)
+	stateless.Liveness = &twins.Liveness{Stale: []string{"temperature"}, Checked: time.Now()}
 
 	cases := []struct {
 		desc        string
 		id          string
+		twin        twins.Twin
 		token       string
 		err         error
+		retrieveErr error
 		identifyErr error
+		authzErr    error
+		adminErr    error
 		userID      string
+		last        twins.State
+		stale       []string
 	}{
 		{
 			desc:        "view existing twin",
 			id:          twin.ID,
+			twin:        twin,
 			token:       token,
 			err:         nil,
 			identifyErr: nil,
@@ -189,6 +716,7 @@ func TestViewTwin(t *testing.T) {
 		{
 			desc:        "view twin with wrong credentials",
 			id:          twin.ID,
+			twin:        twin,
 			token:       invalidToken,
 			err:         svcerr.ErrAuthentication,
 			identifyErr: svcerr.ErrAuthentication,
@@ -198,29 +726,99 @@ func TestViewTwin(t *testing.T) {
 			id:          wrongID,
 			token:       token,
 			err:         svcerr.ErrNotFound,
+			retrieveErr: repoerr.ErrNotFound,
 			identifyErr: nil,
 			userID:      validID,
 		},
+		{
+			desc:     "view twin without domain membership",
+			id:       twin.ID,
+			twin:     twin,
+			token:    token,
+			err:      svcerr.ErrAuthorization,
+			authzErr: svcerr.ErrAuthorization,
+			userID:   validID,
+		},
+		{
+			desc:   "view twin from another domain",
+			id:     foreign.ID,
+			twin:   foreign,
+			token:  token,
+			err:    svcerr.ErrNotFound,
+			userID: validID,
+		},
+		{
+			desc:     "view owned twin",
+			id:       owned.ID,
+			twin:     owned,
+			token:    token,
+			err:      nil,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   validID,
+		},
+		{
+			desc:     "view twin shared with user",
+			id:       shared.ID,
+			twin:     shared,
+			token:    token,
+			err:      nil,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   validID,
+		},
+		{
+			desc:   "view twin with stale attributes",
+			id:     monitored.ID,
+			twin:   monitored,
+			token:  token,
+			err:    nil,
+			userID: validID,
+			last:   twins.State{Payload: map[string]interface{}{}, Stale: []string{"temperature"}},
+			stale:  []string{"temperature"},
+		},
+		{
+			desc:   "view twin without states with stale attributes",
+			id:     stateless.ID,
+			twin:   stateless,
+			token:  token,
+			err:    nil,
+			userID: validID,
+			stale:  []string{"temperature"},
+		},
+		{
+			desc:     "view twin neither owned by nor shared with user",
+			id:       twin.ID,
+			twin:     twin,
+			token:    token,
+			err:      svcerr.ErrAuthorization,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   validID,
+		},
 	}
 
 	for _, tc := range cases {
 		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
-		repoCall := twinRepo.On("RetrieveByID", context.Background(), tc.id).Return(twins.Twin{}, tc.err)
-		_, err := svc.ViewTwin(context.Background(), tc.token, tc.id)
+		authzCall := accessCall(authz, tc.authzErr, tc.adminErr, roles)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), tc.id).Return(tc.twin, tc.retrieveErr)
+		stateCall := stateRepo.On("RetrieveLast", context.Background(), tc.id).Return(tc.last, nil)
+		tw, err := svc.ViewTwin(context.Background(), tc.token, domainID, tc.id)
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.stale, tw.Stale, fmt.Sprintf("%s: expected stale attributes %v got %v\n", tc.desc, tc.stale, tw.Stale))
 		authCall.Unset()
+		authzCall.Unset()
 		repoCall.Unset()
+		stateCall.Unset()
 	}
 }
 
 func TestListTwins(t *testing.T) {
-	svc, auth, twinRepo, _, _ := NewService()
+	svc, auth, authz, policySvc, twinRepo, _, _, _, _ := NewService()
 	twin := twins.Twin{Name: twinName, Owner: email}
 	m := make(map[string]interface{})
 	m["serial"] = "123456"
 	twin.Metadata = m
 
 	n := uint64(10)
+	visible := []string{testsutil.GenerateUUID(t), testsutil.GenerateUUID(t)}
 
 	cases := []struct {
 		desc        string
@@ -232,7 +830,11 @@ func TestListTwins(t *testing.T) {
 		err         error
 		repoerr     error
 		identifyErr error
+		adminErr    error
+		listErr     error
 		userID      string
+		repoIDs     []string
+		parentID    string
 	}{
 		{
 			desc:        "list all twins",
@@ -264,6 +866,35 @@ func TestListTwins(t *testing.T) {
 			identifyErr: nil,
 			userID:      validID,
 		},
+		{
+			desc:     "list twins visible to domain member",
+			token:    token,
+			offset:   0,
+			limit:    n,
+			err:      nil,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   validID,
+			repoIDs:  visible,
+		},
+		{
+			desc:     "list twins visible to domain member with failed policy listing",
+			token:    token,
+			offset:   0,
+			limit:    n,
+			err:      svcerr.ErrViewEntity,
+			adminErr: svcerr.ErrAuthorization,
+			listErr:  svcerr.ErrAuthorization,
+			userID:   validID,
+		},
+		{
+			desc:     "list children of twin",
+			token:    token,
+			offset:   0,
+			limit:    n,
+			err:      nil,
+			userID:   validID,
+			parentID: validID,
+		},
 		{
 			desc:        "list with wrong credentials",
 			token:       invalidToken,
@@ -276,322 +907,5136 @@ func TestListTwins(t *testing.T) {
 
 	for _, tc := range cases {
 		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
-		repoCall := twinRepo.On("RetrieveAll", context.Background(), mock.Anything, tc.offset, tc.limit, twinName, mock.Anything).Return(twins.Page{}, tc.err)
-		_, err := svc.ListTwins(context.Background(), tc.token, tc.offset, tc.limit, twinName, tc.metadata)
+		authzCall := authorizeCall(authz, nil, tc.adminErr)
+		policyCall := policySvc.On("ListAllObjects", context.Background(), mock.Anything).Return(policies.PolicyPage{Policies: visible}, tc.listErr)
+		repoCall := twinRepo.On("RetrieveAll", context.Background(), domainID, tc.repoIDs, tc.offset, tc.limit, twinName, tc.parentID, mock.Anything).Return(twins.Page{}, tc.err)
+		_, err := svc.ListTwins(context.Background(), tc.token, domainID, tc.offset, tc.limit, twinName, tc.parentID, tc.metadata)
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
 		authCall.Unset()
+		authzCall.Unset()
+		policyCall.Unset()
 		repoCall.Unset()
 	}
 }
 
-func TestRemoveTwin(t *testing.T) {
-	svc, auth, twinRepo, twinCache, _ := NewService()
-	twin := twins.Twin{
-		Owner: email,
-		ID:    testsutil.GenerateUUID(t),
-		Name:  twinName,
-	}
+func TestExportTwins(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, _, _, _ := NewService()
+
+	owned := twins.Twin{ID: testsutil.GenerateUUID(t), Owner: validID, Domain: domainID, Definitions: []twins.Definition{{ID: 0}, {ID: 1}}}
+	shared := twins.Twin{ID: testsutil.GenerateUUID(t), Owner: email, Domain: domainID}
 
 	cases := []struct {
-		desc        string
-		id          string
-		token       string
-		err         error
-		removeErr   error
-		identifyErr error
-		userID      string
+		desc     string
+		token    string
+		ownerID  string
+		page     twins.Page
+		exported []twins.Twin
+		adminErr error
+		authnErr error
+		err      error
 	}{
 		{
-			desc:        "remove twin with wrong credentials",
-			id:          twin.ID,
-			token:       invalidToken,
-			err:         svcerr.ErrAuthentication,
-			removeErr:   svcerr.ErrRemoveEntity,
-			identifyErr: svcerr.ErrAuthentication,
+			desc:     "export twins of the user",
+			token:    token,
+			page:     twins.Page{PageMetadata: twins.PageMetadata{Total: 2}, Twins: []twins.Twin{owned, shared}},
+			exported: []twins.Twin{owned},
 		},
 		{
-			desc:        "remove existing twin",
-			id:          twin.ID,
-			token:       token,
-			err:         nil,
-			removeErr:   nil,
-			identifyErr: nil,
-			userID:      validID,
+			desc:     "export twins of other user as domain administrator",
+			token:    token,
+			ownerID:  email,
+			page:     twins.Page{PageMetadata: twins.PageMetadata{Total: 2}, Twins: []twins.Twin{owned, shared}},
+			exported: []twins.Twin{shared},
 		},
 		{
-			desc:        "remove removed twin",
-			id:          twin.ID,
-			token:       token,
-			err:         nil,
-			removeErr:   nil,
-			identifyErr: nil,
-			userID:      validID,
+			desc:     "export twins of other user as domain member",
+			token:    token,
+			ownerID:  email,
+			adminErr: svcerr.ErrAuthorization,
+			err:      svcerr.ErrAuthorization,
 		},
 		{
-			desc:        "remove non-existing twin",
-			id:          wrongID,
-			token:       token,
-			err:         nil,
-			removeErr:   nil,
-			identifyErr: nil,
-			userID:      validID,
+			desc:     "export twins with invalid token",
+			token:    invalidToken,
+			authnErr: svcerr.ErrAuthentication,
+			err:      svcerr.ErrAuthentication,
 		},
 	}
 
 	for _, tc := range cases {
-		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
-		repoCall := twinRepo.On("Remove", context.Background(), tc.id).Return(tc.removeErr)
-		cacheCall := twinCache.On("Remove", context.Background(), tc.id).Return(tc.err)
-		err := svc.RemoveTwin(context.Background(), tc.token, tc.id)
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: validID}, tc.authnErr)
+		authzCall := authorizeCall(authz, nil, tc.adminErr)
+		repoCall := twinRepo.On("RetrieveAll", context.Background(), domainID, []string(nil), uint64(0), uint64(100), "", "", mock.Anything).Return(tc.page, nil)
+		tws, err := svc.ExportTwins(context.Background(), tc.token, domainID, tc.ownerID)
 		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err == nil {
+			assert.Equal(t, tc.exported, tws, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.exported, tws))
+		}
 		authCall.Unset()
+		authzCall.Unset()
 		repoCall.Unset()
-		cacheCall.Unset()
 	}
 }
 
-func TestSaveStates(t *testing.T) {
-	svc, auth, twinRepo, twinCache, stateRepo := NewService()
-
-	def := mocks.CreateDefinition(channels[0:2], subtopics[0:2])
-	twin := twins.Twin{
-		Owner:       email,
-		ID:          testsutil.GenerateUUID(t),
-		Name:        twinName,
-		Definitions: []twins.Definition{def},
-	}
-
-	attr := def.Attributes[0]
-	attrSansTwin := mocks.CreateDefinition(channels[2:3], subtopics[2:3]).Attributes[0]
+func TestImportTwins(t *testing.T) {
+	svc, auth, authz, policySvc, twinRepo, twinCache, _, templateRepo, _ := NewService()
 
-	defWildcard := mocks.CreateDefinition(channels[0:2], []string{twins.SubtopicWildcard, twins.SubtopicWildcard})
-	twWildcard := twins.Twin{
-		Definitions: []twins.Definition{defWildcard},
+	def := twins.Definition{
+		Attributes: []twins.Attribute{
+			{Name: "temperature", Channel: channels[0], Subtopic: subtopics[0], PersistState: true},
+		},
 	}
-
-	recs := make([]senml.Record, numRecs)
-
-	var ttlAdded uint64
+	existing := twins.Twin{ID: testsutil.GenerateUUID(t), Owner: email, Domain: domainID}
+	parent := twins.Twin{ID: testsutil.GenerateUUID(t), Owner: email, Name: "plant", Definitions: []twins.Definition{def}, Revision: 3}
+	child := twins.Twin{ID: testsutil.GenerateUUID(t), Owner: email, Name: "line", Parent: parent.ID, Definitions: []twins.Definition{{ID: 0}, def}}
+	taken := twins.Twin{ID: existing.ID, Name: "copy", Definitions: []twins.Definition{def}}
+	attached := twins.Twin{ID: testsutil.GenerateUUID(t), Name: "pump", Parent: existing.ID, Definitions: []twins.Definition{def}}
+	orphan := twins.Twin{ID: testsutil.GenerateUUID(t), Name: "orphan", Parent: testsutil.GenerateUUID(t), Definitions: []twins.Definition{def}}
+	undefined := twins.Twin{ID: testsutil.GenerateUUID(t), Name: "undefined"}
+	invalid := twins.Twin{ID: testsutil.GenerateUUID(t), Definitions: []twins.Definition{{Attributes: []twins.Attribute{{Name: "speed", Type: "complex"}}}}}
+	cycleA := twins.Twin{ID: testsutil.GenerateUUID(t), Definitions: []twins.Definition{def}}
+	cycleB := twins.Twin{ID: testsutil.GenerateUUID(t), Parent: cycleA.ID, Definitions: []twins.Definition{def}}
+	cycleA.Parent = cycleB.ID
+	tmpl := twins.Template{ID: testsutil.GenerateUUID(t), Domain: domainID}
+	foreignTmpl := twins.Template{ID: testsutil.GenerateUUID(t), Domain: testsutil.GenerateUUID(t)}
+	bindings := map[string]string{"channel": channels[0]}
+	templated := twins.Twin{ID: testsutil.GenerateUUID(t), Template: tmpl.ID, TemplateRevision: 2, Bindings: bindings, Definitions: []twins.Definition{def}}
+	foreignTemplated := twins.Twin{ID: testsutil.GenerateUUID(t), Template: foreignTmpl.ID, TemplateRevision: 2, Bindings: bindings, Definitions: []twins.Definition{def}}
+	unknownTemplated := twins.Twin{ID: testsutil.GenerateUUID(t), Template: testsutil.GenerateUUID(t), TemplateRevision: 2, Bindings: bindings, Definitions: []twins.Definition{def}}
 
 	cases := []struct {
-		desc   string
-		recs   []senml.Record
-		attr   twins.Attribute
-		size   uint64
-		err    error
-		String []string
-		page   twins.StatesPage
+		desc       string
+		token      string
+		tws        []twins.Twin
+		opts       twins.ImportOptions
+		saved      int
+		failID     string
+		adminErr   error
+		channelErr error
+		policyErr  error
+		authnErr   error
+		err        error
 	}{
 		{
-			desc: "add 100 states",
-			recs: recs,
-			attr: attr,
-			size: numRecs,
-			err:  nil,
-			page: twins.StatesPage{
-				PageMetadata: twins.PageMetadata{
-					Total: numRecs,
-				},
-			},
+			desc:  "import twins preserving IDs",
+			token: token,
+			tws:   []twins.Twin{child, parent},
+			saved: 2,
 		},
 		{
-			desc: "add 20 states",
-			recs: recs[10:30],
-			attr: attr,
-			size: 20,
-			err:  nil,
-			page: twins.StatesPage{
-				PageMetadata: twins.PageMetadata{
-					Total: numRecs + 20,
-				},
-			},
+			desc:  "import twins remapping IDs",
+			token: token,
+			tws:   []twins.Twin{parent, child},
+			opts:  twins.ImportOptions{RemapIDs: true},
+			saved: 2,
 		},
 		{
-			desc: "add 20 states for atttribute without twin",
-			recs: recs[30:50],
-			size: 0,
-			attr: attrSansTwin,
-			err:  svcerr.ErrNotFound,
-			page: twins.StatesPage{
-				PageMetadata: twins.PageMetadata{
-					Total: numRecs + 20,
-				},
-			},
+			desc:  "import twins created from templates",
+			token: token,
+			tws:   []twins.Twin{templated, foreignTemplated, unknownTemplated},
+			saved: 3,
 		},
 		{
-			desc: "use empty senml record",
-			recs: []senml.Record{},
-			attr: attr,
-			size: 0,
-			err:  nil,
-			page: twins.StatesPage{
-				PageMetadata: twins.PageMetadata{
-					Total: numRecs + 20,
-				},
-			},
+			desc:  "import twin attached to existing twin",
+			token: token,
+			tws:   []twins.Twin{attached},
+			saved: 1,
+		},
+		{
+			desc:     "import twin attached to twin the user cannot edit",
+			token:    token,
+			tws:      []twins.Twin{attached},
+			adminErr: svcerr.ErrAuthorization,
+			err:      svcerr.ErrAuthorization,
+		},
+		{
+			desc:       "import twin bound to channel without subscribe permission",
+			token:      token,
+			tws:        []twins.Twin{parent},
+			channelErr: svcerr.ErrAuthorization,
+			err:        svcerr.ErrAuthorization,
+		},
+		{
+			desc:   "import twins with failed save",
+			token:  token,
+			tws:    []twins.Twin{parent, child},
+			failID: child.ID,
+			err:    svcerr.ErrCreateEntity,
+		},
+		{
+			desc:      "import twins with failed policy addition",
+			token:     token,
+			tws:       []twins.Twin{parent, child},
+			policyErr: svcerr.ErrAuthorization,
+			err:       svcerr.ErrAddPolicies,
+		},
+		{
+			desc:  "dry run import of twins",
+			token: token,
+			tws:   []twins.Twin{parent, child},
+			opts:  twins.ImportOptions{DryRun: true},
+		},
+		{
+			desc:  "import twin with existing ID",
+			token: token,
+			tws:   []twins.Twin{parent, taken},
+			err:   svcerr.ErrConflict,
+		},
+		{
+			desc:  "import twin with existing ID remapping IDs",
+			token: token,
+			tws:   []twins.Twin{taken},
+			opts:  twins.ImportOptions{RemapIDs: true},
+			saved: 1,
+		},
+		{
+			desc:  "import twins with duplicate IDs",
+			token: token,
+			tws:   []twins.Twin{parent, parent},
+			err:   svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:  "import twin without ID",
+			token: token,
+			tws:   []twins.Twin{{Definitions: []twins.Definition{def}}},
+			opts:  twins.ImportOptions{RemapIDs: true},
+			err:   svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:  "import twin with unknown parent",
+			token: token,
+			tws:   []twins.Twin{orphan},
+			err:   svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:  "import twin without definitions",
+			token: token,
+			tws:   []twins.Twin{undefined},
+			err:   svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:  "import twin with invalid definition",
+			token: token,
+			tws:   []twins.Twin{invalid},
+			err:   svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:  "import twins with cyclic parents",
+			token: token,
+			tws:   []twins.Twin{cycleA, cycleB},
+			err:   svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:  "import no twins",
+			token: token,
+			err:   svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:     "import twins with invalid token",
+			token:    invalidToken,
+			tws:      []twins.Twin{parent},
+			authnErr: svcerr.ErrAuthentication,
+			err:      svcerr.ErrAuthentication,
 		},
 	}
 
 	for _, tc := range cases {
-		repoCall := auth.On("Authenticate", context.TODO(), token).Return(smqauthn.Session{UserID: testsutil.GenerateUUID(t)}, nil)
-		message, err := mocks.CreateMessage(tc.attr, tc.recs)
-		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
-
-		repoCall1 := twinRepo.On("RetrieveByAttribute", context.Background(), mock.Anything, mock.Anything).Return(tc.String, nil)
-		repoCall2 := twinRepo.On("SaveIDs", context.Background(), mock.Anything, mock.Anything, mock.Anything).Return(tc.err)
-		repoCall3 := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return(tc.String, nil)
-		err = svc.SaveStates(context.Background(), message)
-		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
-
-		ttlAdded += tc.size
-		repoCall4 := stateRepo.On("RetrieveAll", context.TODO(), mock.Anything, mock.Anything, twin.ID).Return(tc.page, nil)
-		page, err := svc.ListStates(context.TODO(), token, 0, 10, twin.ID)
-		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
-		assert.Equal(t, ttlAdded, page.Total, fmt.Sprintf("%s: expected %d total got %d total\n", tc.desc, ttlAdded, page.Total))
-
-		repoCall5 := stateRepo.On("RetrieveAll", context.TODO(), mock.Anything, mock.Anything, twWildcard.ID).Return(tc.page, nil)
-		page, err = svc.ListStates(context.TODO(), token, 0, 10, twWildcard.ID)
-		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
-		assert.Equal(t, ttlAdded, page.Total, fmt.Sprintf("%s: expected %d total got %d total\n", tc.desc, ttlAdded, page.Total))
+		saved := map[string]twins.Twin{}
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: validID}, tc.authnErr)
+		authzCall := channelAccessCall(authz, tc.channelErr, nil, tc.adminErr, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), mock.Anything).Return(func(_ context.Context, id string) (twins.Twin, error) {
+			if id == existing.ID {
+				return existing, nil
+			}
+			return twins.Twin{}, repoerr.ErrNotFound
+		})
+		repoCall1 := twinRepo.On("Save", context.Background(), mock.Anything).Return(func(_ context.Context, tw twins.Twin) (string, error) {
+			if tw.ID == tc.failID {
+				return "", repoerr.ErrCreateEntity
+			}
+			saved[tw.ID] = tw
+			return tw.ID, nil
+		})
+		repoCall2 := twinRepo.On("Remove", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			delete(saved, args.String(1))
+		}).Return(nil)
+		tmplCall := templateRepo.On("RetrieveByID", context.Background(), mock.Anything).Return(func(_ context.Context, id string) (twins.Template, error) {
+			for _, tm := range []twins.Template{tmpl, foreignTmpl} {
+				if tm.ID == id {
+					return tm, nil
+				}
+			}
+			return twins.Template{}, repoerr.ErrNotFound
+		})
+		cacheCall := twinCache.On("Save", context.Background(), mock.Anything).Return(nil)
+		cacheCall1 := twinCache.On("Remove", context.Background(), mock.Anything).Return(nil)
+		policyCall := policySvc.On("AddPolicies", context.Background(), mock.Anything).Return(tc.policyErr)
+		policyCall1 := policySvc.On("DeletePolicyFilter", context.Background(), mock.Anything).Return(nil)
+		imp, err := svc.ImportTwins(context.Background(), tc.token, domainID, tc.tws, tc.opts)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Len(t, saved, tc.saved, fmt.Sprintf("%s: expected %d saved twins got %d\n", tc.desc, tc.saved, len(saved)))
+		if err == nil {
+			assert.Equal(t, tc.opts.DryRun, imp.DryRun, fmt.Sprintf("%s: expected dry run %t got %t\n", tc.desc, tc.opts.DryRun, imp.DryRun))
+			assert.Len(t, imp.IDs, len(tc.tws), fmt.Sprintf("%s: expected %d imported IDs got %d\n", tc.desc, len(tc.tws), len(imp.IDs)))
+			for _, tw := range tc.tws {
+				id := imp.IDs[tw.ID]
+				if tc.opts.RemapIDs {
+					assert.NotEqual(t, tw.ID, id, fmt.Sprintf("%s: expected remapped ID of twin %s\n", tc.desc, tw.ID))
+				} else {
+					assert.Equal(t, tw.ID, id, fmt.Sprintf("%s: expected preserved ID %s got %s\n", tc.desc, tw.ID, id))
+				}
+				if tc.opts.DryRun {
+					continue
+				}
+				s := saved[id]
+				wantParent := tw.Parent
+				if p, ok := imp.IDs[tw.Parent]; ok {
+					wantParent = p
+				}
+				assert.Equal(t, wantParent, s.Parent, fmt.Sprintf("%s: expected parent %s got %s\n", tc.desc, wantParent, s.Parent))
+				assert.Equal(t, validID, s.Owner, fmt.Sprintf("%s: expected owner %s got %s\n", tc.desc, validID, s.Owner))
+				assert.Equal(t, domainID, s.Domain, fmt.Sprintf("%s: expected domain %s got %s\n", tc.desc, domainID, s.Domain))
+				assert.Equal(t, 0, s.Revision, fmt.Sprintf("%s: expected revision 0 got %d\n", tc.desc, s.Revision))
+				assert.Equal(t, tw.Definitions, s.Definitions, fmt.Sprintf("%s: expected definitions %v got %v\n", tc.desc, tw.Definitions, s.Definitions))
+				// Only the templates of the domain are kept.
+				if tw.Template == tmpl.ID {
+					assert.Equal(t, tw.Template, s.Template, fmt.Sprintf("%s: expected template %s got %s\n", tc.desc, tw.Template, s.Template))
+					assert.Equal(t, tw.Bindings, s.Bindings, fmt.Sprintf("%s: expected bindings %v got %v\n", tc.desc, tw.Bindings, s.Bindings))
+					continue
+				}
+				assert.Empty(t, s.Template, fmt.Sprintf("%s: expected no template got %s\n", tc.desc, s.Template))
+				assert.Zero(t, s.TemplateRevision, fmt.Sprintf("%s: expected no template revision got %d\n", tc.desc, s.TemplateRevision))
+				assert.Empty(t, s.Bindings, fmt.Sprintf("%s: expected no bindings got %v\n", tc.desc, s.Bindings))
+			}
+		}
+		authCall.Unset()
+		authzCall.Unset()
 		repoCall.Unset()
 		repoCall1.Unset()
 		repoCall2.Unset()
-		repoCall3.Unset()
-		repoCall4.Unset()
-		repoCall5.Unset()
+		tmplCall.Unset()
+		cacheCall.Unset()
+		cacheCall1.Unset()
+		policyCall.Unset()
+		policyCall1.Unset()
 	}
 }
 
-func TestListStates(t *testing.T) {
-	svc, auth, _, _, stateRepo := NewService()
-
-	def := mocks.CreateDefinition(channels[0:2], subtopics[0:2])
-	twin := twins.Twin{
-		Owner:       email,
-		ID:          testsutil.GenerateUUID(t),
-		Name:        twinName,
-		Definitions: []twins.Definition{def},
+const dtdlModel = `[
+	{
+		"@context": "dtmi:dtdl:context;3",
+		"@id": "dtmi:com:example:Pump;1",
+		"@type": "Interface",
+		"displayName": {"en": "Pump"},
+		"extends": "dtmi:com:example:Device;1",
+		"contents": [
+			{"@type": ["Telemetry", "Temperature"], "name": "temperature", "schema": "double", "unit": "degreeCelsius"},
+			{"@type": "Property", "name": "mode", "writable": true, "schema": "dtmi:com:example:Mode;1"},
+			{"@type": "Telemetry", "name": "vibration", "schema": "double", "unit": "gForce"},
+			{"@type": "Telemetry", "name": "location", "schema": {"@type": "Object", "fields": []}},
+			{"@type": "Command", "name": "reboot"},
+			{"@type": "Component", "name": "motor", "schema": "dtmi:com:example:Motor;1"},
+			{"@type": "Relationship", "name": "feeds", "target": "dtmi:com:example:Tank;1"}
+		],
+		"schemas": [
+			{"@id": "dtmi:com:example:Mode;1", "@type": "Enum", "valueSchema": "string", "enumValues": [
+				{"name": "auto", "enumValue": "auto"},
+				{"name": "manual", "enumValue": "manual"}
+			]}
+		]
+	},
+	{
+		"@context": "dtmi:dtdl:context;3",
+		"@id": "dtmi:com:example:Device;1",
+		"@type": "Interface",
+		"contents": [
+			{"@type": "Property", "name": "serial", "schema": "string"}
+		]
+	},
+	{
+		"@context": "dtmi:dtdl:context;3",
+		"@id": "dtmi:com:example:Motor;1",
+		"@type": "Interface",
+		"contents": [
+			{"@type": "Telemetry", "name": "speed", "schema": "integer", "unit": "revolutionPerMinute"},
+			{"@type": "Telemetry", "name": "running", "schema": "boolean"}
+		]
+	},
+	{
+		"@context": "dtmi:dtdl:context;2",
+		"@id": "dtmi:com:example:Tank;1",
+		"@type": "Interface",
+		"contents": [
+			{"@type": "Telemetry", "name": "level", "schema": "float", "unit": "percent"}
+		]
 	}
+]`
 
-	tw2 := twins.Twin{
-		Owner:       email,
-		Definitions: []twins.Definition{mocks.CreateDefinition(channels[2:3], subtopics[2:3])},
+func TestImportDTDL(t *testing.T) {
+	svc, auth, authz, policySvc, twinRepo, twinCache, _, _, _ := NewService()
+
+	attr := func(name, typ, unit string) twins.Attribute {
+		return twins.Attribute{Name: name, Channel: channels[0], Subtopic: name, Type: typ, Unit: unit, PersistState: true}
+	}
+	mode := attr("mode", twins.StringType, "")
+	mode.Enum = []interface{}{"auto", "manual"}
+	pump := []twins.Attribute{
+		attr("serial", twins.StringType, ""),
+		attr("temperature", twins.NumberType, "Cel"),
+		mode,
+		attr("vibration", twins.NumberType, ""),
+		attr("motor.speed", twins.NumberType, "rpm"),
+		attr("motor.running", twins.BoolType, ""),
 	}
+	tank := []twins.Attribute{attr("level", twins.NumberType, "%")}
 
 	cases := []struct {
-		desc        string
-		id          string
-		token       string
-		offset      uint64
-		limit       uint64
-		size        int
-		err         error
-		page        twins.StatesPage
-		identifyErr error
-		userID      string
+		desc       string
+		token      string
+		model      string
+		opts       twins.DTDLImportOptions
+		names      []string
+		attrs      [][]twins.Attribute
+		issues     int
+		failName   string
+		saved      int
+		authnErr   error
+		channelErr error
+		policyErr  error
+		err        error
 	}{
 		{
-			desc:   "get a list of first 10 states",
-			id:     twin.ID,
+			desc:   "import DTDL model",
 			token:  token,
-			offset: 0,
-			limit:  10,
-			size:   10,
-			err:    nil,
-			page: twins.StatesPage{
-				States: genStates(10),
-			},
-			identifyErr: nil,
-			userID:      validID,
+			model:  dtdlModel,
+			opts:   twins.DTDLImportOptions{Channel: channels[0]},
+			names:  []string{"Pump", "Tank"},
+			attrs:  [][]twins.Attribute{pump, tank},
+			issues: 3,
+			saved:  2,
 		},
 		{
-			desc:   "get a list of last 10 states",
-			id:     twin.ID,
+			desc:   "dry run import of DTDL model",
 			token:  token,
-			offset: numRecs - 10,
-			limit:  numRecs,
-			size:   10,
-			err:    nil,
-			page: twins.StatesPage{
-				States: genStates(10),
-			},
-			identifyErr: nil,
-			userID:      validID,
+			model:  dtdlModel,
+			opts:   twins.DTDLImportOptions{Channel: channels[0], DryRun: true},
+			names:  []string{"Pump", "Tank"},
+			attrs:  [][]twins.Attribute{pump, tank},
+			issues: 3,
 		},
 		{
-			desc:   "get a list of last 10 states with limit > numRecs",
-			id:     twin.ID,
-			token:  token,
-			offset: numRecs - 10,
-			limit:  numRecs + 10,
-			size:   10,
-			err:    nil,
-			page: twins.StatesPage{
-				States: genStates(10),
-			},
-			identifyErr: nil,
-			userID:      validID,
+			desc:  "import single DTDL interface",
+			token: token,
+			model: dtdlModel,
+			opts:  twins.DTDLImportOptions{Channel: channels[0], Interface: "dtmi:com:example:Tank;1"},
+			names: []string{"Tank"},
+			attrs: [][]twins.Attribute{tank},
+			saved: 1,
 		},
 		{
-			desc:        "get a list of first 10 states with offset == numRecs",
-			id:          twin.ID,
-			token:       token,
-			offset:      numRecs,
-			limit:       numRecs + 10,
-			size:        0,
-			err:         nil,
-			identifyErr: nil,
-			userID:      validID,
+			desc:  "import unknown DTDL interface",
+			token: token,
+			model: dtdlModel,
+			opts:  twins.DTDLImportOptions{Channel: channels[0], Interface: "dtmi:com:example:Valve;1"},
+			err:   svcerr.ErrMalformedEntity,
 		},
 		{
-			desc:        "get a list with wrong user token",
+			desc:  "import DTDL model of unsupported version",
+			token: token,
+			model: `{"@context": "dtmi:dtdl:context;1", "@id": "dtmi:com:example:Pump;1", "@type": "Interface"}`,
+			opts:  twins.DTDLImportOptions{Channel: channels[0]},
+			err:   svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:  "import malformed DTDL model",
+			token: token,
+			model: `{"@context"`,
+			opts:  twins.DTDLImportOptions{Channel: channels[0]},
+			err:   svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:       "import DTDL model bound to channel without access",
+			token:      token,
+			model:      dtdlModel,
+			opts:       twins.DTDLImportOptions{Channel: channels[0]},
+			channelErr: svcerr.ErrAuthorization,
+			err:        svcerr.ErrAuthorization,
+		},
+		{
+			desc:     "import DTDL model with failed save",
+			token:    token,
+			model:    dtdlModel,
+			opts:     twins.DTDLImportOptions{Channel: channels[0]},
+			failName: "Tank",
+			err:      svcerr.ErrCreateEntity,
+		},
+		{
+			desc:      "import DTDL model with failed policies",
+			token:     token,
+			model:     dtdlModel,
+			opts:      twins.DTDLImportOptions{Channel: channels[0]},
+			policyErr: svcerr.ErrAuthorization,
+			err:       svcerr.ErrAuthorization,
+		},
+		{
+			desc:     "import DTDL model with invalid token",
+			token:    invalidToken,
+			model:    dtdlModel,
+			opts:     twins.DTDLImportOptions{Channel: channels[0]},
+			authnErr: svcerr.ErrAuthentication,
+			err:      svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		saved := map[string]twins.Twin{}
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: validID}, tc.authnErr)
+		authzCall := channelAccessCall(authz, tc.channelErr, nil, nil, nil)
+		repoCall := twinRepo.On("Save", context.Background(), mock.Anything).Return(func(_ context.Context, tw twins.Twin) (string, error) {
+			if tw.Name == tc.failName {
+				return "", repoerr.ErrCreateEntity
+			}
+			saved[tw.ID] = tw
+			return tw.ID, nil
+		})
+		repoCall1 := twinRepo.On("Remove", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			delete(saved, args.String(1))
+		}).Return(nil)
+		cacheCall := twinCache.On("Save", context.Background(), mock.Anything).Return(nil)
+		cacheCall1 := twinCache.On("Remove", context.Background(), mock.Anything).Return(nil)
+		policyCall := policySvc.On("AddPolicies", context.Background(), mock.Anything).Return(tc.policyErr)
+		policyCall1 := policySvc.On("DeletePolicyFilter", context.Background(), mock.Anything).Return(nil)
+		imp, err := svc.ImportDTDL(context.Background(), tc.token, domainID, []byte(tc.model), tc.opts)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Len(t, saved, tc.saved, fmt.Sprintf("%s: expected %d saved twins got %d\n", tc.desc, tc.saved, len(saved)))
+		if err == nil {
+			assert.Len(t, imp.Issues, tc.issues, fmt.Sprintf("%s: expected %d issues got %v\n", tc.desc, tc.issues, imp.Issues))
+			assert.Len(t, imp.Twins, len(tc.names), fmt.Sprintf("%s: expected %d twins got %d\n", tc.desc, len(tc.names), len(imp.Twins)))
+			for i, tw := range imp.Twins {
+				assert.Equal(t, tc.names[i], tw.Name, fmt.Sprintf("%s: expected name %s got %s\n", tc.desc, tc.names[i], tw.Name))
+				assert.Equal(t, tc.attrs[i], tw.Definitions[0].Attributes, fmt.Sprintf("%s: expected attributes %v got %v\n", tc.desc, tc.attrs[i], tw.Definitions[0].Attributes))
+				assert.Contains(t, tw.Metadata, "dtdl", fmt.Sprintf("%s: expected DTDL metadata\n", tc.desc))
+			}
+			for _, tw := range saved {
+				assert.NotEmpty(t, tw.ID, fmt.Sprintf("%s: expected twin ID\n", tc.desc))
+				assert.Equal(t, validID, tw.Owner, fmt.Sprintf("%s: expected owner %s got %s\n", tc.desc, validID, tw.Owner))
+				assert.Equal(t, domainID, tw.Domain, fmt.Sprintf("%s: expected domain %s got %s\n", tc.desc, domainID, tw.Domain))
+			}
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		cacheCall.Unset()
+		cacheCall1.Unset()
+		policyCall.Unset()
+		policyCall1.Unset()
+	}
+}
+
+func TestRemoveTwin(t *testing.T) {
+	svc, auth, authz, policySvc, twinRepo, twinCache, _, _, relationRepo := NewService()
+	twin := twins.Twin{
+		Owner:  email,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+		Name:   twinName,
+	}
+	owned := twin
+	owned.ID = testsutil.GenerateUUID(t)
+	owned.Owner = validID
+	edited := twin
+	edited.ID = testsutil.GenerateUUID(t)
+	roles := map[string]string{owned.ID: policies.AdministratorRelation, edited.ID: twins.EditorRole}
+
+	cases := []struct {
+		desc        string
+		id          string
+		twin        twins.Twin
+		revision    int
+		token       string
+		err         error
+		retrieveErr error
+		removeErr   error
+		identifyErr error
+		adminErr    error
+		userID      string
+	}{
+		{
+			desc:        "remove twin with wrong credentials",
 			id:          twin.ID,
+			twin:        twin,
 			token:       invalidToken,
-			offset:      0,
-			limit:       10,
-			size:        0,
 			err:         svcerr.ErrAuthentication,
+			removeErr:   svcerr.ErrRemoveEntity,
 			identifyErr: svcerr.ErrAuthentication,
 		},
 		{
-			desc:        "get a list with id of non-existent twin",
-			id:          "1234567890",
+			desc:        "remove existing twin",
+			id:          twin.ID,
+			twin:        twin,
 			token:       token,
-			offset:      0,
-			limit:       10,
-			size:        0,
 			err:         nil,
+			removeErr:   nil,
 			identifyErr: nil,
 			userID:      validID,
 		},
 		{
-			desc:        "get a list with id of existing twin without states ",
-			id:          tw2.ID,
+			desc:     "remove owned twin",
+			id:       owned.ID,
+			twin:     owned,
+			token:    token,
+			err:      nil,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   validID,
+		},
+		{
+			desc:     "remove twin shared with user as editor",
+			id:       edited.ID,
+			twin:     edited,
+			token:    token,
+			err:      svcerr.ErrAuthorization,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   validID,
+		},
+		{
+			desc:        "remove non-existing twin",
+			id:          wrongID,
 			token:       token,
-			offset:      0,
-			limit:       10,
-			size:        0,
-			err:         nil,
+			err:         svcerr.ErrNotFound,
+			retrieveErr: repoerr.ErrNotFound,
 			identifyErr: nil,
 			userID:      validID,
 		},
+		{
+			desc:     "remove twin with any revision",
+			id:       twin.ID,
+			twin:     twin,
+			revision: twins.AnyRevision,
+			token:    token,
+			err:      nil,
+			userID:   validID,
+		},
+		{
+			desc:     "remove twin with stale revision",
+			id:       twin.ID,
+			twin:     twin,
+			revision: twin.Revision + 1,
+			token:    token,
+			err:      svcerr.ErrConflict,
+			userID:   validID,
+		},
 	}
 
 	for _, tc := range cases {
-		repoCall := auth.On("Authenticate", context.TODO(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
-		repoCall1 := stateRepo.On("RetrieveAll", context.TODO(), mock.Anything, mock.Anything, tc.id).Return(tc.page, nil)
-		page, err := svc.ListStates(context.TODO(), tc.token, tc.offset, tc.limit, tc.id)
-		assert.Equal(t, tc.err, err, fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
-		assert.Equal(t, tc.size, len(page.States), fmt.Sprintf("%s: expected %d total got %d total\n", tc.desc, tc.size, len(page.States)))
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := accessCall(authz, nil, tc.adminErr, roles)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), tc.id).Return(tc.twin, tc.retrieveErr)
+		repoCall1 := twinRepo.On("Remove", context.Background(), tc.id).Return(tc.removeErr)
+		repoCall2 := twinRepo.On("RetrieveChildren", context.Background(), mock.Anything).Return([]twins.Twin{}, nil)
+		relationCall := relationRepo.On("RemoveByTwin", context.Background(), tc.id).Return(nil)
+		cacheCall := twinCache.On("Remove", context.Background(), tc.id).Return(nil)
+		policyCall := policySvc.On("DeletePolicyFilter", context.Background(), mock.Anything).Return(nil)
+		err := svc.RemoveTwin(context.Background(), tc.token, domainID, tc.id, false, tc.revision)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		authCall.Unset()
+		authzCall.Unset()
 		repoCall.Unset()
 		repoCall1.Unset()
+		repoCall2.Unset()
+		relationCall.Unset()
+		cacheCall.Unset()
+		policyCall.Unset()
 	}
 }
 
-func genStates(length int) []twins.State {
-	states := make([]twins.State, length)
-	for i := range states {
-		states[i] = twins.State{}
+func TestRemoveTwinWithChildren(t *testing.T) {
+	svc, auth, authz, policySvc, twinRepo, twinCache, _, _, relationRepo := NewService()
+
+	parent := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
 	}
-	return states
+	child := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		Parent: parent.ID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	grandchild := twins.Twin{
+		Owner:  email,
+		Domain: domainID,
+		Parent: child.ID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	tree := map[string][]twins.Twin{
+		parent.ID: {child},
+		child.ID:  {grandchild},
+	}
+	roles := map[string]string{parent.ID: policies.AdministratorRelation, child.ID: policies.AdministratorRelation}
+
+	cases := []struct {
+		desc     string
+		cascade  bool
+		adminErr error
+		err      error
+		removed  []string
+		updated  []string
+	}{
+		{
+			desc:     "remove twin and orphan its children",
+			cascade:  false,
+			adminErr: svcerr.ErrAuthorization,
+			err:      nil,
+			removed:  []string{parent.ID},
+			updated:  []string{child.ID},
+		},
+		{
+			desc:     "remove twin with its descendants as domain administrator",
+			cascade:  true,
+			adminErr: nil,
+			err:      nil,
+			removed:  []string{grandchild.ID, child.ID, parent.ID},
+		},
+		{
+			desc:     "remove twin with descendants the user cannot delete",
+			cascade:  true,
+			adminErr: svcerr.ErrAuthorization,
+			err:      svcerr.ErrAuthorization,
+		},
+	}
+
+	for _, tc := range cases {
+		var removed, updated []string
+		authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := accessCall(authz, nil, tc.adminErr, roles)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), parent.ID).Return(parent, nil)
+		repoCall1 := childrenCall(twinRepo, tree)
+		repoCall2 := twinRepo.On("Remove", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			removed = append(removed, args.String(1))
+		}).Return(nil)
+		repoCall3 := twinRepo.On("Update", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			tw := args.Get(1).(twins.Twin)
+			assert.Empty(t, tw.Parent, fmt.Sprintf("%s: expected orphaned child got parent %s\n", tc.desc, tw.Parent))
+			updated = append(updated, tw.ID)
+		}).Return(nil)
+		relationCall := relationRepo.On("RemoveByTwin", context.Background(), mock.Anything).Return(nil)
+		cacheCall := twinCache.On("Remove", context.Background(), mock.Anything).Return(nil)
+		policyCall := policySvc.On("DeletePolicyFilter", context.Background(), mock.Anything).Return(nil)
+		err := svc.RemoveTwin(context.Background(), token, domainID, parent.ID, tc.cascade, twins.AnyRevision)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.removed, removed, fmt.Sprintf("%s: expected removed twins %v got %v\n", tc.desc, tc.removed, removed))
+		assert.Equal(t, tc.updated, updated, fmt.Sprintf("%s: expected updated twins %v got %v\n", tc.desc, tc.updated, updated))
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		repoCall2.Unset()
+		repoCall3.Unset()
+		relationCall.Unset()
+		cacheCall.Unset()
+		policyCall.Unset()
+	}
+}
+
+func TestAttachChild(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, _, _, _ := NewService()
+
+	root := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	parent := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		Parent: root.ID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	child := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	attached := child
+	attached.Parent = root.ID
+	viewed := child
+	viewed.ID = testsutil.GenerateUUID(t)
+	viewed.Owner = email
+	foreign := child
+	foreign.Domain = testsutil.GenerateUUID(t)
+	all := map[string]twins.Twin{root.ID: root, parent.ID: parent}
+	roles := map[string]string{
+		root.ID:   policies.AdministratorRelation,
+		parent.ID: policies.AdministratorRelation,
+		child.ID:  policies.AdministratorRelation,
+		viewed.ID: twins.ViewerRole,
+	}
+
+	cases := []struct {
+		desc     string
+		parentID string
+		child    twins.Twin
+		err      error
+	}{
+		{
+			desc:     "attach child to twin",
+			parentID: parent.ID,
+			child:    child,
+			err:      nil,
+		},
+		{
+			desc:     "attach child which already has a parent",
+			parentID: parent.ID,
+			child:    attached,
+			err:      svcerr.ErrConflict,
+		},
+		{
+			desc:     "attach ancestor as child",
+			parentID: parent.ID,
+			child:    root,
+			err:      svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:     "attach twin to itself",
+			parentID: child.ID,
+			child:    child,
+			err:      svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:     "attach child shared with user as viewer",
+			parentID: parent.ID,
+			child:    viewed,
+			err:      svcerr.ErrAuthorization,
+		},
+		{
+			desc:     "attach child from another domain",
+			parentID: parent.ID,
+			child:    foreign,
+			err:      svcerr.ErrNotFound,
+		},
+		{
+			desc:     "attach child to non-existing twin",
+			parentID: wrongID,
+			child:    child,
+			err:      svcerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		var saved twins.Twin
+		twinsByID := map[string]twins.Twin{tc.child.ID: tc.child}
+		for id, tw := range all {
+			twinsByID[id] = tw
+		}
+		authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := accessCall(authz, nil, svcerr.ErrAuthorization, roles)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), mock.Anything).Return(func(_ context.Context, id string) (twins.Twin, error) {
+			tw, ok := twinsByID[id]
+			if !ok {
+				return twins.Twin{}, repoerr.ErrNotFound
+			}
+			return tw, nil
+		})
+		repoCall1 := twinRepo.On("Update", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(twins.Twin)
+		}).Return(nil)
+		err := svc.AttachChild(context.Background(), token, domainID, tc.parentID, tc.child.ID)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, tc.parentID, saved.Parent, fmt.Sprintf("%s: expected parent %s got %s\n", tc.desc, tc.parentID, saved.Parent))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+func TestDetachChild(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, _, _, _ := NewService()
+
+	parent := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	child := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		Parent: parent.ID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	other := child
+	other.Parent = testsutil.GenerateUUID(t)
+
+	cases := []struct {
+		desc  string
+		child twins.Twin
+		err   error
+	}{
+		{
+			desc:  "detach child from twin",
+			child: child,
+			err:   nil,
+		},
+		{
+			desc:  "detach child of another twin",
+			child: other,
+			err:   svcerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		var saved twins.Twin
+		authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := accessCall(authz, nil, svcerr.ErrAuthorization, ownerRoles(parent, child))
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), parent.ID).Return(parent, nil)
+		repoCall1 := twinRepo.On("RetrieveByID", context.Background(), tc.child.ID).Return(tc.child, nil)
+		repoCall2 := twinRepo.On("Update", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(twins.Twin)
+		}).Return(nil)
+		err := svc.DetachChild(context.Background(), token, domainID, parent.ID, tc.child.ID)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, tc.child.ID, saved.ID, fmt.Sprintf("%s: expected updated twin %s got %s\n", tc.desc, tc.child.ID, saved.ID))
+			assert.Empty(t, saved.Parent, fmt.Sprintf("%s: expected detached child got parent %s\n", tc.desc, saved.Parent))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		repoCall2.Unset()
+	}
+}
+
+func TestViewSubtree(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, _, _, _ := NewService()
+
+	root := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	child := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		Parent: root.ID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	hidden := twins.Twin{
+		Owner:  email,
+		Domain: domainID,
+		Parent: root.ID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	grandchild := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		Parent: hidden.ID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	tree := map[string][]twins.Twin{
+		root.ID:   {child, hidden},
+		hidden.ID: {grandchild},
+	}
+
+	cases := []struct {
+		desc     string
+		adminErr error
+		node     twins.TwinNode
+	}{
+		{
+			desc:     "view subtree as domain member",
+			adminErr: svcerr.ErrAuthorization,
+			node: twins.TwinNode{
+				Twin:     root,
+				Children: []twins.TwinNode{{Twin: child}},
+			},
+		},
+		{
+			desc:     "view subtree as domain administrator",
+			adminErr: nil,
+			node: twins.TwinNode{
+				Twin: root,
+				Children: []twins.TwinNode{
+					{Twin: child},
+					{Twin: hidden, Children: []twins.TwinNode{{Twin: grandchild}}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := accessCall(authz, nil, tc.adminErr, ownerRoles(root, child, hidden, grandchild))
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), root.ID).Return(root, nil)
+		repoCall1 := childrenCall(twinRepo, tree)
+		node, err := svc.ViewSubtree(context.Background(), token, domainID, root.ID)
+		assert.Nil(t, err, fmt.Sprintf("%s: expected no error got %s\n", tc.desc, err))
+		assert.Equal(t, tc.node, node, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.node, node))
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+func TestViewCompositeState(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, stateRepo, _, _ := NewService()
+
+	root := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+		Name:   "line",
+	}
+	child := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		Parent: root.ID,
+		ID:     testsutil.GenerateUUID(t),
+		Name:   "machine",
+	}
+	tree := map[string][]twins.Twin{root.ID: {child}}
+
+	now := time.Now()
+	rootState := twins.State{TwinID: root.ID, Created: now.Add(-time.Hour), Payload: map[string]interface{}{"speed": 10.0}}
+	childState := twins.State{TwinID: child.ID, Created: now, Payload: map[string]interface{}{"temperature": 42.0}}
+
+	cases := []struct {
+		desc        string
+		states      map[string]twins.State
+		retrieveErr error
+		err         error
+		cs          twins.CompositeState
+	}{
+		{
+			desc:   "view composite state",
+			states: map[string]twins.State{root.ID: rootState, child.ID: childState},
+			err:    nil,
+			cs: twins.CompositeState{
+				TwinID:  root.ID,
+				Name:    root.Name,
+				State:   rootState,
+				Updated: now,
+				Children: []twins.CompositeState{
+					{TwinID: child.ID, Name: child.Name, State: childState, Updated: now},
+				},
+			},
+		},
+		{
+			desc:   "view composite state of twin without states",
+			states: map[string]twins.State{child.ID: childState},
+			err:    nil,
+			cs: twins.CompositeState{
+				TwinID:  root.ID,
+				Name:    root.Name,
+				Updated: now,
+				Children: []twins.CompositeState{
+					{TwinID: child.ID, Name: child.Name, State: childState, Updated: now},
+				},
+			},
+		},
+		{
+			desc:        "view composite state with failed state retrieval",
+			retrieveErr: repoerr.ErrViewEntity,
+			err:         svcerr.ErrViewEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := accessCall(authz, nil, svcerr.ErrAuthorization, ownerRoles(root, child))
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), root.ID).Return(root, nil)
+		repoCall1 := childrenCall(twinRepo, tree)
+		stateCall := stateRepo.On("RetrieveLast", context.Background(), mock.Anything).Return(func(_ context.Context, id string) (twins.State, error) {
+			return tc.states[id], tc.retrieveErr
+		})
+		cs, err := svc.ViewCompositeState(context.Background(), token, domainID, root.ID)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.cs, cs, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.cs, cs))
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		stateCall.Unset()
+	}
+}
+
+func TestAddRelation(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, _, _, relationRepo := NewService()
+
+	pump := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	tank := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	viewed := twins.Twin{
+		Owner:  email,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	foreign := twins.Twin{
+		Owner:  validID,
+		Domain: testsutil.GenerateUUID(t),
+		ID:     testsutil.GenerateUUID(t),
+	}
+	twinsByID := map[string]twins.Twin{pump.ID: pump, tank.ID: tank, viewed.ID: viewed, foreign.ID: foreign}
+
+	roles := map[string]string{pump.ID: policies.AdministratorRelation, tank.ID: policies.AdministratorRelation, viewed.ID: twins.ViewerRole}
+
+	cases := []struct {
+		desc    string
+		rel     twins.Relation
+		saveErr error
+		err     error
+	}{
+		{
+			desc: "add relation",
+			rel:  twins.Relation{Source: pump.ID, Target: tank.ID, Type: "feeds", Metadata: twins.Metadata{"pipe": "p-1"}},
+			err:  nil,
+		},
+		{
+			desc: "add relation to twin shared with user as viewer",
+			rel:  twins.Relation{Source: pump.ID, Target: viewed.ID, Type: "feeds"},
+			err:  nil,
+		},
+		{
+			desc: "add relation from twin shared with user as viewer",
+			rel:  twins.Relation{Source: viewed.ID, Target: pump.ID, Type: "feeds"},
+			err:  svcerr.ErrAuthorization,
+		},
+		{
+			desc: "add relation with invalid type",
+			rel:  twins.Relation{Source: pump.ID, Target: tank.ID, Type: "Feeds!"},
+			err:  svcerr.ErrMalformedEntity,
+		},
+		{
+			desc: "add relation of twin to itself",
+			rel:  twins.Relation{Source: pump.ID, Target: pump.ID, Type: "feeds"},
+			err:  svcerr.ErrMalformedEntity,
+		},
+		{
+			desc: "add relation to twin from another domain",
+			rel:  twins.Relation{Source: pump.ID, Target: foreign.ID, Type: "feeds"},
+			err:  svcerr.ErrNotFound,
+		},
+		{
+			desc: "add relation to non-existing twin",
+			rel:  twins.Relation{Source: pump.ID, Target: wrongID, Type: "feeds"},
+			err:  svcerr.ErrNotFound,
+		},
+		{
+			desc:    "add existing relation",
+			rel:     twins.Relation{Source: pump.ID, Target: tank.ID, Type: "feeds"},
+			saveErr: repoerr.ErrConflict,
+			err:     svcerr.ErrConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		var saved twins.Relation
+		authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := accessCall(authz, nil, svcerr.ErrAuthorization, roles)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), mock.Anything).Return(func(_ context.Context, id string) (twins.Twin, error) {
+			tw, ok := twinsByID[id]
+			if !ok {
+				return twins.Twin{}, repoerr.ErrNotFound
+			}
+			return tw, nil
+		})
+		repoCall1 := relationRepo.On("Save", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(twins.Relation)
+		}).Return(tc.saveErr)
+		rel, err := svc.AddRelation(context.Background(), token, domainID, tc.rel)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, saved, rel, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, saved, rel))
+			assert.Equal(t, domainID, rel.Domain, fmt.Sprintf("%s: expected domain %s got %s\n", tc.desc, domainID, rel.Domain))
+			assert.False(t, rel.Created.IsZero(), fmt.Sprintf("%s: expected creation time to be set\n", tc.desc))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+func TestRemoveRelation(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, _, _, relationRepo := NewService()
+
+	pump := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	viewed := twins.Twin{
+		Owner:  email,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	tankID := testsutil.GenerateUUID(t)
+
+	roles := map[string]string{pump.ID: policies.AdministratorRelation, viewed.ID: twins.ViewerRole}
+
+	cases := []struct {
+		desc      string
+		source    twins.Twin
+		removeErr error
+		err       error
+	}{
+		{
+			desc:   "remove relation",
+			source: pump,
+			err:    nil,
+		},
+		{
+			desc:      "remove non-existing relation",
+			source:    pump,
+			removeErr: repoerr.ErrNotFound,
+			err:       svcerr.ErrNotFound,
+		},
+		{
+			desc:      "remove relation with failed removal",
+			source:    pump,
+			removeErr: repoerr.ErrRemoveEntity,
+			err:       svcerr.ErrRemoveEntity,
+		},
+		{
+			desc:   "remove relation from twin shared with user as viewer",
+			source: viewed,
+			err:    svcerr.ErrAuthorization,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := accessCall(authz, nil, svcerr.ErrAuthorization, roles)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), tc.source.ID).Return(tc.source, nil)
+		repoCall1 := relationRepo.On("Remove", context.Background(), tc.source.ID, "feeds", tankID).Return(tc.removeErr)
+		rel := twins.Relation{Source: tc.source.ID, Target: tankID, Type: "feeds"}
+		err := svc.RemoveRelation(context.Background(), token, domainID, rel)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+func TestListRelations(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, _, _, relationRepo := NewService()
+
+	pump := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+	page := twins.RelationsPage{
+		PageMetadata: twins.PageMetadata{Total: 1, Offset: 0, Limit: 10},
+		Relations: []twins.Relation{
+			{Domain: domainID, Source: pump.ID, Target: testsutil.GenerateUUID(t), Type: "feeds"},
+		},
+	}
+
+	cases := []struct {
+		desc        string
+		id          string
+		token       string
+		filter      twins.RelationFilter
+		page        twins.RelationsPage
+		retrieveErr error
+		identifyErr error
+		err         error
+	}{
+		{
+			desc:   "list relations",
+			id:     pump.ID,
+			token:  token,
+			filter: twins.RelationFilter{Types: []string{"feeds"}, Direction: twins.OutgoingDir},
+			page:   page,
+		},
+		{
+			desc:   "list relations with invalid direction",
+			id:     pump.ID,
+			token:  token,
+			filter: twins.RelationFilter{Direction: "sideways"},
+			err:    svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:        "list relations of non-existing twin",
+			id:          wrongID,
+			token:       token,
+			retrieveErr: repoerr.ErrNotFound,
+			err:         svcerr.ErrNotFound,
+		},
+		{
+			desc:        "list relations with wrong credentials",
+			id:          pump.ID,
+			token:       invalidToken,
+			identifyErr: svcerr.ErrAuthentication,
+			err:         svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: validID}, tc.identifyErr)
+		authzCall := authorizeCall(authz, nil, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), tc.id).Return(pump, tc.retrieveErr)
+		repoCall1 := relationRepo.On("RetrieveAll", context.Background(), tc.id, uint64(0), uint64(10), tc.filter).Return(tc.page, nil)
+		res, err := svc.ListRelations(context.Background(), tc.token, domainID, tc.id, 0, 10, tc.filter)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, tc.page, res, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.page, res))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+func TestTraverseRelations(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, _, _, relationRepo := NewService()
+
+	twin := func() twins.Twin {
+		return twins.Twin{Owner: validID, Domain: domainID, ID: testsutil.GenerateUUID(t)}
+	}
+	pump, tank, boiler, meter := twin(), twin(), twin(), twin()
+	hidden := twin()
+	hidden.Owner = email
+	foreign := twin()
+	foreign.Domain = testsutil.GenerateUUID(t)
+	twinsByID := map[string]twins.Twin{}
+	for _, tw := range []twins.Twin{pump, tank, boiler, meter, hidden, foreign} {
+		twinsByID[tw.ID] = tw
+	}
+
+	// pump feeds tank, which feeds boiler and the hidden twin, while meter
+	// measures tank.
+	rel := func(source twins.Twin, relType string, target twins.Twin) twins.Relation {
+		return twins.Relation{Domain: domainID, Source: source.ID, Type: relType, Target: target.ID}
+	}
+	pumpTank := rel(pump, "feeds", tank)
+	tankBoiler := rel(tank, "feeds", boiler)
+	tankHidden := rel(tank, "feeds", hidden)
+	hiddenForeign := rel(hidden, "feeds", foreign)
+	meterTank := rel(meter, "measures", tank)
+	all := []twins.Relation{pumpTank, tankBoiler, tankHidden, hiddenForeign, meterTank}
+
+	cases := []struct {
+		desc   string
+		origin twins.Twin
+		query  twins.TraversalQuery
+		hops   []twins.Hop
+		err    error
+	}{
+		{
+			desc:   "traverse one hop downstream",
+			origin: pump,
+			query:  twins.TraversalQuery{Depth: 1},
+			hops:   []twins.Hop{{Twin: tank, Depth: 1, Via: pumpTank}},
+		},
+		{
+			desc:   "traverse downstream by type",
+			origin: pump,
+			query:  twins.TraversalQuery{Types: []string{"feeds"}, Direction: twins.OutgoingDir, Depth: 3},
+			hops: []twins.Hop{
+				{Twin: tank, Depth: 1, Via: pumpTank},
+				{Twin: boiler, Depth: 2, Via: tankBoiler},
+			},
+		},
+		{
+			desc:   "traverse upstream",
+			origin: boiler,
+			query:  twins.TraversalQuery{Direction: twins.IncomingDir, Depth: 2},
+			hops: []twins.Hop{
+				{Twin: tank, Depth: 1, Via: tankBoiler},
+				{Twin: pump, Depth: 2, Via: pumpTank},
+				{Twin: meter, Depth: 2, Via: meterTank},
+			},
+		},
+		{
+			desc:   "traverse both directions",
+			origin: meter,
+			query:  twins.TraversalQuery{Direction: twins.BothDirs, Depth: 2},
+			hops: []twins.Hop{
+				{Twin: tank, Depth: 1, Via: meterTank},
+				{Twin: pump, Depth: 2, Via: pumpTank},
+				{Twin: boiler, Depth: 2, Via: tankBoiler},
+			},
+		},
+		{
+			desc:   "traverse twin without relations",
+			origin: boiler,
+			query:  twins.TraversalQuery{Depth: 5},
+			hops:   []twins.Hop{},
+		},
+		{
+			desc:   "traverse with zero depth",
+			origin: pump,
+			query:  twins.TraversalQuery{Depth: 0},
+			err:    svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:   "traverse with too large depth",
+			origin: pump,
+			query:  twins.TraversalQuery{Depth: twins.MaxTraversalDepth + 1},
+			err:    svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:   "traverse with invalid direction",
+			origin: pump,
+			query:  twins.TraversalQuery{Direction: "sideways", Depth: 1},
+			err:    svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:   "traverse from twin from another domain",
+			origin: foreign,
+			query:  twins.TraversalQuery{Depth: 1},
+			err:    svcerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := accessCall(authz, nil, svcerr.ErrAuthorization, ownerRoles(pump, tank, boiler, meter, hidden, foreign))
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), mock.Anything).Return(func(_ context.Context, id string) (twins.Twin, error) {
+			tw, ok := twinsByID[id]
+			if !ok {
+				return twins.Twin{}, repoerr.ErrNotFound
+			}
+			return tw, nil
+		})
+		repoCall1 := relationRepo.On("RetrieveByTwins", context.Background(), mock.Anything, mock.Anything).Return(func(_ context.Context, ids []string, filter twins.RelationFilter) ([]twins.Relation, error) {
+			var rels []twins.Relation
+			for _, r := range all {
+				if len(filter.Types) > 0 && !slices.Contains(filter.Types, r.Type) {
+					continue
+				}
+				out := filter.Direction != twins.IncomingDir && slices.Contains(ids, r.Source)
+				in := filter.Direction != twins.OutgoingDir && slices.Contains(ids, r.Target)
+				if out || in {
+					rels = append(rels, r)
+				}
+			}
+			return rels, nil
+		})
+		trav, err := svc.TraverseRelations(context.Background(), token, domainID, tc.origin.ID, tc.query)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, tc.origin.ID, trav.Origin, fmt.Sprintf("%s: expected origin %s got %s\n", tc.desc, tc.origin.ID, trav.Origin))
+			assert.Equal(t, tc.hops, trav.Hops, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.hops, trav.Hops))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+func TestShareTwin(t *testing.T) {
+	svc, auth, authz, policySvc, twinRepo, _, _, _, _ := NewService()
+
+	twin := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+		Name:   twinName,
+	}
+	editorID := testsutil.GenerateUUID(t)
+	edited := twin
+	edited.ID = testsutil.GenerateUUID(t)
+	foreign := twin
+	foreign.Domain = testsutil.GenerateUUID(t)
+	userIDs := []string{testsutil.GenerateUUID(t)}
+	roles := map[string]string{twin.ID: policies.AdministratorRelation, edited.ID: twins.EditorRole}
+
+	cases := []struct {
+		desc        string
+		twin        twins.Twin
+		token       string
+		role        string
+		err         error
+		addErr      error
+		identifyErr error
+		adminErr    error
+		userID      string
+	}{
+		{
+			desc:     "share twin as owner",
+			twin:     twin,
+			token:    token,
+			role:     twins.ViewerRole,
+			err:      nil,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   validID,
+		},
+		{
+			desc:   "share twin as domain administrator",
+			twin:   edited,
+			token:  token,
+			role:   twins.EditorRole,
+			err:    nil,
+			userID: editorID,
+		},
+		{
+			desc:     "share twin as editor",
+			twin:     edited,
+			token:    token,
+			role:     twins.ViewerRole,
+			err:      svcerr.ErrAuthorization,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   editorID,
+		},
+		{
+			desc:   "share twin with invalid role",
+			twin:   twin,
+			token:  token,
+			role:   "owner",
+			err:    svcerr.ErrInvalidRole,
+			userID: validID,
+		},
+		{
+			desc:   "share twin from another domain",
+			twin:   foreign,
+			token:  token,
+			role:   twins.ViewerRole,
+			err:    svcerr.ErrNotFound,
+			userID: validID,
+		},
+		{
+			desc:   "share twin with failed policy addition",
+			twin:   twin,
+			token:  token,
+			role:   twins.ViewerRole,
+			err:    svcerr.ErrAddPolicies,
+			addErr: svcerr.ErrAuthorization,
+			userID: validID,
+		},
+		{
+			desc:        "share twin with wrong credentials",
+			twin:        twin,
+			token:       invalidToken,
+			role:        twins.ViewerRole,
+			err:         svcerr.ErrAuthentication,
+			identifyErr: svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		var added []policies.Policy
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := accessCall(authz, nil, tc.adminErr, roles)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), tc.twin.ID).Return(tc.twin, nil)
+		policyCall := policySvc.On("DeletePolicies", context.Background(), mock.Anything).Return(nil)
+		policyCall1 := policySvc.On("AddPolicies", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			added = args.Get(1).([]policies.Policy)
+		}).Return(tc.addErr)
+		err := svc.ShareTwin(context.Background(), tc.token, domainID, tc.twin.ID, tc.role, userIDs)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			want := []policies.Policy{{
+				Domain:      domainID,
+				SubjectType: policies.UserType,
+				Subject:     policies.EncodeDomainUserID(domainID, userIDs[0]),
+				Relation:    tc.role,
+				ObjectType:  twins.TwinType,
+				Object:      tc.twin.ID,
+			}}
+			assert.Equal(t, want, added, fmt.Sprintf("%s: expected policies %v got %v\n", tc.desc, want, added))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		policyCall.Unset()
+		policyCall1.Unset()
+	}
+}
+
+func TestUnshareTwin(t *testing.T) {
+	svc, auth, authz, policySvc, twinRepo, _, _, _, _ := NewService()
+
+	viewerID := testsutil.GenerateUUID(t)
+	twin := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+		Name:   twinName,
+	}
+
+	cases := []struct {
+		desc        string
+		token       string
+		err         error
+		deleteErr   error
+		identifyErr error
+		adminErr    error
+		userID      string
+	}{
+		{
+			desc:     "unshare twin as viewer",
+			token:    token,
+			err:      svcerr.ErrAuthorization,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   viewerID,
+		},
+		{
+			desc:        "unshare twin with wrong credentials",
+			token:       invalidToken,
+			err:         svcerr.ErrAuthentication,
+			identifyErr: svcerr.ErrAuthentication,
+		},
+		{
+			desc:     "unshare twin as owner",
+			token:    token,
+			err:      nil,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   validID,
+		},
+		{
+			desc:      "unshare twin with failed policy removal",
+			token:     token,
+			err:       svcerr.ErrDeletePolicies,
+			deleteErr: svcerr.ErrAuthorization,
+			userID:    validID,
+		},
+	}
+
+	for _, tc := range cases {
+		var removed []policies.Policy
+		roles := map[string]string{twin.ID: policies.AdministratorRelation}
+		if tc.userID == viewerID {
+			roles[twin.ID] = twins.ViewerRole
+		}
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := accessCall(authz, nil, tc.adminErr, roles)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+		policyCall := policySvc.On("DeletePolicies", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			removed = args.Get(1).([]policies.Policy)
+		}).Return(tc.deleteErr)
+		err := svc.UnshareTwin(context.Background(), tc.token, domainID, twin.ID, []string{viewerID})
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			for _, pr := range removed {
+				assert.Equal(t, policies.EncodeDomainUserID(domainID, viewerID), pr.Subject, fmt.Sprintf("%s: expected subject %s got %s\n", tc.desc, viewerID, pr.Subject))
+				assert.Equal(t, twin.ID, pr.Object, fmt.Sprintf("%s: expected object %s got %s\n", tc.desc, twin.ID, pr.Object))
+			}
+			assert.Len(t, removed, 2, fmt.Sprintf("%s: expected viewer and editor policies to be removed got %v\n", tc.desc, removed))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		policyCall.Unset()
+	}
+}
+
+func TestSaveStates(t *testing.T) {
+	svc, auth, authz, _, twinRepo, twinCache, stateRepo, _, _ := NewService()
+
+	def := mocks.CreateDefinition(channels[0:2], subtopics[0:2])
+	twin := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Name:        twinName,
+		Definitions: []twins.Definition{def},
+	}
+
+	attr := def.Attributes[0]
+	attrSansTwin := mocks.CreateDefinition(channels[2:3], subtopics[2:3]).Attributes[0]
+
+	defWildcard := mocks.CreateDefinition(channels[0:2], []string{twins.SubtopicWildcard, twins.SubtopicWildcard})
+	twWildcard := twins.Twin{
+		Domain:      domainID,
+		Definitions: []twins.Definition{defWildcard},
+	}
+
+	recs := make([]senml.Record, numRecs)
+
+	var ttlAdded uint64
+
+	cases := []struct {
+		desc   string
+		recs   []senml.Record
+		attr   twins.Attribute
+		size   uint64
+		err    error
+		String []string
+		page   twins.StatesPage
+	}{
+		{
+			desc: "add 100 states",
+			recs: recs,
+			attr: attr,
+			size: numRecs,
+			err:  nil,
+			page: twins.StatesPage{
+				PageMetadata: twins.PageMetadata{
+					Total: numRecs,
+				},
+			},
+		},
+		{
+			desc: "add 20 states",
+			recs: recs[10:30],
+			attr: attr,
+			size: 20,
+			err:  nil,
+			page: twins.StatesPage{
+				PageMetadata: twins.PageMetadata{
+					Total: numRecs + 20,
+				},
+			},
+		},
+		{
+			desc: "add 20 states for atttribute without twin",
+			recs: recs[30:50],
+			size: 0,
+			attr: attrSansTwin,
+			err:  svcerr.ErrNotFound,
+			page: twins.StatesPage{
+				PageMetadata: twins.PageMetadata{
+					Total: numRecs + 20,
+				},
+			},
+		},
+		{
+			desc: "use empty senml record",
+			recs: []senml.Record{},
+			attr: attr,
+			size: 0,
+			err:  nil,
+			page: twins.StatesPage{
+				PageMetadata: twins.PageMetadata{
+					Total: numRecs + 20,
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		repoCall := auth.On("Authenticate", context.TODO(), token).Return(smqauthn.Session{UserID: testsutil.GenerateUUID(t)}, nil)
+		authzCall := authorizeCall(authz, nil, nil)
+		message, err := mocks.CreateMessage(domainID, tc.attr, tc.recs)
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		repoCall1 := twinRepo.On("RetrieveByAttribute", context.Background(), mock.Anything, mock.Anything).Return(tc.String, nil)
+		repoCall2 := twinRepo.On("SaveIDs", context.Background(), mock.Anything, mock.Anything, mock.Anything).Return(tc.err)
+		repoCall3 := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return(tc.String, nil)
+		err = svc.SaveStates(context.Background(), message)
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		ttlAdded += tc.size
+		repoCall4 := stateRepo.On("RetrieveAll", context.TODO(), mock.Anything, mock.Anything, twin.ID, twins.StateFilter{}).Return(tc.page, nil)
+		repoCall6 := twinRepo.On("RetrieveByID", context.TODO(), twin.ID).Return(twin, nil)
+		page, err := svc.ListStates(context.TODO(), token, domainID, 0, 10, twin.ID, twins.StateFilter{})
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		assert.Equal(t, ttlAdded, page.Total, fmt.Sprintf("%s: expected %d total got %d total\n", tc.desc, ttlAdded, page.Total))
+
+		repoCall5 := stateRepo.On("RetrieveAll", context.TODO(), mock.Anything, mock.Anything, twWildcard.ID, twins.StateFilter{}).Return(tc.page, nil)
+		repoCall7 := twinRepo.On("RetrieveByID", context.TODO(), twWildcard.ID).Return(twWildcard, nil)
+		page, err = svc.ListStates(context.TODO(), token, domainID, 0, 10, twWildcard.ID, twins.StateFilter{})
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		assert.Equal(t, ttlAdded, page.Total, fmt.Sprintf("%s: expected %d total got %d total\n", tc.desc, ttlAdded, page.Total))
+		repoCall.Unset()
+		repoCall1.Unset()
+		repoCall2.Unset()
+		repoCall3.Unset()
+		repoCall4.Unset()
+		repoCall5.Unset()
+		repoCall6.Unset()
+		repoCall7.Unset()
+		authzCall.Unset()
+	}
+}
+
+func TestSaveStatesComputedAttributes(t *testing.T) {
+	svc, _, _, _, twinRepo, twinCache, stateRepo, _, _ := NewService()
+
+	voltage := twins.Attribute{Name: "voltage", Channel: channels[0], Subtopic: subtopics[0], PersistState: true}
+	current := twins.Attribute{Name: "current", Channel: channels[0], Subtopic: subtopics[1], PersistState: true}
+	def := twins.Definition{
+		Attributes: []twins.Attribute{
+			voltage,
+			current,
+			{Name: "power", Expression: "voltage * current", PersistState: true},
+			{Name: "power_kw", Expression: "power / 1000", PersistState: true},
+			{Name: "voltage_avg", Expression: "ema(voltage, 0.5)", PersistState: true},
+			{Name: "ratio", Expression: "voltage / (current - 2)", PersistState: true},
+		},
+		Delta: 1,
+	}
+	twin := twins.Twin{
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{def},
+	}
+
+	cases := []struct {
+		desc    string
+		attr    twins.Attribute
+		value   float64
+		last    twins.State
+		payload map[string]interface{}
+	}{
+		{
+			desc:  "compute attributes on input change",
+			attr:  voltage,
+			value: 230,
+			last: twins.State{
+				TwinID:  twin.ID,
+				Payload: map[string]interface{}{"current": 4.0, "voltage_avg": 220.0},
+			},
+			payload: map[string]interface{}{
+				"voltage":     230.0,
+				"current":     4.0,
+				"power":       920.0,
+				"power_kw":    0.92,
+				"voltage_avg": 225.0,
+				"ratio":       115.0,
+			},
+		},
+		{
+			desc:  "compute attributes with missing input",
+			attr:  voltage,
+			value: 230,
+			last: twins.State{
+				TwinID:  twin.ID,
+				Payload: map[string]interface{}{},
+			},
+			payload: map[string]interface{}{
+				"voltage":     230.0,
+				"voltage_avg": 230.0,
+			},
+		},
+		{
+			desc:  "compute attributes with division by zero",
+			attr:  current,
+			value: 2,
+			last: twins.State{
+				TwinID:  twin.ID,
+				Payload: map[string]interface{}{"voltage": 100.0, "ratio": 10.0},
+			},
+			payload: map[string]interface{}{
+				"voltage":  100.0,
+				"current":  2.0,
+				"power":    200.0,
+				"power_kw": 0.2,
+				"ratio":    10.0,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		var saved twins.State
+		val := tc.value
+		message, err := mocks.CreateMessage(domainID, tc.attr, []senml.Record{{Name: tc.attr.Name, Value: &val}})
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		cacheCall := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return([]string{twin.ID}, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+		stateCall := stateRepo.On("RetrieveLast", context.Background(), twin.ID).Return(tc.last, nil)
+		stateCall1 := stateRepo.On("Save", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			states := args.Get(1).([]twins.State)
+			saved = states[len(states)-1]
+		}).Return(nil)
+		err = svc.SaveStates(context.Background(), message)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		payload := map[string]interface{}{}
+		for name, val := range saved.Payload {
+			if f, ok := val.(*float64); ok {
+				val = *f
+			}
+			payload[name] = val
+		}
+		assert.Equal(t, tc.payload, payload, fmt.Sprintf("%s: expected payload %v got %v", tc.desc, tc.payload, payload))
+		cacheCall.Unset()
+		repoCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+	}
+}
+
+func TestSaveStatesDomainIsolation(t *testing.T) {
+	svc, _, _, _, twinRepo, twinCache, stateRepo, _, _ := NewService()
+
+	attr := twins.Attribute{Name: "temperature", Channel: channels[0], Subtopic: subtopics[0], PersistState: true}
+	twin := twins.Twin{
+		Owner:       validID,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{{Attributes: []twins.Attribute{attr}}},
+	}
+
+	cases := []struct {
+		desc     string
+		domainID string
+		saved    bool
+	}{
+		{
+			desc:     "save state from message of twin domain",
+			domainID: domainID,
+			saved:    true,
+		},
+		{
+			desc:     "save state from message of another domain",
+			domainID: testsutil.GenerateUUID(t),
+			saved:    false,
+		},
+	}
+
+	for _, tc := range cases {
+		saved := false
+		val := 21.5
+		message, err := mocks.CreateMessage(tc.domainID, attr, []senml.Record{{Name: attr.Name, Value: &val}})
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		cacheCall := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return([]string{twin.ID}, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+		stateCall := stateRepo.On("RetrieveLast", context.Background(), twin.ID).Return(twins.State{}, nil)
+		stateCall1 := stateRepo.On("Save", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			saved = true
+		}).Return(nil)
+		err = svc.SaveStates(context.Background(), message)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.saved, saved, fmt.Sprintf("%s: expected state saved %t got %t", tc.desc, tc.saved, saved))
+		cacheCall.Unset()
+		repoCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+	}
+}
+
+func TestSaveStatesSchemaValidation(t *testing.T) {
+	svc, _, _, _, twinRepo, twinCache, stateRepo, _, _ := NewService()
+
+	minTemp, maxTemp := -40.0, 125.0
+	temperature := twins.Attribute{
+		Name:         "temperature",
+		Channel:      channels[0],
+		Subtopic:     subtopics[0],
+		Type:         twins.NumberType,
+		Unit:         "Cel",
+		Min:          &minTemp,
+		Max:          &maxTemp,
+		PersistState: true,
+	}
+	mode := twins.Attribute{
+		Name:         "mode",
+		Channel:      channels[0],
+		Subtopic:     subtopics[1],
+		Type:         twins.StringType,
+		Enum:         []interface{}{"eco", "comfort"},
+		PersistState: true,
+	}
+	twin := twins.Twin{
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{{Attributes: []twins.Attribute{temperature, mode}, Delta: 1}},
+	}
+
+	num := func(v float64) *float64 { return &v }
+	str := func(v string) *string { return &v }
+
+	cases := []struct {
+		desc  string
+		attr  twins.Attribute
+		rec   senml.Record
+		saved bool
+	}{
+		{
+			desc:  "save valid number",
+			attr:  temperature,
+			rec:   senml.Record{Name: "temperature", Unit: "Cel", Value: num(21.5)},
+			saved: true,
+		},
+		{
+			desc:  "save valid number without unit",
+			attr:  temperature,
+			rec:   senml.Record{Name: "temperature", Value: num(21.5)},
+			saved: true,
+		},
+		{
+			desc:  "save string as number",
+			attr:  temperature,
+			rec:   senml.Record{Name: "temperature", StringValue: str("hot")},
+			saved: false,
+		},
+		{
+			desc:  "save number below minimum",
+			attr:  temperature,
+			rec:   senml.Record{Name: "temperature", Value: num(-50)},
+			saved: false,
+		},
+		{
+			desc:  "save number above maximum",
+			attr:  temperature,
+			rec:   senml.Record{Name: "temperature", Value: num(150)},
+			saved: false,
+		},
+		{
+			desc:  "save number with wrong unit",
+			attr:  temperature,
+			rec:   senml.Record{Name: "temperature", Unit: "K", Value: num(300)},
+			saved: false,
+		},
+		{
+			desc:  "save allowed string",
+			attr:  mode,
+			rec:   senml.Record{Name: "mode", StringValue: str("eco")},
+			saved: true,
+		},
+		{
+			desc:  "save string not in enum",
+			attr:  mode,
+			rec:   senml.Record{Name: "mode", StringValue: str("turbo")},
+			saved: false,
+		},
+	}
+
+	for _, tc := range cases {
+		saved := false
+		message, err := mocks.CreateMessage(domainID, tc.attr, []senml.Record{tc.rec})
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		cacheCall := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return([]string{twin.ID}, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+		stateCall := stateRepo.On("RetrieveLast", context.Background(), twin.ID).Return(twins.State{}, nil)
+		stateCall1 := stateRepo.On("Save", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			saved = true
+		}).Return(nil)
+		err = svc.SaveStates(context.Background(), message)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.saved, saved, fmt.Sprintf("%s: expected saved %t got %t", tc.desc, tc.saved, saved))
+		cacheCall.Unset()
+		repoCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+	}
+}
+
+func TestSaveStatesJSONPayload(t *testing.T) {
+	svc, _, _, _, twinRepo, twinCache, stateRepo, _, _ := NewService()
+
+	temperature := twins.Attribute{
+		Name:         "temperature",
+		Channel:      channels[0],
+		Subtopic:     subtopics[0],
+		Path:         "/sensors/0/temperature",
+		TimePath:     "/time",
+		PersistState: true,
+	}
+	mode := twins.Attribute{
+		Name:         "mode",
+		Channel:      channels[0],
+		Subtopic:     subtopics[1],
+		Path:         "/config/a~1b",
+		PersistState: true,
+	}
+	twin := twins.Twin{
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{{Attributes: []twins.Attribute{temperature, mode}, Delta: 1}},
+	}
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		desc    string
+		attr    twins.Attribute
+		payload string
+		value   interface{}
+		created time.Time
+		err     bool
+	}{
+		{
+			desc:    "save value with timestamp",
+			attr:    temperature,
+			payload: `{"time": "2024-05-01T10:00:00Z", "sensors": [{"temperature": 21.5}]}`,
+			value:   21.5,
+			created: created,
+		},
+		{
+			desc:    "save value with numeric timestamp",
+			attr:    temperature,
+			payload: fmt.Sprintf(`{"time": %d, "sensors": [{"temperature": 22}]}`, created.Unix()),
+			value:   22.0,
+			created: created,
+		},
+		{
+			desc:    "save value with escaped pointer",
+			attr:    mode,
+			payload: `{"config": {"a/b": "eco"}}`,
+			value:   "eco",
+		},
+		{
+			desc:    "save value missing from payload",
+			attr:    temperature,
+			payload: `{"time": "2024-05-01T10:00:00Z", "sensors": []}`,
+			err:     true,
+		},
+		{
+			desc:    "save value with invalid timestamp",
+			attr:    temperature,
+			payload: `{"time": "yesterday", "sensors": [{"temperature": 21.5}]}`,
+			err:     true,
+		},
+		{
+			desc:    "save SenML payload",
+			attr:    mode,
+			payload: `[{"n": "mode", "vs": "eco"}]`,
+			err:     true,
+		},
+	}
+
+	for _, tc := range cases {
+		var saved twins.State
+		message := &messaging.Message{
+			Domain:    domainID,
+			Channel:   tc.attr.Channel,
+			Subtopic:  tc.attr.Subtopic,
+			Payload:   []byte(tc.payload),
+			Publisher: validID,
+		}
+
+		cacheCall := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return([]string{twin.ID}, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+		stateCall := stateRepo.On("RetrieveLast", context.Background(), twin.ID).Return(twins.State{}, nil)
+		stateCall1 := stateRepo.On("Save", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			states := args.Get(1).([]twins.State)
+			saved = states[len(states)-1]
+		}).Return(nil)
+		err := svc.SaveStates(context.Background(), message)
+		assert.Equal(t, tc.err, err != nil, fmt.Sprintf("%s: expected error %t got %s", tc.desc, tc.err, err))
+		if !tc.err {
+			val := saved.Payload[tc.attr.Name]
+			switch v := val.(type) {
+			case *float64:
+				val = *v
+			case *string:
+				val = *v
+			}
+			assert.Equal(t, tc.value, val, fmt.Sprintf("%s: expected value %v got %v", tc.desc, tc.value, val))
+			if !tc.created.IsZero() {
+				assert.True(t, tc.created.Equal(saved.Created), fmt.Sprintf("%s: expected created %s got %s", tc.desc, tc.created, saved.Created))
+			}
+		}
+		cacheCall.Unset()
+		repoCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+	}
+}
+
+func TestSaveStatesJSONPayloadAttributes(t *testing.T) {
+	svc, _, _, _, twinRepo, twinCache, stateRepo, _, _ := NewService()
+
+	temperature := twins.Attribute{
+		Name:         "temperature",
+		Channel:      channels[0],
+		Subtopic:     subtopics[0],
+		Path:         "/temperature",
+		PersistState: true,
+	}
+	humidity := twins.Attribute{
+		Name:         "humidity",
+		Channel:      channels[0],
+		Subtopic:     subtopics[0],
+		Path:         "/humidity",
+		PersistState: true,
+	}
+	twin := twins.Twin{
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{{Attributes: []twins.Attribute{temperature, humidity}, Delta: int64(time.Millisecond)}},
+	}
+
+	cases := []struct {
+		desc    string
+		payload string
+		states  int
+		values  map[string]interface{}
+		err     bool
+	}{
+		{
+			desc:    "save values of attributes bound to the same subtopic",
+			payload: `{"temperature": 21.5, "humidity": 40}`,
+			states:  1,
+			values:  map[string]interface{}{"temperature": 21.5, "humidity": 40.0},
+		},
+		{
+			desc:    "save value of one of attributes bound to the same subtopic",
+			payload: `{"humidity": 45}`,
+			states:  1,
+			values:  map[string]interface{}{"humidity": 45.0},
+		},
+		{
+			desc:    "save values missing from payload",
+			payload: `{"pressure": 1.2}`,
+			err:     true,
+		},
+	}
+
+	for _, tc := range cases {
+		var saved []twins.State
+		message := &messaging.Message{
+			Domain:    domainID,
+			Channel:   channels[0],
+			Subtopic:  subtopics[0],
+			Payload:   []byte(tc.payload),
+			Publisher: validID,
+			Created:   time.Now().UnixNano(),
+		}
+
+		cacheCall := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return([]string{twin.ID}, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+		stateCall := stateRepo.On("RetrieveLast", context.Background(), twin.ID).Return(twins.State{}, nil)
+		stateCall1 := stateRepo.On("Save", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).([]twins.State)
+		}).Return(nil)
+		err := svc.SaveStates(context.Background(), message)
+		assert.Equal(t, tc.err, err != nil, fmt.Sprintf("%s: expected error %t got %s", tc.desc, tc.err, err))
+		assert.Len(t, saved, tc.states, fmt.Sprintf("%s: expected %d saved states got %d", tc.desc, tc.states, len(saved)))
+		if len(saved) > 0 {
+			values := map[string]interface{}{}
+			for name, val := range saved[0].Payload {
+				if v, ok := val.(*float64); ok {
+					val = *v
+				}
+				values[name] = val
+			}
+			assert.Equal(t, tc.values, values, fmt.Sprintf("%s: expected values %v got %v", tc.desc, tc.values, values))
+		}
+		cacheCall.Unset()
+		repoCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+	}
+}
+
+func TestSaveStatesSubtopicPattern(t *testing.T) {
+	svc, _, _, _, twinRepo, twinCache, stateRepo, _, _ := NewService()
+
+	cases := []struct {
+		desc     string
+		pattern  string
+		subtopic string
+		saved    bool
+	}{
+		{
+			desc:     "save state for subtopic matching single-level wildcard",
+			pattern:  "sensors.*.temp",
+			subtopic: "sensors.room_1.temp",
+			saved:    true,
+		},
+		{
+			desc:     "save state for subtopic matching plus wildcard",
+			pattern:  "sensors.+.temp",
+			subtopic: "sensors.room_1.temp",
+			saved:    true,
+		},
+		{
+			desc:     "save state for subtopic with extra level",
+			pattern:  "sensors.*.temp",
+			subtopic: "sensors.room_1.temp.max",
+			saved:    false,
+		},
+		{
+			desc:     "save state for subtopic matching multi-level wildcard",
+			pattern:  "sensors.>",
+			subtopic: "sensors.room_1.temp",
+			saved:    true,
+		},
+		{
+			desc:     "save state for subtopic matching hash wildcard",
+			pattern:  "sensors.#",
+			subtopic: "sensors.room_1",
+			saved:    true,
+		},
+		{
+			desc:     "save state for parent of multi-level wildcard",
+			pattern:  "sensors.>",
+			subtopic: "sensors",
+			saved:    false,
+		},
+		{
+			desc:     "save state for empty subtopic",
+			pattern:  "sensors.*",
+			subtopic: "",
+			saved:    false,
+		},
+	}
+
+	for _, tc := range cases {
+		saved := false
+		attr := twins.Attribute{Name: "temperature", Channel: channels[0], Subtopic: tc.pattern, PersistState: true}
+		twin := twins.Twin{
+			Domain:      domainID,
+			ID:          testsutil.GenerateUUID(t),
+			Definitions: []twins.Definition{{Attributes: []twins.Attribute{attr}}},
+		}
+		val := 21.5
+		message, err := mocks.CreateMessage(domainID, twins.Attribute{Channel: attr.Channel, Subtopic: tc.subtopic}, []senml.Record{{Name: attr.Name, Value: &val}})
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		cacheCall := twinCache.On("IDs", context.Background(), attr.Channel, tc.subtopic).Return([]string{twin.ID}, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+		stateCall := stateRepo.On("RetrieveLast", context.Background(), twin.ID).Return(twins.State{}, nil)
+		stateCall1 := stateRepo.On("Save", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			saved = true
+		}).Return(nil)
+		err = svc.SaveStates(context.Background(), message)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.saved, saved, fmt.Sprintf("%s: expected saved %t got %t", tc.desc, tc.saved, saved))
+		cacheCall.Unset()
+		repoCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+	}
+}
+
+func TestSaveStatesIngest(t *testing.T) {
+	twinRepo := new(mocks.TwinRepository)
+	twinCache := new(mocks.TwinCache)
+	stateRepo := new(mocks.StateRepository)
+	ingest := twins.IngestConfig{Workers: 4, QueueSize: 16, BatchSize: 10, ViewTTL: time.Minute}
+	svc := twins.New(mocks.NewBroker(map[string]string{"chanID": "chanID"}), nil, nil, nil, twinRepo, twinCache, stateRepo, nil, nil, nil, uuid.NewMock(), "chanID", ingest, smqlog.NewMock())
+
+	attr := twins.Attribute{Name: "temperature", Channel: channels[0], Subtopic: subtopics[0], PersistState: true}
+	twin := twins.Twin{
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{{Attributes: []twins.Attribute{attr}}},
+	}
+
+	var mu sync.Mutex
+	var saved []twins.State
+	done := make(chan struct{})
+	cacheCall := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return([]string{twin.ID}, nil)
+	repoCall := twinRepo.On("RetrieveByID", mock.Anything, twin.ID).Return(twin, nil)
+	stateCall := stateRepo.On("RetrieveLast", mock.Anything, twin.ID).Return(twins.State{}, nil)
+	stateCall1 := stateRepo.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		mu.Lock()
+		defer mu.Unlock()
+		saved = append(saved, args.Get(1).([]twins.State)...)
+		if len(saved) == numRecs {
+			close(done)
+		}
+	}).Return(nil)
+	defer func() {
+		cacheCall.Unset()
+		repoCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+	}()
+
+	for i := 0; i < numRecs; i++ {
+		val := float64(i)
+		message, err := mocks.CreateMessage(domainID, attr, []senml.Record{{Name: attr.Name, Value: &val}})
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		err = svc.SaveStates(context.Background(), message)
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the states to be saved")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i, st := range saved {
+		assert.Equal(t, int64(i), st.ID, fmt.Sprintf("expected state ID %d got %d", i, st.ID))
+		val := st.Payload[attr.Name].(*float64)
+		assert.Equal(t, float64(i), *val, fmt.Sprintf("expected value %d got %v", i, *val))
+	}
+	twinRepo.AssertNumberOfCalls(t, "RetrieveByID", 1)
+	stateRepo.AssertNumberOfCalls(t, "RetrieveLast", 1)
+}
+
+func TestDrainStates(t *testing.T) {
+	twinRepo := new(mocks.TwinRepository)
+	twinCache := new(mocks.TwinCache)
+	stateRepo := new(mocks.StateRepository)
+	failed := generic.NewCounter("failed")
+	ingest := twins.IngestConfig{Workers: 4, QueueSize: numRecs, BatchSize: 10, ViewTTL: time.Minute, Failed: failed}
+	svc := twins.New(mocks.NewBroker(map[string]string{"chanID": "chanID"}), nil, nil, nil, twinRepo, twinCache, stateRepo, nil, nil, nil, uuid.NewMock(), "chanID", ingest, smqlog.NewMock())
+
+	attr := twins.Attribute{Name: "temperature", Channel: channels[0], Subtopic: subtopics[0], PersistState: true}
+	twin := twins.Twin{
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{{Attributes: []twins.Attribute{attr}}},
+	}
+
+	cacheCall := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return([]string{twin.ID}, nil)
+	repoCall := twinRepo.On("RetrieveByID", mock.Anything, twin.ID).Return(twin, nil)
+	stateCall := stateRepo.On("RetrieveLast", mock.Anything, twin.ID).Return(twins.State{}, nil)
+	stateCall1 := stateRepo.On("Save", mock.Anything, mock.Anything).Return(repoerr.ErrCreateEntity)
+	defer func() {
+		cacheCall.Unset()
+		repoCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+	}()
+
+	for i := 0; i < numRecs; i++ {
+		val := float64(i)
+		message, err := mocks.CreateMessage(domainID, attr, []senml.Record{{Name: attr.Name, Value: &val}})
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+		err = svc.SaveStates(context.Background(), message)
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := svc.DrainStates(ctx)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, float64(numRecs), failed.Value(), fmt.Sprintf("expected %d failed messages got %v", numRecs, failed.Value()))
+
+	val := float64(numRecs)
+	message, err := mocks.CreateMessage(domainID, attr, []senml.Record{{Name: attr.Name, Value: &val}})
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	err = svc.SaveStates(context.Background(), message)
+	assert.NotNil(t, err, "expected message received after drain to be rejected")
+}
+
+func TestSaveStatesAlarms(t *testing.T) {
+	twinRepo := new(mocks.TwinRepository)
+	twinCache := new(mocks.TwinCache)
+	stateRepo := new(mocks.StateRepository)
+	ingest := twins.IngestConfig{ViewTTL: time.Minute}
+	svc := twins.New(mocks.NewBroker(map[string]string{"chanID": "chanID"}), nil, nil, nil, twinRepo, twinCache, stateRepo, nil, nil, nil, uuid.NewMock(), "chanID", ingest, smqlog.NewMock())
+
+	attr := twins.Attribute{
+		Name:     "temperature",
+		Channel:  channels[0],
+		Subtopic: subtopics[0],
+		Alarms: []twins.AlarmRule{
+			{Name: "overheat", Condition: twins.GreaterCondition, Severity: twins.CriticalSeverity, Threshold: 80, Hysteresis: 5},
+			{Name: "spike", Condition: twins.RateCondition, Severity: twins.WarningSeverity, Threshold: 1, Hysteresis: 0.5},
+			{Name: "stuck", Condition: twins.StuckCondition, Severity: twins.InfoSeverity, Duration: int64(30 * time.Second), Hysteresis: 0.1},
+		},
+		PersistState: true,
+	}
+	twin := twins.Twin{
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{{Attributes: []twins.Attribute{attr}, Delta: 1}},
+	}
+
+	var saved []twins.Alarm
+	cacheCall := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return([]string{twin.ID}, nil)
+	repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+	stateCall := stateRepo.On("RetrieveLast", context.Background(), twin.ID).Return(twins.State{}, nil)
+	stateCall1 := stateRepo.On("RetrieveActiveAlarms", context.Background(), twin.ID).Return([]twins.Alarm{}, nil)
+	stateCall2 := stateRepo.On("Save", context.Background(), mock.Anything).Return(nil)
+	stateCall3 := stateRepo.On("SaveAlarm", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, args.Get(1).(twins.Alarm))
+	}).Return(nil)
+	defer func() {
+		cacheCall.Unset()
+		repoCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+		stateCall2.Unset()
+		stateCall3.Unset()
+	}()
+
+	cases := []struct {
+		desc   string
+		value  float64
+		alarms []string
+	}{
+		{
+			desc:  "save value within limits",
+			value: 70,
+		},
+		{
+			desc:   "save value above threshold with fast change",
+			value:  85,
+			alarms: []string{"overheat:" + twins.RaisedStatus, "spike:" + twins.RaisedStatus},
+		},
+		{
+			desc:  "save value below threshold within hysteresis",
+			value: 78,
+		},
+		{
+			desc:   "save value below threshold past hysteresis",
+			value:  74,
+			alarms: []string{"overheat:" + twins.ClearedStatus, "spike:" + twins.ClearedStatus},
+		},
+		{
+			desc:  "save value changed within stuck hysteresis",
+			value: 74.05,
+		},
+		{
+			desc:  "save unchanged value before stuck duration",
+			value: 74,
+		},
+		{
+			desc:   "save unchanged value after stuck duration",
+			value:  74,
+			alarms: []string{"stuck:" + twins.RaisedStatus},
+		},
+		{
+			desc:   "save changed value",
+			value:  76,
+			alarms: []string{"stuck:" + twins.ClearedStatus},
+		},
+	}
+
+	start := float64(time.Now().Unix())
+	for i, tc := range cases {
+		saved = nil
+		val := tc.value
+		message, err := mocks.CreateMessage(domainID, attr, []senml.Record{{Name: attr.Name, Time: start + float64(i*10), Value: &val}})
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		err = svc.SaveStates(context.Background(), message)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+		var alarms []string
+		for _, a := range saved {
+			alarms = append(alarms, a.Rule+":"+a.Status)
+			assert.Equal(t, twin.ID, a.TwinID, fmt.Sprintf("%s: expected twin %s got %s", tc.desc, twin.ID, a.TwinID))
+			assert.Equal(t, a.Status == twins.ClearedStatus, a.Cleared != nil, fmt.Sprintf("%s: unexpected clearing time of %s alarm", tc.desc, a.Status))
+		}
+		assert.ElementsMatch(t, tc.alarms, alarms, fmt.Sprintf("%s: expected alarms %v got %v", tc.desc, tc.alarms, alarms))
+	}
+	twinRepo.AssertNumberOfCalls(t, "RetrieveByID", 1)
+}
+
+func TestSaveStatesAlarmsDroppedValues(t *testing.T) {
+	twinRepo := new(mocks.TwinRepository)
+	twinCache := new(mocks.TwinCache)
+	stateRepo := new(mocks.StateRepository)
+	ingest := twins.IngestConfig{ViewTTL: time.Minute}
+	svc := twins.New(mocks.NewBroker(map[string]string{"chanID": "chanID"}), nil, nil, nil, twinRepo, twinCache, stateRepo, nil, nil, nil, uuid.NewMock(), "chanID", ingest, smqlog.NewMock())
+
+	temperature := twins.Attribute{
+		Name:        "temperature",
+		Channel:     channels[0],
+		Subtopic:    subtopics[0],
+		Persistence: &twins.Persistence{Policy: twins.ChangePolicy},
+		Alarms: []twins.AlarmRule{
+			{Name: "overheat", Condition: twins.GreaterCondition, Severity: twins.CriticalSeverity, Threshold: 80},
+			{Name: "stuck", Condition: twins.StuckCondition, Severity: twins.InfoSeverity, Duration: int64(30 * time.Second)},
+		},
+		PersistState: true,
+	}
+	pressure := twins.Attribute{
+		Name:        "pressure",
+		Channel:     channels[0],
+		Subtopic:    subtopics[1],
+		Persistence: &twins.Persistence{Policy: twins.DeadbandPolicy, Deadband: 10},
+		Alarms: []twins.AlarmRule{
+			{Name: "overpressure", Condition: twins.GreaterCondition, Severity: twins.CriticalSeverity, Threshold: 80},
+		},
+		PersistState: true,
+	}
+	twin := twins.Twin{
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{{Attributes: []twins.Attribute{temperature, pressure}, Delta: 1}},
+	}
+
+	var saved []twins.Alarm
+	var states int
+	cacheCall := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return([]string{twin.ID}, nil)
+	repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+	stateCall := stateRepo.On("RetrieveLast", context.Background(), twin.ID).Return(twins.State{}, nil)
+	stateCall1 := stateRepo.On("RetrieveActiveAlarms", context.Background(), twin.ID).Return([]twins.Alarm{}, nil)
+	stateCall2 := stateRepo.On("Save", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+		states++
+	}).Return(nil)
+	stateCall3 := stateRepo.On("SaveAlarm", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, args.Get(1).(twins.Alarm))
+	}).Return(nil)
+	defer func() {
+		cacheCall.Unset()
+		repoCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+		stateCall2.Unset()
+		stateCall3.Unset()
+	}()
+
+	cases := []struct {
+		desc   string
+		attr   twins.Attribute
+		value  float64
+		states int
+		alarms []string
+	}{
+		{
+			desc:   "save changed value",
+			attr:   temperature,
+			value:  70,
+			states: 1,
+		},
+		{
+			desc:  "drop unchanged value before stuck duration",
+			attr:  temperature,
+			value: 70,
+		},
+		{
+			desc:   "drop unchanged value after stuck duration",
+			attr:   temperature,
+			value:  70,
+			alarms: []string{"stuck:" + twins.RaisedStatus},
+		},
+		{
+			desc:   "save value outside deadband",
+			attr:   pressure,
+			value:  75,
+			states: 1,
+		},
+		{
+			desc:   "drop value within deadband above threshold",
+			attr:   pressure,
+			value:  82,
+			alarms: []string{"overpressure:" + twins.RaisedStatus},
+		},
+		{
+			desc:   "drop value within deadband below threshold",
+			attr:   pressure,
+			value:  78,
+			alarms: []string{"overpressure:" + twins.ClearedStatus},
+		},
+	}
+
+	start := float64(time.Now().Unix())
+	for i, tc := range cases {
+		saved, states = nil, 0
+		val := tc.value
+		message, err := mocks.CreateMessage(domainID, tc.attr, []senml.Record{{Name: tc.attr.Name, Time: start + float64(i*20), Value: &val}})
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		err = svc.SaveStates(context.Background(), message)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.states, states, fmt.Sprintf("%s: expected %d saved states got %d", tc.desc, tc.states, states))
+
+		var alarms []string
+		for _, a := range saved {
+			alarms = append(alarms, a.Rule+":"+a.Status)
+		}
+		assert.ElementsMatch(t, tc.alarms, alarms, fmt.Sprintf("%s: expected alarms %v got %v", tc.desc, tc.alarms, alarms))
+	}
+}
+
+func TestSaveStatesLiveness(t *testing.T) {
+	svc, _, _, _, twinRepo, twinCache, stateRepo, _, _ := NewService()
+
+	attr := twins.Attribute{Name: "temperature", Channel: channels[0], Subtopic: subtopics[0], UpdateInterval: int64(time.Minute), PersistState: true}
+	other := twins.Attribute{Name: "humidity", Channel: channels[0], Subtopic: subtopics[1], UpdateInterval: int64(time.Minute), PersistState: true}
+	twin := twins.Twin{
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{{Attributes: []twins.Attribute{attr, other}, Delta: int64(time.Hour)}},
+	}
+	old := time.Now().Add(-time.Hour)
+	last := twins.State{
+		TwinID:  twin.ID,
+		Created: time.Now(),
+		Payload: map[string]interface{}{"temperature": 21.5, "humidity": 40.0},
+		Updated: map[string]time.Time{"temperature": old, "humidity": old},
+		Stale:   []string{"temperature", "humidity"},
+	}
+
+	var updated twins.State
+	cacheCall := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return([]string{twin.ID}, nil)
+	repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+	stateCall := stateRepo.On("RetrieveLast", context.Background(), twin.ID).Return(last, nil)
+	stateCall1 := stateRepo.On("Update", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+		updated = args.Get(1).(twins.State)
+	}).Return(nil)
+	defer func() {
+		cacheCall.Unset()
+		repoCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+	}()
+
+	val := 22.5
+	message, err := mocks.CreateMessage(domainID, attr, []senml.Record{{Name: attr.Name, Time: float64(time.Now().Unix()), Value: &val}})
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	err = svc.SaveStates(context.Background(), message)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Equal(t, []string{"humidity"}, updated.Stale, fmt.Sprintf("expected stale attributes %v got %v", []string{"humidity"}, updated.Stale))
+	assert.True(t, updated.Updated["temperature"].After(old), fmt.Sprintf("expected temperature update after %s got %s", old, updated.Updated["temperature"]))
+	assert.Equal(t, old, updated.Updated["humidity"], fmt.Sprintf("expected humidity update %s got %s", old, updated.Updated["humidity"]))
+	assert.Equal(t, []string{"temperature", "humidity"}, last.Stale, "expected last state not to be changed")
+}
+
+func TestSaveStatesLivenessWithoutStates(t *testing.T) {
+	svc, _, _, _, twinRepo, twinCache, stateRepo, _, _ := NewService()
+
+	attr := twins.Attribute{Name: "temperature", Channel: channels[0], Subtopic: subtopics[0], UpdateInterval: int64(time.Minute), PersistState: true}
+	other := twins.Attribute{Name: "humidity", Channel: channels[0], Subtopic: subtopics[1], UpdateInterval: int64(time.Minute), PersistState: true}
+	twin := twins.Twin{
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{{Attributes: []twins.Attribute{attr, other}, Delta: int64(time.Hour)}},
+		Liveness:    &twins.Liveness{Stale: []string{"temperature", "humidity"}, Checked: time.Now()},
+	}
+
+	var saved []twins.State
+	cacheCall := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return([]string{twin.ID}, nil)
+	repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+	stateCall := stateRepo.On("RetrieveLast", context.Background(), twin.ID).Return(twins.State{}, nil)
+	stateCall1 := stateRepo.On("Save", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, args.Get(1).([]twins.State)...)
+	}).Return(nil)
+	defer func() {
+		cacheCall.Unset()
+		repoCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+	}()
+
+	val := 22.5
+	message, err := mocks.CreateMessage(domainID, attr, []senml.Record{{Name: attr.Name, Time: float64(time.Now().Unix()), Value: &val}})
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+	err = svc.SaveStates(context.Background(), message)
+	assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+	assert.Len(t, saved, 1, fmt.Sprintf("expected one saved state got %d", len(saved)))
+	if len(saved) == 1 {
+		assert.Equal(t, []string{"humidity"}, saved[0].Stale, fmt.Sprintf("expected stale attributes %v got %v", []string{"humidity"}, saved[0].Stale))
+	}
+	assert.Equal(t, []string{"temperature", "humidity"}, twin.Liveness.Stale, "expected twin liveness not to be changed")
+}
+
+func TestSaveStatesPersistence(t *testing.T) {
+	twinRepo := new(mocks.TwinRepository)
+	twinCache := new(mocks.TwinCache)
+	stateRepo := new(mocks.StateRepository)
+	ingest := twins.IngestConfig{ViewTTL: time.Minute}
+	svc := twins.New(mocks.NewBroker(map[string]string{"chanID": "chanID"}), nil, nil, nil, twinRepo, twinCache, stateRepo, nil, nil, nil, uuid.NewMock(), "chanID", ingest, smqlog.NewMock())
+
+	temperature := twins.Attribute{Name: "temperature", Channel: channels[0], Subtopic: subtopics[0], Persistence: &twins.Persistence{Policy: twins.DeadbandPolicy, Deadband: 1}, PersistState: true}
+	level := twins.Attribute{Name: "level", Channel: channels[0], Subtopic: subtopics[1], Persistence: &twins.Persistence{Policy: twins.DeadbandPolicy, Deadband: 10, Percent: true}, PersistState: true}
+	mode := twins.Attribute{Name: "mode", Channel: channels[0], Subtopic: subtopics[2], Type: twins.StringType, UpdateInterval: int64(time.Minute), Persistence: &twins.Persistence{Policy: twins.ChangePolicy}, PersistState: true}
+	counter := twins.Attribute{Name: "counter", Channel: channels[1], Subtopic: subtopics[0], Persistence: &twins.Persistence{Policy: twins.AlwaysPolicy}, PersistState: true}
+	humidity := twins.Attribute{Name: "humidity", Channel: channels[1], Subtopic: subtopics[1], PersistState: true}
+	twin := twins.Twin{
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{{Attributes: []twins.Attribute{temperature, level, mode, counter, humidity}, Delta: int64(time.Hour)}},
+	}
+
+	var saved, updated []twins.State
+	cacheCall := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return([]string{twin.ID}, nil)
+	repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+	stateCall := stateRepo.On("RetrieveLast", context.Background(), twin.ID).Return(twins.State{}, nil)
+	stateCall1 := stateRepo.On("RetrieveActiveAlarms", context.Background(), twin.ID).Return([]twins.Alarm{}, nil)
+	stateCall2 := stateRepo.On("Save", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+		saved = append(saved, args.Get(1).([]twins.State)...)
+	}).Return(nil)
+	stateCall3 := stateRepo.On("Update", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+		updated = append(updated, args.Get(1).(twins.State))
+	}).Return(nil)
+	defer func() {
+		cacheCall.Unset()
+		repoCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+		stateCall2.Unset()
+		stateCall3.Unset()
+	}()
+
+	cases := []struct {
+		desc    string
+		attr    twins.Attribute
+		value   interface{}
+		saved   bool
+		updated bool
+	}{
+		{
+			desc:  "save first value of deadband attribute",
+			attr:  temperature,
+			value: 20.0,
+			saved: true,
+		},
+		{
+			desc:  "save value within deadband",
+			attr:  temperature,
+			value: 20.5,
+		},
+		{
+			desc:  "save value outside deadband",
+			attr:  temperature,
+			value: 21.5,
+			saved: true,
+		},
+		{
+			desc:  "save first value of percent deadband attribute",
+			attr:  level,
+			value: 50.0,
+			saved: true,
+		},
+		{
+			desc:  "save value within percent deadband",
+			attr:  level,
+			value: 54.0,
+		},
+		{
+			desc:  "save value outside percent deadband",
+			attr:  level,
+			value: 56.0,
+			saved: true,
+		},
+		{
+			desc:  "save first value of on-change attribute",
+			attr:  mode,
+			value: "eco",
+			saved: true,
+		},
+		{
+			desc:    "save unchanged value of on-change attribute with liveness",
+			attr:    mode,
+			value:   "eco",
+			updated: true,
+		},
+		{
+			desc:  "save changed value of on-change attribute",
+			attr:  mode,
+			value: "comfort",
+			saved: true,
+		},
+		{
+			desc:  "save value of always attribute",
+			attr:  counter,
+			value: 1.0,
+			saved: true,
+		},
+		{
+			desc:  "save same value of always attribute",
+			attr:  counter,
+			value: 1.0,
+			saved: true,
+		},
+		{
+			desc:    "save value within definition delta",
+			attr:    humidity,
+			value:   40.0,
+			updated: true,
+		},
+	}
+
+	start := float64(time.Now().Unix())
+	for i, tc := range cases {
+		saved, updated = nil, nil
+		rec := senml.Record{Name: tc.attr.Name, Time: start + float64(i*10)}
+		switch v := tc.value.(type) {
+		case string:
+			rec.StringValue = &v
+		case float64:
+			rec.Value = &v
+		}
+		message, err := mocks.CreateMessage(domainID, tc.attr, []senml.Record{rec})
+		assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+
+		err = svc.SaveStates(context.Background(), message)
+		assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+		assert.Equal(t, tc.saved, len(saved) > 0, fmt.Sprintf("%s: expected state saved %t got %t", tc.desc, tc.saved, len(saved) > 0))
+		assert.Equal(t, tc.updated, len(updated) > 0, fmt.Sprintf("%s: expected state updated %t got %t", tc.desc, tc.updated, len(updated) > 0))
+		if tc.saved && len(saved) > 0 {
+			val := saved[0].Payload[tc.attr.Name]
+			switch v := val.(type) {
+			case *float64:
+				val = *v
+			case *string:
+				val = *v
+			}
+			assert.Equal(t, tc.value, val, fmt.Sprintf("%s: expected value %v got %v", tc.desc, tc.value, val))
+		}
+	}
+}
+
+func TestListAlarms(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, stateRepo, _, _ := NewService()
+
+	twin := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{mocks.CreateDefinition(channels[0:1], subtopics[0:1])},
+	}
+	cleared := time.Now()
+	page := twins.AlarmsPage{
+		PageMetadata: twins.PageMetadata{Total: 2, Limit: 10},
+		Alarms: []twins.Alarm{
+			{ID: testsutil.GenerateUUID(t), TwinID: twin.ID, Rule: "overheat", Status: twins.RaisedStatus, Raised: cleared},
+			{ID: testsutil.GenerateUUID(t), TwinID: twin.ID, Rule: "overheat", Status: twins.ClearedStatus, Raised: cleared.Add(-time.Hour), Cleared: &cleared},
+		},
+	}
+
+	cases := []struct {
+		desc        string
+		token       string
+		filter      twins.AlarmFilter
+		page        twins.AlarmsPage
+		identifyErr error
+		retrieveErr error
+		err         error
+	}{
+		{
+			desc:  "list alarms",
+			token: token,
+			page:  page,
+		},
+		{
+			desc:   "list raised alarms",
+			token:  token,
+			filter: twins.AlarmFilter{Status: twins.RaisedStatus},
+			page:   twins.AlarmsPage{Alarms: page.Alarms[:1]},
+		},
+		{
+			desc:        "list alarms with invalid token",
+			token:       invalidToken,
+			identifyErr: svcerr.ErrAuthentication,
+			err:         svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "list alarms with failed retrieval",
+			token:       token,
+			retrieveErr: repoerr.ErrViewEntity,
+			err:         repoerr.ErrViewEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, tc.token).Return(smqauthn.Session{UserID: validID}, tc.identifyErr)
+		authzCall := authorizeCall(authz, nil, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+		stateCall := stateRepo.On("RetrieveAlarms", context.Background(), uint64(0), uint64(10), twin.ID, tc.filter).Return(tc.page, tc.retrieveErr)
+		res, err := svc.ListAlarms(context.Background(), tc.token, domainID, 0, 10, twin.ID, tc.filter)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s", tc.desc, tc.err, err))
+		assert.Equal(t, tc.page, res, fmt.Sprintf("%s: expected %v got %v", tc.desc, tc.page, res))
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		stateCall.Unset()
+	}
+}
+
+func TestListStates(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, stateRepo, _, _ := NewService()
+
+	def := mocks.CreateDefinition(channels[0:2], subtopics[0:2])
+	twin := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Name:        twinName,
+		Definitions: []twins.Definition{def},
+	}
+
+	tw2 := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		Definitions: []twins.Definition{mocks.CreateDefinition(channels[2:3], subtopics[2:3])},
+	}
+
+	defID := 0
+	now := time.Now()
+
+	cases := []struct {
+		desc        string
+		id          string
+		token       string
+		offset      uint64
+		limit       uint64
+		filter      twins.StateFilter
+		size        int
+		err         error
+		page        twins.StatesPage
+		retrieveErr error
+		identifyErr error
+		adminErr    error
+		userID      string
+	}{
+		{
+			desc:   "get a list of first 10 states",
+			id:     twin.ID,
+			token:  token,
+			offset: 0,
+			limit:  10,
+			size:   10,
+			err:    nil,
+			page: twins.StatesPage{
+				States: genStates(10),
+			},
+			identifyErr: nil,
+			userID:      validID,
+		},
+		{
+			desc:   "get a list of last 10 states",
+			id:     twin.ID,
+			token:  token,
+			offset: numRecs - 10,
+			limit:  numRecs,
+			size:   10,
+			err:    nil,
+			page: twins.StatesPage{
+				States: genStates(10),
+			},
+			identifyErr: nil,
+			userID:      validID,
+		},
+		{
+			desc:   "get a list of last 10 states with limit > numRecs",
+			id:     twin.ID,
+			token:  token,
+			offset: numRecs - 10,
+			limit:  numRecs + 10,
+			size:   10,
+			err:    nil,
+			page: twins.StatesPage{
+				States: genStates(10),
+			},
+			identifyErr: nil,
+			userID:      validID,
+		},
+		{
+			desc:   "get a list of states filtered by period, definition and attribute",
+			id:     twin.ID,
+			token:  token,
+			offset: 0,
+			limit:  10,
+			filter: twins.StateFilter{
+				From:       now.Add(-time.Hour),
+				To:         now,
+				Definition: &defID,
+				Attribute:  "temperature",
+				Dir:        "desc",
+			},
+			size: 5,
+			err:  nil,
+			page: twins.StatesPage{
+				States: genStates(5),
+			},
+			identifyErr: nil,
+			userID:      validID,
+		},
+		{
+			desc:        "get a list of first 10 states with offset == numRecs",
+			id:          twin.ID,
+			token:       token,
+			offset:      numRecs,
+			limit:       numRecs + 10,
+			size:        0,
+			err:         nil,
+			identifyErr: nil,
+			userID:      validID,
+		},
+		{
+			desc:        "get a list with wrong user token",
+			id:          twin.ID,
+			token:       invalidToken,
+			offset:      0,
+			limit:       10,
+			size:        0,
+			err:         svcerr.ErrAuthentication,
+			identifyErr: svcerr.ErrAuthentication,
+		},
+		{
+			desc:        "get a list with id of non-existent twin",
+			id:          "1234567890",
+			token:       token,
+			offset:      0,
+			limit:       10,
+			size:        0,
+			err:         svcerr.ErrNotFound,
+			retrieveErr: repoerr.ErrNotFound,
+			identifyErr: nil,
+			userID:      validID,
+		},
+		{
+			desc:     "get a list of states of twin not shared with user",
+			id:       twin.ID,
+			token:    token,
+			offset:   0,
+			limit:    10,
+			size:     0,
+			err:      svcerr.ErrAuthorization,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   validID,
+		},
+		{
+			desc:        "get a list with id of existing twin without states ",
+			id:          tw2.ID,
+			token:       token,
+			offset:      0,
+			limit:       10,
+			size:        0,
+			err:         nil,
+			identifyErr: nil,
+			userID:      validID,
+		},
+	}
+
+	for _, tc := range cases {
+		repoCall := auth.On("Authenticate", context.TODO(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := authorizeCall(authz, nil, tc.adminErr)
+		repoCall1 := stateRepo.On("RetrieveAll", context.TODO(), mock.Anything, mock.Anything, tc.id, tc.filter).Return(tc.page, nil)
+		repoCall2 := twinRepo.On("RetrieveByID", context.TODO(), tc.id).Return(twin, tc.retrieveErr)
+		page, err := svc.ListStates(context.TODO(), tc.token, domainID, tc.offset, tc.limit, tc.id, tc.filter)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.size, len(page.States), fmt.Sprintf("%s: expected %d total got %d total\n", tc.desc, tc.size, len(page.States)))
+		repoCall.Unset()
+		authzCall.Unset()
+		repoCall1.Unset()
+		repoCall2.Unset()
+	}
+}
+
+func genStates(length int) []twins.State {
+	states := make([]twins.State, length)
+	for i := range states {
+		states[i] = twins.State{}
+	}
+	return states
+}
+
+func TestUpdateDesiredState(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, stateRepo, _, _ := NewService()
+
+	def := mocks.CreateDefinition(channels[0:2], subtopics[0:2])
+	def.Attributes[0].Name = "temperature"
+	def.Attributes[0].Type = twins.NumberType
+	def.Attributes[1].Name = "pressure"
+	twin := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Name:        twinName,
+		Definitions: []twins.Definition{def},
+	}
+	temp, pressure := 21.5, 1.2
+	last := twins.State{
+		TwinID:  twin.ID,
+		Payload: map[string]interface{}{"temperature": &temp, "pressure": &pressure},
+	}
+
+	cases := []struct {
+		desc        string
+		id          string
+		token       string
+		payload     map[string]interface{}
+		delta       twins.Delta
+		err         error
+		retrieveErr error
+		saveErr     error
+		identifyErr error
+		adminErr    error
+		channelErr  error
+		role        string
+		userID      string
+	}{
+		{
+			desc:    "update desired state with changed attribute",
+			id:      twin.ID,
+			token:   token,
+			payload: map[string]interface{}{"temperature": 25.0, "pressure": pressure},
+			delta:   twins.Delta{"temperature": 25.0},
+			userID:  validID,
+		},
+		{
+			desc:    "update desired state matching reported state",
+			id:      twin.ID,
+			token:   token,
+			payload: map[string]interface{}{"temperature": temp},
+			delta:   twins.Delta{},
+			userID:  validID,
+		},
+		{
+			desc:    "update desired state with unknown attribute",
+			id:      twin.ID,
+			token:   token,
+			payload: map[string]interface{}{"humidity": 40.0},
+			err:     svcerr.ErrMalformedEntity,
+			userID:  validID,
+		},
+		{
+			desc:    "update desired state with value violating attribute schema",
+			id:      twin.ID,
+			token:   token,
+			payload: map[string]interface{}{"temperature": "hot"},
+			err:     svcerr.ErrMalformedEntity,
+			userID:  validID,
+		},
+		{
+			desc:        "update desired state of non-existing twin",
+			id:          wrongID,
+			token:       token,
+			payload:     map[string]interface{}{"temperature": 25.0},
+			err:         svcerr.ErrNotFound,
+			retrieveErr: repoerr.ErrNotFound,
+			userID:      validID,
+		},
+		{
+			desc:    "update desired state with failed save",
+			id:      twin.ID,
+			token:   token,
+			payload: map[string]interface{}{"temperature": 25.0},
+			err:     svcerr.ErrUpdateEntity,
+			saveErr: repoerr.ErrUpdateEntity,
+			userID:  validID,
+		},
+		{
+			desc:     "update desired state of twin shared with user as editor",
+			id:       twin.ID,
+			token:    token,
+			payload:  map[string]interface{}{"pressure": 1.5},
+			delta:    twins.Delta{"pressure": 1.5},
+			adminErr: svcerr.ErrAuthorization,
+			role:     twins.EditorRole,
+			userID:   validID,
+		},
+		{
+			desc:     "update desired state of twin not shared with user",
+			id:       twin.ID,
+			token:    token,
+			payload:  map[string]interface{}{"pressure": 1.5},
+			err:      svcerr.ErrAuthorization,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   testsutil.GenerateUUID(t),
+		},
+		{
+			desc:       "update desired state without publish permission on attribute channel",
+			id:         twin.ID,
+			token:      token,
+			payload:    map[string]interface{}{"temperature": 25.0},
+			err:        svcerr.ErrAuthorization,
+			channelErr: svcerr.ErrAuthorization,
+			userID:     validID,
+		},
+		{
+			desc:        "update desired state with wrong credentials",
+			id:          twin.ID,
+			token:       invalidToken,
+			payload:     map[string]interface{}{"temperature": 25.0},
+			err:         svcerr.ErrAuthentication,
+			identifyErr: svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := channelAccessCall(authz, tc.channelErr, nil, tc.adminErr, map[string]string{twin.ID: tc.role})
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), tc.id).Return(twin, tc.retrieveErr)
+		repoCall1 := stateRepo.On("SaveDesired", context.Background(), mock.Anything).Return(tc.saveErr)
+		repoCall2 := stateRepo.On("RetrieveLast", context.Background(), tc.id).Return(last, nil)
+		delta, err := svc.UpdateDesiredState(context.Background(), tc.token, domainID, tc.id, tc.payload)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, tc.delta, delta, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.delta, delta))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		repoCall2.Unset()
+	}
+}
+
+func TestStreamStates(t *testing.T) {
+	svc, auth, authz, _, twinRepo, twinCache, stateRepo, _, _ := NewService()
+
+	def := mocks.CreateDefinition(channels[0:2], subtopics[0:2])
+	def.Attributes[0].Name = "temperature"
+	def.Attributes[1].Name = "pressure"
+	twin := twins.Twin{
+		Owner:       validID,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{def},
+	}
+	temp, pressure := 21.5, 1.2
+	last := twins.State{
+		TwinID:  twin.ID,
+		Payload: map[string]interface{}{"pressure": &pressure},
+	}
+
+	cases := []struct {
+		desc        string
+		token       string
+		twinIDs     []string
+		attributes  []string
+		payload     map[string]interface{}
+		err         error
+		identifyErr error
+		adminErr    error
+		userID      string
+	}{
+		{
+			desc:    "stream states of twin",
+			token:   token,
+			twinIDs: []string{twin.ID},
+			payload: map[string]interface{}{"temperature": &temp, "pressure": &pressure},
+			userID:  validID,
+		},
+		{
+			desc:       "stream states of twin filtered by attribute",
+			token:      token,
+			twinIDs:    []string{twin.ID},
+			attributes: []string{"temperature"},
+			payload:    map[string]interface{}{"temperature": &temp},
+			userID:     validID,
+		},
+		{
+			desc:     "stream states of twin not shared with user",
+			token:    token,
+			twinIDs:  []string{twin.ID},
+			err:      svcerr.ErrAuthorization,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   testsutil.GenerateUUID(t),
+		},
+		{
+			desc:   "stream states without twins",
+			token:  token,
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:        "stream states with wrong credentials",
+			token:       invalidToken,
+			twinIDs:     []string{twin.ID},
+			err:         svcerr.ErrAuthentication,
+			identifyErr: svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", mock.Anything, tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := authorizeCall(authz, nil, tc.adminErr)
+		repoCall := twinRepo.On("RetrieveByID", mock.Anything, twin.ID).Return(twin, nil)
+		cacheCall := twinCache.On("IDs", context.Background(), mock.Anything, mock.Anything).Return([]string{twin.ID}, nil)
+		stateCall := stateRepo.On("RetrieveLast", context.Background(), twin.ID).Return(last, nil)
+		stateCall1 := stateRepo.On("Save", context.Background(), mock.Anything).Return(nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		events, err := svc.StreamStates(ctx, tc.token, domainID, tc.twinIDs, tc.attributes)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err == nil {
+			message, err := mocks.CreateMessage(domainID, def.Attributes[0], []senml.Record{{Name: "temperature", Value: &temp}})
+			assert.Nil(t, err, fmt.Sprintf("unexpected error: %s", err))
+			err = svc.SaveStates(context.Background(), message)
+			assert.Nil(t, err, fmt.Sprintf("%s: unexpected error: %s", tc.desc, err))
+
+			ev := <-events
+			assert.Equal(t, twins.StateCreated, ev.Type, fmt.Sprintf("%s: expected event %s got %s\n", tc.desc, twins.StateCreated, ev.Type))
+			assert.Equal(t, twin.ID, ev.TwinID, fmt.Sprintf("%s: expected twin %s got %s\n", tc.desc, twin.ID, ev.TwinID))
+			assert.Equal(t, tc.payload, ev.State.Payload, fmt.Sprintf("%s: expected payload %v got %v\n", tc.desc, tc.payload, ev.State.Payload))
+
+			cancel()
+			_, open := <-events
+			assert.False(t, open, fmt.Sprintf("%s: expected closed stream after cancellation\n", tc.desc))
+		}
+		cancel()
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		cacheCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+	}
+}
+
+func TestViewDelta(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, stateRepo, _, _ := NewService()
+
+	twinID := testsutil.GenerateUUID(t)
+	twin := twins.Twin{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     twinID,
+	}
+	on := true
+	desired := twins.DesiredState{
+		TwinID:  twinID,
+		Payload: map[string]interface{}{"valve": true, "mode": "eco"},
+	}
+	last := twins.State{
+		TwinID:  twinID,
+		Payload: map[string]interface{}{"valve": &on},
+	}
+
+	cases := []struct {
+		desc        string
+		id          string
+		token       string
+		delta       twins.Delta
+		err         error
+		retrieveErr error
+		identifyErr error
+		userID      string
+	}{
+		{
+			desc:   "view delta of twin with desired state",
+			id:     twinID,
+			token:  token,
+			delta:  twins.Delta{"mode": "eco"},
+			userID: validID,
+		},
+		{
+			desc:        "view delta of twin without desired state",
+			id:          wrongID,
+			token:       token,
+			err:         svcerr.ErrNotFound,
+			retrieveErr: repoerr.ErrNotFound,
+			userID:      validID,
+		},
+		{
+			desc:        "view delta with wrong credentials",
+			id:          twinID,
+			token:       invalidToken,
+			err:         svcerr.ErrAuthentication,
+			identifyErr: svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := authorizeCall(authz, nil, nil)
+		twinCall := twinRepo.On("RetrieveByID", context.Background(), tc.id).Return(twin, nil)
+		repoCall := stateRepo.On("RetrieveDesired", context.Background(), tc.id).Return(desired, tc.retrieveErr)
+		repoCall1 := stateRepo.On("RetrieveLast", context.Background(), tc.id).Return(last, nil)
+		delta, err := svc.ViewDelta(context.Background(), tc.token, domainID, tc.id)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.delta, delta, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.delta, delta))
+		authCall.Unset()
+		authzCall.Unset()
+		twinCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+func TestStateAt(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, stateRepo, _, _ := NewService()
+
+	created := time.Now().Add(-2 * time.Hour)
+	def0 := twins.Definition{ID: 0, Created: created}
+	def1 := mocks.CreateDefinition(channels[0:2], subtopics[0:2])
+	def1.ID = 1
+	def1.Created = created.Add(time.Hour)
+	twin := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Name:        twinName,
+		Created:     created,
+		Definitions: []twins.Definition{def0, def1},
+	}
+	state := twins.State{TwinID: twin.ID, ID: 3, Definition: def0.ID}
+
+	cases := []struct {
+		desc        string
+		id          string
+		token       string
+		at          time.Time
+		def         twins.Definition
+		err         error
+		retrieveErr error
+		stateErr    error
+		identifyErr error
+		userID      string
+	}{
+		{
+			desc:   "view state at time of the first definition",
+			id:     twin.ID,
+			token:  token,
+			at:     created.Add(30 * time.Minute),
+			def:    def0,
+			userID: validID,
+		},
+		{
+			desc:   "view state at time of the second definition",
+			id:     twin.ID,
+			token:  token,
+			at:     created.Add(90 * time.Minute),
+			def:    def1,
+			userID: validID,
+		},
+		{
+			desc:   "view state before twin creation",
+			id:     twin.ID,
+			token:  token,
+			at:     created.Add(-time.Minute),
+			err:    svcerr.ErrNotFound,
+			userID: validID,
+		},
+		{
+			desc:     "view state at time without states",
+			id:       twin.ID,
+			token:    token,
+			at:       created.Add(time.Minute),
+			err:      svcerr.ErrNotFound,
+			stateErr: repoerr.ErrNotFound,
+			userID:   validID,
+		},
+		{
+			desc:        "view state of non-existing twin",
+			id:          wrongID,
+			token:       token,
+			at:          created,
+			err:         svcerr.ErrNotFound,
+			retrieveErr: repoerr.ErrNotFound,
+			userID:      validID,
+		},
+		{
+			desc:        "view state with wrong credentials",
+			id:          twin.ID,
+			token:       invalidToken,
+			at:          created,
+			err:         svcerr.ErrAuthentication,
+			identifyErr: svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := authorizeCall(authz, nil, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), tc.id).Return(twin, tc.retrieveErr)
+		repoCall1 := stateRepo.On("RetrieveAt", context.Background(), tc.id, tc.at).Return(state, tc.stateErr)
+		st, def, err := svc.StateAt(context.Background(), tc.token, domainID, tc.id, tc.at)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, state, st, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, state, st))
+			assert.Equal(t, tc.def, def, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.def, def))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+func TestDiffStates(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, stateRepo, _, _ := NewService()
+
+	created := time.Now().Add(-2 * time.Hour)
+	def0 := twins.Definition{ID: 0, Created: created, Attributes: []twins.Attribute{{Name: "temperature"}, {Name: "status"}}}
+	def1 := twins.Definition{ID: 1, Created: created.Add(time.Hour), Attributes: []twins.Attribute{{Name: "temperature"}, {Name: "pressure"}}}
+	twin := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Name:        twinName,
+		Created:     created,
+		Definitions: []twins.Definition{def0, def1},
+	}
+	first := twins.State{TwinID: twin.ID, ID: 0, Definition: def0.ID, Payload: map[string]interface{}{"temperature": 20.5, "status": "ok"}}
+	second := twins.State{TwinID: twin.ID, ID: 1, Definition: def0.ID, Payload: map[string]interface{}{"temperature": 22.0, "status": "ok"}}
+	third := twins.State{TwinID: twin.ID, ID: 2, Definition: def1.ID, Payload: map[string]interface{}{"temperature": 19.0, "pressure": 1.2}}
+	states := map[int64]twins.State{first.ID: first, second.ID: second, third.ID: third}
+
+	id := func(id int64) *int64 {
+		return &id
+	}
+	delta := func(d float64) *float64 {
+		return &d
+	}
+	at := created.Add(90 * time.Minute)
+
+	cases := []struct {
+		desc        string
+		id          string
+		token       string
+		from        twins.StateSelector
+		to          twins.StateSelector
+		added       []twins.AttributeValue
+		removed     []twins.AttributeValue
+		changed     []twins.ValueChange
+		defDiff     bool
+		err         error
+		retrieveErr error
+		identifyErr error
+		userID      string
+	}{
+		{
+			desc:    "diff states by ID",
+			id:      twin.ID,
+			token:   token,
+			from:    twins.StateSelector{ID: id(first.ID)},
+			to:      twins.StateSelector{ID: id(second.ID)},
+			added:   []twins.AttributeValue{},
+			removed: []twins.AttributeValue{},
+			changed: []twins.ValueChange{{Name: "temperature", From: 20.5, To: 22.0, Delta: delta(1.5)}},
+			userID:  validID,
+		},
+		{
+			desc:    "diff states of different definitions",
+			id:      twin.ID,
+			token:   token,
+			from:    twins.StateSelector{ID: id(second.ID)},
+			to:      twins.StateSelector{At: at},
+			added:   []twins.AttributeValue{{Name: "pressure", Value: 1.2}},
+			removed: []twins.AttributeValue{{Name: "status", Value: "ok"}},
+			changed: []twins.ValueChange{{Name: "temperature", From: 22.0, To: 19.0, Delta: delta(-3)}},
+			defDiff: true,
+			userID:  validID,
+		},
+		{
+			desc:    "diff state with itself",
+			id:      twin.ID,
+			token:   token,
+			from:    twins.StateSelector{ID: id(third.ID)},
+			to:      twins.StateSelector{At: at},
+			added:   []twins.AttributeValue{},
+			removed: []twins.AttributeValue{},
+			changed: []twins.ValueChange{},
+			userID:  validID,
+		},
+		{
+			desc:   "diff non-existing state",
+			id:     twin.ID,
+			token:  token,
+			from:   twins.StateSelector{ID: id(7)},
+			to:     twins.StateSelector{ID: id(second.ID)},
+			err:    svcerr.ErrNotFound,
+			userID: validID,
+		},
+		{
+			desc:   "diff state before the first state",
+			id:     twin.ID,
+			token:  token,
+			from:   twins.StateSelector{At: created.Add(-time.Hour)},
+			to:     twins.StateSelector{ID: id(second.ID)},
+			err:    svcerr.ErrNotFound,
+			userID: validID,
+		},
+		{
+			desc:   "diff states without selector",
+			id:     twin.ID,
+			token:  token,
+			to:     twins.StateSelector{ID: id(second.ID)},
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:   "diff states selected both by ID and time",
+			id:     twin.ID,
+			token:  token,
+			from:   twins.StateSelector{ID: id(first.ID)},
+			to:     twins.StateSelector{ID: id(second.ID), At: at},
+			err:    svcerr.ErrMalformedEntity,
+			userID: validID,
+		},
+		{
+			desc:        "diff states of non-existing twin",
+			id:          wrongID,
+			token:       token,
+			from:        twins.StateSelector{ID: id(first.ID)},
+			to:          twins.StateSelector{ID: id(second.ID)},
+			err:         svcerr.ErrNotFound,
+			retrieveErr: repoerr.ErrNotFound,
+			userID:      validID,
+		},
+		{
+			desc:        "diff states with wrong credentials",
+			id:          twin.ID,
+			token:       invalidToken,
+			from:        twins.StateSelector{ID: id(first.ID)},
+			to:          twins.StateSelector{ID: id(second.ID)},
+			err:         svcerr.ErrAuthentication,
+			identifyErr: svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := authorizeCall(authz, nil, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), tc.id).Return(twin, tc.retrieveErr)
+		repoCall1 := stateRepo.On("RetrieveByID", context.Background(), tc.id, mock.Anything).Return(func(_ context.Context, _ string, id int64) (twins.State, error) {
+			st, ok := states[id]
+			if !ok {
+				return twins.State{}, repoerr.ErrNotFound
+			}
+			return st, nil
+		})
+		repoCall2 := stateRepo.On("RetrieveAt", context.Background(), tc.id, mock.Anything).Return(func(_ context.Context, _ string, t time.Time) (twins.State, error) {
+			if t.Before(created) {
+				return twins.State{}, repoerr.ErrNotFound
+			}
+			return third, nil
+		})
+		diff, err := svc.DiffStates(context.Background(), tc.token, domainID, tc.id, tc.from, tc.to)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, tc.added, diff.Added, fmt.Sprintf("%s: expected added %v got %v\n", tc.desc, tc.added, diff.Added))
+			assert.Equal(t, tc.removed, diff.Removed, fmt.Sprintf("%s: expected removed %v got %v\n", tc.desc, tc.removed, diff.Removed))
+			assert.Equal(t, tc.changed, diff.Changed, fmt.Sprintf("%s: expected changed %v got %v\n", tc.desc, tc.changed, diff.Changed))
+			assert.Equal(t, tc.defDiff, diff.Definition != nil, fmt.Sprintf("%s: expected definition diff %t got %v\n", tc.desc, tc.defDiff, diff.Definition))
+			if tc.defDiff {
+				assert.Equal(t, []twins.Attribute{{Name: "pressure"}}, diff.Definition.Added, fmt.Sprintf("%s: expected added attribute pressure got %v\n", tc.desc, diff.Definition.Added))
+			}
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		repoCall2.Unset()
+	}
+}
+
+// readRange returns the SenML messages of the page received within the time
+// range of the page metadata, as the readers do.
+func readRange(page readers.MessagesPage, pm readers.PageMetadata) readers.MessagesPage {
+	var msgs []readers.Message
+	for _, m := range page.Messages {
+		msg := m.(smqsenml.Message)
+		if (pm.From == 0 || msg.Time >= pm.From) && (pm.To == 0 || msg.Time < pm.To) {
+			msgs = append(msgs, msg)
+		}
+	}
+	return readers.MessagesPage{Total: uint64(len(msgs)), Messages: msgs}
+}
+
+func TestReplayStates(t *testing.T) {
+	auth := new(authnmocks.Authentication)
+	authz := new(authzmocks.Authorization)
+	twinRepo := new(mocks.TwinRepository)
+	stateRepo := new(mocks.StateRepository)
+	msgRepo := new(readersmocks.MessageRepository)
+	svc := twins.New(mocks.NewBroker(map[string]string{"chanID": "chanID"}), auth, authz, new(policymocks.Service), twinRepo, new(mocks.TwinCache), stateRepo, nil, nil, msgRepo, uuid.NewMock(), "chanID", twins.IngestConfig{}, smqlog.NewMock())
+
+	temperature := twins.Attribute{Name: "temperature", Channel: channels[0], Subtopic: subtopics[0], UpdateInterval: int64(time.Minute), PersistState: true}
+	mode := twins.Attribute{Name: "mode", Channel: channels[0], Subtopic: subtopics[1], Type: twins.StringType, Persistence: &twins.Persistence{Policy: twins.ChangePolicy}, PersistState: true}
+	twin := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{{Attributes: []twins.Attribute{temperature, mode}, Delta: int64(time.Minute)}},
+	}
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	seed := twins.State{TwinID: twin.ID, ID: 4, Created: start.Add(-30 * time.Second), Payload: map[string]interface{}{"temperature": 18.0}}
+	message := func(attr twins.Attribute, sec float64, val interface{}) smqsenml.Message {
+		msg := smqsenml.Message{Channel: attr.Channel, Subtopic: attr.Subtopic, Name: attr.Name, Time: float64(start.Unix()) + sec}
+		switch v := val.(type) {
+		case float64:
+			msg.Value = &v
+		case string:
+			msg.StringValue = &v
+		}
+		return msg
+	}
+	unbound := message(temperature, 40, 25.0)
+	unbound.Subtopic = subtopics[2]
+	// Readers return the newest messages of the subtopic first.
+	pages := map[string]readers.MessagesPage{
+		subtopics[0]: {
+			Total: 4,
+			Messages: []readers.Message{
+				message(temperature, 120, 22.0),
+				unbound,
+				message(temperature, 10, 21.0),
+				message(temperature, 0, 20.0),
+			},
+		},
+		subtopics[1]: {
+			Total: 3,
+			Messages: []readers.Message{
+				message(mode, 50, 5.0),
+				message(mode, 30, "eco"),
+				message(mode, 20, "eco"),
+			},
+		},
+	}
+
+	cases := []struct {
+		desc        string
+		id          string
+		token       string
+		opts        twins.ReplayOptions
+		seedErr     error
+		readErr     error
+		retrieveErr error
+		identifyErr error
+		channelErr  error
+		changed     bool
+		userID      string
+		ids         []int64
+		created     int
+		removed     int64
+		err         error
+	}{
+		{
+			desc:    "simulate replay",
+			id:      twin.ID,
+			token:   token,
+			opts:    twins.ReplayOptions{From: start, To: start.Add(time.Hour), Simulate: true},
+			userID:  validID,
+			ids:     []int64{4, 5, 6},
+			created: 2,
+		},
+		{
+			desc:    "simulate replay without preceding state",
+			id:      twin.ID,
+			token:   token,
+			opts:    twins.ReplayOptions{From: start, Simulate: true},
+			seedErr: repoerr.ErrNotFound,
+			userID:  validID,
+			ids:     []int64{0, 1, 2},
+			created: 3,
+		},
+		{
+			desc:    "replay into live history",
+			id:      twin.ID,
+			token:   token,
+			opts:    twins.ReplayOptions{From: start},
+			userID:  validID,
+			created: 2,
+			removed: 3,
+		},
+		{
+			desc:   "replay into live history with end time",
+			id:     twin.ID,
+			token:  token,
+			opts:   twins.ReplayOptions{From: start, To: start.Add(time.Hour)},
+			userID: validID,
+			err:    svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:   "simulate replay ending before it starts",
+			id:     twin.ID,
+			token:  token,
+			opts:   twins.ReplayOptions{From: start, To: start.Add(-time.Hour), Simulate: true},
+			userID: validID,
+			err:    svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:    "simulate replay of whole history",
+			id:      twin.ID,
+			token:   token,
+			opts:    twins.ReplayOptions{Simulate: true},
+			seedErr: repoerr.ErrNotFound,
+			userID:  validID,
+			ids:     []int64{0, 1, 2},
+			created: 3,
+		},
+		{
+			desc:    "replay into live history of twin changed meanwhile",
+			id:      twin.ID,
+			token:   token,
+			opts:    twins.ReplayOptions{From: start},
+			changed: true,
+			userID:  validID,
+			err:     svcerr.ErrConflict,
+		},
+		{
+			desc:       "replay messages of channel without access",
+			id:         twin.ID,
+			token:      token,
+			opts:       twins.ReplayOptions{From: start, Simulate: true},
+			channelErr: svcerr.ErrAuthorization,
+			userID:     validID,
+			err:        svcerr.ErrAuthorization,
+		},
+		{
+			desc:    "replay with failed message reading",
+			id:      twin.ID,
+			token:   token,
+			opts:    twins.ReplayOptions{From: start, Simulate: true},
+			readErr: readers.ErrReadMessages,
+			userID:  validID,
+			err:     svcerr.ErrViewEntity,
+		},
+		{
+			desc:        "replay into non-existing twin",
+			id:          wrongID,
+			token:       token,
+			opts:        twins.ReplayOptions{From: start, Simulate: true},
+			retrieveErr: repoerr.ErrNotFound,
+			userID:      validID,
+			err:         svcerr.ErrNotFound,
+		},
+		{
+			desc:        "replay with wrong credentials",
+			id:          twin.ID,
+			token:       invalidToken,
+			opts:        twins.ReplayOptions{From: start, Simulate: true},
+			identifyErr: svcerr.ErrAuthentication,
+			err:         svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		var updated, saved, simulated []twins.State
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := channelAccessCall(authz, tc.channelErr, nil, nil, nil)
+		retrieved := 0
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), tc.id).Return(func(context.Context, string) (twins.Twin, error) {
+			// The twin is retrieved again once the history is read.
+			tw := twin
+			if retrieved++; tc.changed && retrieved > 1 {
+				tw.Revision++
+			}
+			return tw, tc.retrieveErr
+		})
+		readCall := msgRepo.On("ReadAll", channels[0], mock.Anything).Return(func(_ string, pm readers.PageMetadata) (readers.MessagesPage, error) {
+			return readRange(pages[pm.Subtopic], pm), tc.readErr
+		})
+		repoCall1 := stateRepo.On("RetrieveAt", context.Background(), tc.id, tc.opts.From).Return(seed, tc.seedErr)
+		repoCall2 := stateRepo.On("RemoveAfter", context.Background(), tc.id, seed.ID).Return(tc.removed, nil)
+		repoCall3 := stateRepo.On("Update", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			updated = append(updated, args.Get(1).(twins.State))
+		}).Return(nil)
+		repoCall4 := stateRepo.On("Save", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			saved = append(saved, args.Get(1).([]twins.State)...)
+		}).Return(nil)
+		repoCall5 := stateRepo.On("SaveSimulated", context.Background(), tc.id, mock.Anything).Run(func(args mock.Arguments) {
+			simulated = append(simulated, args.Get(2).([]twins.State)...)
+		}).Return(nil)
+		rp, err := svc.ReplayStates(context.Background(), tc.token, domainID, tc.id, tc.opts)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, uint64(7), rp.Messages, fmt.Sprintf("%s: expected 7 messages got %d\n", tc.desc, rp.Messages))
+			assert.Equal(t, uint64(2), rp.Skipped, fmt.Sprintf("%s: expected 2 skipped messages got %d\n", tc.desc, rp.Skipped))
+			assert.Equal(t, tc.created, rp.Created, fmt.Sprintf("%s: expected %d created states got %d\n", tc.desc, tc.created, rp.Created))
+			assert.Equal(t, tc.removed, rp.Removed, fmt.Sprintf("%s: expected %d removed states got %d\n", tc.desc, tc.removed, rp.Removed))
+			var ids []int64
+			for _, st := range rp.States {
+				ids = append(ids, st.ID)
+			}
+			assert.Equal(t, tc.ids, ids, fmt.Sprintf("%s: expected states %v got %v\n", tc.desc, tc.ids, ids))
+		}
+		switch {
+		case tc.err == nil && !tc.opts.Simulate:
+			assert.Len(t, saved, tc.created, fmt.Sprintf("%s: expected %d saved states got %d\n", tc.desc, tc.created, len(saved)))
+			if assert.Len(t, updated, 1, fmt.Sprintf("%s: expected preceding state to be updated\n", tc.desc)) {
+				val := updated[0].Payload["temperature"].(*float64)
+				assert.Equal(t, 21.0, *val, fmt.Sprintf("%s: expected temperature 21 got %v\n", tc.desc, *val))
+				assert.Equal(t, start.Add(10*time.Second), updated[0].Updated["temperature"], fmt.Sprintf("%s: expected update at the record time\n", tc.desc))
+			}
+			assert.Empty(t, simulated, fmt.Sprintf("%s: expected no simulated states got %v\n", tc.desc, simulated))
+		case tc.err == nil:
+			assert.Equal(t, rp.States, simulated, fmt.Sprintf("%s: expected simulated states %v got %v\n", tc.desc, rp.States, simulated))
+			fallthrough
+		default:
+			assert.Empty(t, saved, fmt.Sprintf("%s: expected no saved states got %v\n", tc.desc, saved))
+			assert.Empty(t, updated, fmt.Sprintf("%s: expected no updated states got %v\n", tc.desc, updated))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		readCall.Unset()
+		repoCall1.Unset()
+		repoCall2.Unset()
+		repoCall3.Unset()
+		repoCall4.Unset()
+		repoCall5.Unset()
+	}
+}
+
+func TestReplayStatesJSONMessages(t *testing.T) {
+	auth := new(authnmocks.Authentication)
+	authz := new(authzmocks.Authorization)
+	twinRepo := new(mocks.TwinRepository)
+	stateRepo := new(mocks.StateRepository)
+	msgRepo := new(readersmocks.MessageRepository)
+	svc := twins.New(mocks.NewBroker(map[string]string{"chanID": "chanID"}), auth, authz, new(policymocks.Service), twinRepo, new(mocks.TwinCache), stateRepo, nil, nil, msgRepo, uuid.NewMock(), "chanID", twins.IngestConfig{}, smqlog.NewMock())
+
+	subtopic := "sensors.json"
+	temperature := twins.Attribute{Name: "temperature", Channel: channels[0], Subtopic: subtopic, Path: "/temp", PersistState: true}
+	humidity := twins.Attribute{Name: "humidity", Channel: channels[0], Subtopic: subtopic, Path: "/hum", PersistState: true}
+	twin := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Definitions: []twins.Definition{{Attributes: []twins.Attribute{temperature, humidity}, Delta: int64(time.Minute)}},
+	}
+
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	message := func(sec int64, payload map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"channel":  channels[0],
+			"subtopic": subtopic,
+			"created":  start.Add(time.Duration(sec) * time.Second).UnixNano(),
+			"payload":  payload,
+		}
+	}
+	page := readers.MessagesPage{
+		Total: 3,
+		Messages: []readers.Message{
+			message(120, map[string]interface{}{"temp": 22.0, "hum": 40.0}),
+			message(60, map[string]interface{}{"other": 1.0}),
+			message(0, map[string]interface{}{"temp": 21.0, "hum": 45.0}),
+		},
+	}
+
+	var pms []readers.PageMetadata
+	authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: validID}, nil)
+	authzCall := authorizeCall(authz, nil, nil)
+	repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+	readCall := msgRepo.On("ReadAll", channels[0], mock.Anything).Run(func(args mock.Arguments) {
+		pms = append(pms, args.Get(1).(readers.PageMetadata))
+	}).Return(page, nil)
+	repoCall1 := stateRepo.On("RetrieveAt", context.Background(), twin.ID, time.Time{}).Return(twins.State{}, repoerr.ErrNotFound)
+	repoCall2 := stateRepo.On("SaveSimulated", context.Background(), twin.ID, mock.Anything).Return(nil)
+	defer func() {
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		readCall.Unset()
+		repoCall1.Unset()
+		repoCall2.Unset()
+	}()
+
+	rp, err := svc.ReplayStates(context.Background(), token, domainID, twin.ID, twins.ReplayOptions{Simulate: true})
+	assert.Nil(t, err, fmt.Sprintf("unexpected error %s\n", err))
+	if assert.Len(t, pms, 1, "expected messages to be read once") {
+		assert.Equal(t, subtopic, pms[0].Subtopic, fmt.Sprintf("expected subtopic filter %s got %s\n", subtopic, pms[0].Subtopic))
+		assert.Equal(t, "json", pms[0].Format, fmt.Sprintf("expected format json got %s\n", pms[0].Format))
+		assert.Zero(t, pms[0].From, fmt.Sprintf("expected no start time got %v\n", pms[0].From))
+		assert.Zero(t, pms[0].To, fmt.Sprintf("expected no end time got %v\n", pms[0].To))
+	}
+	assert.Equal(t, uint64(3), rp.Messages, fmt.Sprintf("expected 3 messages got %d\n", rp.Messages))
+	assert.Equal(t, uint64(1), rp.Skipped, fmt.Sprintf("expected 1 skipped message got %d\n", rp.Skipped))
+	if assert.Len(t, rp.States, 2, fmt.Sprintf("expected 2 states got %v\n", rp.States)) {
+		for i, want := range []map[string]float64{{"temperature": 21.0, "humidity": 45.0}, {"temperature": 22.0, "humidity": 40.0}} {
+			for name, val := range want {
+				got, ok := rp.States[i].Payload[name].(*float64)
+				if assert.True(t, ok, fmt.Sprintf("expected %s in state %d got %v\n", name, i, rp.States[i].Payload)) {
+					assert.Equal(t, val, *got, fmt.Sprintf("expected %s %v in state %d got %v\n", name, val, i, *got))
+				}
+			}
+		}
+	}
+}
+
+func TestReplayStatesJSONRejected(t *testing.T) {
+	auth := new(authnmocks.Authentication)
+	authz := new(authzmocks.Authorization)
+	twinRepo := new(mocks.TwinRepository)
+	msgRepo := new(readersmocks.MessageRepository)
+	svc := twins.New(mocks.NewBroker(map[string]string{"chanID": "chanID"}), auth, authz, new(policymocks.Service), twinRepo, new(mocks.TwinCache), new(mocks.StateRepository), nil, nil, msgRepo, uuid.NewMock(), "chanID", twins.IngestConfig{}, smqlog.NewMock())
+
+	attr := twins.Attribute{Name: "temperature", Channel: channels[0], Subtopic: "sensors.json", Path: "/temp", PersistState: true}
+	pattern := attr
+	pattern.Subtopic = "sensors.*"
+	start := time.Now().Add(-time.Hour)
+
+	cases := []struct {
+		desc string
+		attr twins.Attribute
+		opts twins.ReplayOptions
+	}{
+		{
+			desc: "simulate replay of JSON messages within range",
+			attr: attr,
+			opts: twins.ReplayOptions{From: start, Simulate: true},
+		},
+		{
+			desc: "simulate replay of JSON messages with end time",
+			attr: attr,
+			opts: twins.ReplayOptions{To: start, Simulate: true},
+		},
+		{
+			desc: "replay JSON messages into live history",
+			attr: attr,
+			opts: twins.ReplayOptions{From: start},
+		},
+		{
+			desc: "simulate replay of JSON messages without format",
+			attr: pattern,
+			opts: twins.ReplayOptions{Simulate: true},
+		},
+	}
+
+	for _, tc := range cases {
+		twin := twins.Twin{
+			Owner:       email,
+			Domain:      domainID,
+			ID:          testsutil.GenerateUUID(t),
+			Definitions: []twins.Definition{{Attributes: []twins.Attribute{tc.attr}}},
+		}
+		authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := authorizeCall(authz, nil, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), twin.ID).Return(twin, nil)
+		_, err := svc.ReplayStates(context.Background(), token, domainID, twin.ID, tc.opts)
+		assert.True(t, errors.Contains(err, svcerr.ErrMalformedEntity), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, svcerr.ErrMalformedEntity, err))
+		msgRepo.AssertNotCalled(t, "ReadAll", mock.Anything, mock.Anything)
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+	}
+}
+
+func TestReplayStatesWithoutReader(t *testing.T) {
+	svc, auth, authz, _, _, _, _, _, _ := NewService()
+
+	authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: validID}, nil)
+	authzCall := authorizeCall(authz, nil, nil)
+	defer func() {
+		authCall.Unset()
+		authzCall.Unset()
+	}()
+
+	_, err := svc.ReplayStates(context.Background(), token, domainID, testsutil.GenerateUUID(t), twins.ReplayOptions{From: time.Now().Add(-time.Hour)})
+	assert.True(t, errors.Contains(err, svcerr.ErrMalformedEntity), fmt.Sprintf("expected %s got %s\n", svcerr.ErrMalformedEntity, err))
+}
+
+func TestListDefinitions(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, stateRepo, _, _ := NewService()
+
+	created := time.Now().Add(-2 * time.Hour)
+	def0 := twins.Definition{ID: 0, Created: created}
+	def1 := mocks.CreateDefinition(channels[0:2], subtopics[0:2])
+	def1.ID = 1
+	def1.Created = created.Add(time.Hour)
+	twin := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Name:        twinName,
+		Created:     created,
+		Definitions: []twins.Definition{def0, def1},
+	}
+	first := twins.State{TwinID: twin.ID, ID: 0, Definition: def0.ID, Created: created.Add(time.Minute)}
+	last := twins.State{TwinID: twin.ID, ID: 4, Definition: def0.ID, Created: created.Add(50 * time.Minute)}
+
+	cases := []struct {
+		desc        string
+		id          string
+		token       string
+		defs        []twins.DefinitionSummary
+		err         error
+		retrieveErr error
+		statesErr   error
+		identifyErr error
+		userID      string
+	}{
+		{
+			desc:  "list definitions",
+			id:    twin.ID,
+			token: token,
+			defs: []twins.DefinitionSummary{
+				{Definition: def0, States: 5, First: first.Created, Last: last.Created},
+				{Definition: def1},
+			},
+			userID: validID,
+		},
+		{
+			desc:      "list definitions with failed states retrieval",
+			id:        twin.ID,
+			token:     token,
+			err:       svcerr.ErrViewEntity,
+			statesErr: repoerr.ErrViewEntity,
+			userID:    validID,
+		},
+		{
+			desc:        "list definitions of non-existing twin",
+			id:          wrongID,
+			token:       token,
+			err:         svcerr.ErrNotFound,
+			retrieveErr: repoerr.ErrNotFound,
+			userID:      validID,
+		},
+		{
+			desc:        "list definitions with wrong credentials",
+			id:          twin.ID,
+			token:       invalidToken,
+			err:         svcerr.ErrAuthentication,
+			identifyErr: svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := authorizeCall(authz, nil, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), tc.id).Return(twin, tc.retrieveErr)
+		repoCall1 := stateRepo.On("RetrieveAll", context.Background(), uint64(0), uint64(1), tc.id, mock.Anything).Return(func(_ context.Context, _, _ uint64, _ string, filter twins.StateFilter) (twins.StatesPage, error) {
+			if *filter.Definition != def0.ID {
+				return twins.StatesPage{States: []twins.State{}}, tc.statesErr
+			}
+			st := first
+			if filter.Dir == "desc" {
+				st = last
+			}
+			return twins.StatesPage{PageMetadata: twins.PageMetadata{Total: 5}, States: []twins.State{st}}, tc.statesErr
+		})
+		defs, err := svc.ListDefinitions(context.Background(), tc.token, domainID, tc.id)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.defs, defs, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.defs, defs))
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+func TestDiffDefinitions(t *testing.T) {
+	svc, auth, authz, _, twinRepo, _, _, _, _ := NewService()
+
+	minTemp := 0.0
+	def0 := twins.Definition{
+		ID: 0,
+		Attributes: []twins.Attribute{
+			{Name: "temperature", Channel: channels[0], Subtopic: "temperature", PersistState: true},
+			{Name: "humidity", Channel: channels[0], Subtopic: "humidity", PersistState: true},
+		},
+		Delta: 1000,
+	}
+	def1 := twins.Definition{
+		ID: 1,
+		Attributes: []twins.Attribute{
+			{Name: "temperature", Channel: channels[0], Subtopic: "temperature", Min: &minTemp, PersistState: true},
+			{Name: "pressure", Channel: channels[1], Subtopic: "pressure", PersistState: true},
+		},
+		Delta:     1000,
+		Retention: &twins.Retention{MaxAge: int64(time.Hour)},
+	}
+	twin := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Name:        twinName,
+		Definitions: []twins.Definition{def0, def1},
+	}
+	delta := int64(1000)
+
+	cases := []struct {
+		desc        string
+		id          string
+		token       string
+		from        int
+		to          int
+		diff        twins.DefinitionDiff
+		err         error
+		retrieveErr error
+		identifyErr error
+		userID      string
+	}{
+		{
+			desc:  "diff definitions",
+			id:    twin.ID,
+			token: token,
+			from:  def0.ID,
+			to:    def1.ID,
+			diff: twins.DefinitionDiff{
+				From:      def0.ID,
+				To:        def1.ID,
+				Added:     []twins.Attribute{def1.Attributes[1]},
+				Removed:   []twins.Attribute{def0.Attributes[1]},
+				Changed:   []twins.AttributeChange{{Name: "temperature", From: def0.Attributes[0], To: def1.Attributes[0]}},
+				Retention: &twins.RetentionChange{To: def1.Retention},
+			},
+			userID: validID,
+		},
+		{
+			desc:  "diff definition with itself",
+			id:    twin.ID,
+			token: token,
+			from:  def1.ID,
+			to:    def1.ID,
+			diff: twins.DefinitionDiff{
+				From:    def1.ID,
+				To:      def1.ID,
+				Added:   []twins.Attribute{},
+				Removed: []twins.Attribute{},
+				Changed: []twins.AttributeChange{},
+			},
+			userID: validID,
+		},
+		{
+			desc:   "diff non-existing definition",
+			id:     twin.ID,
+			token:  token,
+			from:   def0.ID,
+			to:     5,
+			err:    svcerr.ErrNotFound,
+			userID: validID,
+		},
+		{
+			desc:        "diff definitions of non-existing twin",
+			id:          wrongID,
+			token:       token,
+			err:         svcerr.ErrNotFound,
+			retrieveErr: repoerr.ErrNotFound,
+			userID:      validID,
+		},
+		{
+			desc:        "diff definitions with wrong credentials",
+			id:          twin.ID,
+			token:       invalidToken,
+			err:         svcerr.ErrAuthentication,
+			identifyErr: svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := authorizeCall(authz, nil, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), tc.id).Return(twin, tc.retrieveErr)
+		diff, err := svc.DiffDefinitions(context.Background(), tc.token, domainID, tc.id, tc.from, tc.to)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, tc.diff, diff, fmt.Sprintf("%s: expected %v got %v\n", tc.desc, tc.diff, diff))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+	}
+
+	// Delta is reported only when it changes.
+	changed := twin
+	changed.Definitions = []twins.Definition{def0, {ID: 1, Attributes: def0.Attributes, Delta: delta * 2}}
+	authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: validID}, nil)
+	authzCall := authorizeCall(authz, nil, nil)
+	repoCall := twinRepo.On("RetrieveByID", context.Background(), changed.ID).Return(changed, nil)
+	diff, err := svc.DiffDefinitions(context.Background(), token, domainID, changed.ID, 0, 1)
+	assert.Nil(t, err, fmt.Sprintf("diff definitions with changed delta: unexpected error %s\n", err))
+	assert.Equal(t, delta*2, *diff.Delta, fmt.Sprintf("diff definitions with changed delta: expected %d got %d\n", delta*2, *diff.Delta))
+	authCall.Unset()
+	authzCall.Unset()
+	repoCall.Unset()
+}
+
+func TestRollbackDefinition(t *testing.T) {
+	svc, auth, authz, _, twinRepo, twinCache, _, _, _ := NewService()
+
+	created := time.Now().Add(-2 * time.Hour)
+	def0 := twins.Definition{ID: 0, Created: created}
+	def1 := mocks.CreateDefinition(channels[0:2], subtopics[0:2])
+	def1.ID = 1
+	def1.Created = created.Add(time.Hour)
+	def2 := mocks.CreateDefinition(channels[0:1], subtopics[0:1])
+	def2.ID = 2
+	def2.Created = created.Add(90 * time.Minute)
+	twin := twins.Twin{
+		Owner:       email,
+		Domain:      domainID,
+		ID:          testsutil.GenerateUUID(t),
+		Name:        twinName,
+		Created:     created,
+		Revision:    3,
+		Definitions: []twins.Definition{def0, def1, def2},
+	}
+
+	cases := []struct {
+		desc         string
+		id           string
+		token        string
+		definitionID int
+		revision     int
+		err          error
+		retrieveErr  error
+		updateErr    error
+		identifyErr  error
+		channelErr   error
+		userID       string
+	}{
+		{
+			desc:         "rollback to previous definition",
+			id:           twin.ID,
+			token:        token,
+			definitionID: def1.ID,
+			revision:     twin.Revision,
+			userID:       validID,
+		},
+		{
+			desc:         "rollback to previous definition with any revision",
+			id:           twin.ID,
+			token:        token,
+			definitionID: def1.ID,
+			revision:     twins.AnyRevision,
+			userID:       validID,
+		},
+		{
+			desc:         "rollback to latest definition",
+			id:           twin.ID,
+			token:        token,
+			definitionID: def2.ID,
+			revision:     twins.AnyRevision,
+			err:          svcerr.ErrMalformedEntity,
+			userID:       validID,
+		},
+		{
+			desc:         "rollback to definition bound to revoked channel",
+			id:           twin.ID,
+			token:        token,
+			definitionID: def1.ID,
+			revision:     twins.AnyRevision,
+			err:          svcerr.ErrAuthorization,
+			channelErr:   svcerr.ErrAuthorization,
+			userID:       validID,
+		},
+		{
+			desc:         "rollback to non-existing definition",
+			id:           twin.ID,
+			token:        token,
+			definitionID: 7,
+			revision:     twins.AnyRevision,
+			err:          svcerr.ErrNotFound,
+			userID:       validID,
+		},
+		{
+			desc:         "rollback with stale revision",
+			id:           twin.ID,
+			token:        token,
+			definitionID: def1.ID,
+			revision:     twin.Revision - 1,
+			err:          svcerr.ErrConflict,
+			userID:       validID,
+		},
+		{
+			desc:         "rollback modified concurrently",
+			id:           twin.ID,
+			token:        token,
+			definitionID: def1.ID,
+			revision:     twin.Revision,
+			err:          svcerr.ErrConflict,
+			updateErr:    repoerr.ErrConflict,
+			userID:       validID,
+		},
+		{
+			desc:         "rollback definition of non-existing twin",
+			id:           wrongID,
+			token:        token,
+			definitionID: def1.ID,
+			revision:     twins.AnyRevision,
+			err:          svcerr.ErrNotFound,
+			retrieveErr:  repoerr.ErrNotFound,
+			userID:       validID,
+		},
+		{
+			desc:         "rollback definition with wrong credentials",
+			id:           twin.ID,
+			token:        invalidToken,
+			definitionID: def1.ID,
+			revision:     twins.AnyRevision,
+			err:          svcerr.ErrAuthentication,
+			identifyErr:  svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: tc.userID}, tc.identifyErr)
+		authzCall := channelAccessCall(authz, tc.channelErr, nil, nil, nil)
+		repoCall := twinRepo.On("RetrieveByID", context.Background(), tc.id).Return(twin, tc.retrieveErr)
+		var updated twins.Twin
+		repoCall1 := twinRepo.On("Update", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			updated = args.Get(1).(twins.Twin)
+		}).Return(tc.updateErr)
+		cacheCall := twinCache.On("Update", context.Background(), mock.Anything).Return(nil)
+		def, err := svc.RollbackDefinition(context.Background(), tc.token, domainID, tc.id, tc.definitionID, tc.revision)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if tc.err == nil {
+			assert.Equal(t, def2.ID+1, def.ID, fmt.Sprintf("%s: expected definition %d got %d\n", tc.desc, def2.ID+1, def.ID))
+			assert.Equal(t, def1.Attributes, def.Attributes, fmt.Sprintf("%s: expected attributes %v got %v\n", tc.desc, def1.Attributes, def.Attributes))
+			assert.Len(t, updated.Definitions, len(twin.Definitions)+1, fmt.Sprintf("%s: expected %d definitions got %d\n", tc.desc, len(twin.Definitions)+1, len(updated.Definitions)))
+			assert.Equal(t, def, updated.Definitions[len(updated.Definitions)-1], fmt.Sprintf("%s: expected newest definition %v got %v\n", tc.desc, def, updated.Definitions[len(updated.Definitions)-1]))
+			assert.Equal(t, twin.Revision+1, updated.Revision, fmt.Sprintf("%s: expected revision %d got %d\n", tc.desc, twin.Revision+1, updated.Revision))
+		}
+		if tc.channelErr != nil {
+			assert.Empty(t, updated.Definitions, fmt.Sprintf("%s: expected twin not to be updated\n", tc.desc))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		cacheCall.Unset()
+	}
+}
+
+func TestAddTwinFromTemplate(t *testing.T) {
+	svc, auth, authz, policySvc, twinRepo, twinCache, _, templateRepo, _ := NewService()
+
+	tmpl := twins.Template{
+		Owner:    validID,
+		Domain:   domainID,
+		ID:       testsutil.GenerateUUID(t),
+		Revision: 2,
+		Definition: twins.Definition{
+			Attributes: []twins.Attribute{
+				{Name: "pressure", Channel: "{{channel}}", Subtopic: "pressure", PersistState: true},
+				{Name: "flow", Channel: "{{channel}}", Subtopic: "{{ line }}.flow", PersistState: true},
+			},
+			Delta: 1000,
+		},
+	}
+	foreign := tmpl
+	foreign.ID = testsutil.GenerateUUID(t)
+	foreign.Domain = testsutil.GenerateUUID(t)
+	bindings := map[string]string{"channel": channels[0], "line": "a"}
+
+	cases := []struct {
+		desc  string
+		twin  twins.Twin
+		def   twins.Definition
+		tmpl  twins.Template
+		attrs []twins.Attribute
+		err   error
+	}{
+		{
+			desc: "add twin from template",
+			twin: twins.Twin{Template: tmpl.ID, Bindings: bindings},
+			tmpl: tmpl,
+			attrs: []twins.Attribute{
+				{Name: "pressure", Channel: channels[0], Subtopic: "pressure", PersistState: true},
+				{Name: "flow", Channel: channels[0], Subtopic: "a.flow", PersistState: true},
+			},
+			err: nil,
+		},
+		{
+			desc: "add twin from template with missing binding",
+			twin: twins.Twin{Template: tmpl.ID, Bindings: map[string]string{"channel": channels[0]}},
+			tmpl: tmpl,
+			err:  svcerr.ErrMalformedEntity,
+		},
+		{
+			desc: "add twin from template with definition",
+			twin: twins.Twin{Template: tmpl.ID, Bindings: bindings},
+			def:  mocks.CreateDefinition(channels[0:1], subtopics[0:1]),
+			tmpl: tmpl,
+			err:  svcerr.ErrMalformedEntity,
+		},
+		{
+			desc: "add twin from template of other domain",
+			twin: twins.Twin{Template: foreign.ID, Bindings: bindings},
+			tmpl: foreign,
+			err:  svcerr.ErrNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		var saved twins.Twin
+		authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := authorizeCall(authz, nil, nil)
+		tmplCall := templateRepo.On("RetrieveByID", context.Background(), tc.twin.Template).Return(tc.tmpl, nil)
+		repoCall := twinRepo.On("Save", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(1).(twins.Twin)
+		}).Return(retained, nil)
+		cacheCall := twinCache.On("Save", context.Background(), mock.Anything).Return(nil)
+		policyCall := policySvc.On("AddPolicies", context.Background(), mock.Anything).Return(nil)
+		_, err := svc.AddTwin(context.Background(), token, domainID, tc.twin, tc.def)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err == nil {
+			def := saved.Definitions[0]
+			assert.Equal(t, tc.attrs, def.Attributes, fmt.Sprintf("%s: expected attributes %v got %v\n", tc.desc, tc.attrs, def.Attributes))
+			assert.Equal(t, tc.tmpl.Definition.Delta, def.Delta, fmt.Sprintf("%s: expected delta %d got %d\n", tc.desc, tc.tmpl.Definition.Delta, def.Delta))
+			assert.Equal(t, tc.tmpl.ID, saved.Template, fmt.Sprintf("%s: expected template %s got %s\n", tc.desc, tc.tmpl.ID, saved.Template))
+			assert.Equal(t, tc.tmpl.Revision, saved.TemplateRevision, fmt.Sprintf("%s: expected template revision %d got %d\n", tc.desc, tc.tmpl.Revision, saved.TemplateRevision))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		tmplCall.Unset()
+		repoCall.Unset()
+		cacheCall.Unset()
+		policyCall.Unset()
+	}
+}
+
+func TestAddTemplate(t *testing.T) {
+	svc, auth, authz, _, _, _, _, templateRepo, _ := NewService()
+
+	cases := []struct {
+		desc        string
+		tmpl        twins.Template
+		token       string
+		err         error
+		identifyErr error
+	}{
+		{
+			desc: "add template",
+			tmpl: twins.Template{
+				Name: "pump",
+				Definition: twins.Definition{
+					Attributes: []twins.Attribute{{Name: "pressure", Channel: "{{channel}}", Subtopic: "pressure", PersistState: true}},
+				},
+			},
+			token: token,
+			err:   nil,
+		},
+		{
+			desc: "add template with invalid parameter",
+			tmpl: twins.Template{
+				Definition: twins.Definition{
+					Attributes: []twins.Attribute{{Name: "pressure", Channel: "{{channel id}}", PersistState: true}},
+				},
+			},
+			token: token,
+			err:   svcerr.ErrMalformedEntity,
+		},
+		{
+			desc: "add template with invalid definition",
+			tmpl: twins.Template{
+				Definition: twins.Definition{
+					Attributes: []twins.Attribute{{Name: "pressure", Channel: "{{channel}}", Type: "integer", PersistState: true}},
+				},
+			},
+			token: token,
+			err:   svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:        "add template with wrong credentials",
+			tmpl:        twins.Template{},
+			token:       invalidToken,
+			err:         svcerr.ErrAuthentication,
+			identifyErr: svcerr.ErrAuthentication,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", context.Background(), tc.token).Return(smqauthn.Session{UserID: validID}, tc.identifyErr)
+		authzCall := authorizeCall(authz, nil, nil)
+		repoCall := templateRepo.On("Save", context.Background(), mock.Anything).Return(retained, nil)
+		tmpl, err := svc.AddTemplate(context.Background(), tc.token, domainID, tc.tmpl)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err == nil {
+			assert.Equal(t, domainID, tmpl.Domain, fmt.Sprintf("%s: expected domain %s got %s\n", tc.desc, domainID, tmpl.Domain))
+			assert.Equal(t, validID, tmpl.Owner, fmt.Sprintf("%s: expected owner %s got %s\n", tc.desc, validID, tmpl.Owner))
+			assert.Equal(t, []string{"channel"}, tmpl.Parameters(), fmt.Sprintf("%s: expected parameters %v got %v\n", tc.desc, []string{"channel"}, tmpl.Parameters()))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+	}
+}
+
+func TestUpdateTemplate(t *testing.T) {
+	svc, auth, authz, _, _, _, _, templateRepo, _ := NewService()
+
+	tmpl := twins.Template{
+		Owner:    email,
+		Domain:   domainID,
+		ID:       testsutil.GenerateUUID(t),
+		Name:     "pump",
+		Revision: 1,
+	}
+	def := twins.Definition{
+		Attributes: []twins.Attribute{{Name: "pressure", Channel: "{{channel}}", Subtopic: "pressure", PersistState: true}},
+	}
+
+	cases := []struct {
+		desc     string
+		tmpl     twins.Template
+		revision int
+		err      error
+		adminErr error
+		userID   string
+	}{
+		{
+			desc:     "update template definition",
+			tmpl:     twins.Template{ID: tmpl.ID, Definition: def},
+			revision: 2,
+			userID:   email,
+		},
+		{
+			desc:     "update template name",
+			tmpl:     twins.Template{ID: tmpl.ID, Name: "valve"},
+			revision: 1,
+			userID:   email,
+		},
+		{
+			desc:   "update template without changes",
+			tmpl:   twins.Template{ID: tmpl.ID},
+			err:    svcerr.ErrMalformedEntity,
+			userID: email,
+		},
+		{
+			desc:     "update template as domain administrator",
+			tmpl:     twins.Template{ID: tmpl.ID, Definition: def},
+			revision: 2,
+			userID:   validID,
+		},
+		{
+			desc:     "update template of other user",
+			tmpl:     twins.Template{ID: tmpl.ID, Definition: def},
+			err:      svcerr.ErrAuthorization,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   validID,
+		},
+	}
+
+	for _, tc := range cases {
+		var updated twins.Template
+		authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: tc.userID}, nil)
+		authzCall := authorizeCall(authz, nil, tc.adminErr)
+		repoCall := templateRepo.On("RetrieveByID", context.Background(), tmpl.ID).Return(tmpl, nil)
+		repoCall1 := templateRepo.On("Update", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			updated = args.Get(1).(twins.Template)
+		}).Return(nil)
+		err := svc.UpdateTemplate(context.Background(), token, domainID, tc.tmpl)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err == nil {
+			assert.Equal(t, tc.revision, updated.Revision, fmt.Sprintf("%s: expected revision %d got %d\n", tc.desc, tc.revision, updated.Revision))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+func TestRemoveTemplate(t *testing.T) {
+	svc, auth, authz, _, _, _, _, templateRepo, _ := NewService()
+
+	tmpl := twins.Template{
+		Owner:  validID,
+		Domain: domainID,
+		ID:     testsutil.GenerateUUID(t),
+	}
+
+	cases := []struct {
+		desc        string
+		id          string
+		err         error
+		retrieveErr error
+		adminErr    error
+		userID      string
+	}{
+		{
+			desc:   "remove template",
+			id:     tmpl.ID,
+			userID: validID,
+		},
+		{
+			desc:        "remove non-existing template",
+			id:          wrongID,
+			err:         svcerr.ErrNotFound,
+			retrieveErr: repoerr.ErrNotFound,
+			userID:      validID,
+		},
+		{
+			desc:     "remove template of other user",
+			id:       tmpl.ID,
+			err:      svcerr.ErrAuthorization,
+			adminErr: svcerr.ErrAuthorization,
+			userID:   email,
+		},
+	}
+
+	for _, tc := range cases {
+		authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: tc.userID}, nil)
+		authzCall := authorizeCall(authz, nil, tc.adminErr)
+		repoCall := templateRepo.On("RetrieveByID", context.Background(), tc.id).Return(tmpl, tc.retrieveErr)
+		repoCall1 := templateRepo.On("Remove", context.Background(), tc.id).Return(nil)
+		err := svc.RemoveTemplate(context.Background(), token, domainID, tc.id)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		authCall.Unset()
+		authzCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+	}
+}
+
+func TestRolloutTemplate(t *testing.T) {
+	svc, auth, authz, _, twinRepo, twinCache, _, templateRepo, _ := NewService()
+
+	tmpl := twins.Template{
+		Owner:    validID,
+		Domain:   domainID,
+		ID:       testsutil.GenerateUUID(t),
+		Revision: 2,
+		Definition: twins.Definition{
+			Attributes: []twins.Attribute{
+				{Name: "pressure", Channel: "{{channel}}", Subtopic: "pressure", PersistState: true},
+				{Name: "flow", Channel: "{{channel}}", Subtopic: "{{line}}", PersistState: true},
+			},
+		},
+	}
+	derived := func(owner string, revision int, bindings map[string]string) twins.Twin {
+		return twins.Twin{
+			Owner:            owner,
+			Domain:           domainID,
+			ID:               testsutil.GenerateUUID(t),
+			Template:         tmpl.ID,
+			TemplateRevision: revision,
+			Bindings:         bindings,
+			Definitions:      []twins.Definition{{ID: 0}},
+		}
+	}
+	outdated := derived(validID, 1, map[string]string{"channel": channels[0], "line": "a"})
+	unbound := derived(validID, 1, map[string]string{"channel": channels[1]})
+	current := derived(validID, 2, map[string]string{"channel": channels[2], "line": "c"})
+	foreign := derived(email, 1, map[string]string{"channel": channels[2], "line": "c"})
+	invalid := derived(validID, 1, map[string]string{"channel": channels[1], "line": "a..b"})
+
+	cases := []struct {
+		desc       string
+		derived    []twins.Twin
+		bindings   map[string]string
+		updated    []string
+		skipped    []string
+		err        error
+		adminErr   error
+		channelErr error
+	}{
+		{
+			desc:    "rollout template to outdated twins",
+			derived: []twins.Twin{outdated, current},
+			updated: []string{outdated.ID},
+			skipped: []string{},
+		},
+		{
+			desc:     "rollout template with default bindings",
+			derived:  []twins.Twin{outdated, unbound},
+			bindings: map[string]string{"line": "b"},
+			updated:  []string{outdated.ID, unbound.ID},
+			skipped:  []string{},
+		},
+		{
+			desc:    "rollout template with missing bindings",
+			derived: []twins.Twin{outdated, unbound},
+			err:     svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:     "rollout template skipping twins of other users",
+			derived:  []twins.Twin{outdated, foreign},
+			updated:  []string{outdated.ID},
+			skipped:  []string{foreign.ID},
+			adminErr: svcerr.ErrAuthorization,
+		},
+		{
+			desc:    "rollout template with bindings producing invalid subtopic",
+			derived: []twins.Twin{outdated, invalid},
+			err:     svcerr.ErrMalformedEntity,
+		},
+		{
+			desc:       "rollout template with bound channel without access",
+			derived:    []twins.Twin{outdated},
+			err:        svcerr.ErrAuthorization,
+			channelErr: svcerr.ErrAuthorization,
+		},
+	}
+
+	for _, tc := range cases {
+		updated := map[string]twins.Twin{}
+		authCall := auth.On("Authenticate", context.Background(), token).Return(smqauthn.Session{UserID: validID}, nil)
+		authzCall := channelAccessCall(authz, tc.channelErr, nil, tc.adminErr, ownerRoles(tc.derived...))
+		tmplCall := templateRepo.On("RetrieveByID", context.Background(), tmpl.ID).Return(tmpl, nil)
+		repoCall := twinRepo.On("RetrieveByTemplate", context.Background(), tmpl.ID).Return(tc.derived, nil)
+		repoCall1 := twinRepo.On("Update", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			tw := args.Get(1).(twins.Twin)
+			updated[tw.ID] = tw
+		}).Return(nil)
+		cacheCall := twinCache.On("Update", context.Background(), mock.Anything).Return(nil)
+		ro, err := svc.RolloutTemplate(context.Background(), token, domainID, tmpl.ID, tc.bindings)
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		if err == nil {
+			assert.Equal(t, tc.updated, ro.Updated, fmt.Sprintf("%s: expected updated twins %v got %v\n", tc.desc, tc.updated, ro.Updated))
+			assert.Equal(t, tc.skipped, ro.Skipped, fmt.Sprintf("%s: expected skipped twins %v got %v\n", tc.desc, tc.skipped, ro.Skipped))
+			for _, id := range tc.updated {
+				tw := updated[id]
+				def := tw.Definitions[len(tw.Definitions)-1]
+				assert.Equal(t, tmpl.Revision, tw.TemplateRevision, fmt.Sprintf("%s: expected template revision %d got %d\n", tc.desc, tmpl.Revision, tw.TemplateRevision))
+				assert.Equal(t, 1, def.ID, fmt.Sprintf("%s: expected definition %d got %d\n", tc.desc, 1, def.ID))
+				assert.Equal(t, tw.Bindings["channel"], def.Attributes[0].Channel, fmt.Sprintf("%s: expected bound channel %s got %s\n", tc.desc, tw.Bindings["channel"], def.Attributes[0].Channel))
+				assert.Equal(t, tw.Bindings["line"], def.Attributes[1].Subtopic, fmt.Sprintf("%s: expected bound subtopic %s got %s\n", tc.desc, tw.Bindings["line"], def.Attributes[1].Subtopic))
+			}
+		}
+		if tc.err != nil {
+			assert.Empty(t, updated, fmt.Sprintf("%s: expected no updated twins got %v\n", tc.desc, updated))
+		}
+		authCall.Unset()
+		authzCall.Unset()
+		tmplCall.Unset()
+		repoCall.Unset()
+		repoCall1.Unset()
+		cacheCall.Unset()
+	}
+}
+
+func TestApplyRetention(t *testing.T) {
+	svc, _, _, _, twinRepo, _, stateRepo, _, _ := NewService()
+
+	hour := int64(time.Hour)
+	last := twins.State{ID: 10, Created: time.Now().Add(-time.Minute), Payload: map[string]interface{}{"temperature": 21.5}}
+	twin := func(ret twins.Retention) twins.Twin {
+		return twins.Twin{
+			ID:          testsutil.GenerateUUID(t),
+			Domain:      domainID,
+			Definitions: []twins.Definition{{Retention: &ret}},
+		}
+	}
+
+	cases := []struct {
+		desc        string
+		twin        twins.Twin
+		last        twins.State
+		retrieveErr error
+		removed     int64
+		removeErr   error
+		compacted   int64
+		compactErr  error
+		compactions []twins.Compaction
+		err         error
+	}{
+		{
+			desc:    "apply retention removing old states",
+			twin:    twin(twins.Retention{MaxAge: 24 * hour}),
+			last:    last,
+			removed: 5,
+		},
+		{
+			desc:      "apply retention compacting old states",
+			twin:      twin(twins.Retention{CompactAge: hour, Bucket: twins.HourBucket}),
+			last:      last,
+			compacted: 3,
+		},
+		{
+			desc:      "apply retention removing and compacting old states",
+			twin:      twin(twins.Retention{MaxAge: 24 * hour, CompactAge: hour, Bucket: twins.DayBucket}),
+			last:      last,
+			removed:   5,
+			compacted: 3,
+		},
+		{
+			desc: "apply retention to twin without states",
+			twin: twin(twins.Retention{MaxAge: 24 * hour}),
+			last: twins.State{},
+		},
+		{
+			desc: "apply retention without expired states",
+			twin: twin(twins.Retention{MaxAge: 24 * hour}),
+			last: last,
+		},
+		{
+			desc:      "apply retention with failed removal",
+			twin:      twin(twins.Retention{MaxAge: 24 * hour}),
+			last:      last,
+			removeErr: repoerr.ErrRemoveEntity,
+		},
+		{
+			desc:        "apply retention with failed twins retrieval",
+			twin:        twin(twins.Retention{MaxAge: 24 * hour}),
+			retrieveErr: repoerr.ErrViewEntity,
+			err:         svcerr.ErrViewEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		page := twins.Page{Twins: []twins.Twin{tc.twin}, PageMetadata: twins.PageMetadata{Total: 1}}
+		repoCall := twinRepo.On("RetrieveRetained", context.Background(), uint64(0), mock.Anything).Return(page, tc.retrieveErr)
+		stateCall := stateRepo.On("RetrieveLast", context.Background(), tc.twin.ID).Return(tc.last, nil)
+		var removeBefore, compactBefore time.Time
+		stateCall1 := stateRepo.On("RemoveBefore", context.Background(), tc.twin.ID, mock.Anything).Run(func(args mock.Arguments) {
+			removeBefore = args.Get(2).(time.Time)
+		}).Return(tc.removed, tc.removeErr)
+		stateCall2 := stateRepo.On("Compact", context.Background(), tc.twin.ID, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			compactBefore = args.Get(2).(time.Time)
+		}).Return(tc.compacted, tc.compactErr)
+		comps, err := svc.ApplyRetention(context.Background())
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		var expected []twins.Compaction
+		if tc.removed > 0 || tc.compacted > 0 {
+			expected = []twins.Compaction{{TwinID: tc.twin.ID, Removed: tc.removed, Compacted: tc.compacted}}
+		}
+		if tc.removeErr != nil {
+			expected = nil
+		}
+		assert.Equal(t, expected, comps, fmt.Sprintf("%s: expected compactions %v got %v\n", tc.desc, expected, comps))
+		if ret := tc.twin.Definitions[0].Retention; !removeBefore.IsZero() {
+			assert.True(t, removeBefore.Before(time.Now().Add(-time.Duration(ret.MaxAge))), fmt.Sprintf("%s: removed states created before %s", tc.desc, removeBefore))
+		}
+		if !compactBefore.IsZero() {
+			assert.True(t, compactBefore.Equal(compactBefore.Truncate(time.Hour)), fmt.Sprintf("%s: compacted states before unaligned time %s", tc.desc, compactBefore))
+		}
+		repoCall.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+		stateCall2.Unset()
+	}
+}
+
+func TestCheckLiveness(t *testing.T) {
+	svc, _, _, _, twinRepo, _, stateRepo, _, _ := NewService()
+
+	interval := time.Minute
+	recent := time.Now().Add(-time.Second)
+	old := time.Now().Add(-time.Hour)
+	twin := twins.Twin{
+		ID:     testsutil.GenerateUUID(t),
+		Domain: domainID,
+		Definitions: []twins.Definition{{
+			Created:    old,
+			Attributes: []twins.Attribute{{Name: "temperature", UpdateInterval: int64(interval)}},
+		}},
+	}
+	state := func(updated time.Time, stale ...string) twins.State {
+		st := twins.State{TwinID: twin.ID, ID: 10, Payload: map[string]interface{}{"temperature": 21.5}, Stale: stale}
+		if !updated.IsZero() {
+			st.Updated = map[string]time.Time{"temperature": updated}
+		}
+		return st
+	}
+
+	cases := []struct {
+		desc        string
+		last        twins.State
+		liveness    *twins.Liveness
+		retrieveErr error
+		updateErr   error
+		livenessErr error
+		stale       []string
+		transitions []twins.Staleness
+		err         error
+	}{
+		{
+			desc:        "check liveness of attribute not received in time",
+			last:        state(old),
+			stale:       []string{"temperature"},
+			transitions: []twins.Staleness{{TwinID: twin.ID, Attribute: "temperature", Stale: true, Updated: &old}},
+		},
+		{
+			desc: "check liveness of attribute received in time",
+			last: state(recent),
+		},
+		{
+			desc: "check liveness of attribute already marked stale",
+			last: state(old, "temperature"),
+		},
+		{
+			desc:        "check liveness of stale attribute received in time",
+			last:        state(recent, "temperature"),
+			stale:       []string{},
+			transitions: []twins.Staleness{{TwinID: twin.ID, Attribute: "temperature", Stale: false, Updated: &recent}},
+		},
+		{
+			desc:        "check liveness of attribute never received",
+			last:        state(time.Time{}),
+			stale:       []string{"temperature"},
+			transitions: []twins.Staleness{{TwinID: twin.ID, Attribute: "temperature", Stale: true}},
+		},
+		{
+			desc:        "check liveness of twin without states",
+			last:        twins.State{},
+			stale:       []string{"temperature"},
+			transitions: []twins.Staleness{{TwinID: twin.ID, Attribute: "temperature", Stale: true}},
+		},
+		{
+			desc:     "check liveness of twin without states already marked stale",
+			last:     twins.State{},
+			liveness: &twins.Liveness{Stale: []string{"temperature"}, Checked: recent},
+		},
+		{
+			desc:        "check liveness of twin without states with failed update",
+			last:        twins.State{},
+			stale:       []string{"temperature"},
+			livenessErr: repoerr.ErrUpdateEntity,
+		},
+		{
+			desc:      "check liveness with failed update",
+			last:      state(old),
+			stale:     []string{"temperature"},
+			updateErr: repoerr.ErrUpdateEntity,
+		},
+		{
+			desc:        "check liveness with failed twins retrieval",
+			retrieveErr: repoerr.ErrViewEntity,
+			err:         svcerr.ErrViewEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		tw := twin
+		tw.Liveness = tc.liveness
+		page := twins.Page{Twins: []twins.Twin{tw}, PageMetadata: twins.PageMetadata{Total: 1}}
+		repoCall := twinRepo.On("RetrieveMonitored", context.Background(), uint64(0), mock.Anything).Return(page, tc.retrieveErr)
+		stateCall := stateRepo.On("RetrieveLast", context.Background(), twin.ID).Return(tc.last, nil)
+		var updated *twins.State
+		stateCall1 := stateRepo.On("UpdateStale", context.Background(), mock.Anything).Run(func(args mock.Arguments) {
+			st := args.Get(1).(twins.State)
+			updated = &st
+		}).Return(tc.updateErr)
+		var liveness *twins.Liveness
+		repoCall1 := twinRepo.On("UpdateLiveness", context.Background(), twin.ID, mock.Anything).Run(func(args mock.Arguments) {
+			l := args.Get(2).(twins.Liveness)
+			liveness = &l
+			updated = &twins.State{Stale: l.Stale}
+		}).Return(tc.livenessErr)
+		trs, err := svc.CheckLiveness(context.Background())
+		assert.True(t, errors.Contains(err, tc.err), fmt.Sprintf("%s: expected %s got %s\n", tc.desc, tc.err, err))
+		assert.Equal(t, tc.transitions, trs, fmt.Sprintf("%s: expected transitions %v got %v\n", tc.desc, tc.transitions, trs))
+		switch {
+		case tc.stale == nil:
+			assert.Nil(t, updated, fmt.Sprintf("%s: unexpected stale attributes update", tc.desc))
+		case updated == nil:
+			assert.Fail(t, fmt.Sprintf("%s: expected stale attributes update", tc.desc))
+		default:
+			assert.ElementsMatch(t, tc.stale, updated.Stale, fmt.Sprintf("%s: expected stale attributes %v got %v\n", tc.desc, tc.stale, updated.Stale))
+		}
+		if tc.last.Payload == nil && liveness != nil {
+			assert.False(t, liveness.Checked.IsZero(), fmt.Sprintf("%s: expected liveness check time\n", tc.desc))
+		}
+		repoCall.Unset()
+		repoCall1.Unset()
+		stateCall.Unset()
+		stateCall1.Unset()
+	}
+}
+
+// benchmarkSaveStates saves the states of the twins bound to the same
+// channel and subtopic, simulating the repository round-trip latency.
+func benchmarkSaveStates(b *testing.B, ingest twins.IngestConfig) {
+	const (
+		numTwins = 10
+		latency  = 100 * time.Microsecond
+	)
+
+	twinRepo := new(mocks.TwinRepository)
+	twinCache := new(mocks.TwinCache)
+	stateRepo := new(mocks.StateRepository)
+	svc := twins.New(mocks.NewBroker(map[string]string{"chanID": "chanID"}), nil, nil, nil, twinRepo, twinCache, stateRepo, nil, nil, nil, uuid.NewMock(), "chanID", ingest, smqlog.NewMock())
+
+	attr := twins.Attribute{Name: "temperature", Channel: channels[0], Subtopic: subtopics[0], PersistState: true}
+	var ids []string
+	for i := 0; i < numTwins; i++ {
+		tw := twins.Twin{
+			ID:          fmt.Sprintf("%s-%d", validID, i),
+			Definitions: []twins.Definition{{Attributes: []twins.Attribute{attr}}},
+		}
+		ids = append(ids, tw.ID)
+		twinRepo.On("RetrieveByID", mock.Anything, tw.ID).Return(tw, nil).After(latency)
+		stateRepo.On("RetrieveLast", mock.Anything, tw.ID).Return(twins.State{}, nil).After(latency)
+	}
+
+	var saved atomic.Int64
+	total := int64(b.N * numTwins)
+	done := make(chan struct{})
+	twinCache.On("IDs", mock.Anything, mock.Anything, mock.Anything).Return(ids, nil)
+	stateRepo.On("Save", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		if saved.Add(int64(len(args.Get(1).([]twins.State)))) == total {
+			close(done)
+		}
+	}).Return(nil).After(latency)
+
+	val := 21.5
+	message, err := mocks.CreateMessage(domainID, attr, []senml.Record{{Name: attr.Name, Value: &val}})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := svc.SaveStates(context.Background(), message); err != nil {
+			b.Fatal(err)
+		}
+	}
+	<-done
+}
+
+func BenchmarkSaveStates(b *testing.B) {
+	benchmarkSaveStates(b, twins.IngestConfig{})
+}
+
+func BenchmarkSaveStatesIngest(b *testing.B) {
+	benchmarkSaveStates(b, twins.IngestConfig{Workers: 8, QueueSize: 1024, BatchSize: 100, ViewTTL: time.Minute})
 }
@@ -0,0 +1,166 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	svcerr "github.com/absmach/supermq/pkg/errors/service"
+	"github.com/absmach/supermq/pkg/policies"
+)
+
+var errStateSelector = errors.New("state must be selected either by ID or by time")
+
+// StateSelector selects the state of the twin either by its ID or by the
+// time, in which case the last state created at or before it is selected.
+type StateSelector struct {
+	ID *int64
+	At time.Time
+}
+
+// AttributeValue holds the value of the attribute in the state payload.
+type AttributeValue struct {
+	Name  string
+	Value interface{}
+}
+
+// ValueChange holds the values of the attribute in the source and the target
+// state. Delta is the difference of the values if both are numbers.
+type ValueChange struct {
+	Name  string
+	From  interface{}
+	To    interface{}
+	Delta *float64
+}
+
+// StateDiff describes the changes of the attribute values between the source
+// and the target state, ordered by the attribute name. Definition is set only
+// if the states were created using different definitions.
+type StateDiff struct {
+	From       State
+	To         State
+	Added      []AttributeValue
+	Removed    []AttributeValue
+	Changed    []ValueChange
+	Definition *DefinitionDiff
+}
+
+func (sel StateSelector) validate() error {
+	if (sel.ID == nil) == sel.At.IsZero() {
+		return errStateSelector
+	}
+
+	return nil
+}
+
+// diffStates compares the payloads of the states by the attribute name.
+func diffStates(from, to State) StateDiff {
+	diff := StateDiff{
+		From:    from,
+		To:      to,
+		Added:   []AttributeValue{},
+		Removed: []AttributeValue{},
+		Changed: []ValueChange{},
+	}
+
+	for _, name := range sortedKeys(to.Payload) {
+		val := to.Payload[name]
+		old, ok := from.Payload[name]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, AttributeValue{Name: name, Value: val})
+		case !reflect.DeepEqual(old, val):
+			change := ValueChange{Name: name, From: old, To: val}
+			x, okFrom := toNumber(old)
+			y, okTo := toNumber(val)
+			if okFrom && okTo {
+				delta := y - x
+				change.Delta = &delta
+			}
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+	for _, name := range sortedKeys(from.Payload) {
+		if _, ok := to.Payload[name]; !ok {
+			diff.Removed = append(diff.Removed, AttributeValue{Name: name, Value: from.Payload[name]})
+		}
+	}
+
+	return diff
+}
+
+func sortedKeys(payload map[string]interface{}) []string {
+	keys := make([]string, 0, len(payload))
+	for k := range payload {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (ts *twinservice) DiffStates(ctx context.Context, token, domainID, twinID string, from, to StateSelector) (StateDiff, error) {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return StateDiff{}, err
+	}
+
+	if err := from.validate(); err != nil {
+		return StateDiff{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+	if err := to.validate(); err != nil {
+		return StateDiff{}, errors.Wrap(svcerr.ErrMalformedEntity, err)
+	}
+
+	tw, err := ts.authorize(ctx, session, domainID, twinID, policies.ViewPermission)
+	if err != nil {
+		return StateDiff{}, err
+	}
+
+	src, err := ts.selectState(ctx, twinID, from)
+	if err != nil {
+		return StateDiff{}, err
+	}
+	dst, err := ts.selectState(ctx, twinID, to)
+	if err != nil {
+		return StateDiff{}, err
+	}
+
+	diff := diffStates(src, dst)
+	if src.Definition != dst.Definition {
+		srcDef, okSrc := tw.DefinitionByID(src.Definition)
+		dstDef, okDst := tw.DefinitionByID(dst.Definition)
+		if okSrc && okDst {
+			defDiff := diffDefinitions(srcDef, dstDef)
+			diff.Definition = &defDiff
+		}
+	}
+
+	return diff, nil
+}
+
+// selectState retrieves the state of the twin matching the selector.
+func (ts *twinservice) selectState(ctx context.Context, twinID string, sel StateSelector) (State, error) {
+	var st State
+	var err error
+	switch sel.ID {
+	case nil:
+		st, err = ts.states.RetrieveAt(ctx, twinID, sel.At)
+	default:
+		st, err = ts.states.RetrieveByID(ctx, twinID, *sel.ID)
+	}
+	if err != nil {
+		if errors.Contains(err, repoerr.ErrNotFound) {
+			return State{}, errors.Wrap(svcerr.ErrNotFound, err)
+		}
+		return State{}, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+
+	return st, nil
+}
@@ -8,13 +8,66 @@ import (
 	"time"
 )
 
-// State stores actual snapshot of entity's values.
+// State stores actual snapshot of entity's values. Updated holds the times
+// the values of the attributes with the update interval were last received,
+// while Stale holds the attributes which were not received in time.
 type State struct {
 	TwinID     string
 	ID         int64
 	Definition int
 	Created    time.Time
 	Payload    map[string]interface{}
+	Updated    map[string]time.Time
+	Stale      []string
+}
+
+// DesiredState stores the attribute values the twin is expected to converge
+// to, as opposed to the reported values stored in State.
+type DesiredState struct {
+	TwinID  string
+	Updated time.Time
+	Payload map[string]interface{}
+}
+
+// Delta contains desired attribute values which differ from the values of
+// the last reported state.
+type Delta map[string]interface{}
+
+// CompositeState rolls up the last states of a twin and its descendants.
+type CompositeState struct {
+	TwinID string
+	Name   string
+	State  State
+
+	// Updated is the creation time of the newest state within the subtree.
+	Updated  time.Time
+	Children []CompositeState
+}
+
+// StateFilter narrows down the subset of states retrieved from the
+// repository. Zero values of the fields are ignored.
+type StateFilter struct {
+	// From limits states to the ones created at or after the given time.
+	From time.Time
+
+	// To limits states to the ones created at or before the given time.
+	To time.Time
+
+	// Definition limits states to the ones created using the definition
+	// with the given ID.
+	Definition *int
+
+	// Attribute limits states to the ones whose payload contains the
+	// attribute with the given name.
+	Attribute string
+
+	// Dir sets the states order by ID, either ascending (default) or
+	// descending.
+	Dir string
+
+	// Simulated retrieves the states saved by the last simulated replay of
+	// the twin instead of its live history.
+	Simulated bool
 }
 
 // StatesPage contains page related metadata as well as a list of twins that
@@ -26,8 +79,8 @@ type StatesPage struct {
 
 // StateRepository specifies a state persistence API.
 type StateRepository interface {
-	// Save persists the state
-	Save(ctx context.Context, state State) error
+	// Save persists the states at once
+	Save(ctx context.Context, states ...State) error
 
 	// Update updates the state
 	Update(ctx context.Context, state State) error
@@ -35,9 +88,54 @@ type StateRepository interface {
 	// Count returns the number of states related to state
 	Count(ctx context.Context, twin Twin) (int64, error)
 
-	// RetrieveAll retrieves the subset of states related to twin specified by id
-	RetrieveAll(ctx context.Context, offset uint64, limit uint64, twinID string) (StatesPage, error)
+	// RetrieveAll retrieves the subset of states related to twin specified by
+	// id and matching the provided filter
+	RetrieveAll(ctx context.Context, offset uint64, limit uint64, twinID string, filter StateFilter) (StatesPage, error)
 
 	// RetrieveLast retrieves the last saved state
 	RetrieveLast(ctx context.Context, twinID string) (State, error)
+
+	// RetrieveByID retrieves the state of the twin specified by id with the
+	// given state id
+	RetrieveByID(ctx context.Context, twinID string, id int64) (State, error)
+
+	// RetrieveAt retrieves the last state created at or before the given time
+	RetrieveAt(ctx context.Context, twinID string, at time.Time) (State, error)
+
+	// RemoveBefore removes the states of the twin created before the given
+	// time and returns the number of removed states
+	RemoveBefore(ctx context.Context, twinID string, before time.Time) (int64, error)
+
+	// RemoveAfter removes the states of the twin with the id greater than
+	// the given one and returns the number of removed states
+	RemoveAfter(ctx context.Context, twinID string, id int64) (int64, error)
+
+	// SaveSimulated replaces the simulated states of the twin with the given
+	// ones
+	SaveSimulated(ctx context.Context, twinID string, states ...State) error
+
+	// Compact removes the states of the twin created before the given time,
+	// except the last state of each bucket of the given duration, and
+	// returns the number of removed states
+	Compact(ctx context.Context, twinID string, before time.Time, bucket time.Duration) (int64, error)
+
+	// UpdateStale updates the stale attributes of the state
+	UpdateStale(ctx context.Context, state State) error
+
+	// SaveDesired creates or replaces the desired state of the twin
+	SaveDesired(ctx context.Context, ds DesiredState) error
+
+	// RetrieveDesired retrieves the desired state of the twin specified by id
+	RetrieveDesired(ctx context.Context, twinID string) (DesiredState, error)
+
+	// SaveAlarm creates or replaces the alarm
+	SaveAlarm(ctx context.Context, alarm Alarm) error
+
+	// RetrieveActiveAlarms retrieves the raised alarms of the twin specified
+	// by id
+	RetrieveActiveAlarms(ctx context.Context, twinID string) ([]Alarm, error)
+
+	// RetrieveAlarms retrieves the subset of alarms of the twin specified by
+	// id and matching the provided filter, newest first
+	RetrieveAlarms(ctx context.Context, offset, limit uint64, twinID string, filter AlarmFilter) (AlarmsPage, error)
 }
@@ -0,0 +1,130 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import "sync"
+
+// Types of the events delivered to the stream subscribers.
+const (
+	// StateCreated is sent when a new twin state is saved.
+	StateCreated = "state.created"
+
+	// StateUpdated is sent when the last twin state is updated.
+	StateUpdated = "state.updated"
+
+	// DefinitionUpdated is sent when a new twin definition is added.
+	DefinitionUpdated = "definition.updated"
+)
+
+// streamBuffer is the number of events buffered for each subscriber. Events
+// are dropped for subscribers which do not keep up.
+const streamBuffer = 64
+
+// StreamEvent is a change of the twin state or definition delivered to the
+// stream subscribers. Depending on the type, either State or Definition is
+// set.
+type StreamEvent struct {
+	Type       string
+	TwinID     string
+	State      *State
+	Definition *Definition
+}
+
+type subscription struct {
+	twins      map[string]bool
+	attributes map[string]bool
+	events     chan StreamEvent
+}
+
+// stream fans the twin changes made by this service instance out to the
+// subscribers. The changes made by the other instances are not delivered.
+type stream struct {
+	mu   sync.RWMutex
+	subs map[*subscription]struct{}
+}
+
+func newStream() *stream {
+	return &stream{
+		subs: make(map[*subscription]struct{}),
+	}
+}
+
+func (s *stream) subscribe(twinIDs, attributes []string) *subscription {
+	sub := &subscription{
+		twins:      make(map[string]bool),
+		attributes: make(map[string]bool),
+		events:     make(chan StreamEvent, streamBuffer),
+	}
+	for _, id := range twinIDs {
+		sub.twins[id] = true
+	}
+	for _, name := range attributes {
+		sub.attributes[name] = true
+	}
+
+	s.mu.Lock()
+	s.subs[sub] = struct{}{}
+	s.mu.Unlock()
+
+	return sub
+}
+
+func (s *stream) unsubscribe(sub *subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[sub]; ok {
+		delete(s.subs, sub)
+		close(sub.events)
+	}
+}
+
+// broadcast delivers the event to the subscribers of the twin and returns
+// the number of subscribers which missed the event because their buffer was
+// full.
+func (s *stream) broadcast(ev StreamEvent) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dropped := 0
+	for sub := range s.subs {
+		if !sub.twins[ev.TwinID] {
+			continue
+		}
+		e, ok := sub.filter(ev)
+		if !ok {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			dropped++
+		}
+	}
+
+	return dropped
+}
+
+// filter returns the copy of the event whose state payload contains only
+// the attributes the subscriber is interested in. States without any of
+// these attributes are skipped.
+func (sub *subscription) filter(ev StreamEvent) (StreamEvent, bool) {
+	if ev.State == nil {
+		return ev, true
+	}
+
+	st := *ev.State
+	st.Payload = make(map[string]interface{}, len(ev.State.Payload))
+	for name, val := range ev.State.Payload {
+		if len(sub.attributes) == 0 || sub.attributes[name] {
+			st.Payload[name] = val
+		}
+	}
+	if len(st.Payload) == 0 && len(sub.attributes) > 0 {
+		return StreamEvent{}, false
+	}
+	ev.State = &st
+
+	return ev, true
+}
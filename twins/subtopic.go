@@ -0,0 +1,91 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/absmach/supermq/pkg/errors"
+)
+
+// Attribute subtopics are split into levels by the separator and may
+// contain wildcards, following the NATS and MQTT conventions. Single-level
+// wildcards match exactly one level, while multi-level wildcards match one
+// or more trailing levels. The bare SubtopicWildcard matches any subtopic,
+// including the empty one.
+const (
+	SubtopicSeparator = "."
+	SingleLevelStar   = "*"
+	SingleLevelPlus   = "+"
+	MultiLevelHash    = "#"
+)
+
+var errInvalidSubtopic = errors.New("invalid attribute subtopic")
+
+// IsSubtopicPattern reports whether the subtopic contains wildcards.
+func IsSubtopicPattern(subtopic string) bool {
+	if subtopic == SubtopicWildcard {
+		return true
+	}
+	for _, level := range strings.Split(subtopic, SubtopicSeparator) {
+		switch level {
+		case SingleLevelStar, SingleLevelPlus, SubtopicWildcard, MultiLevelHash:
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchSubtopic reports whether the concrete subtopic matches the pattern.
+func MatchSubtopic(pattern, subtopic string) bool {
+	if pattern == subtopic || pattern == SubtopicWildcard {
+		return true
+	}
+	if subtopic == "" {
+		return false
+	}
+
+	pl := strings.Split(pattern, SubtopicSeparator)
+	sl := strings.Split(subtopic, SubtopicSeparator)
+	for i, level := range pl {
+		switch level {
+		case SubtopicWildcard, MultiLevelHash:
+			return i < len(sl)
+		case SingleLevelStar, SingleLevelPlus:
+			if i >= len(sl) {
+				return false
+			}
+		default:
+			if i >= len(sl) || level != sl[i] {
+				return false
+			}
+		}
+	}
+
+	return len(pl) == len(sl)
+}
+
+// validateSubtopic verifies that the multi-level wildcards of the attribute
+// subtopic are its last level and that no level is empty.
+func validateSubtopic(attr Attribute) error {
+	if attr.Subtopic == "" || attr.Subtopic == SubtopicWildcard {
+		return nil
+	}
+
+	levels := strings.Split(attr.Subtopic, SubtopicSeparator)
+	for i, level := range levels {
+		switch {
+		case level == "":
+			return errors.Wrap(errInvalidSubtopic, fmt.Errorf("subtopic %s of attribute %s has an empty level", attr.Subtopic, attr.Name))
+		case (level == SubtopicWildcard || level == MultiLevelHash) && i != len(levels)-1:
+			return errors.Wrap(errInvalidSubtopic, fmt.Errorf("multi-level wildcard of attribute %s is not the last level", attr.Name))
+		case len(level) > 1 && strings.ContainsAny(level, SingleLevelStar+SingleLevelPlus+SubtopicWildcard+MultiLevelHash):
+			return errors.Wrap(errInvalidSubtopic, fmt.Errorf("wildcard of attribute %s does not occupy the whole level", attr.Name))
+		}
+	}
+
+	return nil
+}
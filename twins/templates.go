@@ -0,0 +1,136 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/absmach/supermq/pkg/errors"
+)
+
+var (
+	errMissingBinding   = errors.New("template parameter is not bound")
+	errTemplateAndDef   = errors.New("twin cannot have both the template and the definition")
+	errInvalidParameter = errors.New("invalid template parameter")
+)
+
+// placeholder matches the template parameters of the form {{name}}.
+var placeholder = regexp.MustCompile(`{{\s*([^{}]*?)\s*}}`)
+
+// parameterName matches the valid template parameter names.
+var parameterName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Template is a reusable twin definition. Channels and subtopics of the
+// template attributes can contain parameters of the form {{name}}, which
+// are bound to actual values when the twin is created from the template.
+// Twins derived from the template can be updated to its latest revision.
+type Template struct {
+	Owner      string
+	Domain     string
+	ID         string
+	Name       string
+	Created    time.Time
+	Updated    time.Time
+	Revision   int
+	Definition Definition
+	Metadata   Metadata
+}
+
+// TemplatesPage contains page related metadata as well as a list of
+// templates that belong to this page.
+type TemplatesPage struct {
+	PageMetadata
+	Templates []Template
+}
+
+// Rollout describes the outcome of updating the twins derived from the
+// template to the template revision.
+type Rollout struct {
+	Template string
+	Revision int
+
+	// Updated contains IDs of the twins updated to the template revision.
+	Updated []string
+
+	// Skipped contains IDs of the twins the user is not allowed to edit.
+	Skipped []string
+}
+
+// TemplateRepository specifies a template persistence API.
+type TemplateRepository interface {
+	// Save persists the template.
+	Save(ctx context.Context, tmpl Template) (string, error)
+
+	// Update performs an update to the existing template.
+	Update(ctx context.Context, tmpl Template) error
+
+	// RetrieveByID retrieves the template having the provided identifier.
+	RetrieveByID(ctx context.Context, id string) (Template, error)
+
+	// RetrieveAll retrieves the subset of templates belonging to the
+	// specified domain.
+	RetrieveAll(ctx context.Context, domainID string, offset, limit uint64, name string) (TemplatesPage, error)
+
+	// Remove removes the template having the provided identifier.
+	Remove(ctx context.Context, id string) error
+}
+
+// Parameters returns the names of the template parameters.
+func (tmpl Template) Parameters() []string {
+	seen := map[string]bool{}
+	var params []string
+	for _, attr := range tmpl.Definition.Attributes {
+		for _, field := range []string{attr.Channel, attr.Subtopic} {
+			for _, m := range placeholder.FindAllStringSubmatch(field, -1) {
+				if !seen[m[1]] {
+					seen[m[1]] = true
+					params = append(params, m[1])
+				}
+			}
+		}
+	}
+	return params
+}
+
+// validateTemplate verifies the template parameters and its definition.
+func validateTemplate(tmpl Template) error {
+	for _, param := range tmpl.Parameters() {
+		if !parameterName.MatchString(param) {
+			return errors.Wrap(errInvalidParameter, errors.New(param))
+		}
+	}
+
+	return validateDefinition(tmpl.Definition)
+}
+
+// instantiate returns the template definition with the parameters replaced
+// by the bound values.
+func instantiate(tmpl Template, bindings map[string]string) (Definition, error) {
+	def := tmpl.Definition
+	def.Attributes = make([]Attribute, len(tmpl.Definition.Attributes))
+
+	var err error
+	bind := func(s string) string {
+		return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+			name := placeholder.FindStringSubmatch(m)[1]
+			val, ok := bindings[name]
+			if !ok && err == nil {
+				err = errors.Wrap(errMissingBinding, errors.New(name))
+			}
+			return val
+		})
+	}
+	for i, attr := range tmpl.Definition.Attributes {
+		attr.Channel = bind(attr.Channel)
+		attr.Subtopic = bind(attr.Subtopic)
+		def.Attributes[i] = attr
+	}
+	if err != nil {
+		return Definition{}, err
+	}
+
+	return def, nil
+}
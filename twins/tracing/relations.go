@@ -0,0 +1,69 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing
+
+import (
+	"context"
+
+	"github.com/absmach/supermq-contrib/twins"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	saveRelationOp             = "save_relation"
+	removeRelationOp           = "remove_relation"
+	removeRelationsByTwinOp    = "remove_relations_by_twin"
+	retrieveAllRelationsOp     = "retrieve_all_relations"
+	retrieveRelationsByTwinsOp = "retrieve_relations_by_twins"
+)
+
+var _ twins.RelationRepository = (*relationRepositoryMiddleware)(nil)
+
+type relationRepositoryMiddleware struct {
+	tracer trace.Tracer
+	repo   twins.RelationRepository
+}
+
+// RelationRepositoryMiddleware tracks request and their latency, and adds spans to context.
+func RelationRepositoryMiddleware(tracer trace.Tracer, repo twins.RelationRepository) twins.RelationRepository {
+	return relationRepositoryMiddleware{
+		tracer: tracer,
+		repo:   repo,
+	}
+}
+
+func (rrm relationRepositoryMiddleware) Save(ctx context.Context, rel twins.Relation) error {
+	ctx, span := createSpan(ctx, rrm.tracer, saveRelationOp)
+	defer span.End()
+
+	return rrm.repo.Save(ctx, rel)
+}
+
+func (rrm relationRepositoryMiddleware) Remove(ctx context.Context, source, relType, target string) error {
+	ctx, span := createSpan(ctx, rrm.tracer, removeRelationOp)
+	defer span.End()
+
+	return rrm.repo.Remove(ctx, source, relType, target)
+}
+
+func (rrm relationRepositoryMiddleware) RemoveByTwin(ctx context.Context, twinID string) error {
+	ctx, span := createSpan(ctx, rrm.tracer, removeRelationsByTwinOp)
+	defer span.End()
+
+	return rrm.repo.RemoveByTwin(ctx, twinID)
+}
+
+func (rrm relationRepositoryMiddleware) RetrieveAll(ctx context.Context, twinID string, offset, limit uint64, filter twins.RelationFilter) (twins.RelationsPage, error) {
+	ctx, span := createSpan(ctx, rrm.tracer, retrieveAllRelationsOp)
+	defer span.End()
+
+	return rrm.repo.RetrieveAll(ctx, twinID, offset, limit, filter)
+}
+
+func (rrm relationRepositoryMiddleware) RetrieveByTwins(ctx context.Context, twinIDs []string, filter twins.RelationFilter) ([]twins.Relation, error) {
+	ctx, span := createSpan(ctx, rrm.tracer, retrieveRelationsByTwinsOp)
+	defer span.End()
+
+	return rrm.repo.RetrieveByTwins(ctx, twinIDs, filter)
+}
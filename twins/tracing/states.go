@@ -5,6 +5,7 @@ package tracing
 
 import (
 	"context"
+	"time"
 
 	"github.com/absmach/supermq-contrib/twins"
 	"go.opentelemetry.io/otel/trace"
@@ -13,8 +14,18 @@ import (
 const (
 	saveStateOp         = "save_state"
 	updateStateOp       = "update_state"
+	updateStaleOp       = "update_stale_attributes"
 	countStatesOp       = "count_states"
 	retrieveAllStatesOp = "retrieve_all_states"
+	retrieveStateOp     = "retrieve_state_by_id"
+	retrieveStateAtOp   = "retrieve_state_at"
+	removeStatesOp      = "remove_states"
+	saveSimulatedOp     = "save_simulated_states"
+	compactStatesOp     = "compact_states"
+	saveDesiredStateOp  = "save_desired_state"
+	retrieveDesiredOp   = "retrieve_desired_state"
+	saveAlarmOp         = "save_alarm"
+	retrieveAlarmsOp    = "retrieve_alarms"
 )
 
 var _ twins.StateRepository = (*stateRepositoryMiddleware)(nil)
@@ -33,11 +44,11 @@ func StateRepositoryMiddleware(tracer trace.Tracer, repo twins.StateRepository)
 	}
 }
 
-func (trm stateRepositoryMiddleware) Save(ctx context.Context, st twins.State) error {
+func (trm stateRepositoryMiddleware) Save(ctx context.Context, sts ...twins.State) error {
 	ctx, span := createSpan(ctx, trm.tracer, saveStateOp)
 	defer span.End()
 
-	return trm.repo.Save(ctx, st)
+	return trm.repo.Save(ctx, sts...)
 }
 
 func (trm stateRepositoryMiddleware) Update(ctx context.Context, st twins.State) error {
@@ -47,6 +58,13 @@ func (trm stateRepositoryMiddleware) Update(ctx context.Context, st twins.State)
 	return trm.repo.Update(ctx, st)
 }
 
+func (trm stateRepositoryMiddleware) UpdateStale(ctx context.Context, st twins.State) error {
+	ctx, span := createSpan(ctx, trm.tracer, updateStaleOp)
+	defer span.End()
+
+	return trm.repo.UpdateStale(ctx, st)
+}
+
 func (trm stateRepositoryMiddleware) Count(ctx context.Context, tw twins.Twin) (int64, error) {
 	ctx, span := createSpan(ctx, trm.tracer, countStatesOp)
 	defer span.End()
@@ -54,11 +72,11 @@ func (trm stateRepositoryMiddleware) Count(ctx context.Context, tw twins.Twin) (
 	return trm.repo.Count(ctx, tw)
 }
 
-func (trm stateRepositoryMiddleware) RetrieveAll(ctx context.Context, offset, limit uint64, twinID string) (twins.StatesPage, error) {
+func (trm stateRepositoryMiddleware) RetrieveAll(ctx context.Context, offset, limit uint64, twinID string, filter twins.StateFilter) (twins.StatesPage, error) {
 	ctx, span := createSpan(ctx, trm.tracer, retrieveAllStatesOp)
 	defer span.End()
 
-	return trm.repo.RetrieveAll(ctx, offset, limit, twinID)
+	return trm.repo.RetrieveAll(ctx, offset, limit, twinID, filter)
 }
 
 func (trm stateRepositoryMiddleware) RetrieveLast(ctx context.Context, twinID string) (twins.State, error) {
@@ -67,3 +85,80 @@ func (trm stateRepositoryMiddleware) RetrieveLast(ctx context.Context, twinID st
 
 	return trm.repo.RetrieveLast(ctx, twinID)
 }
+
+func (trm stateRepositoryMiddleware) RetrieveByID(ctx context.Context, twinID string, id int64) (twins.State, error) {
+	ctx, span := createSpan(ctx, trm.tracer, retrieveStateOp)
+	defer span.End()
+
+	return trm.repo.RetrieveByID(ctx, twinID, id)
+}
+
+func (trm stateRepositoryMiddleware) RetrieveAt(ctx context.Context, twinID string, at time.Time) (twins.State, error) {
+	ctx, span := createSpan(ctx, trm.tracer, retrieveStateAtOp)
+	defer span.End()
+
+	return trm.repo.RetrieveAt(ctx, twinID, at)
+}
+
+func (trm stateRepositoryMiddleware) RemoveBefore(ctx context.Context, twinID string, before time.Time) (int64, error) {
+	ctx, span := createSpan(ctx, trm.tracer, removeStatesOp)
+	defer span.End()
+
+	return trm.repo.RemoveBefore(ctx, twinID, before)
+}
+
+func (trm stateRepositoryMiddleware) RemoveAfter(ctx context.Context, twinID string, id int64) (int64, error) {
+	ctx, span := createSpan(ctx, trm.tracer, removeStatesOp)
+	defer span.End()
+
+	return trm.repo.RemoveAfter(ctx, twinID, id)
+}
+
+func (trm stateRepositoryMiddleware) SaveSimulated(ctx context.Context, twinID string, sts ...twins.State) error {
+	ctx, span := createSpan(ctx, trm.tracer, saveSimulatedOp)
+	defer span.End()
+
+	return trm.repo.SaveSimulated(ctx, twinID, sts...)
+}
+
+func (trm stateRepositoryMiddleware) Compact(ctx context.Context, twinID string, before time.Time, bucket time.Duration) (int64, error) {
+	ctx, span := createSpan(ctx, trm.tracer, compactStatesOp)
+	defer span.End()
+
+	return trm.repo.Compact(ctx, twinID, before, bucket)
+}
+
+func (trm stateRepositoryMiddleware) SaveDesired(ctx context.Context, ds twins.DesiredState) error {
+	ctx, span := createSpan(ctx, trm.tracer, saveDesiredStateOp)
+	defer span.End()
+
+	return trm.repo.SaveDesired(ctx, ds)
+}
+
+func (trm stateRepositoryMiddleware) RetrieveDesired(ctx context.Context, twinID string) (twins.DesiredState, error) {
+	ctx, span := createSpan(ctx, trm.tracer, retrieveDesiredOp)
+	defer span.End()
+
+	return trm.repo.RetrieveDesired(ctx, twinID)
+}
+
+func (trm stateRepositoryMiddleware) SaveAlarm(ctx context.Context, alarm twins.Alarm) error {
+	ctx, span := createSpan(ctx, trm.tracer, saveAlarmOp)
+	defer span.End()
+
+	return trm.repo.SaveAlarm(ctx, alarm)
+}
+
+func (trm stateRepositoryMiddleware) RetrieveActiveAlarms(ctx context.Context, twinID string) ([]twins.Alarm, error) {
+	ctx, span := createSpan(ctx, trm.tracer, retrieveAlarmsOp)
+	defer span.End()
+
+	return trm.repo.RetrieveActiveAlarms(ctx, twinID)
+}
+
+func (trm stateRepositoryMiddleware) RetrieveAlarms(ctx context.Context, offset, limit uint64, twinID string, filter twins.AlarmFilter) (twins.AlarmsPage, error) {
+	ctx, span := createSpan(ctx, trm.tracer, retrieveAlarmsOp)
+	defer span.End()
+
+	return trm.repo.RetrieveAlarms(ctx, offset, limit, twinID, filter)
+}
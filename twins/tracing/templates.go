@@ -0,0 +1,69 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package tracing
+
+import (
+	"context"
+
+	"github.com/absmach/supermq-contrib/twins"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	saveTemplateOp         = "save_template"
+	updateTemplateOp       = "update_template"
+	retrieveTemplateByIDOp = "retrieve_template_by_id"
+	retrieveAllTemplatesOp = "retrieve_all_templates"
+	removeTemplateOp       = "remove_template"
+)
+
+var _ twins.TemplateRepository = (*templateRepositoryMiddleware)(nil)
+
+type templateRepositoryMiddleware struct {
+	tracer trace.Tracer
+	repo   twins.TemplateRepository
+}
+
+// TemplateRepositoryMiddleware tracks request and their latency, and adds spans to context.
+func TemplateRepositoryMiddleware(tracer trace.Tracer, repo twins.TemplateRepository) twins.TemplateRepository {
+	return templateRepositoryMiddleware{
+		tracer: tracer,
+		repo:   repo,
+	}
+}
+
+func (trm templateRepositoryMiddleware) Save(ctx context.Context, tmpl twins.Template) (string, error) {
+	ctx, span := createSpan(ctx, trm.tracer, saveTemplateOp)
+	defer span.End()
+
+	return trm.repo.Save(ctx, tmpl)
+}
+
+func (trm templateRepositoryMiddleware) Update(ctx context.Context, tmpl twins.Template) error {
+	ctx, span := createSpan(ctx, trm.tracer, updateTemplateOp)
+	defer span.End()
+
+	return trm.repo.Update(ctx, tmpl)
+}
+
+func (trm templateRepositoryMiddleware) RetrieveByID(ctx context.Context, id string) (twins.Template, error) {
+	ctx, span := createSpan(ctx, trm.tracer, retrieveTemplateByIDOp)
+	defer span.End()
+
+	return trm.repo.RetrieveByID(ctx, id)
+}
+
+func (trm templateRepositoryMiddleware) RetrieveAll(ctx context.Context, domainID string, offset, limit uint64, name string) (twins.TemplatesPage, error) {
+	ctx, span := createSpan(ctx, trm.tracer, retrieveAllTemplatesOp)
+	defer span.End()
+
+	return trm.repo.RetrieveAll(ctx, domainID, offset, limit, name)
+}
+
+func (trm templateRepositoryMiddleware) Remove(ctx context.Context, id string) error {
+	ctx, span := createSpan(ctx, trm.tracer, removeTemplateOp)
+	defer span.End()
+
+	return trm.repo.Remove(ctx, id)
+}
@@ -17,6 +17,11 @@ const (
 	retrieveTwinByIDOp         = "retrieve_twin_by_id"
 	retrieveAllTwinsOp         = "retrieve_all_twins"
 	retrieveTwinsByAttributeOp = "retrieve_twins_by_attribute"
+	retrieveTwinChildrenOp     = "retrieve_twin_children"
+	retrieveTwinsByTemplateOp  = "retrieve_twins_by_template"
+	retrieveRetainedTwinsOp    = "retrieve_retained_twins"
+	retrieveMonitoredTwinsOp   = "retrieve_monitored_twins"
+	updateTwinLivenessOp       = "update_twin_liveness"
 	removeTwinOp               = "remove_twin"
 )
 
@@ -56,11 +61,46 @@ func (trm twinRepositoryMiddleware) RetrieveByID(ctx context.Context, twinID str
 	return trm.repo.RetrieveByID(ctx, twinID)
 }
 
-func (trm twinRepositoryMiddleware) RetrieveAll(ctx context.Context, owner string, offset, limit uint64, name string, metadata twins.Metadata) (twins.Page, error) {
+func (trm twinRepositoryMiddleware) RetrieveAll(ctx context.Context, domainID string, ids []string, offset, limit uint64, name, parentID string, metadata twins.Metadata) (twins.Page, error) {
 	ctx, span := createSpan(ctx, trm.tracer, retrieveAllTwinsOp)
 	defer span.End()
 
-	return trm.repo.RetrieveAll(ctx, owner, offset, limit, name, metadata)
+	return trm.repo.RetrieveAll(ctx, domainID, ids, offset, limit, name, parentID, metadata)
+}
+
+func (trm twinRepositoryMiddleware) RetrieveChildren(ctx context.Context, parentIDs ...string) ([]twins.Twin, error) {
+	ctx, span := createSpan(ctx, trm.tracer, retrieveTwinChildrenOp)
+	defer span.End()
+
+	return trm.repo.RetrieveChildren(ctx, parentIDs...)
+}
+
+func (trm twinRepositoryMiddleware) RetrieveRetained(ctx context.Context, offset, limit uint64) (twins.Page, error) {
+	ctx, span := createSpan(ctx, trm.tracer, retrieveRetainedTwinsOp)
+	defer span.End()
+
+	return trm.repo.RetrieveRetained(ctx, offset, limit)
+}
+
+func (trm twinRepositoryMiddleware) RetrieveMonitored(ctx context.Context, offset, limit uint64) (twins.Page, error) {
+	ctx, span := createSpan(ctx, trm.tracer, retrieveMonitoredTwinsOp)
+	defer span.End()
+
+	return trm.repo.RetrieveMonitored(ctx, offset, limit)
+}
+
+func (trm twinRepositoryMiddleware) RetrieveByTemplate(ctx context.Context, templateID string) ([]twins.Twin, error) {
+	ctx, span := createSpan(ctx, trm.tracer, retrieveTwinsByTemplateOp)
+	defer span.End()
+
+	return trm.repo.RetrieveByTemplate(ctx, templateID)
+}
+
+func (trm twinRepositoryMiddleware) UpdateLiveness(ctx context.Context, twinID string, liveness twins.Liveness) error {
+	ctx, span := createSpan(ctx, trm.tracer, updateTwinLivenessOp)
+	defer span.End()
+
+	return trm.repo.UpdateLiveness(ctx, twinID, liveness)
 }
 
 func (trm twinRepositoryMiddleware) RetrieveByAttribute(ctx context.Context, channel, subtopic string) ([]string, error) {
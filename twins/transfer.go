@@ -0,0 +1,277 @@
+// Copyright (c) Abstract Machines
+// SPDX-License-Identifier: Apache-2.0
+
+package twins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/absmach/supermq/pkg/errors"
+	repoerr "github.com/absmach/supermq/pkg/errors/repository"
+	svcerr "github.com/absmach/supermq/pkg/errors/service"
+	"github.com/absmach/supermq/pkg/policies"
+)
+
+const exportPageSize = 100
+
+var (
+	errEmptyImport   = errors.New("no twins to import")
+	errMissingID     = errors.New("twin identifier is missing")
+	errDuplicateID   = errors.New("twin identifier is not unique")
+	errNoDefinitions = errors.New("twin has no definitions")
+	errUnknownParent = errors.New("parent twin does not exist")
+)
+
+// ImportOptions specifies how the exported twins are imported.
+type ImportOptions struct {
+	// RemapIDs assigns new identifiers to the imported twins and updates the
+	// parent references between them. Otherwise, the identifiers of the
+	// exported twins are preserved.
+	RemapIDs bool
+
+	// DryRun validates the twins without saving them.
+	DryRun bool
+}
+
+// Import describes the outcome of importing the exported twins.
+type Import struct {
+	DryRun bool
+
+	// IDs maps the identifiers of the exported twins to the identifiers of
+	// the imported ones.
+	IDs map[string]string
+}
+
+func (ts *twinservice) ExportTwins(ctx context.Context, token, domainID, ownerID string) ([]Twin, error) {
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Twins of other users are exported by domain administrators only.
+	if ownerID == "" {
+		ownerID = session.UserID
+	}
+	if ownerID != session.UserID {
+		if err := ts.checkDomain(ctx, session.UserID, domainID, policies.AdminPermission); err != nil {
+			return nil, err
+		}
+	}
+
+	// Twins are paged in the order of their creation, so that the twins
+	// created meanwhile do not shift the pages.
+	tws := []Twin{}
+	for offset := uint64(0); ; offset += exportPageSize {
+		page, err := ts.twins.RetrieveAll(ctx, domainID, nil, offset, exportPageSize, "", "", nil)
+		if err != nil {
+			return nil, errors.Wrap(svcerr.ErrViewEntity, err)
+		}
+		for _, tw := range page.Twins {
+			if tw.Owner == ownerID {
+				tws = append(tws, tw)
+			}
+		}
+		if len(page.Twins) == 0 || offset+uint64(len(page.Twins)) >= page.Total {
+			return tws, nil
+		}
+	}
+}
+
+func (ts *twinservice) ImportTwins(ctx context.Context, token, domainID string, tws []Twin, opts ImportOptions) (imp Import, err error) {
+	var b []byte
+	id := domainID
+	defer func() {
+		if opts.DryRun {
+			return
+		}
+		ts.publish(ctx, &id, &err, crudOp["importSucc"], crudOp["importFail"], &b)
+	}()
+
+	session, err := ts.identify(ctx, token, domainID)
+	if err != nil {
+		return Import{}, err
+	}
+
+	imp = Import{DryRun: opts.DryRun}
+	if imp.IDs, err = ts.importIDs(ctx, tws, opts.RemapIDs); err != nil {
+		return Import{}, err
+	}
+	if err := ts.validateImport(ctx, session.UserID, domainID, tws); err != nil {
+		return Import{}, err
+	}
+	if opts.DryRun {
+		return imp, nil
+	}
+
+	now := time.Now()
+	saved := make([]Twin, 0, len(tws))
+	// Either all the twins are imported or none of them.
+	defer func() {
+		if err != nil {
+			ts.rollbackImport(ctx, saved)
+		}
+	}()
+	for _, tw := range tws {
+		tw.ID = imp.IDs[tw.ID]
+		if parent, ok := imp.IDs[tw.Parent]; ok {
+			tw.Parent = parent
+		}
+		tw.Owner = session.UserID
+		tw.Domain = domainID
+		tw.Stale = nil
+		tw.Liveness = nil
+		tw.Revision = 0
+		tw.Updated = now
+		if tw.Created.IsZero() {
+			tw.Created = now
+		}
+		if tw.Template != "" {
+			linked, err := ts.importTemplate(ctx, domainID, tw.Template)
+			if err != nil {
+				return Import{}, err
+			}
+			// Template rollouts must not reach twins of other domains.
+			if !linked {
+				tw.Template, tw.TemplateRevision, tw.Bindings = "", 0, nil
+			}
+		}
+
+		if _, err := ts.twins.Save(ctx, tw); err != nil {
+			return Import{}, errors.Wrap(svcerr.ErrCreateEntity, err)
+		}
+		saved = append(saved, tw)
+	}
+	if err := ts.addTwinPolicies(ctx, saved...); err != nil {
+		return Import{}, err
+	}
+	for _, tw := range saved {
+		if err := ts.twinCache.Save(ctx, tw); err != nil {
+			return Import{}, err
+		}
+	}
+
+	b, err = json.Marshal(imp)
+
+	return imp, err
+}
+
+// importIDs maps the identifiers of the exported twins to the identifiers
+// they are imported with. Preserved identifiers must not be taken.
+func (ts *twinservice) importIDs(ctx context.Context, tws []Twin, remap bool) (map[string]string, error) {
+	if len(tws) == 0 {
+		return nil, errors.Wrap(svcerr.ErrMalformedEntity, errEmptyImport)
+	}
+
+	ids := make(map[string]string, len(tws))
+	for _, tw := range tws {
+		if tw.ID == "" {
+			return nil, errors.Wrap(svcerr.ErrMalformedEntity, errMissingID)
+		}
+		if _, ok := ids[tw.ID]; ok {
+			return nil, errors.Wrap(svcerr.ErrMalformedEntity, fmt.Errorf("%w: %s", errDuplicateID, tw.ID))
+		}
+
+		if !remap {
+			_, err := ts.twins.RetrieveByID(ctx, tw.ID)
+			switch {
+			case err == nil:
+				return nil, errors.Wrap(svcerr.ErrConflict, fmt.Errorf("twin %s already exists", tw.ID))
+			case !errors.Contains(err, repoerr.ErrNotFound):
+				return nil, errors.Wrap(svcerr.ErrViewEntity, err)
+			}
+			ids[tw.ID] = tw.ID
+			continue
+		}
+
+		id, err := ts.idProvider.ID()
+		if err != nil {
+			return nil, err
+		}
+		ids[tw.ID] = id
+	}
+
+	return ids, nil
+}
+
+// importTemplate reports whether the template the imported twin was created
+// from exists in the domain, in which case the twin stays linked to it.
+func (ts *twinservice) importTemplate(ctx context.Context, domainID, templateID string) (bool, error) {
+	tmpl, err := ts.templates.RetrieveByID(ctx, templateID)
+	switch {
+	case err == nil:
+		return tmpl.Domain == domainID, nil
+	case errors.Contains(err, repoerr.ErrNotFound):
+		return false, nil
+	default:
+		return false, errors.Wrap(svcerr.ErrViewEntity, err)
+	}
+}
+
+// rollbackImport removes the imported twins, together with their policies
+// and cached attributes. Failures are only logged since the import already
+// failed.
+func (ts *twinservice) rollbackImport(ctx context.Context, tws []Twin) {
+	for _, tw := range tws {
+		if err := ts.twins.Remove(ctx, tw.ID); err != nil {
+			ts.logger.Warn(fmt.Sprintf("Failed to remove imported twin %s: %s", tw.ID, err))
+		}
+		if err := ts.twinCache.Remove(ctx, tw.ID); err != nil {
+			ts.logger.Warn(fmt.Sprintf("Failed to remove imported twin %s from cache: %s", tw.ID, err))
+		}
+		if err := ts.policies.DeletePolicyFilter(ctx, policies.Policy{ObjectType: TwinType, Object: tw.ID}); err != nil {
+			ts.logger.Warn(fmt.Sprintf("Failed to remove policies of imported twin %s: %s", tw.ID, err))
+		}
+	}
+}
+
+// validateImport verifies the definitions of the exported twins and the
+// access of the user to their channels, and that their parents are either
+// imported as well or exist in the domain and can be edited by the user,
+// without forming cycles.
+func (ts *twinservice) validateImport(ctx context.Context, userID, domainID string, tws []Twin) error {
+	parents := make(map[string]string, len(tws))
+	for _, tw := range tws {
+		parents[tw.ID] = tw.Parent
+	}
+
+	for _, tw := range tws {
+		if len(tw.Definitions) == 0 {
+			return errors.Wrap(svcerr.ErrMalformedEntity, fmt.Errorf("%w: %s", errNoDefinitions, tw.ID))
+		}
+		for _, def := range tw.Definitions {
+			if err := validateDefinition(def); err != nil {
+				return errors.Wrap(svcerr.ErrMalformedEntity, errors.Wrap(err, fmt.Errorf("twin %s", tw.ID)))
+			}
+			if err := ts.checkChannels(ctx, userID, domainID, subscribePermission, def); err != nil {
+				return err
+			}
+		}
+
+		if _, ok := parents[tw.Parent]; tw.Parent == "" || ok {
+			continue
+		}
+		parent, err := ts.twins.RetrieveByID(ctx, tw.Parent)
+		if err != nil || parent.Domain != domainID {
+			return errors.Wrap(svcerr.ErrMalformedEntity, fmt.Errorf("%w: %s", errUnknownParent, tw.Parent))
+		}
+		if err := ts.checkTwin(ctx, userID, domainID, parent.ID, policies.EditPermission); err != nil {
+			return err
+		}
+	}
+
+	// Walk up the imported ancestors of every twin to prevent cycles.
+	for _, tw := range tws {
+		seen := map[string]bool{tw.ID: true}
+		for id := parents[tw.ID]; id != ""; id = parents[id] {
+			if seen[id] {
+				return errors.Wrap(svcerr.ErrMalformedEntity, errors.Wrap(errCycle, fmt.Errorf("twin %s", tw.ID)))
+			}
+			seen[id] = true
+		}
+	}
+
+	return nil
+}
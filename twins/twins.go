@@ -11,33 +11,114 @@ import (
 // Metadata stores arbitrary twin data.
 type Metadata map[string]interface{}
 
-// Attribute stores individual attribute data.
+// Attribute stores individual attribute data. Attributes either mirror the
+// values received on the channel and subtopic, or are computed from other
+// attributes of the same twin using the expression. The optional schema,
+// i.e. type, unit, bounds and allowed values, is used to validate the
+// received values. Values are read from SenML messages by default. If the
+// path is set, messages are plain JSON documents and the value, and
+// optionally the timestamp, are read at the given JSON pointers. Numeric
+// values are checked against the alarm rules when the states are saved.
+// Attributes with the update interval, expressed in nanoseconds, are marked
+// stale when their values are not received in time.
 type Attribute struct {
-	Name         string `json:"name"`
-	Channel      string `json:"channel"`
-	Subtopic     string `json:"subtopic"`
-	PersistState bool   `json:"persist_state"`
+	Name           string        `json:"name"`
+	Channel        string        `json:"channel"`
+	Subtopic       string        `json:"subtopic"`
+	Path           string        `json:"path,omitempty"`
+	TimePath       string        `json:"time_path,omitempty"`
+	Expression     string        `json:"expression,omitempty"`
+	Type           string        `json:"type,omitempty"`
+	Unit           string        `json:"unit,omitempty"`
+	Min            *float64      `json:"min,omitempty"`
+	Max            *float64      `json:"max,omitempty"`
+	Enum           []interface{} `json:"enum,omitempty"`
+	Alarms         []AlarmRule   `json:"alarms,omitempty"`
+	UpdateInterval int64         `json:"update_interval,omitempty"`
+	Persistence    *Persistence  `json:"persistence,omitempty"`
+	PersistState   bool          `json:"persist_state"`
 }
 
-// Definition stores entity's attributes.
+// Definition stores entity's attributes. The retention of the latest
+// definition applies to all the states of the twin.
 type Definition struct {
 	ID         int         `json:"id"`
 	Created    time.Time   `json:"created"`
 	Attributes []Attribute `json:"attributes"`
 	Delta      int64       `json:"delta"`
+	Retention  *Retention  `json:"retention,omitempty"`
 }
 
+// Roles which can be granted to users a twin is shared with. The roles are
+// stored as relations of the users to the twin in the SuperMQ policies.
+const (
+	// ViewerRole allows viewing the twin and its states.
+	ViewerRole = "viewer"
+
+	// EditorRole allows viewing and updating the twin and its desired state.
+	EditorRole = "editor"
+)
+
+// TwinType is the type of the twin objects in the SuperMQ policies.
+const TwinType = "twin"
+
 // Twin is a SupeMQ data system representation. Each twin is owned
-// by a single user, and is assigned with the unique identifier.
+// by a single user within a single domain, and is assigned with the unique
+// identifier. The owner can share the twin with other domain members through
+// the SuperMQ policies.
+// Twins can be composed into hierarchies, in which case Parent holds the
+// identifier of the parent twin. Twins created from a template keep the
+// template identifier and revision, and the parameter bindings used to
+// instantiate it. Revision is incremented on every change of the twin and
+// is used to detect concurrent modifications. Stale holds the attributes
+// marked stale by the last liveness check, and is not persisted. Liveness
+// holds the outcome of the liveness check of the twin until it has states,
+// and is changed by the liveness check only.
 type Twin struct {
-	Owner       string
-	ID          string
-	Name        string
-	Created     time.Time
-	Updated     time.Time
-	Revision    int
-	Definitions []Definition
-	Metadata    Metadata
+	Owner            string
+	Domain           string
+	Parent           string
+	Template         string
+	TemplateRevision int
+	Bindings         map[string]string
+	ID               string
+	Name             string
+	Created          time.Time
+	Updated          time.Time
+	Revision         int
+	Definitions      []Definition
+	Metadata         Metadata
+	Stale            []string  `bson:"-"`
+	Liveness         *Liveness `bson:"liveness,omitempty"`
+}
+
+// Liveness is the outcome of the liveness check of a twin without states.
+// Stale holds the attributes which were not received in time, measured from
+// the creation of the definition, and Checked is the time of the check.
+type Liveness struct {
+	Stale   []string  `json:"stale,omitempty" bson:"stale"`
+	Checked time.Time `json:"checked" bson:"checked"`
+}
+
+// AnyRevision is used instead of the expected twin revision to update or
+// remove the twin regardless of its current revision.
+const AnyRevision = -1
+
+// DefinitionAt returns the definition which was the newest one at the given
+// time. The second return value reports whether such definition exists.
+func (tw Twin) DefinitionAt(at time.Time) (Definition, bool) {
+	for i := len(tw.Definitions) - 1; i >= 0; i-- {
+		if !tw.Definitions[i].Created.After(at) {
+			return tw.Definitions[i], true
+		}
+	}
+	return Definition{}, false
+}
+
+// TwinNode represents a twin together with its descendants.
+type TwinNode struct {
+	Twin     Twin
+	Children []TwinNode
 }
 
 // PageMetadata contains page metadata that helps navigation.
@@ -59,7 +140,9 @@ type TwinRepository interface {
 	// Save persists the twin
 	Save(ctx context.Context, twin Twin) (string, error)
 
-	// Update performs an update to the existing twin. A non-nil error is
+	// Update performs an update to the existing twin. The update succeeds
+	// only if the stored twin revision precedes the revision of the provided
+	// twin, otherwise a conflict error is returned. A non-nil error is
 	// returned to indicate operation failure.
 	Update(ctx context.Context, twin Twin) error
 
@@ -70,8 +153,31 @@ type TwinRepository interface {
 	// the attribute with given channel and subtopic
 	RetrieveByAttribute(ctx context.Context, channel, subtopic string) ([]string, error)
 
-	// RetrieveAll retrieves the subset of twins owned by the specified user.
-	RetrieveAll(ctx context.Context, owner string, offset, limit uint64, name string, metadata Metadata) (Page, error)
+	// RetrieveAll retrieves the subset of twins belonging to the specified
+	// domain, ordered by their creation time and ID. If the twin IDs are not
+	// nil, only the twins having one of them are retrieved. If the parent ID
+	// is provided, only children of the parent twin are retrieved.
+	RetrieveAll(ctx context.Context, domainID string, ids []string, offset, limit uint64, name, parentID string, metadata Metadata) (Page, error)
+
+	// RetrieveChildren retrieves all the twins whose parent is one of the
+	// twins identified by the provided IDs.
+	RetrieveChildren(ctx context.Context, parentIDs ...string) ([]Twin, error)
+
+	// RetrieveRetained retrieves the subset of twins whose latest definition
+	// specifies the state retention, regardless of the domain.
+	RetrieveRetained(ctx context.Context, offset, limit uint64) (Page, error)
+
+	// RetrieveMonitored retrieves the subset of twins whose latest definition
+	// contains attributes with the update interval, regardless of the domain.
+	RetrieveMonitored(ctx context.Context, offset, limit uint64) (Page, error)
+
+	// RetrieveByTemplate retrieves all the twins created from the template
+	// identified by the provided ID.
+	RetrieveByTemplate(ctx context.Context, templateID string) ([]Twin, error)
+
+	// UpdateLiveness updates the liveness of the twin having the provided
+	// identifier, without changing its revision.
+	UpdateLiveness(ctx context.Context, twinID string, liveness Liveness) error
 
 	// Remove removes the twin having the provided identifier.
 	Remove(ctx context.Context, twinID string) error